@@ -32,7 +32,7 @@ import (
 
 // baseClient is a basic client for all other complex client.
 type baseClient struct {
-	urls      []string
+	urls      atomic.Value // Store as []string
 	clusterID uint64
 	// PD leader URL
 	leader atomic.Value // Store as string
@@ -49,9 +49,52 @@ type baseClient struct {
 
 	security SecurityOption
 
-	gRPCDialOptions []grpc.DialOption
-	timeout         time.Duration
-	maxRetryTimes   int
+	gRPCDialOptions   []grpc.DialOption
+	timeout           time.Duration
+	maxRetryTimes     int
+	enableRegionCache bool
+
+	// allowFollowerHandle is true if the region queries can be handled by
+	// the followers, whose responses are accepted if they are at most
+	// maxFollowerLag behind the newest region syncer index seen, and the
+	// followers heard from the leader within maxFollowerStaleness.
+	allowFollowerHandle  bool
+	maxFollowerLag       uint64
+	maxFollowerStaleness time.Duration
+
+	// tsoStreamCount is the number of the tso streams of each dc-location.
+	tsoStreamCount int
+
+	// enableForwarding is true if the requests can be forwarded to the leader
+	// by a follower when the leader is unreachable.
+	enableForwarding bool
+	// forwardingHost is the URL of the follower forwarding the requests, it
+	// is "" if the leader is reachable.
+	forwardingHost atomic.Value // Store as string
+}
+
+// ServiceDiscovery finds the members and the leader of the PD cluster. It can
+// be shared by the clients of other protocols, such as the HTTP client, and a
+// Client created by NewClient also implements it.
+type ServiceDiscovery interface {
+	// GetClusterID returns the ID of the cluster.
+	GetClusterID(ctx context.Context) uint64
+	// GetLeaderAddr returns the URL of the leader. It returns "" before the
+	// leader is found.
+	GetLeaderAddr() string
+	// GetURLs returns the URLs of all members.
+	GetURLs() []string
+	// ScheduleCheckLeader triggers the update of the leader, which is called
+	// when the leader seems to be unavailable.
+	ScheduleCheckLeader()
+	// Close stops the discovery.
+	Close()
+}
+
+// NewServiceDiscovery creates a ServiceDiscovery which keeps the leader
+// updated until it is closed.
+func NewServiceDiscovery(ctx context.Context, pdAddrs []string, security SecurityOption, opts ...ClientOption) (ServiceDiscovery, error) {
+	return newBaseClient(ctx, addrsToUrls(pdAddrs), security, opts...)
 }
 
 // SecurityOption records options about tls
@@ -85,18 +128,72 @@ func WithMaxErrorRetry(count int) ClientOption {
 	}
 }
 
+// WithRegionCache enables the RegionCache of the client.
+func WithRegionCache() ClientOption {
+	return func(c *baseClient) {
+		c.enableRegionCache = true
+	}
+}
+
+// WithAllowFollowerHandle allows the region queries to be handled by the
+// followers, which falls back to the leader if the followers fail or are
+// too stale.
+func WithAllowFollowerHandle() ClientOption {
+	return func(c *baseClient) {
+		c.allowFollowerHandle = true
+	}
+}
+
+// WithMaxFollowerLag configures how far the region syncer index of a follower
+// can be behind the newest one seen by the client.
+func WithMaxFollowerLag(lag uint64) ClientOption {
+	return func(c *baseClient) {
+		c.maxFollowerLag = lag
+	}
+}
+
+// WithMaxFollowerStaleness configures how long it can be since a follower
+// last heard from the leader for its responses to be accepted.
+func WithMaxFollowerStaleness(staleness time.Duration) ClientOption {
+	return func(c *baseClient) {
+		c.maxFollowerStaleness = staleness
+	}
+}
+
+// WithForwardingOption configures whether the requests are forwarded to the
+// leader by a follower when the leader is unreachable.
+func WithForwardingOption(enableForwarding bool) ClientOption {
+	return func(c *baseClient) {
+		c.enableForwarding = enableForwarding
+	}
+}
+
+// WithTSOStreamCount configures the number of the tso streams of each
+// dc-location. The tso requests are spread over the streams, which helps
+// when a single stream is saturated by the concurrent requests.
+func WithTSOStreamCount(count int) ClientOption {
+	return func(c *baseClient) {
+		if count > 0 {
+			c.tsoStreamCount = count
+		}
+	}
+}
+
 // newBaseClient returns a new baseClient.
 func newBaseClient(ctx context.Context, urls []string, security SecurityOption, opts ...ClientOption) (*baseClient, error) {
 	ctx1, cancel := context.WithCancel(ctx)
 	c := &baseClient{
-		urls:          urls,
-		checkLeaderCh: make(chan struct{}, 1),
-		ctx:           ctx1,
-		cancel:        cancel,
-		security:      security,
-		timeout:       defaultPDTimeout,
-		maxRetryTimes: maxInitClusterRetries,
+		checkLeaderCh:        make(chan struct{}, 1),
+		ctx:                  ctx1,
+		cancel:               cancel,
+		security:             security,
+		timeout:              defaultPDTimeout,
+		maxRetryTimes:        maxInitClusterRetries,
+		maxFollowerLag:       defaultMaxFollowerLag,
+		maxFollowerStaleness: defaultMaxFollowerStaleness,
+		tsoStreamCount:       1,
 	}
+	c.urls.Store(urls)
 	for _, opt := range opts {
 		opt(c)
 	}
@@ -113,6 +210,10 @@ func newBaseClient(ctx context.Context, urls []string, security SecurityOption,
 
 	c.wg.Add(1)
 	go c.leaderLoop()
+	if c.enableForwarding {
+		c.wg.Add(1)
+		go c.checkLeaderHealthLoop()
+	}
 
 	return c, nil
 }
@@ -152,6 +253,95 @@ func (c *baseClient) leaderLoop() {
 	}
 }
 
+// checkLeaderHealthLoop checks whether the leader is reachable, and switches
+// to a follower as the proxy if it is not.
+func (c *baseClient) checkLeaderHealthLoop() {
+	defer c.wg.Done()
+
+	ticker := time.NewTicker(healthCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			c.checkLeaderHealth()
+		case <-c.ctx.Done():
+			return
+		}
+	}
+}
+
+func (c *baseClient) checkLeaderHealth() {
+	leader := c.GetLeaderAddr()
+	if leader == "" {
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.ctx, updateLeaderTimeout)
+	_, err := c.getMembers(ctx, leader)
+	cancel()
+	oldHost := c.getForwardingHost()
+	if err == nil {
+		if oldHost != "" {
+			log.Info("[pd] the leader is reachable, stop forwarding requests", zap.String("leader", leader), zap.String("delegate", oldHost))
+			c.forwardingHost.Store("")
+			requestForwarded.WithLabelValues(leader, oldHost).Set(0)
+		}
+		return
+	}
+	host := c.chooseForwardingHost(leader)
+	if host == oldHost {
+		return
+	}
+	if oldHost != "" {
+		requestForwarded.WithLabelValues(leader, oldHost).Set(0)
+	}
+	if host != "" {
+		log.Warn("[pd] the leader is unreachable, forward requests by follower", zap.String("leader", leader), zap.String("delegate", host), errs.ZapError(err))
+		requestForwarded.WithLabelValues(leader, host).Set(1)
+	}
+	c.forwardingHost.Store(host)
+}
+
+// chooseForwardingHost returns a follower which can reach the leader.
+func (c *baseClient) chooseForwardingHost(leader string) string {
+	for _, u := range c.GetURLs() {
+		if u == leader {
+			continue
+		}
+		ctx, cancel := context.WithTimeout(c.ctx, updateLeaderTimeout)
+		members, err := c.getMembers(ctx, u)
+		cancel()
+		if err != nil {
+			continue
+		}
+		for _, url := range members.GetLeader().GetClientUrls() {
+			if url == leader {
+				return u
+			}
+		}
+	}
+	return ""
+}
+
+// getForwardingHost returns the URL of the follower forwarding the requests
+// to the leader, it returns "" if the requests are sent to the leader directly.
+func (c *baseClient) getForwardingHost() string {
+	host, _ := c.forwardingHost.Load().(string)
+	return host
+}
+
+// Close stops the leader loop and closes the gRPC connections.
+func (c *baseClient) Close() {
+	c.cancel()
+	c.wg.Wait()
+
+	c.clientConns.Range(func(_, cc interface{}) bool {
+		if err := cc.(*grpc.ClientConn).Close(); err != nil {
+			log.Error("[pd] failed to close gRPC clientConn", errs.ZapError(errs.ErrCloseGRPCConn, err))
+		}
+		return true
+	})
+}
+
 // ScheduleCheckLeader is used to check leader.
 func (c *baseClient) ScheduleCheckLeader() {
 	select {
@@ -175,10 +365,10 @@ func (c *baseClient) GetLeaderAddr() string {
 	return leaderAddr.(string)
 }
 
-// GetURLs returns the URLs.
-// For testing use. It should only be called when the client is closed.
+// GetURLs returns the URLs of the PD members.
 func (c *baseClient) GetURLs() []string {
-	return c.urls
+	urls, _ := c.urls.Load().([]string)
+	return urls
 }
 
 func (c *baseClient) GetAllocatorLeaderURLs() map[string]string {
@@ -229,7 +419,7 @@ func (c *baseClient) gcAllocatorLeaderAddr(curAllocatorMap map[string]*pdpb.Memb
 func (c *baseClient) initClusterID() error {
 	ctx, cancel := context.WithCancel(c.ctx)
 	defer cancel()
-	for _, u := range c.urls {
+	for _, u := range c.GetURLs() {
 		timeoutCtx, timeoutCancel := context.WithTimeout(ctx, c.timeout)
 		members, err := c.getMembers(timeoutCtx, u)
 		timeoutCancel()
@@ -244,7 +434,7 @@ func (c *baseClient) initClusterID() error {
 }
 
 func (c *baseClient) updateLeader() error {
-	for _, u := range c.urls {
+	for _, u := range c.GetURLs() {
 		ctx, cancel := context.WithTimeout(c.ctx, updateLeaderTimeout)
 		members, err := c.getMembers(ctx, u)
 		if err != nil {
@@ -265,7 +455,7 @@ func (c *baseClient) updateLeader() error {
 		c.updateURLs(members.GetMembers())
 		return c.switchLeader(members.GetLeader().GetClientUrls())
 	}
-	return errs.ErrClientGetLeader.FastGenByArgs(c.urls)
+	return errs.ErrClientGetLeader.FastGenByArgs(c.GetURLs())
 }
 
 func (c *baseClient) getMembers(ctx context.Context, url string) (*pdpb.GetMembersResponse, error) {
@@ -288,13 +478,14 @@ func (c *baseClient) updateURLs(members []*pdpb.Member) {
 	}
 
 	sort.Strings(urls)
+	oldURLs := c.GetURLs()
 	// the url list is same.
-	if reflect.DeepEqual(c.urls, urls) {
+	if reflect.DeepEqual(oldURLs, urls) {
 		return
 	}
 
-	log.Info("[pd] update member urls", zap.Strings("old-urls", c.urls), zap.Strings("new-urls", urls))
-	c.urls = urls
+	log.Info("[pd] update member urls", zap.Strings("old-urls", oldURLs), zap.Strings("new-urls", urls))
+	c.urls.Store(urls)
 }
 
 func (c *baseClient) switchLeader(addrs []string) error {
@@ -16,8 +16,10 @@ package pd
 import (
 	"context"
 	"fmt"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/opentracing/opentracing-go"
@@ -26,8 +28,11 @@ import (
 	"github.com/pingcap/kvproto/pkg/pdpb"
 	"github.com/pingcap/log"
 	"github.com/tikv/pd/pkg/errs"
+	"github.com/tikv/pd/pkg/grpcutil"
+	"github.com/tikv/pd/pkg/watch"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
 )
 
 // Region contains information of a region's meta and its peers.
@@ -81,6 +86,19 @@ type Client interface {
 	// The store may expire later. Caller is responsible for caching and taking care
 	// of store change.
 	GetAllStores(ctx context.Context, opts ...GetStoreOption) ([]*metapb.Store, error)
+	// WatchStores watches the changes of the stores. The first batch of the
+	// events adds all stores, and the later ones are the changes in order.
+	// The watch resumes from the last change after reconnecting, and the
+	// channel is closed when the context is done or the client is closed.
+	WatchStores(ctx context.Context) (<-chan []*watch.StoreEvent, error)
+	// WatchRegions watches the changes of the regions overlapping with the
+	// key ranges, or all regions if there is no range, from the start index.
+	// A response requiring a reset means the changes from the index are no
+	// longer kept, the caller should reload the regions, e.g. by ScanRegions,
+	// and apply the later changes by comparing the region epochs. The watch
+	// resumes from the last index after reconnecting, and the channel is
+	// closed when the context is done or the client is closed.
+	WatchRegions(ctx context.Context, startIndex uint64, ranges ...*watch.KeyRange) (<-chan *watch.WatchRegionsResponse, error)
 	// Update GC safe point. TiKV will check it and do GC themselves if necessary.
 	// If the given safePoint is less than the current one, it will not be updated.
 	// Returns the new safePoint after updating.
@@ -102,6 +120,13 @@ type Client interface {
 	SplitRegions(ctx context.Context, splitKeys [][]byte, opts ...RegionsOption) (*pdpb.SplitRegionsResponse, error)
 	// GetOperator gets the status of operator of the specified region.
 	GetOperator(ctx context.Context, regionID uint64) (*pdpb.GetOperatorResponse, error)
+	// GetRegionCache returns the region cache of the client, which is nil
+	// unless the client is created with WithRegionCache.
+	GetRegionCache() *RegionCache
+	// GetSlowTSORequests returns the slowest TSO requests in the recent
+	// minute, the slowest first. It tells whether the requests are slow in
+	// the client or in PD.
+	GetSlowTSORequests() []*TSOTrace
 	// Close closes the client.
 	Close()
 }
@@ -146,9 +171,17 @@ type tsoRequest struct {
 	physical   int64
 	logical    int64
 	dcLocation string
+
+	// batchSend and response are when the batch of the request is sent to
+	// PD and when the response is received, and start is the enqueue time.
+	batchSend time.Time
+	response  time.Time
 }
 
+// lastTSO is the largest timestamp received from the tso streams of a
+// dc-location.
 type lastTSO struct {
+	sync.Mutex
 	physical int64
 	logical  int64
 }
@@ -160,6 +193,16 @@ const (
 	tsLoopDCCheckInterval = time.Minute
 	maxMergeTSORequests   = 10000 // should be higher if client is sending requests in burst
 	maxInitClusterRetries = 100
+	// followerHandleTimeout is the timeout of a region query sent to a
+	// follower, which leaves the rest of the time to the leader.
+	followerHandleTimeout = time.Second
+	defaultMaxFollowerLag = 1024
+	// defaultMaxFollowerStaleness is twice the interval of the keepalives
+	// sent by the leader to the followers.
+	defaultMaxFollowerStaleness = 20 * time.Second
+	// healthCheckInterval is the interval to check whether the leader is
+	// reachable when forwarding is enabled.
+	healthCheckInterval = time.Second
 )
 
 var (
@@ -176,12 +219,23 @@ type client struct {
 	// tsoDispatcher is used to dispatch different TSO requests to
 	// the corresponding dc-location TSO channel.
 	tsoDispatcher sync.Map // Same as map[string]chan *tsoRequest
-	// dc-location -> deadline
-	tsDeadline sync.Map // Same as map[string]chan deadline
+	// TSO stream -> deadline
+	tsDeadline sync.Map // Same as map[tsoStreamKey]chan deadline
 	// dc-location -> *lastTSO
 	lastTSMap sync.Map // Same as map[string]*lastTSO
+	// dc-location -> the number of the tso streams which are up
+	tsoStreamsUp sync.Map // Same as map[string]*int32
 
 	checkTSDeadlineCh chan struct{}
+
+	regionCache *RegionCache
+
+	// followerIndex is used to pick the followers in turn.
+	followerIndex uint32
+	// regionSyncIndex is the newest region syncer index in the responses.
+	regionSyncIndex uint64
+
+	slowTSORequests slowTSORequests
 }
 
 // NewClient creates a PD client.
@@ -200,6 +254,9 @@ func NewClientWithContext(ctx context.Context, pdAddrs []string, security Securi
 		baseClient:        base,
 		checkTSDeadlineCh: make(chan struct{}),
 	}
+	if base.enableRegionCache {
+		c.regionCache = NewRegionCache(c)
+	}
 
 	c.wg.Add(2)
 	go c.tsLoop()
@@ -223,9 +280,11 @@ func (c *client) tsCancelLoop() {
 	ticker := time.NewTicker(tsLoopDCCheckInterval)
 	defer ticker.Stop()
 	for {
-		// Watch every dc-location's tsDeadlineCh
+		// Watch every tso stream's tsDeadlineCh
 		c.allocators.Range(func(dcLocation, _ interface{}) bool {
-			c.watchTSDeadline(tsCancelLoopCtx, dcLocation.(string))
+			for i := 0; i < c.tsoStreamCount; i++ {
+				c.watchTSDeadline(tsCancelLoopCtx, tsoStreamKey{dcLocation: dcLocation.(string), index: i})
+			}
 			return true
 		})
 		select {
@@ -239,10 +298,10 @@ func (c *client) tsCancelLoop() {
 	}
 }
 
-func (c *client) watchTSDeadline(ctx context.Context, dcLocation string) {
-	if _, exist := c.tsDeadline.Load(dcLocation); !exist {
+func (c *client) watchTSDeadline(ctx context.Context, key tsoStreamKey) {
+	if _, exist := c.tsDeadline.Load(key); !exist {
 		tsDeadlineCh := make(chan deadline, 1)
-		c.tsDeadline.Store(dcLocation, tsDeadlineCh)
+		c.tsDeadline.Store(key, tsDeadlineCh)
 		go func(dc string, tsDeadlineCh <-chan deadline) {
 			for {
 				select {
@@ -259,7 +318,7 @@ func (c *client) watchTSDeadline(ctx context.Context, dcLocation string) {
 					return
 				}
 			}
-		}(dcLocation, tsDeadlineCh)
+		}(key.dcLocation, tsDeadlineCh)
 	}
 }
 
@@ -313,95 +372,14 @@ func (c *client) tsLoop() {
 			if !c.checkTSODispatcher(dcLocation) {
 				c.createTSODispatcher(dcLocation)
 				dispatcher, _ := c.tsoDispatcher.Load(dcLocation)
-				// Each goroutine is responsible for handling the tso stream request for its dc-location.
+				// Each goroutine is responsible for handling a tso stream of its dc-location,
+				// and the requests are spread over the streams.
 				// The only case that will make the dispatcher goroutine exit
 				// is that the loopCtx is done, otherwise there is no circumstance
 				// this goroutine should exit.
-				go func(dc string, tsoDispatcher chan *tsoRequest) {
-					var (
-						err      error
-						ctx      context.Context
-						cancel   context.CancelFunc
-						stream   pdpb.PD_TsoClient
-						opts     []opentracing.StartSpanOption
-						requests = make([]*tsoRequest, maxMergeTSORequests+1)
-					)
-					defer func() {
-						if cancel != nil {
-							cancel()
-						}
-					}()
-					for {
-						// If the tso stream for the corresponding dc-location has not been created yet or needs to be re-created,
-						// we will try to create the stream first.
-						if stream == nil {
-							ctx, cancel = context.WithCancel(loopCtx)
-							done := make(chan struct{})
-							go c.checkStreamTimeout(ctx, cancel, done)
-							stream, err = pdpb.NewPDClient(c.getClientConnByDCLocation(dc)).Tso(ctx)
-							done <- struct{}{}
-							if err != nil {
-								select {
-								case <-loopCtx.Done():
-									return
-								default:
-								}
-								log.Error("[pd] create tso stream error", zap.String("dc-location", dc), errs.ZapError(errs.ErrClientCreateTSOStream, err))
-								c.ScheduleCheckLeader()
-								cancel()
-								c.revokeTSORequest(errors.WithStack(err), tsoDispatcher)
-								select {
-								case <-time.After(time.Second):
-								case <-loopCtx.Done():
-									return
-								}
-								continue
-							}
-						}
-						select {
-						case first := <-tsoDispatcher:
-							pendingPlus1 := len(tsoDispatcher) + 1
-							requests[0] = first
-							for i := 1; i < pendingPlus1; i++ {
-								requests[i] = <-tsoDispatcher
-							}
-							done := make(chan struct{})
-							dl := deadline{
-								timer:  time.After(c.timeout),
-								done:   done,
-								cancel: cancel,
-							}
-							tsDeadlineCh, ok := c.tsDeadline.Load(dc)
-							if !ok || tsDeadlineCh == nil {
-								c.scheduleCheckTSDeadline()
-								time.Sleep(time.Millisecond * 100)
-								tsDeadlineCh, _ = c.tsDeadline.Load(dc)
-							}
-							select {
-							case tsDeadlineCh.(chan deadline) <- dl:
-							case <-loopCtx.Done():
-								return
-							}
-							opts = extractSpanReference(requests[:pendingPlus1], opts[:0])
-							err = c.processTSORequests(stream, dc, requests[:pendingPlus1], opts)
-							close(done)
-						case <-loopCtx.Done():
-							return
-						}
-						// If error happens during tso stream handling, reset stream and run the next trial.
-						if err != nil {
-							select {
-							case <-loopCtx.Done():
-								return
-							default:
-							}
-							log.Error("[pd] getTS error", zap.String("dc-location", dc), errs.ZapError(errs.ErrClientGetTSO, err))
-							c.ScheduleCheckLeader()
-							cancel()
-							stream = nil
-						}
-					}
-				}(dcLocation, dispatcher.(chan *tsoRequest))
+				for i := 0; i < c.tsoStreamCount; i++ {
+					go c.handleDispatcher(loopCtx, tsoStreamKey{dcLocation: dcLocation, index: i}, dispatcher.(chan *tsoRequest))
+				}
 			}
 			return true
 		})
@@ -414,6 +392,125 @@ func (c *client) tsLoop() {
 	}
 }
 
+// tsoStreamKey identifies a tso stream of a dc-location.
+type tsoStreamKey struct {
+	dcLocation string
+	index      int
+}
+
+// handleDispatcher sends the requests from the dispatcher of the dc-location
+// with a tso stream. The requests are taken by the first idle stream if there
+// are several streams of the dc-location.
+func (c *client) handleDispatcher(loopCtx context.Context, key tsoStreamKey, tsoDispatcher chan *tsoRequest) {
+	dc := key.dcLocation
+	var (
+		err        error
+		ctx        context.Context
+		cancel     context.CancelFunc
+		stream     pdpb.PD_TsoClient
+		streamAddr string
+		opts       []opentracing.StartSpanOption
+		requests   = make([]*tsoRequest, maxMergeTSORequests+1)
+	)
+	streamsUp := c.getTSOStreamsUp(dc)
+	resetStream := func() {
+		cancel()
+		stream = nil
+		atomic.AddInt32(streamsUp, -1)
+	}
+	defer func() {
+		if stream != nil {
+			resetStream()
+		} else if cancel != nil {
+			cancel()
+		}
+	}()
+	for {
+		// Re-create the stream if the requests start or stop being forwarded.
+		if stream != nil && c.tsoStreamAddr(dc) != streamAddr {
+			resetStream()
+		}
+		// If the tso stream for the corresponding dc-location has not been created yet or needs to be re-created,
+		// we will try to create the stream first.
+		if stream == nil {
+			ctx, cancel = context.WithCancel(loopCtx)
+			done := make(chan struct{})
+			go c.checkStreamTimeout(ctx, cancel, done)
+			streamAddr = c.tsoStreamAddr(dc)
+			stream, err = c.createTSOStream(ctx, dc, streamAddr)
+			done <- struct{}{}
+			if err != nil {
+				select {
+				case <-loopCtx.Done():
+					return
+				default:
+				}
+				log.Error("[pd] create tso stream error", zap.String("dc-location", dc), errs.ZapError(errs.ErrClientCreateTSOStream, err))
+				c.ScheduleCheckLeader()
+				cancel()
+				// The pending requests are left to the other streams of the
+				// dc-location if any of them is up.
+				if atomic.LoadInt32(streamsUp) == 0 {
+					c.revokeTSORequest(errors.WithStack(err), tsoDispatcher)
+				}
+				select {
+				case <-time.After(time.Second):
+				case <-loopCtx.Done():
+					return
+				}
+				continue
+			}
+			atomic.AddInt32(streamsUp, 1)
+		}
+		select {
+		case first := <-tsoDispatcher:
+			pendingPlus1 := len(tsoDispatcher) + 1
+			requests[0] = first
+			for i := 1; i < pendingPlus1; i++ {
+				select {
+				case requests[i] = <-tsoDispatcher:
+				default:
+					// The rest requests are taken by the other streams.
+					pendingPlus1 = i
+				}
+			}
+			done := make(chan struct{})
+			dl := deadline{
+				timer:  time.After(c.timeout),
+				done:   done,
+				cancel: cancel,
+			}
+			tsDeadlineCh, ok := c.tsDeadline.Load(key)
+			if !ok || tsDeadlineCh == nil {
+				c.scheduleCheckTSDeadline()
+				time.Sleep(time.Millisecond * 100)
+				tsDeadlineCh, _ = c.tsDeadline.Load(key)
+			}
+			select {
+			case tsDeadlineCh.(chan deadline) <- dl:
+			case <-loopCtx.Done():
+				return
+			}
+			opts = extractSpanReference(requests[:pendingPlus1], opts[:0])
+			err = c.processTSORequests(stream, key, requests[:pendingPlus1], opts)
+			close(done)
+		case <-loopCtx.Done():
+			return
+		}
+		// If error happens during tso stream handling, reset stream and run the next trial.
+		if err != nil {
+			select {
+			case <-loopCtx.Done():
+				return
+			default:
+			}
+			log.Error("[pd] getTS error", zap.String("dc-location", dc), errs.ZapError(errs.ErrClientGetTSO, err))
+			c.ScheduleCheckLeader()
+			resetStream()
+		}
+	}
+}
+
 func (c *client) checkTSODispatcher(dcLocation string) bool {
 	tsoChan, ok := c.tsoDispatcher.Load(dcLocation)
 	if !ok || tsoChan == nil {
@@ -422,6 +519,13 @@ func (c *client) checkTSODispatcher(dcLocation string) bool {
 	return true
 }
 
+// getTSOStreamsUp returns the number of the tso streams of the dc-location
+// which are up.
+func (c *client) getTSOStreamsUp(dcLocation string) *int32 {
+	streamsUp, _ := c.tsoStreamsUp.LoadOrStore(dcLocation, new(int32))
+	return streamsUp.(*int32)
+}
+
 func (c *client) createTSODispatcher(dcLocation string) {
 	c.tsoDispatcher.Store(dcLocation, make(chan *tsoRequest, maxMergeTSORequests))
 }
@@ -435,7 +539,7 @@ func extractSpanReference(requests []*tsoRequest, opts []opentracing.StartSpanOp
 	return opts
 }
 
-func (c *client) processTSORequests(stream pdpb.PD_TsoClient, dcLocation string, requests []*tsoRequest, opts []opentracing.StartSpanOption) error {
+func (c *client) processTSORequests(stream pdpb.PD_TsoClient, key tsoStreamKey, requests []*tsoRequest, opts []opentracing.StartSpanOption) error {
 	if len(opts) > 0 {
 		span := opentracing.StartSpan("pdclient.processTSORequests", opts...)
 		defer span.Finish()
@@ -445,9 +549,15 @@ func (c *client) processTSORequests(stream pdpb.PD_TsoClient, dcLocation string,
 	req := &pdpb.TsoRequest{
 		Header:     c.requestHeader(),
 		Count:      uint32(count),
-		DcLocation: dcLocation,
+		DcLocation: key.dcLocation,
 	}
 
+	// Any timestamp received before the request is sent, by any stream of the
+	// dc-location, must be less than the ones of the request.
+	last := c.getLastTS(key.dcLocation)
+	last.Lock()
+	lastPhysical, lastLogical := last.physical, last.logical
+	last.Unlock()
 	if err := stream.Send(req); err != nil {
 		err = errors.WithStack(err)
 		c.finishTSORequest(requests, 0, 0, err)
@@ -459,8 +569,10 @@ func (c *client) processTSORequests(stream pdpb.PD_TsoClient, dcLocation string,
 		c.finishTSORequest(requests, 0, 0, err)
 		return err
 	}
-	requestDurationTSO.Observe(time.Since(start).Seconds())
+	now := time.Now()
+	requestDurationTSO.Observe(now.Sub(start).Seconds())
 	tsoBatchSize.Observe(float64(count))
+	c.traceTSORequests(key.dcLocation, requests, start, now)
 
 	if resp.GetCount() != uint32(len(requests)) {
 		err = errors.WithStack(errTSOLength)
@@ -471,31 +583,32 @@ func (c *client) processTSORequests(stream pdpb.PD_TsoClient, dcLocation string,
 	physical, logical := resp.GetTimestamp().GetPhysical(), resp.GetTimestamp().GetLogical()
 	// Server returns the highest ts.
 	logical -= int64(resp.GetCount() - 1)
-	c.compareAndSwapTS(dcLocation, physical, logical, int64(len(requests)))
+	c.compareAndSwapTS(key.dcLocation, lastPhysical, lastLogical, physical, logical, int64(len(requests)))
 	c.finishTSORequest(requests, physical, logical, nil)
 	return nil
 }
 
-func (c *client) compareAndSwapTS(dcLocation string, physical, logical, n int64) {
-	lastTSOInterface, ok := c.lastTSMap.Load(dcLocation)
-	if !ok {
-		var loaded bool
-		if lastTSOInterface, loaded = c.lastTSMap.LoadOrStore(dcLocation, &lastTSO{
-			physical: physical,
-			logical:  logical + n - 1,
-		}); !loaded {
-			return
-		}
-	}
-	lastTSOPointer := lastTSOInterface.(*lastTSO)
-	lastPhysical := lastTSOPointer.physical
-	lastLogical := lastTSOPointer.logical
+func (c *client) getLastTS(dcLocation string) *lastTSO {
+	last, _ := c.lastTSMap.LoadOrStore(dcLocation, &lastTSO{})
+	return last.(*lastTSO)
+}
+
+// compareAndSwapTS checks the timestamps returned by a tso stream are larger
+// than the last one received before the request was sent, by any stream of
+// the dc-location. The responses of different streams may arrive in any
+// order, so the last one is only replaced by a larger timestamp.
+func (c *client) compareAndSwapTS(dcLocation string, lastPhysical, lastLogical, physical, logical, n int64) {
 	if tsLessEqual(physical, logical, lastPhysical, lastLogical) {
 		panic(errors.Errorf("%s timestamp fallback, newly acquired ts (%d, %d) is less or equal to last one (%d, %d)",
 			dcLocation, physical, logical, lastPhysical, lastLogical))
 	}
-	lastTSOPointer.physical = physical
-	lastTSOPointer.logical = logical + n - 1
+	last := c.getLastTS(dcLocation)
+	last.Lock()
+	defer last.Unlock()
+	if tsLessEqual(last.physical, last.logical, physical, logical+n-1) {
+		last.physical = physical
+		last.logical = logical + n - 1
+	}
 }
 
 func tsLessEqual(physical, logical, thatPhysical, thatLogical int64) bool {
@@ -517,26 +630,23 @@ func (c *client) finishTSORequest(requests []*tsoRequest, physical, firstLogical
 
 func (c *client) revokeTSORequest(err error, tsoDispatcher chan *tsoRequest) {
 	for i := 0; i < len(tsoDispatcher); i++ {
-		req := <-tsoDispatcher
-		req.done <- err
+		select {
+		case req := <-tsoDispatcher:
+			req.done <- err
+		default:
+			// The rest requests are taken by the other streams.
+			return
+		}
 	}
 }
 
 func (c *client) Close() {
-	c.cancel()
-	c.wg.Wait()
+	c.baseClient.Close()
 
 	c.tsoDispatcher.Range(func(_, dispatcher interface{}) bool {
 		c.revokeTSORequest(errors.WithStack(errClosing), dispatcher.(chan *tsoRequest))
 		return true
 	})
-
-	c.clientConns.Range(func(_, cc interface{}) bool {
-		if err := cc.(*grpc.ClientConn).Close(); err != nil {
-			log.Error("[pd] failed to close gRPC clientConn", errs.ZapError(errs.ErrCloseGRPCConn, err))
-		}
-		return true
-	})
 }
 
 // leaderClient gets the client of current PD leader.
@@ -547,6 +657,131 @@ func (c *client) leaderClient() pdpb.PDClient {
 	return nil
 }
 
+// leaderClientWithForwarding gets the client to send requests to the leader.
+// If the leader is unreachable, it is the client of the follower forwarding
+// the requests, and the returned context carries the leader to forward to.
+func (c *client) leaderClientWithForwarding(ctx context.Context) (context.Context, pdpb.PDClient) {
+	if host := c.getForwardingHost(); host != "" {
+		if cc, ok := c.clientConns.Load(host); ok {
+			return grpcutil.BuildForwardContext(ctx, c.GetLeaderAddr()), pdpb.NewPDClient(cc.(*grpc.ClientConn))
+		}
+	}
+	return ctx, c.leaderClient()
+}
+
+// tsoStreamAddr returns the URL to create the tso stream of the dc-location
+// with. The Global TSO requests are sent to the forwarding follower if the
+// leader is unreachable.
+func (c *client) tsoStreamAddr(dcLocation string) string {
+	if dcLocation == globalDCLocation {
+		if host := c.getForwardingHost(); host != "" {
+			return host
+		}
+	}
+	addr, _ := c.getAllocatorLeaderAddrByDCLocation(dcLocation)
+	return addr
+}
+
+func (c *client) createTSOStream(ctx context.Context, dcLocation, addr string) (pdpb.PD_TsoClient, error) {
+	if dcLocation == globalDCLocation && addr == c.getForwardingHost() {
+		cc, err := c.getOrCreateGRPCConn(addr)
+		if err != nil {
+			return nil, err
+		}
+		return pdpb.NewPDClient(cc).Tso(grpcutil.BuildForwardContext(ctx, c.GetLeaderAddr()))
+	}
+	return pdpb.NewPDClient(c.getClientConnByDCLocation(dcLocation)).Tso(ctx)
+}
+
+// followerClient returns the client of a follower, the followers are picked
+// in turn.
+func (c *client) followerClient() pdpb.PDClient {
+	leader, urls := c.GetLeaderAddr(), c.GetURLs()
+	start := int(atomic.AddUint32(&c.followerIndex, 1))
+	for i := range urls {
+		url := urls[(start+i)%len(urls)]
+		if url == leader {
+			continue
+		}
+		cc, err := c.getOrCreateGRPCConn(url)
+		if err != nil {
+			log.Warn("[pd] failed to connect to follower", zap.String("url", url), errs.ZapError(err))
+			continue
+		}
+		return pdpb.NewPDClient(cc)
+	}
+	return nil
+}
+
+// regionRequest sends a region query with f. If follower handle is allowed,
+// the query is sent to a follower first, and then to the leader if the
+// follower fails or its response is too stale.
+func (c *client) regionRequest(ctx context.Context, f func(ctx context.Context, cli pdpb.PDClient, opts ...grpc.CallOption) error) error {
+	if !c.allowFollowerHandle {
+		return f(c.leaderClientWithForwarding(ctx))
+	}
+	ctx = metadata.AppendToOutgoingContext(ctx, grpcutil.AllowFollowerHandleKey, "true")
+	if cli := c.followerClient(); cli != nil {
+		var md metadata.MD
+		followerCtx, cancel := context.WithTimeout(ctx, followerHandleTimeout)
+		err := f(followerCtx, cli, grpc.Header(&md))
+		cancel()
+		if err == nil && c.acceptRegionSyncIndex(md, true) {
+			return nil
+		}
+		if err != nil {
+			log.Debug("[pd] failed to handle region request by follower", errs.ZapError(err))
+		}
+	}
+	var md metadata.MD
+	leaderCtx, leaderCli := c.leaderClientWithForwarding(ctx)
+	if err := f(leaderCtx, leaderCli, grpc.Header(&md)); err != nil {
+		return err
+	}
+	c.acceptRegionSyncIndex(md, false)
+	return nil
+}
+
+// acceptRegionSyncIndex records the region syncer index in the response
+// header. The response of a follower is rejected if it has not heard from the
+// leader within maxFollowerStaleness, which bounds the staleness even if the
+// client never reaches the leader, or if its index is more than
+// maxFollowerLag behind the newest one seen.
+func (c *client) acceptRegionSyncIndex(md metadata.MD, follower bool) bool {
+	if follower && !c.acceptFollowerStaleness(md) {
+		return false
+	}
+	values := md.Get(grpcutil.RegionSyncIndexKey)
+	if len(values) == 0 {
+		return !follower
+	}
+	index, err := strconv.ParseUint(values[0], 10, 64)
+	if err != nil {
+		return !follower
+	}
+	for {
+		newest := atomic.LoadUint64(&c.regionSyncIndex)
+		if follower && index+c.maxFollowerLag < newest {
+			return false
+		}
+		if index <= newest || atomic.CompareAndSwapUint64(&c.regionSyncIndex, newest, index) {
+			return true
+		}
+	}
+}
+
+func (c *client) acceptFollowerStaleness(md metadata.MD) bool {
+	values := md.Get(grpcutil.RegionSyncStalenessKey)
+	if len(values) == 0 {
+		return false
+	}
+	staleness, err := strconv.ParseInt(values[0], 10, 64)
+	if err != nil {
+		return false
+	}
+	return time.Duration(staleness)*time.Millisecond <= c.maxFollowerStaleness
+}
+
 var tsoReqPool = sync.Pool{
 	New: func() interface{} {
 		return &tsoRequest{
@@ -672,9 +907,13 @@ func (c *client) GetRegion(ctx context.Context, key []byte) (*Region, error) {
 	defer func() { cmdDurationGetRegion.Observe(time.Since(start).Seconds()) }()
 
 	ctx, cancel := context.WithTimeout(ctx, c.timeout)
-	resp, err := c.leaderClient().GetRegion(ctx, &pdpb.GetRegionRequest{
-		Header:    c.requestHeader(),
-		RegionKey: key,
+	var resp *pdpb.GetRegionResponse
+	err := c.regionRequest(ctx, func(ctx context.Context, cli pdpb.PDClient, opts ...grpc.CallOption) (err error) {
+		resp, err = cli.GetRegion(ctx, &pdpb.GetRegionRequest{
+			Header:    c.requestHeader(),
+			RegionKey: key,
+		}, opts...)
+		return
 	})
 	cancel()
 
@@ -733,9 +972,13 @@ func (c *client) GetPrevRegion(ctx context.Context, key []byte) (*Region, error)
 	defer func() { cmdDurationGetPrevRegion.Observe(time.Since(start).Seconds()) }()
 
 	ctx, cancel := context.WithTimeout(ctx, c.timeout)
-	resp, err := c.leaderClient().GetPrevRegion(ctx, &pdpb.GetRegionRequest{
-		Header:    c.requestHeader(),
-		RegionKey: key,
+	var resp *pdpb.GetRegionResponse
+	err := c.regionRequest(ctx, func(ctx context.Context, cli pdpb.PDClient, opts ...grpc.CallOption) (err error) {
+		resp, err = cli.GetPrevRegion(ctx, &pdpb.GetRegionRequest{
+			Header:    c.requestHeader(),
+			RegionKey: key,
+		}, opts...)
+		return
 	})
 	cancel()
 
@@ -756,9 +999,13 @@ func (c *client) GetRegionByID(ctx context.Context, regionID uint64) (*Region, e
 	defer func() { cmdDurationGetRegionByID.Observe(time.Since(start).Seconds()) }()
 
 	ctx, cancel := context.WithTimeout(ctx, c.timeout)
-	resp, err := c.leaderClient().GetRegionByID(ctx, &pdpb.GetRegionByIDRequest{
-		Header:   c.requestHeader(),
-		RegionId: regionID,
+	var resp *pdpb.GetRegionResponse
+	err := c.regionRequest(ctx, func(ctx context.Context, cli pdpb.PDClient, opts ...grpc.CallOption) (err error) {
+		resp, err = cli.GetRegionByID(ctx, &pdpb.GetRegionByIDRequest{
+			Header:   c.requestHeader(),
+			RegionId: regionID,
+		}, opts...)
+		return
 	})
 	cancel()
 
@@ -785,11 +1032,15 @@ func (c *client) ScanRegions(ctx context.Context, key, endKey []byte, limit int)
 		defer cancel()
 	}
 
-	resp, err := c.leaderClient().ScanRegions(scanCtx, &pdpb.ScanRegionsRequest{
-		Header:   c.requestHeader(),
-		StartKey: key,
-		EndKey:   endKey,
-		Limit:    int32(limit),
+	var resp *pdpb.ScanRegionsResponse
+	err := c.regionRequest(scanCtx, func(ctx context.Context, cli pdpb.PDClient, opts ...grpc.CallOption) (err error) {
+		resp, err = cli.ScanRegions(ctx, &pdpb.ScanRegionsRequest{
+			Header:   c.requestHeader(),
+			StartKey: key,
+			EndKey:   endKey,
+			Limit:    int32(limit),
+		}, opts...)
+		return
 	})
 	if err != nil {
 		cmdFailedDurationScanRegions.Observe(time.Since(start).Seconds())
@@ -989,6 +1240,10 @@ func (c *client) GetOperator(ctx context.Context, regionID uint64) (*pdpb.GetOpe
 	})
 }
 
+func (c *client) GetRegionCache() *RegionCache {
+	return c.regionCache
+}
+
 // SplitRegions split regions by given split keys
 func (c *client) SplitRegions(ctx context.Context, splitKeys [][]byte, opts ...RegionsOption) (*pdpb.SplitRegionsResponse, error) {
 	if span := opentracing.SpanFromContext(ctx); span != nil {
@@ -21,9 +21,11 @@ import (
 	. "github.com/pingcap/check"
 	"github.com/pingcap/errors"
 	"github.com/pingcap/kvproto/pkg/pdpb"
+	"github.com/tikv/pd/pkg/grpcutil"
 	"github.com/tikv/pd/pkg/testutil"
 	"go.uber.org/goleak"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
 )
 
 func Test(t *testing.T) {
@@ -61,11 +63,11 @@ func (s *testClientSuite) TestUpdateURLs(c *C) {
 	}
 	cli := &baseClient{}
 	cli.updateURLs(members[1:])
-	c.Assert(cli.urls, DeepEquals, getURLs([]*pdpb.Member{members[1], members[3], members[2]}))
+	c.Assert(cli.GetURLs(), DeepEquals, getURLs([]*pdpb.Member{members[1], members[3], members[2]}))
 	cli.updateURLs(members[1:])
-	c.Assert(cli.urls, DeepEquals, getURLs([]*pdpb.Member{members[1], members[3], members[2]}))
+	c.Assert(cli.GetURLs(), DeepEquals, getURLs([]*pdpb.Member{members[1], members[3], members[2]}))
 	cli.updateURLs(members)
-	c.Assert(cli.urls, DeepEquals, getURLs([]*pdpb.Member{members[1], members[3], members[2], members[0]}))
+	c.Assert(cli.GetURLs(), DeepEquals, getURLs([]*pdpb.Member{members[1], members[3], members[2], members[0]}))
 }
 
 var _ = Suite(&testClientCtxSuite{})
@@ -98,13 +100,13 @@ func (s *testClientDialOptionSuite) TestGRPCDialOption(c *C) {
 	defer cancel()
 	// nolint
 	cli := &baseClient{
-		urls:            []string{"localhost:8080"},
 		checkLeaderCh:   make(chan struct{}, 1),
 		ctx:             ctx,
 		cancel:          cancel,
 		security:        SecurityOption{},
 		gRPCDialOptions: []grpc.DialOption{grpc.WithBlock()},
 	}
+	cli.urls.Store([]string{"localhost:8080"})
 
 	err := cli.updateLeader()
 	c.Assert(err, NotNil)
@@ -140,3 +142,68 @@ func (s *testTsoRequestSuite) TestTsoRequestWait(c *C) {
 	_, _, err = req.Wait()
 	c.Assert(errors.Cause(err), Equals, context.Canceled)
 }
+
+func (s *testClientSuite) TestAcceptRegionSyncIndex(c *C) {
+	cli := &client{baseClient: &baseClient{maxFollowerLag: 10, maxFollowerStaleness: time.Second}}
+	md := func(index string) metadata.MD {
+		return metadata.Pairs(grpcutil.RegionSyncIndexKey, index, grpcutil.RegionSyncStalenessKey, "0")
+	}
+	c.Assert(cli.acceptRegionSyncIndex(md("100"), false), IsTrue)
+	c.Assert(cli.regionSyncIndex, Equals, uint64(100))
+	c.Assert(cli.acceptRegionSyncIndex(md("90"), true), IsTrue)
+	c.Assert(cli.acceptRegionSyncIndex(md("89"), true), IsFalse)
+	c.Assert(cli.acceptRegionSyncIndex(md("120"), true), IsTrue)
+	c.Assert(cli.regionSyncIndex, Equals, uint64(120))
+	c.Assert(cli.acceptRegionSyncIndex(md("105"), true), IsFalse)
+	// The response without the index is only accepted from the leader.
+	c.Assert(cli.acceptRegionSyncIndex(metadata.MD{}, true), IsFalse)
+	c.Assert(cli.acceptRegionSyncIndex(metadata.MD{}, false), IsTrue)
+
+	// The response of a follower which has not heard from the leader for too
+	// long is rejected, even if the client has not seen any newer index.
+	cli = &client{baseClient: &baseClient{maxFollowerLag: 10, maxFollowerStaleness: time.Second}}
+	stale := metadata.Pairs(grpcutil.RegionSyncIndexKey, "100", grpcutil.RegionSyncStalenessKey, "1001")
+	c.Assert(cli.acceptRegionSyncIndex(stale, true), IsFalse)
+	c.Assert(cli.regionSyncIndex, Equals, uint64(0))
+	fresh := metadata.Pairs(grpcutil.RegionSyncIndexKey, "100", grpcutil.RegionSyncStalenessKey, "1000")
+	c.Assert(cli.acceptRegionSyncIndex(fresh, true), IsTrue)
+	c.Assert(cli.acceptRegionSyncIndex(metadata.Pairs(grpcutil.RegionSyncIndexKey, "100"), true), IsFalse)
+}
+
+func (s *testClientSuite) TestCompareAndSwapTS(c *C) {
+	cli := &client{}
+	// The responses of two streams arrive out of order, both are larger than
+	// the last timestamp when their requests were sent.
+	cli.compareAndSwapTS("global", 0, 0, 1, 10, 5)
+	cli.compareAndSwapTS("global", 0, 0, 1, 5, 5)
+	last := cli.getLastTS("global")
+	c.Assert(last.physical, Equals, int64(1))
+	c.Assert(last.logical, Equals, int64(14))
+	// A stream falls back behind the timestamp received by another stream.
+	c.Assert(func() { cli.compareAndSwapTS("global", 1, 14, 1, 12, 1) }, PanicMatches, ".*timestamp fallback.*")
+	// The dc-locations are checked separately.
+	cli.compareAndSwapTS("dc-1", 0, 0, 1, 1, 1)
+}
+
+func (s *testClientSuite) TestSlowTSORequests(c *C) {
+	var slow slowTSORequests
+	now := time.Now()
+	// The expired request is dropped however slow it is.
+	slow.observe(&TSOTrace{
+		Enqueue:   now.Add(-time.Hour),
+		BatchSend: now.Add(-2 * slowTSORequestWindow),
+		Response:  now.Add(-2 * slowTSORequestWindow),
+	})
+	for i := 0; i < maxSlowTSORequests*2; i++ {
+		slow.observe(&TSOTrace{
+			Enqueue:   now.Add(-time.Duration(i) * time.Millisecond),
+			BatchSend: now,
+			Response:  now,
+		})
+	}
+	traces := slow.list()
+	c.Assert(traces, HasLen, maxSlowTSORequests)
+	for i, trace := range traces {
+		c.Assert(trace.Total(), Equals, time.Duration(maxSlowTSORequests*2-1-i)*time.Millisecond)
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"fmt"
+	"net/url"
+)
+
+// The paths of the PD REST API.
+const (
+	apiPrefix             = "/pd/api/v1"
+	storesURI             = apiPrefix + "/stores"
+	storeURI              = apiPrefix + "/store"
+	placementRulesURI     = apiPrefix + "/config/rules"
+	placementRuleURI      = apiPrefix + "/config/rule"
+	placementRuleGroupURI = apiPrefix + "/config/rule_group"
+	placementBundlesURI   = apiPrefix + "/config/placement-rule"
+	schedulersURI         = apiPrefix + "/schedulers"
+	operatorsURI          = apiPrefix + "/operators"
+	hotReadRegionsURI     = apiPrefix + "/hotspot/regions/read"
+	hotWriteRegionsURI    = apiPrefix + "/hotspot/regions/write"
+	configURI             = apiPrefix + "/config"
+	scheduleConfigURI     = apiPrefix + "/config/schedule"
+	replicateConfigURI    = apiPrefix + "/config/replicate"
+	gcSafePointURI        = apiPrefix + "/gc/safepoint"
+)
+
+func storeByID(storeID uint64) string {
+	return fmt.Sprintf("%s/%d", storeURI, storeID)
+}
+
+func storeLabels(storeID uint64) string {
+	return fmt.Sprintf("%s/%d/label", storeURI, storeID)
+}
+
+func placementRulesByGroup(group string) string {
+	return fmt.Sprintf("%s/group/%s", placementRulesURI, url.PathEscape(group))
+}
+
+func placementRuleByGroupAndID(group, id string) string {
+	return fmt.Sprintf("%s/%s/%s", placementRuleURI, url.PathEscape(group), url.PathEscape(id))
+}
+
+func placementRuleGroupByID(id string) string {
+	return fmt.Sprintf("%s/%s", placementRuleGroupURI, url.PathEscape(id))
+}
+
+func placementBundleByGroup(group string) string {
+	return fmt.Sprintf("%s/%s", placementBundlesURI, url.PathEscape(group))
+}
+
+func schedulerByName(name string) string {
+	return fmt.Sprintf("%s/%s", schedulersURI, url.PathEscape(name))
+}
+
+func operatorByRegion(regionID uint64) string {
+	return fmt.Sprintf("%s/%d", operatorsURI, regionID)
+}
+
+func gcSafePointByService(serviceID string) string {
+	return fmt.Sprintf("%s/%s", gcSafePointURI, url.PathEscape(serviceID))
+}
@@ -0,0 +1,523 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pingcap/log"
+	pd "github.com/tikv/pd/client"
+	"github.com/tikv/pd/pkg/errs"
+	"github.com/tikv/pd/pkg/grpcutil"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultTimeout       = 30 * time.Second
+	defaultMaxRetryTimes = 3
+	retryInterval        = 500 * time.Millisecond
+
+	// redirectFailedHeader is set by a follower if it fails to redirect the
+	// request to the leader, which is the same as
+	// serverapi.RedirectFailedHeader.
+	redirectFailedHeader = "PD-Redirect-Failed"
+)
+
+// Client is a client of the PD REST API. The requests are sent to the PD
+// leader and retried on other members if the leader is unavailable.
+type Client interface {
+	// GetStores gets all stores, including the tombstone ones.
+	GetStores(ctx context.Context) (*StoresInfo, error)
+	// GetStore gets a store by ID.
+	GetStore(ctx context.Context, storeID uint64) (*StoreInfo, error)
+	// DeleteStore makes a store offline.
+	DeleteStore(ctx context.Context, storeID uint64) error
+	// SetStoreLabels adds or updates the labels of a store.
+	SetStoreLabels(ctx context.Context, storeID uint64, labels map[string]string) error
+
+	// GetPlacementRules gets all placement rules.
+	GetPlacementRules(ctx context.Context) ([]*Rule, error)
+	// GetPlacementRulesByGroup gets the placement rules of a group.
+	GetPlacementRulesByGroup(ctx context.Context, group string) ([]*Rule, error)
+	// GetPlacementRule gets a placement rule.
+	GetPlacementRule(ctx context.Context, group, id string) (*Rule, error)
+	// SetPlacementRule adds or updates a placement rule.
+	SetPlacementRule(ctx context.Context, rule *Rule) error
+	// DeletePlacementRule deletes a placement rule.
+	DeletePlacementRule(ctx context.Context, group, id string) error
+	// GetPlacementRuleGroup gets the config of a rule group.
+	GetPlacementRuleGroup(ctx context.Context, id string) (*RuleGroup, error)
+	// SetPlacementRuleGroup adds or updates the config of a rule group.
+	SetPlacementRuleGroup(ctx context.Context, group *RuleGroup) error
+	// DeletePlacementRuleGroup deletes the config of a rule group.
+	DeletePlacementRuleGroup(ctx context.Context, id string) error
+	// GetPlacementRuleBundles gets the placement rules grouped by the groups.
+	GetPlacementRuleBundles(ctx context.Context) ([]*GroupBundle, error)
+	// GetPlacementRuleBundle gets the placement rules of a group.
+	GetPlacementRuleBundle(ctx context.Context, group string) (*GroupBundle, error)
+	// SetPlacementRuleBundles replaces all placement rules, or only the groups
+	// in the bundles if partial is true.
+	SetPlacementRuleBundles(ctx context.Context, bundles []*GroupBundle, partial bool) error
+
+	// GetSchedulers gets the names of the running schedulers.
+	GetSchedulers(ctx context.Context) ([]string, error)
+	// AddScheduler adds a scheduler. The args are the same as the body of the
+	// REST API except the name, such as {"store_id": 1} for the
+	// evict-leader-scheduler.
+	AddScheduler(ctx context.Context, name string, args map[string]interface{}) error
+	// RemoveScheduler removes a scheduler.
+	RemoveScheduler(ctx context.Context, name string) error
+	// PauseScheduler pauses a scheduler for a while. It resumes the scheduler
+	// if the duration is 0.
+	PauseScheduler(ctx context.Context, name string, duration time.Duration) error
+
+	// GetOperators gets the running operators.
+	GetOperators(ctx context.Context) ([]string, error)
+	// GetOperatorByRegion gets the operator of a region and its status.
+	GetOperatorByRegion(ctx context.Context, regionID uint64) (string, error)
+	// TransferLeader creates an operator to transfer the leader of a region.
+	TransferLeader(ctx context.Context, regionID, toStoreID uint64) error
+	// TransferRegion creates an operator to move the peers of a region to the
+	// stores.
+	TransferRegion(ctx context.Context, regionID uint64, toStoreIDs []uint64) error
+	// RemoveOperator cancels the operator of a region.
+	RemoveOperator(ctx context.Context, regionID uint64) error
+
+	// GetHotReadRegions gets the hot read peers of the stores.
+	GetHotReadRegions(ctx context.Context) (*StoreHotPeersInfos, error)
+	// GetHotWriteRegions gets the hot write peers of the stores.
+	GetHotWriteRegions(ctx context.Context) (*StoreHotPeersInfos, error)
+
+	// GetConfig gets the config of the cluster.
+	GetConfig(ctx context.Context) (map[string]interface{}, error)
+	// SetConfig updates the config items, such as {"schedule.leader-schedule-limit": 8}.
+	SetConfig(ctx context.Context, config map[string]interface{}) error
+	// GetScheduleConfig gets the schedule config.
+	GetScheduleConfig(ctx context.Context) (map[string]interface{}, error)
+	// GetReplicateConfig gets the replication config.
+	GetReplicateConfig(ctx context.Context) (map[string]interface{}, error)
+
+	// GetGCSafePoint gets the GC safe point and the GC safe points of services.
+	GetGCSafePoint(ctx context.Context) (*ListServiceGCSafePoint, error)
+	// DeleteGCSafePoint deletes the GC safe point of a service.
+	DeleteGCSafePoint(ctx context.Context, serviceID string) error
+
+	// Close closes the client.
+	Close()
+}
+
+// ClientOption configures the client.
+type ClientOption func(c *client)
+
+// WithHTTPClient configures the client with a custom http.Client. The TLS
+// config of the SecurityOption is not applied to it.
+func WithHTTPClient(cli *http.Client) ClientOption {
+	return func(c *client) {
+		c.cli = cli
+	}
+}
+
+// WithTimeout configures the timeout of each request.
+func WithTimeout(timeout time.Duration) ClientOption {
+	return func(c *client) {
+		c.timeout = timeout
+	}
+}
+
+// WithMaxRetryTimes configures the max times that a request is sent.
+func WithMaxRetryTimes(count int) ClientOption {
+	return func(c *client) {
+		c.maxRetryTimes = count
+	}
+}
+
+type client struct {
+	sd pd.ServiceDiscovery
+	// ownDiscovery is true if sd is created by the client and should be
+	// closed with the client.
+	ownDiscovery bool
+
+	cli           *http.Client
+	timeout       time.Duration
+	maxRetryTimes int
+}
+
+// NewClient creates a client of the PD REST API, which finds the PD leader
+// with the gRPC API of the members.
+func NewClient(ctx context.Context, pdAddrs []string, security pd.SecurityOption, opts ...ClientOption) (Client, error) {
+	sd, err := pd.NewServiceDiscovery(ctx, pdAddrs, security)
+	if err != nil {
+		return nil, err
+	}
+	c, err := newClient(sd, security, opts...)
+	if err != nil {
+		sd.Close()
+		return nil, err
+	}
+	c.ownDiscovery = true
+	return c, nil
+}
+
+// NewClientWithServiceDiscovery creates a client of the PD REST API which
+// shares the member discovery with others, such as a gRPC client created by
+// pd.NewClient. The ServiceDiscovery is not closed with the client.
+func NewClientWithServiceDiscovery(sd pd.ServiceDiscovery, security pd.SecurityOption, opts ...ClientOption) (Client, error) {
+	return newClient(sd, security, opts...)
+}
+
+func newClient(sd pd.ServiceDiscovery, security pd.SecurityOption, opts ...ClientOption) (*client, error) {
+	c := &client{
+		sd:            sd,
+		timeout:       defaultTimeout,
+		maxRetryTimes: defaultMaxRetryTimes,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.cli == nil {
+		tlsCfg, err := grpcutil.TLSConfig{
+			CAPath:   security.CAPath,
+			CertPath: security.CertPath,
+			KeyPath:  security.KeyPath,
+		}.ToTLSConfig()
+		if err != nil {
+			return nil, err
+		}
+		c.cli = &http.Client{Transport: &http.Transport{TLSClientConfig: tlsCfg}}
+	}
+	return c, nil
+}
+
+func (c *client) Close() {
+	c.cli.CloseIdleConnections()
+	if c.ownDiscovery {
+		c.sd.Close()
+	}
+}
+
+// targets returns the URLs to send a request to, with the leader first.
+func (c *client) targets() []string {
+	leader := c.sd.GetLeaderAddr()
+	urls := make([]string, 0, len(c.sd.GetURLs())+1)
+	if leader != "" {
+		urls = append(urls, leader)
+	}
+	for _, u := range c.sd.GetURLs() {
+		if u != leader {
+			urls = append(urls, u)
+		}
+	}
+	return urls
+}
+
+// request sends the request to the leader, and retries on the other members
+// if the leader is unavailable. The response is decoded into res if it is not
+// nil.
+func (c *client) request(ctx context.Context, method, uri string, body interface{}, res interface{}) error {
+	var data []byte
+	if body != nil {
+		var err error
+		if data, err = json.Marshal(body); err != nil {
+			return errs.ErrJSONMarshal.Wrap(err).FastGenWithCause()
+		}
+	}
+	var err error
+	for i := 0; i < c.maxRetryTimes; i++ {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				return err
+			case <-time.After(retryInterval):
+			}
+		}
+		for _, addr := range c.targets() {
+			var retryable bool
+			retryable, err = c.do(ctx, method, addr, uri, data, res)
+			if err == nil || !retryable || ctx.Err() != nil {
+				return err
+			}
+			log.Warn("[pd] failed to send http request, try another member",
+				zap.String("method", method), zap.String("addr", addr), zap.String("uri", uri), errs.ZapError(err))
+			c.sd.ScheduleCheckLeader()
+		}
+	}
+	return err
+}
+
+// isIdempotent checks if sending the request more than once has the same
+// effect as sending it once.
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// do sends the request to the address once. It returns whether the request
+// can be retried on other members if it fails. A request which may have been
+// handled is only retried if it is idempotent.
+func (c *client) do(ctx context.Context, method, addr, uri string, body []byte, res interface{}) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, method, addr+uri, bytes.NewReader(body))
+	if err != nil {
+		return false, errs.ErrClientHTTPRequest.Wrap(err).FastGenWithCause(method, uri)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := c.cli.Do(req)
+	if err != nil {
+		return isIdempotent(method), errs.ErrClientHTTPRequest.Wrap(err).FastGenWithCause(method, uri)
+	}
+	defer resp.Body.Close()
+	content, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return isIdempotent(method), errs.ErrClientHTTPRequest.Wrap(err).FastGenWithCause(method, uri)
+	}
+	if resp.StatusCode/100 != 2 {
+		// The member is not ready to serve or does not know the leader, so the
+		// request is not handled. If the member fails to redirect the request
+		// to the leader, it may have been handled by the leader.
+		retryable := resp.StatusCode == http.StatusServiceUnavailable ||
+			(resp.Header.Get(redirectFailedHeader) != "" && isIdempotent(method))
+		msg := strings.TrimSpace(string(content))
+		return retryable, errs.ErrClientHTTPResponse.FastGenByArgs(method, uri, resp.StatusCode, msg)
+	}
+	if res != nil {
+		if err := json.Unmarshal(content, res); err != nil {
+			return false, errs.ErrJSONUnmarshal.Wrap(err).FastGenWithCause()
+		}
+	}
+	return false, nil
+}
+
+func (c *client) GetStores(ctx context.Context) (*StoresInfo, error) {
+	var stores StoresInfo
+	if err := c.request(ctx, http.MethodGet, storesURI+"?state=0&state=1&state=2", nil, &stores); err != nil {
+		return nil, err
+	}
+	return &stores, nil
+}
+
+func (c *client) GetStore(ctx context.Context, storeID uint64) (*StoreInfo, error) {
+	var store StoreInfo
+	if err := c.request(ctx, http.MethodGet, storeByID(storeID), nil, &store); err != nil {
+		return nil, err
+	}
+	return &store, nil
+}
+
+func (c *client) DeleteStore(ctx context.Context, storeID uint64) error {
+	return c.request(ctx, http.MethodDelete, storeByID(storeID), nil, nil)
+}
+
+func (c *client) SetStoreLabels(ctx context.Context, storeID uint64, labels map[string]string) error {
+	return c.request(ctx, http.MethodPost, storeLabels(storeID), labels, nil)
+}
+
+func (c *client) GetPlacementRules(ctx context.Context) ([]*Rule, error) {
+	var rules []*Rule
+	if err := c.request(ctx, http.MethodGet, placementRulesURI, nil, &rules); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+func (c *client) GetPlacementRulesByGroup(ctx context.Context, group string) ([]*Rule, error) {
+	var rules []*Rule
+	if err := c.request(ctx, http.MethodGet, placementRulesByGroup(group), nil, &rules); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+func (c *client) GetPlacementRule(ctx context.Context, group, id string) (*Rule, error) {
+	var rule Rule
+	if err := c.request(ctx, http.MethodGet, placementRuleByGroupAndID(group, id), nil, &rule); err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+func (c *client) SetPlacementRule(ctx context.Context, rule *Rule) error {
+	return c.request(ctx, http.MethodPost, placementRuleURI, rule, nil)
+}
+
+func (c *client) DeletePlacementRule(ctx context.Context, group, id string) error {
+	return c.request(ctx, http.MethodDelete, placementRuleByGroupAndID(group, id), nil, nil)
+}
+
+func (c *client) GetPlacementRuleGroup(ctx context.Context, id string) (*RuleGroup, error) {
+	var group RuleGroup
+	if err := c.request(ctx, http.MethodGet, placementRuleGroupByID(id), nil, &group); err != nil {
+		return nil, err
+	}
+	return &group, nil
+}
+
+func (c *client) SetPlacementRuleGroup(ctx context.Context, group *RuleGroup) error {
+	return c.request(ctx, http.MethodPost, placementRuleGroupURI, group, nil)
+}
+
+func (c *client) DeletePlacementRuleGroup(ctx context.Context, id string) error {
+	return c.request(ctx, http.MethodDelete, placementRuleGroupByID(id), nil, nil)
+}
+
+func (c *client) GetPlacementRuleBundles(ctx context.Context) ([]*GroupBundle, error) {
+	var bundles []*GroupBundle
+	if err := c.request(ctx, http.MethodGet, placementBundlesURI, nil, &bundles); err != nil {
+		return nil, err
+	}
+	return bundles, nil
+}
+
+func (c *client) GetPlacementRuleBundle(ctx context.Context, group string) (*GroupBundle, error) {
+	var bundle GroupBundle
+	if err := c.request(ctx, http.MethodGet, placementBundleByGroup(group), nil, &bundle); err != nil {
+		return nil, err
+	}
+	return &bundle, nil
+}
+
+func (c *client) SetPlacementRuleBundles(ctx context.Context, bundles []*GroupBundle, partial bool) error {
+	uri := placementBundlesURI
+	if partial {
+		uri += "?partial=true"
+	}
+	return c.request(ctx, http.MethodPost, uri, bundles, nil)
+}
+
+func (c *client) GetSchedulers(ctx context.Context) ([]string, error) {
+	var names []string
+	if err := c.request(ctx, http.MethodGet, schedulersURI, nil, &names); err != nil {
+		return nil, err
+	}
+	return names, nil
+}
+
+func (c *client) AddScheduler(ctx context.Context, name string, args map[string]interface{}) error {
+	input := make(map[string]interface{}, len(args)+1)
+	for k, v := range args {
+		input[k] = v
+	}
+	input["name"] = name
+	return c.request(ctx, http.MethodPost, schedulersURI, input, nil)
+}
+
+func (c *client) RemoveScheduler(ctx context.Context, name string) error {
+	return c.request(ctx, http.MethodDelete, schedulerByName(name), nil, nil)
+}
+
+func (c *client) PauseScheduler(ctx context.Context, name string, duration time.Duration) error {
+	input := map[string]interface{}{"delay": int64(duration / time.Second)}
+	return c.request(ctx, http.MethodPost, schedulerByName(name), input, nil)
+}
+
+func (c *client) GetOperators(ctx context.Context) ([]string, error) {
+	var ops []string
+	if err := c.request(ctx, http.MethodGet, operatorsURI, nil, &ops); err != nil {
+		return nil, err
+	}
+	return ops, nil
+}
+
+func (c *client) GetOperatorByRegion(ctx context.Context, regionID uint64) (string, error) {
+	var op string
+	if err := c.request(ctx, http.MethodGet, operatorByRegion(regionID), nil, &op); err != nil {
+		return "", err
+	}
+	return op, nil
+}
+
+func (c *client) TransferLeader(ctx context.Context, regionID, toStoreID uint64) error {
+	input := map[string]interface{}{
+		"name":        "transfer-leader",
+		"region_id":   regionID,
+		"to_store_id": toStoreID,
+	}
+	return c.request(ctx, http.MethodPost, operatorsURI, input, nil)
+}
+
+func (c *client) TransferRegion(ctx context.Context, regionID uint64, toStoreIDs []uint64) error {
+	input := map[string]interface{}{
+		"name":         "transfer-region",
+		"region_id":    regionID,
+		"to_store_ids": toStoreIDs,
+	}
+	return c.request(ctx, http.MethodPost, operatorsURI, input, nil)
+}
+
+func (c *client) RemoveOperator(ctx context.Context, regionID uint64) error {
+	return c.request(ctx, http.MethodDelete, operatorByRegion(regionID), nil, nil)
+}
+
+func (c *client) GetHotReadRegions(ctx context.Context) (*StoreHotPeersInfos, error) {
+	var infos StoreHotPeersInfos
+	if err := c.request(ctx, http.MethodGet, hotReadRegionsURI, nil, &infos); err != nil {
+		return nil, err
+	}
+	return &infos, nil
+}
+
+func (c *client) GetHotWriteRegions(ctx context.Context) (*StoreHotPeersInfos, error) {
+	var infos StoreHotPeersInfos
+	if err := c.request(ctx, http.MethodGet, hotWriteRegionsURI, nil, &infos); err != nil {
+		return nil, err
+	}
+	return &infos, nil
+}
+
+func (c *client) getConfig(ctx context.Context, uri string) (map[string]interface{}, error) {
+	var config map[string]interface{}
+	if err := c.request(ctx, http.MethodGet, uri, nil, &config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+func (c *client) GetConfig(ctx context.Context) (map[string]interface{}, error) {
+	return c.getConfig(ctx, configURI)
+}
+
+func (c *client) SetConfig(ctx context.Context, config map[string]interface{}) error {
+	return c.request(ctx, http.MethodPost, configURI, config, nil)
+}
+
+func (c *client) GetScheduleConfig(ctx context.Context) (map[string]interface{}, error) {
+	return c.getConfig(ctx, scheduleConfigURI)
+}
+
+func (c *client) GetReplicateConfig(ctx context.Context) (map[string]interface{}, error) {
+	return c.getConfig(ctx, replicateConfigURI)
+}
+
+func (c *client) GetGCSafePoint(ctx context.Context) (*ListServiceGCSafePoint, error) {
+	var safePoints ListServiceGCSafePoint
+	if err := c.request(ctx, http.MethodGet, gcSafePointURI, nil, &safePoints); err != nil {
+		return nil, err
+	}
+	return &safePoints, nil
+}
+
+func (c *client) DeleteGCSafePoint(ctx context.Context, serviceID string) error {
+	return c.request(ctx, http.MethodDelete, gcSafePointByService(serviceID), nil, nil)
+}
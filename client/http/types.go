@@ -0,0 +1,169 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"time"
+
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/tikv/pd/pkg/typeutil"
+)
+
+// The types below mirror the JSON bodies of the PD REST API, so that the
+// client does not depend on the server packages.
+
+// MetaStore contains meta information about a store.
+type MetaStore struct {
+	*metapb.Store
+	StateName string `json:"state_name"`
+}
+
+// StoreStatus contains status about a store.
+type StoreStatus struct {
+	Capacity           typeutil.ByteSize  `json:"capacity"`
+	Available          typeutil.ByteSize  `json:"available"`
+	UsedSize           typeutil.ByteSize  `json:"used_size"`
+	LeaderCount        int                `json:"leader_count"`
+	LeaderWeight       float64            `json:"leader_weight"`
+	LeaderScore        float64            `json:"leader_score"`
+	LeaderSize         int64              `json:"leader_size"`
+	RegionCount        int                `json:"region_count"`
+	RegionWeight       float64            `json:"region_weight"`
+	RegionScore        float64            `json:"region_score"`
+	RegionSize         int64              `json:"region_size"`
+	SendingSnapCount   uint32             `json:"sending_snap_count,omitempty"`
+	ReceivingSnapCount uint32             `json:"receiving_snap_count,omitempty"`
+	ApplyingSnapCount  uint32             `json:"applying_snap_count,omitempty"`
+	IsBusy             bool               `json:"is_busy,omitempty"`
+	SlowScore          uint64             `json:"slow_score,omitempty"`
+	StartTS            *time.Time         `json:"start_ts,omitempty"`
+	LastHeartbeatTS    *time.Time         `json:"last_heartbeat_ts,omitempty"`
+	Uptime             *typeutil.Duration `json:"uptime,omitempty"`
+}
+
+// StoreInfo contains information about a store.
+type StoreInfo struct {
+	Store  *MetaStore   `json:"store"`
+	Status *StoreStatus `json:"status"`
+}
+
+// StoresInfo records stores' info.
+type StoresInfo struct {
+	Count  int          `json:"count"`
+	Stores []*StoreInfo `json:"stores"`
+}
+
+// PeerRoleType is the expected peer type of the placement rule.
+type PeerRoleType string
+
+// The peer roles of placement rules.
+const (
+	Voter    PeerRoleType = "voter"
+	Leader   PeerRoleType = "leader"
+	Follower PeerRoleType = "follower"
+	Learner  PeerRoleType = "learner"
+	Witness  PeerRoleType = "witness"
+)
+
+// LabelConstraintOp defines how a LabelConstraint matches a store.
+type LabelConstraintOp string
+
+// The ops of label constraints.
+const (
+	In        LabelConstraintOp = "in"
+	NotIn     LabelConstraintOp = "notIn"
+	Exists    LabelConstraintOp = "exists"
+	NotExists LabelConstraintOp = "notExists"
+)
+
+// LabelConstraint is used to filter store when trying to place peer of a region.
+type LabelConstraint struct {
+	Key    string            `json:"key,omitempty"`
+	Op     LabelConstraintOp `json:"op,omitempty"`
+	Values []string          `json:"values,omitempty"`
+}
+
+// Rule is the placement rule. The keys are in hex format.
+type Rule struct {
+	GroupID          string            `json:"group_id"`
+	ID               string            `json:"id"`
+	Index            int               `json:"index,omitempty"`
+	Override         bool              `json:"override,omitempty"`
+	StartKeyHex      string            `json:"start_key"`
+	EndKeyHex        string            `json:"end_key"`
+	Template         string            `json:"template,omitempty"`
+	Role             PeerRoleType      `json:"role"`
+	Count            int               `json:"count"`
+	LabelConstraints []LabelConstraint `json:"label_constraints,omitempty"`
+	LocationLabels   []string          `json:"location_labels,omitempty"`
+	IsolationLevel   string            `json:"isolation_level,omitempty"`
+}
+
+// RuleGroup is the config of a group of placement rules.
+type RuleGroup struct {
+	ID       string `json:"id,omitempty"`
+	Index    int    `json:"index,omitempty"`
+	Override bool   `json:"override,omitempty"`
+	Template string `json:"template,omitempty"`
+}
+
+// GroupBundle is a group of placement rules and the config of the group.
+type GroupBundle struct {
+	ID       string  `json:"group_id"`
+	Index    int     `json:"group_index"`
+	Override bool    `json:"group_override"`
+	Template string  `json:"group_template,omitempty"`
+	Rules    []*Rule `json:"rules"`
+}
+
+// HotPeerStat records the flow of a hot peer.
+type HotPeerStat struct {
+	StoreID        uint64    `json:"store_id"`
+	RegionID       uint64    `json:"region_id"`
+	HotDegree      int       `json:"hot_degree"`
+	AntiCount      int       `json:"anti_count"`
+	ByteRate       float64   `json:"flow_bytes"`
+	KeyRate        float64   `json:"flow_keys"`
+	LastUpdateTime time.Time `json:"last_update_time"`
+}
+
+// HotPeersStat records the hot peers of a store.
+type HotPeersStat struct {
+	TotalBytesRate float64       `json:"total_flow_bytes"`
+	TotalKeysRate  float64       `json:"total_flow_keys"`
+	Count          int           `json:"regions_count"`
+	Stats          []HotPeerStat `json:"statistics"`
+}
+
+// StoreHotPeersStat is the hot peers grouped by store.
+type StoreHotPeersStat map[uint64]*HotPeersStat
+
+// StoreHotPeersInfos is the hot peers of all stores.
+type StoreHotPeersInfos struct {
+	AsPeer   StoreHotPeersStat `json:"as_peer"`
+	AsLeader StoreHotPeersStat `json:"as_leader"`
+}
+
+// ServiceSafePoint is the GC safe point of a service.
+type ServiceSafePoint struct {
+	ServiceID string `json:"service_id"`
+	ExpiredAt int64  `json:"expired_at"`
+	SafePoint uint64 `json:"safe_point"`
+}
+
+// ListServiceGCSafePoint is the GC safe points of all services.
+type ListServiceGCSafePoint struct {
+	ServiceGCSafePoints []*ServiceSafePoint `json:"service_gc_safe_points"`
+	GCSafePoint         uint64              `json:"gc_safe_point"`
+}
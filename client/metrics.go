@@ -51,6 +51,22 @@ var (
 			Help:      "Bucketed histogram of the batch size of handled requests.",
 			Buckets:   prometheus.ExponentialBuckets(1, 2, 13),
 		})
+
+	regionCacheCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "pd_client",
+			Subsystem: "region_cache",
+			Name:      "operations_total",
+			Help:      "Counter of the region cache operations, the hit ratio is hit / (hit + miss).",
+		}, []string{"type"})
+
+	requestForwarded = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "pd_client",
+			Subsystem: "request",
+			Name:      "forwarded_status",
+			Help:      "The status to indicate if the requests to the leader are forwarded by the delegate.",
+		}, []string{"host", "delegate"})
 )
 
 var (
@@ -83,6 +99,14 @@ var (
 	cmdFailedDurationUpdateGCSafePoint        = cmdFailedDuration.WithLabelValues("update_gc_safe_point")
 	cmdFailedDurationUpdateServiceGCSafePoint = cmdFailedDuration.WithLabelValues("update_service_gc_safe_point")
 	requestDurationTSO                        = requestDuration.WithLabelValues("tso")
+	tsoDispatcherWaitDuration                 = requestDuration.WithLabelValues("tso_dispatcher_wait")
+)
+
+var (
+	regionCacheHit        = regionCacheCounter.WithLabelValues("hit")
+	regionCacheMiss       = regionCacheCounter.WithLabelValues("miss")
+	regionCacheInvalidate = regionCacheCounter.WithLabelValues("invalidate")
+	regionCacheStale      = regionCacheCounter.WithLabelValues("stale")
 )
 
 func init() {
@@ -90,4 +114,6 @@ func init() {
 	prometheus.MustRegister(cmdFailedDuration)
 	prometheus.MustRegister(requestDuration)
 	prometheus.MustRegister(tsoBatchSize)
+	prometheus.MustRegister(regionCacheCounter)
+	prometheus.MustRegister(requestForwarded)
 }
@@ -0,0 +1,255 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pd
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"strings"
+	"sync"
+
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/tikv/pd/pkg/btree"
+	"github.com/tikv/pd/pkg/errs"
+)
+
+const (
+	regionCacheBTreeDegree = 64
+	// regionCacheScanLimit is the number of regions loaded at a time when
+	// locating a range.
+	regionCacheScanLimit = 64
+)
+
+var _ btree.Item = &regionCacheItem{}
+
+type regionCacheItem struct {
+	region *Region
+}
+
+// Less returns true if the region start key is less than the other.
+func (r *regionCacheItem) Less(other btree.Item) bool {
+	return bytes.Compare(r.region.Meta.GetStartKey(), other.(*regionCacheItem).region.Meta.GetStartKey()) < 0
+}
+
+func (r *regionCacheItem) contains(key []byte) bool {
+	start, end := r.region.Meta.GetStartKey(), r.region.Meta.GetEndKey()
+	return bytes.Compare(key, start) >= 0 && (len(end) == 0 || bytes.Compare(key, end) < 0)
+}
+
+// RegionCache caches the regions loaded from PD by their key ranges. The
+// regions are loaded lazily when they are located for the first time, and a
+// cached region is replaced once a region with a newer epoch overlaps with it.
+// Callers should invalidate the region or update the cache with the latest
+// regions when a request meets an epoch mismatch.
+type RegionCache struct {
+	cli Client
+
+	mu      sync.RWMutex
+	tree    *btree.BTree
+	regions map[uint64]*Region // region ID -> region
+}
+
+// NewRegionCache creates a RegionCache which loads regions with the client.
+func NewRegionCache(cli Client) *RegionCache {
+	return &RegionCache{
+		cli:     cli,
+		tree:    btree.New(regionCacheBTreeDegree),
+		regions: make(map[uint64]*Region),
+	}
+}
+
+// LocateKey returns the region which contains the key.
+func (rc *RegionCache) LocateKey(ctx context.Context, key []byte) (*Region, error) {
+	if region := rc.searchKey(key); region != nil {
+		regionCacheHit.Inc()
+		return region, nil
+	}
+	regionCacheMiss.Inc()
+	region, err := rc.cli.GetRegion(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if region == nil || region.Meta == nil {
+		return nil, errs.ErrClientRegionNotFound.FastGenByArgs(hexKey(key))
+	}
+	rc.UpdateRegion(region)
+	return region, nil
+}
+
+// LocateRegionByID returns the region with the ID.
+func (rc *RegionCache) LocateRegionByID(ctx context.Context, regionID uint64) (*Region, error) {
+	rc.mu.RLock()
+	region, ok := rc.regions[regionID]
+	rc.mu.RUnlock()
+	if ok {
+		regionCacheHit.Inc()
+		return region, nil
+	}
+	regionCacheMiss.Inc()
+	region, err := rc.cli.GetRegionByID(ctx, regionID)
+	if err != nil {
+		return nil, err
+	}
+	if region == nil || region.Meta == nil {
+		return nil, nil
+	}
+	rc.UpdateRegion(region)
+	return region, nil
+}
+
+// LocateRange returns the regions which cover the range [startKey, endKey)
+// in order. An empty endKey means the end of the key space.
+func (rc *RegionCache) LocateRange(ctx context.Context, startKey, endKey []byte) ([]*Region, error) {
+	var regions []*Region
+	key := startKey
+	for {
+		region := rc.searchKey(key)
+		if region == nil {
+			regionCacheMiss.Inc()
+			loaded, err := rc.cli.ScanRegions(ctx, key, endKey, regionCacheScanLimit)
+			if err != nil {
+				return nil, err
+			}
+			rc.UpdateRegion(loaded...)
+			if region = rc.searchKey(key); region == nil {
+				return nil, errs.ErrClientRegionNotFound.FastGenByArgs(hexKey(key))
+			}
+		} else {
+			regionCacheHit.Inc()
+		}
+		regions = append(regions, region)
+		end := region.Meta.GetEndKey()
+		if len(end) == 0 || (len(endKey) > 0 && bytes.Compare(end, endKey) >= 0) {
+			return regions, nil
+		}
+		key = end
+	}
+}
+
+// UpdateRegion puts the regions into the cache, such as the latest regions
+// returned with an epoch mismatch error. The cached regions overlapping with
+// a region are removed, unless one of them has a newer epoch, in which case
+// the region is stale and ignored.
+func (rc *RegionCache) UpdateRegion(regions ...*Region) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	for _, region := range regions {
+		if region == nil || region.Meta == nil {
+			continue
+		}
+		overlaps := rc.getOverlaps(region)
+		if isStaleRegion(region, overlaps) {
+			regionCacheStale.Inc()
+			continue
+		}
+		for _, overlap := range overlaps {
+			rc.removeLocked(overlap)
+		}
+		if old, ok := rc.regions[region.Meta.GetId()]; ok {
+			rc.removeLocked(old)
+		}
+		rc.tree.ReplaceOrInsert(&regionCacheItem{region: region})
+		rc.regions[region.Meta.GetId()] = region
+	}
+}
+
+// InvalidateRegion removes the region from the cache, so that it is loaded
+// from PD again when it is located next time.
+func (rc *RegionCache) InvalidateRegion(regionID uint64) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if region, ok := rc.regions[regionID]; ok {
+		regionCacheInvalidate.Inc()
+		rc.removeLocked(region)
+	}
+}
+
+// Clear removes all regions from the cache.
+func (rc *RegionCache) Clear() {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.tree.Clear(false)
+	rc.regions = make(map[uint64]*Region)
+}
+
+// Len returns the number of the cached regions.
+func (rc *RegionCache) Len() int {
+	rc.mu.RLock()
+	defer rc.mu.RUnlock()
+	return len(rc.regions)
+}
+
+func (rc *RegionCache) searchKey(key []byte) *Region {
+	rc.mu.RLock()
+	defer rc.mu.RUnlock()
+	if item := rc.find(key); item != nil {
+		return item.region
+	}
+	return nil
+}
+
+// find returns the item which contains the key.
+func (rc *RegionCache) find(key []byte) *regionCacheItem {
+	var result *regionCacheItem
+	rc.tree.DescendLessOrEqual(&regionCacheItem{region: &Region{Meta: &metapb.Region{StartKey: key}}}, func(i btree.Item) bool {
+		result = i.(*regionCacheItem)
+		return false
+	})
+	if result == nil || !result.contains(key) {
+		return nil
+	}
+	return result
+}
+
+// getOverlaps returns the cached regions overlapping with the region.
+func (rc *RegionCache) getOverlaps(region *Region) []*Region {
+	start := &regionCacheItem{region: region}
+	if item := rc.find(region.Meta.GetStartKey()); item != nil {
+		start = item
+	}
+	var overlaps []*Region
+	rc.tree.AscendGreaterOrEqual(start, func(i btree.Item) bool {
+		over := i.(*regionCacheItem).region
+		if len(region.Meta.GetEndKey()) > 0 && bytes.Compare(region.Meta.GetEndKey(), over.Meta.GetStartKey()) <= 0 {
+			return false
+		}
+		overlaps = append(overlaps, over)
+		return true
+	})
+	return overlaps
+}
+
+func (rc *RegionCache) removeLocked(region *Region) {
+	rc.tree.Delete(&regionCacheItem{region: region})
+	delete(rc.regions, region.Meta.GetId())
+}
+
+// isStaleRegion returns true if any of the overlapping regions has a newer
+// epoch than the region.
+func isStaleRegion(region *Region, overlaps []*Region) bool {
+	epoch := region.Meta.GetRegionEpoch()
+	for _, overlap := range overlaps {
+		e := overlap.Meta.GetRegionEpoch()
+		if e.GetVersion() > epoch.GetVersion() ||
+			(overlap.Meta.GetId() == region.Meta.GetId() && e.GetConfVer() > epoch.GetConfVer()) {
+			return true
+		}
+	}
+	return false
+}
+
+func hexKey(key []byte) string {
+	return strings.ToUpper(hex.EncodeToString(key))
+}
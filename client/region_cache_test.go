@@ -0,0 +1,163 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pd
+
+import (
+	"bytes"
+	"context"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/kvproto/pkg/metapb"
+)
+
+var _ = Suite(&testRegionCacheSuite{})
+
+type testRegionCacheSuite struct{}
+
+// mockRegionClient serves the regions from a list sorted by the start key.
+type mockRegionClient struct {
+	Client
+	regions []*Region
+	loads   int
+}
+
+func (m *mockRegionClient) GetRegion(ctx context.Context, key []byte) (*Region, error) {
+	m.loads++
+	for _, r := range m.regions {
+		if bytes.Compare(key, r.Meta.GetStartKey()) >= 0 && (len(r.Meta.GetEndKey()) == 0 || bytes.Compare(key, r.Meta.GetEndKey()) < 0) {
+			return r, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *mockRegionClient) GetRegionByID(ctx context.Context, regionID uint64) (*Region, error) {
+	m.loads++
+	for _, r := range m.regions {
+		if r.Meta.GetId() == regionID {
+			return r, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *mockRegionClient) ScanRegions(ctx context.Context, key, endKey []byte, limit int) ([]*Region, error) {
+	m.loads++
+	var regions []*Region
+	for _, r := range m.regions {
+		if len(r.Meta.GetEndKey()) > 0 && bytes.Compare(r.Meta.GetEndKey(), key) <= 0 {
+			continue
+		}
+		if len(endKey) > 0 && bytes.Compare(r.Meta.GetStartKey(), endKey) >= 0 {
+			break
+		}
+		regions = append(regions, r)
+		if len(regions) >= limit {
+			break
+		}
+	}
+	return regions, nil
+}
+
+func newCacheTestRegion(id uint64, start, end string, version uint64) *Region {
+	return &Region{Meta: &metapb.Region{
+		Id:          id,
+		StartKey:    []byte(start),
+		EndKey:      []byte(end),
+		RegionEpoch: &metapb.RegionEpoch{Version: version, ConfVer: 1},
+	}}
+}
+
+func (s *testRegionCacheSuite) TestLocate(c *C) {
+	ctx := context.Background()
+	cli := &mockRegionClient{regions: []*Region{
+		newCacheTestRegion(1, "", "b", 1),
+		newCacheTestRegion(2, "b", "d", 1),
+		newCacheTestRegion(3, "d", "", 1),
+	}}
+	rc := NewRegionCache(cli)
+
+	// The region is loaded for the first time and cached later.
+	r, err := rc.LocateKey(ctx, []byte("c"))
+	c.Assert(err, IsNil)
+	c.Assert(r.Meta.GetId(), Equals, uint64(2))
+	r, err = rc.LocateKey(ctx, []byte("b"))
+	c.Assert(err, IsNil)
+	c.Assert(r.Meta.GetId(), Equals, uint64(2))
+	c.Assert(cli.loads, Equals, 1)
+	r, err = rc.LocateRegionByID(ctx, 2)
+	c.Assert(err, IsNil)
+	c.Assert(r.Meta.GetId(), Equals, uint64(2))
+	c.Assert(cli.loads, Equals, 1)
+
+	// The regions missing in the cache are scanned.
+	regions, err := rc.LocateRange(ctx, []byte("a"), []byte("e"))
+	c.Assert(err, IsNil)
+	c.Assert(regions, HasLen, 3)
+	for i, r := range regions {
+		c.Assert(r.Meta.GetId(), Equals, uint64(i+1))
+	}
+	c.Assert(cli.loads, Equals, 2)
+	c.Assert(rc.Len(), Equals, 3)
+	regions, err = rc.LocateRange(ctx, []byte("b"), []byte("d"))
+	c.Assert(err, IsNil)
+	c.Assert(regions, HasLen, 1)
+	regions, err = rc.LocateRange(ctx, []byte(""), []byte(""))
+	c.Assert(err, IsNil)
+	c.Assert(regions, HasLen, 3)
+	c.Assert(cli.loads, Equals, 2)
+
+	// The invalidated region is loaded again.
+	rc.InvalidateRegion(2)
+	c.Assert(rc.Len(), Equals, 2)
+	r, err = rc.LocateKey(ctx, []byte("c"))
+	c.Assert(err, IsNil)
+	c.Assert(r.Meta.GetId(), Equals, uint64(2))
+	c.Assert(cli.loads, Equals, 3)
+
+	// The key not covered by any region.
+	cli.regions = cli.regions[:1]
+	rc.Clear()
+	_, err = rc.LocateKey(ctx, []byte("c"))
+	c.Assert(err, NotNil)
+	_, err = rc.LocateRange(ctx, []byte("a"), []byte("c"))
+	c.Assert(err, NotNil)
+}
+
+func (s *testRegionCacheSuite) TestUpdateRegion(c *C) {
+	rc := NewRegionCache(&mockRegionClient{})
+	rc.UpdateRegion(
+		newCacheTestRegion(1, "", "b", 1),
+		newCacheTestRegion(2, "b", "d", 1),
+		newCacheTestRegion(3, "d", "", 1),
+	)
+	c.Assert(rc.Len(), Equals, 3)
+
+	// Region 2 is split into [b, c) and [c, d).
+	rc.UpdateRegion(newCacheTestRegion(4, "b", "c", 2), newCacheTestRegion(2, "c", "d", 2))
+	c.Assert(rc.Len(), Equals, 4)
+	c.Assert(rc.searchKey([]byte("b")).Meta.GetId(), Equals, uint64(4))
+	c.Assert(rc.searchKey([]byte("c")).Meta.GetId(), Equals, uint64(2))
+
+	// The stale region is ignored.
+	rc.UpdateRegion(newCacheTestRegion(2, "b", "d", 1))
+	c.Assert(rc.Len(), Equals, 4)
+	c.Assert(rc.searchKey([]byte("b")).Meta.GetId(), Equals, uint64(4))
+
+	// Region 2 and 3 are merged.
+	rc.UpdateRegion(newCacheTestRegion(3, "c", "", 3))
+	c.Assert(rc.Len(), Equals, 3)
+	c.Assert(rc.searchKey([]byte("c")).Meta.GetId(), Equals, uint64(3))
+	c.Assert(rc.searchKey([]byte("e")).Meta.GetId(), Equals, uint64(3))
+}
@@ -0,0 +1,128 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pd
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// maxSlowTSORequests is the max number of the slow tso requests kept.
+	maxSlowTSORequests = 16
+	// slowTSORequestWindow is how long a slow tso request is kept.
+	slowTSORequestWindow = time.Minute
+)
+
+// TSOTrace is the timing of a tso request, which tells whether the time is
+// spent in the client or in PD.
+type TSOTrace struct {
+	DCLocation string `json:"dc_location"`
+	// Enqueue is when the request is sent to the dispatcher.
+	Enqueue time.Time `json:"enqueue"`
+	// BatchSend is when the batch of the request is sent to PD.
+	BatchSend time.Time `json:"batch_send"`
+	// Response is when the response of the batch is received.
+	Response  time.Time `json:"response"`
+	BatchSize int       `json:"batch_size"`
+}
+
+// DispatcherWait returns the time the request waits for being sent.
+func (t *TSOTrace) DispatcherWait() time.Duration {
+	return t.BatchSend.Sub(t.Enqueue)
+}
+
+// RPC returns the time PD takes to handle the batch of the request.
+func (t *TSOTrace) RPC() time.Duration {
+	return t.Response.Sub(t.BatchSend)
+}
+
+// Total returns the time from enqueue to response.
+func (t *TSOTrace) Total() time.Duration {
+	return t.Response.Sub(t.Enqueue)
+}
+
+// slowTSORequests keeps the slowest tso requests in the recent window.
+type slowTSORequests struct {
+	mu     sync.Mutex
+	traces []*TSOTrace
+}
+
+// observe keeps the trace if it is one of the slowest recent requests.
+func (s *slowTSORequests) observe(trace *TSOTrace) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expireLocked(time.Now())
+	if len(s.traces) < maxSlowTSORequests {
+		s.traces = append(s.traces, trace)
+		return
+	}
+	fastest := 0
+	for i, t := range s.traces {
+		if t.Total() < s.traces[fastest].Total() {
+			fastest = i
+		}
+	}
+	if trace.Total() > s.traces[fastest].Total() {
+		s.traces[fastest] = trace
+	}
+}
+
+// list returns the slowest recent requests, the slowest first.
+func (s *slowTSORequests) list() []*TSOTrace {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expireLocked(time.Now())
+	traces := make([]*TSOTrace, len(s.traces))
+	copy(traces, s.traces)
+	sort.Slice(traces, func(i, j int) bool { return traces[i].Total() > traces[j].Total() })
+	return traces
+}
+
+func (s *slowTSORequests) expireLocked(now time.Time) {
+	traces := s.traces[:0]
+	for _, t := range s.traces {
+		if now.Sub(t.Response) < slowTSORequestWindow {
+			traces = append(traces, t)
+		}
+	}
+	s.traces = traces
+}
+
+// traceTSORequests records the timing of the requests of a batch received
+// successfully.
+func (c *client) traceTSORequests(dcLocation string, requests []*tsoRequest, batchSend, response time.Time) {
+	oldest := requests[0]
+	for _, req := range requests {
+		req.batchSend, req.response = batchSend, response
+		tsoDispatcherWaitDuration.Observe(batchSend.Sub(req.start).Seconds())
+		if req.start.Before(oldest.start) {
+			oldest = req
+		}
+	}
+	// The request waiting the longest is the slowest one of the batch.
+	c.slowTSORequests.observe(&TSOTrace{
+		DCLocation: dcLocation,
+		Enqueue:    oldest.start,
+		BatchSend:  oldest.batchSend,
+		Response:   oldest.response,
+		BatchSize:  len(requests),
+	})
+}
+
+// GetSlowTSORequests returns the slowest tso requests in the recent minute.
+func (c *client) GetSlowTSORequests() []*TSOTrace {
+	return c.slowTSORequests.list()
+}
@@ -0,0 +1,224 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pd
+
+import (
+	"context"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/pingcap/errors"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/log"
+	"github.com/tikv/pd/pkg/errs"
+	"github.com/tikv/pd/pkg/watch"
+	"google.golang.org/grpc"
+)
+
+const (
+	// watchRetryInterval is the interval to reconnect a broken watch stream.
+	watchRetryInterval = time.Second
+	watchChannelSize   = 16
+)
+
+// WatchStores watches the changes of the stores.
+func (c *client) WatchStores(ctx context.Context) (<-chan []*watch.StoreEvent, error) {
+	ctx, cancel := c.watchContext(ctx)
+	stream, err := c.watchStores(ctx, 0)
+	if err != nil {
+		cancel()
+		c.ScheduleCheckLeader()
+		return nil, err
+	}
+	ch := make(chan []*watch.StoreEvent, watchChannelSize)
+	c.wg.Add(1)
+	go c.storeWatchLoop(ctx, cancel, stream, ch)
+	return ch, nil
+}
+
+// watchContext returns a context which is also canceled when the client is
+// closed, so that the watch streams are not left blocked.
+func (c *client) watchContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		select {
+		case <-c.ctx.Done():
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, cancel
+}
+
+func (c *client) watchStores(ctx context.Context, revision uint64) (watch.WatchStoresClient, error) {
+	cc, ok := c.clientConns.Load(c.GetLeaderAddr())
+	if !ok {
+		return nil, errors.Errorf("[pd] no connection to the leader %s", c.GetLeaderAddr())
+	}
+	stream, err := watch.WatchStores(ctx, cc.(*grpc.ClientConn), &watch.WatchStoresRequest{
+		ClusterID: c.clusterID,
+		Revision:  revision,
+	})
+	return stream, errors.WithStack(err)
+}
+
+// storeWatchLoop receives the store changes from the stream, and reconnects
+// from the last revision when the stream is broken. If the server sends a
+// snapshot, it is compared with the known stores to generate the changes.
+func (c *client) storeWatchLoop(ctx context.Context, cancel context.CancelFunc, stream watch.WatchStoresClient, ch chan<- []*watch.StoreEvent) {
+	defer c.wg.Done()
+	defer cancel()
+	defer close(ch)
+
+	var revision uint64
+	stores := make(map[uint64]*metapb.Store)
+	for {
+		if stream == nil {
+			select {
+			case <-time.After(watchRetryInterval):
+			case <-ctx.Done():
+				return
+			}
+			var err error
+			if stream, err = c.watchStores(ctx, revision); err != nil {
+				log.Warn("[pd] failed to watch stores", errs.ZapError(err))
+				c.ScheduleCheckLeader()
+				continue
+			}
+		}
+		resp, err := stream.Recv()
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Warn("[pd] the store watch stream is broken", errs.ZapError(err))
+			c.ScheduleCheckLeader()
+			stream = nil
+			continue
+		}
+		events := resp.Events
+		if resp.Snapshot {
+			events = diffStores(stores, resp.Events, resp.Revision)
+		}
+		for _, e := range events {
+			if e.Type == watch.EventRemove {
+				delete(stores, e.Store.GetId())
+			} else {
+				stores[e.Store.GetId()] = e.Store
+			}
+		}
+		revision = resp.Revision
+		if len(events) == 0 {
+			continue
+		}
+		select {
+		case ch <- events:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// diffStores returns the changes from the known stores to the snapshot.
+func diffStores(known map[uint64]*metapb.Store, snapshot []*watch.StoreEvent, revision uint64) []*watch.StoreEvent {
+	var events []*watch.StoreEvent
+	current := make(map[uint64]struct{}, len(snapshot))
+	for _, e := range snapshot {
+		current[e.Store.GetId()] = struct{}{}
+		old, ok := known[e.Store.GetId()]
+		switch {
+		case !ok:
+			events = append(events, &watch.StoreEvent{Type: watch.EventAdd, Revision: revision, Store: e.Store})
+		case !proto.Equal(old, e.Store):
+			events = append(events, &watch.StoreEvent{Type: watch.EventUpdate, Revision: revision, Store: e.Store})
+		}
+	}
+	for id, store := range known {
+		if _, ok := current[id]; !ok {
+			events = append(events, &watch.StoreEvent{Type: watch.EventRemove, Revision: revision, Store: store})
+		}
+	}
+	return events
+}
+
+// WatchRegions watches the changes of the regions.
+func (c *client) WatchRegions(ctx context.Context, startIndex uint64, ranges ...*watch.KeyRange) (<-chan *watch.WatchRegionsResponse, error) {
+	ctx, cancel := c.watchContext(ctx)
+	stream, err := c.watchRegions(ctx, startIndex, ranges)
+	if err != nil {
+		cancel()
+		c.ScheduleCheckLeader()
+		return nil, err
+	}
+	ch := make(chan *watch.WatchRegionsResponse, watchChannelSize)
+	c.wg.Add(1)
+	go c.regionWatchLoop(ctx, cancel, stream, startIndex, ranges, ch)
+	return ch, nil
+}
+
+func (c *client) watchRegions(ctx context.Context, index uint64, ranges []*watch.KeyRange) (watch.WatchRegionsClient, error) {
+	cc, ok := c.clientConns.Load(c.GetLeaderAddr())
+	if !ok {
+		return nil, errors.Errorf("[pd] no connection to the leader %s", c.GetLeaderAddr())
+	}
+	stream, err := watch.WatchRegions(ctx, cc.(*grpc.ClientConn), &watch.WatchRegionsRequest{
+		ClusterID:  c.clusterID,
+		StartIndex: index,
+		KeyRanges:  ranges,
+	})
+	return stream, errors.WithStack(err)
+}
+
+// regionWatchLoop receives the region changes from the stream, and reconnects
+// from the next index when the stream is broken. The index is shared by the
+// PD members, so the watch can resume from a new leader.
+func (c *client) regionWatchLoop(ctx context.Context, cancel context.CancelFunc, stream watch.WatchRegionsClient, index uint64, ranges []*watch.KeyRange, ch chan<- *watch.WatchRegionsResponse) {
+	defer c.wg.Done()
+	defer cancel()
+	defer close(ch)
+
+	for {
+		if stream == nil {
+			select {
+			case <-time.After(watchRetryInterval):
+			case <-ctx.Done():
+				return
+			}
+			var err error
+			if stream, err = c.watchRegions(ctx, index, ranges); err != nil {
+				log.Warn("[pd] failed to watch regions", errs.ZapError(err))
+				c.ScheduleCheckLeader()
+				continue
+			}
+		}
+		resp, err := stream.Recv()
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Warn("[pd] the region watch stream is broken", errs.ZapError(err))
+			c.ScheduleCheckLeader()
+			stream = nil
+			continue
+		}
+		index = resp.NextIndex
+		select {
+		case ch <- resp:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
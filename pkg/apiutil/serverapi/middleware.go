@@ -32,6 +32,9 @@ const (
 	RedirectorHeader    = "PD-Redirector"
 	AllowFollowerHandle = "PD-Allow-follower-handle"
 	FollowerHandle      = "PD-Follower-handle"
+	// RedirectFailedHeader is set if a follower fails to redirect the request
+	// to the leader.
+	RedirectFailedHeader = "PD-Redirect-Failed"
 )
 
 const (
@@ -102,6 +105,7 @@ func (h *redirector) ServeHTTP(w http.ResponseWriter, r *http.Request, next http
 	// Prevent more than one redirection.
 	if name := r.Header.Get(RedirectorHeader); len(name) != 0 {
 		log.Error("redirect but server is not leader", zap.String("from", name), zap.String("server", h.s.Name()), errs.ZapError(errs.ErrRedirect))
+		w.Header().Set(RedirectFailedHeader, "true")
 		http.Error(w, errRedirectToNotLeader, http.StatusInternalServerError)
 		return
 	}
@@ -168,6 +172,7 @@ func (p *customReverseProxies) ServeHTTP(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	w.Header().Set(RedirectFailedHeader, "true")
 	http.Error(w, errRedirectFailed, http.StatusInternalServerError)
 }
 
@@ -28,8 +28,8 @@ import (
 	"github.com/tikv/pd/server/cluster"
 	"github.com/tikv/pd/server/config"
 	"github.com/tikv/pd/server/core"
+	"github.com/tikv/pd/server/kv"
 	"github.com/tikv/pd/server/schedule/filter"
-	"go.etcd.io/etcd/clientv3"
 	"go.uber.org/zap"
 )
 
@@ -88,7 +88,7 @@ func getPlans(rc *cluster.RaftCluster, querier Querier, strategy *Strategy, comp
 	if component == TiKV {
 		instances = filterTiKVInstances(rc)
 	} else {
-		instances = getTiDBInstances(rc.GetEtcdClient())
+		instances = getTiDBInstances(rc.GetKV())
 	}
 
 	if len(instances) == 0 {
@@ -143,8 +143,8 @@ func filterTiKVInstances(informer core.StoreSetInformer) []instance {
 	return instances
 }
 
-func getTiDBInstances(etcdClient *clientv3.Client) []instance {
-	infos, err := GetTiDBs(etcdClient)
+func getTiDBInstances(kvBase kv.Base) []instance {
+	infos, err := GetTiDBs(kvBase)
 	if err != nil {
 		// TODO: error handling
 		return []instance{}
@@ -308,7 +308,7 @@ func getScaledGroupsByComponent(rc *cluster.RaftCluster, component ComponentType
 	case TiKV:
 		return getScaledTiKVGroups(rc, healthyInstances)
 	case TiDB:
-		return getScaledTiDBGroups(rc.GetEtcdClient(), healthyInstances)
+		return getScaledTiDBGroups(rc.GetKV(), healthyInstances)
 	default:
 		return nil, errors.Errorf("unknown component type %s", component.String())
 	}
@@ -344,11 +344,11 @@ func getScaledTiKVGroups(informer core.StoreSetInformer, healthyInstances []inst
 	return buildPlans(planMap, resourceTypeMap, TiKV), nil
 }
 
-func getScaledTiDBGroups(etcdClient *clientv3.Client, healthyInstances []instance) ([]*Plan, error) {
+func getScaledTiDBGroups(kvBase kv.Base, healthyInstances []instance) ([]*Plan, error) {
 	planMap := make(map[string]map[string]struct{}, len(healthyInstances))
 	resourceTypeMap := make(map[string]string)
 	for _, instance := range healthyInstances {
-		tidb, err := GetTiDB(etcdClient, instance.address)
+		tidb, err := GetTiDB(kvBase, instance.address)
 		if err != nil {
 			// TODO: error handling
 			return nil, err
@@ -24,6 +24,7 @@ import (
 	"github.com/tikv/pd/pkg/mock/mockcluster"
 	"github.com/tikv/pd/server/config"
 	"github.com/tikv/pd/server/core"
+	"github.com/tikv/pd/server/kv"
 )
 
 func Test(t *testing.T) {
@@ -362,3 +363,40 @@ func (s *calculationTestSuite) TestStrategyChangeCount(c *C) {
 	plans = calculateScaleOutPlan(strategy, TiKV, scaleOutQuota, instances, groups)
 	c.Assert(plans[0].Count, Equals, uint64(1))
 }
+
+func (s *calculationTestSuite) TestGetScaledTiDBGroups(c *C) {
+	kvBase := kv.NewMemoryKV()
+	groupName := fmt.Sprintf("%s-%s-0", autoScalingGroupLabelKeyPrefix, TiDB.String())
+	for address, labels := range map[string]map[string]string{
+		"tidb-1:4000": {groupLabelKey: groupName, resourceTypeLabelKey: "a"},
+		"tidb-2:4000": {groupLabelKey: groupName, resourceTypeLabelKey: "a"},
+		"tidb-3:4000": {},
+	} {
+		info, err := json.Marshal(&TiDBInfo{Labels: labels})
+		c.Assert(err, IsNil)
+		c.Assert(kvBase.Save(fmt.Sprintf("/topology/tidb/%s/info", address), string(info)), IsNil)
+		c.Assert(kvBase.Save(fmt.Sprintf("/topology/tidb/%s/ttl", address), "1"), IsNil)
+	}
+	// The keys which are not the ttl of a tidb are ignored.
+	c.Assert(kvBase.Save("/topology/tidb/tidb-4:4000/info", "{}"), IsNil)
+	c.Assert(kvBase.Save("/topology/tidb/tidb-5/4000/ttl", "1"), IsNil)
+	c.Assert(kvBase.Save("/topology/tikv/tikv-1:20160/ttl", "1"), IsNil)
+
+	instances := getTiDBInstances(kvBase)
+	c.Assert(getAddresses(instances), DeepEquals, []string{"tidb-1:4000", "tidb-2:4000", "tidb-3:4000"})
+	plans, err := getScaledTiDBGroups(kvBase, instances)
+	c.Assert(err, IsNil)
+	c.Assert(plans, DeepEquals, []*Plan{{
+		Component:    TiDB.String(),
+		Count:        2,
+		ResourceType: "a",
+		Labels: map[string]string{
+			groupLabelKey:        groupName,
+			resourceTypeLabelKey: "a",
+		},
+	}})
+
+	// A healthy instance without the info is inconsistent.
+	_, err = getScaledTiDBGroups(kvBase, append(instances, instance{address: "tidb-6:4000"}))
+	c.Assert(err, NotNil)
+}
@@ -19,8 +19,7 @@ import (
 	"regexp"
 	"strings"
 
-	"github.com/tikv/pd/pkg/etcdutil"
-	"go.etcd.io/etcd/clientv3"
+	"github.com/tikv/pd/server/kv"
 )
 
 // Strategy within a HTTP request provides rules and resources to help make decision for auto scaling.
@@ -141,18 +140,18 @@ func (t *TiDBInfo) getLabels() map[string]string {
 }
 
 // GetTiDB get TiDB info which registered in PD by address
-func GetTiDB(etcdClient *clientv3.Client, address string) (*TiDBInfo, error) {
+func GetTiDB(kvBase kv.Base, address string) (*TiDBInfo, error) {
 	key := fmt.Sprintf("/topology/tidb/%s/info", address)
-	resp, err := etcdutil.EtcdKVGet(etcdClient, key)
+	value, err := kvBase.Load(key)
 	if err != nil {
 		return nil, err
 	}
-	if resp.Count < 1 {
+	if value == "" {
 		err := fmt.Errorf("resp loaded for tidb [%s] is empty", address)
 		return nil, err
 	}
 	tidb := &TiDBInfo{}
-	err = json.Unmarshal(resp.Kvs[0].Value, tidb)
+	err = json.Unmarshal([]byte(value), tidb)
 	if err != nil {
 		return nil, err
 	}
@@ -166,18 +165,17 @@ const (
 )
 
 // GetTiDBs list TiDB register in PD
-func GetTiDBs(etcdClient *clientv3.Client) ([]*TiDBInfo, error) {
+func GetTiDBs(kvBase kv.Base) ([]*TiDBInfo, error) {
 	tidbTTLPattern, err := regexp.Compile(tidbTTLPatternStr)
 	if err != nil {
 		return nil, err
 	}
-	resps, err := etcdutil.EtcdKVGet(etcdClient, tidbInfoPrefix, clientv3.WithPrefix())
+	keys, _, err := kvBase.LoadRange(tidbInfoPrefix, kv.GetPrefixRangeEnd(tidbInfoPrefix), 0)
 	if err != nil {
 		return nil, err
 	}
-	tidbs := make([]*TiDBInfo, 0, resps.Count)
-	for _, resp := range resps.Kvs {
-		key := string(resp.Key)
+	tidbs := make([]*TiDBInfo, 0, len(keys))
+	for _, key := range keys {
 		if tidbTTLPattern.MatchString(key) {
 			address := key[len(tidbInfoPrefix) : len(key)-len("/ttl")]
 			// In order to avoid make "aaa/bbb" in "/topology/tidb/aaa/bbb/ttl" stored as tidb address
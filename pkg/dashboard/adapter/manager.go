@@ -26,7 +26,6 @@ import (
 	"github.com/tikv/pd/pkg/errs"
 	"github.com/tikv/pd/pkg/logutil"
 	"github.com/tikv/pd/server"
-	"github.com/tikv/pd/server/cluster"
 )
 
 var (
@@ -104,7 +103,7 @@ func (m *Manager) updateInfo() {
 	m.isLeader = true
 
 	var err error
-	if m.members, err = cluster.GetMembers(m.srv.GetClient()); err != nil {
+	if m.members, err = m.srv.GetMember().GetMembers(); err != nil {
 		log.Warn("failed to get members", errs.ZapError(err))
 		m.members = nil
 		return
@@ -37,12 +37,19 @@ var (
 	ErrGenerateTimestamp  = errors.Normalize("generate timestamp failed, %s", errors.RFCCodeText("PD:tso:ErrGenerateTimestamp"))
 	ErrInvalidTimestamp   = errors.Normalize("invalid timestamp", errors.RFCCodeText("PD:tso:ErrInvalidTimestamp"))
 	ErrLogicOverflow      = errors.Normalize("logic part overflow", errors.RFCCodeText("PD:tso:ErrLogicOverflow"))
+	ErrTSOSkew            = errors.Normalize("the tso falls back", errors.RFCCodeText("PD:tso:ErrTSOSkew"))
+	ErrSetDCLocation      = errors.Normalize("set dc-location failed, %s", errors.RFCCodeText("PD:tso:ErrSetDCLocation"))
+	ErrRemoveDCLocation   = errors.Normalize("remove dc-location failed, %s", errors.RFCCodeText("PD:tso:ErrRemoveDCLocation"))
+	ErrInvalidDCLocation  = errors.Normalize("invalid dc-location, %s", errors.RFCCodeText("PD:tso:ErrInvalidDCLocation"))
+	ErrDCLocationNotFound = errors.Normalize("dc-location %s not found", errors.RFCCodeText("PD:tso:ErrDCLocationNotFound"))
+	ErrDCLocationInUse    = errors.Normalize("dc-location %s is still in use, %s", errors.RFCCodeText("PD:tso:ErrDCLocationInUse"))
 )
 
 // member errors
 var (
 	ErrEtcdLeaderNotFound = errors.Normalize("etcd leader not found", errors.RFCCodeText("PD:member:ErrEtcdLeaderNotFound"))
 	ErrMarshalLeader      = errors.Normalize("marshal leader failed", errors.RFCCodeText("PD:member:ErrMarshalLeader"))
+	ErrStandaloneMode     = errors.Normalize("%s is not supported in the standalone mode", errors.RFCCodeText("PD:member:ErrStandaloneMode"))
 )
 
 // core errors
@@ -51,6 +58,8 @@ var (
 	ErrStoreNotFound       = errors.Normalize("store %v not found", errors.RFCCodeText("PD:core:ErrStoreNotFound"))
 	ErrPauseLeaderTransfer = errors.Normalize("store %v is paused for leader transfer", errors.RFCCodeText("PD:core:ErrPauseLeaderTransfer"))
 	ErrStoreTombstone      = errors.Normalize("store %v has been removed", errors.RFCCodeText("PD:core:ErrStoreTombstone"))
+	ErrSlowStoreEvicted    = errors.Normalize("store %v is evicted as a slow store", errors.RFCCodeText("PD:core:ErrSlowStoreEvicted"))
+	ErrBatchTooLarge       = errors.Normalize("batch with %v ops of %v bytes exceeds the limits of a transaction", errors.RFCCodeText("PD:core:ErrBatchTooLarge"))
 )
 
 // client errors
@@ -60,6 +69,9 @@ var (
 	ErrClientGetTSO          = errors.Normalize("get TSO failed, %v", errors.RFCCodeText("PD:client:ErrClientGetTSO"))
 	ErrClientGetLeader       = errors.Normalize("get leader from %v error", errors.RFCCodeText("PD:client:ErrClientGetLeader"))
 	ErrClientGetMember       = errors.Normalize("get member failed", errors.RFCCodeText("PD:client:ErrClientGetMember"))
+	ErrClientHTTPRequest     = errors.Normalize("send request %s %s failed", errors.RFCCodeText("PD:client:ErrClientHTTPRequest"))
+	ErrClientHTTPResponse    = errors.Normalize("request %s %s failed with status %d, %s", errors.RFCCodeText("PD:client:ErrClientHTTPResponse"))
+	ErrClientRegionNotFound  = errors.Normalize("region not found for key %s", errors.RFCCodeText("PD:client:ErrClientRegionNotFound"))
 )
 
 // schedule errors
@@ -67,6 +79,7 @@ var (
 	ErrUnexpectedOperatorStatus = errors.Normalize("operator with unexpected status", errors.RFCCodeText("PD:schedule:ErrUnexpectedOperatorStatus"))
 	ErrUnknownOperatorStep      = errors.Normalize("unknown operator step found", errors.RFCCodeText("PD:schedule:ErrUnknownOperatorStep"))
 	ErrMergeOperator            = errors.Normalize("merge operator error, %s", errors.RFCCodeText("PD:schedule:ErrMergeOperator"))
+	ErrOperatorJournalNotLoaded = errors.Normalize("operator journal is not loaded yet", errors.RFCCodeText("PD:schedule:ErrOperatorJournalNotLoaded"))
 )
 
 // scheduler errors
@@ -79,20 +92,36 @@ var (
 	ErrCacheOverflow                    = errors.Normalize("cache overflow", errors.RFCCodeText("PD:scheduler:ErrCacheOverflow"))
 	ErrInternalGrowth                   = errors.Normalize("unknown interval growth type error", errors.RFCCodeText("PD:scheduler:ErrInternalGrowth"))
 	ErrSchedulerCreateFuncNotRegistered = errors.Normalize("create func of %v is not registered", errors.RFCCodeText("PD:scheduler:ErrSchedulerCreateFuncNotRegistered"))
+	ErrDiagnoseConfig                   = errors.Normalize("invalid config to diagnose with, %s", errors.RFCCodeText("PD:scheduler:ErrDiagnoseConfig"))
 )
 
 // placement errors
 var (
-	ErrRuleContent   = errors.Normalize("invalid rule content, %s", errors.RFCCodeText("PD:placement:ErrRuleContent"))
-	ErrLoadRule      = errors.Normalize("load rule failed", errors.RFCCodeText("PD:placement:ErrLoadRule"))
-	ErrLoadRuleGroup = errors.Normalize("load rule group failed", errors.RFCCodeText("PD:placement:ErrLoadRuleGroup"))
-	ErrBuildRuleList = errors.Normalize("build rule list failed, %s", errors.RFCCodeText("PD:placement:ErrBuildRuleList"))
+	ErrRuleContent          = errors.Normalize("invalid rule content, %s", errors.RFCCodeText("PD:placement:ErrRuleContent"))
+	ErrLoadRule             = errors.Normalize("load rule failed", errors.RFCCodeText("PD:placement:ErrLoadRule"))
+	ErrLoadRuleGroup        = errors.Normalize("load rule group failed", errors.RFCCodeText("PD:placement:ErrLoadRuleGroup"))
+	ErrBuildRuleList        = errors.Normalize("build rule list failed, %s", errors.RFCCodeText("PD:placement:ErrBuildRuleList"))
+	ErrLoadRuleTemplate     = errors.Normalize("load rule template failed", errors.RFCCodeText("PD:placement:ErrLoadRuleTemplate"))
+	ErrRuleTemplateNotFound = errors.Normalize("rule template %s not found", errors.RFCCodeText("PD:placement:ErrRuleTemplateNotFound"))
+	ErrRuleTemplateInUse    = errors.Normalize("rule template %s is referenced by %s", errors.RFCCodeText("PD:placement:ErrRuleTemplateInUse"))
+	ErrRuleNotFit           = errors.Normalize("rule %s needs %d stores but only %d stores match", errors.RFCCodeText("PD:placement:ErrRuleNotFit"))
+)
+
+// region label errors
+var (
+	ErrRegionRuleContent  = errors.Normalize("invalid region rule content, %s", errors.RFCCodeText("PD:labeler:ErrRegionRuleContent"))
+	ErrRegionRuleNotFound = errors.Normalize("region label rule not found for id %s", errors.RFCCodeText("PD:labeler:ErrRegionRuleNotFound"))
+	ErrLoadRegionRule     = errors.Normalize("load region label rule failed", errors.RFCCodeText("PD:labeler:ErrLoadRegionRule"))
 )
 
 // cluster errors
 var (
-	ErrNotBootstrapped = errors.Normalize("TiKV cluster not bootstrapped, please start TiKV first", errors.RFCCodeText("PD:cluster:ErrNotBootstrapped"))
-	ErrStoreIsUp       = errors.Normalize("store is still up, please remove store gracefully", errors.RFCCodeText("PD:cluster:ErrStoreIsUp"))
+	ErrNotBootstrapped            = errors.Normalize("TiKV cluster not bootstrapped, please start TiKV first", errors.RFCCodeText("PD:cluster:ErrNotBootstrapped"))
+	ErrStoreIsUp                  = errors.Normalize("store is still up, please remove store gracefully", errors.RFCCodeText("PD:cluster:ErrStoreIsUp"))
+	ErrUnsafeRecoveryIsRunning    = errors.Normalize("unsafe recovery is running", errors.RFCCodeText("PD:cluster:ErrUnsafeRecoveryIsRunning"))
+	ErrUnsafeRecoveryIsNotRunning = errors.Normalize("unsafe recovery is not running", errors.RFCCodeText("PD:cluster:ErrUnsafeRecoveryIsNotRunning"))
+	ErrUnsafeRecoveryInvalidInput = errors.Normalize("invalid input for unsafe recovery, %s", errors.RFCCodeText("PD:cluster:ErrUnsafeRecoveryInvalidInput"))
+	ErrUnsafeRecoveryNotSupported = errors.Normalize("unsafe recovery is not supported by the store heartbeats yet", errors.RFCCodeText("PD:cluster:ErrUnsafeRecoveryNotSupported"))
 )
 
 // versioninfo errors
@@ -127,6 +156,7 @@ var (
 	ErrClientURLEmpty        = errors.Normalize("client url empty", errors.RFCCodeText("PD:server:ErrClientEmpty"))
 	ErrLeaderNil             = errors.Normalize("leader is nil", errors.RFCCodeText("PD:server:ErrLeaderNil"))
 	ErrCancelStartEtcd       = errors.Normalize("etcd start canceled", errors.RFCCodeText("PD:server:ErrCancelStartEtcd"))
+	ErrListenClientURL       = errors.Normalize("listen client url %s error", errors.RFCCodeText("PD:server:ErrListenClientURL"))
 )
 
 // logutil errors
@@ -168,6 +198,7 @@ var (
 	ErrStartEtcd         = errors.Normalize("start etcd failed", errors.RFCCodeText("PD:etcd:ErrStartEtcd"))
 	ErrEtcdURLMap        = errors.Normalize("etcd url map error", errors.RFCCodeText("PD:etcd:ErrEtcdURLMap"))
 	ErrEtcdGrantLease    = errors.Normalize("etcd lease failed", errors.RFCCodeText("PD:etcd:ErrEtcdGrantLease"))
+	ErrEtcdRevokeLease   = errors.Normalize("etcd revoke lease failed", errors.RFCCodeText("PD:etcd:ErrEtcdRevokeLease"))
 	ErrEtcdTxn           = errors.Normalize("etcd Txn failed", errors.RFCCodeText("PD:etcd:ErrEtcdTxn"))
 	ErrEtcdKVPut         = errors.Normalize("etcd KV put failed", errors.RFCCodeText("PD:etcd:ErrEtcdKVPut"))
 	ErrEtcdKVDelete      = errors.Normalize("etcd KV delete failed", errors.RFCCodeText("PD:etcd:ErrEtcdKVDelete"))
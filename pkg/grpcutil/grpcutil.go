@@ -22,8 +22,54 @@ import (
 	"go.etcd.io/etcd/pkg/transport"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
 )
 
+// The gRPC metadata keys used by the region queries served by followers.
+const (
+	// AllowFollowerHandleKey is set by the client if the request can be
+	// handled by a follower.
+	AllowFollowerHandleKey = "pd-allow-follower-handle"
+	// FollowerHandleKey is set by the server if the response is from a follower.
+	FollowerHandleKey = "pd-follower-handle"
+	// RegionSyncIndexKey is set by the server to the index of the region syncer,
+	// with which the client can tell how stale the response is.
+	RegionSyncIndexKey = "pd-region-sync-index"
+	// RegionSyncStalenessKey is set by a follower to the milliseconds since it
+	// last heard from the leader, with which the client can bound the staleness
+	// even if it never reaches the leader.
+	RegionSyncStalenessKey = "pd-region-sync-staleness"
+	// ForwardMetadataKey is set by the client to the URL of the leader, if
+	// the request is sent to a follower and should be forwarded to the leader.
+	ForwardMetadataKey = "pd-forwarded-host"
+)
+
+// BuildForwardContext creates a context with the leader URL, with which the
+// request is forwarded to the leader.
+func BuildForwardContext(ctx context.Context, leaderAddr string) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, ForwardMetadataKey, leaderAddr)
+}
+
+// GetForwardedHost returns the leader URL which the incoming request should be
+// forwarded to. It returns "" if the request is not forwarded.
+func GetForwardedHost(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	if hosts := md.Get(ForwardMetadataKey); len(hosts) > 0 {
+		return hosts[0]
+	}
+	return ""
+}
+
+// IsFollowerHandleEnabled returns true if the incoming request allows
+// follower handle.
+func IsFollowerHandleEnabled(ctx context.Context) bool {
+	md, ok := metadata.FromIncomingContext(ctx)
+	return ok && len(md.Get(AllowFollowerHandleKey)) > 0
+}
+
 // TLSConfig is the configuration for supporting tls.
 type TLSConfig struct {
 	// CAPath is the path of file that contains list of trusted SSL CAs. if set, following four settings shouldn't be empty
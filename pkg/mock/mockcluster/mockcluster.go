@@ -26,6 +26,7 @@ import (
 	"github.com/tikv/pd/server/core"
 	"github.com/tikv/pd/server/core/storelimit"
 	"github.com/tikv/pd/server/kv"
+	"github.com/tikv/pd/server/schedule/labeler"
 	"github.com/tikv/pd/server/schedule/placement"
 	"github.com/tikv/pd/server/statistics"
 	"github.com/tikv/pd/server/versioninfo"
@@ -45,6 +46,7 @@ type Cluster struct {
 	*placement.RuleManager
 	*statistics.HotStat
 	*config.PersistOptions
+	RegionLabeler    *labeler.RegionLabeler
 	ID               uint64
 	suspectRegions   map[uint64]struct{}
 	disabledFeatures map[versioninfo.Feature]struct{}
@@ -60,6 +62,7 @@ func NewCluster(opts *config.PersistOptions) *Cluster {
 		suspectRegions:   map[uint64]struct{}{},
 		disabledFeatures: make(map[versioninfo.Feature]struct{}),
 	}
+	clus.RegionLabeler, _ = labeler.NewRegionLabeler(core.NewStorage(kv.NewMemoryKV()))
 	if clus.PersistOptions.GetReplicationConfig().EnablePlacementRules {
 		clus.initRuleManager()
 	}
@@ -158,6 +161,11 @@ func (mc *Cluster) GetRuleManager() *placement.RuleManager {
 	return mc.RuleManager
 }
 
+// GetRegionLabeler returns the region labeler of the cluster.
+func (mc *Cluster) GetRegionLabeler() *labeler.RegionLabeler {
+	return mc.RegionLabeler
+}
+
 // SetStoreUp sets store state to be up.
 func (mc *Cluster) SetStoreUp(storeID uint64) {
 	store := mc.GetStore(storeID)
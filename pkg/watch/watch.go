@@ -0,0 +1,373 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package watch defines the gRPC service to watch the changes of the cluster
+// metadata, which is served on the same gRPC server as the PD service:
+//
+//	service Watch {
+//	    rpc WatchStores(WatchStoresRequest) returns (stream WatchStoresResponse) {}
+//	    rpc WatchRegions(WatchRegionsRequest) returns (stream WatchRegionsResponse) {}
+//	}
+//
+// The messages are not generated from proto files as the service is not in
+// kvproto yet. They are defined with the protobuf tags of the fields below, so
+// they are encoded in the protobuf wire format by the proto codec of gRPC like
+// the generated messages, and can be replaced by the generated ones later
+// without changing the wire format.
+package watch
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"google.golang.org/grpc"
+)
+
+// EventType is the type of a change.
+//
+//	enum EventType {
+//	    Add = 0;
+//	    Update = 1;
+//	    Remove = 2;
+//	}
+type EventType int32
+
+// The types of the changes.
+const (
+	EventAdd    EventType = 0
+	EventUpdate EventType = 1
+	EventRemove EventType = 2
+)
+
+var eventTypeNames = map[EventType]string{
+	EventAdd:    "Add",
+	EventUpdate: "Update",
+	EventRemove: "Remove",
+}
+
+func (t EventType) String() string {
+	if name, ok := eventTypeNames[t]; ok {
+		return name
+	}
+	return "Unknown"
+}
+
+// StoreEvent is a change of a store.
+//
+//	message StoreEvent {
+//	    EventType type = 1;
+//	    uint64 revision = 2;
+//	    metapb.Store store = 3;
+//	}
+type StoreEvent struct {
+	Type     EventType     `protobuf:"varint,1,opt,name=type,proto3" json:"type,omitempty"`
+	Revision uint64        `protobuf:"varint,2,opt,name=revision,proto3" json:"revision,omitempty"`
+	Store    *metapb.Store `protobuf:"bytes,3,opt,name=store" json:"store,omitempty"`
+}
+
+// Reset implements proto.Message.
+func (m *StoreEvent) Reset() { *m = StoreEvent{} }
+
+// String implements proto.Message.
+func (m *StoreEvent) String() string { return proto.CompactTextString(m) }
+
+// ProtoMessage implements proto.Message.
+func (*StoreEvent) ProtoMessage() {}
+
+// WatchStoresRequest starts watching the changes of the stores after the
+// revision. A zero revision means starting with all stores.
+//
+//	message WatchStoresRequest {
+//	    uint64 cluster_id = 1;
+//	    uint64 revision = 2;
+//	}
+type WatchStoresRequest struct {
+	ClusterID uint64 `protobuf:"varint,1,opt,name=cluster_id,json=clusterId,proto3" json:"cluster_id,omitempty"`
+	Revision  uint64 `protobuf:"varint,2,opt,name=revision,proto3" json:"revision,omitempty"`
+}
+
+// Reset implements proto.Message.
+func (m *WatchStoresRequest) Reset() { *m = WatchStoresRequest{} }
+
+// String implements proto.Message.
+func (m *WatchStoresRequest) String() string { return proto.CompactTextString(m) }
+
+// ProtoMessage implements proto.Message.
+func (*WatchStoresRequest) ProtoMessage() {}
+
+// WatchStoresResponse is a batch of the store changes.
+//
+//	message WatchStoresResponse {
+//	    bool snapshot = 1;
+//	    uint64 revision = 2;
+//	    repeated StoreEvent events = 3;
+//	}
+type WatchStoresResponse struct {
+	// Snapshot is true if Events are the add events of all stores rather
+	// than the changes, which happens when the watch starts or the changes
+	// after the requested revision are no longer kept.
+	Snapshot bool          `protobuf:"varint,1,opt,name=snapshot,proto3" json:"snapshot,omitempty"`
+	Revision uint64        `protobuf:"varint,2,opt,name=revision,proto3" json:"revision,omitempty"`
+	Events   []*StoreEvent `protobuf:"bytes,3,rep,name=events" json:"events,omitempty"`
+}
+
+// Reset implements proto.Message.
+func (m *WatchStoresResponse) Reset() { *m = WatchStoresResponse{} }
+
+// String implements proto.Message.
+func (m *WatchStoresResponse) String() string { return proto.CompactTextString(m) }
+
+// ProtoMessage implements proto.Message.
+func (*WatchStoresResponse) ProtoMessage() {}
+
+// RegionEvent is a change of a region recorded at the index.
+//
+//	message RegionEvent {
+//	    uint64 index = 1;
+//	    metapb.Region region = 2;
+//	    metapb.Peer leader = 3;
+//	}
+type RegionEvent struct {
+	Index  uint64         `protobuf:"varint,1,opt,name=index,proto3" json:"index,omitempty"`
+	Region *metapb.Region `protobuf:"bytes,2,opt,name=region" json:"region,omitempty"`
+	Leader *metapb.Peer   `protobuf:"bytes,3,opt,name=leader" json:"leader,omitempty"`
+}
+
+// Reset implements proto.Message.
+func (m *RegionEvent) Reset() { *m = RegionEvent{} }
+
+// String implements proto.Message.
+func (m *RegionEvent) String() string { return proto.CompactTextString(m) }
+
+// ProtoMessage implements proto.Message.
+func (*RegionEvent) ProtoMessage() {}
+
+// KeyRange is a range of keys. An empty EndKey means no upper bound.
+//
+//	message KeyRange {
+//	    bytes start_key = 1;
+//	    bytes end_key = 2;
+//	}
+type KeyRange struct {
+	StartKey []byte `protobuf:"bytes,1,opt,name=start_key,json=startKey,proto3" json:"start_key,omitempty"`
+	EndKey   []byte `protobuf:"bytes,2,opt,name=end_key,json=endKey,proto3" json:"end_key,omitempty"`
+}
+
+// Reset implements proto.Message.
+func (m *KeyRange) Reset() { *m = KeyRange{} }
+
+// String implements proto.Message.
+func (m *KeyRange) String() string { return proto.CompactTextString(m) }
+
+// ProtoMessage implements proto.Message.
+func (*KeyRange) ProtoMessage() {}
+
+// Overlaps returns true if the region overlaps with the range.
+func (m *KeyRange) Overlaps(region *metapb.Region) bool {
+	return (len(m.EndKey) == 0 || bytes.Compare(region.GetStartKey(), m.EndKey) < 0) &&
+		(len(region.GetEndKey()) == 0 || bytes.Compare(region.GetEndKey(), m.StartKey) > 0)
+}
+
+// WatchRegionsRequest starts watching the changes of the regions from the
+// index. Only the regions overlapping with the key ranges are watched, or all
+// regions if there is no key range.
+//
+//	message WatchRegionsRequest {
+//	    uint64 cluster_id = 1;
+//	    uint64 start_index = 2;
+//	    repeated KeyRange key_ranges = 3;
+//	}
+type WatchRegionsRequest struct {
+	ClusterID  uint64      `protobuf:"varint,1,opt,name=cluster_id,json=clusterId,proto3" json:"cluster_id,omitempty"`
+	StartIndex uint64      `protobuf:"varint,2,opt,name=start_index,json=startIndex,proto3" json:"start_index,omitempty"`
+	KeyRanges  []*KeyRange `protobuf:"bytes,3,rep,name=key_ranges,json=keyRanges" json:"key_ranges,omitempty"`
+}
+
+// Reset implements proto.Message.
+func (m *WatchRegionsRequest) Reset() { *m = WatchRegionsRequest{} }
+
+// String implements proto.Message.
+func (m *WatchRegionsRequest) String() string { return proto.CompactTextString(m) }
+
+// ProtoMessage implements proto.Message.
+func (*WatchRegionsRequest) ProtoMessage() {}
+
+// WatchRegionsResponse is a batch of the region changes.
+//
+//	message WatchRegionsResponse {
+//	    bool reset_required = 1;
+//	    uint64 next_index = 2;
+//	    repeated RegionEvent events = 3;
+//	}
+type WatchRegionsResponse struct {
+	// ResetRequired is true if the changes from the requested index are no
+	// longer kept. The watcher should reload the regions, and the changes
+	// from NextIndex follow.
+	ResetRequired bool `protobuf:"varint,1,opt,name=reset_required,json=resetRequired,proto3" json:"reset_required,omitempty"`
+	// NextIndex is the index to resume the watch from.
+	NextIndex uint64         `protobuf:"varint,2,opt,name=next_index,json=nextIndex,proto3" json:"next_index,omitempty"`
+	Events    []*RegionEvent `protobuf:"bytes,3,rep,name=events" json:"events,omitempty"`
+}
+
+// Reset implements proto.Message.
+func (m *WatchRegionsResponse) Reset() { *m = WatchRegionsResponse{} }
+
+// String implements proto.Message.
+func (m *WatchRegionsResponse) String() string { return proto.CompactTextString(m) }
+
+// ProtoMessage implements proto.Message.
+func (*WatchRegionsResponse) ProtoMessage() {}
+
+// Server is the server API of the watch service.
+type Server interface {
+	WatchStores(*WatchStoresRequest, WatchStoresServer) error
+	WatchRegions(*WatchRegionsRequest, WatchRegionsServer) error
+}
+
+// WatchStoresServer is the server side of the WatchStores stream.
+type WatchStoresServer interface {
+	Send(*WatchStoresResponse) error
+	grpc.ServerStream
+}
+
+type watchStoresServer struct {
+	grpc.ServerStream
+}
+
+func (s *watchStoresServer) Send(m *WatchStoresResponse) error {
+	return s.ServerStream.SendMsg(m)
+}
+
+func watchStoresHandler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchStoresRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(Server).WatchStores(m, &watchStoresServer{stream})
+}
+
+// WatchRegionsServer is the server side of the WatchRegions stream.
+type WatchRegionsServer interface {
+	Send(*WatchRegionsResponse) error
+	grpc.ServerStream
+}
+
+type watchRegionsServer struct {
+	grpc.ServerStream
+}
+
+func (s *watchRegionsServer) Send(m *WatchRegionsResponse) error {
+	return s.ServerStream.SendMsg(m)
+}
+
+func watchRegionsHandler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchRegionsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(Server).WatchRegions(m, &watchRegionsServer{stream})
+}
+
+var serviceDesc = grpc.ServiceDesc{
+	ServiceName: "pd.watch.Watch",
+	HandlerType: (*Server)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchStores",
+			Handler:       watchStoresHandler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "WatchRegions",
+			Handler:       watchRegionsHandler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "watch",
+}
+
+// RegisterServer registers the watch service to the gRPC server.
+func RegisterServer(s *grpc.Server, srv Server) {
+	s.RegisterService(&serviceDesc, srv)
+}
+
+// WatchStoresClient is the client side of the WatchStores stream.
+type WatchStoresClient interface {
+	Recv() (*WatchStoresResponse, error)
+	grpc.ClientStream
+}
+
+type watchStoresClient struct {
+	grpc.ClientStream
+}
+
+func (c *watchStoresClient) Recv() (*WatchStoresResponse, error) {
+	m := new(WatchStoresResponse)
+	if err := c.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// WatchStores starts watching the stores with the connection.
+func WatchStores(ctx context.Context, cc *grpc.ClientConn, req *WatchStoresRequest, opts ...grpc.CallOption) (WatchStoresClient, error) {
+	stream, err := cc.NewStream(ctx, &serviceDesc.Streams[0], "/pd.watch.Watch/WatchStores", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &watchStoresClient{stream}
+	if err := x.ClientStream.SendMsg(req); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// WatchRegionsClient is the client side of the WatchRegions stream.
+type WatchRegionsClient interface {
+	Recv() (*WatchRegionsResponse, error)
+	grpc.ClientStream
+}
+
+type watchRegionsClient struct {
+	grpc.ClientStream
+}
+
+func (c *watchRegionsClient) Recv() (*WatchRegionsResponse, error) {
+	m := new(WatchRegionsResponse)
+	if err := c.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// WatchRegions starts watching the regions with the connection.
+func WatchRegions(ctx context.Context, cc *grpc.ClientConn, req *WatchRegionsRequest, opts ...grpc.CallOption) (WatchRegionsClient, error) {
+	stream, err := cc.NewStream(ctx, &serviceDesc.Streams[1], "/pd.watch.Watch/WatchRegions", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &watchRegionsClient{stream}
+	if err := x.ClientStream.SendMsg(req); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
@@ -0,0 +1,175 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package watch
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/encoding"
+	_ "google.golang.org/grpc/encoding/proto"
+)
+
+func Test(t *testing.T) {
+	TestingT(t)
+}
+
+var _ = Suite(&testWatchSuite{})
+
+type testWatchSuite struct{}
+
+func (s *testWatchSuite) TestKeyRangeOverlaps(c *C) {
+	r := &KeyRange{StartKey: []byte("b"), EndKey: []byte("d")}
+	testCases := []struct {
+		startKey, endKey string
+		overlaps         bool
+	}{
+		{"", "", true},
+		{"", "b", false},
+		{"", "c", true},
+		{"c", "", true},
+		{"d", "", false},
+		{"d", "e", false},
+		{"a", "b", false},
+		{"a", "bb", true},
+		{"bb", "c", true},
+	}
+	for _, t := range testCases {
+		region := &metapb.Region{StartKey: []byte(t.startKey), EndKey: []byte(t.endKey)}
+		c.Assert(r.Overlaps(region), Equals, t.overlaps)
+	}
+	// A range without the end key has no upper bound.
+	r = &KeyRange{StartKey: []byte("b")}
+	c.Assert(r.Overlaps(&metapb.Region{StartKey: []byte("z")}), IsTrue)
+	c.Assert(r.Overlaps(&metapb.Region{EndKey: []byte("b")}), IsFalse)
+}
+
+func (s *testWatchSuite) TestCodec(c *C) {
+	codec := encoding.GetCodec("proto")
+	// The messages are encoded in the protobuf wire format.
+	data, err := codec.Marshal(&WatchStoresRequest{ClusterID: 1, Revision: 300})
+	c.Assert(err, IsNil)
+	c.Assert(data, DeepEquals, []byte{0x08, 0x01, 0x10, 0xac, 0x02})
+
+	stores := &WatchStoresResponse{
+		Snapshot: true,
+		Revision: 3,
+		Events: []*StoreEvent{
+			{Type: EventAdd, Revision: 1, Store: &metapb.Store{Id: 1, Address: "s1"}},
+			{Type: EventRemove, Revision: 3, Store: &metapb.Store{Id: 2, Labels: []*metapb.StoreLabel{{Key: "zone", Value: "z1"}}}},
+		},
+	}
+	data, err = codec.Marshal(stores)
+	c.Assert(err, IsNil)
+	var gotStores WatchStoresResponse
+	c.Assert(codec.Unmarshal(data, &gotStores), IsNil)
+	c.Assert(&gotStores, DeepEquals, stores)
+
+	regions := &WatchRegionsResponse{
+		NextIndex: 10,
+		Events: []*RegionEvent{{
+			Index:  9,
+			Region: &metapb.Region{Id: 2, StartKey: []byte("a"), EndKey: []byte("b"), Peers: []*metapb.Peer{{Id: 3, StoreId: 1}}},
+			Leader: &metapb.Peer{Id: 3, StoreId: 1},
+		}},
+	}
+	data, err = codec.Marshal(regions)
+	c.Assert(err, IsNil)
+	var gotRegions WatchRegionsResponse
+	c.Assert(codec.Unmarshal(data, &gotRegions), IsNil)
+	c.Assert(&gotRegions, DeepEquals, regions)
+
+	req := &WatchRegionsRequest{ClusterID: 1, StartIndex: 5, KeyRanges: []*KeyRange{{StartKey: []byte("a")}}}
+	data, err = codec.Marshal(req)
+	c.Assert(err, IsNil)
+	var gotReq WatchRegionsRequest
+	c.Assert(codec.Unmarshal(data, &gotReq), IsNil)
+	c.Assert(gotReq.StartIndex, Equals, uint64(5))
+	c.Assert(gotReq.KeyRanges, HasLen, 1)
+	c.Assert(gotReq.KeyRanges[0].StartKey, DeepEquals, []byte("a"))
+}
+
+type mockServer struct {
+	stores  []*WatchStoresResponse
+	regions []*WatchRegionsResponse
+}
+
+func (s *mockServer) WatchStores(req *WatchStoresRequest, stream WatchStoresServer) error {
+	for _, resp := range s.stores {
+		if resp.Revision > req.Revision {
+			if err := stream.Send(resp); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s *mockServer) WatchRegions(req *WatchRegionsRequest, stream WatchRegionsServer) error {
+	for _, resp := range s.regions {
+		if resp.NextIndex > req.StartIndex {
+			if err := stream.Send(resp); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s *testWatchSuite) TestService(c *C) {
+	srv := &mockServer{
+		stores: []*WatchStoresResponse{
+			{Revision: 1, Events: []*StoreEvent{{Type: EventAdd, Revision: 1, Store: &metapb.Store{Id: 1}}}},
+			{Revision: 2, Events: []*StoreEvent{{Type: EventUpdate, Revision: 2, Store: &metapb.Store{Id: 1, Address: "s1"}}}},
+		},
+		regions: []*WatchRegionsResponse{
+			{NextIndex: 2, Events: []*RegionEvent{{Index: 1, Region: &metapb.Region{Id: 1}}}},
+			{ResetRequired: true, NextIndex: 5},
+		},
+	}
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	c.Assert(err, IsNil)
+	gs := grpc.NewServer()
+	RegisterServer(gs, srv)
+	go gs.Serve(l)
+	defer gs.Stop()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cc, err := grpc.DialContext(ctx, l.Addr().String(), grpc.WithInsecure())
+	c.Assert(err, IsNil)
+	defer cc.Close()
+
+	stores, err := WatchStores(ctx, cc, &WatchStoresRequest{Revision: 1})
+	c.Assert(err, IsNil)
+	resp, err := stores.Recv()
+	c.Assert(err, IsNil)
+	c.Assert(resp, DeepEquals, srv.stores[1])
+	_, err = stores.Recv()
+	c.Assert(err, NotNil)
+
+	regions, err := WatchRegions(ctx, cc, &WatchRegionsRequest{})
+	c.Assert(err, IsNil)
+	for _, expect := range srv.regions {
+		resp, err := regions.Recv()
+		c.Assert(err, IsNil)
+		c.Assert(resp, DeepEquals, expect)
+	}
+	_, err = regions.Recv()
+	c.Assert(err, NotNil)
+}
@@ -14,13 +14,18 @@
 package api
 
 import (
+	"fmt"
 	"io/ioutil"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/pingcap/kvproto/pkg/pdpb"
 	"github.com/tikv/pd/pkg/apiutil"
+	"github.com/tikv/pd/pkg/errs"
 	"github.com/tikv/pd/server"
+	"github.com/tikv/pd/server/cluster"
 	"github.com/unrolled/render"
 )
 
@@ -94,6 +99,94 @@ func (h *adminHandler) ResetTS(w http.ResponseWriter, r *http.Request) {
 	h.rd.JSON(w, http.StatusOK, "Reset ts successfully.")
 }
 
+// @Tags admin
+// @Summary Get the recent skew incidents, in which the TSO of this server falls back.
+// @Produce json
+// @Success 200 {array} tso.SkewIncident
+// @Router /admin/tso/skew-incidents [get]
+func (h *adminHandler) GetTSOSkewIncidents(w http.ResponseWriter, r *http.Request) {
+	h.rd.JSON(w, http.StatusOK, h.svr.GetTSOAllocatorManager().GetSkewIncidents())
+}
+
+// @Tags admin
+// @Summary Move a PD member to another dc-location at runtime.
+// @Accept json
+// @Param name path string true "PD server name"
+// @Param body body object true "json params"
+// @Produce json
+// @Success 200 {string} string "The dc-location of the member is updated."
+// @Failure 400 {string} string "The input is invalid."
+// @Failure 404 {string} string "The member does not exist."
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /admin/tso/dc-location/members/{name} [post]
+func (h *adminHandler) SetMemberDCLocation(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		DCLocation string `json:"dc-location"`
+	}
+	if err := apiutil.ReadJSONRespondError(h.rd, w, r.Body, &input); err != nil {
+		return
+	}
+	if len(input.DCLocation) == 0 {
+		h.rd.JSON(w, http.StatusBadRequest, "invalid dc-location")
+		return
+	}
+	req := &pdpb.GetMembersRequest{Header: &pdpb.RequestHeader{ClusterId: h.svr.ClusterID()}}
+	members, err := h.svr.GetMembers(r.Context(), req)
+	if err != nil {
+		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	var memberID uint64
+	name := mux.Vars(r)["name"]
+	for _, m := range members.GetMembers() {
+		if m.GetName() == name {
+			memberID = m.GetMemberId()
+			break
+		}
+	}
+	if memberID == 0 {
+		h.rd.JSON(w, http.StatusNotFound, fmt.Sprintf("not found, pd: %s", name))
+		return
+	}
+	if err := h.svr.GetTSOAllocatorManager().SetMemberDCLocation(memberID, input.DCLocation); err != nil {
+		if errs.ErrInvalidDCLocation.Equal(err) {
+			h.rd.JSON(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, "The dc-location of the member is updated.")
+}
+
+// @Tags admin
+// @Summary Remove a dc-location without any member and reclaim its Local TSO suffix.
+// @Param dc_location path string true "The dc-location"
+// @Produce json
+// @Success 200 {string} string "The dc-location is removed."
+// @Failure 400 {string} string "The dc-location can't be removed."
+// @Failure 404 {string} string "The dc-location does not exist."
+// @Failure 409 {string} string "The dc-location is still in use, retry later."
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /admin/tso/dc-location/{dc_location} [delete]
+func (h *adminHandler) RemoveDCLocation(w http.ResponseWriter, r *http.Request) {
+	dcLocation := mux.Vars(r)["dc_location"]
+	if err := h.svr.GetTSOAllocatorManager().RemoveDCLocation(dcLocation); err != nil {
+		switch {
+		case errs.ErrInvalidDCLocation.Equal(err):
+			h.rd.JSON(w, http.StatusBadRequest, err.Error())
+		case errs.ErrDCLocationNotFound.Equal(err):
+			h.rd.JSON(w, http.StatusNotFound, err.Error())
+		case errs.ErrDCLocationInUse.Equal(err):
+			h.rd.JSON(w, http.StatusConflict, err.Error())
+		default:
+			h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, "The dc-location is removed.")
+}
+
 // Intentionally no swagger mark as it is supposed to be only used in
 // server-to-server.
 func (h *adminHandler) persistFile(w http.ResponseWriter, r *http.Request) {
@@ -141,3 +234,56 @@ func (h *adminHandler) UpdateWaitAsyncTime(w http.ResponseWriter, r *http.Reques
 	cluster.GetReplicationMode().UpdateMemberWaitAsyncTime(memberID)
 	h.rd.JSON(w, http.StatusOK, nil)
 }
+
+// @Tags admin
+// @Summary Remove failed stores unsafely and recover the regions that lost the majority of replicas. The recovery fails if it is not finished in the timeout, which is in seconds and 600 by default. It is disabled until the store heartbeats carry the store reports and the recovery plans.
+// @Accept json
+// @Param body body object true "json params"
+// @Produce json
+// @Success 200 {string} string "Request has been accepted."
+// @Failure 400 {string} string "The input is invalid."
+// @Router /admin/unsafe/remove-failed-stores [post]
+func (h *adminHandler) RemoveFailedStoresUnsafely(w http.ResponseWriter, r *http.Request) {
+	rc := getCluster(r.Context())
+	var input struct {
+		Stores  []uint64 `json:"stores"`
+		Timeout *uint64  `json:"timeout"`
+	}
+	if err := apiutil.ReadJSONRespondError(h.rd, w, r.Body, &input); err != nil {
+		return
+	}
+	timeout := cluster.DefaultUnsafeRecoveryTimeout
+	if input.Timeout != nil {
+		timeout = time.Duration(*input.Timeout) * time.Second
+	}
+	if err := rc.GetUnsafeRecoveryController().RemoveFailedStores(input.Stores, timeout); err != nil {
+		h.rd.JSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, "Request has been accepted.")
+}
+
+// @Tags admin
+// @Summary Abort the current unsafe recovery.
+// @Produce json
+// @Success 200 {string} string "The recovery is aborted."
+// @Failure 400 {string} string "There is no recovery in progress."
+// @Router /admin/unsafe/remove-failed-stores/abort [post]
+func (h *adminHandler) AbortUnsafeRecovery(w http.ResponseWriter, r *http.Request) {
+	rc := getCluster(r.Context())
+	if err := rc.GetUnsafeRecoveryController().Abort(); err != nil {
+		h.rd.JSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, "The recovery is aborted.")
+}
+
+// @Tags admin
+// @Summary Show the progress of the current unsafe recovery, or the report of the last one.
+// @Produce json
+// @Success 200 {object} cluster.UnsafeRecoveryProgress
+// @Router /admin/unsafe/remove-failed-stores/show [get]
+func (h *adminHandler) GetUnsafeRecoveryProgress(w http.ResponseWriter, r *http.Request) {
+	rc := getCluster(r.Context())
+	h.rd.JSON(w, http.StatusOK, rc.GetUnsafeRecoveryController().Show())
+}
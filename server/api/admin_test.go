@@ -23,7 +23,9 @@ import (
 	. "github.com/pingcap/check"
 	"github.com/pingcap/kvproto/pkg/metapb"
 	"github.com/tikv/pd/server"
+	"github.com/tikv/pd/server/cluster"
 	"github.com/tikv/pd/server/core"
+	"github.com/tikv/pd/server/tso"
 )
 
 var _ = Suite(&testAdminSuite{})
@@ -83,6 +85,18 @@ func (s *testAdminSuite) TestDropRegion(c *C) {
 	c.Assert(region.GetRegionEpoch().Version, Equals, uint64(50))
 }
 
+func (s *testAdminSuite) TestUnsafeRecovery(c *C) {
+	url := s.urlPrefix + "/admin/unsafe/remove-failed-stores"
+	c.Assert(postJSON(testDialClient, url, []byte(`{"stores": []}`)), NotNil)
+	c.Assert(postJSON(testDialClient, url, []byte(`{"stores": [100]}`)), NotNil)
+	c.Assert(postJSON(testDialClient, url, []byte(`{"stores": [1], "timeout": 0}`)), NotNil)
+	c.Assert(postJSON(testDialClient, url+"/abort", nil), NotNil)
+
+	var progress cluster.UnsafeRecoveryProgress
+	c.Assert(readJSON(testDialClient, url+"/show", &progress), IsNil)
+	c.Assert(progress.Stage, Equals, cluster.UnsafeRecoveryIdle)
+}
+
 var _ = Suite(&testTSOSuite{})
 
 type testTSOSuite struct {
@@ -158,3 +172,42 @@ func (s *testTSOSuite) TestResetTS(c *C) {
 	c.Assert(err, NotNil)
 	c.Assert(err.Error(), Equals, "\"invalid tso value\"\n")
 }
+
+func (s *testTSOSuite) TestSkewIncidents(c *C) {
+	url := fmt.Sprintf("%s%s/api/v1/admin/tso/skew-incidents", s.svr.GetAddr(), apiPrefix)
+	var incidents []*tso.SkewIncident
+	c.Assert(readJSON(testDialClient, url, &incidents), IsNil)
+	c.Assert(incidents, HasLen, 0)
+}
+
+func (s *testTSOSuite) TestDCLocation(c *C) {
+	urlPrefix := fmt.Sprintf("%s%s/api/v1/admin/tso/dc-location", s.svr.GetAddr(), apiPrefix)
+	values, err := json.Marshal(map[string]string{"dc-location": "dc-1"})
+	c.Assert(err, IsNil)
+	err = postJSON(testDialClient, urlPrefix+"/members/"+s.svr.Name(), values,
+		func(_ []byte, code int) { c.Assert(code, Equals, http.StatusOK) })
+	c.Assert(err, IsNil)
+	c.Assert(s.svr.GetTSOAllocatorManager().GetClusterDCLocations()["dc-1"], DeepEquals, []uint64{s.svr.GetMember().ID()})
+
+	err = postJSON(testDialClient, urlPrefix+"/members/unknown", values,
+		func(_ []byte, code int) { c.Assert(code, Equals, http.StatusNotFound) })
+	c.Assert(err, NotNil)
+	err = postJSON(testDialClient, urlPrefix+"/members/"+s.svr.Name(), []byte(`{}`),
+		func(_ []byte, code int) { c.Assert(code, Equals, http.StatusBadRequest) })
+	c.Assert(err, NotNil)
+	err = postJSON(testDialClient, urlPrefix+"/members/"+s.svr.Name(), []byte(`{"dc-location":"global"}`),
+		func(_ []byte, code int) { c.Assert(code, Equals, http.StatusBadRequest) })
+	c.Assert(err, NotNil)
+
+	// The dc-location still having members can't be removed.
+	for dcLocation, code := range map[string]int{
+		"dc-1":   http.StatusConflict,
+		"dc-2":   http.StatusNotFound,
+		"global": http.StatusBadRequest,
+	} {
+		res, err := doDelete(testDialClient, urlPrefix+"/"+dcLocation)
+		c.Assert(err, IsNil)
+		c.Assert(res.StatusCode, Equals, code)
+		res.Body.Close()
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/tikv/pd/pkg/errs"
+	"github.com/tikv/pd/server"
+	"github.com/unrolled/render"
+)
+
+const defaultDiagnoseRegionLimit = 1024
+
+type checkerHandler struct {
+	*server.Handler
+	r *render.Render
+}
+
+func newCheckerHandler(svr *server.Server, r *render.Render) *checkerHandler {
+	return &checkerHandler{
+		Handler: svr.GetHandler(),
+		r:       r,
+	}
+}
+
+// @Tags checker
+// @Summary Run the checkers once against the regions from the start of the key space, and return the operators without dispatching them.
+// @Param limit query integer false "The max number of regions to check." default(1024)
+// @Param {config-item} query string false "A schedule config item to use instead of the current one, such as replica-schedule-limit."
+// @Produce json
+// @Success 200 {object} cluster.CheckerDiagnosis
+// @Failure 400 {string} string "Bad format request."
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /checkers/diagnose [get]
+func (h *checkerHandler) Diagnose(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	limit := defaultDiagnoseRegionLimit
+	if v := query.Get("limit"); v != "" {
+		var err error
+		if limit, err = strconv.Atoi(v); err != nil || limit <= 0 {
+			h.r.JSON(w, http.StatusBadRequest, "limit should be a positive integer")
+			return
+		}
+	}
+	diagnosis, err := h.DiagnoseCheckers(limit, diagnoseOverrides(query, "limit"))
+	if err != nil {
+		if errs.ErrDiagnoseConfig.Equal(err) {
+			h.r.JSON(w, http.StatusBadRequest, err.Error())
+		} else {
+			h.r.JSON(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+	h.r.JSON(w, http.StatusOK, diagnosis)
+}
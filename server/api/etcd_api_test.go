@@ -26,6 +26,9 @@ var _ = Suite(&testEtcdAPISuite{})
 type testEtcdAPISuite struct{}
 
 func (s *testEtcdAPISuite) TestGRPCGateway(c *C) {
+	if standalone {
+		c.Skip("the grpc gateway is served by the embedded etcd")
+	}
 	svr, clean := mustNewServer(c)
 	defer clean()
 
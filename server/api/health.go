@@ -47,8 +47,7 @@ func newHealthHandler(svr *server.Server, rd *render.Render) *healthHandler {
 // @Failure 500 {string} string "PD server failed to proceed the request."
 // @Router /health [get]
 func (h *healthHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	client := h.svr.GetClient()
-	members, err := cluster.GetMembers(client)
+	members, err := h.svr.GetMember().GetMembers()
 	if err != nil {
 		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
 		return
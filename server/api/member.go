@@ -25,7 +25,6 @@ import (
 	"github.com/pingcap/log"
 	"github.com/tikv/pd/pkg/apiutil"
 	"github.com/tikv/pd/pkg/errs"
-	"github.com/tikv/pd/pkg/etcdutil"
 	"github.com/tikv/pd/server"
 	"github.com/unrolled/render"
 	"go.uber.org/zap"
@@ -105,19 +104,17 @@ func (h *memberHandler) getMembers() (*pdpb.GetMembersResponse, error) {
 // @Failure 500 {string} string "PD server failed to proceed the request."
 // @Router /members/name/{name} [delete]
 func (h *memberHandler) DeleteByName(w http.ResponseWriter, r *http.Request) {
-	client := h.svr.GetClient()
-
 	// Get etcd ID by name.
 	var id uint64
 	name := mux.Vars(r)["name"]
-	listResp, err := etcdutil.ListEtcdMembers(client)
+	members, err := h.svr.GetMember().GetMembers()
 	if err != nil {
 		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
 		return
 	}
-	for _, m := range listResp.Members {
-		if name == m.Name {
-			id = m.ID
+	for _, m := range members {
+		if name == m.GetName() {
+			id = m.GetMemberId()
 			break
 		}
 	}
@@ -141,7 +138,7 @@ func (h *memberHandler) DeleteByName(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Remove member by id
-	_, err = etcdutil.RemoveEtcdMember(client, id)
+	err = h.svr.GetMember().RemoveMember(id)
 	if err != nil {
 		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
 		return
@@ -179,8 +176,7 @@ func (h *memberHandler) DeleteByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	client := h.svr.GetClient()
-	_, err = etcdutil.RemoveEtcdMember(client, id)
+	err = h.svr.GetMember().RemoveMember(id)
 	if err != nil {
 		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
 		return
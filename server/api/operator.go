@@ -16,6 +16,7 @@ package api
 import (
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/tikv/pd/pkg/apiutil"
@@ -107,6 +108,52 @@ func (h *operatorHandler) List(w http.ResponseWriter, r *http.Request) {
 	h.r.JSON(w, http.StatusOK, results)
 }
 
+// @Tags operator
+// @Summary List the records of operators in the journal.
+// @Param region_id query integer false "A Region's Id"
+// @Param store_id query integer false "A Store's Id"
+// @Param since query integer false "From Unix timestamp"
+// @Produce json
+// @Success 200 {array} schedule.OperatorRecord
+// @Failure 400 {string} string "The input is invalid."
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /operators/records [get]
+func (h *operatorHandler) Records(w http.ResponseWriter, r *http.Request) {
+	var (
+		regionID, storeID uint64
+		since             time.Time
+		err               error
+	)
+	query := r.URL.Query()
+	if v := query.Get("region_id"); v != "" {
+		if regionID, err = strconv.ParseUint(v, 10, 64); err != nil {
+			h.r.JSON(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	if v := query.Get("store_id"); v != "" {
+		if storeID, err = strconv.ParseUint(v, 10, 64); err != nil {
+			h.r.JSON(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	if v := query.Get("since"); v != "" {
+		sinceInt, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			h.r.JSON(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		since = time.Unix(sinceInt, 0)
+	}
+
+	records, err := h.GetOperatorRecords(regionID, storeID, since)
+	if err != nil {
+		h.r.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.r.JSON(w, http.StatusOK, records)
+}
+
 // FIXME: details of input json body params
 // @Tags operator
 // @Summary Create an operator.
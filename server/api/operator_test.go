@@ -19,15 +19,18 @@ import (
 	"fmt"
 	"io/ioutil"
 	"strings"
+	"time"
 
 	. "github.com/pingcap/check"
 	"github.com/pingcap/failpoint"
 	"github.com/pingcap/kvproto/pkg/metapb"
 	"github.com/pingcap/kvproto/pkg/pdpb"
 	"github.com/tikv/pd/pkg/mock/mockhbstream"
+	"github.com/tikv/pd/pkg/testutil"
 	"github.com/tikv/pd/server"
 	"github.com/tikv/pd/server/config"
 	"github.com/tikv/pd/server/core"
+	"github.com/tikv/pd/server/schedule"
 	pdoperator "github.com/tikv/pd/server/schedule/operator"
 	"github.com/tikv/pd/server/schedule/placement"
 	"github.com/tikv/pd/server/versioninfo"
@@ -141,6 +144,35 @@ func (s *testOperatorSuite) TestMergeRegionOperator(c *C) {
 	c.Assert(err, NotNil)
 }
 
+func (s *testOperatorSuite) TestRecords(c *C) {
+	mustPutStore(c, s.svr, 1, metapb.StoreState_Up, nil)
+	mustPutStore(c, s.svr, 5, metapb.StoreState_Up, nil)
+	r := newTestRegionInfo(40, 1, []byte("x"), []byte("y"), core.SetRegionConfVer(10), core.SetRegionVersion(10))
+	mustRegionHeartbeat(c, s.svr, r)
+
+	err := postJSON(testDialClient, fmt.Sprintf("%s/operators", s.urlPrefix), []byte(`{"name":"add-peer", "region_id": 40, "store_id": 5}`))
+	c.Assert(err, IsNil)
+	_, err = doDelete(testDialClient, fmt.Sprintf("%s/operators/40", s.urlPrefix))
+	c.Assert(err, IsNil)
+
+	var records []*schedule.OperatorRecord
+	testutil.WaitUntil(c, func(c *C) bool {
+		c.Assert(readJSON(testDialClient, fmt.Sprintf("%s/operators/records?region_id=40", s.urlPrefix), &records), IsNil)
+		return len(records) == 3
+	})
+	c.Assert(records[0].Event, Equals, schedule.OperatorEventCreate)
+	c.Assert(records[0].Source, Equals, "admin-add-peer")
+	c.Assert(records[1].Event, Equals, schedule.OperatorEventStep)
+	c.Assert(records[2].Event, Equals, schedule.OperatorEventCancel)
+
+	c.Assert(readJSON(testDialClient, fmt.Sprintf("%s/operators/records?store_id=5", s.urlPrefix), &records), IsNil)
+	c.Assert(records, HasLen, 3)
+	since := time.Now().Add(time.Hour).Unix()
+	c.Assert(readJSON(testDialClient, fmt.Sprintf("%s/operators/records?region_id=40&since=%d", s.urlPrefix, since), &records), IsNil)
+	c.Assert(records, HasLen, 0)
+	c.Assert(readJSON(testDialClient, fmt.Sprintf("%s/operators/records?region_id=x", s.urlPrefix), &records), NotNil)
+}
+
 type testTransferRegionOperatorSuite struct {
 	svr       *server.Server
 	cleanup   cleanUpFunc
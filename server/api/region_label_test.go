@@ -0,0 +1,104 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+
+	. "github.com/pingcap/check"
+	"github.com/tikv/pd/server"
+	"github.com/tikv/pd/server/schedule/labeler"
+)
+
+var _ = Suite(&testRegionLabelSuite{})
+
+type testRegionLabelSuite struct {
+	svr       *server.Server
+	cleanup   cleanUpFunc
+	urlPrefix string
+}
+
+func (s *testRegionLabelSuite) SetUpSuite(c *C) {
+	s.svr, s.cleanup = mustNewServer(c)
+	mustWaitLeader(c, []*server.Server{s.svr})
+
+	addr := s.svr.GetAddr()
+	s.urlPrefix = fmt.Sprintf("%s%s/api/v1/config/region-label", addr, apiPrefix)
+
+	mustBootstrapCluster(c, s.svr)
+}
+
+func (s *testRegionLabelSuite) TearDownSuite(c *C) {
+	s.cleanup()
+}
+
+func (s *testRegionLabelSuite) TestGetSet(c *C) {
+	var resp []*labeler.LabelRule
+	err := readJSON(testDialClient, s.urlPrefix+"/rules", &resp)
+	c.Assert(err, IsNil)
+	c.Assert(resp, HasLen, 0)
+
+	rules := []*labeler.LabelRule{
+		{ID: "rule1", Labels: []labeler.RegionLabel{{Key: "k1", Value: "v1"}}, StartKeyHex: "1234", EndKeyHex: "5678"},
+		{ID: "rule2/a/b", Labels: []labeler.RegionLabel{{Key: "k2", Value: "v2"}}, StartKeyHex: "ab12", EndKeyHex: "cd12"},
+		{ID: "rule3", Labels: []labeler.RegionLabel{{Key: "k3", Value: "v3"}}, StartKeyHex: "abcd", EndKeyHex: ""},
+	}
+	for _, rule := range rules {
+		data, _ := json.Marshal(rule)
+		err = postJSON(testDialClient, s.urlPrefix+"/rule", data)
+		c.Assert(err, IsNil)
+	}
+	for i, id := range []string{"rule1", "rule2/a/b", "rule3"} {
+		var rule labeler.LabelRule
+		err = readJSON(testDialClient, s.urlPrefix+"/rule/"+url.QueryEscape(id), &rule)
+		c.Assert(err, IsNil)
+		c.Assert(rule.ID, Equals, rules[i].ID)
+		c.Assert(rule.Labels, DeepEquals, rules[i].Labels)
+		c.Assert(rule.StartKeyHex, Equals, rules[i].StartKeyHex)
+		c.Assert(rule.EndKeyHex, Equals, rules[i].EndKeyHex)
+	}
+
+	err = readJSON(testDialClient, s.urlPrefix+"/rules", &resp)
+	c.Assert(err, IsNil)
+	c.Assert(resp, HasLen, 3)
+	sort.Slice(resp, func(i, j int) bool { return resp[i].ID < resp[j].ID })
+	c.Assert(resp[0].ID, Equals, "rule1")
+
+	// Invalid rule content.
+	data, _ := json.Marshal(&labeler.LabelRule{ID: "rule4", StartKeyHex: "xxxx"})
+	err = postJSON(testDialClient, s.urlPrefix+"/rule", data)
+	c.Assert(err, NotNil)
+
+	res, err := doDelete(testDialClient, s.urlPrefix+"/rule/"+url.QueryEscape("rule2/a/b"))
+	c.Assert(err, IsNil)
+	c.Assert(res.StatusCode, Equals, http.StatusOK)
+	res, err = doDelete(testDialClient, s.urlPrefix+"/rule/"+url.QueryEscape("rule2/a/b"))
+	c.Assert(err, IsNil)
+	c.Assert(res.StatusCode, Equals, http.StatusNotFound)
+	err = readJSON(testDialClient, s.urlPrefix+"/rules", &resp)
+	c.Assert(err, IsNil)
+	c.Assert(resp, HasLen, 2)
+
+	// Get labels of a region.
+	r := newTestRegionInfo(2, 1, []byte{0x12, 0x34, 0x56}, []byte{0x23})
+	mustRegionHeartbeat(c, s.svr, r)
+	var labels []*labeler.RegionLabel
+	err = readJSON(testDialClient, s.urlPrefix+"/region/2", &labels)
+	c.Assert(err, IsNil)
+	c.Assert(labels, DeepEquals, []*labeler.RegionLabel{{Key: "k1", Value: "v1"}})
+}
@@ -59,6 +59,7 @@ func createRouter(ctx context.Context, prefix string, svr *server.Server) *mux.R
 	operatorHandler := newOperatorHandler(handler, rd)
 	apiRouter.HandleFunc("/operators", operatorHandler.List).Methods("GET")
 	apiRouter.HandleFunc("/operators", operatorHandler.Post).Methods("POST")
+	apiRouter.HandleFunc("/operators/records", operatorHandler.Records).Methods("GET")
 	apiRouter.HandleFunc("/operators/{region_id}", operatorHandler.Get).Methods("GET")
 	apiRouter.HandleFunc("/operators/{region_id}", operatorHandler.Delete).Methods("DELETE")
 
@@ -67,6 +68,10 @@ func createRouter(ctx context.Context, prefix string, svr *server.Server) *mux.R
 	apiRouter.HandleFunc("/schedulers", schedulerHandler.Post).Methods("POST")
 	apiRouter.HandleFunc("/schedulers/{name}", schedulerHandler.Delete).Methods("DELETE")
 	apiRouter.HandleFunc("/schedulers/{name}", schedulerHandler.PauseOrResume).Methods("POST")
+	apiRouter.HandleFunc("/schedulers/{name}/diagnose", schedulerHandler.Diagnose).Methods("GET")
+
+	checkerHandler := newCheckerHandler(svr, rd)
+	apiRouter.HandleFunc("/checkers/diagnose", checkerHandler.Diagnose).Methods("GET")
 
 	schedulerConfigHandler := newSchedulerConfigHandler(svr, rd)
 	apiRouter.PathPrefix("/scheduler-config").Handler(schedulerConfigHandler)
@@ -106,6 +111,11 @@ func createRouter(ctx context.Context, prefix string, svr *server.Server) *mux.R
 	clusterRouter.HandleFunc("/config/rule_group/{id}", rulesHandler.DeleteGroupConfig).Methods("DELETE")
 	clusterRouter.HandleFunc("/config/rule_groups", rulesHandler.GetAllGroupConfigs).Methods("GET")
 
+	clusterRouter.HandleFunc("/config/rule_templates", rulesHandler.GetAllTemplates).Methods("GET")
+	clusterRouter.HandleFunc("/config/rule_template/{name}", rulesHandler.GetTemplate).Methods("GET")
+	clusterRouter.HandleFunc("/config/rule_template", rulesHandler.SetTemplate).Methods("POST")
+	clusterRouter.HandleFunc("/config/rule_template/{name}", rulesHandler.DeleteTemplate).Methods("DELETE")
+
 	clusterRouter.HandleFunc("/config/placement-rule", rulesHandler.GetAllGroupBundles).Methods("GET")
 	clusterRouter.HandleFunc("/config/placement-rule", rulesHandler.SetAllGroupBundles).Methods("POST")
 	// {group} can be a regular expression, we should enable path encode to
@@ -115,6 +125,13 @@ func createRouter(ctx context.Context, prefix string, svr *server.Server) *mux.R
 	clusterRouter.HandleFunc("/config/placement-rule/{group}", rulesHandler.SetGroupBundle).Methods("POST")
 	escapeRouter.HandleFunc("/config/placement-rule/{group}", rulesHandler.DeleteGroupBundle).Methods("DELETE")
 
+	regionLabelHandler := newRegionLabelHandler(svr, rd)
+	clusterRouter.HandleFunc("/config/region-label/rules", regionLabelHandler.GetAllRules).Methods("GET")
+	escapeRouter.HandleFunc("/config/region-label/rule/{id}", regionLabelHandler.GetRule).Methods("GET")
+	clusterRouter.HandleFunc("/config/region-label/rule", regionLabelHandler.SetRule).Methods("POST")
+	escapeRouter.HandleFunc("/config/region-label/rule/{id}", regionLabelHandler.DeleteRule).Methods("DELETE")
+	clusterRouter.HandleFunc("/config/region-label/region/{id}", regionLabelHandler.GetRegionLabels).Methods("GET")
+
 	storeHandler := newStoreHandler(handler, rd)
 	clusterRouter.HandleFunc("/store/{id}", storeHandler.Get).Methods("GET")
 	clusterRouter.HandleFunc("/store/{id}", storeHandler.Delete).Methods("DELETE")
@@ -194,8 +211,14 @@ func createRouter(ctx context.Context, prefix string, svr *server.Server) *mux.R
 	adminHandler := newAdminHandler(svr, rd)
 	clusterRouter.HandleFunc("/admin/cache/region/{id}", adminHandler.HandleDropCacheRegion).Methods("DELETE")
 	clusterRouter.HandleFunc("/admin/reset-ts", adminHandler.ResetTS).Methods("POST")
+	apiRouter.HandleFunc("/admin/tso/skew-incidents", adminHandler.GetTSOSkewIncidents).Methods("GET")
+	apiRouter.HandleFunc("/admin/tso/dc-location/members/{name}", adminHandler.SetMemberDCLocation).Methods("POST")
+	apiRouter.HandleFunc("/admin/tso/dc-location/{dc_location}", adminHandler.RemoveDCLocation).Methods("DELETE")
 	apiRouter.HandleFunc("/admin/persist-file/{file_name}", adminHandler.persistFile).Methods("POST")
 	clusterRouter.HandleFunc("/admin/replication_mode/wait-async", adminHandler.UpdateWaitAsyncTime).Methods("POST")
+	clusterRouter.HandleFunc("/admin/unsafe/remove-failed-stores", adminHandler.RemoveFailedStoresUnsafely).Methods("POST")
+	clusterRouter.HandleFunc("/admin/unsafe/remove-failed-stores/show", adminHandler.GetUnsafeRecoveryProgress).Methods("GET")
+	clusterRouter.HandleFunc("/admin/unsafe/remove-failed-stores/abort", adminHandler.AbortUnsafeRecovery).Methods("POST")
 
 	logHandler := newLogHandler(svr, rd)
 	apiRouter.HandleFunc("/admin/log", logHandler.Handle).Methods("POST")
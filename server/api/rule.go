@@ -25,6 +25,7 @@ import (
 	"github.com/pingcap/errors"
 	"github.com/tikv/pd/pkg/apiutil"
 	"github.com/tikv/pd/pkg/codec"
+	"github.com/tikv/pd/pkg/errs"
 	"github.com/tikv/pd/server"
 	"github.com/tikv/pd/server/core"
 	"github.com/tikv/pd/server/schedule/placement"
@@ -87,7 +88,7 @@ func (h *ruleHandler) SetAll(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 	if err := cluster.GetRuleManager().SetRules(rules); err != nil {
-		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		h.rd.JSON(w, ruleErrorStatus(err), err.Error())
 		return
 	}
 	h.rd.JSON(w, http.StatusOK, "Update rules successfully.")
@@ -215,7 +216,7 @@ func (h *ruleHandler) Set(w http.ResponseWriter, r *http.Request) {
 	}
 	oldRule := cluster.GetRuleManager().GetRule(rule.GroupID, rule.ID)
 	if err := cluster.GetRuleManager().SetRule(&rule); err != nil {
-		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		h.rd.JSON(w, ruleErrorStatus(err), err.Error())
 		return
 	}
 	cluster.AddSuspectKeyRange(rule.StartKey, rule.EndKey)
@@ -273,7 +274,7 @@ func (h *ruleHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	group, id := mux.Vars(r)["group"], mux.Vars(r)["id"]
 	rule := cluster.GetRuleManager().GetRule(group, id)
 	if err := cluster.GetRuleManager().DeleteRule(group, id); err != nil {
-		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		h.rd.JSON(w, ruleErrorStatus(err), err.Error())
 		return
 	}
 	if rule != nil {
@@ -312,7 +313,7 @@ func (h *ruleHandler) Batch(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 	if err := cluster.GetRuleManager().Batch(opts); err != nil {
-		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		h.rd.JSON(w, ruleErrorStatus(err), err.Error())
 		return
 	}
 	h.rd.JSON(w, http.StatusOK, "Batch operations successfully.")
@@ -362,7 +363,7 @@ func (h *ruleHandler) SetGroupConfig(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	if err := cluster.GetRuleManager().SetRuleGroup(&ruleGroup); err != nil {
-		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		h.rd.JSON(w, ruleErrorStatus(err), err.Error())
 		return
 	}
 	for _, r := range cluster.GetRuleManager().GetRulesByGroup(ruleGroup.ID) {
@@ -388,7 +389,7 @@ func (h *ruleHandler) DeleteGroupConfig(w http.ResponseWriter, r *http.Request)
 	id := mux.Vars(r)["id"]
 	err := cluster.GetRuleManager().DeleteRuleGroup(id)
 	if err != nil {
-		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		h.rd.JSON(w, ruleErrorStatus(err), err.Error())
 		return
 	}
 	for _, r := range cluster.GetRuleManager().GetRulesByGroup(id) {
@@ -465,7 +466,7 @@ func (h *ruleHandler) SetAllGroupBundles(w http.ResponseWriter, r *http.Request)
 	}
 	_, partial := r.URL.Query()["partial"]
 	if err := cluster.GetRuleManager().SetAllGroupBundles(groups, !partial); err != nil {
-		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		h.rd.JSON(w, ruleErrorStatus(err), err.Error())
 		return
 	}
 	h.rd.JSON(w, http.StatusOK, "Update rules and groups successfully.")
@@ -511,7 +512,7 @@ func (h *ruleHandler) DeleteGroupBundle(w http.ResponseWriter, r *http.Request)
 	}
 	_, regex := r.URL.Query()["regexp"]
 	if err := cluster.GetRuleManager().DeleteGroupBundle(group, regex); err != nil {
-		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		h.rd.JSON(w, ruleErrorStatus(err), err.Error())
 		return
 	}
 	h.rd.JSON(w, http.StatusOK, "Delete group and rules successfully.")
@@ -557,8 +558,110 @@ func (h *ruleHandler) SetGroupBundle(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 	if err := cluster.GetRuleManager().SetGroupBundle(group); err != nil {
-		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		h.rd.JSON(w, ruleErrorStatus(err), err.Error())
 		return
 	}
 	h.rd.JSON(w, http.StatusOK, "Update group and rules successfully.")
 }
+
+// @Tags rule
+// @Summary List all rule templates.
+// @Produce json
+// @Success 200 {array} placement.RuleTemplate
+// @Failure 412 {string} string "Placement rules feature is disabled."
+// @Router /config/rule_templates [get]
+func (h *ruleHandler) GetAllTemplates(w http.ResponseWriter, r *http.Request) {
+	cluster := getCluster(r.Context())
+	if !cluster.GetOpts().IsPlacementRulesEnabled() {
+		h.rd.JSON(w, http.StatusPreconditionFailed, errPlacementDisabled.Error())
+		return
+	}
+	templates := cluster.GetRuleManager().GetRuleTemplates()
+	h.rd.JSON(w, http.StatusOK, templates)
+}
+
+// @Tags rule
+// @Summary Get rule template by name.
+// @Param name path string true "Template name"
+// @Produce json
+// @Success 200 {object} placement.RuleTemplate
+// @Failure 404 {string} string "The template does not exist."
+// @Failure 412 {string} string "Placement rules feature is disabled."
+// @Router /config/rule_template/{name} [get]
+func (h *ruleHandler) GetTemplate(w http.ResponseWriter, r *http.Request) {
+	cluster := getCluster(r.Context())
+	if !cluster.GetOpts().IsPlacementRulesEnabled() {
+		h.rd.JSON(w, http.StatusPreconditionFailed, errPlacementDisabled.Error())
+		return
+	}
+	template := cluster.GetRuleManager().GetRuleTemplate(mux.Vars(r)["name"])
+	if template == nil {
+		h.rd.JSON(w, http.StatusNotFound, nil)
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, template)
+}
+
+// @Tags rule
+// @Summary Update rule template. All rules referencing the template are updated at once, and the update is rejected if they do not fit the current stores.
+// @Accept json
+// @Param template body placement.RuleTemplate true "Parameters of rule template"
+// @Produce json
+// @Success 200 {string} string "Update rule template successfully."
+// @Failure 400 {string} string "The input is invalid."
+// @Failure 412 {string} string "Placement rules feature is disabled."
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /config/rule_template [post]
+func (h *ruleHandler) SetTemplate(w http.ResponseWriter, r *http.Request) {
+	cluster := getCluster(r.Context())
+	if !cluster.GetOpts().IsPlacementRulesEnabled() {
+		h.rd.JSON(w, http.StatusPreconditionFailed, errPlacementDisabled.Error())
+		return
+	}
+	var template placement.RuleTemplate
+	if err := apiutil.ReadJSONRespondError(h.rd, w, r.Body, &template); err != nil {
+		return
+	}
+	if err := cluster.GetRuleManager().SetRuleTemplate(&template, cluster); err != nil {
+		h.rd.JSON(w, ruleErrorStatus(err), err.Error())
+		return
+	}
+	for _, r := range cluster.GetRuleManager().GetRulesByTemplate(template.Name) {
+		cluster.AddSuspectKeyRange(r.StartKey, r.EndKey)
+	}
+	h.rd.JSON(w, http.StatusOK, "Update rule template successfully.")
+}
+
+// @Tags rule
+// @Summary Delete rule template. It fails if the template is referenced by any rule or rule group.
+// @Param name path string true "Template name"
+// @Produce json
+// @Success 200 {string} string "Delete rule template successfully."
+// @Failure 409 {string} string "The template is referenced by a rule or a rule group."
+// @Failure 412 {string} string "Placement rules feature is disabled."
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /config/rule_template/{name} [delete]
+func (h *ruleHandler) DeleteTemplate(w http.ResponseWriter, r *http.Request) {
+	cluster := getCluster(r.Context())
+	if !cluster.GetOpts().IsPlacementRulesEnabled() {
+		h.rd.JSON(w, http.StatusPreconditionFailed, errPlacementDisabled.Error())
+		return
+	}
+	if err := cluster.GetRuleManager().DeleteRuleTemplate(mux.Vars(r)["name"]); err != nil {
+		h.rd.JSON(w, ruleErrorStatus(err), err.Error())
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, "Delete rule template successfully.")
+}
+
+// ruleErrorStatus returns the status code for an error from updating the rules.
+func ruleErrorStatus(err error) int {
+	switch {
+	case errs.ErrRuleContent.Equal(err), errs.ErrRuleNotFit.Equal(err), errs.ErrRuleTemplateNotFound.Equal(err):
+		return http.StatusBadRequest
+	case errs.ErrRuleTemplateInUse.Equal(err):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
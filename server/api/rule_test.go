@@ -14,6 +14,7 @@
 package api
 
 import (
+	"bytes"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
@@ -780,6 +781,55 @@ func (s *testRuleSuite) TestBundleBadRequest(c *C) {
 	}
 }
 
+func (s *testRuleSuite) TestTemplate(c *C) {
+	template := placement.RuleTemplate{Name: "t1", Role: "voter", Count: 1}
+	data, err := json.Marshal(template)
+	c.Assert(err, IsNil)
+	c.Assert(postJSON(testDialClient, s.urlPrefix+"/rule_template", data), IsNil)
+
+	var t1 placement.RuleTemplate
+	c.Assert(readJSON(testDialClient, s.urlPrefix+"/rule_template/t1", &t1), IsNil)
+	c.Assert(t1, DeepEquals, template)
+	var templates []*placement.RuleTemplate
+	c.Assert(readJSON(testDialClient, s.urlPrefix+"/rule_templates", &templates), IsNil)
+	c.Assert(templates, HasLen, 1)
+
+	// The rule is derived from the template.
+	c.Assert(postJSON(testDialClient, s.urlPrefix+"/rule", []byte(`{"group_id":"e","id":"1","template":"t1","start_key":"11","end_key":"22"}`)), IsNil)
+	var rule placement.Rule
+	c.Assert(readJSON(testDialClient, s.urlPrefix+"/rule/e/1", &rule), IsNil)
+	c.Assert(rule.Role, Equals, placement.Voter)
+	c.Assert(rule.Count, Equals, 1)
+
+	// There are not enough stores for the derived rule.
+	template.Count = 5
+	data, err = json.Marshal(template)
+	c.Assert(err, IsNil)
+	res, err := testDialClient.Post(s.urlPrefix+"/rule_template", "application/json", bytes.NewBuffer(data))
+	c.Assert(err, IsNil)
+	res.Body.Close()
+	c.Assert(res.StatusCode, Equals, http.StatusBadRequest)
+	c.Assert(readJSON(testDialClient, s.urlPrefix+"/rule/e/1", &rule), IsNil)
+	c.Assert(rule.Count, Equals, 1)
+
+	// The rule referencing a missing template is rejected.
+	res, err = testDialClient.Post(s.urlPrefix+"/rule", "application/json", bytes.NewBufferString(`{"group_id":"e","id":"2","template":"t2"}`))
+	c.Assert(err, IsNil)
+	res.Body.Close()
+	c.Assert(res.StatusCode, Equals, http.StatusBadRequest)
+
+	// The template in use cannot be deleted.
+	res, err = doDelete(testDialClient, s.urlPrefix+"/rule_template/t1")
+	c.Assert(err, IsNil)
+	c.Assert(res.StatusCode, Equals, http.StatusConflict)
+	_, err = doDelete(testDialClient, s.urlPrefix+"/rule/e/1")
+	c.Assert(err, IsNil)
+	res, err = doDelete(testDialClient, s.urlPrefix+"/rule_template/t1")
+	c.Assert(err, IsNil)
+	c.Assert(res.StatusCode, Equals, http.StatusOK)
+	c.Assert(readJSON(testDialClient, s.urlPrefix+"/rule_template/t1", &t1), NotNil)
+}
+
 func compareBundle(c *C, b1, b2 placement.GroupBundle) {
 	c.Assert(b1.ID, Equals, b2.ID)
 	c.Assert(b1.Index, Equals, b2.Index)
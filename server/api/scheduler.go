@@ -17,6 +17,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
 
 	"github.com/gorilla/mux"
@@ -195,6 +197,30 @@ func (h *schedulerHandler) Post(w http.ResponseWriter, r *http.Request) {
 			h.r.JSON(w, http.StatusInternalServerError, err.Error())
 			return
 		}
+	case schedulers.EvictSlowStoreName:
+		if err := h.AddEvictSlowStoreScheduler(); err != nil {
+			h.r.JSON(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	case schedulers.SplitHotRegionName:
+		if err := h.AddSplitHotRegionScheduler(); err != nil {
+			h.r.JSON(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	case schedulers.LeaderPreferenceName:
+		preferences, ok := input["preferences"].(string)
+		if !ok {
+			h.r.JSON(w, http.StatusBadRequest, "missing preferences")
+			return
+		}
+		if err := h.AddLeaderPreferenceScheduler(preferences); err != nil {
+			if errs.ErrSchedulerConfig.Equal(err) {
+				h.r.JSON(w, http.StatusBadRequest, err.Error())
+			} else {
+				h.r.JSON(w, http.StatusInternalServerError, err.Error())
+			}
+			return
+		}
 	case schedulers.ShuffleLeaderName:
 		if err := h.AddShuffleLeaderScheduler(); err != nil {
 			h.r.JSON(w, http.StatusInternalServerError, err.Error())
@@ -273,6 +299,8 @@ func (h *schedulerHandler) Delete(w http.ResponseWriter, r *http.Request) {
 func (h *schedulerHandler) handleErr(w http.ResponseWriter, err error) {
 	if errors.ErrorEqual(err, errs.ErrSchedulerNotFound.FastGenByArgs()) {
 		h.r.JSON(w, http.StatusNotFound, err.Error())
+	} else if errs.ErrDiagnoseConfig.Equal(err) {
+		h.r.JSON(w, http.StatusBadRequest, err.Error())
 	} else {
 		h.r.JSON(w, http.StatusInternalServerError, err.Error())
 	}
@@ -322,6 +350,53 @@ func (h *schedulerHandler) PauseOrResume(w http.ResponseWriter, r *http.Request)
 	h.r.JSON(w, http.StatusOK, "Pause or resume the scheduler successfully.")
 }
 
+// @Tags scheduler
+// @Summary Diagnose a scheduler. With dry-run, the scheduler runs once and the operators are returned without being dispatched.
+// @Param name path string true "The name of the scheduler."
+// @Param dry-run query boolean false "Whether to run the scheduler once."
+// @Param {config-item} query string false "A schedule config item to use in the dry run instead of the current one, such as region-schedule-limit."
+// @Produce json
+// @Success 200 {object} cluster.SchedulerDiagnosis
+// @Failure 400 {string} string "Bad format request."
+// @Failure 404 {string} string "The scheduler is not found."
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /schedulers/{name}/diagnose [get]
+func (h *schedulerHandler) Diagnose(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	query := r.URL.Query()
+	var dryRun bool
+	if v := query.Get("dry-run"); v != "" {
+		var err error
+		if dryRun, err = strconv.ParseBool(v); err != nil {
+			h.r.JSON(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	overrides := diagnoseOverrides(query, "dry-run")
+	if !dryRun && len(overrides) > 0 {
+		h.r.JSON(w, http.StatusBadRequest, "config items can only be used with dry-run")
+		return
+	}
+	diagnosis, err := h.DiagnoseScheduler(name, dryRun, overrides)
+	if err != nil {
+		h.handleErr(w, err)
+		return
+	}
+	h.r.JSON(w, http.StatusOK, diagnosis)
+}
+
+// diagnoseOverrides returns the schedule config items in the query, which are
+// all the parameters except the reserved one.
+func diagnoseOverrides(query url.Values, reserved string) map[string]string {
+	overrides := make(map[string]string)
+	for key := range query {
+		if key != reserved {
+			overrides[key] = query.Get(key)
+		}
+	}
+	return overrides
+}
+
 type schedulerConfigHandler struct {
 	svr *server.Server
 	rd  *render.Render
@@ -14,14 +14,22 @@
 package api
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
+	"strings"
 	"time"
 
 	. "github.com/pingcap/check"
 	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/kvproto/pkg/pdpb"
+	"github.com/tikv/pd/pkg/slice"
 	"github.com/tikv/pd/server"
+	"github.com/tikv/pd/server/cluster"
 	"github.com/tikv/pd/server/config"
+	"github.com/tikv/pd/server/schedule/filter"
 	_ "github.com/tikv/pd/server/schedulers"
 )
 
@@ -57,6 +65,15 @@ func (s *testScheduleSuite) TestOriginAPI(c *C) {
 	body, err := json.Marshal(input)
 	c.Assert(err, IsNil)
 	c.Assert(postJSON(testDialClient, addURL, body), IsNil)
+	// The scheduler config is saved with the option.
+	names, _, err := s.svr.GetStorage().LoadAllScheduleConfig()
+	c.Assert(err, IsNil)
+	c.Assert(slice.AnyOf(names, func(i int) bool { return names[i] == "evict-leader-scheduler" }), IsTrue)
+	cfg := &config.Config{}
+	_, err = s.svr.GetStorage().LoadConfig(cfg)
+	c.Assert(err, IsNil)
+	schedulers := cfg.Schedule.Schedulers
+	c.Assert(slice.AnyOf(schedulers, func(i int) bool { return schedulers[i].Type == "evict-leader" }), IsTrue)
 	input1 := make(map[string]interface{})
 	input1["name"] = "evict-leader-scheduler"
 	input1["store_id"] = 2
@@ -88,6 +105,69 @@ func (s *testScheduleSuite) TestOriginAPI(c *C) {
 	c.Assert(r.StatusCode, Equals, 404)
 }
 
+func (s *testScheduleSuite) TestDiagnose(c *C) {
+	input := map[string]interface{}{"name": "balance-region-scheduler"}
+	body, err := json.Marshal(input)
+	c.Assert(err, IsNil)
+	c.Assert(postJSON(testDialClient, s.urlPrefix, body), IsNil)
+	defer s.deleteScheduler("balance-region-scheduler", c)
+
+	diagnoseURL := fmt.Sprintf("%s/%s/diagnose", s.urlPrefix, "balance-region-scheduler")
+	var diagnosis cluster.SchedulerDiagnosis
+	c.Assert(readJSON(testDialClient, diagnoseURL, &diagnosis), IsNil)
+	c.Assert(diagnosis.Name, Equals, "balance-region-scheduler")
+	c.Assert(diagnosis.Paused, IsFalse)
+	c.Assert(diagnosis.ScheduleAllowed, IsTrue)
+	c.Assert(diagnosis.FilteredStores, HasLen, 0)
+
+	// The busy store is rejected by the store state filter.
+	s.storeHeartbeat(c, 2, true)
+	defer s.storeHeartbeat(c, 2, false)
+	c.Assert(readJSON(testDialClient, diagnoseURL+"?dry-run=true", &diagnosis), IsNil)
+	c.Assert(diagnosis.Operators, HasLen, 0)
+	c.Assert(diagnosis.FilteredStores, HasLen, 1)
+	c.Assert(diagnosis.FilteredStores[0], DeepEquals, filter.Record{Action: "filter-source", StoreID: 2, Type: "store-state-busy-filter", Count: 1})
+	// The dry run does not dispatch operators.
+	ops, err := s.svr.GetHandler().GetOperators()
+	c.Assert(err, IsNil)
+	c.Assert(ops, HasLen, 0)
+
+	// The config items only take effect in the dry run.
+	c.Assert(readJSON(testDialClient, diagnoseURL+"?dry-run=true&tolerant-size-ratio=2", &diagnosis), IsNil)
+	c.Assert(diagnosis.FilteredStores, HasLen, 1)
+	c.Assert(s.svr.GetScheduleConfig().TolerantSizeRatio, Not(Equals), 2.0)
+
+	for _, query := range []string{"?dry-run=x", "?dry-run=true&unknown-config=1", "?dry-run=true&region-schedule-limit=x", "?region-schedule-limit=1"} {
+		res, err := testDialClient.Get(diagnoseURL + query)
+		c.Assert(err, IsNil)
+		res.Body.Close()
+		c.Assert(res.StatusCode, Equals, http.StatusBadRequest)
+	}
+	res, err := testDialClient.Get(fmt.Sprintf("%s/%s/diagnose", s.urlPrefix, "unknown-scheduler"))
+	c.Assert(err, IsNil)
+	res.Body.Close()
+	c.Assert(res.StatusCode, Equals, http.StatusNotFound)
+
+	checkerURL := strings.TrimSuffix(s.urlPrefix, "/schedulers") + "/checkers/diagnose"
+	var checkerDiagnosis cluster.CheckerDiagnosis
+	c.Assert(readJSON(testDialClient, checkerURL+"?limit=10&replica-schedule-limit=0", &checkerDiagnosis), IsNil)
+	c.Assert(checkerDiagnosis.Operators, HasLen, 0)
+	for _, query := range []string{"?limit=0", "?limit=x", "?unknown-config=1"} {
+		res, err := testDialClient.Get(checkerURL + query)
+		c.Assert(err, IsNil)
+		res.Body.Close()
+		c.Assert(res.StatusCode, Equals, http.StatusBadRequest)
+	}
+}
+
+func (s *testScheduleSuite) storeHeartbeat(c *C, storeID uint64, busy bool) {
+	_, err := s.svr.StoreHeartbeat(context.Background(), &pdpb.StoreHeartbeatRequest{
+		Header: &pdpb.RequestHeader{ClusterId: s.svr.ClusterID()},
+		Stats:  &pdpb.StoreStats{StoreId: storeID, IsBusy: busy},
+	})
+	c.Assert(err, IsNil)
+}
+
 func (s *testScheduleSuite) TestAPI(c *C) {
 	type arg struct {
 		opt   string
@@ -141,6 +221,38 @@ func (s *testScheduleSuite) TestAPI(c *C) {
 			},
 		},
 		{name: "balance-region-scheduler"},
+		{
+			name: "split-hot-region-scheduler",
+			extraTestFunc: func(name string, c *C) {
+				resp := make(map[string]interface{})
+				listURL := fmt.Sprintf("%s%s%s/%s/list", s.svr.GetAddr(), apiPrefix, server.SchedulerConfigHandlerPath, name)
+				c.Assert(readJSON(testDialClient, listURL, &resp), IsNil)
+				expectMap := map[string]float64{
+					"min-hot-degree":    5,
+					"min-hot-byte-rate": 30 * 1024 * 1024,
+					"min-hot-key-rate":  3000,
+					"split-limit":       4,
+				}
+				for key := range expectMap {
+					c.Assert(resp[key], DeepEquals, expectMap[key])
+				}
+				dataMap := map[string]interface{}{"split-limit": 8.0}
+				expectMap["split-limit"] = 8.0
+				updateURL := fmt.Sprintf("%s%s%s/%s/config", s.svr.GetAddr(), apiPrefix, server.SchedulerConfigHandlerPath, name)
+				body, err := json.Marshal(dataMap)
+				c.Assert(err, IsNil)
+				c.Assert(postJSON(testDialClient, updateURL, body), IsNil)
+				resp = make(map[string]interface{})
+				c.Assert(readJSON(testDialClient, listURL, &resp), IsNil)
+				for key := range expectMap {
+					c.Assert(resp[key], DeepEquals, expectMap[key])
+				}
+				// invalid config
+				body, err = json.Marshal(map[string]interface{}{"min-hot-degree": 0})
+				c.Assert(err, IsNil)
+				c.Assert(postJSON(testDialClient, updateURL, body), NotNil)
+			},
+		},
 		{name: "shuffle-leader-scheduler"},
 		{name: "shuffle-region-scheduler"},
 		{
@@ -378,6 +490,18 @@ func (s *testScheduleSuite) TestDisable(c *C) {
 	s.deleteScheduler(name, c)
 }
 
+func (s *testScheduleSuite) TestInvalidLeaderPreferences(c *C) {
+	for _, preferences := range []string{"zone", "zone=z1:x", "zone=z1:0", "zone=z1:1,zone=z1:2"} {
+		input := map[string]interface{}{"name": "leader-preference-scheduler", "preferences": preferences}
+		body, err := json.Marshal(input)
+		c.Assert(err, IsNil)
+		res, err := testDialClient.Post(s.urlPrefix, "application/json", bytes.NewBuffer(body))
+		c.Assert(err, IsNil)
+		res.Body.Close()
+		c.Assert(res.StatusCode, Equals, http.StatusBadRequest)
+	}
+}
+
 func (s *testScheduleSuite) addScheduler(name, createdName string, body []byte, extraTest func(string, *C), c *C) {
 	if createdName == "" {
 		createdName = name
@@ -57,11 +57,21 @@ var (
 	}
 )
 
+// standalone makes the suites run with the standalone servers.
+var standalone bool
+
 func TestAPIServer(t *testing.T) {
 	server.EnableZap = true
 	TestingT(t)
 }
 
+func TestAPIServerStandalone(t *testing.T) {
+	server.EnableZap = true
+	standalone = true
+	defer func() { standalone = false }()
+	TestingT(t)
+}
+
 func TestMain(m *testing.M) {
 	goleak.VerifyTestMain(m, testutil.LeakOptions...)
 }
@@ -76,6 +86,12 @@ func mustNewServer(c *C, opts ...func(cfg *config.Config)) (*server.Server, clea
 var zapLogOnce sync.Once
 
 func mustNewCluster(c *C, num int, opts ...func(cfg *config.Config)) ([]*config.Config, []*server.Server, cleanUpFunc) {
+	if standalone {
+		if num > 1 {
+			c.Skip("only one server runs in the standalone mode")
+		}
+		opts = append(opts, func(cfg *config.Config) { cfg.Standalone = true })
+	}
 	ctx, cancel := context.WithCancel(context.Background())
 	svrs := make([]*server.Server, 0, num)
 	cfgs := server.NewTestMultiConfig(c, num)
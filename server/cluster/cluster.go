@@ -31,23 +31,25 @@ import (
 	"github.com/tikv/pd/pkg/cache"
 	"github.com/tikv/pd/pkg/component"
 	"github.com/tikv/pd/pkg/errs"
-	"github.com/tikv/pd/pkg/etcdutil"
 	"github.com/tikv/pd/pkg/keyutil"
 	"github.com/tikv/pd/pkg/logutil"
 	"github.com/tikv/pd/pkg/typeutil"
+	"github.com/tikv/pd/pkg/watch"
 	"github.com/tikv/pd/server/config"
 	"github.com/tikv/pd/server/core"
 	"github.com/tikv/pd/server/core/storelimit"
 	"github.com/tikv/pd/server/id"
+	"github.com/tikv/pd/server/kv"
+	"github.com/tikv/pd/server/member"
 	syncer "github.com/tikv/pd/server/region_syncer"
 	"github.com/tikv/pd/server/replication"
 	"github.com/tikv/pd/server/schedule"
 	"github.com/tikv/pd/server/schedule/checker"
 	"github.com/tikv/pd/server/schedule/hbstream"
+	"github.com/tikv/pd/server/schedule/labeler"
 	"github.com/tikv/pd/server/schedule/placement"
 	"github.com/tikv/pd/server/statistics"
 	"github.com/tikv/pd/server/versioninfo"
-	"go.etcd.io/etcd/clientv3"
 	"go.uber.org/zap"
 )
 
@@ -109,15 +111,23 @@ type RaftCluster struct {
 	quit         chan struct{}
 	regionSyncer *syncer.RegionSyncer
 
-	ruleManager *placement.RuleManager
-	etcdClient  *clientv3.Client
-	httpClient  *http.Client
+	ruleManager   *placement.RuleManager
+	regionLabeler *labeler.RegionLabeler
+	witnessCache  *witnessCache
+	member        member.ElectionMember
+	// kv is the kv without root path, which stores the ttl config.
+	kv         kv.TTLBase
+	httpClient *http.Client
 
 	replicationMode *replication.ModeManager
 	traceRegionFlow bool
 
 	// It's used to manage components.
 	componentManager *component.Manager
+
+	unsafeRecoveryController *UnsafeRecoveryController
+
+	storeNotifier storeNotifier
 }
 
 // Status saves some state information.
@@ -128,7 +138,7 @@ type Status struct {
 }
 
 // NewRaftCluster create a new cluster.
-func NewRaftCluster(ctx context.Context, root string, clusterID uint64, regionSyncer *syncer.RegionSyncer, etcdClient *clientv3.Client, httpClient *http.Client) *RaftCluster {
+func NewRaftCluster(ctx context.Context, root string, clusterID uint64, regionSyncer *syncer.RegionSyncer, member member.ElectionMember, kvBase kv.TTLBase, httpClient *http.Client) *RaftCluster {
 	return &RaftCluster{
 		ctx:          ctx,
 		running:      false,
@@ -136,7 +146,8 @@ func NewRaftCluster(ctx context.Context, root string, clusterID uint64, regionSy
 		clusterRoot:  root,
 		regionSyncer: regionSyncer,
 		httpClient:   httpClient,
-		etcdClient:   etcdClient,
+		member:       member,
+		kv:           kvBase,
 	}
 }
 
@@ -202,6 +213,7 @@ func (c *RaftCluster) InitCluster(id id.Allocator, opt *config.PersistOptions, s
 	c.labelLevelStats = statistics.NewLabelStatistics()
 	c.hotStat = statistics.NewHotStat()
 	c.prepareChecker = newPrepareChecker()
+	c.witnessCache = newWitnessCache()
 	c.changedRegions = make(chan *core.RegionInfo, defaultChangedRegionsLimit)
 	c.suspectRegions = cache.NewIDTTL(c.ctx, time.Minute, 3*time.Minute)
 	c.suspectKeyRanges = cache.NewStringTTL(c.ctx, time.Minute, 3*time.Minute)
@@ -235,6 +247,11 @@ func (c *RaftCluster) Start(s Server) error {
 		}
 	}
 
+	c.regionLabeler, err = labeler.NewRegionLabeler(c.storage)
+	if err != nil {
+		return err
+	}
+
 	c.componentManager = component.NewManager(c.storage)
 	_, err = c.storage.LoadComponent(&c.componentManager)
 	if err != nil {
@@ -247,6 +264,7 @@ func (c *RaftCluster) Start(s Server) error {
 	}
 
 	c.coordinator = newCoordinator(c.ctx, cluster, s.GetHBStreams())
+	c.unsafeRecoveryController = newUnsafeRecoveryController(cluster)
 	c.regionStats = statistics.NewRegionStatistics(c.opt, c.ruleManager)
 	c.limiter = NewStoreLimiter(s.GetPersistOptions())
 	c.quit = make(chan struct{})
@@ -397,6 +415,13 @@ func (c *RaftCluster) GetHeartbeatStreams() *hbstream.HeartbeatStreams {
 	return c.coordinator.hbStreams
 }
 
+// GetUnsafeRecoveryController returns the unsafe recovery controller.
+func (c *RaftCluster) GetUnsafeRecoveryController() *UnsafeRecoveryController {
+	c.RLock()
+	defer c.RUnlock()
+	return c.unsafeRecoveryController
+}
+
 // GetCoordinator returns the coordinator.
 func (c *RaftCluster) GetCoordinator() *coordinator {
 	c.RLock()
@@ -617,6 +642,14 @@ func (c *RaftCluster) processRegionHeartbeat(region *core.RegionInfo) error {
 		}
 	}
 
+	// The witnesses depend on the placement rules, which may change while the
+	// region does not, so they are checked on every heartbeat and the cache is
+	// updated once they change.
+	region = c.fillWitnesses(region)
+	if origin != nil && witnessesChanged(origin.GetWitnesses(), region.GetWitnesses()) {
+		saveCache = true
+	}
+
 	if len(writeItems) == 0 && len(readItems) == 0 && !saveKV && !saveCache && !isNew {
 		return nil
 	}
@@ -650,6 +683,7 @@ func (c *RaftCluster) processRegionHeartbeat(region *core.RegionInfo) error {
 			if c.regionStats != nil {
 				c.regionStats.ClearDefunctRegion(item.GetID())
 			}
+			c.witnessCache.delete(item.GetID())
 			c.labelLevelStats.ClearDefunctRegion(item.GetID(), c.opt.GetLocationLabels())
 		}
 
@@ -713,7 +747,9 @@ func (c *RaftCluster) updateStoreStatusLocked(id uint64) {
 	regionCount := c.core.GetStoreRegionCount(id)
 	pendingPeerCount := c.core.GetStorePendingPeerCount(id)
 	leaderRegionSize := c.core.GetStoreLeaderRegionSize(id)
-	regionSize := c.core.GetStoreRegionSize(id)
+	// The witnesses do not hold data, so they are excluded from the region
+	// size, which is used to calculate the region score.
+	regionSize := c.core.GetStoreRegionSize(id) - c.core.GetStoreWitnessRegionSize(id)
 	c.core.UpdateStoreStatus(id, leaderCount, regionCount, pendingPeerCount, leaderRegionSize, regionSize)
 }
 
@@ -1090,6 +1126,29 @@ func (c *RaftCluster) ResumeLeaderTransfer(storeID uint64) {
 	c.core.ResumeLeaderTransfer(storeID)
 }
 
+// SlowStoreEvicted marks a store as a slow store and prevents transferring
+// leader to the store
+func (c *RaftCluster) SlowStoreEvicted(storeID uint64) error {
+	return c.core.SlowStoreEvicted(storeID)
+}
+
+// SlowStoreRecovered cleans the evicted state of a store.
+func (c *RaftCluster) SlowStoreRecovered(storeID uint64) {
+	c.core.SlowStoreRecovered(storeID)
+}
+
+// SetLeaderGroups sets the groups of stores that leaders are only balanced
+// within.
+func (c *RaftCluster) SetLeaderGroups(groups core.StoreGroups) {
+	c.core.SetLeaderGroups(groups)
+}
+
+// GetLeaderGroups returns the groups of stores that leaders are only balanced
+// within.
+func (c *RaftCluster) GetLeaderGroups() core.StoreGroups {
+	return c.core.GetLeaderGroups()
+}
+
 // AttachAvailableFunc attaches an available function to a specific store.
 func (c *RaftCluster) AttachAvailableFunc(storeID uint64, limitType storelimit.Type, f func() bool) {
 	c.core.AttachAvailableFunc(storeID, limitType, f)
@@ -1146,7 +1205,12 @@ func (c *RaftCluster) putStoreLocked(store *core.StoreInfo) error {
 			return err
 		}
 	}
+	var oldMeta *metapb.Store
+	if old := c.core.GetStore(store.GetID()); old != nil {
+		oldMeta = old.GetMeta()
+	}
 	c.core.PutStore(store)
+	c.storeNotifier.notify(oldMeta, store.GetMeta())
 	c.hotStat.GetOrCreateRollingStoreStats(store.GetID())
 	return nil
 }
@@ -1224,10 +1288,31 @@ func (c *RaftCluster) deleteStoreLocked(store *core.StoreInfo) error {
 		}
 	}
 	c.core.DeleteStore(store)
+	c.storeNotifier.notify(store.GetMeta(), nil)
 	c.hotStat.RemoveRollingStoreStats(store.GetID())
 	return nil
 }
 
+// GetStoreEvents returns the store changes after the revision, and a channel
+// closed once there are newer changes. If the changes after the revision are
+// no longer kept, all stores are returned as a snapshot.
+func (c *RaftCluster) GetStoreEvents(revision uint64) (*watch.WatchStoresResponse, <-chan struct{}) {
+	c.RLock()
+	defer c.RUnlock()
+	events, current, changed, ok := c.storeNotifier.getEvents(revision)
+	if ok {
+		if len(events) == 0 {
+			return nil, changed
+		}
+		return &watch.WatchStoresResponse{Revision: current, Events: events}, changed
+	}
+	resp := &watch.WatchStoresResponse{Snapshot: true, Revision: current}
+	for _, store := range c.GetMetaStores() {
+		resp.Events = append(resp.Events, &watch.StoreEvent{Type: watch.EventAdd, Revision: current, Store: store})
+	}
+	return resp, changed
+}
+
 func (c *RaftCluster) collectMetrics() {
 	statsMap := statistics.NewStoreStatisticsMap(c.opt)
 	stores := c.GetStores()
@@ -1278,7 +1363,7 @@ func (c *RaftCluster) resetClusterMetrics() {
 }
 
 func (c *RaftCluster) collectHealthStatus() {
-	members, err := GetMembers(c.etcdClient)
+	members, err := c.member.GetMembers()
 	if err != nil {
 		log.Error("get members error", errs.ZapError(err))
 	}
@@ -1507,11 +1592,115 @@ func (c *RaftCluster) GetRuleManager() *placement.RuleManager {
 	return c.ruleManager
 }
 
+// GetRegionLabeler returns the region labeler.
+func (c *RaftCluster) GetRegionLabeler() *labeler.RegionLabeler {
+	c.RLock()
+	defer c.RUnlock()
+	return c.regionLabeler
+}
+
 // FitRegion tries to fit the region with placement rules.
 func (c *RaftCluster) FitRegion(region *core.RegionInfo) *placement.RegionFit {
 	return c.GetRuleManager().FitRegion(c, region)
 }
 
+// fillWitnesses sets the witnesses of the region, which are the peers fitted
+// to the placement rules with the witness role. The region is fitted again
+// only after its peers, its epoch or the rules change.
+func (c *RaftCluster) fillWitnesses(region *core.RegionInfo) *core.RegionInfo {
+	if !c.opt.IsPlacementRulesEnabled() || c.ruleManager == nil || !c.ruleManager.HasWitnessRule() {
+		return region
+	}
+	version := c.ruleManager.GetVersion()
+	witnesses, ok := c.witnessCache.get(region, version)
+	if !ok {
+		witnesses = c.FitRegion(region).GetWitnesses()
+		c.witnessCache.put(region, version, witnesses)
+	}
+	if len(witnesses) == 0 {
+		return region
+	}
+	return region.Clone(core.WithWitnesses(witnesses))
+}
+
+func witnessesChanged(origin, witnesses []*metapb.Peer) bool {
+	if len(origin) != len(witnesses) {
+		return true
+	}
+	ids := make(map[uint64]struct{}, len(origin))
+	for _, p := range origin {
+		ids[p.GetId()] = struct{}{}
+	}
+	for _, p := range witnesses {
+		if _, ok := ids[p.GetId()]; !ok {
+			return true
+		}
+	}
+	return false
+}
+
+// witnessCache caches the witnesses of the regions with the version of the
+// rules they are fitted to.
+type witnessCache struct {
+	sync.Mutex
+	items map[uint64]*witnessCacheItem
+}
+
+type witnessCacheItem struct {
+	ruleVersion uint64
+	epoch       *metapb.RegionEpoch
+	peers       []*metapb.Peer
+	witnesses   []*metapb.Peer
+}
+
+func newWitnessCache() *witnessCache {
+	return &witnessCache{items: make(map[uint64]*witnessCacheItem)}
+}
+
+// get returns the cached witnesses of the region if the region and the rules
+// do not change after they are fitted.
+func (w *witnessCache) get(region *core.RegionInfo, ruleVersion uint64) ([]*metapb.Peer, bool) {
+	w.Lock()
+	defer w.Unlock()
+	item, ok := w.items[region.GetID()]
+	if !ok || item.ruleVersion != ruleVersion ||
+		item.epoch.GetConfVer() != region.GetRegionEpoch().GetConfVer() ||
+		item.epoch.GetVersion() != region.GetRegionEpoch().GetVersion() ||
+		!samePeers(item.peers, region.GetPeers()) {
+		return nil, false
+	}
+	return item.witnesses, true
+}
+
+func (w *witnessCache) put(region *core.RegionInfo, ruleVersion uint64, witnesses []*metapb.Peer) {
+	w.Lock()
+	defer w.Unlock()
+	w.items[region.GetID()] = &witnessCacheItem{
+		ruleVersion: ruleVersion,
+		epoch:       region.GetRegionEpoch(),
+		peers:       region.GetPeers(),
+		witnesses:   witnesses,
+	}
+}
+
+func (w *witnessCache) delete(regionID uint64) {
+	w.Lock()
+	defer w.Unlock()
+	delete(w.items, regionID)
+}
+
+func samePeers(a, b []*metapb.Peer) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].GetId() != b[i].GetId() || a[i].GetStoreId() != b[i].GetStoreId() || a[i].GetRole() != b[i].GetRole() {
+			return false
+		}
+	}
+	return true
+}
+
 type prepareChecker struct {
 	reactiveRegions map[uint64]int
 	start           time.Time
@@ -1621,6 +1810,27 @@ func (c *RaftCluster) IsSchedulerDisabled(name string) (bool, error) {
 	return c.coordinator.isSchedulerDisabled(name)
 }
 
+// DiagnoseScheduler checks the status of a scheduler, and runs it once
+// without dispatching the operators if it is a dry run. The overrides are
+// the schedule config items used in the dry run instead of the cluster's.
+func (c *RaftCluster) DiagnoseScheduler(name string, dryRun bool, overrides map[string]string) (*SchedulerDiagnosis, error) {
+	// The scheduler accesses the cluster during scheduling, so the lock is not
+	// held when running it.
+	c.RLock()
+	co := c.coordinator
+	c.RUnlock()
+	return co.diagnoseScheduler(name, dryRun, overrides)
+}
+
+// DiagnoseCheckers runs the checkers once against at most limit regions
+// without dispatching the operators.
+func (c *RaftCluster) DiagnoseCheckers(limit int, overrides map[string]string) (*CheckerDiagnosis, error) {
+	c.RLock()
+	co := c.coordinator
+	c.RUnlock()
+	return co.diagnoseCheckers(limit, overrides)
+}
+
 // GetStoreLimiter returns the dynamic adjusting limiter
 func (c *RaftCluster) GetStoreLimiter() *StoreLimiter {
 	return c.limiter
@@ -1681,7 +1891,7 @@ func (c *RaftCluster) SetAllStoresLimit(typ storelimit.Type, ratePerMin float64)
 
 // SetAllStoresLimitTTL sets all store limit for a given type and rate with ttl.
 func (c *RaftCluster) SetAllStoresLimitTTL(typ storelimit.Type, ratePerMin float64, ttl time.Duration) {
-	c.opt.SetAllStoresLimitTTL(c.ctx, c.etcdClient, typ, ratePerMin, ttl)
+	c.opt.SetAllStoresLimitTTL(c.ctx, c.kv, typ, ratePerMin, ttl)
 }
 
 // GetClusterVersion returns the current cluster version.
@@ -1689,9 +1899,9 @@ func (c *RaftCluster) GetClusterVersion() string {
 	return c.opt.GetClusterVersion().String()
 }
 
-// GetEtcdClient returns the current etcd client
-func (c *RaftCluster) GetEtcdClient() *clientv3.Client {
-	return c.etcdClient
+// GetKV returns the kv without root path.
+func (c *RaftCluster) GetKV() kv.Base {
+	return c.kv
 }
 
 var healthURL = "/pd/api/v1/ping"
@@ -1723,33 +1933,8 @@ func CheckHealth(client *http.Client, members []*pdpb.Member) map[uint64]*pdpb.M
 	return healthMembers
 }
 
-// GetMembers return a slice of Members.
-func GetMembers(etcdClient *clientv3.Client) ([]*pdpb.Member, error) {
-	listResp, err := etcdutil.ListEtcdMembers(etcdClient)
-	if err != nil {
-		return nil, err
-	}
-
-	members := make([]*pdpb.Member, 0, len(listResp.Members))
-	for _, m := range listResp.Members {
-		info := &pdpb.Member{
-			Name:       m.Name,
-			MemberId:   m.ID,
-			ClientUrls: m.ClientURLs,
-			PeerUrls:   m.PeerURLs,
-		}
-		members = append(members, info)
-	}
-
-	return members, nil
-}
-
 // IsClientURL returns whether addr is a ClientUrl of any member.
-func IsClientURL(addr string, etcdClient *clientv3.Client) bool {
-	members, err := GetMembers(etcdClient)
-	if err != nil {
-		return false
-	}
+func IsClientURL(addr string, members []*pdpb.Member) bool {
 	for _, member := range members {
 		for _, u := range member.GetClientUrls() {
 			if u == addr {
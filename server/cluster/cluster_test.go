@@ -27,10 +27,12 @@ import (
 	"github.com/pingcap/kvproto/pkg/metapb"
 	"github.com/pingcap/kvproto/pkg/pdpb"
 	"github.com/tikv/pd/pkg/mock/mockid"
+	"github.com/tikv/pd/pkg/watch"
 	"github.com/tikv/pd/server/config"
 	"github.com/tikv/pd/server/core"
 	"github.com/tikv/pd/server/id"
 	"github.com/tikv/pd/server/kv"
+	"github.com/tikv/pd/server/schedule/labeler"
 	"github.com/tikv/pd/server/schedule/opt"
 	"github.com/tikv/pd/server/schedule/placement"
 	"github.com/tikv/pd/server/versioninfo"
@@ -125,6 +127,66 @@ func (s *testClusterInfoSuite) TestFilterUnhealthyStore(c *C) {
 	}
 }
 
+func (s *testClusterInfoSuite) TestStoreEvents(c *C) {
+	_, opt, err := newTestScheduleConfig()
+	c.Assert(err, IsNil)
+	cluster := newTestRaftCluster(mockid.NewIDAllocator(), opt, core.NewStorage(kv.NewMemoryKV()), core.NewBasicCluster())
+
+	// The watch starts with a snapshot.
+	stores := newTestStores(3, "2.0.0")
+	c.Assert(cluster.putStoreLocked(stores[0]), IsNil)
+	resp, changed := cluster.GetStoreEvents(0)
+	c.Assert(resp.Snapshot, IsTrue)
+	c.Assert(resp.Events, HasLen, 1)
+	revision := resp.Revision
+
+	// No event if nothing changes.
+	c.Assert(cluster.putStoreLocked(stores[0].Clone(core.SetLeaderWeight(2))), IsNil)
+	resp, _ = cluster.GetStoreEvents(revision)
+	c.Assert(resp, IsNil)
+
+	c.Assert(cluster.putStoreLocked(stores[1]), IsNil)
+	c.Assert(cluster.putStoreLocked(stores[0].Clone(core.SetStoreState(metapb.StoreState_Tombstone))), IsNil)
+	c.Assert(cluster.deleteStoreLocked(cluster.GetStore(stores[0].GetID())), IsNil)
+	select {
+	case <-changed:
+	default:
+		c.Fatal("the watcher is not notified")
+	}
+	resp, _ = cluster.GetStoreEvents(revision)
+	c.Assert(resp.Snapshot, IsFalse)
+	c.Assert(resp.Revision, Equals, revision+3)
+	c.Assert(resp.Events, HasLen, 3)
+	expected := []struct {
+		tp      watch.EventType
+		storeID uint64
+	}{
+		{watch.EventAdd, stores[1].GetID()},
+		{watch.EventUpdate, stores[0].GetID()},
+		{watch.EventRemove, stores[0].GetID()},
+	}
+	for i, e := range resp.Events {
+		c.Assert(e.Type, Equals, expected[i].tp)
+		c.Assert(e.Store.GetId(), Equals, expected[i].storeID)
+		c.Assert(e.Revision, Equals, revision+uint64(i)+1)
+	}
+	c.Assert(resp.Events[1].Store.GetState(), Equals, metapb.StoreState_Tombstone)
+
+	// The watch resumes from a revision in the middle.
+	resp, _ = cluster.GetStoreEvents(revision + 2)
+	c.Assert(resp.Events, HasLen, 1)
+	c.Assert(resp.Events[0].Type, Equals, watch.EventRemove)
+
+	// A snapshot is sent if the events are no longer kept.
+	for i := 0; i < maxStoreEvents; i++ {
+		c.Assert(cluster.putStoreLocked(stores[2].Clone(core.SetStoreAddress(fmt.Sprintf("127.0.0.1:%d", i), "", ""))), IsNil)
+	}
+	resp, _ = cluster.GetStoreEvents(revision)
+	c.Assert(resp.Snapshot, IsTrue)
+	c.Assert(resp.Events, HasLen, 2)
+	c.Assert(resp.Revision, Equals, revision+3+maxStoreEvents)
+}
+
 func (s *testClusterInfoSuite) TestSetStoreState(c *C) {
 	_, opt, err := newTestScheduleConfig()
 	c.Assert(err, IsNil)
@@ -591,6 +653,71 @@ func (s *testClusterInfoSuite) TestUpdateStorePendingPeerCount(c *C) {
 	checkPendingPeerCount([]int{0, 0, 0, 1}, tc.RaftCluster, c)
 }
 
+func (s *testClusterInfoSuite) TestWitnessRegionSize(c *C) {
+	_, opt, err := newTestScheduleConfig()
+	c.Assert(err, IsNil)
+	opt.SetPlacementRuleEnabled(true)
+	tc := newTestCluster(opt)
+	stores := newTestStores(3, "2.0.0")
+	for i, s := range stores {
+		zone := "z1"
+		if i == 2 {
+			zone = "z2"
+		}
+		s = s.Clone(core.SetStoreLabels([]*metapb.StoreLabel{{Key: "zone", Value: zone}}))
+		c.Assert(tc.putStoreLocked(s), IsNil)
+	}
+	c.Assert(tc.ruleManager.SetRule(&placement.Rule{
+		GroupID: "pd", ID: "default", Role: placement.Voter, Count: 2,
+		LabelConstraints: []placement.LabelConstraint{{Key: "zone", Op: placement.In, Values: []string{"z1"}}},
+	}), IsNil)
+	c.Assert(tc.ruleManager.SetRule(&placement.Rule{
+		GroupID: "pd", ID: "witness", Role: placement.Witness, Count: 1,
+		LabelConstraints: []placement.LabelConstraint{{Key: "zone", Op: placement.In, Values: []string{"z2"}}},
+	}), IsNil)
+
+	peers := []*metapb.Peer{{Id: 4, StoreId: 1}, {Id: 5, StoreId: 2}, {Id: 6, StoreId: 3}}
+	region := core.NewRegionInfo(&metapb.Region{Id: 1, Peers: peers}, peers[0], core.SetApproximateSize(100))
+	c.Assert(tc.processRegionHeartbeat(region), IsNil)
+	c.Assert(tc.GetRegion(1).GetWitnesses(), HasLen, 1)
+	c.Assert(tc.GetRegion(1).GetStoreWitness(3), NotNil)
+	c.Assert(tc.GetStore(1).GetRegionSize(), Equals, int64(100))
+	c.Assert(tc.GetStore(2).GetRegionSize(), Equals, int64(100))
+	c.Assert(tc.GetStore(3).GetRegionSize(), Equals, int64(0))
+	c.Assert(tc.GetStore(3).GetRegionCount(), Equals, 1)
+
+	// The witnesses are updated by the next heartbeat after the rules change,
+	// even if the region does not change.
+	c.Assert(tc.ruleManager.DeleteRule("pd", "witness"), IsNil)
+	c.Assert(tc.ruleManager.SetRule(&placement.Rule{
+		GroupID: "pd", ID: "default", Role: placement.Voter, Count: 3,
+	}), IsNil)
+	c.Assert(tc.processRegionHeartbeat(region), IsNil)
+	c.Assert(tc.GetRegion(1).GetWitnesses(), HasLen, 0)
+	c.Assert(tc.GetStore(3).GetRegionSize(), Equals, int64(100))
+	c.Assert(tc.ruleManager.SetRule(&placement.Rule{
+		GroupID: "pd", ID: "witness", Role: placement.Witness, Count: 1,
+		LabelConstraints: []placement.LabelConstraint{{Key: "zone", Op: placement.In, Values: []string{"z1"}}},
+	}), IsNil)
+	c.Assert(tc.ruleManager.SetRule(&placement.Rule{
+		GroupID: "pd", ID: "default", Role: placement.Voter, Count: 2,
+	}), IsNil)
+	c.Assert(tc.processRegionHeartbeat(region), IsNil)
+	c.Assert(tc.GetRegion(1).GetWitnesses(), HasLen, 1)
+	c.Assert(tc.GetRegion(1).GetStoreWitness(3), IsNil)
+
+	// The region is not fitted again until its peers, its epoch or the rules
+	// change.
+	for _, id := range []uint64{1, 2} {
+		store := tc.GetStore(id).Clone(core.SetStoreLabels([]*metapb.StoreLabel{{Key: "zone", Value: "z2"}}))
+		c.Assert(tc.putStoreLocked(store), IsNil)
+	}
+	c.Assert(tc.processRegionHeartbeat(region), IsNil)
+	c.Assert(tc.GetRegion(1).GetWitnesses(), HasLen, 1)
+	c.Assert(tc.processRegionHeartbeat(region.Clone(core.SetRegionConfVer(2))), IsNil)
+	c.Assert(tc.GetRegion(1).GetWitnesses(), HasLen, 0)
+}
+
 var _ = Suite(&testStoresInfoSuite{})
 
 type testStoresInfoSuite struct{}
@@ -793,6 +920,7 @@ func newTestCluster(opt *config.PersistOptions) *testCluster {
 func newTestRaftCluster(id id.Allocator, opt *config.PersistOptions, storage *core.Storage, basicCluster *core.BasicCluster) *RaftCluster {
 	rc := &RaftCluster{ctx: context.TODO()}
 	rc.InitCluster(id, opt, storage, basicCluster)
+	rc.regionLabeler, _ = labeler.NewRegionLabeler(storage)
 	return rc
 }
 
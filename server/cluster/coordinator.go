@@ -31,6 +31,7 @@ import (
 	"github.com/tikv/pd/server/core"
 	"github.com/tikv/pd/server/kv"
 	"github.com/tikv/pd/server/schedule"
+	"github.com/tikv/pd/server/schedule/filter"
 	"github.com/tikv/pd/server/schedule/hbstream"
 	"github.com/tikv/pd/server/schedule/operator"
 	"github.com/tikv/pd/server/schedulers"
@@ -73,6 +74,9 @@ type coordinator struct {
 func newCoordinator(ctx context.Context, cluster *RaftCluster, hbStreams *hbstream.HeartbeatStreams) *coordinator {
 	ctx, cancel := context.WithCancel(ctx)
 	opController := schedule.NewOperatorController(ctx, cluster, hbStreams)
+	if cluster.storage != nil {
+		opController.SetOperatorJournal(schedule.NewOperatorJournal(ctx, cluster.storage))
+	}
 	return &coordinator{
 		ctx:             ctx,
 		cancel:          cancel,
@@ -615,12 +619,14 @@ func (c *coordinator) removeScheduler(name string) error {
 		return err
 	}
 
-	if err = opt.Persist(c.cluster.storage); err != nil {
+	// Persist the option and remove the scheduler config atomically.
+	batch := c.cluster.storage.NewBatch()
+	if err = opt.PersistToBatch(batch); err != nil {
 		log.Error("the option can not persist scheduler config", errs.ZapError(err))
 		return err
 	}
-
-	if err = c.cluster.storage.RemoveScheduleConfig(name); err != nil {
+	batch.RemoveScheduleConfig(name)
+	if err = batch.Commit(); err != nil {
 		log.Error("can not remove the scheduler config", errs.ZapError(err))
 		return err
 	}
@@ -713,6 +719,119 @@ func (c *coordinator) isSchedulerDisabled(name string) (bool, error) {
 	return false, nil
 }
 
+// SchedulerDiagnosis is the diagnosis of a scheduler.
+type SchedulerDiagnosis struct {
+	Name            string `json:"name"`
+	Paused          bool   `json:"paused"`
+	ScheduleAllowed bool   `json:"schedule_allowed"`
+	// Operators and FilteredStores are only set when it is a dry run.
+	Operators      []*operator.Operator `json:"operators,omitempty"`
+	FilteredStores []filter.Record      `json:"filtered_stores,omitempty"`
+}
+
+// diagnoseScheduler checks the status of a scheduler. For a dry run, a copy of
+// the scheduler runs once against the current cluster, the operators are
+// returned instead of being added to the operator controller, along with the
+// stores rejected by the filters of the scheduler. The overrides are the
+// schedule config items to use in the dry run instead of the cluster's, and
+// whether the schedule is allowed is checked with them too, regardless of
+// whether the scheduler is paused.
+func (c *coordinator) diagnoseScheduler(name string, dryRun bool, overrides map[string]string) (*SchedulerDiagnosis, error) {
+	c.RLock()
+	if c.cluster == nil {
+		c.RUnlock()
+		return nil, errs.ErrNotBootstrapped.FastGenByArgs()
+	}
+	s, ok := c.schedulers[name]
+	c.RUnlock()
+	if !ok {
+		return nil, errs.ErrSchedulerNotFound.FastGenByArgs()
+	}
+	diagnosis := &SchedulerDiagnosis{
+		Name:            name,
+		Paused:          s.IsPaused(),
+		ScheduleAllowed: s.IsScheduleAllowed(c.cluster),
+	}
+	if !dryRun {
+		return diagnosis, nil
+	}
+
+	// The copy of the scheduler runs on a dryRunCluster with a throwaway
+	// operator controller, so that neither the running scheduler nor the
+	// cluster is affected. Its config is persisted in a temporary storage.
+	cluster, err := newDryRunCluster(c.cluster, overrides)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(c.ctx)
+	defer cancel()
+	opController := schedule.NewOperatorController(ctx, cluster, nil)
+	opController.CopyOperatorCounts(c.opController)
+	data, err := s.EncodeConfig()
+	if err != nil {
+		return nil, err
+	}
+	tmp, err := schedule.CreateScheduler(s.GetType(), opController, core.NewStorage(kv.NewMemoryKV()), schedule.ConfigJSONDecoder(data))
+	if err != nil {
+		return nil, err
+	}
+	diagnosis.ScheduleAllowed = tmp.IsScheduleAllowed(cluster)
+	if !diagnosis.ScheduleAllowed {
+		return diagnosis, nil
+	}
+	recorder := filter.NewRecorder(tmp.GetName(), cluster.GetOpts())
+	defer recorder.Stop()
+	diagnosis.Operators = tmp.Schedule(cluster)
+	diagnosis.FilteredStores = recorder.GetRecords()
+	return diagnosis, nil
+}
+
+// CheckerDiagnosis is the result of a dry run of the checkers.
+type CheckerDiagnosis struct {
+	CheckedRegions int                  `json:"checked_regions"`
+	Operators      []*operator.Operator `json:"operators,omitempty"`
+	FilteredStores []filter.Record      `json:"filtered_stores,omitempty"`
+}
+
+// checkerScopes are the scopes of the filters used by the checkers.
+var checkerScopes = []string{"replica-checker", "rule-checker"}
+
+// diagnoseCheckers runs the checkers once against at most limit regions from
+// the start of the key space, like diagnoseScheduler does for a scheduler.
+func (c *coordinator) diagnoseCheckers(limit int, overrides map[string]string) (*CheckerDiagnosis, error) {
+	c.RLock()
+	if c.cluster == nil {
+		c.RUnlock()
+		return nil, errs.ErrNotBootstrapped.FastGenByArgs()
+	}
+	c.RUnlock()
+
+	cluster, err := newDryRunCluster(c.cluster, overrides)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(c.ctx)
+	defer cancel()
+	opController := schedule.NewOperatorController(ctx, cluster, nil)
+	checkers := c.checkers.ForkDryRun(cluster, c.cluster.ruleManager, opController)
+	recorders := make([]*filter.Recorder, 0, len(checkerScopes))
+	for _, scope := range checkerScopes {
+		recorder := filter.NewRecorder(scope, cluster.GetOpts())
+		defer recorder.Stop()
+		recorders = append(recorders, recorder)
+	}
+
+	regions := c.cluster.ScanRegions(nil, nil, limit)
+	diagnosis := &CheckerDiagnosis{CheckedRegions: len(regions)}
+	for _, region := range regions {
+		diagnosis.Operators = append(diagnosis.Operators, checkers.CheckRegion(region)...)
+	}
+	for _, recorder := range recorders {
+		diagnosis.FilteredStores = append(diagnosis.FilteredStores, recorder.GetRecords()...)
+	}
+	return diagnosis, nil
+}
+
 func (c *coordinator) runScheduler(s *scheduleController) {
 	defer logutil.LogPanic()
 	defer c.wg.Done()
@@ -777,7 +896,7 @@ func (s *scheduleController) Stop() {
 func (s *scheduleController) Schedule() []*operator.Operator {
 	for i := 0; i < maxScheduleRetries; i++ {
 		// If we have schedule, reset interval to the minimal interval.
-		if op := s.Scheduler.Schedule(s.cluster); op != nil {
+		if op := schedulers.ScheduleWithDiagnostic(s.Scheduler, s.cluster); op != nil {
 			s.nextInterval = s.Scheduler.GetMinInterval()
 			return op
 		}
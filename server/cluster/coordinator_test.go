@@ -25,6 +25,7 @@ import (
 	"github.com/pingcap/kvproto/pkg/eraftpb"
 	"github.com/pingcap/kvproto/pkg/metapb"
 	"github.com/pingcap/kvproto/pkg/pdpb"
+	"github.com/tikv/pd/pkg/errs"
 	"github.com/tikv/pd/pkg/mock/mockhbstream"
 	"github.com/tikv/pd/pkg/testutil"
 	"github.com/tikv/pd/pkg/typeutil"
@@ -773,6 +774,103 @@ func (s *testCoordinatorSuite) TestPersistScheduler(c *C) {
 	c.Assert(co.schedulers, HasLen, 3)
 }
 
+func (s *testCoordinatorSuite) TestDiagnoseScheduler(c *C) {
+	tc, co, cleanup := prepare(nil, nil, nil, c)
+	defer cleanup()
+
+	c.Assert(tc.addLeaderStore(1, 1), IsNil)
+	c.Assert(tc.addLeaderStore(2, 1), IsNil)
+	c.Assert(tc.addLeaderStore(3, 0), IsNil)
+	c.Assert(tc.addLeaderRegion(1, 1, 2, 3), IsNil)
+	c.Assert(tc.addLeaderRegion(2, 2, 1, 3), IsNil)
+
+	es, err := schedule.CreateScheduler(schedulers.EvictSlowStoreType, co.opController, core.NewStorage(kv.NewMemoryKV()), schedule.ConfigSliceDecoder(schedulers.EvictSlowStoreType, nil))
+	c.Assert(err, IsNil)
+	c.Assert(co.addScheduler(es), IsNil)
+	c.Assert(co.pauseOrResumeScheduler(es.GetName(), 60), IsNil)
+	// Store 1 becomes slow.
+	for i := 0; i < 10; i++ {
+		store := tc.GetStore(1)
+		tc.core.PutStore(store.Clone(core.SetStoreStats(&pdpb.StoreStats{StoreId: 1, IsBusy: true})))
+	}
+	c.Assert(tc.GetStore(1).IsSlow(), IsTrue)
+
+	diagnosis, err := co.diagnoseScheduler(es.GetName(), false, nil)
+	c.Assert(err, IsNil)
+	c.Assert(diagnosis.Paused, IsTrue)
+	c.Assert(diagnosis.Operators, HasLen, 0)
+
+	conf, err := es.EncodeConfig()
+	c.Assert(err, IsNil)
+	diagnosis, err = co.diagnoseScheduler(es.GetName(), true, nil)
+	c.Assert(err, IsNil)
+	c.Assert(diagnosis.Operators, HasLen, 1)
+	c.Assert(diagnosis.Operators[0].RegionID(), Equals, uint64(1))
+	// The dry run affects neither the cluster nor the running scheduler.
+	c.Assert(tc.GetStore(1).EvictedAsSlowStore(), IsFalse)
+	c.Assert(co.opController.GetOperators(), HasLen, 0)
+	data, err := es.EncodeConfig()
+	c.Assert(err, IsNil)
+	c.Assert(data, DeepEquals, conf)
+
+	// The schedule is checked with the overrides and the running operators.
+	bl, err := schedule.CreateScheduler(schedulers.BalanceLeaderType, co.opController, core.NewStorage(kv.NewMemoryKV()), schedule.ConfigSliceDecoder(schedulers.BalanceLeaderType, nil))
+	c.Assert(err, IsNil)
+	c.Assert(co.addScheduler(bl), IsNil)
+	diagnosis, err = co.diagnoseScheduler(bl.GetName(), true, map[string]string{"leader-schedule-limit": "0"})
+	c.Assert(err, IsNil)
+	c.Assert(diagnosis.ScheduleAllowed, IsFalse)
+	c.Assert(diagnosis.Operators, HasLen, 0)
+	c.Assert(co.opController.AddWaitingOperator(newTestOperator(2, tc.GetRegion(2).GetRegionEpoch(), operator.OpLeader)), Equals, 1)
+	diagnosis, err = co.diagnoseScheduler(bl.GetName(), true, map[string]string{"leader-schedule-limit": "1"})
+	c.Assert(err, IsNil)
+	c.Assert(diagnosis.ScheduleAllowed, IsFalse)
+	diagnosis, err = co.diagnoseScheduler(bl.GetName(), true, map[string]string{"leader-schedule-limit": "2"})
+	c.Assert(err, IsNil)
+	c.Assert(diagnosis.ScheduleAllowed, IsTrue)
+
+	_, err = co.diagnoseScheduler(es.GetName(), true, map[string]string{"unknown-config": "1"})
+	c.Assert(errs.ErrDiagnoseConfig.Equal(err), IsTrue)
+	_, err = co.diagnoseScheduler("unknown", true, nil)
+	c.Assert(errs.ErrSchedulerNotFound.Equal(err), IsTrue)
+}
+
+func (s *testCoordinatorSuite) TestDiagnoseCheckers(c *C) {
+	tc, co, cleanup := prepare(nil, nil, nil, c)
+	defer cleanup()
+
+	c.Assert(tc.addRegionStore(1, 1), IsNil)
+	c.Assert(tc.addRegionStore(2, 1), IsNil)
+	c.Assert(tc.addRegionStore(3, 1), IsNil)
+	c.Assert(tc.addRegionStore(4, 0), IsNil)
+	c.Assert(tc.addLeaderRegion(1, 1, 2, 3), IsNil)
+	// Region 2 lacks a replica.
+	c.Assert(tc.addLeaderRegion(2, 1, 2), IsNil)
+
+	diagnosis, err := co.diagnoseCheckers(10, nil)
+	c.Assert(err, IsNil)
+	c.Assert(diagnosis.CheckedRegions, Equals, 2)
+	c.Assert(diagnosis.Operators, HasLen, 1)
+	c.Assert(diagnosis.Operators[0].RegionID(), Equals, uint64(2))
+	c.Assert(diagnosis.Operators[0].Kind()&operator.OpReplica, Not(Equals), operator.OpKind(0))
+	c.Assert(co.opController.GetOperators(), HasLen, 0)
+	diagnosis, err = co.diagnoseCheckers(1, nil)
+	c.Assert(err, IsNil)
+	c.Assert(diagnosis.CheckedRegions, Equals, 1)
+	c.Assert(diagnosis.Operators, HasLen, 0)
+
+	// What if the replica schedule limit were 0.
+	diagnosis, err = co.diagnoseCheckers(10, map[string]string{"replica-schedule-limit": "0"})
+	c.Assert(err, IsNil)
+	c.Assert(diagnosis.Operators, HasLen, 0)
+	c.Assert(tc.opt.GetReplicaScheduleLimit(), Not(Equals), uint64(0))
+
+	_, err = co.diagnoseCheckers(10, map[string]string{"replica-schedule-limit": "x"})
+	c.Assert(errs.ErrDiagnoseConfig.Equal(err), IsTrue)
+	_, err = co.diagnoseCheckers(10, map[string]string{"max-store-down-time": "1"})
+	c.Assert(errs.ErrDiagnoseConfig.Equal(err), IsTrue)
+}
+
 func (s *testCoordinatorSuite) TestRemoveScheduler(c *C) {
 	tc, co, cleanup := prepare(func(cfg *config.ScheduleConfig) {
 		cfg.ReplicaScheduleLimit = 0
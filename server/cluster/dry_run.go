@@ -0,0 +1,133 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/tikv/pd/pkg/errs"
+	"github.com/tikv/pd/server/config"
+	"github.com/tikv/pd/server/core"
+	"github.com/tikv/pd/server/core/storelimit"
+	"github.com/tikv/pd/server/schedule/opt"
+)
+
+// dryRunCluster wraps a cluster for the dry runs of schedulers and checkers.
+// Regions and stores are read from the wrapped cluster, but the changes to
+// the stores and the schedulers are dropped and IDs are allocated locally, so
+// that a dry run never affects the cluster. The options are a copy of the
+// cluster's, which may be overridden to see what a config change would do.
+type dryRunCluster struct {
+	opt.Cluster
+	opts   *config.PersistOptions
+	nextID uint64
+}
+
+// newDryRunCluster creates a dryRunCluster. The overrides are the schedule
+// config items, keyed by their names in the config API, to use instead of the
+// cluster's.
+func newDryRunCluster(cluster opt.Cluster, overrides map[string]string) (*dryRunCluster, error) {
+	opts := cluster.GetOpts().Clone()
+	if len(overrides) > 0 {
+		cfg, err := overrideScheduleConfig(opts.GetScheduleConfig(), overrides)
+		if err != nil {
+			return nil, err
+		}
+		opts.SetScheduleConfig(cfg)
+	}
+	return &dryRunCluster{Cluster: cluster, opts: opts}, nil
+}
+
+// GetOpts returns the options of the dry run.
+func (c *dryRunCluster) GetOpts() *config.PersistOptions {
+	return c.opts
+}
+
+// AllocID returns a placeholder ID which is only unique in the dry run.
+func (c *dryRunCluster) AllocID() (uint64, error) {
+	return atomic.AddUint64(&c.nextID, 1), nil
+}
+
+// RemoveScheduler is a no-op in a dry run.
+func (c *dryRunCluster) RemoveScheduler(name string) error { return nil }
+
+// AddSuspectRegions is a no-op in a dry run.
+func (c *dryRunCluster) AddSuspectRegions(ids ...uint64) {}
+
+// PauseLeaderTransfer is a no-op in a dry run.
+func (c *dryRunCluster) PauseLeaderTransfer(id uint64) error { return nil }
+
+// ResumeLeaderTransfer is a no-op in a dry run.
+func (c *dryRunCluster) ResumeLeaderTransfer(id uint64) {}
+
+// SlowStoreEvicted is a no-op in a dry run.
+func (c *dryRunCluster) SlowStoreEvicted(id uint64) error { return nil }
+
+// SlowStoreRecovered is a no-op in a dry run.
+func (c *dryRunCluster) SlowStoreRecovered(id uint64) {}
+
+// SetLeaderGroups is a no-op in a dry run.
+func (c *dryRunCluster) SetLeaderGroups(groups core.StoreGroups) {}
+
+// AttachAvailableFunc is a no-op in a dry run.
+func (c *dryRunCluster) AttachAvailableFunc(id uint64, limitType storelimit.Type, f func() bool) {}
+
+// overrideScheduleConfig returns a copy of the schedule config with the items
+// overridden. The values are parsed according to the types of the items.
+func overrideScheduleConfig(cfg *config.ScheduleConfig, overrides map[string]string) (*config.ScheduleConfig, error) {
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+	current := make(map[string]interface{})
+	if err = json.Unmarshal(data, &current); err != nil {
+		return nil, err
+	}
+	items := make(map[string]interface{}, len(overrides))
+	for key, value := range overrides {
+		old, ok := current[key]
+		if !ok {
+			return nil, errs.ErrDiagnoseConfig.FastGenByArgs(fmt.Sprintf("config item %s not found", key))
+		}
+		var v interface{}
+		switch old.(type) {
+		case float64:
+			v, err = strconv.ParseFloat(value, 64)
+		case bool:
+			v, err = strconv.ParseBool(value)
+		case string:
+			v = value
+		default:
+			err = json.Unmarshal([]byte(value), &v)
+		}
+		if err != nil {
+			return nil, errs.ErrDiagnoseConfig.FastGenByArgs(fmt.Sprintf("%s: %v", key, err))
+		}
+		items[key] = v
+	}
+	if data, err = json.Marshal(items); err != nil {
+		return nil, err
+	}
+	n := cfg.Clone()
+	if err = json.Unmarshal(data, n); err != nil {
+		return nil, errs.ErrDiagnoseConfig.FastGenByArgs(err.Error())
+	}
+	if err = n.Validate(); err != nil {
+		return nil, errs.ErrDiagnoseConfig.FastGenByArgs(err.Error())
+	}
+	return n, nil
+}
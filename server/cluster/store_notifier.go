@@ -0,0 +1,91 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"sync"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/tikv/pd/pkg/watch"
+)
+
+// maxStoreEvents is the number of the latest store events kept for the
+// watchers to resume from.
+const maxStoreEvents = 1024
+
+// storeNotifier records the changes of the stores and wakes up the watchers.
+// The zero value is ready to use.
+type storeNotifier struct {
+	sync.Mutex
+	// revision is the revision of the latest event. It starts from the
+	// creation time in nanoseconds, so that the revisions of a previous
+	// leader are never mistaken for the ones of this notifier.
+	revision uint64
+	events   []*watch.StoreEvent
+	// changed is closed when a new event is recorded.
+	changed chan struct{}
+}
+
+func (n *storeNotifier) initLocked() {
+	if n.changed == nil {
+		n.revision = uint64(time.Now().UnixNano())
+		n.changed = make(chan struct{})
+	}
+}
+
+// notify records the change from the old store meta to the new one, either of
+// which is nil if the store is added or removed.
+func (n *storeNotifier) notify(old, new *metapb.Store) {
+	var event *watch.StoreEvent
+	switch {
+	case old == nil:
+		event = &watch.StoreEvent{Type: watch.EventAdd, Store: new}
+	case new == nil:
+		event = &watch.StoreEvent{Type: watch.EventRemove, Store: old}
+	case !proto.Equal(old, new):
+		event = &watch.StoreEvent{Type: watch.EventUpdate, Store: new}
+	default:
+		return
+	}
+
+	n.Lock()
+	defer n.Unlock()
+	n.initLocked()
+	n.revision++
+	event.Revision = n.revision
+	n.events = append(n.events, event)
+	if len(n.events) > maxStoreEvents {
+		n.events = append(n.events[:0:0], n.events[len(n.events)-maxStoreEvents:]...)
+	}
+	close(n.changed)
+	n.changed = make(chan struct{})
+}
+
+// getEvents returns the events after the revision, and a channel closed once
+// there are newer events. It returns ok = false if the events after the
+// revision are no longer kept, in which case the current revision is
+// returned to be used with a snapshot of the stores.
+func (n *storeNotifier) getEvents(revision uint64) (events []*watch.StoreEvent, current uint64, changed <-chan struct{}, ok bool) {
+	n.Lock()
+	defer n.Unlock()
+	n.initLocked()
+	oldest := n.revision - uint64(len(n.events))
+	if revision < oldest || revision > n.revision {
+		return nil, n.revision, n.changed, false
+	}
+	events = n.events[len(n.events)-int(n.revision-revision):]
+	return events, n.revision, n.changed, true
+}
@@ -0,0 +1,448 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/kvproto/pkg/raft_serverpb"
+	"github.com/pingcap/log"
+	"github.com/tikv/pd/pkg/errs"
+	"go.uber.org/zap"
+)
+
+// The stages of unsafe recovery.
+const (
+	UnsafeRecoveryIdle               = "idle"
+	UnsafeRecoveryCollectingReports  = "collecting-reports"
+	UnsafeRecoveryForceLeader        = "force-leader"
+	UnsafeRecoveryDemoteFailedVoters = "demote-failed-voters"
+	UnsafeRecoveryFinished           = "finished"
+	UnsafeRecoveryFailed             = "failed"
+)
+
+// DefaultUnsafeRecoveryTimeout is the default timeout of unsafe recovery.
+const DefaultUnsafeRecoveryTimeout = 10 * time.Minute
+
+// RegionRecoveryPlan is the plan to recover a region which lost the majority
+// of its voters.
+type RegionRecoveryPlan struct {
+	RegionID uint64 `json:"region_id"`
+	// Leader is the surviving peer that is forced to be the leader.
+	Leader *metapb.Peer `json:"leader"`
+	// FailedVoters are the voters on the failed stores to be demoted.
+	FailedVoters []*metapb.Peer `json:"failed_voters"`
+	ForcedLeader bool           `json:"forced_leader"`
+	Finished     bool           `json:"finished"`
+}
+
+// UnsafeRecoveryProgress is the progress of unsafe recovery. It is also the
+// final report after the recovery ends.
+type UnsafeRecoveryProgress struct {
+	Stage        string   `json:"stage"`
+	Step         uint64   `json:"step"`
+	FailedStores []uint64 `json:"failed_stores,omitempty"`
+	// ReportedStores and WaitingStores are the surviving stores that have or
+	// have not reported in the current step.
+	ReportedStores []uint64              `json:"reported_stores,omitempty"`
+	WaitingStores  []uint64              `json:"waiting_stores,omitempty"`
+	Plans          []*RegionRecoveryPlan `json:"plans,omitempty"`
+	// LostRegions are the regions without any surviving voter, which cannot be
+	// recovered by PD.
+	LostRegions []uint64 `json:"lost_regions,omitempty"`
+	// Error is the reason why the recovery failed.
+	Error      string    `json:"error,omitempty"`
+	StartTime  time.Time `json:"start_time"`
+	Deadline   time.Time `json:"deadline"`
+	FinishTime time.Time `json:"finish_time"`
+}
+
+// UnsafeRecoveryController recovers the regions that lost the majority of
+// voters after some stores are lost permanently. The recovery is driven by
+// the store heartbeats, and goes on in steps. In each step, every surviving
+// store gets a recovery plan, which may be empty, in the heartbeat response,
+// and reports the states of its peers in a later heartbeat after executing the
+// plan. Once all surviving stores report, the next step is planned from the
+// reports:
+//  1. Collecting reports: the stores only report.
+//  2. Force leader: for each region that lost the majority, the surviving
+//     voter with the most logs is forced to be the leader.
+//  3. Demote failed voters: the forced leaders demote the voters on the failed
+//     stores to learners, so that the regions have the majority again, and
+//     the learners are removed later by the replica checker.
+//
+// The recovery fails if it is not finished before the deadline or is aborted,
+// and the stores are asked to exit the force leaders then.
+type UnsafeRecoveryController struct {
+	sync.Mutex
+	cluster *RaftCluster
+	// enabled is false until the store heartbeats can carry the store reports
+	// and the recovery plans.
+	enabled bool
+
+	stage        string
+	step         uint64
+	failedStores map[uint64]struct{}
+	// storeReports are the reports of the current step.
+	storeReports map[uint64]*StoreReport
+	// storePlans are the plans of the current step.
+	storePlans map[uint64]*RecoveryPlan
+	// forceLeaderStores are the stores which may have force leaders, and are
+	// asked to exit them after the recovery ends.
+	forceLeaderStores map[uint64]struct{}
+	plans             map[uint64]*RegionRecoveryPlan
+	lostRegions       []uint64
+	err               string
+	startTime         time.Time
+	deadline          time.Time
+	finishTime        time.Time
+}
+
+func newUnsafeRecoveryController(cluster *RaftCluster) *UnsafeRecoveryController {
+	return &UnsafeRecoveryController{
+		cluster: cluster,
+		stage:   UnsafeRecoveryIdle,
+	}
+}
+
+// RemoveFailedStores starts the unsafe recovery for the failed stores. It
+// fails if there is a recovery in progress, or unsafe recovery is disabled.
+func (u *UnsafeRecoveryController) RemoveFailedStores(storeIDs []uint64, timeout time.Duration) error {
+	u.Lock()
+	defer u.Unlock()
+	if !u.enabled {
+		return errs.ErrUnsafeRecoveryNotSupported.FastGenByArgs()
+	}
+	if u.isRunningLocked() {
+		return errs.ErrUnsafeRecoveryIsRunning.FastGenByArgs()
+	}
+	if len(storeIDs) == 0 {
+		return errs.ErrUnsafeRecoveryInvalidInput.FastGenByArgs("no failed store is specified")
+	}
+	if timeout <= 0 {
+		return errs.ErrUnsafeRecoveryInvalidInput.FastGenByArgs("timeout should be positive")
+	}
+	failedStores := make(map[uint64]struct{}, len(storeIDs))
+	for _, id := range storeIDs {
+		store := u.cluster.GetStore(id)
+		if store == nil || store.IsTombstone() {
+			return errs.ErrUnsafeRecoveryInvalidInput.FastGenByArgs(fmt.Sprintf("store %d does not exist or is tombstone", id))
+		}
+		failedStores[id] = struct{}{}
+	}
+	u.failedStores = failedStores
+	if len(u.survivingStoresLocked()) == 0 {
+		u.failedStores = nil
+		return errs.ErrUnsafeRecoveryInvalidInput.FastGenByArgs("no surviving store")
+	}
+	u.stage = UnsafeRecoveryCollectingReports
+	u.step++
+	u.storeReports = make(map[uint64]*StoreReport)
+	u.storePlans = make(map[uint64]*RecoveryPlan)
+	u.forceLeaderStores = make(map[uint64]struct{})
+	u.plans = make(map[uint64]*RegionRecoveryPlan)
+	u.lostRegions, u.err = nil, ""
+	u.startTime, u.finishTime = time.Now(), time.Time{}
+	u.deadline = u.startTime.Add(timeout)
+	log.Warn("unsafe recovery is started", zap.Uint64s("failed-stores", storeIDs), zap.Duration("timeout", timeout))
+	return nil
+}
+
+// Abort stops the current recovery.
+func (u *UnsafeRecoveryController) Abort() error {
+	u.Lock()
+	defer u.Unlock()
+	if !u.isRunningLocked() {
+		return errs.ErrUnsafeRecoveryIsNotRunning.FastGenByArgs()
+	}
+	u.failLocked("aborted")
+	return nil
+}
+
+// handleStoreReport collects the report of the store, and returns the
+// recovery plan of the store, or nil if there is not any. The report and the
+// plan are supposed to be carried by the store heartbeats, which is not
+// supported by the kvproto in use yet.
+func (u *UnsafeRecoveryController) handleStoreReport(storeID uint64, report *StoreReport) *RecoveryPlan {
+	u.Lock()
+	defer u.Unlock()
+	if u.isRunningLocked() && time.Now().After(u.deadline) {
+		u.failLocked("timeout")
+	}
+	if u.isRunningLocked() && !u.isFailedStore(storeID) && report != nil && report.GetStep() == u.step {
+		u.storeReports[storeID] = report
+		if len(u.waitingStoresLocked()) == 0 {
+			u.nextStepLocked()
+		}
+	}
+	if !u.isRunningLocked() {
+		if _, ok := u.forceLeaderStores[storeID]; ok {
+			delete(u.forceLeaderStores, storeID)
+			// An empty plan tells the store to exit the force leaders.
+			return &RecoveryPlan{Step: u.step}
+		}
+		return nil
+	}
+	if _, ok := u.storeReports[storeID]; ok || u.isFailedStore(storeID) {
+		return nil
+	}
+	if plan := u.storePlans[storeID]; plan != nil {
+		return plan
+	}
+	return &RecoveryPlan{Step: u.step}
+}
+
+// Show returns the progress of the current recovery, or the report of the
+// last recovery.
+func (u *UnsafeRecoveryController) Show() *UnsafeRecoveryProgress {
+	u.Lock()
+	defer u.Unlock()
+	if u.isRunningLocked() && time.Now().After(u.deadline) {
+		u.failLocked("timeout")
+	}
+	progress := &UnsafeRecoveryProgress{
+		Stage:        u.stage,
+		Step:         u.step,
+		FailedStores: sortedIDs(u.failedStores),
+		LostRegions:  u.lostRegions,
+		Error:        u.err,
+		StartTime:    u.startTime,
+		Deadline:     u.deadline,
+		FinishTime:   u.finishTime,
+	}
+	if u.isRunningLocked() {
+		for id := range u.storeReports {
+			progress.ReportedStores = append(progress.ReportedStores, id)
+		}
+		sort.Slice(progress.ReportedStores, func(i, j int) bool { return progress.ReportedStores[i] < progress.ReportedStores[j] })
+		progress.WaitingStores = u.waitingStoresLocked()
+	}
+	for _, plan := range u.plans {
+		progress.Plans = append(progress.Plans, plan)
+	}
+	sort.Slice(progress.Plans, func(i, j int) bool { return progress.Plans[i].RegionID < progress.Plans[j].RegionID })
+	return progress
+}
+
+func (u *UnsafeRecoveryController) isRunningLocked() bool {
+	return u.stage != UnsafeRecoveryIdle && u.stage != UnsafeRecoveryFinished && u.stage != UnsafeRecoveryFailed
+}
+
+func (u *UnsafeRecoveryController) isFailedStore(storeID uint64) bool {
+	_, ok := u.failedStores[storeID]
+	return ok
+}
+
+// survivingStoresLocked returns the stores that are expected to report.
+func (u *UnsafeRecoveryController) survivingStoresLocked() []uint64 {
+	var ids []uint64
+	for _, s := range u.cluster.GetStores() {
+		if s.IsTombstone() || s.IsDisconnected() || u.isFailedStore(s.GetID()) {
+			continue
+		}
+		ids = append(ids, s.GetID())
+	}
+	return ids
+}
+
+func (u *UnsafeRecoveryController) waitingStoresLocked() []uint64 {
+	var ids []uint64
+	for _, id := range u.survivingStoresLocked() {
+		if _, ok := u.storeReports[id]; !ok {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// nextStepLocked plans the next step with the reports of the current step.
+func (u *UnsafeRecoveryController) nextStepLocked() {
+	peers := u.collectPeersLocked()
+	switch u.stage {
+	case UnsafeRecoveryCollectingReports:
+		u.generatePlansLocked(peers)
+		log.Warn("unsafe recovery plans are generated", zap.Int("plans", len(u.plans)), zap.Uint64s("lost-regions", u.lostRegions))
+		u.stage = UnsafeRecoveryForceLeader
+	case UnsafeRecoveryForceLeader:
+		forced := true
+		for _, plan := range u.plans {
+			p := peers[plan.RegionID][plan.Leader.GetStoreId()]
+			plan.ForcedLeader = p != nil && p.GetIsForceLeader()
+			forced = forced && plan.ForcedLeader
+		}
+		if forced {
+			u.stage = UnsafeRecoveryDemoteFailedVoters
+		}
+	case UnsafeRecoveryDemoteFailedVoters:
+		for _, plan := range u.plans {
+			p := peers[plan.RegionID][plan.Leader.GetStoreId()]
+			plan.Finished = p != nil && !u.hasFailedVoters(p.GetRegionState().GetRegion())
+		}
+	}
+
+	u.step++
+	u.storeReports = make(map[uint64]*StoreReport)
+	u.storePlans = make(map[uint64]*RecoveryPlan)
+	finished := true
+	for _, plan := range u.plans {
+		if plan.Finished {
+			continue
+		}
+		finished = false
+		storeID := plan.Leader.GetStoreId()
+		storePlan := u.storePlans[storeID]
+		if storePlan == nil {
+			storePlan = &RecoveryPlan{Step: u.step}
+			u.storePlans[storeID] = storePlan
+		}
+		if u.stage == UnsafeRecoveryForceLeader {
+			if storePlan.ForceLeader == nil {
+				storePlan.ForceLeader = &ForceLeader{FailedStores: sortedIDs(u.failedStores)}
+			}
+			storePlan.ForceLeader.EnterForceLeaders = append(storePlan.ForceLeader.EnterForceLeaders, plan.RegionID)
+			u.forceLeaderStores[storeID] = struct{}{}
+		} else {
+			storePlan.Demotes = append(storePlan.Demotes, &DemoteFailedVoters{RegionID: plan.RegionID, FailedVoters: plan.FailedVoters})
+		}
+	}
+	if finished {
+		u.stage = UnsafeRecoveryFinished
+		u.finishTime = time.Now()
+		log.Warn("unsafe recovery is finished", zap.Int("recovered-regions", len(u.plans)), zap.Uint64s("lost-regions", u.lostRegions))
+	}
+}
+
+// collectPeersLocked returns the reported peers by region and store.
+func (u *UnsafeRecoveryController) collectPeersLocked() map[uint64]map[uint64]*PeerReport {
+	peers := make(map[uint64]map[uint64]*PeerReport)
+	for storeID, report := range u.storeReports {
+		for _, p := range report.GetPeerReports() {
+			state := p.GetRegionState()
+			if state.GetState() == raft_serverpb.PeerState_Tombstone || state.GetRegion() == nil {
+				continue
+			}
+			regionID := state.GetRegion().GetId()
+			if peers[regionID] == nil {
+				peers[regionID] = make(map[uint64]*PeerReport)
+			}
+			peers[regionID][storeID] = p
+		}
+	}
+	return peers
+}
+
+// generatePlansLocked makes the plans for the regions that lost the majority
+// from the reported peers, since the regions in the cache may be stale.
+func (u *UnsafeRecoveryController) generatePlansLocked(peers map[uint64]map[uint64]*PeerReport) {
+	for regionID, reports := range peers {
+		// The peer with the latest epoch has the latest peer list.
+		var latest *metapb.Region
+		for _, p := range reports {
+			region := p.GetRegionState().GetRegion()
+			if latest == nil || isNewerEpoch(region.GetRegionEpoch(), latest.GetRegionEpoch()) {
+				latest = region
+			}
+		}
+		var voters, alive int
+		var failed []*metapb.Peer
+		var leader *metapb.Peer
+		var leaderReport *PeerReport
+		for _, p := range latest.GetPeers() {
+			if p.GetRole() == metapb.PeerRole_Learner {
+				continue
+			}
+			voters++
+			if u.isFailedStore(p.GetStoreId()) {
+				failed = append(failed, p)
+				continue
+			}
+			report, ok := reports[p.GetStoreId()]
+			if !ok {
+				continue
+			}
+			alive++
+			if leader == nil || hasMoreLogs(report.GetRaftState(), leaderReport.GetRaftState()) {
+				leader, leaderReport = p, report
+			}
+		}
+		if len(failed) == 0 || alive > voters/2 {
+			// The region still has the majority of voters.
+			continue
+		}
+		if leader == nil {
+			u.lostRegions = append(u.lostRegions, regionID)
+			continue
+		}
+		u.plans[regionID] = &RegionRecoveryPlan{
+			RegionID:     regionID,
+			Leader:       leader,
+			FailedVoters: failed,
+		}
+	}
+	// The regions of which no peer is reported are all on the failed stores.
+	for _, region := range u.cluster.GetRegions() {
+		if _, ok := peers[region.GetID()]; ok {
+			continue
+		}
+		if u.hasFailedVoters(region.GetMeta()) {
+			u.lostRegions = append(u.lostRegions, region.GetID())
+		}
+	}
+	sort.Slice(u.lostRegions, func(i, j int) bool { return u.lostRegions[i] < u.lostRegions[j] })
+}
+
+func (u *UnsafeRecoveryController) hasFailedVoters(region *metapb.Region) bool {
+	for _, p := range region.GetPeers() {
+		if p.GetRole() != metapb.PeerRole_Learner && u.isFailedStore(p.GetStoreId()) {
+			return true
+		}
+	}
+	return false
+}
+
+func (u *UnsafeRecoveryController) failLocked(reason string) {
+	u.stage = UnsafeRecoveryFailed
+	u.err = reason
+	u.finishTime = time.Now()
+	u.step++
+	log.Warn("unsafe recovery failed", zap.String("reason", reason))
+}
+
+func isNewerEpoch(a, b *metapb.RegionEpoch) bool {
+	if a.GetVersion() != b.GetVersion() {
+		return a.GetVersion() > b.GetVersion()
+	}
+	return a.GetConfVer() > b.GetConfVer()
+}
+
+func hasMoreLogs(a, b *raft_serverpb.RaftLocalState) bool {
+	if a.GetHardState().GetTerm() != b.GetHardState().GetTerm() {
+		return a.GetHardState().GetTerm() > b.GetHardState().GetTerm()
+	}
+	return a.GetLastIndex() > b.GetLastIndex()
+}
+
+func sortedIDs(m map[uint64]struct{}) []uint64 {
+	ids := make([]uint64, 0, len(m))
+	for id := range m {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
@@ -0,0 +1,207 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"time"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/kvproto/pkg/eraftpb"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/kvproto/pkg/raft_serverpb"
+	"github.com/tikv/pd/server/core"
+)
+
+var _ = Suite(&testUnsafeRecoverySuite{})
+
+type testUnsafeRecoverySuite struct{}
+
+func newRecoveryRegionMeta(id uint64, storeIDs ...uint64) *metapb.Region {
+	var peers []*metapb.Peer
+	for _, storeID := range storeIDs {
+		peers = append(peers, &metapb.Peer{Id: id*10 + storeID, StoreId: storeID})
+	}
+	return &metapb.Region{
+		Id:          id,
+		Peers:       peers,
+		StartKey:    []byte{byte(id)},
+		EndKey:      []byte{byte(id + 1)},
+		RegionEpoch: &metapb.RegionEpoch{ConfVer: 1, Version: 1},
+	}
+}
+
+func newPeerReport(region *metapb.Region, lastIndex uint64, forceLeader bool) *PeerReport {
+	return &PeerReport{
+		RaftState:     &raft_serverpb.RaftLocalState{HardState: &eraftpb.HardState{Term: 5}, LastIndex: lastIndex},
+		RegionState:   &raft_serverpb.RegionLocalState{Region: region},
+		IsForceLeader: forceLeader,
+	}
+}
+
+func (s *testUnsafeRecoverySuite) newTestCluster(c *C, storeCount uint64) *testCluster {
+	_, opt, err := newTestScheduleConfig()
+	c.Assert(err, IsNil)
+	tc := newTestCluster(opt)
+	for _, store := range newTestStores(storeCount, "5.0.0") {
+		c.Assert(tc.putStoreLocked(store.Clone(core.SetLastHeartbeatTS(time.Now()))), IsNil)
+	}
+	return tc
+}
+
+// newEnabledUnsafeRecoveryController returns a controller with unsafe recovery
+// enabled, which is disabled by default.
+func newEnabledUnsafeRecoveryController(tc *testCluster) *UnsafeRecoveryController {
+	u := newUnsafeRecoveryController(tc.RaftCluster)
+	u.enabled = true
+	return u
+}
+
+func (s *testUnsafeRecoverySuite) TestRemoveFailedStores(c *C) {
+	tc := s.newTestCluster(c, 5)
+	// Region 1 and 5 lose the majority, and region 4 has no surviving voter.
+	regions := map[uint64]*metapb.Region{
+		1: newRecoveryRegionMeta(1, 1, 2, 3),
+		2: newRecoveryRegionMeta(2, 1, 4, 5),
+		3: newRecoveryRegionMeta(3, 1, 2, 4),
+		4: newRecoveryRegionMeta(4, 2, 3),
+		5: newRecoveryRegionMeta(5, 1, 4, 2, 3),
+	}
+	for _, region := range regions {
+		c.Assert(tc.processRegionHeartbeat(core.NewRegionInfo(region, region.Peers[0])), IsNil)
+	}
+
+	u := newEnabledUnsafeRecoveryController(tc)
+	c.Assert(u.Show().Stage, Equals, UnsafeRecoveryIdle)
+	c.Assert(u.RemoveFailedStores(nil, time.Minute), NotNil)
+	c.Assert(u.RemoveFailedStores([]uint64{2, 6}, time.Minute), NotNil)
+	c.Assert(u.RemoveFailedStores([]uint64{1, 2, 3, 4, 5}, time.Minute), NotNil)
+	c.Assert(u.RemoveFailedStores([]uint64{2, 3}, 0), NotNil)
+	c.Assert(u.RemoveFailedStores([]uint64{2, 3}, time.Minute), IsNil)
+	c.Assert(u.RemoveFailedStores([]uint64{2, 3}, time.Minute), NotNil)
+
+	// The surviving stores are asked to report.
+	plan := u.handleStoreReport(1, nil)
+	c.Assert(plan, NotNil)
+	step := plan.GetStep()
+	c.Assert(plan.GetForceLeader(), IsNil)
+	c.Assert(u.handleStoreReport(2, nil), IsNil)
+	reports := map[uint64][]*PeerReport{
+		1: {newPeerReport(regions[1], 10, false), newPeerReport(regions[2], 10, false), newPeerReport(regions[3], 10, false), newPeerReport(regions[5], 10, false)},
+		4: {newPeerReport(regions[2], 10, false), newPeerReport(regions[3], 10, false), newPeerReport(regions[5], 11, false)},
+		5: {newPeerReport(regions[2], 10, false)},
+	}
+	c.Assert(u.handleStoreReport(1, &StoreReport{PeerReports: reports[1], Step: step}), IsNil)
+	// The report of another step is ignored.
+	c.Assert(u.handleStoreReport(4, &StoreReport{PeerReports: reports[4], Step: step - 1}), NotNil)
+	progress := u.Show()
+	c.Assert(progress.Stage, Equals, UnsafeRecoveryCollectingReports)
+	c.Assert(progress.FailedStores, DeepEquals, []uint64{2, 3})
+	c.Assert(progress.ReportedStores, DeepEquals, []uint64{1})
+	c.Assert(progress.WaitingStores, DeepEquals, []uint64{4, 5})
+	c.Assert(u.handleStoreReport(4, &StoreReport{PeerReports: reports[4], Step: step}), IsNil)
+
+	// The plans are made from the reports once all surviving stores report.
+	plan = u.handleStoreReport(5, &StoreReport{PeerReports: reports[5], Step: step})
+	c.Assert(plan.GetStep(), Equals, step+1)
+	c.Assert(plan.GetForceLeader(), IsNil)
+	step = plan.GetStep()
+	progress = u.Show()
+	c.Assert(progress.Stage, Equals, UnsafeRecoveryForceLeader)
+	c.Assert(progress.LostRegions, DeepEquals, []uint64{4})
+	c.Assert(progress.Plans, HasLen, 2)
+	c.Assert(progress.Plans[0].RegionID, Equals, uint64(1))
+	c.Assert(progress.Plans[0].Leader.GetStoreId(), Equals, uint64(1))
+	c.Assert(progress.Plans[0].FailedVoters, HasLen, 2)
+	// The peer with more logs is selected.
+	c.Assert(progress.Plans[1].RegionID, Equals, uint64(5))
+	c.Assert(progress.Plans[1].Leader.GetStoreId(), Equals, uint64(4))
+	for storeID, regionID := range map[uint64]uint64{1: 1, 4: 5} {
+		plan = u.handleStoreReport(storeID, nil)
+		c.Assert(plan.GetStep(), Equals, step)
+		c.Assert(plan.GetForceLeader().GetFailedStores(), DeepEquals, []uint64{2, 3})
+		c.Assert(plan.GetForceLeader().GetEnterForceLeaders(), DeepEquals, []uint64{regionID})
+	}
+
+	// Region 5 fails to force the leader, so that it is retried.
+	reports[1][0].IsForceLeader = true
+	c.Assert(u.handleStoreReport(1, &StoreReport{PeerReports: reports[1], Step: step}), IsNil)
+	c.Assert(u.handleStoreReport(4, &StoreReport{PeerReports: reports[4], Step: step}), IsNil)
+	c.Assert(u.handleStoreReport(5, &StoreReport{PeerReports: reports[5], Step: step}), NotNil)
+	step++
+	progress = u.Show()
+	c.Assert(progress.Stage, Equals, UnsafeRecoveryForceLeader)
+	c.Assert(progress.Plans[0].ForcedLeader, IsTrue)
+	c.Assert(progress.Plans[1].ForcedLeader, IsFalse)
+	c.Assert(u.handleStoreReport(4, nil).GetForceLeader().GetEnterForceLeaders(), DeepEquals, []uint64{5})
+	reports[4][2].IsForceLeader = true
+	for _, storeID := range []uint64{1, 4, 5} {
+		u.handleStoreReport(storeID, &StoreReport{PeerReports: reports[storeID], Step: step})
+	}
+	step++
+
+	// The forced leaders demote the failed voters.
+	c.Assert(u.Show().Stage, Equals, UnsafeRecoveryDemoteFailedVoters)
+	plan = u.handleStoreReport(1, nil)
+	c.Assert(plan.GetForceLeader(), IsNil)
+	c.Assert(plan.GetDemotes(), HasLen, 1)
+	c.Assert(plan.GetDemotes()[0].GetRegionID(), Equals, uint64(1))
+	c.Assert(plan.GetDemotes()[0].GetFailedVoters(), DeepEquals, progress.Plans[0].FailedVoters)
+	for _, regionID := range []uint64{1, 5} {
+		for _, p := range regions[regionID].GetPeers() {
+			if p.GetStoreId() == 2 || p.GetStoreId() == 3 {
+				p.Role = metapb.PeerRole_Learner
+			}
+		}
+	}
+	for _, storeID := range []uint64{1, 4, 5} {
+		u.handleStoreReport(storeID, &StoreReport{PeerReports: reports[storeID], Step: step})
+	}
+	progress = u.Show()
+	c.Assert(progress.Stage, Equals, UnsafeRecoveryFinished)
+	c.Assert(progress.Plans[0].Finished, IsTrue)
+	c.Assert(progress.Plans[1].Finished, IsTrue)
+	c.Assert(progress.FinishTime.IsZero(), IsFalse)
+
+	// The stores are told to exit the force leaders once.
+	plan = u.handleStoreReport(4, nil)
+	c.Assert(plan, NotNil)
+	c.Assert(plan.GetForceLeader(), IsNil)
+	c.Assert(plan.GetDemotes(), HasLen, 0)
+	c.Assert(u.handleStoreReport(4, nil), IsNil)
+	c.Assert(u.handleStoreReport(5, nil), IsNil)
+}
+
+func (s *testUnsafeRecoverySuite) TestTimeoutAndAbort(c *C) {
+	tc := s.newTestCluster(c, 3)
+	// Unsafe recovery is disabled until the store heartbeats carry the reports.
+	c.Assert(newUnsafeRecoveryController(tc.RaftCluster).RemoveFailedStores([]uint64{3}, time.Minute), NotNil)
+	u := newEnabledUnsafeRecoveryController(tc)
+	c.Assert(u.Abort(), NotNil)
+
+	c.Assert(u.RemoveFailedStores([]uint64{3}, time.Millisecond), IsNil)
+	time.Sleep(10 * time.Millisecond)
+	progress := u.Show()
+	c.Assert(progress.Stage, Equals, UnsafeRecoveryFailed)
+	c.Assert(progress.Error, Equals, "timeout")
+	c.Assert(u.handleStoreReport(1, nil), IsNil)
+
+	c.Assert(u.RemoveFailedStores([]uint64{3}, time.Minute), IsNil)
+	c.Assert(u.handleStoreReport(1, nil), NotNil)
+	c.Assert(u.Abort(), IsNil)
+	progress = u.Show()
+	c.Assert(progress.Stage, Equals, UnsafeRecoveryFailed)
+	c.Assert(progress.Error, Equals, "aborted")
+	c.Assert(u.handleStoreReport(1, nil), IsNil)
+	c.Assert(u.Abort(), NotNil)
+}
@@ -0,0 +1,148 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/kvproto/pkg/raft_serverpb"
+)
+
+// The messages below are the ones of online unsafe recovery, which are carried
+// by the store heartbeats as StoreHeartbeatRequest.store_report and
+// StoreHeartbeatResponse.recovery_plan. The kvproto in use does not have them
+// yet, so unsafe recovery is disabled until kvproto is upgraded, and these
+// types are replaced by the generated ones in pdpb then.
+
+// PeerReport is the state of a peer reported by a store.
+type PeerReport struct {
+	RaftState     *raft_serverpb.RaftLocalState
+	RegionState   *raft_serverpb.RegionLocalState
+	IsForceLeader bool
+}
+
+func (m *PeerReport) GetRaftState() *raft_serverpb.RaftLocalState {
+	if m != nil {
+		return m.RaftState
+	}
+	return nil
+}
+
+func (m *PeerReport) GetRegionState() *raft_serverpb.RegionLocalState {
+	if m != nil {
+		return m.RegionState
+	}
+	return nil
+}
+
+func (m *PeerReport) GetIsForceLeader() bool {
+	if m != nil {
+		return m.IsForceLeader
+	}
+	return false
+}
+
+// StoreReport is the states of all peers on a store. Step is the step of the
+// recovery plan which the store has executed before reporting.
+type StoreReport struct {
+	PeerReports []*PeerReport
+	Step        uint64
+}
+
+func (m *StoreReport) GetPeerReports() []*PeerReport {
+	if m != nil {
+		return m.PeerReports
+	}
+	return nil
+}
+
+func (m *StoreReport) GetStep() uint64 {
+	if m != nil {
+		return m.Step
+	}
+	return 0
+}
+
+// ForceLeader asks a store to make its peers of the regions leaders without
+// the votes of the peers on the failed stores.
+type ForceLeader struct {
+	FailedStores      []uint64
+	EnterForceLeaders []uint64
+}
+
+func (m *ForceLeader) GetFailedStores() []uint64 {
+	if m != nil {
+		return m.FailedStores
+	}
+	return nil
+}
+
+func (m *ForceLeader) GetEnterForceLeaders() []uint64 {
+	if m != nil {
+		return m.EnterForceLeaders
+	}
+	return nil
+}
+
+// DemoteFailedVoters asks the forced leader of a region to demote the voters
+// on the failed stores to learners.
+type DemoteFailedVoters struct {
+	RegionID     uint64
+	FailedVoters []*metapb.Peer
+}
+
+func (m *DemoteFailedVoters) GetRegionID() uint64 {
+	if m != nil {
+		return m.RegionID
+	}
+	return 0
+}
+
+func (m *DemoteFailedVoters) GetFailedVoters() []*metapb.Peer {
+	if m != nil {
+		return m.FailedVoters
+	}
+	return nil
+}
+
+// RecoveryPlan is the operations of a step of the recovery for a store. The
+// store reports with the step after executing it. The force leader is the
+// whole set of the force leaders the store should have, and a plan without any
+// operation only asks the store to report, which also makes the store exit the
+// force leaders if there are any.
+type RecoveryPlan struct {
+	ForceLeader *ForceLeader
+	Demotes     []*DemoteFailedVoters
+	Step        uint64
+}
+
+func (m *RecoveryPlan) GetForceLeader() *ForceLeader {
+	if m != nil {
+		return m.ForceLeader
+	}
+	return nil
+}
+
+func (m *RecoveryPlan) GetDemotes() []*DemoteFailedVoters {
+	if m != nil {
+		return m.Demotes
+	}
+	return nil
+}
+
+func (m *RecoveryPlan) GetStep() uint64 {
+	if m != nil {
+		return m.Step
+	}
+	return 0
+}
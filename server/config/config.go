@@ -33,11 +33,13 @@ import (
 	"github.com/tikv/pd/pkg/metricutil"
 	"github.com/tikv/pd/pkg/typeutil"
 	"github.com/tikv/pd/server/core/storelimit"
+	"github.com/tikv/pd/server/kv"
 	"github.com/tikv/pd/server/versioninfo"
 
 	"github.com/BurntSushi/toml"
 	"github.com/coreos/go-semver/semver"
 	"github.com/pingcap/errors"
+	"github.com/pingcap/kvproto/pkg/encryptionpb"
 	"github.com/pingcap/kvproto/pkg/metapb"
 	"github.com/pingcap/log"
 	"go.etcd.io/etcd/embed"
@@ -71,6 +73,10 @@ type Config struct {
 	// Join to an existing pd cluster, a string of endpoints.
 	Join string `toml:"join" json:"join"`
 
+	// Standalone runs a single pd server without the embedded etcd, which
+	// stores the metadata in LevelDB under the data directory.
+	Standalone bool `toml:"standalone" json:"standalone"`
+
 	// LeaderLease time, if leader doesn't update its TTL
 	// in etcd after lease time, etcd will expire the leader key
 	// and other servers can campaign the leader again.
@@ -174,6 +180,7 @@ func NewConfig() *Config {
 	fs.StringVar(&cfg.AdvertisePeerUrls, "advertise-peer-urls", "", "advertise url for peer traffic (default '${peer-urls}')")
 	fs.StringVar(&cfg.InitialCluster, "initial-cluster", "", "initial cluster configuration for bootstrapping, e,g. pd=http://127.0.0.1:2380")
 	fs.StringVar(&cfg.Join, "join", "", "join to an existing cluster (usage: cluster's '${advertise-client-urls}'")
+	fs.BoolVar(&cfg.Standalone, "standalone", false, "run a single pd server without the embedded etcd")
 
 	fs.StringVar(&cfg.Metric.PushAddress, "metrics-addr", "", "prometheus pushgateway address, leaves it empty will disable prometheus push")
 
@@ -398,6 +405,19 @@ func (c *Config) Validate() error {
 	if c.Join != "" && c.InitialCluster != "" {
 		return errors.New("-initial-cluster and -join can not be provided at the same time")
 	}
+	if c.Standalone {
+		if c.Join != "" {
+			return errors.New("-standalone and -join can not be provided at the same time")
+		}
+		if c.LocalTSO.EnableLocalTSO {
+			return errors.New("local tso can not be enabled in the standalone mode")
+		}
+		if c.Security.Encryption.DataEncryptionMethod != "" {
+			if m, err := c.Security.Encryption.GetMethod(); err != nil || m != encryptionpb.EncryptionMethod_PLAINTEXT {
+				return errors.New("data encryption can not be enabled in the standalone mode")
+			}
+		}
+	}
 	dataDir, err := filepath.Abs(c.DataDir)
 	if err != nil {
 		return errors.WithStack(err)
@@ -1206,6 +1226,8 @@ func (c *Config) GenEmbedEtcdConfig() (*embed.Config, error) {
 	cfg.AutoCompactionMode = c.AutoCompactionMode
 	cfg.AutoCompactionRetention = c.AutoCompactionRetention
 	cfg.QuotaBackendBytes = int64(c.QuotaBackendBytes)
+	cfg.MaxTxnOps = kv.MaxTxnOps
+	cfg.MaxRequestBytes = kv.MaxTxnBytes
 
 	allowedCN, serr := c.Security.GetOneAllowedCN()
 	if serr != nil {
@@ -156,6 +156,21 @@ func (s *testConfigSuite) TestValidation(c *C) {
 	c.Assert(cfg.Schedule.Validate(), NotNil)
 	// check quota
 	c.Assert(cfg.QuotaBackendBytes, Equals, defaultQuotaBackendBytes)
+
+	// check standalone mode
+	cfg = NewConfig()
+	cfg.Standalone = true
+	c.Assert(cfg.Adjust(nil, false), IsNil)
+	cfg.Join = "http://127.0.0.1:2379"
+	c.Assert(cfg.Validate(), NotNil)
+	cfg.Join = ""
+	cfg.LocalTSO.EnableLocalTSO = true
+	c.Assert(cfg.Validate(), NotNil)
+	cfg.LocalTSO.EnableLocalTSO = false
+	cfg.Security.Encryption.DataEncryptionMethod = "aes128-ctr"
+	c.Assert(cfg.Validate(), NotNil)
+	cfg.Security.Encryption.DataEncryptionMethod = "plaintext"
+	c.Assert(cfg.Validate(), IsNil)
 }
 
 func (s *testConfigSuite) TestAdjust(c *C) {
@@ -27,12 +27,11 @@ import (
 	"github.com/pingcap/kvproto/pkg/metapb"
 	"github.com/pingcap/log"
 	"github.com/tikv/pd/pkg/cache"
-	"github.com/tikv/pd/pkg/etcdutil"
 	"github.com/tikv/pd/pkg/slice"
 	"github.com/tikv/pd/pkg/typeutil"
 	"github.com/tikv/pd/server/core"
 	"github.com/tikv/pd/server/core/storelimit"
-	"go.etcd.io/etcd/clientv3"
+	"github.com/tikv/pd/server/kv"
 )
 
 // PersistOptions wraps all configurations that need to persist to storage and
@@ -61,6 +60,20 @@ func NewPersistOptions(cfg *Config) *PersistOptions {
 	return o
 }
 
+// Clone returns a copy of the options. The configurations of the copy can be
+// changed without affecting the original one, while the TTL configurations
+// are shared.
+func (o *PersistOptions) Clone() *PersistOptions {
+	n := &PersistOptions{ttl: o.ttl}
+	n.schedule.Store(o.GetScheduleConfig().Clone())
+	n.replication.Store(o.GetReplicationConfig().Clone())
+	n.pdServerConfig.Store(o.GetPDServerConfig().Clone())
+	n.replicationMode.Store(o.GetReplicationModeConfig().Clone())
+	n.labelProperty.Store(o.GetLabelPropertyConfig().Clone())
+	n.SetClusterVersion(o.GetClusterVersion())
+	return n
+}
+
 // GetScheduleConfig returns scheduling configurations.
 func (o *PersistOptions) GetScheduleConfig() *ScheduleConfig {
 	return o.schedule.Load().(*ScheduleConfig)
@@ -553,7 +566,17 @@ func (o *PersistOptions) DeleteLabelProperty(typ, labelKey, labelValue string) {
 
 // Persist saves the configuration to the storage.
 func (o *PersistOptions) Persist(storage *core.Storage) error {
-	cfg := &Config{
+	return storage.SaveConfig(o.persistedConfig())
+}
+
+// PersistToBatch adds the configuration to the batch, which saves it with
+// other updates atomically.
+func (o *PersistOptions) PersistToBatch(batch *core.Batch) error {
+	return batch.SaveConfig(o.persistedConfig())
+}
+
+func (o *PersistOptions) persistedConfig() *Config {
+	return &Config{
 		Schedule:        *o.GetScheduleConfig(),
 		Replication:     *o.GetReplicationConfig(),
 		PDServerCfg:     *o.GetPDServerConfig(),
@@ -561,7 +584,6 @@ func (o *PersistOptions) Persist(storage *core.Storage) error {
 		LabelProperty:   o.GetLabelPropertyConfig(),
 		ClusterVersion:  *o.GetClusterVersion(),
 	}
-	return storage.SaveConfig(cfg)
 }
 
 // Reload reloads the configuration from the storage.
@@ -614,12 +636,11 @@ func (o *PersistOptions) CheckLabelProperty(typ string, labels []*metapb.StoreLa
 const ttlConfigPrefix = "/config/ttl"
 
 // SetTTLData set temporary configuration
-func (o *PersistOptions) SetTTLData(parCtx context.Context, client *clientv3.Client, key string, value string, ttl time.Duration) error {
+func (o *PersistOptions) SetTTLData(parCtx context.Context, kvBase kv.TTLBase, key string, value string, ttl time.Duration) error {
 	if o.ttl == nil {
 		o.ttl = cache.NewStringTTL(parCtx, time.Second*5, time.Minute*5)
 	}
-	_, err := etcdutil.EtcdKVPutWithTTL(parCtx, client, ttlConfigPrefix+"/"+key, value, int64(ttl.Seconds()))
-	if err != nil {
+	if err := kvBase.SaveWithTTL(ttlConfigPrefix+"/"+key, value, ttl); err != nil {
 		return err
 	}
 	o.ttl.PutWithTTL(key, value, ttl)
@@ -674,36 +695,29 @@ func (o *PersistOptions) getTTLData(key string) (string, bool) {
 	return "", false
 }
 
-// LoadTTLFromEtcd loads temporary configuration which was persisted into etcd
-func (o *PersistOptions) LoadTTLFromEtcd(ctx context.Context, client *clientv3.Client) error {
-	resps, err := etcdutil.EtcdKVGet(client, ttlConfigPrefix, clientv3.WithPrefix())
+// LoadTTLFromKV loads temporary configuration which was persisted into the kv
+func (o *PersistOptions) LoadTTLFromKV(ctx context.Context, kvBase kv.TTLBase) error {
+	keys, values, ttls, err := kvBase.LoadWithTTL(ttlConfigPrefix + "/")
 	if err != nil {
 		return err
 	}
 	if o.ttl == nil {
 		o.ttl = cache.NewStringTTL(ctx, time.Second*5, time.Minute*5)
 	}
-	for _, resp := range resps.Kvs {
-		key := string(resp.Key)[len(ttlConfigPrefix)+1:]
-		value := string(resp.Value)
-		leaseID := resp.Lease
-		resp, err := client.TimeToLive(ctx, clientv3.LeaseID(leaseID))
-		if err != nil {
-			return err
-		}
-		o.ttl.PutWithTTL(key, value, time.Duration(resp.TTL)*time.Second)
+	for i, key := range keys {
+		o.ttl.PutWithTTL(key[len(ttlConfigPrefix)+1:], values[i], ttls[i])
 	}
 	return nil
 }
 
 // SetAllStoresLimitTTL sets all store limit for a given type and rate with ttl.
-func (o *PersistOptions) SetAllStoresLimitTTL(ctx context.Context, client *clientv3.Client, typ storelimit.Type, ratePerMin float64, ttl time.Duration) error {
+func (o *PersistOptions) SetAllStoresLimitTTL(ctx context.Context, kvBase kv.TTLBase, typ storelimit.Type, ratePerMin float64, ttl time.Duration) error {
 	var err error
 	switch typ {
 	case storelimit.AddPeer:
-		err = o.SetTTLData(ctx, client, "default-add-peer", fmt.Sprint(ratePerMin), ttl)
+		err = o.SetTTLData(ctx, kvBase, "default-add-peer", fmt.Sprint(ratePerMin), ttl)
 	case storelimit.RemovePeer:
-		err = o.SetTTLData(ctx, client, "default-remove-peer", fmt.Sprint(ratePerMin), ttl)
+		err = o.SetTTLData(ctx, kvBase, "default-remove-peer", fmt.Sprint(ratePerMin), ttl)
 	}
 	return err
 }
@@ -30,6 +30,9 @@ type BasicCluster struct {
 	sync.RWMutex
 	Stores  *StoresInfo
 	Regions *RegionsInfo
+	// leaderGroups are the groups of stores that leaders are only balanced
+	// within, or nil if leaders are balanced across all stores.
+	leaderGroups StoreGroups
 }
 
 // NewBasicCluster creates a BasicCluster.
@@ -145,6 +148,37 @@ func (bc *BasicCluster) ResumeLeaderTransfer(storeID uint64) {
 	bc.Stores.ResumeLeaderTransfer(storeID)
 }
 
+// SlowStoreEvicted marks a store as a slow store and prevents transferring
+// leader to the store
+func (bc *BasicCluster) SlowStoreEvicted(storeID uint64) error {
+	bc.Lock()
+	defer bc.Unlock()
+	return bc.Stores.SlowStoreEvicted(storeID)
+}
+
+// SlowStoreRecovered cleans the evicted state of a store.
+func (bc *BasicCluster) SlowStoreRecovered(storeID uint64) {
+	bc.Lock()
+	defer bc.Unlock()
+	bc.Stores.SlowStoreRecovered(storeID)
+}
+
+// SetLeaderGroups sets the groups of stores that leaders are only balanced
+// within. Setting nil makes leaders balanced across all stores again.
+func (bc *BasicCluster) SetLeaderGroups(groups StoreGroups) {
+	bc.Lock()
+	defer bc.Unlock()
+	bc.leaderGroups = groups
+}
+
+// GetLeaderGroups returns the groups of stores that leaders are only balanced
+// within, or nil if there is not any.
+func (bc *BasicCluster) GetLeaderGroups() StoreGroups {
+	bc.RLock()
+	defer bc.RUnlock()
+	return bc.leaderGroups
+}
+
 // AttachAvailableFunc attaches an available function to a specific store.
 func (bc *BasicCluster) AttachAvailableFunc(storeID uint64, limitType storelimit.Type, f func() bool) {
 	bc.Lock()
@@ -261,6 +295,13 @@ func (bc *BasicCluster) GetStoreRegionSize(storeID uint64) int64 {
 	return bc.Regions.GetStoreLeaderRegionSize(storeID) + bc.Regions.GetStoreFollowerRegionSize(storeID) + bc.Regions.GetStoreLearnerRegionSize(storeID)
 }
 
+// GetStoreWitnessRegionSize get total size of store's witness regions.
+func (bc *BasicCluster) GetStoreWitnessRegionSize(storeID uint64) int64 {
+	bc.RLock()
+	defer bc.RUnlock()
+	return bc.Regions.GetStoreWitnessRegionSize(storeID)
+}
+
 // GetAverageRegionSize returns the average region approximate size.
 func (bc *BasicCluster) GetAverageRegionSize() int64 {
 	bc.RLock()
@@ -396,6 +437,8 @@ type StoreSetInformer interface {
 	GetRegionStores(region *RegionInfo) []*StoreInfo
 	GetFollowerStores(region *RegionInfo) []*StoreInfo
 	GetLeaderStore(region *RegionInfo) *StoreInfo
+
+	GetLeaderGroups() StoreGroups
 }
 
 // StoreSetController is used to control stores' status.
@@ -403,7 +446,18 @@ type StoreSetController interface {
 	PauseLeaderTransfer(id uint64) error
 	ResumeLeaderTransfer(id uint64)
 
+	SlowStoreEvicted(id uint64) error
+	SlowStoreRecovered(id uint64)
+
 	AttachAvailableFunc(id uint64, limitType storelimit.Type, f func() bool)
+
+	SetLeaderGroups(groups StoreGroups)
+}
+
+// StoreGroups divides the stores into groups.
+type StoreGroups interface {
+	// InSameGroup checks if the two stores are in the same group.
+	InSameGroup(a, b *StoreInfo) bool
 }
 
 // KeyRange is a key range.
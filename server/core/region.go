@@ -41,6 +41,7 @@ type RegionInfo struct {
 	meta              *metapb.Region
 	learners          []*metapb.Peer
 	voters            []*metapb.Peer
+	witnesses         []*metapb.Peer
 	leader            *metapb.Peer
 	downPeers         []*pdpb.PeerStats
 	pendingPeers      []*metapb.Peer
@@ -69,6 +70,7 @@ func NewRegionInfo(region *metapb.Region, leader *metapb.Peer, opts ...RegionCre
 }
 
 // classifyVoterAndLearner sorts out voter and learner from peers into different slice.
+// The witnesses which are not followers any more are also removed.
 func classifyVoterAndLearner(region *RegionInfo) {
 	learners := make([]*metapb.Peer, 0, 1)
 	voters := make([]*metapb.Peer, 0, len(region.meta.Peers))
@@ -81,6 +83,20 @@ func classifyVoterAndLearner(region *RegionInfo) {
 	}
 	region.learners = learners
 	region.voters = voters
+
+	if len(region.witnesses) == 0 {
+		return
+	}
+	witnesses := make([]*metapb.Peer, 0, len(region.witnesses))
+	for _, w := range region.witnesses {
+		for _, p := range voters {
+			if p.GetId() == w.GetId() && p.GetId() != region.leader.GetId() {
+				witnesses = append(witnesses, p)
+				break
+			}
+		}
+	}
+	region.witnesses = witnesses
 }
 
 // EmptyRegionApproximateSize is the region approximate size of an empty region
@@ -141,6 +157,7 @@ func (r *RegionInfo) Clone(opts ...RegionCreateOption) *RegionInfo {
 		approximateKeys:   r.approximateKeys,
 		interval:          proto.Clone(r.interval).(*pdpb.TimeInterval),
 		replicationStatus: r.replicationStatus,
+		witnesses:         r.witnesses,
 	}
 
 	for _, opt := range opts {
@@ -265,6 +282,22 @@ func (r *RegionInfo) GetStoreLearner(storeID uint64) *metapb.Peer {
 	return nil
 }
 
+// GetWitnesses returns the witness peers, which are the followers that vote
+// but do not hold data. They are decided by the placement rules.
+func (r *RegionInfo) GetWitnesses() []*metapb.Peer {
+	return r.witnesses
+}
+
+// GetStoreWitness returns the witness peer in specified store.
+func (r *RegionInfo) GetStoreWitness(storeID uint64) *metapb.Peer {
+	for _, peer := range r.witnesses {
+		if peer.GetStoreId() == storeID {
+			return peer
+		}
+	}
+	return nil
+}
+
 // GetStoreIds returns a map indicate the region distributed.
 func (r *RegionInfo) GetStoreIds() map[uint64]struct{} {
 	peers := r.meta.GetPeers()
@@ -565,6 +598,7 @@ type RegionsInfo struct {
 	leaders      map[uint64]*regionSubTree // storeID -> regionSubTree
 	followers    map[uint64]*regionSubTree // storeID -> regionSubTree
 	learners     map[uint64]*regionSubTree // storeID -> regionSubTree
+	witnesses    map[uint64]*regionSubTree // storeID -> regionSubTree
 	pendingPeers map[uint64]*regionSubTree // storeID -> regionSubTree
 }
 
@@ -576,6 +610,7 @@ func NewRegionsInfo() *RegionsInfo {
 		leaders:      make(map[uint64]*regionSubTree),
 		followers:    make(map[uint64]*regionSubTree),
 		learners:     make(map[uint64]*regionSubTree),
+		witnesses:    make(map[uint64]*regionSubTree),
 		pendingPeers: make(map[uint64]*regionSubTree),
 	}
 }
@@ -677,6 +712,17 @@ func (r *RegionsInfo) AddRegion(region *RegionInfo) []*RegionInfo {
 		store.update(region)
 	}
 
+	// Add to witnesses, which are also in followers.
+	for _, peer := range region.GetWitnesses() {
+		storeID := peer.GetStoreId()
+		store, ok := r.witnesses[storeID]
+		if !ok {
+			store = newRegionSubTree()
+			r.witnesses[storeID] = store
+		}
+		store.update(region)
+	}
+
 	for _, peer := range region.pendingPeers {
 		storeID := peer.GetStoreId()
 		store, ok := r.pendingPeers[storeID]
@@ -713,6 +759,7 @@ func (r *RegionsInfo) removeRegionFromSubTree(region *RegionInfo) {
 		r.leaders[storeID].remove(region)
 		r.followers[storeID].remove(region)
 		r.learners[storeID].remove(region)
+		r.witnesses[storeID].remove(region)
 		r.pendingPeers[storeID].remove(region)
 	}
 }
@@ -750,6 +797,7 @@ func (r *RegionsInfo) shouldRemoveFromSubTree(region *RegionInfo, origin *Region
 	return origin.leader.GetId() != region.leader.GetId() ||
 		checkPeersChange(origin.GetVoters(), region.GetVoters()) ||
 		checkPeersChange(origin.GetLearners(), region.GetLearners()) ||
+		checkPeersChange(origin.GetWitnesses(), region.GetWitnesses()) ||
 		checkPeersChange(origin.GetPendingPeers(), region.GetPendingPeers())
 }
 
@@ -810,6 +858,11 @@ func (r *RegionsInfo) GetStoreLearnerRegionSize(storeID uint64) int64 {
 	return r.learners[storeID].TotalSize()
 }
 
+// GetStoreWitnessRegionSize get total size of store's witness regions
+func (r *RegionsInfo) GetStoreWitnessRegionSize(storeID uint64) int64 {
+	return r.witnesses[storeID].TotalSize()
+}
+
 // GetStoreRegionSize get total size of store's regions
 func (r *RegionsInfo) GetStoreRegionSize(storeID uint64) int64 {
 	return r.GetStoreLeaderRegionSize(storeID) + r.GetStoreFollowerRegionSize(storeID) + r.GetStoreLearnerRegionSize(storeID)
@@ -849,6 +902,11 @@ func (r *RegionsInfo) GetStoreFollowerCount(storeID uint64) int {
 	return r.followers[storeID].length()
 }
 
+// GetStoreWitnessCount get the total count of a store's witness RegionInfo
+func (r *RegionsInfo) GetStoreWitnessCount(storeID uint64) int {
+	return r.witnesses[storeID].length()
+}
+
 // GetStoreLearnerCount get the total count of a store's learner RegionInfo
 func (r *RegionsInfo) GetStoreLearnerCount(storeID uint64) int {
 	return r.learners[storeID].length()
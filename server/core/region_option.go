@@ -54,6 +54,14 @@ func WithLearners(learners []*metapb.Peer) RegionCreateOption {
 	}
 }
 
+// WithWitnesses sets the witness peers for the region. The peers which are
+// not followers of the region are ignored.
+func WithWitnesses(witnesses []*metapb.Peer) RegionCreateOption {
+	return func(region *RegionInfo) {
+		region.witnesses = witnesses
+	}
+}
+
 // WithLeader sets the leader for the region.
 func WithLeader(leader *metapb.Peer) RegionCreateOption {
 	return func(region *RegionInfo) {
@@ -244,6 +244,37 @@ func (*testRegionKey) TestShouldRemoveFromSubTree(c *C) {
 	c.Assert(regions.shouldRemoveFromSubTree(region, origin), Equals, true)
 }
 
+func (*testRegionKey) TestWitness(c *C) {
+	regions := NewRegionsInfo()
+	peer1 := &metapb.Peer{StoreId: uint64(1), Id: uint64(1)}
+	peer2 := &metapb.Peer{StoreId: uint64(2), Id: uint64(2)}
+	peer3 := &metapb.Peer{StoreId: uint64(3), Id: uint64(3)}
+	region := NewRegionInfo(&metapb.Region{
+		Id:    uint64(1),
+		Peers: []*metapb.Peer{peer1, peer2, peer3},
+	}, peer1, SetApproximateSize(10), WithWitnesses([]*metapb.Peer{peer3}))
+	c.Assert(region.GetWitnesses(), DeepEquals, []*metapb.Peer{peer3})
+	c.Assert(region.GetStoreWitness(3), Equals, peer3)
+	c.Assert(region.GetStoreWitness(2), IsNil)
+	regions.SetRegion(region)
+	c.Assert(regions.GetStoreWitnessCount(3), Equals, 1)
+	c.Assert(regions.GetStoreWitnessRegionSize(3), Equals, int64(10))
+	c.Assert(regions.GetStoreFollowerRegionSize(3), Equals, int64(10))
+
+	// The witnesses are inherited when cloned.
+	region = region.Clone(SetApproximateSize(20))
+	c.Assert(region.GetWitnesses(), HasLen, 1)
+	regions.SetRegion(region)
+	c.Assert(regions.GetStoreWitnessRegionSize(3), Equals, int64(20))
+
+	// The leader or removed peer is not a witness.
+	c.Assert(region.Clone(WithLeader(peer3)).GetWitnesses(), HasLen, 0)
+	region = region.Clone(WithRemoveStorePeer(3))
+	c.Assert(region.GetWitnesses(), HasLen, 0)
+	regions.SetRegion(region)
+	c.Assert(regions.GetStoreWitnessCount(3), Equals, 0)
+}
+
 func checkRegions(c *C, regions *RegionsInfo) {
 	leaderMap := make(map[uint64]uint64)
 	followerMap := make(map[uint64]uint64)
@@ -31,7 +31,6 @@ import (
 	"github.com/tikv/pd/pkg/errs"
 	"github.com/tikv/pd/server/encryptionkm"
 	"github.com/tikv/pd/server/kv"
-	"go.etcd.io/etcd/clientv3"
 )
 
 const (
@@ -41,6 +40,9 @@ const (
 	gcPath                     = "gc"
 	rulesPath                  = "rules"
 	ruleGroupPath              = "rule_group"
+	ruleTemplatePath           = "rule_template"
+	regionLabelPath            = "region_label"
+	operatorRecordPath         = "operator_record"
 	replicationPath            = "replication_mode"
 	componentPath              = "component"
 	customScheduleConfigPath   = "scheduler_config"
@@ -286,6 +288,55 @@ func (s *Storage) LoadRuleGroups(f func(k, v string)) error {
 	return s.LoadRangeByPrefix(ruleGroupPath+"/", f)
 }
 
+// SaveRuleTemplate stores a rule template to storage.
+func (s *Storage) SaveRuleTemplate(name string, template interface{}) error {
+	return s.SaveJSON(ruleTemplatePath, name, template)
+}
+
+// DeleteRuleTemplate removes a rule template from storage.
+func (s *Storage) DeleteRuleTemplate(name string) error {
+	return s.Remove(path.Join(ruleTemplatePath, name))
+}
+
+// LoadRuleTemplates loads all rule templates from storage.
+func (s *Storage) LoadRuleTemplates(f func(k, v string)) error {
+	return s.LoadRangeByPrefix(ruleTemplatePath+"/", f)
+}
+
+// SaveRegionRule saves a region label rule to the storage.
+func (s *Storage) SaveRegionRule(ruleKey string, rule interface{}) error {
+	return s.SaveJSON(regionLabelPath, ruleKey, rule)
+}
+
+// DeleteRegionRule removes a region label rule from storage.
+func (s *Storage) DeleteRegionRule(ruleKey string) error {
+	return s.Remove(path.Join(regionLabelPath, ruleKey))
+}
+
+// LoadRegionRules loads region label rules from storage.
+func (s *Storage) LoadRegionRules(f func(k, v string)) error {
+	return s.LoadRangeByPrefix(regionLabelPath+"/", f)
+}
+
+// SaveOperatorRecord saves an operator record to the storage.
+func (s *Storage) SaveOperatorRecord(id uint64, record interface{}) error {
+	return s.SaveJSON(operatorRecordPath, operatorRecordKey(id), record)
+}
+
+// DeleteOperatorRecord removes an operator record from storage.
+func (s *Storage) DeleteOperatorRecord(id uint64) error {
+	return s.Remove(path.Join(operatorRecordPath, operatorRecordKey(id)))
+}
+
+func operatorRecordKey(id uint64) string {
+	return fmt.Sprintf("%020d", id)
+}
+
+// LoadOperatorRecords loads operator records from storage in the order of ID.
+func (s *Storage) LoadOperatorRecords(f func(k, v string)) error {
+	return s.LoadRangeByPrefix(operatorRecordPath+"/", f)
+}
+
 // SaveJSON saves json format data to storage.
 func (s *Storage) SaveJSON(prefix, key string, data interface{}) error {
 	value, err := json.Marshal(data)
@@ -298,7 +349,7 @@ func (s *Storage) SaveJSON(prefix, key string, data interface{}) error {
 // LoadRangeByPrefix iterates all key-value pairs in the storage that has the prefix.
 func (s *Storage) LoadRangeByPrefix(prefix string, f func(k, v string)) error {
 	nextKey := prefix
-	endKey := clientv3.GetPrefixRangeEnd(prefix)
+	endKey := kv.GetPrefixRangeEnd(prefix)
 	for {
 		keys, values, err := s.LoadRange(nextKey, endKey, minKVRangeLimit)
 		if err != nil {
@@ -400,12 +451,9 @@ func (s *Storage) LoadStores(f func(store *StoreInfo)) error {
 
 // SaveStoreWeight saves a store's leader and region weight to storage.
 func (s *Storage) SaveStoreWeight(storeID uint64, leader, region float64) error {
-	leaderValue := strconv.FormatFloat(leader, 'f', -1, 64)
-	if err := s.Save(s.storeLeaderWeightPath(storeID), leaderValue); err != nil {
-		return err
-	}
-	regionValue := strconv.FormatFloat(region, 'f', -1, 64)
-	return s.Save(s.storeRegionWeightPath(storeID), regionValue)
+	batch := s.NewBatch()
+	batch.SaveStoreWeight(storeID, leader, region)
+	return batch.Commit()
 }
 
 func (s *Storage) loadFloatWithDefaultValue(path string, def float64) (float64, error) {
@@ -516,7 +564,7 @@ func (s *Storage) initServiceGCSafePointForGCWorker() (*ServiceSafePoint, error)
 // LoadMinServiceGCSafePoint returns the minimum safepoint across all services
 func (s *Storage) LoadMinServiceGCSafePoint(now time.Time) (*ServiceSafePoint, error) {
 	prefix := path.Join(gcPath, "safe_point", "service") + "/"
-	prefixEnd := clientv3.GetPrefixRangeEnd(prefix)
+	prefixEnd := kv.GetPrefixRangeEnd(prefix)
 	keys, values, err := s.LoadRange(prefix, prefixEnd, 0)
 	if err != nil {
 		return nil, err
@@ -547,7 +595,7 @@ func (s *Storage) LoadMinServiceGCSafePoint(now time.Time) (*ServiceSafePoint, e
 // GetAllServiceGCSafePoints returns all services GC safepoints
 func (s *Storage) GetAllServiceGCSafePoints() ([]*ServiceSafePoint, error) {
 	prefix := path.Join(gcPath, "safe_point", "service") + "/"
-	prefixEnd := clientv3.GetPrefixRangeEnd(prefix)
+	prefixEnd := kv.GetPrefixRangeEnd(prefix)
 	keys, values, err := s.LoadRange(prefix, prefixEnd, 0)
 	if err != nil {
 		return nil, err
@@ -571,7 +619,7 @@ func (s *Storage) GetAllServiceGCSafePoints() ([]*ServiceSafePoint, error) {
 // LoadAllScheduleConfig loads all schedulers' config.
 func (s *Storage) LoadAllScheduleConfig() ([]string, []string, error) {
 	prefix := customScheduleConfigPath + "/"
-	keys, values, err := s.LoadRange(prefix, clientv3.GetPrefixRangeEnd(prefix), 1000)
+	keys, values, err := s.LoadRange(prefix, kv.GetPrefixRangeEnd(prefix), 1000)
 	for i, key := range keys {
 		keys[i] = strings.TrimPrefix(key, prefix)
 	}
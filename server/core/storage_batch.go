@@ -0,0 +1,148 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"encoding/json"
+	"path"
+	"strconv"
+
+	"github.com/tikv/pd/pkg/errs"
+	"github.com/tikv/pd/server/kv"
+)
+
+// Batch collects the updates of several keys, which are saved to the storage
+// atomically when committing.
+type Batch struct {
+	storage *Storage
+	ops     []kv.Op
+}
+
+// NewBatch creates a new Batch of the storage.
+func (s *Storage) NewBatch() *Batch {
+	return &Batch{storage: s}
+}
+
+// Len returns the number of the updates in the batch.
+func (b *Batch) Len() int {
+	return len(b.ops)
+}
+
+// Commit saves all updates in the batch atomically in one transaction. It
+// returns an error if the updates exceed the limits of a transaction, and the
+// updates failed to save are kept in the batch.
+func (b *Batch) Commit() error {
+	if len(b.ops) == 0 {
+		return nil
+	}
+	size := 0
+	for _, op := range b.ops {
+		size += len(op.Key) + len(op.Value) + kv.TxnOpOverhead
+	}
+	if len(b.ops) > kv.MaxTxnOps || size > kv.MaxTxnBytes {
+		return errs.ErrBatchTooLarge.FastGenByArgs(len(b.ops), size)
+	}
+	ok, err := b.storage.Txn(nil, b.ops)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errs.ErrEtcdTxn.FastGenByArgs()
+	}
+	b.ops = nil
+	return nil
+}
+
+func (b *Batch) save(key, value string) {
+	b.ops = append(b.ops, kv.OpSave(key, value))
+}
+
+func (b *Batch) remove(key string) {
+	b.ops = append(b.ops, kv.OpRemove(key))
+}
+
+func (b *Batch) saveJSON(prefix, key string, data interface{}) error {
+	value, err := json.Marshal(data)
+	if err != nil {
+		return errs.ErrJSONMarshal.Wrap(err).GenWithStackByArgs()
+	}
+	b.save(path.Join(prefix, key), string(value))
+	return nil
+}
+
+// SaveConfig stores marshallable cfg to the configPath.
+func (b *Batch) SaveConfig(cfg interface{}) error {
+	value, err := json.Marshal(cfg)
+	if err != nil {
+		return errs.ErrJSONMarshal.Wrap(err).GenWithStackByCause()
+	}
+	b.save(configPath, string(value))
+	return nil
+}
+
+// SaveScheduleConfig saves the config of scheduler.
+func (b *Batch) SaveScheduleConfig(scheduleName string, data []byte) {
+	b.save(path.Join(customScheduleConfigPath, scheduleName), string(data))
+}
+
+// RemoveScheduleConfig removes the config of scheduler.
+func (b *Batch) RemoveScheduleConfig(scheduleName string) {
+	b.remove(path.Join(customScheduleConfigPath, scheduleName))
+}
+
+// SaveStoreWeight saves a store's leader and region weight.
+func (b *Batch) SaveStoreWeight(storeID uint64, leader, region float64) {
+	b.save(b.storage.storeLeaderWeightPath(storeID), strconv.FormatFloat(leader, 'f', -1, 64))
+	b.save(b.storage.storeRegionWeightPath(storeID), strconv.FormatFloat(region, 'f', -1, 64))
+}
+
+// SaveRule stores a rule cfg to the rulesPath.
+func (b *Batch) SaveRule(ruleKey string, rule interface{}) error {
+	return b.saveJSON(rulesPath, ruleKey, rule)
+}
+
+// DeleteRule removes a rule.
+func (b *Batch) DeleteRule(ruleKey string) {
+	b.remove(path.Join(rulesPath, ruleKey))
+}
+
+// SaveRuleGroup stores a rule group config.
+func (b *Batch) SaveRuleGroup(groupID string, group interface{}) error {
+	return b.saveJSON(ruleGroupPath, groupID, group)
+}
+
+// DeleteRuleGroup removes a rule group.
+func (b *Batch) DeleteRuleGroup(groupID string) {
+	b.remove(path.Join(ruleGroupPath, groupID))
+}
+
+// SaveRuleTemplate stores a rule template.
+func (b *Batch) SaveRuleTemplate(name string, template interface{}) error {
+	return b.saveJSON(ruleTemplatePath, name, template)
+}
+
+// DeleteRuleTemplate removes a rule template.
+func (b *Batch) DeleteRuleTemplate(name string) {
+	b.remove(path.Join(ruleTemplatePath, name))
+}
+
+// SaveOperatorRecord stores an operator record.
+func (b *Batch) SaveOperatorRecord(id uint64, record interface{}) error {
+	return b.saveJSON(operatorRecordPath, operatorRecordKey(id), record)
+}
+
+// DeleteOperatorRecord removes an operator record.
+func (b *Batch) DeleteOperatorRecord(id uint64) {
+	b.remove(path.Join(operatorRecordPath, operatorRecordKey(id)))
+}
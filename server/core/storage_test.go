@@ -24,6 +24,7 @@ import (
 	. "github.com/pingcap/check"
 	"github.com/pingcap/errors"
 	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/tikv/pd/pkg/errs"
 	"github.com/tikv/pd/server/kv"
 	"go.etcd.io/etcd/clientv3"
 )
@@ -123,6 +124,70 @@ func (s *testKVSuite) TestStoreWeight(c *C) {
 	}
 }
 
+func (s *testKVSuite) TestBatch(c *C) {
+	storage := NewStorage(kv.NewMemoryKV())
+	c.Assert(storage.SaveRule("a", "rule-a"), IsNil)
+	c.Assert(storage.SaveScheduleConfig("s", []byte("cfg")), IsNil)
+
+	batch := storage.NewBatch()
+	c.Assert(batch.SaveRule("b", "rule-b"), IsNil)
+	batch.DeleteRule("a")
+	c.Assert(batch.SaveRuleGroup("g", "group"), IsNil)
+	batch.RemoveScheduleConfig("s")
+	c.Assert(batch.Len(), Equals, 4)
+	// Nothing is saved before committing.
+	v, err := storage.LoadScheduleConfig("s")
+	c.Assert(err, IsNil)
+	c.Assert(v, Equals, "cfg")
+	c.Assert(batch.Commit(), IsNil)
+	c.Assert(batch.Len(), Equals, 0)
+
+	rules := make(map[string]string)
+	c.Assert(storage.LoadRules(func(k, v string) { rules[k] = v }), IsNil)
+	c.Assert(rules, DeepEquals, map[string]string{"b": `"rule-b"`})
+	groups := make(map[string]string)
+	c.Assert(storage.LoadRuleGroups(func(k, v string) { groups[k] = v }), IsNil)
+	c.Assert(groups, DeepEquals, map[string]string{"g": `"group"`})
+	v, err = storage.LoadScheduleConfig("s")
+	c.Assert(err, IsNil)
+	c.Assert(v, Equals, "")
+}
+
+func (s *testKVSuite) TestBatchExceedLimits(c *C) {
+	storage := NewStorage(kv.NewMemoryKV())
+
+	// The rules larger than a transaction are rejected.
+	rule := strings.Repeat("r", 100*1024)
+	batch := storage.NewBatch()
+	for i := 0; i < 40; i++ {
+		c.Assert(batch.SaveRule(fmt.Sprintf("rule-%02d", i), rule), IsNil)
+	}
+	c.Assert(errs.ErrBatchTooLarge.Equal(batch.Commit()), IsTrue)
+	c.Assert(batch.Len(), Equals, 40)
+	rules := 0
+	c.Assert(storage.LoadRules(func(k, v string) { rules++ }), IsNil)
+	c.Assert(rules, Equals, 0)
+
+	// So are too many small ones.
+	batch = storage.NewBatch()
+	for i := 0; i < kv.MaxTxnOps+1; i++ {
+		c.Assert(batch.SaveRule(fmt.Sprintf("rule-%05d", i), "r"), IsNil)
+	}
+	c.Assert(errs.ErrBatchTooLarge.Equal(batch.Commit()), IsTrue)
+	c.Assert(storage.LoadRules(func(k, v string) { rules++ }), IsNil)
+	c.Assert(rules, Equals, 0)
+
+	// The rules within the limits are saved.
+	batch = storage.NewBatch()
+	for i := 0; i < 10; i++ {
+		c.Assert(batch.SaveRule(fmt.Sprintf("rule-%02d", i), rule), IsNil)
+	}
+	c.Assert(batch.Commit(), IsNil)
+	c.Assert(batch.Len(), Equals, 0)
+	c.Assert(storage.LoadRules(func(k, v string) { rules++ }), IsNil)
+	c.Assert(rules, Equals, 10)
+}
+
 func mustSaveRegions(c *C, s *Storage, n int) []*metapb.Region {
 	regions := make([]*metapb.Region, 0, n)
 	for i := 0; i < n; i++ {
@@ -16,11 +16,32 @@ package core
 import (
 	"math"
 	"sync"
+	"time"
 
 	"github.com/pingcap/kvproto/pkg/pdpb"
 	"github.com/tikv/pd/pkg/movingaverage"
 )
 
+const (
+	// MinSlowScore is the slow score of a store which works normally.
+	MinSlowScore = 1
+	// MaxSlowScore is the slow score of a store which is considered slow by
+	// all the recent heartbeats.
+	MaxSlowScore = 100
+
+	// heartbeatDelayTolerance is the heartbeat delay which is considered normal,
+	// it covers the clock drift and the granularity of the reported interval.
+	heartbeatDelayTolerance = 2 * time.Second
+	// slowHeartbeatDelay is the heartbeat delay that makes the store slow.
+	slowHeartbeatDelay = 10 * time.Second
+	// slowOpLatencyRatio is the ratio of recent operation latency to the
+	// baseline that makes the store slow.
+	slowOpLatencyRatio = 4.0
+	// slowScoreWarmUpCount is the number of heartbeats needed before the slow
+	// score is calculated, to avoid judging a store by a single heartbeat.
+	slowScoreWarmUpCount = 6
+)
+
 type storeStats struct {
 	mu       sync.RWMutex
 	rawStats *pdpb.StoreStats
@@ -33,6 +54,18 @@ type storeStats struct {
 	// `HMA` is used to make it smooth.
 	maxAvailableDeviation    *movingaverage.MaxFilter
 	avgMaxAvailableDeviation *movingaverage.HMA
+
+	// Following fields are used to derive the slow score of the store. A store
+	// with a degraded disk is always busy, reports heartbeats with delay or
+	// has operation latencies much higher than before.
+	avgBusy           *movingaverage.WMA
+	avgHeartbeatDelay *movingaverage.MedianFilter
+	avgOpLatency      *movingaverage.WMA
+	// baselineOpLatency is the long-term operation latency of the store, it
+	// stops learning once the store is slow.
+	baselineOpLatency *movingaverage.EMA
+	heartbeatCount    uint64
+	slowScore         uint64
 }
 
 func newStoreStats() *storeStats {
@@ -41,6 +74,11 @@ func newStoreStats() *storeStats {
 		avgAvailable:             movingaverage.NewHMA(240),       // take 40 minutes sample under 10s heartbeat rate
 		maxAvailableDeviation:    movingaverage.NewMaxFilter(120), // take 20 minutes sample under 10s heartbeat rate
 		avgMaxAvailableDeviation: movingaverage.NewHMA(60),        // take 10 minutes sample under 10s heartbeat rate
+		avgBusy:                  movingaverage.NewWMA(6),         // take 1 minute sample under 10s heartbeat rate
+		avgHeartbeatDelay:        movingaverage.NewMedianFilter(6),
+		avgOpLatency:             movingaverage.NewWMA(6),
+		baselineOpLatency:        movingaverage.NewEMA(0.01), // take about 30 minutes sample under 10s heartbeat rate
+		slowScore:                MinSlowScore,
 	}
 }
 
@@ -53,6 +91,56 @@ func (ss *storeStats) updateRawStats(rawStats *pdpb.StoreStats) {
 	deviation := math.Abs(float64(rawStats.GetAvailable()) - ss.avgAvailable.Get())
 	ss.maxAvailableDeviation.Add(deviation)
 	ss.avgMaxAvailableDeviation.Add(ss.maxAvailableDeviation.Get())
+
+	ss.updateSlowScore(rawStats, time.Now())
+}
+
+// updateSlowScore updates the slow score according to the busy flag, the
+// heartbeat delay and the operation latencies. The score ranges from
+// MinSlowScore to MaxSlowScore, and is decided by the most abnormal factor.
+func (ss *storeStats) updateSlowScore(rawStats *pdpb.StoreStats, now time.Time) {
+	if rawStats.GetIsBusy() {
+		ss.avgBusy.Add(1)
+	} else {
+		ss.avgBusy.Add(0)
+	}
+	if end := rawStats.GetInterval().GetEndTimestamp(); end > 0 {
+		delay := now.Sub(time.Unix(int64(end), 0))
+		ss.avgHeartbeatDelay.Add(math.Max(delay.Seconds(), 0))
+	}
+	if latency := averageOpLatency(rawStats.GetOpLatencies()); latency > 0 {
+		ss.avgOpLatency.Add(latency)
+		baseline := ss.baselineOpLatency.Get()
+		if baseline == 0 || ss.avgOpLatency.Get() < baseline*slowOpLatencyRatio {
+			ss.baselineOpLatency.Add(latency)
+		}
+	}
+
+	ss.heartbeatCount++
+	if ss.heartbeatCount < slowScoreWarmUpCount {
+		return
+	}
+	factor := ss.avgBusy.Get()
+	delayFactor := (ss.avgHeartbeatDelay.Get() - heartbeatDelayTolerance.Seconds()) /
+		(slowHeartbeatDelay.Seconds() - heartbeatDelayTolerance.Seconds())
+	factor = math.Max(factor, delayFactor)
+	if baseline := ss.baselineOpLatency.Get(); baseline > 0 {
+		latencyFactor := (ss.avgOpLatency.Get()/baseline - 1) / (slowOpLatencyRatio - 1)
+		factor = math.Max(factor, latencyFactor)
+	}
+	factor = math.Min(math.Max(factor, 0), 1)
+	ss.slowScore = MinSlowScore + uint64(math.Round(factor*(MaxSlowScore-MinSlowScore)))
+}
+
+func averageOpLatency(latencies []*pdpb.RecordPair) float64 {
+	if len(latencies) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, l := range latencies {
+		sum += float64(l.GetValue())
+	}
+	return sum / float64(len(latencies))
 }
 
 // GetStoreStats returns the statistics information of the store.
@@ -153,6 +241,26 @@ func (ss *storeStats) GetAvailableDeviation() uint64 {
 	return climp0(ss.avgMaxAvailableDeviation.Get())
 }
 
+// GetSlowScore returns the slow score of the store.
+func (ss *storeStats) GetSlowScore() uint64 {
+	ss.mu.RLock()
+	defer ss.mu.RUnlock()
+	return ss.slowScore
+}
+
+// IsSlowScoreWarmingUp returns if the store hasn't reported enough heartbeats
+// to calculate the slow score, such as after the PD leader changes.
+func (ss *storeStats) IsSlowScoreWarmingUp() bool {
+	ss.mu.RLock()
+	defer ss.mu.RUnlock()
+	return ss.heartbeatCount < slowScoreWarmUpCount
+}
+
+// IsSlow returns if the store is considered slow by all the recent heartbeats.
+func (ss *storeStats) IsSlow() bool {
+	return ss.GetSlowScore() >= MaxSlowScore
+}
+
 func climp0(v float64) uint64 {
 	if v <= 0 {
 		return 0
@@ -14,6 +14,8 @@
 package core
 
 import (
+	"time"
+
 	. "github.com/pingcap/check"
 	"github.com/pingcap/kvproto/pkg/metapb"
 	"github.com/pingcap/kvproto/pkg/pdpb"
@@ -50,3 +52,57 @@ func (s *testStoreStatsSuite) TestStoreStats(c *C) {
 	c.Assert(store.GetAvailableDeviation(), Greater, uint64(0))
 	c.Assert(store.GetAvailableDeviation(), Less, 10*G)
 }
+
+func (s *testStoreStatsSuite) TestSlowScore(c *C) {
+	meta := &metapb.Store{Id: 1, State: metapb.StoreState_Up}
+	store := NewStoreInfo(meta)
+	c.Assert(store.GetSlowScore(), Equals, uint64(MinSlowScore))
+
+	// A single busy heartbeat should not make the store slow.
+	store = store.Clone(SetStoreStats(&pdpb.StoreStats{IsBusy: true}))
+	c.Assert(store.GetSlowScore(), Equals, uint64(MinSlowScore))
+	c.Assert(store.IsSlow(), IsFalse)
+
+	for i := 0; i < slowScoreWarmUpCount; i++ {
+		store = store.Clone(SetStoreStats(&pdpb.StoreStats{IsBusy: true}))
+	}
+	c.Assert(store.GetSlowScore(), Equals, uint64(MaxSlowScore))
+	c.Assert(store.IsSlow(), IsTrue)
+
+	for i := 0; i < slowScoreWarmUpCount; i++ {
+		store = store.Clone(SetStoreStats(&pdpb.StoreStats{IsBusy: false}))
+	}
+	c.Assert(store.GetSlowScore(), Equals, uint64(MinSlowScore))
+
+	// The heartbeat is delayed.
+	end := uint64(time.Now().Add(-time.Minute).Unix())
+	for i := 0; i < slowScoreWarmUpCount; i++ {
+		store = store.Clone(SetStoreStats(&pdpb.StoreStats{Interval: &pdpb.TimeInterval{EndTimestamp: end}}))
+	}
+	c.Assert(store.IsSlow(), IsTrue)
+}
+
+func (s *testStoreStatsSuite) TestSlowScoreByOpLatency(c *C) {
+	meta := &metapb.Store{Id: 1, State: metapb.StoreState_Up}
+	store := NewStoreInfo(meta)
+	latencies := func(v uint64) []*pdpb.RecordPair {
+		return []*pdpb.RecordPair{{Key: "apply", Value: v}, {Key: "write", Value: v}}
+	}
+	for i := 0; i < 100; i++ {
+		store = store.Clone(SetStoreStats(&pdpb.StoreStats{OpLatencies: latencies(10)}))
+	}
+	c.Assert(store.GetSlowScore(), Equals, uint64(MinSlowScore))
+
+	// The latency is doubled, the store is slower but not slow.
+	for i := 0; i < slowScoreWarmUpCount; i++ {
+		store = store.Clone(SetStoreStats(&pdpb.StoreStats{OpLatencies: latencies(20)}))
+	}
+	c.Assert(store.GetSlowScore(), Greater, uint64(MinSlowScore))
+	c.Assert(store.IsSlow(), IsFalse)
+
+	// The baseline stops learning when the store is slow.
+	for i := 0; i < 100; i++ {
+		store = store.Clone(SetStoreStats(&pdpb.StoreStats{OpLatencies: latencies(100)}))
+	}
+	c.Assert(store.IsSlow(), IsTrue)
+}
@@ -15,6 +15,7 @@ package election
 
 import (
 	"context"
+	"fmt"
 	"sync/atomic"
 
 	"github.com/pingcap/failpoint"
@@ -42,8 +43,75 @@ func GetLeader(c *clientv3.Client, leaderPath string) (*pdpb.Member, int64, erro
 	return leader, rev, nil
 }
 
+// RevokeLeader revokes the lease of the leader key if the leader is the given
+// member, which makes the member step down once it finds the lease expired.
+// It returns whether the lease is revoked.
+func RevokeLeader(c *clientv3.Client, leaderPath string, memberID uint64) (bool, error) {
+	resp, err := etcdutil.EtcdKVGet(c, leaderPath)
+	if err != nil {
+		return false, err
+	}
+	if len(resp.Kvs) != 1 {
+		return false, nil
+	}
+	leader := &pdpb.Member{}
+	if err := leader.Unmarshal(resp.Kvs[0].Value); err != nil {
+		return false, errs.ErrProtoUnmarshal.Wrap(err).GenWithStackByCause()
+	}
+	if leader.GetMemberId() != memberID {
+		return false, nil
+	}
+	ctx, cancel := context.WithTimeout(c.Ctx(), requestTimeout)
+	defer cancel()
+	if _, err := c.Revoke(ctx, clientv3.LeaseID(resp.Kvs[0].Lease)); err != nil {
+		return false, errs.ErrEtcdRevokeLease.Wrap(err).GenWithStackByCause()
+	}
+	return true, nil
+}
+
+// SetNextLeader writes the ID of the member which should be the next leader
+// into the key with a lease of the ttl in seconds. It returns false if the
+// key already exists.
+func SetNextLeader(c *clientv3.Client, nextLeaderKey string, memberID uint64, ttl int64) (bool, error) {
+	ctx, cancel := context.WithTimeout(c.Ctx(), requestTimeout)
+	leaseResp, err := clientv3.NewLease(c).Grant(ctx, ttl)
+	cancel()
+	if err != nil {
+		return false, errs.ErrEtcdGrantLease.Wrap(err).GenWithStackByCause()
+	}
+	resp, err := kv.NewSlowLogTxn(c).
+		If(clientv3.Compare(clientv3.CreateRevision(nextLeaderKey), "=", 0)).
+		Then(clientv3.OpPut(nextLeaderKey, fmt.Sprint(memberID), clientv3.WithLease(leaseResp.ID))).
+		Commit()
+	if err != nil {
+		return false, errs.ErrEtcdTxn.Wrap(err).GenWithStackByCause()
+	}
+	return resp.Succeeded, nil
+}
+
 // Leadership is used to manage the leadership campaigning.
-type Leadership struct {
+type Leadership interface {
+	// GetLeaderKey returns the key which stores the leader.
+	GetLeaderKey() string
+	// Campaign campaigns the leadership with the lease timeout in seconds.
+	Campaign(leaseTimeout int64, leaderData string) error
+	// Keep keeps the leadership available until the context is done.
+	Keep(ctx context.Context)
+	// Check returns whether the leadership is still available.
+	Check() bool
+	// LeaderCondition returns the condition of a transaction, which is met
+	// only if the leadership is still held.
+	LeaderCondition() kv.Condition
+	// DeleteLeader deletes the leader key to let others campaign again.
+	DeleteLeader() error
+	// Watch watches the leader key of the given revision until it is deleted.
+	Watch(serverCtx context.Context, revision int64)
+	// Reset gives up the leadership.
+	Reset()
+}
+
+// EtcdLeadership is a Leadership whose leader key is kept alive by an etcd lease.
+type EtcdLeadership struct {
 	// purpose is used to show what this election for
 	purpose string
 	// The lease which is used to get this leadership
@@ -54,9 +122,9 @@ type Leadership struct {
 	leaderValue string
 }
 
-// NewLeadership creates a new Leadership.
-func NewLeadership(client *clientv3.Client, leaderKey, purpose string) *Leadership {
-	leadership := &Leadership{
+// NewEtcdLeadership creates a new EtcdLeadership.
+func NewEtcdLeadership(client *clientv3.Client, leaderKey, purpose string) *EtcdLeadership {
+	leadership := &EtcdLeadership{
 		purpose:   purpose,
 		client:    client,
 		leaderKey: leaderKey,
@@ -66,7 +134,7 @@ func NewLeadership(client *clientv3.Client, leaderKey, purpose string) *Leadersh
 
 // getLease gets the lease of leadership, only if leadership is valid,
 // i.e the owner is a true leader, the lease is not nil.
-func (ls *Leadership) getLease() *lease {
+func (ls *EtcdLeadership) getLease() *lease {
 	l := ls.lease.Load()
 	if l == nil {
 		return nil
@@ -74,22 +142,22 @@ func (ls *Leadership) getLease() *lease {
 	return l.(*lease)
 }
 
-func (ls *Leadership) setLease(lease *lease) {
+func (ls *EtcdLeadership) setLease(lease *lease) {
 	ls.lease.Store(lease)
 }
 
 // GetClient is used to get the etcd client.
-func (ls *Leadership) GetClient() *clientv3.Client {
+func (ls *EtcdLeadership) GetClient() *clientv3.Client {
 	return ls.client
 }
 
 // GetLeaderKey is used to get the leader key of etcd.
-func (ls *Leadership) GetLeaderKey() string {
+func (ls *EtcdLeadership) GetLeaderKey() string {
 	return ls.leaderKey
 }
 
 // Campaign is used to campaign the leader with given lease and returns a leadership
-func (ls *Leadership) Campaign(leaseTimeout int64, leaderData string) error {
+func (ls *EtcdLeadership) Campaign(leaseTimeout int64, leaderData string) error {
 	ls.leaderValue = leaderData
 	// Create a new lease to campaign
 	ls.setLease(&lease{
@@ -119,28 +187,34 @@ func (ls *Leadership) Campaign(leaseTimeout int64, leaderData string) error {
 }
 
 // Keep will keep the leadership available by update the lease's expired time continuously
-func (ls *Leadership) Keep(ctx context.Context) {
+func (ls *EtcdLeadership) Keep(ctx context.Context) {
 	ls.getLease().KeepAlive(ctx)
 }
 
 // Check returns whether the leadership is still available
-func (ls *Leadership) Check() bool {
+func (ls *EtcdLeadership) Check() bool {
 	return ls != nil && ls.getLease() != nil && !ls.getLease().IsExpired()
 }
 
 // LeaderTxn returns txn() with a leader comparison to guarantee that
 // the transaction can be executed only if the server is leader.
-func (ls *Leadership) LeaderTxn(cs ...clientv3.Cmp) clientv3.Txn {
+func (ls *EtcdLeadership) LeaderTxn(cs ...clientv3.Cmp) clientv3.Txn {
 	txn := kv.NewSlowLogTxn(ls.client)
 	return txn.If(append(cs, ls.leaderCmp())...)
 }
 
-func (ls *Leadership) leaderCmp() clientv3.Cmp {
+func (ls *EtcdLeadership) leaderCmp() clientv3.Cmp {
 	return clientv3.Compare(clientv3.Value(ls.leaderKey), "=", ls.leaderValue)
 }
 
+// LeaderCondition returns the condition which is the same as the leader
+// comparison of LeaderTxn.
+func (ls *EtcdLeadership) LeaderCondition() kv.Condition {
+	return kv.Condition{Key: ls.leaderKey, Value: ls.leaderValue}
+}
+
 // DeleteLeader deletes the corresponding leader from etcd by given leaderPath (as the key).
-func (ls *Leadership) DeleteLeader() error {
+func (ls *EtcdLeadership) DeleteLeader() error {
 	// delete leader itself and let others start a new election again.
 	resp, err := ls.LeaderTxn().Then(clientv3.OpDelete(ls.leaderKey)).Commit()
 	if err != nil {
@@ -155,7 +229,7 @@ func (ls *Leadership) DeleteLeader() error {
 
 // Watch is used to watch the changes of the leadership, usually is used to
 // detect the leadership stepping down and restart an election as soon as possible.
-func (ls *Leadership) Watch(serverCtx context.Context, revision int64) {
+func (ls *EtcdLeadership) Watch(serverCtx context.Context, revision int64) {
 	watcher := clientv3.NewWatcher(ls.client)
 	defer watcher.Close()
 	ctx, cancel := context.WithCancel(serverCtx)
@@ -204,7 +278,7 @@ func (ls *Leadership) Watch(serverCtx context.Context, revision int64) {
 }
 
 // Reset does some defer job such as closing lease, resetting lease etc.
-func (ls *Leadership) Reset() {
+func (ls *EtcdLeadership) Reset() {
 	if ls == nil || ls.getLease() == nil {
 		return
 	}
@@ -20,6 +20,7 @@ import (
 
 	. "github.com/pingcap/check"
 	"github.com/tikv/pd/pkg/etcdutil"
+	"github.com/tikv/pd/server/kv"
 	"go.etcd.io/etcd/clientv3"
 	"go.etcd.io/etcd/embed"
 )
@@ -52,8 +53,8 @@ func (s *testLeadershipSuite) TestLeadership(c *C) {
 	<-etcd.Server.ReadyNotify()
 
 	// Campaign the same leadership
-	leadership1 := NewLeadership(client, "/test_leader", "test_leader_1")
-	leadership2 := NewLeadership(client, "/test_leader", "test_leader_2")
+	leadership1 := NewEtcdLeadership(client, "/test_leader", "test_leader_1")
+	leadership2 := NewEtcdLeadership(client, "/test_leader", "test_leader_2")
 
 	// leadership1 starts first and get the leadership
 	err = leadership1.Campaign(defaultTestLeaderLease, "test_leader_1")
@@ -85,3 +86,25 @@ func (s *testLeadershipSuite) TestLeadership(c *C) {
 
 	c.Assert(leadership1.Check(), IsTrue)
 }
+
+func (s *testLeadershipSuite) TestLocalLeadership(c *C) {
+	kvBase := kv.NewMemoryKV()
+	leadership := NewLocalLeadership(kvBase, "/test_leader", "test_leader")
+	c.Assert(leadership.Check(), IsFalse)
+
+	// The leader key left by the last run is overwritten.
+	c.Assert(kvBase.Save("/test_leader", "old_leader"), IsNil)
+	c.Assert(leadership.Campaign(defaultTestLeaderLease, "test_leader"), IsNil)
+	c.Assert(leadership.Check(), IsTrue)
+	ok, err := kvBase.Txn([]kv.Condition{leadership.LeaderCondition()}, []kv.Op{kv.OpSave("/test_key", "1")})
+	c.Assert(err, IsNil)
+	c.Assert(ok, IsTrue)
+
+	// The writes guarded by the leader condition fail after it resets.
+	leadership.Reset()
+	c.Assert(leadership.Check(), IsFalse)
+	ok, err = kvBase.Txn([]kv.Condition{leadership.LeaderCondition()}, []kv.Op{kv.OpSave("/test_key", "2")})
+	c.Assert(err, IsNil)
+	c.Assert(ok, IsFalse)
+	c.Assert(leadership.DeleteLeader(), NotNil)
+}
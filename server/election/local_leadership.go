@@ -0,0 +1,107 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package election
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/pingcap/log"
+	"github.com/tikv/pd/pkg/errs"
+	"github.com/tikv/pd/server/kv"
+	"go.uber.org/zap"
+)
+
+// LocalLeadership is a Leadership for a single server, which is always the
+// leader once it campaigns. The leader key is stored in the kv, so that the
+// writes guarded by the leader condition work the same as the etcd ones.
+type LocalLeadership struct {
+	// purpose is used to show what this election for
+	purpose string
+	kv      kv.Base
+	// leaderKey and leaderValue are key-value pair in the kv
+	leaderKey   string
+	leaderValue string
+	valid       int32
+}
+
+// NewLocalLeadership creates a new LocalLeadership.
+func NewLocalLeadership(kvBase kv.Base, leaderKey, purpose string) *LocalLeadership {
+	return &LocalLeadership{
+		purpose:   purpose,
+		kv:        kvBase,
+		leaderKey: leaderKey,
+	}
+}
+
+// GetLeaderKey is used to get the leader key of the kv.
+func (ls *LocalLeadership) GetLeaderKey() string {
+	return ls.leaderKey
+}
+
+// Campaign writes the leader data to the leader key. There is no other server
+// to compete with, so the leader key left by the last run is overwritten.
+func (ls *LocalLeadership) Campaign(leaseTimeout int64, leaderData string) error {
+	ls.leaderValue = leaderData
+	if err := ls.kv.Save(ls.leaderKey, leaderData); err != nil {
+		return err
+	}
+	atomic.StoreInt32(&ls.valid, 1)
+	log.Info("write leaderData to leaderPath ok", zap.String("leaderPath", ls.leaderKey), zap.String("purpose", ls.purpose))
+	return nil
+}
+
+// Keep blocks until the context is done, the leadership never expires.
+func (ls *LocalLeadership) Keep(ctx context.Context) {
+	<-ctx.Done()
+}
+
+// Check returns whether the leadership is still available.
+func (ls *LocalLeadership) Check() bool {
+	return ls != nil && atomic.LoadInt32(&ls.valid) == 1
+}
+
+// LeaderCondition returns the condition which requires the leader key to
+// hold the leader data.
+func (ls *LocalLeadership) LeaderCondition() kv.Condition {
+	return kv.Condition{Key: ls.leaderKey, Value: ls.leaderValue}
+}
+
+// DeleteLeader deletes the leader key if it is still held by the leadership.
+func (ls *LocalLeadership) DeleteLeader() error {
+	ok, err := ls.kv.Txn([]kv.Condition{ls.LeaderCondition()}, []kv.Op{kv.OpRemove(ls.leaderKey)})
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errs.ErrEtcdTxn.FastGenByArgs()
+	}
+	return nil
+}
+
+// Watch blocks until the context is done, since no other server can hold
+// the leadership.
+func (ls *LocalLeadership) Watch(serverCtx context.Context, revision int64) {
+	<-serverCtx.Done()
+}
+
+// Reset gives up the leadership and deletes the leader key.
+func (ls *LocalLeadership) Reset() {
+	if ls == nil || !atomic.CompareAndSwapInt32(&ls.valid, 1, 0) {
+		return
+	}
+	if _, err := ls.kv.Txn([]kv.Condition{ls.LeaderCondition()}, []kv.Op{kv.OpRemove(ls.leaderKey)}); err != nil {
+		log.Warn("failed to delete the leader key", zap.String("purpose", ls.purpose), errs.ZapError(err))
+	}
+}
@@ -26,6 +26,7 @@ import (
 	"github.com/tikv/pd/pkg/errs"
 	"github.com/tikv/pd/pkg/etcdutil"
 	"github.com/tikv/pd/server/election"
+	"github.com/tikv/pd/server/kv"
 	"go.etcd.io/etcd/clientv3"
 	"go.etcd.io/etcd/mvcc/mvccpb"
 	"go.uber.org/zap"
@@ -48,6 +49,8 @@ const (
 type KeyManager struct {
 	// Backing storage for key dictionary.
 	etcdClient *clientv3.Client
+	// kv is the kv of the etcd client to save keys.
+	kv kv.Base
 	// Encryption method used to encrypt data
 	method encryptionpb.EncryptionMethod
 	// Time interval between data key rotation.
@@ -62,7 +65,7 @@ type KeyManager struct {
 		// PD leadership of the current PD node. Only the PD leader will rotate data keys,
 		// or change current encryption method.
 		// Guarded by mu.
-		leadership *election.Leadership
+		leadership election.Leadership
 		// Revision of keys loaded from etcd. Guarded by mu.
 		keysRevision int64
 	}
@@ -73,7 +76,8 @@ type KeyManager struct {
 
 // saveKeys saves encryption keys in etcd. Fail if given leadership is not current.
 func saveKeys(
-	leadership *election.Leadership,
+	kvBase kv.Base,
+	leadership election.Leadership,
 	masterKeyMeta *encryptionpb.MasterKey,
 	keys *encryptionpb.KeyDictionary,
 	helper keyManagerHelper,
@@ -109,14 +113,12 @@ func saveKeys(
 		return errs.ErrProtoMarshal.Wrap(err).GenWithStack("fail to marshal encrypted encryption keys")
 	}
 	// Avoid write conflict with PD peer by checking if we are leader.
-	resp, err := leadership.LeaderTxn().
-		Then(clientv3.OpPut(EncryptionKeysPath, string(value))).
-		Commit()
+	ok, err := kvBase.Txn([]kv.Condition{leadership.LeaderCondition()}, []kv.Op{kv.OpSave(EncryptionKeysPath, string(value))})
 	if err != nil {
 		log.Warn("fail to save encryption keys.", zap.Error(err))
 		return errs.ErrEtcdTxn.Wrap(err).GenWithStack("fail to save encryption keys")
 	}
-	if !resp.Succeeded {
+	if !ok {
 		log.Warn("fail to save encryption keys. leader expired.")
 		return errs.ErrEncryptionSaveDataKeys.GenWithStack("leader expired")
 	}
@@ -182,6 +184,7 @@ func newKeyManagerImpl(
 	}
 	m := &KeyManager{
 		etcdClient:            etcdClient,
+		kv:                    kv.NewEtcdKVBase(etcdClient, ""),
 		method:                method,
 		dataKeyRotationPeriod: config.DataKeyRotationPeriod.Duration,
 		masterKeyMeta:         masterKeyMeta,
@@ -401,7 +404,7 @@ func (m *KeyManager) rotateKeyIfNeeded(forceUpdate bool) error {
 		return nil
 	}
 	// Store updated keys in etcd.
-	err = saveKeys(m.mu.leadership, m.masterKeyMeta, keys, m.helper)
+	err = saveKeys(m.kv, m.mu.leadership, m.masterKeyMeta, keys, m.helper)
 	if err != nil {
 		m.helper.eventSaveKeysFailure()
 		log.Error("failed to save keys", zap.Error(err))
@@ -482,7 +485,7 @@ func (m *KeyManager) GetKey(keyID uint64) (*encryptionpb.DataKey, error) {
 
 // SetLeadership sets the PD leadership of the current node. PD leader is responsible to update
 // encryption keys, e.g. key rotation.
-func (m *KeyManager) SetLeadership(leadership *election.Leadership) error {
+func (m *KeyManager) SetLeadership(leadership election.Leadership) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	m.mu.leadership = leadership
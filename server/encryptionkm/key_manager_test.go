@@ -33,6 +33,7 @@ import (
 	"github.com/tikv/pd/pkg/tempurl"
 	"github.com/tikv/pd/pkg/typeutil"
 	"github.com/tikv/pd/server/election"
+	"github.com/tikv/pd/server/kv"
 	"go.etcd.io/etcd/clientv3"
 	"go.etcd.io/etcd/embed"
 )
@@ -108,10 +109,10 @@ func newTestKeyFile(c *C, key ...string) (keyFilePath string, cleanup func()) {
 	return keyFilePath, cleanup
 }
 
-func newTestLeader(c *C, client *clientv3.Client) *election.Leadership {
-	leader := election.NewLeadership(client, "test_leader", "test")
+func newTestLeader(c *C, client *clientv3.Client) *election.EtcdLeadership {
+	leader := election.NewEtcdLeadership(client, "test_leader", "test")
 	timeout := int64(30000000) // about a year.
-	err := leader.Campaign(timeout, "")
+	err := leader.Campaign(timeout, "test")
 	c.Assert(err, IsNil)
 	return leader
 }
@@ -215,7 +216,7 @@ func (s *testKeyManagerSuite) TestNewKeyManagerLoadKeys(c *C) {
 			},
 		},
 	}
-	err = saveKeys(leadership, masterKeyMeta, keys, defaultKeyManagerHelper())
+	err = saveKeys(kv.NewEtcdKVBase(client, ""), leadership, masterKeyMeta, keys, defaultKeyManagerHelper())
 	c.Assert(err, IsNil)
 	// Create the key manager.
 	m, err := NewKeyManager(client, config)
@@ -308,7 +309,7 @@ func (s *testKeyManagerSuite) TestGetKey(c *C) {
 			},
 		},
 	}
-	err := saveKeys(leadership, masterKeyMeta, keys, defaultKeyManagerHelper())
+	err := saveKeys(kv.NewEtcdKVBase(client, ""), leadership, masterKeyMeta, keys, defaultKeyManagerHelper())
 	c.Assert(err, IsNil)
 	// Use default config.
 	config := &encryption.Config{}
@@ -363,7 +364,7 @@ func (s *testKeyManagerSuite) TestLoadKeyEmpty(c *C) {
 			},
 		},
 	}
-	err := saveKeys(leadership, masterKeyMeta, keys, defaultKeyManagerHelper())
+	err := saveKeys(kv.NewEtcdKVBase(client, ""), leadership, masterKeyMeta, keys, defaultKeyManagerHelper())
 	c.Assert(err, IsNil)
 	// Use default config.
 	config := &encryption.Config{}
@@ -427,7 +428,7 @@ func (s *testKeyManagerSuite) TestWatcher(c *C) {
 			},
 		},
 	}
-	err = saveKeys(leadership, masterKeyMeta, keys, defaultKeyManagerHelper())
+	err = saveKeys(kv.NewEtcdKVBase(client, ""), leadership, masterKeyMeta, keys, defaultKeyManagerHelper())
 	c.Assert(err, IsNil)
 	<-reloadEvent
 	key, err := m.GetKey(123)
@@ -453,7 +454,7 @@ func (s *testKeyManagerSuite) TestWatcher(c *C) {
 			},
 		},
 	}
-	err = saveKeys(leadership, masterKeyMeta, keys, defaultKeyManagerHelper())
+	err = saveKeys(kv.NewEtcdKVBase(client, ""), leadership, masterKeyMeta, keys, defaultKeyManagerHelper())
 	c.Assert(err, IsNil)
 	<-reloadEvent
 	key, err = m.GetKey(123)
@@ -579,7 +580,7 @@ func (s *testKeyManagerSuite) TestSetLeadershipWithEncryptionMethodChanged(c *C)
 			},
 		},
 	}
-	err := saveKeys(leadership, masterKeyMeta, keys, defaultKeyManagerHelper())
+	err := saveKeys(kv.NewEtcdKVBase(client, ""), leadership, masterKeyMeta, keys, defaultKeyManagerHelper())
 	c.Assert(err, IsNil)
 	// Config with different encrption method.
 	config := &encryption.Config{
@@ -656,7 +657,7 @@ func (s *testKeyManagerSuite) TestSetLeadershipWithCurrentKeyExposed(c *C) {
 			},
 		},
 	}
-	err := saveKeys(leadership, masterKeyMeta, keys, defaultKeyManagerHelper())
+	err := saveKeys(kv.NewEtcdKVBase(client, ""), leadership, masterKeyMeta, keys, defaultKeyManagerHelper())
 	c.Assert(err, IsNil)
 	// Config with different encrption method.
 	config := &encryption.Config{
@@ -734,7 +735,7 @@ func (s *testKeyManagerSuite) TestSetLeadershipWithCurrentKeyExpired(c *C) {
 			},
 		},
 	}
-	err := saveKeys(leadership, masterKeyMeta, keys, defaultKeyManagerHelper())
+	err := saveKeys(kv.NewEtcdKVBase(client, ""), leadership, masterKeyMeta, keys, defaultKeyManagerHelper())
 	c.Assert(err, IsNil)
 	// Config with 100s rotation period.
 	rotationPeriod, err := time.ParseDuration("100s")
@@ -818,7 +819,7 @@ func (s *testKeyManagerSuite) TestSetLeadershipWithMasterKeyChanged(c *C) {
 			},
 		},
 	}
-	err := saveKeys(leadership, masterKeyMeta, keys, defaultKeyManagerHelper())
+	err := saveKeys(kv.NewEtcdKVBase(client, ""), leadership, masterKeyMeta, keys, defaultKeyManagerHelper())
 	c.Assert(err, IsNil)
 	// Config with a different master key.
 	config := &encryption.Config{
@@ -901,7 +902,7 @@ func (s *testKeyManagerSuite) TestSetLeadershipMasterKeyWithCiphertextKey(c *C)
 			},
 		},
 	}
-	err := saveKeys(leadership, masterKeyMeta, keys, defaultKeyManagerHelper())
+	err := saveKeys(kv.NewEtcdKVBase(client, ""), leadership, masterKeyMeta, keys, defaultKeyManagerHelper())
 	c.Assert(err, IsNil)
 	// Config with a different master key.
 	config := &encryption.Config{
@@ -972,7 +973,7 @@ func (s *testKeyManagerSuite) TestSetLeadershipWithEncryptionDisabling(c *C) {
 			},
 		},
 	}
-	err := saveKeys(leadership, masterKeyMeta, keys, defaultKeyManagerHelper())
+	err := saveKeys(kv.NewEtcdKVBase(client, ""), leadership, masterKeyMeta, keys, defaultKeyManagerHelper())
 	c.Assert(err, IsNil)
 	// Use default config.
 	config := &encryption.Config{}
@@ -1046,7 +1047,7 @@ func (s *testKeyManagerSuite) TestKeyRotation(c *C) {
 			},
 		},
 	}
-	err := saveKeys(leadership, masterKeyMeta, keys, defaultKeyManagerHelper())
+	err := saveKeys(kv.NewEtcdKVBase(client, ""), leadership, masterKeyMeta, keys, defaultKeyManagerHelper())
 	c.Assert(err, IsNil)
 	// Config with 100s rotation period.
 	rotationPeriod, err := time.ParseDuration("100s")
@@ -1159,7 +1160,7 @@ func (s *testKeyManagerSuite) TestKeyRotationConflict(c *C) {
 			},
 		},
 	}
-	err := saveKeys(leadership, masterKeyMeta, keys, defaultKeyManagerHelper())
+	err := saveKeys(kv.NewEtcdKVBase(client, ""), leadership, masterKeyMeta, keys, defaultKeyManagerHelper())
 	c.Assert(err, IsNil)
 	// Config with 100s rotation period.
 	rotationPeriod, err := time.ParseDuration("100s")
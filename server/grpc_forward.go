@@ -0,0 +1,177 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"io"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/kvproto/pkg/pdpb"
+	"github.com/pingcap/log"
+	"github.com/tikv/pd/pkg/errs"
+	"github.com/tikv/pd/pkg/grpcutil"
+	"github.com/tikv/pd/pkg/logutil"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+)
+
+// isLocalRequest returns true if the request should be handled by the server
+// itself rather than be forwarded to the leader.
+func (s *Server) isLocalRequest(forwardedHost string) bool {
+	if forwardedHost == "" {
+		return true
+	}
+	for _, url := range s.GetMemberInfo().GetClientUrls() {
+		if url == forwardedHost {
+			return true
+		}
+	}
+	return false
+}
+
+// getDelegateClient returns the client of the leader which the request is
+// forwarded to. Only the forwarding to the current leader is allowed.
+func (s *Server) getDelegateClient(ctx context.Context, forwardedHost string) (pdpb.PDClient, error) {
+	isLeader := false
+	for _, url := range s.GetLeader().GetClientUrls() {
+		if url == forwardedHost {
+			isLeader = true
+			break
+		}
+	}
+	if !isLeader {
+		return nil, errors.WithStack(ErrNotLeader)
+	}
+	if cc, ok := s.clientConns.Load(forwardedHost); ok {
+		return pdpb.NewPDClient(cc.(*grpc.ClientConn)), nil
+	}
+	tlsConfig, err := s.GetTLSConfig().ToTLSConfig()
+	if err != nil {
+		return nil, err
+	}
+	cc, err := grpcutil.GetClientConn(ctx, forwardedHost, tlsConfig)
+	if err != nil {
+		return nil, err
+	}
+	if old, loaded := s.clientConns.LoadOrStore(forwardedHost, cc); loaded {
+		cc.Close()
+		cc = old.(*grpc.ClientConn)
+	}
+	log.Info("create the client to forward requests to the leader", zap.String("leader", forwardedHost))
+	return pdpb.NewPDClient(cc), nil
+}
+
+// closeDelegateClients closes the connections used to forward requests.
+func (s *Server) closeDelegateClients() {
+	s.clientConns.Range(func(key, cc interface{}) bool {
+		if err := cc.(*grpc.ClientConn).Close(); err != nil {
+			log.Error("failed to close the forwarding connection", errs.ZapError(errs.ErrCloseGRPCConn, err))
+		}
+		s.clientConns.Delete(key)
+		return true
+	})
+}
+
+// forwardStream is a bidirectional gRPC stream whose messages can be relayed.
+type forwardStream interface {
+	SendMsg(m interface{}) error
+	RecvMsg(m interface{}) error
+}
+
+// forwardTSO relays the TSO stream to the leader.
+func (s *Server) forwardTSO(stream pdpb.PD_TsoServer, forwardedHost string) error {
+	client, err := s.getDelegateClient(stream.Context(), forwardedHost)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
+	leaderStream, err := client.Tso(ctx)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return relayStream(stream, leaderStream, "tso",
+		func() interface{} { return &pdpb.TsoRequest{} },
+		func() interface{} { return &pdpb.TsoResponse{} })
+}
+
+// forwardRegionHeartbeat relays the region heartbeat stream to the leader.
+func (s *Server) forwardRegionHeartbeat(stream pdpb.PD_RegionHeartbeatServer, forwardedHost string) error {
+	client, err := s.getDelegateClient(stream.Context(), forwardedHost)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
+	leaderStream, err := client.RegionHeartbeat(ctx)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return relayStream(stream, leaderStream, "region_heartbeat",
+		func() interface{} { return &pdpb.RegionHeartbeatRequest{} },
+		func() interface{} { return &pdpb.RegionHeartbeatResponse{} })
+}
+
+// relayStream sends the requests from the client to the leader, and the
+// responses from the leader back to the client, in two goroutines so that a
+// failure of either side is returned right away. When the client finishes
+// sending, the leader stream is closed for sending and drained, so that the
+// responses of the requests already relayed still reach the client.
+func relayStream(client forwardStream, leader grpc.ClientStream, tp string, newRequest, newResponse func() interface{}) error {
+	errCh := make(chan error, 2)
+	go func() {
+		defer logutil.LogPanic()
+		counter := forwardedRequestCounter.WithLabelValues(tp)
+		for {
+			req := newRequest()
+			if err := client.RecvMsg(req); err != nil {
+				if err != io.EOF {
+					errCh <- errors.WithStack(err)
+				} else if err := leader.CloseSend(); err != nil {
+					errCh <- errors.WithStack(err)
+				}
+				return
+			}
+			counter.Inc()
+			if err := leader.SendMsg(req); err != nil {
+				// The status of a stream ended by the leader is returned by
+				// RecvMsg rather than SendMsg.
+				if err != io.EOF {
+					errCh <- errors.WithStack(err)
+				}
+				return
+			}
+		}
+	}()
+	go func() {
+		defer logutil.LogPanic()
+		for {
+			resp := newResponse()
+			if err := leader.RecvMsg(resp); err != nil {
+				if err == io.EOF {
+					errCh <- nil
+				} else {
+					errCh <- errors.WithStack(err)
+				}
+				return
+			}
+			if err := client.SendMsg(resp); err != nil {
+				errCh <- errors.WithStack(err)
+				return
+			}
+		}
+	}()
+	return <-errCh
+}
@@ -0,0 +1,147 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"io"
+	"time"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/errors"
+	"github.com/pingcap/kvproto/pkg/pdpb"
+	"google.golang.org/grpc/metadata"
+)
+
+var _ = Suite(&testRelayStreamSuite{})
+
+type testRelayStreamSuite struct{}
+
+// mockClientStream receives the requests from reqCh until it is closed, and
+// sends the responses to respCh.
+type mockClientStream struct {
+	reqCh  chan *pdpb.TsoRequest
+	respCh chan *pdpb.TsoResponse
+}
+
+func (s *mockClientStream) SendMsg(m interface{}) error {
+	s.respCh <- m.(*pdpb.TsoResponse)
+	return nil
+}
+
+func (s *mockClientStream) RecvMsg(m interface{}) error {
+	req, ok := <-s.reqCh
+	if !ok {
+		return io.EOF
+	}
+	*m.(*pdpb.TsoRequest) = *req
+	return nil
+}
+
+// mockLeaderStream answers each request with a response of the same count,
+// and ends the stream after it is closed for sending, or with recvErr.
+type mockLeaderStream struct {
+	pending chan *pdpb.TsoResponse
+	recvErr chan error
+}
+
+func newMockLeaderStream() *mockLeaderStream {
+	return &mockLeaderStream{
+		pending: make(chan *pdpb.TsoResponse, 16),
+		recvErr: make(chan error, 1),
+	}
+}
+
+func (s *mockLeaderStream) Header() (metadata.MD, error) { return nil, nil }
+func (s *mockLeaderStream) Trailer() metadata.MD         { return nil }
+func (s *mockLeaderStream) Context() context.Context     { return context.Background() }
+
+func (s *mockLeaderStream) CloseSend() error {
+	close(s.pending)
+	return nil
+}
+
+func (s *mockLeaderStream) SendMsg(m interface{}) error {
+	s.pending <- &pdpb.TsoResponse{Count: m.(*pdpb.TsoRequest).GetCount()}
+	return nil
+}
+
+func (s *mockLeaderStream) RecvMsg(m interface{}) error {
+	select {
+	case resp, ok := <-s.pending:
+		if !ok {
+			return io.EOF
+		}
+		*m.(*pdpb.TsoResponse) = *resp
+		return nil
+	case err := <-s.recvErr:
+		return err
+	}
+}
+
+func (s *testRelayStreamSuite) relay(client *mockClientStream, leader *mockLeaderStream) chan error {
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- relayStream(client, leader, "tso",
+			func() interface{} { return &pdpb.TsoRequest{} },
+			func() interface{} { return &pdpb.TsoResponse{} })
+	}()
+	return errCh
+}
+
+func (s *testRelayStreamSuite) TestDrainAfterClientEOF(c *C) {
+	client := &mockClientStream{
+		reqCh:  make(chan *pdpb.TsoRequest, 3),
+		respCh: make(chan *pdpb.TsoResponse, 3),
+	}
+	for i := uint32(1); i <= 3; i++ {
+		client.reqCh <- &pdpb.TsoRequest{Count: i}
+	}
+	close(client.reqCh)
+	errCh := s.relay(client, newMockLeaderStream())
+
+	select {
+	case err := <-errCh:
+		c.Assert(err, IsNil)
+	case <-time.After(5 * time.Second):
+		c.Fatal("the stream is not relayed")
+	}
+	// The responses of all the relayed requests reach the client.
+	close(client.respCh)
+	var counts []uint32
+	for resp := range client.respCh {
+		counts = append(counts, resp.GetCount())
+	}
+	c.Assert(counts, DeepEquals, []uint32{1, 2, 3})
+}
+
+func (s *testRelayStreamSuite) TestLeaderError(c *C) {
+	// The client does not send anything, but the error of the leader is
+	// still returned right away.
+	client := &mockClientStream{
+		reqCh:  make(chan *pdpb.TsoRequest),
+		respCh: make(chan *pdpb.TsoResponse, 1),
+	}
+	leader := newMockLeaderStream()
+	errCh := s.relay(client, leader)
+	leader.recvErr <- errors.New("leader failed")
+
+	select {
+	case err := <-errCh:
+		c.Assert(err, ErrorMatches, "leader failed")
+	case <-time.After(5 * time.Second):
+		c.Fatal("the error of the leader is not returned")
+	}
+	close(client.reqCh)
+}
@@ -28,14 +28,18 @@ import (
 	"github.com/pingcap/kvproto/pkg/pdpb"
 	"github.com/pingcap/log"
 	"github.com/tikv/pd/pkg/errs"
+	"github.com/tikv/pd/pkg/grpcutil"
 	"github.com/tikv/pd/pkg/logutil"
 	"github.com/tikv/pd/pkg/tsoutil"
 	"github.com/tikv/pd/server/cluster"
 	"github.com/tikv/pd/server/config"
 	"github.com/tikv/pd/server/core"
+	syncer "github.com/tikv/pd/server/region_syncer"
 	"github.com/tikv/pd/server/versioninfo"
 	"go.uber.org/zap"
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 )
 
@@ -54,7 +58,7 @@ func (s *Server) GetMembers(context.Context, *pdpb.GetMembersRequest) (*pdpb.Get
 	if s.IsClosed() {
 		return nil, status.Errorf(codes.Unknown, "server not started")
 	}
-	members, err := cluster.GetMembers(s.GetClient())
+	members, err := s.member.GetMembers()
 	if err != nil {
 		return nil, status.Errorf(codes.Unknown, err.Error())
 	}
@@ -85,6 +89,9 @@ func (s *Server) GetMembers(context.Context, *pdpb.GetMembersRequest) (*pdpb.Get
 
 // Tso implements gRPC PDServer.
 func (s *Server) Tso(stream pdpb.PD_TsoServer) error {
+	if forwardedHost := grpcutil.GetForwardedHost(stream.Context()); !s.isLocalRequest(forwardedHost) {
+		return s.forwardTSO(stream, forwardedHost)
+	}
 	for {
 		request, err := stream.Recv()
 		if err == io.EOF {
@@ -102,7 +109,14 @@ func (s *Server) Tso(stream pdpb.PD_TsoServer) error {
 			return status.Errorf(codes.FailedPrecondition, "mismatch cluster id, need %d but got %d", s.clusterID, request.GetHeader().GetClusterId())
 		}
 		count := request.GetCount()
-		ts, err := s.tsoAllocatorManager.HandleTSORequest(request.GetDcLocation(), count)
+		var ts pdpb.Timestamp
+		// The requests are merged only if there is no Local TSO, whose
+		// timestamps are differentiated by the suffix of the dc-location.
+		if s.tsoAllocatorManager.GetClusterDCLocationsNumber() == 0 {
+			ts, err = s.tsoMerger.handle(request.GetDcLocation(), count)
+		} else {
+			ts, err = s.tsoAllocatorManager.HandleTSORequest(request.GetDcLocation(), count)
+		}
 		if err != nil {
 			return status.Errorf(codes.Unknown, err.Error())
 		}
@@ -288,6 +302,14 @@ func (s *Server) GetAllStores(ctx context.Context, request *pdpb.GetAllStoresReq
 
 // StoreHeartbeat implements gRPC PDServer.
 func (s *Server) StoreHeartbeat(ctx context.Context, request *pdpb.StoreHeartbeatRequest) (*pdpb.StoreHeartbeatResponse, error) {
+	if forwardedHost := grpcutil.GetForwardedHost(ctx); !s.isLocalRequest(forwardedHost) {
+		client, err := s.getDelegateClient(ctx, forwardedHost)
+		if err != nil {
+			return nil, err
+		}
+		forwardedRequestCounter.WithLabelValues("store_heartbeat").Inc()
+		return client.StoreHeartbeat(ctx, request)
+	}
 	if err := s.validateRequest(request.GetHeader()); err != nil {
 		return nil, err
 	}
@@ -373,6 +395,9 @@ func (s *heartbeatServer) Recv() (*pdpb.RegionHeartbeatRequest, error) {
 
 // RegionHeartbeat implements gRPC PDServer.
 func (s *Server) RegionHeartbeat(stream pdpb.PD_RegionHeartbeatServer) error {
+	if forwardedHost := grpcutil.GetForwardedHost(stream.Context()); !s.isLocalRequest(forwardedHost) {
+		return s.forwardRegionHeartbeat(stream, forwardedHost)
+	}
 	server := &heartbeatServer{stream: stream}
 	rc := s.GetRaftCluster()
 	if rc == nil {
@@ -456,15 +481,29 @@ func (s *Server) RegionHeartbeat(stream pdpb.PD_RegionHeartbeatServer) error {
 
 // GetRegion implements gRPC PDServer.
 func (s *Server) GetRegion(ctx context.Context, request *pdpb.GetRegionRequest) (*pdpb.GetRegionResponse, error) {
-	if err := s.validateRequest(request.GetHeader()); err != nil {
+	if forwardedHost := grpcutil.GetForwardedHost(ctx); !s.isLocalRequest(forwardedHost) {
+		client, err := s.getDelegateClient(ctx, forwardedHost)
+		if err != nil {
+			return nil, err
+		}
+		forwardedRequestCounter.WithLabelValues("get_region").Inc()
+		return client.GetRegion(ctx, request)
+	}
+	followerHandle, err := s.validateRegionRequest(ctx, request.GetHeader())
+	if err != nil {
 		return nil, err
 	}
 
-	rc := s.GetRaftCluster()
-	if rc == nil {
-		return &pdpb.GetRegionResponse{Header: s.notBootstrappedHeader()}, nil
+	var region *core.RegionInfo
+	if followerHandle {
+		region = s.basicCluster.SearchRegion(request.GetRegionKey())
+	} else {
+		rc := s.GetRaftCluster()
+		if rc == nil {
+			return &pdpb.GetRegionResponse{Header: s.notBootstrappedHeader()}, nil
+		}
+		region = rc.GetRegionByKey(request.GetRegionKey())
 	}
-	region := rc.GetRegionByKey(request.GetRegionKey())
 	if region == nil {
 		return &pdpb.GetRegionResponse{Header: s.header()}, nil
 	}
@@ -479,16 +518,29 @@ func (s *Server) GetRegion(ctx context.Context, request *pdpb.GetRegionRequest)
 
 // GetPrevRegion implements gRPC PDServer
 func (s *Server) GetPrevRegion(ctx context.Context, request *pdpb.GetRegionRequest) (*pdpb.GetRegionResponse, error) {
-	if err := s.validateRequest(request.GetHeader()); err != nil {
+	if forwardedHost := grpcutil.GetForwardedHost(ctx); !s.isLocalRequest(forwardedHost) {
+		client, err := s.getDelegateClient(ctx, forwardedHost)
+		if err != nil {
+			return nil, err
+		}
+		forwardedRequestCounter.WithLabelValues("get_prev_region").Inc()
+		return client.GetPrevRegion(ctx, request)
+	}
+	followerHandle, err := s.validateRegionRequest(ctx, request.GetHeader())
+	if err != nil {
 		return nil, err
 	}
 
-	rc := s.GetRaftCluster()
-	if rc == nil {
-		return &pdpb.GetRegionResponse{Header: s.notBootstrappedHeader()}, nil
+	var region *core.RegionInfo
+	if followerHandle {
+		region = s.basicCluster.SearchPrevRegion(request.GetRegionKey())
+	} else {
+		rc := s.GetRaftCluster()
+		if rc == nil {
+			return &pdpb.GetRegionResponse{Header: s.notBootstrappedHeader()}, nil
+		}
+		region = rc.GetPrevRegionByKey(request.GetRegionKey())
 	}
-
-	region := rc.GetPrevRegionByKey(request.GetRegionKey())
 	if region == nil {
 		return &pdpb.GetRegionResponse{Header: s.header()}, nil
 	}
@@ -503,15 +555,29 @@ func (s *Server) GetPrevRegion(ctx context.Context, request *pdpb.GetRegionReque
 
 // GetRegionByID implements gRPC PDServer.
 func (s *Server) GetRegionByID(ctx context.Context, request *pdpb.GetRegionByIDRequest) (*pdpb.GetRegionResponse, error) {
-	if err := s.validateRequest(request.GetHeader()); err != nil {
+	if forwardedHost := grpcutil.GetForwardedHost(ctx); !s.isLocalRequest(forwardedHost) {
+		client, err := s.getDelegateClient(ctx, forwardedHost)
+		if err != nil {
+			return nil, err
+		}
+		forwardedRequestCounter.WithLabelValues("get_region_by_id").Inc()
+		return client.GetRegionByID(ctx, request)
+	}
+	followerHandle, err := s.validateRegionRequest(ctx, request.GetHeader())
+	if err != nil {
 		return nil, err
 	}
 
-	rc := s.GetRaftCluster()
-	if rc == nil {
-		return &pdpb.GetRegionResponse{Header: s.notBootstrappedHeader()}, nil
+	var region *core.RegionInfo
+	if followerHandle {
+		region = s.basicCluster.GetRegion(request.GetRegionId())
+	} else {
+		rc := s.GetRaftCluster()
+		if rc == nil {
+			return &pdpb.GetRegionResponse{Header: s.notBootstrappedHeader()}, nil
+		}
+		region = rc.GetRegion(request.GetRegionId())
 	}
-	region := rc.GetRegion(request.GetRegionId())
 	if region == nil {
 		return &pdpb.GetRegionResponse{Header: s.header()}, nil
 	}
@@ -526,15 +592,29 @@ func (s *Server) GetRegionByID(ctx context.Context, request *pdpb.GetRegionByIDR
 
 // ScanRegions implements gRPC PDServer.
 func (s *Server) ScanRegions(ctx context.Context, request *pdpb.ScanRegionsRequest) (*pdpb.ScanRegionsResponse, error) {
-	if err := s.validateRequest(request.GetHeader()); err != nil {
+	if forwardedHost := grpcutil.GetForwardedHost(ctx); !s.isLocalRequest(forwardedHost) {
+		client, err := s.getDelegateClient(ctx, forwardedHost)
+		if err != nil {
+			return nil, err
+		}
+		forwardedRequestCounter.WithLabelValues("scan_regions").Inc()
+		return client.ScanRegions(ctx, request)
+	}
+	followerHandle, err := s.validateRegionRequest(ctx, request.GetHeader())
+	if err != nil {
 		return nil, err
 	}
 
-	rc := s.GetRaftCluster()
-	if rc == nil {
-		return &pdpb.ScanRegionsResponse{Header: s.notBootstrappedHeader()}, nil
+	var regions []*core.RegionInfo
+	if followerHandle {
+		regions = s.basicCluster.ScanRange(request.GetStartKey(), request.GetEndKey(), int(request.GetLimit()))
+	} else {
+		rc := s.GetRaftCluster()
+		if rc == nil {
+			return &pdpb.ScanRegionsResponse{Header: s.notBootstrappedHeader()}, nil
+		}
+		regions = rc.ScanRegions(request.GetStartKey(), request.GetEndKey(), int(request.GetLimit()))
 	}
-	regions := rc.ScanRegions(request.GetStartKey(), request.GetEndKey(), int(request.GetLimit()))
 	resp := &pdpb.ScanRegionsResponse{Header: s.header()}
 	for _, r := range regions {
 		leader := r.GetLeader()
@@ -785,6 +865,15 @@ func (s *Server) SyncRegions(stream pdpb.PD_SyncRegionsServer) error {
 	return s.cluster.GetRegionSyncer().Sync(stream)
 }
 
+// RegionSnapshot sends a snapshot of the regions to the follower which falls
+// out of the history window of the region syncer.
+func (s *Server) RegionSnapshot(request *syncer.SnapshotRequest, stream syncer.SnapshotServer) error {
+	if s.cluster == nil {
+		return ErrNotStarted
+	}
+	return s.cluster.GetRegionSyncer().RegionSnapshot(request, stream)
+}
+
 // UpdateGCSafePoint implements gRPC PDServer.
 func (s *Server) UpdateGCSafePoint(ctx context.Context, request *pdpb.UpdateGCSafePointRequest) (*pdpb.UpdateGCSafePointResponse, error) {
 	if err := s.validateRequest(request.GetHeader()); err != nil {
@@ -928,6 +1017,41 @@ func (s *Server) validateRequest(header *pdpb.RequestHeader) error {
 	return nil
 }
 
+// validateRegionRequest checks the request of the region queries, which can be
+// handled by a follower if the client allows it and the follower is
+// synchronizing the regions from the leader. It returns true if the request is
+// handled by the follower. The index of the region syncer, and on a follower
+// the time since it last heard from the leader, are set in the response
+// header, so that the client can tell how stale the regions are.
+func (s *Server) validateRegionRequest(ctx context.Context, header *pdpb.RequestHeader) (bool, error) {
+	if !grpcutil.IsFollowerHandleEnabled(ctx) {
+		return false, s.validateRequest(header)
+	}
+	syncer := s.cluster.GetRegionSyncer()
+	isLeader := s.member.IsLeader()
+	if isLeader {
+		if err := s.validateRequest(header); err != nil {
+			return false, err
+		}
+	} else {
+		if s.IsClosed() || !syncer.IsRunning() {
+			return false, errors.WithStack(ErrNotLeader)
+		}
+		if header.GetClusterId() != s.clusterID {
+			return false, status.Errorf(codes.FailedPrecondition, "mismatch cluster id, need %d but got %d", s.clusterID, header.GetClusterId())
+		}
+	}
+	md := metadata.Pairs(grpcutil.RegionSyncIndexKey, strconv.FormatUint(syncer.GetNextIndex(), 10))
+	if !isLeader {
+		md.Set(grpcutil.FollowerHandleKey, "true")
+		md.Set(grpcutil.RegionSyncStalenessKey, strconv.FormatInt(syncer.GetSyncStaleness().Milliseconds(), 10))
+	}
+	if err := grpc.SetHeader(ctx, md); err != nil {
+		log.Warn("failed to set the header of region request", errs.ZapError(err))
+	}
+	return !isLeader, nil
+}
+
 func (s *Server) header() *pdpb.ResponseHeader {
 	return &pdpb.ResponseHeader{ClusterId: s.clusterID}
 }
@@ -1001,19 +1125,27 @@ func (s *Server) SyncMaxTS(ctx context.Context, request *pdpb.SyncMaxTSRequest)
 			Dcs:        processedDCs,
 		}, nil
 	}
-	// The second phase of synchronization: do the writing
+	// The second phase of synchronization: do the writing. If any issued Local TSO is
+	// not less than the max ts, return the max one, which means the max ts is too small.
+	var maxLocalTS *pdpb.Timestamp
 	for _, allocator := range allocatorLeaders {
 		if !allocator.IsAllocatorLeader() {
 			continue
 		}
+		issuedLocalTSO := allocator.GetLastIssuedTSO()
+		if tsoutil.CompareTimestamp(&issuedLocalTSO, request.GetMaxTs()) >= 0 &&
+			(maxLocalTS == nil || tsoutil.CompareTimestamp(&issuedLocalTSO, maxLocalTS) > 0) {
+			maxLocalTS = &issuedLocalTSO
+		}
 		if err := allocator.WriteTSO(request.GetMaxTs()); err != nil {
 			return nil, err
 		}
 		processedDCs = append(processedDCs, allocator.GetDCLocation())
 	}
 	return &pdpb.SyncMaxTSResponse{
-		Header: s.header(),
-		Dcs:    processedDCs,
+		Header:     s.header(),
+		MaxLocalTs: maxLocalTS,
+		Dcs:        processedDCs,
 	}, nil
 }
 
@@ -0,0 +1,146 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/kvproto/pkg/pdpb"
+	"github.com/pingcap/log"
+	"github.com/tikv/pd/pkg/errs"
+	"github.com/tikv/pd/pkg/watch"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// watchCheckInterval is the interval to check whether the server is still
+// the leader while a watch stream is idle.
+const watchCheckInterval = 3 * time.Second
+
+// WatchStores implements the watch service. It sends the store changes after
+// the requested revision until the stream is closed or the server is no
+// longer the leader.
+func (s *Server) WatchStores(request *watch.WatchStoresRequest, stream watch.WatchStoresServer) error {
+	if err := s.validateRequest(&pdpb.RequestHeader{ClusterId: request.ClusterID}); err != nil {
+		return err
+	}
+	rc := s.GetRaftCluster()
+	if rc == nil {
+		return errs.ErrNotBootstrapped.FastGenByArgs()
+	}
+
+	ticker := time.NewTicker(watchCheckInterval)
+	defer ticker.Stop()
+	revision := request.Revision
+	for {
+		resp, changed := rc.GetStoreEvents(revision)
+		if resp != nil {
+			if err := stream.Send(resp); err != nil {
+				return errors.WithStack(err)
+			}
+			revision = resp.Revision
+			continue
+		}
+		select {
+		case <-changed:
+		case <-ticker.C:
+			if s.IsClosed() || !s.member.IsLeader() {
+				return errors.WithStack(ErrNotLeader)
+			}
+		case <-stream.Context().Done():
+			return nil
+		}
+	}
+}
+
+// WatchRegions implements the watch service. It sends the region changes
+// recorded by the region syncer from the requested index until the stream is
+// closed or the server is no longer the leader. If the changes from the index
+// are no longer kept, it asks the watcher to reset first.
+func (s *Server) WatchRegions(request *watch.WatchRegionsRequest, stream watch.WatchRegionsServer) error {
+	if err := s.validateRequest(&pdpb.RequestHeader{ClusterId: request.ClusterID}); err != nil {
+		return err
+	}
+	if err := s.checkWatcherCN(stream.Context()); err != nil {
+		return err
+	}
+	rc := s.GetRaftCluster()
+	if rc == nil {
+		return errs.ErrNotBootstrapped.FastGenByArgs()
+	}
+	syncer := rc.GetRegionSyncer()
+
+	ticker := time.NewTicker(watchCheckInterval)
+	defer ticker.Stop()
+	index := request.StartIndex
+	for {
+		resp, changed := syncer.GetRegionEvents(index, request.KeyRanges)
+		if resp != nil {
+			// The changes filtered out are skipped without sending.
+			if resp.ResetRequired || len(resp.Events) > 0 {
+				if err := stream.Send(resp); err != nil {
+					return errors.WithStack(err)
+				}
+			}
+			index = resp.NextIndex
+			continue
+		}
+		select {
+		case <-changed:
+		case <-ticker.C:
+			if s.IsClosed() || !s.member.IsLeader() {
+				return errors.WithStack(ErrNotLeader)
+			}
+		case <-stream.Context().Done():
+			return nil
+		}
+	}
+}
+
+// checkWatcherCN authenticates the region watcher, since the region changes
+// are open to the tools besides PD and TiKV. If TLS is enabled, the watcher
+// must provide a client certificate, and its common name must be allowed if
+// cert-allowed-cn is set. If TLS is not enabled, the watcher can not be
+// authenticated, like all the other requests to the server, so it is allowed
+// with a warning unless cert-allowed-cn is set.
+func (s *Server) checkWatcherCN(ctx context.Context) error {
+	allowedCN := s.cfg.Security.CertAllowedCN
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return status.Error(codes.Unauthenticated, "no peer info found")
+	}
+	if len(s.cfg.Security.CertPath) == 0 && len(s.cfg.Security.KeyPath) == 0 && len(allowedCN) == 0 {
+		log.Warn("the region watcher is not authenticated since TLS is not enabled", zap.Stringer("watcher", p.Addr))
+		return nil
+	}
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(tlsInfo.State.PeerCertificates) == 0 {
+		return status.Error(codes.Unauthenticated, "no client certificate found")
+	}
+	if len(allowedCN) == 0 {
+		return nil
+	}
+	cn := tlsInfo.State.PeerCertificates[0].Subject.CommonName
+	for _, allowed := range allowedCN {
+		if cn == allowed {
+			return nil
+		}
+	}
+	return status.Errorf(codes.PermissionDenied, "common name %s is not allowed", cn)
+}
@@ -42,6 +42,10 @@ import (
 	"go.uber.org/zap"
 )
 
+// loadSplitPolicy is the split policy that splits a region at the middle key
+// calculated by PD, it is not a check policy of TiKV.
+const loadSplitPolicy = "load"
+
 var (
 	// SchedulerConfigHandlerPath is the api router path of the schedule config handler.
 	SchedulerConfigHandlerPath = "/api/v1/scheduler-config"
@@ -120,6 +124,27 @@ func (h *Handler) IsSchedulerDisabled(name string) (bool, error) {
 	return rc.IsSchedulerDisabled(name)
 }
 
+// DiagnoseScheduler returns the diagnosis of a scheduler. For a dry run, it
+// also returns the operators the scheduler would create with the overridden
+// schedule config.
+func (h *Handler) DiagnoseScheduler(name string, dryRun bool, overrides map[string]string) (*cluster.SchedulerDiagnosis, error) {
+	rc, err := h.GetRaftCluster()
+	if err != nil {
+		return nil, err
+	}
+	return rc.DiagnoseScheduler(name, dryRun, overrides)
+}
+
+// DiagnoseCheckers returns the operators the checkers would create for at
+// most limit regions with the overridden schedule config.
+func (h *Handler) DiagnoseCheckers(limit int, overrides map[string]string) (*cluster.CheckerDiagnosis, error) {
+	rc, err := h.GetRaftCluster()
+	if err != nil {
+		return nil, err
+	}
+	return rc.DiagnoseCheckers(limit, overrides)
+}
+
 // GetScheduleConfig returns ScheduleConfig.
 func (h *Handler) GetScheduleConfig() *config.ScheduleConfig {
 	return h.s.GetScheduleConfig()
@@ -214,15 +239,26 @@ func (h *Handler) AddScheduler(name string, args ...string) error {
 		return err
 	}
 
-	s, err := schedule.CreateScheduler(name, c.GetOperatorController(), h.s.storage, schedule.ConfigSliceDecoder(name, args))
+	// Save the scheduler config and persist the option atomically.
+	batch := h.s.storage.NewBatch()
+	s, err := schedule.CreateSchedulerInBatch(name, c.GetOperatorController(), h.s.storage, schedule.ConfigSliceDecoder(name, args), batch)
 	if err != nil {
 		return err
 	}
 	log.Info("create scheduler", zap.String("scheduler-name", s.GetName()), zap.Strings("scheduler-args", args))
 	if err = c.AddScheduler(s, args...); err != nil {
 		log.Error("can not add scheduler", zap.String("scheduler-name", s.GetName()), zap.Strings("scheduler-args", args), errs.ZapError(err))
-	} else if err = h.opt.Persist(c.GetStorage()); err != nil {
+		return err
+	}
+	if err = h.opt.PersistToBatch(batch); err == nil {
+		err = batch.Commit()
+	}
+	if err != nil {
 		log.Error("can not persist scheduler config", errs.ZapError(err))
+		// Don't keep running the scheduler which is not persisted.
+		if rmErr := c.RemoveScheduler(s.GetName()); rmErr != nil {
+			log.Error("can not remove the scheduler not persisted", zap.String("scheduler-name", s.GetName()), errs.ZapError(rmErr))
+		}
 	}
 	return err
 }
@@ -292,6 +328,21 @@ func (h *Handler) AddEvictLeaderScheduler(storeID uint64) error {
 	return h.AddScheduler(schedulers.EvictLeaderType, strconv.FormatUint(storeID, 10))
 }
 
+// AddEvictSlowStoreScheduler adds an evict-slow-store-scheduler.
+func (h *Handler) AddEvictSlowStoreScheduler() error {
+	return h.AddScheduler(schedulers.EvictSlowStoreType)
+}
+
+// AddSplitHotRegionScheduler adds a split-hot-region-scheduler.
+func (h *Handler) AddSplitHotRegionScheduler() error {
+	return h.AddScheduler(schedulers.SplitHotRegionType)
+}
+
+// AddLeaderPreferenceScheduler adds a leader-preference-scheduler.
+func (h *Handler) AddLeaderPreferenceScheduler(preferences string) error {
+	return h.AddScheduler(schedulers.LeaderPreferenceType, preferences)
+}
+
 // AddShuffleLeaderScheduler adds a shuffle-leader-scheduler.
 func (h *Handler) AddShuffleLeaderScheduler() error {
 	return h.AddScheduler(schedulers.ShuffleLeaderType)
@@ -415,6 +466,20 @@ func (h *Handler) GetHistory(start time.Time) ([]operator.OpHistory, error) {
 	return c.GetHistory(start), nil
 }
 
+// GetOperatorRecords returns the operator records in the journal, filtered by
+// region and store if they are not 0.
+func (h *Handler) GetOperatorRecords(regionID, storeID uint64, since time.Time) ([]*schedule.OperatorRecord, error) {
+	c, err := h.GetOperatorController()
+	if err != nil {
+		return nil, err
+	}
+	journal := c.GetOperatorJournal()
+	if journal == nil {
+		return nil, nil
+	}
+	return journal.GetRecords(regionID, storeID, since)
+}
+
 // SetAllStoresLimit is used to set limit of all stores.
 func (h *Handler) SetAllStoresLimit(ratePerMin float64, limitType storelimit.Type) error {
 	c, err := h.GetRaftCluster()
@@ -737,23 +802,29 @@ func (h *Handler) AddSplitRegionOperator(regionID uint64, policyStr string, keys
 		return ErrRegionNotFound(regionID)
 	}
 
-	policy, ok := pdpb.CheckPolicy_value[strings.ToUpper(policyStr)]
-	if !ok {
-		return errors.Errorf("check policy %s is not supported", policyStr)
-	}
+	var op *operator.Operator
+	if strings.ToLower(policyStr) == loadSplitPolicy {
+		// The load policy is handled by PD itself, the region is split at the
+		// middle of its key range.
+		op, err = operator.CreateLoadSplitRegionOperator("admin-split-region", region, operator.OpAdmin, c.GetOpts().GetKeyType())
+	} else {
+		policy, ok := pdpb.CheckPolicy_value[strings.ToUpper(policyStr)]
+		if !ok {
+			return errors.Errorf("check policy %s is not supported", policyStr)
+		}
 
-	var splitKeys [][]byte
-	if pdpb.CheckPolicy(policy) == pdpb.CheckPolicy_USEKEY {
-		for i := range keys {
-			k, err := hex.DecodeString(keys[i])
-			if err != nil {
-				return errors.Errorf("split key %s is not in hex format", keys[i])
+		var splitKeys [][]byte
+		if pdpb.CheckPolicy(policy) == pdpb.CheckPolicy_USEKEY {
+			for i := range keys {
+				k, err := hex.DecodeString(keys[i])
+				if err != nil {
+					return errors.Errorf("split key %s is not in hex format", keys[i])
+				}
+				splitKeys = append(splitKeys, k)
 			}
-			splitKeys = append(splitKeys, k)
 		}
+		op, err = operator.CreateSplitRegionOperator("admin-split-region", region, operator.OpAdmin, pdpb.CheckPolicy(policy), splitKeys)
 	}
-
-	op, err := operator.CreateSplitRegionOperator("admin-split-region", region, operator.OpAdmin, pdpb.CheckPolicy(policy), splitKeys)
 	if err != nil {
 		return err
 	}
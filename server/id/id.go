@@ -19,10 +19,8 @@ import (
 
 	"github.com/pingcap/log"
 	"github.com/tikv/pd/pkg/errs"
-	"github.com/tikv/pd/pkg/etcdutil"
 	"github.com/tikv/pd/pkg/typeutil"
 	"github.com/tikv/pd/server/kv"
-	"go.etcd.io/etcd/clientv3"
 	"go.uber.org/zap"
 )
 
@@ -39,14 +37,14 @@ type AllocatorImpl struct {
 	base uint64
 	end  uint64
 
-	client   *clientv3.Client
+	kv       kv.Base
 	rootPath string
 	member   string
 }
 
-// NewAllocatorImpl creates a new IDAllocator.
-func NewAllocatorImpl(client *clientv3.Client, rootPath string, member string) *AllocatorImpl {
-	return &AllocatorImpl{client: client, rootPath: rootPath, member: member}
+// NewAllocatorImpl creates a new IDAllocator. The kv must not have a root path.
+func NewAllocatorImpl(kvBase kv.Base, rootPath string, member string) *AllocatorImpl {
+	return &AllocatorImpl{kv: kvBase, rootPath: rootPath, member: member}
 }
 
 // Alloc returns a new id.
@@ -71,39 +69,28 @@ func (alloc *AllocatorImpl) Alloc() (uint64, error) {
 
 func (alloc *AllocatorImpl) generate() (uint64, error) {
 	key := alloc.getAllocIDPath()
-	value, err := etcdutil.GetValue(alloc.client, key)
+	value, err := alloc.kv.Load(key)
 	if err != nil {
 		return 0, err
 	}
 
-	var (
-		cmp clientv3.Cmp
-		end uint64
-	)
-
-	if value == nil {
-		// create the key
-		cmp = clientv3.Compare(clientv3.CreateRevision(key), "=", 0)
-	} else {
-		// update the key
-		end, err = typeutil.BytesToUint64(value)
+	var end uint64
+	if value != "" {
+		end, err = typeutil.BytesToUint64([]byte(value))
 		if err != nil {
 			return 0, err
 		}
-
-		cmp = clientv3.Compare(clientv3.Value(key), "=", string(value))
 	}
 
 	end += allocStep
-	value = typeutil.Uint64ToBytes(end)
-	txn := kv.NewSlowLogTxn(alloc.client)
 	leaderPath := path.Join(alloc.rootPath, "leader")
-	t := txn.If(append([]clientv3.Cmp{cmp}, clientv3.Compare(clientv3.Value(leaderPath), "=", alloc.member))...)
-	resp, err := t.Then(clientv3.OpPut(key, string(value))).Commit()
+	// An empty value requires the key not to exist, which creates the key.
+	conds := []kv.Condition{{Key: key, Value: value}, {Key: leaderPath, Value: alloc.member}}
+	ok, err := alloc.kv.Txn(conds, []kv.Op{kv.OpSave(key, string(typeutil.Uint64ToBytes(end)))})
 	if err != nil {
 		return 0, errs.ErrEtcdTxn.Wrap(err).GenWithStackByArgs()
 	}
-	if !resp.Succeeded {
+	if !ok {
 		return 0, errs.ErrEtcdTxn.FastGenByArgs()
 	}
 
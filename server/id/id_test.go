@@ -0,0 +1,73 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package id
+
+import (
+	"testing"
+
+	. "github.com/pingcap/check"
+	"github.com/tikv/pd/server/kv"
+)
+
+const (
+	rootPath   = "/pd/0"
+	leaderPath = "/pd/0/leader"
+)
+
+func TestID(t *testing.T) {
+	TestingT(t)
+}
+
+var _ = Suite(&testIDSuite{})
+
+type testIDSuite struct{}
+
+func (s *testIDSuite) TestAlloc(c *C) {
+	kvBase := kv.NewMemoryKV()
+	c.Assert(kvBase.Save(leaderPath, "pd-1"), IsNil)
+
+	alloc := NewAllocatorImpl(kvBase, rootPath, "pd-1")
+	for i := uint64(1); i <= allocStep+1; i++ {
+		id, err := alloc.Alloc()
+		c.Assert(err, IsNil)
+		c.Assert(id, Equals, i)
+	}
+
+	// A new allocator continues after the ids which are allocated.
+	alloc = NewAllocatorImpl(kvBase, rootPath, "pd-1")
+	id, err := alloc.Alloc()
+	c.Assert(err, IsNil)
+	c.Assert(id, Equals, 2*allocStep+1)
+}
+
+func (s *testIDSuite) TestAllocNotLeader(c *C) {
+	kvBase := kv.NewMemoryKV()
+	alloc := NewAllocatorImpl(kvBase, rootPath, "pd-1")
+	_, err := alloc.Alloc()
+	c.Assert(err, NotNil)
+
+	c.Assert(kvBase.Save(leaderPath, "pd-2"), IsNil)
+	_, err = alloc.Alloc()
+	c.Assert(err, NotNil)
+
+	// Another allocator of the leader doesn't allocate the same ids.
+	c.Assert(kvBase.Save(leaderPath, "pd-1"), IsNil)
+	id, err := alloc.Alloc()
+	c.Assert(err, IsNil)
+	c.Assert(id, Equals, uint64(1))
+	alloc2 := NewAllocatorImpl(kvBase, rootPath, "pd-1")
+	id, err = alloc2.Alloc()
+	c.Assert(err, IsNil)
+	c.Assert(id, Equals, allocStep+1)
+}
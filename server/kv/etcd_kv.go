@@ -65,8 +65,10 @@ func (kv *etcdKVBase) LoadRange(key, endKey string, limit int) ([]string, []stri
 	// removes suffix '/' of the joined string.
 	// As a result, when we try to scan from "foo/", it ends up scanning from "/pd/foo"
 	// internally, and returns unexpected keys such as "foo_bar/baz".
-	key = strings.Join([]string{kv.rootPath, key}, "/")
-	endKey = strings.Join([]string{kv.rootPath, endKey}, "/")
+	if kv.rootPath != "" {
+		key = strings.Join([]string{kv.rootPath, key}, "/")
+		endKey = joinRangeEnd(kv.rootPath, endKey)
+	}
 
 	withRange := clientv3.WithRange(endKey)
 	withLimit := clientv3.WithLimit(int64(limit))
@@ -77,12 +79,19 @@ func (kv *etcdKVBase) LoadRange(key, endKey string, limit int) ([]string, []stri
 	keys := make([]string, 0, len(resp.Kvs))
 	values := make([]string, 0, len(resp.Kvs))
 	for _, item := range resp.Kvs {
-		keys = append(keys, strings.TrimPrefix(strings.TrimPrefix(string(item.Key), kv.rootPath), "/"))
+		keys = append(keys, kv.trimRootPath(string(item.Key)))
 		values = append(values, string(item.Value))
 	}
 	return keys, values, nil
 }
 
+func (kv *etcdKVBase) trimRootPath(key string) string {
+	if kv.rootPath == "" {
+		return key
+	}
+	return strings.TrimPrefix(strings.TrimPrefix(key, kv.rootPath), "/")
+}
+
 func (kv *etcdKVBase) Save(key, value string) error {
 	key = path.Join(kv.rootPath, key)
 
@@ -115,6 +124,79 @@ func (kv *etcdKVBase) Remove(key string) error {
 	return nil
 }
 
+func (kv *etcdKVBase) Txn(conds []Condition, ops []Op) (bool, error) {
+	cmps := make([]clientv3.Cmp, 0, len(conds))
+	for _, cond := range conds {
+		key := path.Join(kv.rootPath, cond.Key)
+		if cond.Value == "" {
+			cmps = append(cmps, clientv3.Compare(clientv3.CreateRevision(key), "=", 0))
+		} else {
+			cmps = append(cmps, clientv3.Compare(clientv3.Value(key), "=", cond.Value))
+		}
+	}
+	etcdOps := make([]clientv3.Op, 0, len(ops))
+	for _, op := range ops {
+		key := path.Join(kv.rootPath, op.Key)
+		if op.Remove {
+			etcdOps = append(etcdOps, clientv3.OpDelete(key))
+		} else {
+			etcdOps = append(etcdOps, clientv3.OpPut(key, op.Value))
+		}
+	}
+
+	txn := NewSlowLogTxn(kv.client)
+	resp, err := txn.If(cmps...).Then(etcdOps...).Commit()
+	if err != nil {
+		err = errs.ErrEtcdTxn.Wrap(err).GenWithStackByCause()
+		log.Error("txn to etcd meet error", zap.Int("ops", len(ops)), errs.ZapError(err))
+		return false, err
+	}
+	return resp.Succeeded, nil
+}
+
+func (kv *etcdKVBase) SaveWithTTL(key, value string, ttl time.Duration) error {
+	key = path.Join(kv.rootPath, key)
+
+	ctx, cancel := context.WithTimeout(kv.client.Ctx(), requestTimeout)
+	defer cancel()
+	if _, err := etcdutil.EtcdKVPutWithTTL(ctx, kv.client, key, value, int64(ttl.Seconds())); err != nil {
+		e := errs.ErrEtcdKVPut.Wrap(err).GenWithStackByCause()
+		log.Error("save to etcd with ttl meet error", zap.String("key", key), zap.String("value", value), errs.ZapError(e))
+		return e
+	}
+	return nil
+}
+
+func (kv *etcdKVBase) LoadWithTTL(prefix string) ([]string, []string, []time.Duration, error) {
+	prefix = path.Join(kv.rootPath, prefix)
+
+	resp, err := etcdutil.EtcdKVGet(kv.client, prefix, clientv3.WithPrefix())
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	keys := make([]string, 0, len(resp.Kvs))
+	values := make([]string, 0, len(resp.Kvs))
+	ttls := make([]time.Duration, 0, len(resp.Kvs))
+	for _, item := range resp.Kvs {
+		if item.Lease == 0 {
+			continue
+		}
+		ctx, cancel := context.WithTimeout(kv.client.Ctx(), requestTimeout)
+		ttl, err := kv.client.TimeToLive(ctx, clientv3.LeaseID(item.Lease))
+		cancel()
+		if err != nil {
+			return nil, nil, nil, errs.ErrEtcdKVGet.Wrap(err).GenWithStackByCause()
+		}
+		if ttl.TTL <= 0 {
+			continue
+		}
+		keys = append(keys, kv.trimRootPath(string(item.Key)))
+		values = append(values, string(item.Value))
+		ttls = append(ttls, time.Duration(ttl.TTL)*time.Second)
+	}
+	return keys, values, ttls, nil
+}
+
 // SlowLogTxn wraps etcd transaction and log slow one.
 type SlowLogTxn struct {
 	clientv3.Txn
@@ -13,10 +13,78 @@
 
 package kv
 
+import "time"
+
+// The limits of a transaction, which are the max number of ops and the max
+// request size of the embedded etcd. TxnOpOverhead is the size reserved for
+// the root path of the key and the encoding of each op in the request.
+const (
+	MaxTxnOps     = 10240
+	MaxTxnBytes   = 1536 * 1024
+	TxnOpOverhead = 128
+)
+
 // Base is an abstract interface for load/save pd cluster data.
 type Base interface {
 	Load(key string) (string, error)
 	LoadRange(key, endKey string, limit int) (keys []string, values []string, err error)
 	Save(key, value string) error
 	Remove(key string) error
+	// Txn applies all ops atomically if all conditions are met. It returns
+	// false without applying any op if any condition is not met.
+	Txn(conds []Condition, ops []Op) (bool, error)
+}
+
+// Op is a write operation of a transaction.
+type Op struct {
+	Key    string
+	Value  string
+	Remove bool
+}
+
+// OpSave returns an Op to store a key-value pair.
+func OpSave(key, value string) Op {
+	return Op{Key: key, Value: value}
+}
+
+// OpRemove returns an Op to delete a key-value pair.
+func OpRemove(key string) Op {
+	return Op{Key: key, Remove: true}
+}
+
+// Condition requires the value of the key to be equal to Value when a
+// transaction commits. An empty Value requires the key not to exist, so a key
+// saved with an empty value doesn't meet it, which is the same as comparing
+// the create revision with 0 in etcd. Every Base checks it in the same way.
+type Condition struct {
+	Key   string
+	Value string
+}
+
+// TTLBase is a Base which can save the key-value pairs with a ttl.
+type TTLBase interface {
+	Base
+	// SaveWithTTL stores a key-value pair which is removed after the ttl.
+	SaveWithTTL(key, value string, ttl time.Duration) error
+	// LoadWithTTL gets the unexpired key-value pairs with the prefix, which
+	// are saved by SaveWithTTL, and their remaining ttls.
+	LoadWithTTL(prefix string) (keys []string, values []string, ttls []time.Duration, err error)
+}
+
+// noEndKey is the end key of LoadRange to load all keys from the start key to
+// the end, the same as etcd. Every Base reads it in the same way.
+const noEndKey = "\x00"
+
+// GetPrefixRangeEnd gets the end key of the range which contains all keys
+// with the prefix.
+func GetPrefixRangeEnd(prefix string) string {
+	end := []byte(prefix)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return string(end[:i+1])
+		}
+	}
+	// The prefix is empty or all 0xff, so the range contains all keys after it.
+	return noEndKey
 }
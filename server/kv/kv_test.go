@@ -16,12 +16,15 @@ package kv
 import (
 	"fmt"
 	"io/ioutil"
+	"math"
 	"net/url"
 	"os"
 	"path"
 	"sort"
 	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	. "github.com/pingcap/check"
 	"github.com/tikv/pd/pkg/tempurl"
@@ -54,6 +57,62 @@ func (s *testKVSuite) TestEtcd(c *C) {
 	kv := NewEtcdKVBase(client, rootPath)
 	s.testReadWrite(c, kv)
 	s.testRange(c, kv)
+	s.testTxn(c, kv)
+	s.testTxnCondition(c, kv)
+	s.testTTL(c, kv)
+
+	// The kv without a root path uses the keys as they are.
+	kv = NewEtcdKVBase(client, "")
+	keys, _, err := kv.LoadRange(rootPath+"/test/", GetPrefixRangeEnd(rootPath+"/test/"), 100)
+	c.Assert(err, IsNil)
+	c.Assert(keys, DeepEquals, []string{rootPath + "/test/a", rootPath + "/test/ab"})
+}
+
+func (s *testKVSuite) TestEtcdTxnLimits(c *C) {
+	cfg := newTestSingleConfig()
+	cfg.MaxTxnOps = MaxTxnOps
+	cfg.MaxRequestBytes = MaxTxnBytes
+	defer cleanConfig(cfg)
+	etcd, err := embed.StartEtcd(cfg)
+	c.Assert(err, IsNil)
+	defer etcd.Close()
+
+	ep := cfg.LCUrls[0].String()
+	client, err := clientv3.New(clientv3.Config{
+		Endpoints: []string{ep},
+	})
+	c.Assert(err, IsNil)
+	defer client.Close()
+	kv := NewEtcdKVBase(client, path.Join("/pd", strconv.FormatUint(math.MaxUint64, 10)))
+
+	// The largest transaction within the limits is accepted.
+	var ops []Op
+	size := 0
+	for i := 0; size < MaxTxnBytes; i++ {
+		key := fmt.Sprintf("rules/rule-%02d", i)
+		n := 100*1024 - len(key) - TxnOpOverhead
+		if size+len(key)+n+TxnOpOverhead > MaxTxnBytes {
+			n = MaxTxnBytes - size - len(key) - TxnOpOverhead
+		}
+		ops = append(ops, OpSave(key, strings.Repeat("r", n)))
+		size += len(key) + n + TxnOpOverhead
+	}
+	ok, err := kv.Txn(nil, ops)
+	c.Assert(err, IsNil)
+	c.Assert(ok, IsTrue)
+	ops = ops[:0]
+	for i := 0; i < MaxTxnOps; i++ {
+		ops = append(ops, OpRemove(fmt.Sprintf("rules/rule-%02d", i)))
+	}
+	ok, err = kv.Txn(nil, ops)
+	c.Assert(err, IsNil)
+	c.Assert(ok, IsTrue)
+
+	// The transactions out of the limits are rejected.
+	_, err = kv.Txn(nil, []Op{OpSave("rules/large", strings.Repeat("r", MaxTxnBytes))})
+	c.Assert(err, NotNil)
+	_, err = kv.Txn(nil, append(ops, OpRemove("rules/large")))
+	c.Assert(err, NotNil)
 }
 
 func (s *testKVSuite) TestLevelDB(c *C) {
@@ -62,15 +121,40 @@ func (s *testKVSuite) TestLevelDB(c *C) {
 	defer os.RemoveAll(dir)
 	kv, err := NewLeveldbKV(dir)
 	c.Assert(err, IsNil)
+	defer kv.Close()
 
 	s.testReadWrite(c, kv)
 	s.testRange(c, kv)
+	s.testTxn(c, kv)
+	s.testTxnCondition(c, kv)
+	s.testTTL(c, kv)
+
+	// The expired key-value pairs are removed.
+	c.Assert(kv.SaveWithTTL("ttl/expired", "1", time.Millisecond), IsNil)
+	time.Sleep(10 * time.Millisecond)
+	keys, _, _, err := kv.LoadWithTTL("ttl/expired")
+	c.Assert(err, IsNil)
+	c.Assert(keys, HasLen, 0)
+	v, err := kv.Load("ttl/expired")
+	c.Assert(err, IsNil)
+	c.Assert(v, Equals, "")
+
+	root := NewRootPathKV(kv, "/pd/100")
+	s.testReadWrite(c, root)
+	s.testRange(c, root)
+	s.testTxn(c, root)
+	s.testTxnCondition(c, root)
+	v, err = kv.Load("/pd/100/txn/a")
+	c.Assert(err, IsNil)
+	c.Assert(v, Equals, "3")
 }
 
 func (s *testKVSuite) TestMemKV(c *C) {
 	kv := NewMemoryKV()
 	s.testReadWrite(c, kv)
 	s.testRange(c, kv)
+	s.testTxn(c, kv)
+	s.testTxnCondition(c, kv)
 }
 
 func (s *testKVSuite) testReadWrite(c *C, kv Base) {
@@ -124,6 +208,86 @@ func (s *testKVSuite) testRange(c *C, kv Base) {
 		c.Assert(ks, DeepEquals, tc.expect)
 		c.Assert(vs, DeepEquals, tc.expect)
 	}
+
+	// The range of an empty or all 0xff prefix has no end key.
+	for _, k := range []string{"\xff", "\xff\xff/a"} {
+		c.Assert(kv.Save(k, k), IsNil)
+	}
+	ks, _, err := kv.LoadRange("\xff", GetPrefixRangeEnd("\xff"), 100)
+	c.Assert(err, IsNil)
+	c.Assert(ks, DeepEquals, []string{"\xff", "\xff\xff/a"})
+	ks, _, err = kv.LoadRange("testa/", GetPrefixRangeEnd(""), 100)
+	c.Assert(err, IsNil)
+	c.Assert(ks, DeepEquals, []string{"testa/a", "testa/ab", "\xff", "\xff\xff/a"})
+}
+
+func (s *testKVSuite) testTxn(c *C, kv Base) {
+	ok, err := kv.Txn(nil, []Op{OpSave("txn/a", "1"), OpSave("txn/b", "2")})
+	c.Assert(err, IsNil)
+	c.Assert(ok, IsTrue)
+
+	// The ops are not applied if a condition is not met.
+	ok, err = kv.Txn([]Condition{{Key: "txn/a", Value: "1"}, {Key: "txn/c"}},
+		[]Op{OpSave("txn/a", "3"), OpRemove("txn/b")})
+	c.Assert(err, IsNil)
+	c.Assert(ok, IsTrue)
+	ok, err = kv.Txn([]Condition{{Key: "txn/a", Value: "1"}},
+		[]Op{OpSave("txn/a", "4"), OpSave("txn/c", "4")})
+	c.Assert(err, IsNil)
+	c.Assert(ok, IsFalse)
+	ok, err = kv.Txn([]Condition{{Key: "txn/a"}}, []Op{OpSave("txn/c", "4")})
+	c.Assert(err, IsNil)
+	c.Assert(ok, IsFalse)
+
+	for key, expect := range map[string]string{"txn/a": "3", "txn/b": "", "txn/c": ""} {
+		v, err := kv.Load(key)
+		c.Assert(err, IsNil)
+		c.Assert(v, Equals, expect)
+	}
+}
+
+func (s *testKVSuite) testTxnCondition(c *C, kv Base) {
+	c.Assert(kv.Save("cond/value", "1"), IsNil)
+	c.Assert(kv.Save("cond/empty", ""), IsNil)
+
+	// An empty value requires the key not to exist, even if it's saved with
+	// an empty value.
+	testCases := []struct {
+		cond   Condition
+		expect bool
+	}{
+		{cond: Condition{Key: "cond/value", Value: "1"}, expect: true},
+		{cond: Condition{Key: "cond/value", Value: "2"}, expect: false},
+		{cond: Condition{Key: "cond/value"}, expect: false},
+		{cond: Condition{Key: "cond/empty"}, expect: false},
+		{cond: Condition{Key: "cond/empty", Value: "1"}, expect: false},
+		{cond: Condition{Key: "cond/none"}, expect: true},
+		{cond: Condition{Key: "cond/none", Value: "1"}, expect: false},
+	}
+	for _, tc := range testCases {
+		ok, err := kv.Txn([]Condition{tc.cond}, []Op{OpSave("cond/result", tc.cond.Key)})
+		c.Assert(err, IsNil)
+		c.Assert(ok, Equals, tc.expect, Commentf("%+v", tc.cond))
+	}
+}
+
+func (s *testKVSuite) testTTL(c *C, kv TTLBase) {
+	c.Assert(kv.Save("ttl/a", "0"), IsNil)
+	c.Assert(kv.SaveWithTTL("ttl/b", "1", time.Minute), IsNil)
+	c.Assert(kv.SaveWithTTL("ttl/c", "2", time.Minute), IsNil)
+	v, err := kv.Load("ttl/b")
+	c.Assert(err, IsNil)
+	c.Assert(v, Equals, "1")
+
+	// Only the key-value pairs saved with a ttl are loaded.
+	keys, values, ttls, err := kv.LoadWithTTL("ttl/")
+	c.Assert(err, IsNil)
+	c.Assert(keys, DeepEquals, []string{"ttl/b", "ttl/c"})
+	c.Assert(values, DeepEquals, []string{"1", "2"})
+	for _, ttl := range ttls {
+		c.Assert(ttl, Greater, time.Duration(0))
+		c.Assert(ttl <= time.Minute, IsTrue)
+	}
 }
 
 func newTestSingleConfig() *embed.Config {
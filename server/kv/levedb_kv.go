@@ -14,6 +14,9 @@
 package kv
 
 import (
+	"strconv"
+	"time"
+
 	"github.com/gogo/protobuf/proto"
 	"github.com/pingcap/errors"
 	"github.com/pingcap/kvproto/pkg/metapb"
@@ -50,7 +53,11 @@ func (kv *LeveldbKV) Load(key string) (string, error) {
 
 // LoadRange gets a range of value for a given key range.
 func (kv *LeveldbKV) LoadRange(startKey, endKey string, limit int) ([]string, []string, error) {
-	iter := kv.NewIterator(&util.Range{Start: []byte(startKey), Limit: []byte(endKey)}, nil)
+	r := &util.Range{Start: []byte(startKey), Limit: []byte(endKey)}
+	if endKey == noEndKey {
+		r.Limit = nil
+	}
+	iter := kv.NewIterator(r, nil)
 	keys := make([]string, 0, limit)
 	values := make([]string, 0, limit)
 	count := 0
@@ -76,6 +83,114 @@ func (kv *LeveldbKV) Remove(key string) error {
 	return errors.WithStack(kv.Delete([]byte(key), nil))
 }
 
+// Txn applies the ops in a batch. The conditions are checked in a leveldb
+// transaction, which blocks other writes until it is committed.
+func (kv *LeveldbKV) Txn(conds []Condition, ops []Op) (bool, error) {
+	batch := new(leveldb.Batch)
+	for _, op := range ops {
+		if op.Remove {
+			batch.Delete([]byte(op.Key))
+		} else {
+			batch.Put([]byte(op.Key), []byte(op.Value))
+		}
+	}
+	if len(conds) == 0 {
+		if err := kv.Write(batch, nil); err != nil {
+			return false, errs.ErrLevelDBWrite.Wrap(err).GenWithStackByCause()
+		}
+		return true, nil
+	}
+
+	tr, err := kv.OpenTransaction()
+	if err != nil {
+		return false, errs.ErrLevelDBWrite.Wrap(err).GenWithStackByCause()
+	}
+	defer tr.Discard()
+	for _, cond := range conds {
+		v, err := tr.Get([]byte(cond.Key), nil)
+		if err == leveldb.ErrNotFound {
+			if cond.Value != "" {
+				return false, nil
+			}
+			continue
+		}
+		if err != nil {
+			return false, errors.WithStack(err)
+		}
+		if cond.Value == "" || string(v) != cond.Value {
+			return false, nil
+		}
+	}
+	if err := tr.Write(batch, nil); err != nil {
+		return false, errs.ErrLevelDBWrite.Wrap(err).GenWithStackByCause()
+	}
+	if err := tr.Commit(); err != nil {
+		return false, errs.ErrLevelDBWrite.Wrap(err).GenWithStackByCause()
+	}
+	return true, nil
+}
+
+// ttlDeadlinePrefix is the prefix of the keys which store the deadlines of
+// the key-value pairs saved with a ttl.
+const ttlDeadlinePrefix = "ttl_deadline/"
+
+// SaveWithTTL stores a key-value pair with the deadline of the ttl. It is
+// removed when LoadWithTTL meets it after the deadline.
+func (kv *LeveldbKV) SaveWithTTL(key, value string, ttl time.Duration) error {
+	deadline := time.Now().Add(ttl).UnixNano()
+	batch := new(leveldb.Batch)
+	batch.Put([]byte(key), []byte(value))
+	batch.Put([]byte(ttlDeadlinePrefix+key), []byte(strconv.FormatInt(deadline, 10)))
+	if err := kv.Write(batch, nil); err != nil {
+		return errs.ErrLevelDBWrite.Wrap(err).GenWithStackByCause()
+	}
+	return nil
+}
+
+// LoadWithTTL gets the unexpired key-value pairs with the prefix which are
+// saved by SaveWithTTL, and removes the expired ones.
+func (kv *LeveldbKV) LoadWithTTL(prefix string) ([]string, []string, []time.Duration, error) {
+	keys, values, err := kv.LoadRange(prefix, GetPrefixRangeEnd(prefix), 0)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	var (
+		ttlKeys   []string
+		ttlValues []string
+		ttls      []time.Duration
+		expired   = new(leveldb.Batch)
+		now       = time.Now()
+	)
+	for i, key := range keys {
+		v, err := kv.Load(ttlDeadlinePrefix + key)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if v == "" {
+			continue
+		}
+		deadline, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return nil, nil, nil, errs.ErrStrconvParseInt.Wrap(err).GenWithStackByCause()
+		}
+		ttl := time.Unix(0, deadline).Sub(now)
+		if ttl <= 0 {
+			expired.Delete([]byte(key))
+			expired.Delete([]byte(ttlDeadlinePrefix + key))
+			continue
+		}
+		ttlKeys = append(ttlKeys, key)
+		ttlValues = append(ttlValues, values[i])
+		ttls = append(ttls, ttl)
+	}
+	if expired.Len() > 0 {
+		if err := kv.Write(expired, nil); err != nil {
+			return nil, nil, nil, errs.ErrLevelDBWrite.Wrap(err).GenWithStackByCause()
+		}
+	}
+	return ttlKeys, ttlValues, ttls, nil
+}
+
 // SaveRegions stores some regions.
 func (kv *LeveldbKV) SaveRegions(regions map[string]*metapb.Region) error {
 	batch := new(leveldb.Batch)
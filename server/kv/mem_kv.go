@@ -54,14 +54,19 @@ func (kv *memoryKV) LoadRange(key, endKey string, limit int) ([]string, []string
 	defer kv.RUnlock()
 	keys := make([]string, 0, limit)
 	values := make([]string, 0, limit)
-	kv.tree.AscendRange(memoryKVItem{key, ""}, memoryKVItem{endKey, ""}, func(item btree.Item) bool {
+	iter := func(item btree.Item) bool {
 		keys = append(keys, item.(memoryKVItem).key)
 		values = append(values, item.(memoryKVItem).value)
 		if limit > 0 {
 			return len(keys) < limit
 		}
 		return true
-	})
+	}
+	if endKey == noEndKey {
+		kv.tree.AscendGreaterOrEqual(memoryKVItem{key, ""}, iter)
+	} else {
+		kv.tree.AscendRange(memoryKVItem{key, ""}, memoryKVItem{endKey, ""}, iter)
+	}
 	return keys, values, nil
 }
 
@@ -79,3 +84,27 @@ func (kv *memoryKV) Remove(key string) error {
 	kv.tree.Delete(memoryKVItem{key, ""})
 	return nil
 }
+
+func (kv *memoryKV) Txn(conds []Condition, ops []Op) (bool, error) {
+	kv.Lock()
+	defer kv.Unlock()
+
+	for _, cond := range conds {
+		item := kv.tree.Get(memoryKVItem{cond.Key, ""})
+		if item == nil {
+			if cond.Value != "" {
+				return false, nil
+			}
+		} else if cond.Value == "" || item.(memoryKVItem).value != cond.Value {
+			return false, nil
+		}
+	}
+	for _, op := range ops {
+		if op.Remove {
+			kv.tree.Delete(memoryKVItem{op.Key, ""})
+		} else {
+			kv.tree.ReplaceOrInsert(memoryKVItem{op.Key, op.Value})
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,82 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kv
+
+import (
+	"path"
+	"strings"
+)
+
+type rootPathKV struct {
+	Base
+	rootPath string
+}
+
+// NewRootPathKV creates a kv which puts all keys under the root path of the
+// underlying kv, the same as what the etcd kv does with its root path.
+func NewRootPathKV(base Base, rootPath string) Base {
+	return &rootPathKV{
+		Base:     base,
+		rootPath: rootPath,
+	}
+}
+
+func (kv *rootPathKV) Load(key string) (string, error) {
+	return kv.Base.Load(path.Join(kv.rootPath, key))
+}
+
+func (kv *rootPathKV) LoadRange(key, endKey string, limit int) ([]string, []string, error) {
+	// Use `strings.Join` for the same reason as the etcd kv, which keeps the
+	// suffix '/' of the key.
+	key = strings.Join([]string{kv.rootPath, key}, "/")
+	endKey = joinRangeEnd(kv.rootPath, endKey)
+	keys, values, err := kv.Base.LoadRange(key, endKey, limit)
+	if err != nil {
+		return nil, nil, err
+	}
+	for i := range keys {
+		keys[i] = strings.TrimPrefix(strings.TrimPrefix(keys[i], kv.rootPath), "/")
+	}
+	return keys, values, nil
+}
+
+// joinRangeEnd puts the end key of a range under the root path. The range
+// without an end key ends with the keys under the root path.
+func joinRangeEnd(rootPath, endKey string) string {
+	if endKey == noEndKey {
+		return GetPrefixRangeEnd(rootPath + "/")
+	}
+	return strings.Join([]string{rootPath, endKey}, "/")
+}
+
+func (kv *rootPathKV) Save(key, value string) error {
+	return kv.Base.Save(path.Join(kv.rootPath, key), value)
+}
+
+func (kv *rootPathKV) Remove(key string) error {
+	return kv.Base.Remove(path.Join(kv.rootPath, key))
+}
+
+func (kv *rootPathKV) Txn(conds []Condition, ops []Op) (bool, error) {
+	rootConds := make([]Condition, 0, len(conds))
+	for _, cond := range conds {
+		rootConds = append(rootConds, Condition{Key: path.Join(kv.rootPath, cond.Key), Value: cond.Value})
+	}
+	rootOps := make([]Op, 0, len(ops))
+	for _, op := range ops {
+		op.Key = path.Join(kv.rootPath, op.Key)
+		rootOps = append(rootOps, op)
+	}
+	return kv.Base.Txn(rootConds, rootOps)
+}
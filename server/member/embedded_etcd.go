@@ -0,0 +1,222 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"context"
+	"crypto/tls"
+	"math/rand"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/kvproto/pkg/pdpb"
+	"github.com/pingcap/log"
+	"github.com/tikv/pd/pkg/errs"
+	"github.com/tikv/pd/pkg/etcdutil"
+	"github.com/tikv/pd/server/config"
+	"github.com/tikv/pd/server/election"
+	"github.com/tikv/pd/server/kv"
+	"go.etcd.io/etcd/clientv3"
+	"go.etcd.io/etcd/embed"
+	"go.uber.org/zap"
+)
+
+const (
+	etcdTimeout = time.Second * 3
+	// The timeout to wait transfer etcd leader to complete.
+	moveLeaderTimeout = 5 * time.Second
+)
+
+// EmbeddedEtcdMember is a member of the cluster which runs with the embedded
+// etcd. The PD leader is elected with the etcd lease, and the etcd members
+// are the PD members.
+type EmbeddedEtcdMember struct {
+	baseMember
+	// etcd and cluster information.
+	etcd   *embed.Etcd
+	client *clientv3.Client
+}
+
+// NewEmbeddedEtcdMember creates a new EmbeddedEtcdMember with a client of
+// the started etcd.
+func NewEmbeddedEtcdMember(etcd *embed.Etcd, tlsConfig *tls.Config) (*EmbeddedEtcdMember, error) {
+	endpoints := []string{etcd.Config().ACUrls[0].String()}
+	log.Info("create etcd v3 client", zap.Strings("endpoints", endpoints))
+
+	client, err := clientv3.New(clientv3.Config{
+		Endpoints:   endpoints,
+		DialTimeout: etcdTimeout,
+		TLS:         tlsConfig,
+	})
+	if err != nil {
+		return nil, errs.ErrNewEtcdClient.Wrap(err).GenWithStackByCause()
+	}
+	m := &EmbeddedEtcdMember{
+		etcd:   etcd,
+		client: client,
+	}
+	m.id = uint64(etcd.Server.ID())
+	m.kv = kv.NewEtcdKVBase(client, "")
+	return m, nil
+}
+
+// Etcd returns etcd related information.
+func (m *EmbeddedEtcdMember) Etcd() *embed.Etcd {
+	return m.etcd
+}
+
+// Client returns the etcd client.
+func (m *EmbeddedEtcdMember) Client() *clientv3.Client {
+	return m.client
+}
+
+// CheckLeader checks returns true if it is needed to check later.
+func (m *EmbeddedEtcdMember) CheckLeader() (*pdpb.Member, int64, bool) {
+	if m.GetEtcdLeader() == 0 {
+		log.Error("no etcd leader, check pd leader later", errs.ZapError(errs.ErrEtcdLeaderNotFound))
+		time.Sleep(200 * time.Millisecond)
+		return nil, 0, true
+	}
+
+	leader, rev, err := election.GetLeader(m.client, m.GetLeaderPath())
+	if err != nil {
+		log.Error("getting pd leader meets error", errs.ZapError(err))
+		time.Sleep(200 * time.Millisecond)
+		return nil, 0, true
+	}
+	if leader != nil {
+		if m.isSameLeader(leader) {
+			// oh, we are already a PD leader, which indicates we may meet something wrong
+			// in previous CampaignLeader. We should delete the leadership and campaign again.
+			log.Warn("the pd leader has not changed, delete and campaign again", zap.Stringer("old-pd-leader", leader))
+			if err = m.leadership.DeleteLeader(); err != nil {
+				log.Error("deleting pd leader key meets error", errs.ZapError(err))
+				time.Sleep(200 * time.Millisecond)
+				return nil, 0, true
+			}
+		}
+	}
+	return leader, rev, false
+}
+
+// CheckPriority checks whether the etcd leader should be moved according to the priority.
+func (m *EmbeddedEtcdMember) CheckPriority(ctx context.Context) {
+	etcdLeader := m.GetEtcdLeader()
+	if etcdLeader == m.ID() || etcdLeader == 0 {
+		return
+	}
+	myPriority, err := m.GetMemberLeaderPriority(m.ID())
+	if err != nil {
+		log.Error("failed to load leader priority", errs.ZapError(err))
+		return
+	}
+	leaderPriority, err := m.GetMemberLeaderPriority(etcdLeader)
+	if err != nil {
+		log.Error("failed to load etcd leader priority", errs.ZapError(err))
+		return
+	}
+	if myPriority > leaderPriority {
+		err := m.MoveEtcdLeader(ctx, etcdLeader, m.ID())
+		if err != nil {
+			log.Error("failed to transfer etcd leader", errs.ZapError(err))
+		} else {
+			log.Info("transfer etcd leader",
+				zap.Uint64("from", etcdLeader),
+				zap.Uint64("to", m.ID()))
+		}
+	}
+}
+
+// MoveEtcdLeader tries to transfer etcd leader.
+func (m *EmbeddedEtcdMember) MoveEtcdLeader(ctx context.Context, old, new uint64) error {
+	moveCtx, cancel := context.WithTimeout(ctx, moveLeaderTimeout)
+	defer cancel()
+	err := m.etcd.Server.MoveLeader(moveCtx, old, new)
+	if err != nil {
+		return errs.ErrEtcdMoveLeader.Wrap(err).GenWithStackByCause()
+	}
+	return nil
+}
+
+// GetEtcdLeader returns the etcd leader ID.
+func (m *EmbeddedEtcdMember) GetEtcdLeader() uint64 {
+	return m.etcd.Server.Lead()
+}
+
+// MemberInfo initializes the member info.
+func (m *EmbeddedEtcdMember) MemberInfo(cfg *config.Config, name string, rootPath string) {
+	m.initMemberInfo(cfg, name, rootPath)
+	m.leadership = election.NewEtcdLeadership(m.client, m.GetLeaderPath(), "pd leader election")
+}
+
+// ResignEtcdLeader resigns current PD's etcd leadership. If nextLeader is empty, all
+// other pd-servers can campaign.
+func (m *EmbeddedEtcdMember) ResignEtcdLeader(ctx context.Context, from string, nextEtcdLeader string) error {
+	log.Info("try to resign etcd leader to next pd-server", zap.String("from", from), zap.String("to", nextEtcdLeader))
+	// Determine next etcd leader candidates.
+	var etcdLeaderIDs []uint64
+	res, err := etcdutil.ListEtcdMembers(m.client)
+	if err != nil {
+		return err
+	}
+	for _, member := range res.Members {
+		if (nextEtcdLeader == "" && member.ID != m.id) || (nextEtcdLeader != "" && member.Name == nextEtcdLeader) {
+			etcdLeaderIDs = append(etcdLeaderIDs, member.GetID())
+		}
+	}
+	if len(etcdLeaderIDs) == 0 {
+		return errors.New("no valid pd to transfer etcd leader")
+	}
+	nextEtcdLeaderID := etcdLeaderIDs[rand.Intn(len(etcdLeaderIDs))]
+	return m.MoveEtcdLeader(ctx, m.ID(), nextEtcdLeaderID)
+}
+
+// GetMembers returns the etcd members as PD members.
+func (m *EmbeddedEtcdMember) GetMembers() ([]*pdpb.Member, error) {
+	listResp, err := etcdutil.ListEtcdMembers(m.client)
+	if err != nil {
+		return nil, err
+	}
+
+	members := make([]*pdpb.Member, 0, len(listResp.Members))
+	for _, m := range listResp.Members {
+		info := &pdpb.Member{
+			Name:       m.Name,
+			MemberId:   m.ID,
+			ClientUrls: m.ClientURLs,
+			PeerUrls:   m.PeerURLs,
+		}
+		members = append(members, info)
+	}
+
+	return members, nil
+}
+
+// RemoveMember removes a member from the etcd cluster.
+func (m *EmbeddedEtcdMember) RemoveMember(id uint64) error {
+	_, err := etcdutil.RemoveEtcdMember(m.client, id)
+	return err
+}
+
+// Close closes the etcd client and the embedded etcd.
+func (m *EmbeddedEtcdMember) Close() {
+	if m.client != nil {
+		if err := m.client.Close(); err != nil {
+			log.Error("close etcd client meet error", errs.ZapError(errs.ErrCloseEtcdClient, err))
+		}
+	}
+	if m.etcd != nil {
+		m.etcd.Close()
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"context"
+	"math/rand"
+	"strconv"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/pingcap/kvproto/pkg/pdpb"
+	"github.com/tikv/pd/pkg/errs"
+	"github.com/tikv/pd/server/config"
+	"github.com/tikv/pd/server/election"
+	"github.com/tikv/pd/server/kv"
+)
+
+// standaloneMemberIDPath is the path to persist the member ID, so that the
+// member keeps its ID after restarting.
+const standaloneMemberIDPath = "/pd/standalone_member_id"
+
+// StandaloneMember is the only member of a standalone server, which runs
+// without etcd. It becomes the PD leader as soon as it campaigns.
+type StandaloneMember struct {
+	baseMember
+}
+
+// NewStandaloneMember creates a new StandaloneMember which stores the member
+// information in the kv. The kv must not have a root path.
+func NewStandaloneMember(kvBase kv.Base) (*StandaloneMember, error) {
+	id, err := initOrGetMemberID(kvBase)
+	if err != nil {
+		return nil, err
+	}
+	m := &StandaloneMember{}
+	m.id = id
+	m.kv = kvBase
+	return m, nil
+}
+
+func initOrGetMemberID(kvBase kv.Base) (uint64, error) {
+	value, err := kvBase.Load(standaloneMemberIDPath)
+	if err != nil {
+		return 0, err
+	}
+	if value != "" {
+		id, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return 0, errs.ErrStrconvParseUint.Wrap(err).GenWithStackByCause()
+		}
+		return id, nil
+	}
+	// The ID must not be 0, which means no member.
+	id := rand.Uint64()>>1 + 1
+	if err := kvBase.Save(standaloneMemberIDPath, strconv.FormatUint(id, 10)); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// MemberInfo initializes the member info.
+func (m *StandaloneMember) MemberInfo(cfg *config.Config, name string, rootPath string) {
+	m.initMemberInfo(cfg, name, rootPath)
+	m.leadership = election.NewLocalLeadership(m.kv, m.GetLeaderPath(), "pd leader election")
+}
+
+// CheckLeader always returns no leader, since there is no other member to
+// be the PD leader.
+func (m *StandaloneMember) CheckLeader() (*pdpb.Member, int64, bool) {
+	return nil, 0, false
+}
+
+// CheckPriority does nothing, since there is no etcd leader.
+func (m *StandaloneMember) CheckPriority(ctx context.Context) {}
+
+// GetEtcdLeader returns the member itself, which plays the etcd leader.
+func (m *StandaloneMember) GetEtcdLeader() uint64 {
+	return m.id
+}
+
+// MoveEtcdLeader is not supported in the standalone mode.
+func (m *StandaloneMember) MoveEtcdLeader(ctx context.Context, old, new uint64) error {
+	return errs.ErrStandaloneMode.FastGenByArgs("moving etcd leader")
+}
+
+// ResignEtcdLeader is not supported in the standalone mode.
+func (m *StandaloneMember) ResignEtcdLeader(ctx context.Context, from string, nextEtcdLeader string) error {
+	return errs.ErrStandaloneMode.FastGenByArgs("resigning etcd leader")
+}
+
+// GetMembers returns the member itself.
+func (m *StandaloneMember) GetMembers() ([]*pdpb.Member, error) {
+	return []*pdpb.Member{proto.Clone(m.member).(*pdpb.Member)}, nil
+}
+
+// RemoveMember is not supported in the standalone mode.
+func (m *StandaloneMember) RemoveMember(id uint64) error {
+	return errs.ErrStandaloneMode.FastGenByArgs("removing member")
+}
+
+// Close does nothing, the kv is closed by its owner.
+func (m *StandaloneMember) Close() {}
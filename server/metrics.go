@@ -66,6 +66,15 @@ var (
 			Buckets:   prometheus.ExponentialBuckets(0.0005, 2, 13),
 		})
 
+	tsoMergedRequests = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Namespace: "pd",
+			Subsystem: "server",
+			Name:      "handle_tso_merged_requests",
+			Help:      "Bucketed histogram of the number of tso requests merged into an allocation.",
+			Buckets:   prometheus.ExponentialBuckets(1, 2, 13),
+		})
+
 	regionHeartbeatHandleDuration = prometheus.NewHistogramVec(
 		prometheus.HistogramOpts{
 			Namespace: "pd",
@@ -83,6 +92,14 @@ var (
 			Help:      "Bucketed histogram of processing time (s) of handled store heartbeat requests.",
 			Buckets:   prometheus.ExponentialBuckets(1, 2, 12),
 		}, []string{"address", "store"})
+
+	forwardedRequestCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "pd",
+			Subsystem: "server",
+			Name:      "forwarded_requests_total",
+			Help:      "Counter of the requests forwarded to the leader.",
+		}, []string{"type"})
 )
 
 func init() {
@@ -92,6 +109,8 @@ func init() {
 	prometheus.MustRegister(metadataGauge)
 	prometheus.MustRegister(etcdStateGauge)
 	prometheus.MustRegister(tsoHandleDuration)
+	prometheus.MustRegister(tsoMergedRequests)
 	prometheus.MustRegister(regionHeartbeatHandleDuration)
 	prometheus.MustRegister(storeHeartbeatHandleDuration)
+	prometheus.MustRegister(forwardedRequestCounter)
 }
@@ -15,9 +15,11 @@ package syncer
 
 import (
 	"context"
+	"sync/atomic"
 	"time"
 
 	"github.com/pingcap/errors"
+	"github.com/pingcap/failpoint"
 	"github.com/pingcap/kvproto/pkg/metapb"
 	"github.com/pingcap/kvproto/pkg/pdpb"
 	"github.com/pingcap/log"
@@ -47,6 +49,24 @@ func (s *RegionSyncer) StopSyncWithLeader() {
 	s.wg.Wait()
 }
 
+// IsRunning returns true if the regions are being synchronized from the
+// leader.
+func (s *RegionSyncer) IsRunning() bool {
+	return atomic.LoadInt32(&s.streamingRunning) == 1
+}
+
+// GetSyncStaleness returns how long it has been since the follower last
+// received the regions or a keepalive from the leader.
+func (s *RegionSyncer) GetSyncStaleness() time.Duration {
+	return time.Since(time.Unix(0, atomic.LoadInt64(&s.lastSyncTime)))
+}
+
+// GetNextIndex returns the index of the next region to be recorded in the
+// history buffer. On a follower, it is the index synced from the leader.
+func (s *RegionSyncer) GetNextIndex() uint64 {
+	return s.history.GetNextIndex()
+}
+
 func (s *RegionSyncer) reset() {
 	s.Lock()
 	defer s.Unlock()
@@ -124,6 +144,7 @@ func (s *RegionSyncer) StartSyncWithLeader(addr string) {
 	s.RUnlock()
 	go func() {
 		defer s.wg.Done()
+		defer atomic.StoreInt32(&s.streamingRunning, 0)
 		// used to load region from kv storage to cache storage.
 		err := s.server.GetStorage().LoadRegionsOnce(s.server.GetBasicCluster().CheckAndPutRegion)
 		if err != nil {
@@ -154,6 +175,20 @@ func (s *RegionSyncer) StartSyncWithLeader(addr string) {
 			default:
 			}
 
+			if err := s.syncSnapshot(conn); err != nil {
+				switch status.Code(errors.Cause(err)) {
+				case codes.Canceled:
+					return
+				case codes.Unimplemented:
+					// The leader without the snapshot service syncs all
+					// regions in the region sync stream instead.
+				default:
+					log.Error("server failed to sync the region snapshot with leader", zap.String("server", s.server.Name()), zap.String("leader", s.server.GetLeader().GetName()), errs.ZapError(err))
+					time.Sleep(time.Second)
+					continue
+				}
+			}
+
 			stream, err := s.syncRegion(conn)
 			if err != nil {
 				if ev, ok := status.FromError(err); ok {
@@ -166,9 +201,12 @@ func (s *RegionSyncer) StartSyncWithLeader(addr string) {
 				continue
 			}
 			log.Info("server starts to synchronize with leader", zap.String("server", s.server.Name()), zap.String("leader", s.server.GetLeader().GetName()), zap.Uint64("request-index", s.history.GetNextIndex()))
+			atomic.StoreInt64(&s.lastSyncTime, time.Now().UnixNano())
+			atomic.StoreInt32(&s.streamingRunning, 1)
 			for {
 				resp, err := stream.Recv()
 				if err != nil {
+					atomic.StoreInt32(&s.streamingRunning, 0)
 					log.Error("region sync with leader meet error", errs.ZapError(errs.ErrGRPCRecv, err))
 					if err = stream.CloseSend(); err != nil {
 						log.Error("failed to terminate client stream", errs.ZapError(errs.ErrGRPCCloseSend, err))
@@ -176,6 +214,10 @@ func (s *RegionSyncer) StartSyncWithLeader(addr string) {
 					time.Sleep(time.Second)
 					break
 				}
+				failpoint.Inject("dropSyncRegionResponse", func() {
+					failpoint.Continue()
+				})
+				atomic.StoreInt64(&s.lastSyncTime, time.Now().UnixNano())
 				if s.history.GetNextIndex() != resp.GetStartIndex() {
 					log.Warn("server sync index not match the leader",
 						zap.String("server", s.server.Name()),
@@ -185,31 +227,9 @@ func (s *RegionSyncer) StartSyncWithLeader(addr string) {
 					// reset index
 					s.history.ResetWithIndex(resp.GetStartIndex())
 				}
-				stats := resp.GetRegionStats()
-				regions := resp.GetRegions()
-				regionLeaders := resp.GetRegionLeaders()
-				hasStats := len(stats) == len(regions)
-				for i, r := range regions {
-					var (
-						region       *core.RegionInfo
-						regionLeader *metapb.Peer
-					)
-					if len(regionLeaders) > i && regionLeaders[i].Id != 0 {
-						regionLeader = regionLeaders[i]
-					}
-					if hasStats {
-						region = core.NewRegionInfo(r, regionLeader,
-							core.SetWrittenBytes(stats[i].BytesWritten),
-							core.SetWrittenKeys(stats[i].KeysWritten),
-							core.SetReadBytes(stats[i].BytesRead),
-							core.SetReadKeys(stats[i].KeysRead),
-						)
-					} else {
-						region = core.NewRegionInfo(r, regionLeader)
-					}
-
+				for _, region := range buildRegions(resp) {
 					s.server.GetBasicCluster().CheckAndPutRegion(region)
-					err = s.server.GetStorage().SaveRegion(r)
+					err = s.server.GetStorage().SaveRegion(region.GetMeta())
 					if err == nil {
 						s.history.Record(region)
 					}
@@ -218,3 +238,103 @@ func (s *RegionSyncer) StartSyncWithLeader(addr string) {
 		}
 	}()
 }
+
+// buildRegions builds the regions in the response of the leader.
+func buildRegions(resp *pdpb.SyncRegionResponse) []*core.RegionInfo {
+	stats := resp.GetRegionStats()
+	metas := resp.GetRegions()
+	regionLeaders := resp.GetRegionLeaders()
+	hasStats := len(stats) == len(metas)
+	regions := make([]*core.RegionInfo, 0, len(metas))
+	for i, r := range metas {
+		var regionLeader *metapb.Peer
+		if len(regionLeaders) > i && regionLeaders[i].Id != 0 {
+			regionLeader = regionLeaders[i]
+		}
+		if hasStats {
+			regions = append(regions, core.NewRegionInfo(r, regionLeader,
+				core.SetWrittenBytes(stats[i].BytesWritten),
+				core.SetWrittenKeys(stats[i].KeysWritten),
+				core.SetReadBytes(stats[i].BytesRead),
+				core.SetReadKeys(stats[i].KeysRead),
+			))
+		} else {
+			regions = append(regions, core.NewRegionInfo(r, regionLeader))
+		}
+	}
+	return regions
+}
+
+// snapshotProgress is the progress of receiving a region snapshot.
+type snapshotProgress struct {
+	id   uint64
+	next uint64
+}
+
+// syncSnapshot receives and applies the region snapshot from the leader if
+// the index of the follower is out of the history window of the leader. The
+// chunks applied are kept in the progress, so that the snapshot is resumed
+// from the next chunk after the stream breaks.
+func (s *RegionSyncer) syncSnapshot(conn *grpc.ClientConn) error {
+	stream, err := requestSnapshot(s.regionSyncerCtx, conn, &SnapshotRequest{
+		ClusterID:  s.server.ClusterID(),
+		Name:       s.server.Name(),
+		StartIndex: s.history.GetNextIndex(),
+		SnapshotID: s.receiving.id,
+		NextChunk:  s.receiving.next,
+	})
+	if err != nil {
+		return errs.ErrGRPCCreateStream.Wrap(err).FastGenWithCause()
+	}
+	start := time.Now()
+	for {
+		chunk, err := stream.Recv()
+		if err != nil {
+			snapshotCounter.WithLabelValues("receive_failed").Inc()
+			return errs.ErrGRPCRecv.Wrap(err).FastGenWithCause()
+		}
+		if chunk.Skip {
+			s.receiving = snapshotProgress{}
+			return nil
+		}
+		if chunk.SnapshotID != s.receiving.id {
+			// The leader sends a new snapshot from the first chunk.
+			s.receiving = snapshotProgress{id: chunk.SnapshotID}
+		}
+		if expected := s.receiving.next; chunk.Chunk != expected {
+			s.receiving = snapshotProgress{}
+			return errors.Errorf("unexpected snapshot chunk %d, expect %d", chunk.Chunk, expected)
+		}
+		snapshotBytes.WithLabelValues("receive").Add(float64(len(chunk.Data)))
+		if err := s.applySnapshotChunk(chunk); err != nil {
+			return err
+		}
+		s.receiving.next++
+		if s.receiving.next >= chunk.Chunks {
+			s.receiving = snapshotProgress{}
+			s.history.ResetWithIndex(chunk.Index)
+			snapshotCounter.WithLabelValues("apply").Inc()
+			snapshotDuration.WithLabelValues("apply").Observe(time.Since(start).Seconds())
+			log.Info("server has applied the region snapshot from leader",
+				zap.String("server", s.server.Name()),
+				zap.Uint64("snapshot-index", chunk.Index),
+				zap.Uint64("chunks", chunk.Chunks),
+				zap.Duration("cost", time.Since(start)))
+			return nil
+		}
+	}
+}
+
+func (s *RegionSyncer) applySnapshotChunk(chunk *SnapshotChunk) error {
+	resp, err := decodeSnapshotChunk(chunk.Data)
+	if err != nil {
+		return err
+	}
+	for _, region := range buildRegions(resp) {
+		s.server.GetBasicCluster().CheckAndPutRegion(region)
+		if err := s.server.GetStorage().SaveRegion(region.GetMeta()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -15,14 +15,44 @@ package syncer
 
 import "github.com/prometheus/client_golang/prometheus"
 
-var regionSyncerStatus = prometheus.NewGaugeVec(
-	prometheus.GaugeOpts{
-		Namespace: "pd",
-		Subsystem: "region_syncer",
-		Name:      "status",
-		Help:      "Inner status of the region syncer.",
-	}, []string{"type"})
+var (
+	regionSyncerStatus = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "pd",
+			Subsystem: "region_syncer",
+			Name:      "status",
+			Help:      "Inner status of the region syncer.",
+		}, []string{"type"})
+
+	snapshotCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "pd",
+			Subsystem: "region_syncer",
+			Name:      "snapshot_events",
+			Help:      "Counter of the region snapshot events.",
+		}, []string{"type"})
+
+	snapshotBytes = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "pd",
+			Subsystem: "region_syncer",
+			Name:      "snapshot_bytes",
+			Help:      "Bytes of the compressed region snapshot chunks.",
+		}, []string{"type"})
+
+	snapshotDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "pd",
+			Subsystem: "region_syncer",
+			Name:      "snapshot_duration_seconds",
+			Help:      "Bucketed histogram of the duration of generating and applying a region snapshot.",
+			Buckets:   prometheus.ExponentialBuckets(0.01, 2, 16),
+		}, []string{"type"})
+)
 
 func init() {
 	prometheus.MustRegister(regionSyncerStatus)
+	prometheus.MustRegister(snapshotCounter)
+	prometheus.MustRegister(snapshotBytes)
+	prometheus.MustRegister(snapshotDuration)
 }
@@ -77,6 +77,20 @@ type RegionSyncer struct {
 	history            *historyBuffer
 	limit              *ratelimit.Bucket
 	tlsConfig          *grpcutil.TLSConfig
+	// streamingRunning is 1 if the follower is receiving regions from the leader.
+	streamingRunning int32
+	// lastSyncTime is the unix nano time when the follower last received the
+	// regions or a keepalive from the leader.
+	lastSyncTime int64
+
+	// snapshot is the last region snapshot generated by the leader.
+	snapshotMu sync.Mutex
+	snapshot   *regionSnapshot
+	// receiving is the region snapshot being received by the follower.
+	receiving snapshotProgress
+	// changed is closed when new regions are recorded for the watchers.
+	watchMu sync.Mutex
+	changed chan struct{}
 }
 
 // NewRegionSyncer returns a region syncer.
@@ -129,7 +143,9 @@ func (s *RegionSyncer) RunServer(regionNotifier <-chan *core.RegionInfo, quit ch
 				RegionLeaders: leaders,
 			}
 			s.broadcast(regions)
+			s.notifyWatchers()
 		case <-ticker.C:
+			s.dropExpiredSnapshot()
 			alive := &pdpb.SyncRegionResponse{
 				Header:     &pdpb.ResponseHeader{ClusterId: s.server.ClusterID()},
 				StartIndex: s.history.GetNextIndex(),
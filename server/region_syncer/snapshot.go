@@ -0,0 +1,348 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package syncer
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io/ioutil"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/pingcap/errors"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/kvproto/pkg/pdpb"
+	"github.com/pingcap/log"
+	"github.com/tikv/pd/server/core"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// snapshotChunkSize is the max number of regions in a snapshot chunk.
+	snapshotChunkSize = 4096
+	// snapshotTTL is how long a snapshot is kept for the followers to reuse
+	// or resume it.
+	snapshotTTL = 5 * time.Minute
+)
+
+// The region snapshot is served by the snapshot service on the same gRPC
+// server as the PD service:
+//
+//	service RegionSyncer {
+//	    rpc Snapshot(SnapshotRequest) returns (stream SnapshotChunk) {}
+//	}
+//
+// The messages are not generated from proto files as the service is not in
+// kvproto yet. They are defined with the protobuf tags of the fields like the
+// watch service, so they are encoded in the protobuf wire format by the proto
+// codec of gRPC, and can be replaced by the generated ones later without
+// changing the wire format.
+
+// SnapshotRequest requests a snapshot of the regions from the leader.
+//
+//	message SnapshotRequest {
+//	    uint64 cluster_id = 1;
+//	    string name = 2;
+//	    uint64 start_index = 3;
+//	    uint64 snapshot_id = 4;
+//	    uint64 next_chunk = 5;
+//	}
+type SnapshotRequest struct {
+	ClusterID uint64 `protobuf:"varint,1,opt,name=cluster_id,json=clusterId,proto3" json:"cluster_id,omitempty"`
+	Name      string `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	// StartIndex is the next history index of the follower. The leader sends
+	// a snapshot only if the index is out of the history window.
+	StartIndex uint64 `protobuf:"varint,3,opt,name=start_index,json=startIndex,proto3" json:"start_index,omitempty"`
+	// SnapshotID and NextChunk resume the snapshot received partially.
+	SnapshotID uint64 `protobuf:"varint,4,opt,name=snapshot_id,json=snapshotId,proto3" json:"snapshot_id,omitempty"`
+	NextChunk  uint64 `protobuf:"varint,5,opt,name=next_chunk,json=nextChunk,proto3" json:"next_chunk,omitempty"`
+}
+
+// Reset implements proto.Message.
+func (m *SnapshotRequest) Reset() { *m = SnapshotRequest{} }
+
+// String implements proto.Message.
+func (m *SnapshotRequest) String() string { return proto.CompactTextString(m) }
+
+// ProtoMessage implements proto.Message.
+func (*SnapshotRequest) ProtoMessage() {}
+
+// SnapshotChunk is a chunk of a snapshot of the regions.
+//
+//	message SnapshotChunk {
+//	    bool skip = 1;
+//	    uint64 snapshot_id = 2;
+//	    uint64 index = 3;
+//	    uint64 chunk = 4;
+//	    uint64 chunks = 5;
+//	    bytes data = 6;
+//	}
+type SnapshotChunk struct {
+	// Skip is true if the follower can sync from the history directly.
+	Skip       bool   `protobuf:"varint,1,opt,name=skip,proto3" json:"skip,omitempty"`
+	SnapshotID uint64 `protobuf:"varint,2,opt,name=snapshot_id,json=snapshotId,proto3" json:"snapshot_id,omitempty"`
+	// Index is the history index when the snapshot is taken, the follower
+	// syncs the history from it after applying the snapshot.
+	Index  uint64 `protobuf:"varint,3,opt,name=index,proto3" json:"index,omitempty"`
+	Chunk  uint64 `protobuf:"varint,4,opt,name=chunk,proto3" json:"chunk,omitempty"`
+	Chunks uint64 `protobuf:"varint,5,opt,name=chunks,proto3" json:"chunks,omitempty"`
+	// Data is a gzip compressed SyncRegionResponse of the regions.
+	Data []byte `protobuf:"bytes,6,opt,name=data,proto3" json:"data,omitempty"`
+}
+
+// Reset implements proto.Message.
+func (m *SnapshotChunk) Reset() { *m = SnapshotChunk{} }
+
+// String implements proto.Message.
+func (m *SnapshotChunk) String() string { return proto.CompactTextString(m) }
+
+// ProtoMessage implements proto.Message.
+func (*SnapshotChunk) ProtoMessage() {}
+
+// SnapshotService is the server API of the snapshot service.
+type SnapshotService interface {
+	RegionSnapshot(*SnapshotRequest, SnapshotServer) error
+}
+
+// SnapshotServer is the server side of the snapshot stream.
+type SnapshotServer interface {
+	Send(*SnapshotChunk) error
+	grpc.ServerStream
+}
+
+type snapshotServer struct {
+	grpc.ServerStream
+}
+
+func (s *snapshotServer) Send(m *SnapshotChunk) error {
+	return s.ServerStream.SendMsg(m)
+}
+
+func snapshotHandler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SnapshotRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(SnapshotService).RegionSnapshot(m, &snapshotServer{stream})
+}
+
+var snapshotServiceDesc = grpc.ServiceDesc{
+	ServiceName: "pd.syncer.RegionSyncer",
+	HandlerType: (*SnapshotService)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Snapshot",
+			Handler:       snapshotHandler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "syncer",
+}
+
+// RegisterSnapshotService registers the snapshot service to the gRPC server.
+func RegisterSnapshotService(s *grpc.Server, srv SnapshotService) {
+	s.RegisterService(&snapshotServiceDesc, srv)
+}
+
+type snapshotClient struct {
+	grpc.ClientStream
+}
+
+func (c *snapshotClient) Recv() (*SnapshotChunk, error) {
+	m := new(SnapshotChunk)
+	if err := c.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func requestSnapshot(ctx context.Context, cc *grpc.ClientConn, req *SnapshotRequest) (*snapshotClient, error) {
+	stream, err := cc.NewStream(ctx, &snapshotServiceDesc.Streams[0], "/pd.syncer.RegionSyncer/Snapshot")
+	if err != nil {
+		return nil, err
+	}
+	x := &snapshotClient{stream}
+	if err := x.ClientStream.SendMsg(req); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// regionSnapshot is the compressed chunks of the regions at a history index.
+type regionSnapshot struct {
+	id      uint64
+	index   uint64
+	chunks  [][]byte
+	created time.Time
+}
+
+// RegionSnapshot sends a snapshot of the regions to the follower whose index
+// is out of the history window, which is much faster than sending the regions
+// one batch after another. A snapshot is kept for a while, so that other
+// followers can reuse it and a broken transfer can be resumed.
+func (s *RegionSyncer) RegionSnapshot(request *SnapshotRequest, stream SnapshotServer) error {
+	if request.ClusterID != s.server.ClusterID() {
+		return status.Errorf(codes.FailedPrecondition, "mismatch cluster id, need %d but got %d", s.server.ClusterID(), request.ClusterID)
+	}
+	if !s.needSnapshot(request.StartIndex) {
+		snapshotCounter.WithLabelValues("skip").Inc()
+		return stream.Send(&SnapshotChunk{Skip: true})
+	}
+	snapshot, err := s.getSnapshot()
+	if err != nil {
+		return err
+	}
+	var next uint64
+	if snapshot.id == request.SnapshotID {
+		next = request.NextChunk
+		snapshotCounter.WithLabelValues("resume").Inc()
+	}
+	chunks := uint64(len(snapshot.chunks))
+	log.Info("send the region snapshot",
+		zap.String("requested-server", request.Name),
+		zap.Uint64("request-index", request.StartIndex),
+		zap.Uint64("snapshot-index", snapshot.index),
+		zap.Uint64("from-chunk", next),
+		zap.Uint64("chunks", chunks))
+	for i := next; i < chunks; i++ {
+		data := snapshot.chunks[i]
+		// The snapshot shares the bandwidth limit with the full synchronization.
+		s.limit.Wait(int64(len(data)))
+		if err := stream.Send(&SnapshotChunk{
+			SnapshotID: snapshot.id,
+			Index:      snapshot.index,
+			Chunk:      i,
+			Chunks:     chunks,
+			Data:       data,
+		}); err != nil {
+			snapshotCounter.WithLabelValues("send_failed").Inc()
+			return errors.WithStack(err)
+		}
+		snapshotBytes.WithLabelValues("send").Add(float64(len(data)))
+	}
+	snapshotCounter.WithLabelValues("sent").Inc()
+	return nil
+}
+
+// needSnapshot returns true if the history regions from the index are not
+// kept.
+func (s *RegionSyncer) needSnapshot(index uint64) bool {
+	s.history.RLock()
+	defer s.history.RUnlock()
+	return index < s.history.firstIndex() || index > s.history.nextIndex()
+}
+
+// getSnapshot returns the kept snapshot if it is not expired and the history
+// from its index is still kept, otherwise it generates a new one.
+func (s *RegionSyncer) getSnapshot() (*regionSnapshot, error) {
+	s.snapshotMu.Lock()
+	defer s.snapshotMu.Unlock()
+	if snapshot := s.snapshot; snapshot != nil && time.Since(snapshot.created) < snapshotTTL && !s.needSnapshot(snapshot.index) {
+		snapshotCounter.WithLabelValues("reuse").Inc()
+		return snapshot, nil
+	}
+	s.snapshot = nil
+
+	start := time.Now()
+	// Take the index before getting the regions, the changes after the index
+	// are synced from the history after applying the snapshot.
+	index := s.history.GetNextIndex()
+	regions := s.server.GetRegions()
+	chunks := make([][]byte, 0, len(regions)/snapshotChunkSize+1)
+	for len(regions) > 0 || len(chunks) == 0 {
+		n := len(regions)
+		if n > snapshotChunkSize {
+			n = snapshotChunkSize
+		}
+		data, err := encodeSnapshotChunk(regions[:n])
+		if err != nil {
+			return nil, err
+		}
+		chunks = append(chunks, data)
+		regions = regions[n:]
+	}
+	s.snapshot = &regionSnapshot{
+		id:      uint64(start.UnixNano()),
+		index:   index,
+		chunks:  chunks,
+		created: start,
+	}
+	snapshotCounter.WithLabelValues("generate").Inc()
+	snapshotDuration.WithLabelValues("generate").Observe(time.Since(start).Seconds())
+	return s.snapshot, nil
+}
+
+// dropExpiredSnapshot releases the kept snapshot if it is expired.
+func (s *RegionSyncer) dropExpiredSnapshot() {
+	s.snapshotMu.Lock()
+	defer s.snapshotMu.Unlock()
+	if s.snapshot != nil && time.Since(s.snapshot.created) >= snapshotTTL {
+		s.snapshot = nil
+	}
+}
+
+func encodeSnapshotChunk(regions []*core.RegionInfo) ([]byte, error) {
+	resp := &pdpb.SyncRegionResponse{
+		Regions:       make([]*metapb.Region, 0, len(regions)),
+		RegionStats:   make([]*pdpb.RegionStat, 0, len(regions)),
+		RegionLeaders: make([]*metapb.Peer, 0, len(regions)),
+	}
+	for _, r := range regions {
+		resp.Regions = append(resp.Regions, r.GetMeta())
+		resp.RegionStats = append(resp.RegionStats, r.GetStat())
+		leader := &metapb.Peer{}
+		if r.GetLeader() != nil {
+			leader = r.GetLeader()
+		}
+		resp.RegionLeaders = append(resp.RegionLeaders, leader)
+	}
+	data, err := resp.Marshal()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(data); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if err := w.Close(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return buf.Bytes(), nil
+}
+
+func decodeSnapshotChunk(data []byte) (*pdpb.SyncRegionResponse, error) {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer r.Close()
+	data, err = ioutil.ReadAll(r)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	resp := &pdpb.SyncRegionResponse{}
+	if err := resp.Unmarshal(data); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return resp, nil
+}
@@ -0,0 +1,175 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package syncer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/juju/ratelimit"
+	. "github.com/pingcap/check"
+	"github.com/pingcap/errors"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/kvproto/pkg/pdpb"
+	"github.com/tikv/pd/pkg/grpcutil"
+	"github.com/tikv/pd/server/core"
+	"github.com/tikv/pd/server/kv"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/encoding"
+	_ "google.golang.org/grpc/encoding/proto"
+)
+
+var _ = Suite(&testSnapshotSuite{})
+
+type testSnapshotSuite struct{}
+
+type mockServer struct {
+	regions      []*core.RegionInfo
+	storage      *core.Storage
+	basicCluster *core.BasicCluster
+}
+
+func (s *mockServer) LoopContext() context.Context        { return context.Background() }
+func (s *mockServer) ClusterID() uint64                   { return 1 }
+func (s *mockServer) GetMemberInfo() *pdpb.Member         { return nil }
+func (s *mockServer) GetLeader() *pdpb.Member             { return nil }
+func (s *mockServer) GetStorage() *core.Storage           { return s.storage }
+func (s *mockServer) Name() string                        { return "mock" }
+func (s *mockServer) GetRegions() []*core.RegionInfo      { return s.regions }
+func (s *mockServer) GetTLSConfig() *grpcutil.TLSConfig   { return &grpcutil.TLSConfig{} }
+func (s *mockServer) GetBasicCluster() *core.BasicCluster { return s.basicCluster }
+
+// mockSnapshotStream receives the chunks and fails after sending the limit.
+type mockSnapshotStream struct {
+	grpc.ServerStream
+	chunks []*SnapshotChunk
+	limit  int
+}
+
+func (s *mockSnapshotStream) Send(chunk *SnapshotChunk) error {
+	if len(s.chunks) >= s.limit {
+		return errors.New("stream is broken")
+	}
+	s.chunks = append(s.chunks, chunk)
+	return nil
+}
+
+func newTestSyncer(n int) *RegionSyncer {
+	s := &mockServer{
+		storage:      core.NewStorage(kv.NewMemoryKV()),
+		basicCluster: core.NewBasicCluster(),
+	}
+	for i := 0; i < n; i++ {
+		leader := &metapb.Peer{Id: uint64(i) + 1, StoreId: 1}
+		meta := &metapb.Region{
+			Id:       uint64(i) + 1,
+			StartKey: []byte(fmt.Sprintf("%08d", i)),
+			EndKey:   []byte(fmt.Sprintf("%08d", i+1)),
+			Peers:    []*metapb.Peer{leader},
+		}
+		s.regions = append(s.regions, core.NewRegionInfo(meta, leader, core.SetWrittenBytes(uint64(i))))
+	}
+	return &RegionSyncer{
+		server:  s,
+		history: newHistoryBuffer(10, kv.NewMemoryKV()),
+		limit:   ratelimit.NewBucketWithRate(defaultBucketRate, defaultBucketCapacity),
+	}
+}
+
+func (t *testSnapshotSuite) TestEncodeChunk(c *C) {
+	syncer := newTestSyncer(10)
+	regions := syncer.server.GetRegions()
+	data, err := encodeSnapshotChunk(regions)
+	c.Assert(err, IsNil)
+	resp, err := decodeSnapshotChunk(data)
+	c.Assert(err, IsNil)
+	decoded := buildRegions(resp)
+	c.Assert(decoded, HasLen, len(regions))
+	for i, r := range decoded {
+		c.Assert(r.GetMeta(), DeepEquals, regions[i].GetMeta())
+		c.Assert(r.GetLeader(), DeepEquals, regions[i].GetLeader())
+		c.Assert(r.GetBytesWritten(), Equals, regions[i].GetBytesWritten())
+	}
+}
+
+func (t *testSnapshotSuite) TestCodec(c *C) {
+	codec := encoding.GetCodec("proto")
+	// The messages are encoded in the protobuf wire format.
+	data, err := codec.Marshal(&SnapshotRequest{ClusterID: 1, NextChunk: 2})
+	c.Assert(err, IsNil)
+	c.Assert(data, DeepEquals, []byte{0x08, 0x01, 0x28, 0x02})
+
+	chunk, err := encodeSnapshotChunk(newTestSyncer(10).server.GetRegions())
+	c.Assert(err, IsNil)
+	m := &SnapshotChunk{SnapshotID: 1, Index: 20, Chunk: 1, Chunks: 2, Data: chunk}
+	data, err = codec.Marshal(m)
+	c.Assert(err, IsNil)
+	// The chunk data is carried as raw bytes.
+	c.Assert(bytes.HasSuffix(data, chunk), IsTrue)
+	var got SnapshotChunk
+	c.Assert(codec.Unmarshal(data, &got), IsNil)
+	c.Assert(&got, DeepEquals, m)
+}
+
+func (t *testSnapshotSuite) TestSnapshot(c *C) {
+	leader := newTestSyncer(snapshotChunkSize + 10)
+	for _, r := range leader.server.GetRegions()[:20] {
+		leader.history.Record(r)
+	}
+
+	// The history from the index is kept.
+	stream := &mockSnapshotStream{limit: 10}
+	c.Assert(leader.RegionSnapshot(&SnapshotRequest{ClusterID: 1, StartIndex: 15}, stream), IsNil)
+	c.Assert(stream.chunks, HasLen, 1)
+	c.Assert(stream.chunks[0].Skip, IsTrue)
+
+	// The stream breaks after the first chunk.
+	stream = &mockSnapshotStream{limit: 1}
+	c.Assert(leader.RegionSnapshot(&SnapshotRequest{ClusterID: 1, StartIndex: 5}, stream), NotNil)
+	c.Assert(stream.chunks, HasLen, 1)
+	first := stream.chunks[0]
+	c.Assert(first.Chunk, Equals, uint64(0))
+	c.Assert(first.Chunks, Equals, uint64(2))
+	c.Assert(first.Index, Equals, uint64(20))
+
+	// Resume the snapshot from the second chunk.
+	stream = &mockSnapshotStream{limit: 10}
+	c.Assert(leader.RegionSnapshot(&SnapshotRequest{ClusterID: 1, StartIndex: 5, SnapshotID: first.SnapshotID, NextChunk: 1}, stream), IsNil)
+	c.Assert(stream.chunks, HasLen, 1)
+	second := stream.chunks[0]
+	c.Assert(second.SnapshotID, Equals, first.SnapshotID)
+	c.Assert(second.Chunk, Equals, uint64(1))
+
+	// The follower applies the chunks.
+	follower := newTestSyncer(0)
+	for _, chunk := range []*SnapshotChunk{first, second} {
+		c.Assert(follower.applySnapshotChunk(chunk), IsNil)
+	}
+	c.Assert(follower.server.GetBasicCluster().GetRegionCount(), Equals, snapshotChunkSize+10)
+	var region metapb.Region
+	ok, err := follower.server.GetStorage().LoadRegion(1, &region)
+	c.Assert(err, IsNil)
+	c.Assert(ok, IsTrue)
+
+	// The snapshot is regenerated after the history from its index is dropped.
+	for _, r := range leader.server.GetRegions()[:20] {
+		leader.history.Record(r)
+	}
+	stream = &mockSnapshotStream{limit: 10}
+	c.Assert(leader.RegionSnapshot(&SnapshotRequest{ClusterID: 1, StartIndex: 5, SnapshotID: first.SnapshotID, NextChunk: 1}, stream), IsNil)
+	c.Assert(stream.chunks, HasLen, 2)
+	c.Assert(stream.chunks[0].Chunk, Equals, uint64(0))
+	c.Assert(stream.chunks[0].Index, Equals, uint64(40))
+}
@@ -0,0 +1,90 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package syncer
+
+import (
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/tikv/pd/pkg/watch"
+)
+
+// maxWatchRegionRecords is the max number of the history records checked for
+// a response of a region watcher.
+const maxWatchRegionRecords = 1024
+
+// notifyWatchers wakes up the region watchers after new regions are recorded.
+func (s *RegionSyncer) notifyWatchers() {
+	s.watchMu.Lock()
+	defer s.watchMu.Unlock()
+	if s.changed != nil {
+		close(s.changed)
+		s.changed = nil
+	}
+}
+
+// watchChanged returns a channel closed once new regions are recorded.
+func (s *RegionSyncer) watchChanged() <-chan struct{} {
+	s.watchMu.Lock()
+	defer s.watchMu.Unlock()
+	if s.changed == nil {
+		s.changed = make(chan struct{})
+	}
+	return s.changed
+}
+
+// GetRegionEvents returns the changes of the regions overlapping with the key
+// ranges from the index of the history, and a channel closed once there are
+// newer changes. The response is nil if there is no new change. It requires
+// a reset if the history from the index is no longer kept.
+func (s *RegionSyncer) GetRegionEvents(index uint64, ranges []*watch.KeyRange) (*watch.WatchRegionsResponse, <-chan struct{}) {
+	// Get the channel before reading the history, so that no change is missed.
+	changed := s.watchChanged()
+	s.history.RLock()
+	defer s.history.RUnlock()
+	next := s.history.nextIndex()
+	if index < s.history.firstIndex() || index > next {
+		return &watch.WatchRegionsResponse{ResetRequired: true, NextIndex: next}, changed
+	}
+	if index == next {
+		return nil, changed
+	}
+	end := next
+	if end-index > maxWatchRegionRecords {
+		end = index + maxWatchRegionRecords
+	}
+	resp := &watch.WatchRegionsResponse{NextIndex: end}
+	for i := index; i < end; i++ {
+		region := s.history.get(i)
+		if !inKeyRanges(region.GetMeta(), ranges) {
+			continue
+		}
+		resp.Events = append(resp.Events, &watch.RegionEvent{
+			Index:  i,
+			Region: region.GetMeta(),
+			Leader: region.GetLeader(),
+		})
+	}
+	return resp, changed
+}
+
+func inKeyRanges(region *metapb.Region, ranges []*watch.KeyRange) bool {
+	if len(ranges) == 0 {
+		return true
+	}
+	for _, r := range ranges {
+		if r.Overlaps(region) {
+			return true
+		}
+	}
+	return false
+}
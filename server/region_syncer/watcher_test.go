@@ -0,0 +1,75 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package syncer
+
+import (
+	. "github.com/pingcap/check"
+	"github.com/tikv/pd/pkg/watch"
+)
+
+var _ = Suite(&testWatcherSuite{})
+
+type testWatcherSuite struct{}
+
+func (t *testWatcherSuite) TestGetRegionEvents(c *C) {
+	syncer := newTestSyncer(20)
+	// The regions are [00000000, 00000001), [00000001, 00000002) and so on.
+	regions := syncer.server.GetRegions()
+	for _, r := range regions[:8] {
+		syncer.history.Record(r)
+	}
+	changed := syncer.watchChanged()
+
+	// All regions from the index.
+	resp, _ := syncer.GetRegionEvents(5, nil)
+	c.Assert(resp.ResetRequired, IsFalse)
+	c.Assert(resp.NextIndex, Equals, uint64(8))
+	c.Assert(resp.Events, HasLen, 3)
+	for i, e := range resp.Events {
+		c.Assert(e.Index, Equals, uint64(5+i))
+		c.Assert(e.Region, DeepEquals, regions[5+i].GetMeta())
+	}
+
+	// The regions overlapping with the key ranges.
+	ranges := []*watch.KeyRange{
+		{StartKey: []byte("00000000"), EndKey: []byte("00000001")},
+		{StartKey: []byte("000000055"), EndKey: []byte("000000065")},
+	}
+	resp, _ = syncer.GetRegionEvents(0, ranges)
+	c.Assert(resp.NextIndex, Equals, uint64(8))
+	c.Assert(resp.Events, HasLen, 3)
+	for i, id := range []uint64{1, 6, 7} {
+		c.Assert(resp.Events[i].Region.GetId(), Equals, id)
+	}
+
+	// No new changes.
+	resp, ch := syncer.GetRegionEvents(8, nil)
+	c.Assert(resp, IsNil)
+	c.Assert(ch, Equals, changed)
+
+	// The watchers are notified after the history is dropped.
+	for _, r := range regions[8:] {
+		syncer.history.Record(r)
+	}
+	syncer.notifyWatchers()
+	select {
+	case <-changed:
+	default:
+		c.Fatal("the watchers are not notified")
+	}
+	resp, _ = syncer.GetRegionEvents(5, nil)
+	c.Assert(resp.ResetRequired, IsTrue)
+	c.Assert(resp.NextIndex, Equals, uint64(20))
+	c.Assert(resp.Events, HasLen, 0)
+}
@@ -25,6 +25,7 @@ import (
 	"github.com/tikv/pd/pkg/logutil"
 	"github.com/tikv/pd/server/config"
 	"github.com/tikv/pd/server/core"
+	"github.com/tikv/pd/server/schedule/labeler"
 	"github.com/tikv/pd/server/schedule/operator"
 	"github.com/tikv/pd/server/schedule/opt"
 	"github.com/tikv/pd/server/schedule/placement"
@@ -52,6 +53,17 @@ func NewMergeChecker(ctx context.Context, cluster opt.Cluster) *MergeChecker {
 	}
 }
 
+// Fork creates a MergeChecker on another cluster, which shares the start time
+// and the recently split regions with m.
+func (m *MergeChecker) Fork(cluster opt.Cluster) *MergeChecker {
+	return &MergeChecker{
+		cluster:    cluster,
+		opts:       cluster.GetOpts(),
+		splitCache: m.splitCache,
+		startTime:  m.startTime,
+	}
+}
+
 // RecordRegionSplit put the recently split region into cache. MergeChecker
 // will skip check it for a while.
 func (m *MergeChecker) RecordRegionSplit(regionIDs []uint64) {
@@ -170,6 +182,16 @@ func AllowMerge(cluster opt.Cluster, region *core.RegionInfo, adjacent *core.Reg
 			return false
 		}
 	}
+	// The merged region should not cross the boundaries of the label rules,
+	// and the regions labeled with `merge_option=deny` should not be merged.
+	l := cluster.GetRegionLabeler()
+	if len(l.GetSplitKeys(start, end)) > 0 {
+		return false
+	}
+	if l.GetRegionLabel(region, labeler.MergeOptionKey) == labeler.MergeOptionValueDeny ||
+		l.GetRegionLabel(adjacent, labeler.MergeOptionKey) == labeler.MergeOptionValueDeny {
+		return false
+	}
 	policy := cluster.GetOpts().GetKeyType()
 	switch policy {
 	case core.Table:
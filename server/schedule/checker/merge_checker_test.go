@@ -25,6 +25,7 @@ import (
 	"github.com/tikv/pd/pkg/testutil"
 	"github.com/tikv/pd/server/config"
 	"github.com/tikv/pd/server/core"
+	"github.com/tikv/pd/server/schedule/labeler"
 	"github.com/tikv/pd/server/schedule/operator"
 	"github.com/tikv/pd/server/schedule/opt"
 	"github.com/tikv/pd/server/schedule/placement"
@@ -193,6 +194,31 @@ func (s *testMergeCheckerSuite) TestBasic(c *C) {
 	c.Assert(ops[1].RegionID(), Equals, s.regions[1].GetID())
 	s.cluster.RuleManager.DeleteRule("test", "test")
 
+	// merge cannot across region label rule key.
+	err := s.cluster.RegionLabeler.SetLabelRule(&labeler.LabelRule{
+		ID:          "test",
+		Labels:      []labeler.RegionLabel{{Key: "k1", Value: "v1"}},
+		StartKeyHex: hex.EncodeToString([]byte("x")),
+		EndKeyHex:   hex.EncodeToString([]byte("z")),
+	})
+	c.Assert(err, IsNil)
+	// region 2 can only merge with previous region now.
+	ops = s.mc.Check(s.regions[2])
+	c.Assert(ops, NotNil)
+	c.Assert(ops[0].RegionID(), Equals, s.regions[2].GetID())
+	c.Assert(ops[1].RegionID(), Equals, s.regions[1].GetID())
+	// regions labeled with merge_option=deny cannot be merged.
+	err = s.cluster.RegionLabeler.SetLabelRule(&labeler.LabelRule{
+		ID:          "test",
+		Labels:      []labeler.RegionLabel{{Key: labeler.MergeOptionKey, Value: labeler.MergeOptionValueDeny}},
+		StartKeyHex: hex.EncodeToString([]byte("a")),
+		EndKeyHex:   hex.EncodeToString([]byte("x")),
+	})
+	c.Assert(err, IsNil)
+	ops = s.mc.Check(s.regions[2])
+	c.Assert(ops, IsNil)
+	c.Assert(s.cluster.RegionLabeler.DeleteLabelRule("test"), IsNil)
+
 	// Skip recently split regions.
 	s.cluster.SetSplitMergeInterval(time.Hour)
 	s.mc.RecordRegionSplit([]uint64{s.regions[2].GetID()})
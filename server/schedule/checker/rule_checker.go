@@ -158,7 +158,7 @@ func (c *RuleChecker) fixLooseMatchPeer(region *core.RegionInfo, fit *placement.
 		checkerCounter.WithLabelValues("rule_checker", "not-allow-leader")
 		return nil, errors.New("peer cannot be leader")
 	}
-	if region.GetLeader().GetId() == peer.GetId() && rf.Rule.Role == placement.Follower {
+	if region.GetLeader().GetId() == peer.GetId() && (rf.Rule.Role == placement.Follower || rf.Rule.Role == placement.Witness) {
 		checkerCounter.WithLabelValues("rule_checker", "fix-follower-role").Inc()
 		for _, p := range region.GetPeers() {
 			if c.allowLeader(fit, p) {
@@ -175,6 +175,9 @@ func (c *RuleChecker) allowLeader(fit *placement.RegionFit, peer *metapb.Peer) b
 	if core.IsLearner(peer) {
 		return false
 	}
+	if rf := fit.GetRuleFit(peer.GetId()); rf != nil && rf.Rule.Role == placement.Witness {
+		return false
+	}
 	s := c.cluster.GetStore(peer.GetStoreId())
 	if s == nil {
 		return false
@@ -217,6 +217,50 @@ func (s *testRuleCheckerSuite) TestFixRoleLeader(c *C) {
 	c.Assert(op.Step(0).(operator.TransferLeader).ToStore, Equals, uint64(3))
 }
 
+func (s *testRuleCheckerSuite) TestWitness(c *C) {
+	s.cluster.AddLabelsStore(1, 1, map[string]string{"dc": "dc1"})
+	s.cluster.AddLabelsStore(2, 1, map[string]string{"dc": "dc2"})
+	s.cluster.AddLabelsStore(3, 1, map[string]string{"dc": "dc3"})
+	s.ruleManager.SetRule(&placement.Rule{
+		GroupID:  "pd",
+		ID:       "default",
+		Override: true,
+		Role:     placement.Voter,
+		Count:    2,
+		LabelConstraints: []placement.LabelConstraint{
+			{Key: "dc", Op: "in", Values: []string{"dc1", "dc2"}},
+		},
+	})
+	s.ruleManager.SetRule(&placement.Rule{
+		GroupID: "pd",
+		ID:      "witness",
+		Index:   1,
+		Role:    placement.Witness,
+		Count:   1,
+		LabelConstraints: []placement.LabelConstraint{
+			{Key: "dc", Op: "in", Values: []string{"dc3"}},
+		},
+	})
+
+	// Add a voter as the witness.
+	s.cluster.AddLeaderRegionWithRange(1, "", "", 1, 2)
+	op := s.rc.Check(s.cluster.GetRegion(1))
+	c.Assert(op, NotNil)
+	c.Assert(op.Desc(), Equals, "add-rule-peer")
+	c.Assert(op.Step(0).(operator.AddLearner).ToStore, Equals, uint64(3))
+
+	// The witness should not be the leader.
+	s.cluster.AddLeaderRegionWithRange(1, "", "", 3, 1, 2)
+	op = s.rc.Check(s.cluster.GetRegion(1))
+	c.Assert(op, NotNil)
+	c.Assert(op.Desc(), Equals, "fix-follower-role")
+	c.Assert(op.Step(0).(operator.TransferLeader).ToStore, Not(Equals), uint64(3))
+
+	s.cluster.AddLeaderRegionWithRange(1, "", "", 1, 2, 3)
+	c.Assert(s.rc.Check(s.cluster.GetRegion(1)), IsNil)
+	c.Assert(s.cluster.FitRegion(s.cluster.GetRegion(1)).GetWitnesses()[0].GetStoreId(), Equals, uint64(3))
+}
+
 func (s *testRuleCheckerSuite) TestFixRoleLeaderIssue3130(c *C) {
 	s.cluster.AddLabelsStore(1, 1, map[string]string{"role": "follower"})
 	s.cluster.AddLabelsStore(2, 1, map[string]string{"role": "leader"})
@@ -58,6 +58,28 @@ func NewCheckerController(ctx context.Context, cluster opt.Cluster, ruleManager
 	}
 }
 
+// ForkDryRun creates a CheckerController for the dry runs of checkers, which
+// has its own waiting list. The merge checker shares the start time and the
+// recently split regions with the one of c to make the same decisions.
+func (c *CheckerController) ForkDryRun(cluster opt.Cluster, ruleManager *placement.RuleManager, opController *OperatorController) *CheckerController {
+	regionWaitingList := cache.NewDefaultCache(DefaultCacheSize)
+	var mergeChecker *checker.MergeChecker
+	if c.mergeChecker != nil {
+		mergeChecker = c.mergeChecker.Fork(cluster)
+	}
+	return &CheckerController{
+		cluster:           cluster,
+		opts:              cluster.GetOpts(),
+		opController:      opController,
+		learnerChecker:    checker.NewLearnerChecker(cluster),
+		replicaChecker:    checker.NewReplicaChecker(cluster, regionWaitingList),
+		ruleChecker:       checker.NewRuleChecker(cluster, ruleManager, regionWaitingList),
+		mergeChecker:      mergeChecker,
+		jointStateChecker: checker.NewJointStateChecker(cluster),
+		regionWaitingList: regionWaitingList,
+	}
+}
+
 // CheckRegion will check the region and add a new operator if needed.
 func (c *CheckerController) CheckRegion(region *core.RegionInfo) []*operator.Operator {
 	// If PD has restarted, it need to check learners added before and promote them.
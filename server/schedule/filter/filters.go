@@ -35,7 +35,7 @@ func SelectSourceStores(stores []*core.StoreInfo, filters []Filter, opt *config.
 	return filterStoresBy(stores, func(s *core.StoreInfo) bool {
 		return slice.AllOf(filters, func(i int) bool {
 			if !filters[i].Source(opt, s) {
-				filterCounter.WithLabelValues("filter-source", s.GetAddress(), fmt.Sprintf("%d", s.GetID()), filters[i].Scope(), filters[i].Type()).Inc()
+				onFiltered("filter-source", s, filters[i], opt)
 				return false
 			}
 			return true
@@ -48,7 +48,7 @@ func SelectTargetStores(stores []*core.StoreInfo, filters []Filter, opt *config.
 	return filterStoresBy(stores, func(s *core.StoreInfo) bool {
 		return slice.AllOf(filters, func(i int) bool {
 			if !filters[i].Target(opt, s) {
-				filterCounter.WithLabelValues("filter-target", s.GetAddress(), fmt.Sprintf("%d", s.GetID()), filters[i].Scope(), filters[i].Type()).Inc()
+				onFiltered("filter-target", s, filters[i], opt)
 				return false
 			}
 			return true
@@ -78,11 +78,9 @@ type Filter interface {
 
 // Source checks if store can pass all Filters as source store.
 func Source(opt *config.PersistOptions, store *core.StoreInfo, filters []Filter) bool {
-	storeAddress := store.GetAddress()
-	storeID := fmt.Sprintf("%d", store.GetID())
 	for _, filter := range filters {
 		if !filter.Source(opt, store) {
-			filterCounter.WithLabelValues("filter-source", storeAddress, storeID, filter.Scope(), filter.Type()).Inc()
+			onFiltered("filter-source", store, filter, opt)
 			return false
 		}
 	}
@@ -91,11 +89,9 @@ func Source(opt *config.PersistOptions, store *core.StoreInfo, filters []Filter)
 
 // Target checks if store can pass all Filters as target store.
 func Target(opt *config.PersistOptions, store *core.StoreInfo, filters []Filter) bool {
-	storeAddress := store.GetAddress()
-	storeID := fmt.Sprintf("%d", store.GetID())
 	for _, filter := range filters {
 		if !filter.Target(opt, store) {
-			filterCounter.WithLabelValues("filter-target", storeAddress, storeID, filter.Scope(), filter.Type()).Inc()
+			onFiltered("filter-target", store, filter, opt)
 			return false
 		}
 	}
@@ -135,6 +131,34 @@ func (f *excludedFilter) Target(opt *config.PersistOptions, store *core.StoreInf
 	return !ok
 }
 
+type storeGroupFilter struct {
+	scope  string
+	groups core.StoreGroups
+	source *core.StoreInfo
+}
+
+// NewStoreGroupFilter creates a Filter that filters the target stores which
+// are not in the same group with the source store.
+func NewStoreGroupFilter(scope string, groups core.StoreGroups, source *core.StoreInfo) Filter {
+	return &storeGroupFilter{scope: scope, groups: groups, source: source}
+}
+
+func (f *storeGroupFilter) Scope() string {
+	return f.scope
+}
+
+func (f *storeGroupFilter) Type() string {
+	return "store-group-filter"
+}
+
+func (f *storeGroupFilter) Source(opt *config.PersistOptions, store *core.StoreInfo) bool {
+	return true
+}
+
+func (f *storeGroupFilter) Target(opt *config.PersistOptions, store *core.StoreInfo) bool {
+	return f.groups.InSameGroup(f.source, store)
+}
+
 type storageThresholdFilter struct{ scope string }
 
 // NewStorageThresholdFilter creates a Filter that filters all stores that are
@@ -278,6 +302,11 @@ func (f *StoreStateFilter) pauseLeaderTransfer(opt *config.PersistOptions, store
 	return !store.AllowLeaderTransfer()
 }
 
+func (f *StoreStateFilter) slowStoreEvicted(opt *config.PersistOptions, store *core.StoreInfo) bool {
+	f.Reason = "slow-store"
+	return store.EvictedAsSlowStore()
+}
+
 func (f *StoreStateFilter) isDisconnected(opt *config.PersistOptions, store *core.StoreInfo) bool {
 	f.Reason = "disconnected"
 	return !f.AllowTemporaryStates && store.IsDisconnected()
@@ -322,12 +351,12 @@ func (f *StoreStateFilter) hasRejectLeaderProperty(opts *config.PersistOptions,
 // N: the condition is expected to be true for a long time.
 // X means when the condition is true, the store CANNOT be selected.
 //
-// Condition    Down Offline Tomb Pause Disconn Busy RmLimit AddLimit Snap Pending Reject
-// IsTemporary  N    N       N    N     Y       Y    Y       Y        Y    Y       N
+// Condition    Down Offline Tomb Pause Disconn Busy RmLimit AddLimit Snap Pending Reject Slow
+// IsTemporary  N    N       N    N     Y       Y    Y       Y        Y    Y       N      N
 //
 // LeaderSource X            X    X     X
 // RegionSource                                 X    X                X
-// LeaderTarget X    X       X    X     X       X                                  X
+// LeaderTarget X    X       X    X     X       X                                  X      X
 // RegionTarget X    X       X          X       X            X        X    X
 
 const (
@@ -346,7 +375,7 @@ func (f *StoreStateFilter) anyConditionMatch(typ int, opt *config.PersistOptions
 		funcs = []conditionFunc{f.isBusy, f.exceedRemoveLimit, f.tooManySnapshots}
 	case leaderTarget:
 		funcs = []conditionFunc{f.isTombstone, f.isOffline, f.isDown, f.pauseLeaderTransfer,
-			f.isDisconnected, f.isBusy, f.hasRejectLeaderProperty}
+			f.slowStoreEvicted, f.isDisconnected, f.isBusy, f.hasRejectLeaderProperty}
 	case regionTarget:
 		funcs = []conditionFunc{f.isTombstone, f.isOffline, f.isDown, f.isDisconnected, f.isBusy,
 			f.exceedAddLimit, f.tooManySnapshots, f.tooManyPendingPeers}
@@ -275,6 +275,41 @@ func (s *testFiltersSuite) TestPlacementGuard(c *C) {
 		newRuleFitFilter("", testCluster, region, 1))
 }
 
+func (s *testFiltersSuite) TestRecorder(c *C) {
+	opt := config.NewTestOptions()
+	stores := []*core.StoreInfo{
+		core.NewStoreInfoWithLabel(1, 1, nil),
+		core.NewStoreInfoWithLabel(2, 1, nil),
+		core.NewStoreInfoWithLabel(3, 1, nil),
+	}
+	excluded := map[uint64]struct{}{1: {}, 3: {}}
+	f1 := NewExcludedFilter("scope1", excluded, excluded)
+	f2 := NewExcludedFilter("scope2", excluded, excluded)
+
+	r := NewRecorder("scope1", opt)
+	c.Assert(SelectSourceStores(stores, []Filter{f1}, opt), HasLen, 1)
+	c.Assert(Target(opt, stores[0], []Filter{f1}), IsFalse)
+	c.Assert(SelectTargetStores(stores, []Filter{f2}, opt), HasLen, 1)
+	// The filters running with other options, like a dry run, are not recorded.
+	dryRunOpt := opt.Clone()
+	dryRun := NewRecorder("scope1", dryRunOpt)
+	c.Assert(SelectSourceStores(stores, []Filter{f1}, dryRunOpt), HasLen, 1)
+	c.Assert(r.GetRecords(), DeepEquals, []Record{
+		{Action: "filter-source", StoreID: 1, Type: "exclude-filter", Count: 1},
+		{Action: "filter-target", StoreID: 1, Type: "exclude-filter", Count: 1},
+		{Action: "filter-source", StoreID: 3, Type: "exclude-filter", Count: 1},
+	})
+	c.Assert(dryRun.GetRecords(), DeepEquals, []Record{
+		{Action: "filter-source", StoreID: 1, Type: "exclude-filter", Count: 1},
+		{Action: "filter-source", StoreID: 3, Type: "exclude-filter", Count: 1},
+	})
+	dryRun.Stop()
+
+	r.Stop()
+	c.Assert(Source(opt, stores[2], []Filter{f1}), IsFalse)
+	c.Assert(r.GetRecords(), HasLen, 3)
+}
+
 func BenchmarkCloneRegionTest(b *testing.B) {
 	epoch := &metapb.RegionEpoch{
 		ConfVer: 1,
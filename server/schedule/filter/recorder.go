@@ -0,0 +1,139 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filter
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"github.com/tikv/pd/server/config"
+	"github.com/tikv/pd/server/core"
+)
+
+// Record describes how many times a store is rejected by a filter.
+type Record struct {
+	Action  string `json:"action"`
+	StoreID uint64 `json:"store_id"`
+	Type    string `json:"type"`
+	Count   int    `json:"count"`
+}
+
+type recordKey struct {
+	action  string
+	storeID uint64
+	typ     string
+}
+
+// recorderScope identifies the filters recorded by a recorder. The options
+// are the ones the filters run with, which tell apart a dry run, running on a
+// copy of the options, from the running scheduler or checker with the same
+// scope.
+type recorderScope struct {
+	scope string
+	opts  *config.PersistOptions
+}
+
+// Recorder collects the stores rejected by the filters of a scope, which is
+// used to explain why a scheduler does not create any operator. Only the
+// filters with the same scope and run with the same options are recorded
+// while the recorder is active.
+type Recorder struct {
+	scope   recorderScope
+	mu      sync.Mutex
+	records map[recordKey]int
+}
+
+var (
+	// activeRecorders is the number of the active recorders. It is used to
+	// skip the lookup of recorders when there is no one.
+	activeRecorders int32
+	recordersMu     sync.RWMutex
+	recorders       = make(map[recorderScope][]*Recorder)
+)
+
+// NewRecorder creates a Recorder and starts to record the rejections of the
+// filters with the scope which run with the options. Stop must be called
+// after use.
+func NewRecorder(scope string, opts *config.PersistOptions) *Recorder {
+	r := &Recorder{
+		scope:   recorderScope{scope: scope, opts: opts},
+		records: make(map[recordKey]int),
+	}
+	recordersMu.Lock()
+	defer recordersMu.Unlock()
+	recorders[r.scope] = append(recorders[r.scope], r)
+	atomic.AddInt32(&activeRecorders, 1)
+	return r
+}
+
+// Stop stops recording.
+func (r *Recorder) Stop() {
+	recordersMu.Lock()
+	defer recordersMu.Unlock()
+	rs := recorders[r.scope]
+	for i := range rs {
+		if rs[i] == r {
+			rs = append(rs[:i], rs[i+1:]...)
+			atomic.AddInt32(&activeRecorders, -1)
+			break
+		}
+	}
+	if len(rs) == 0 {
+		delete(recorders, r.scope)
+	} else {
+		recorders[r.scope] = rs
+	}
+}
+
+// GetRecords returns the records sorted by store ID, action and filter type.
+func (r *Recorder) GetRecords() []Record {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	records := make([]Record, 0, len(r.records))
+	for k, cnt := range r.records {
+		records = append(records, Record{Action: k.action, StoreID: k.storeID, Type: k.typ, Count: cnt})
+	}
+	sort.Slice(records, func(i, j int) bool {
+		if records[i].StoreID != records[j].StoreID {
+			return records[i].StoreID < records[j].StoreID
+		}
+		if records[i].Action != records[j].Action {
+			return records[i].Action < records[j].Action
+		}
+		return records[i].Type < records[j].Type
+	})
+	return records
+}
+
+func (r *Recorder) record(key recordKey) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.records[key]++
+}
+
+// onFiltered is called when a store is rejected by a filter which runs with
+// the options.
+func onFiltered(action string, store *core.StoreInfo, f Filter, opts *config.PersistOptions) {
+	filterCounter.WithLabelValues(action, store.GetAddress(), fmt.Sprintf("%d", store.GetID()), f.Scope(), f.Type()).Inc()
+	if atomic.LoadInt32(&activeRecorders) == 0 {
+		return
+	}
+	recordersMu.RLock()
+	defer recordersMu.RUnlock()
+	for _, r := range recorders[recorderScope{scope: f.Scope(), opts: opts}] {
+		r.record(recordKey{action: action, storeID: store.GetID(), typ: f.Type()})
+	}
+}
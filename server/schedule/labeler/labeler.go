@@ -0,0 +1,206 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package labeler
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"sync"
+
+	"github.com/pingcap/log"
+	"github.com/tikv/pd/pkg/errs"
+	"github.com/tikv/pd/server/core"
+	"go.uber.org/zap"
+)
+
+// RegionLabeler is utility to label regions. It maintains the label rules
+// that map key ranges to region labels. It is thread safe.
+type RegionLabeler struct {
+	storage *core.Storage
+	sync.RWMutex
+	labelRules map[string]*LabelRule
+	// sortedRules is sorted by rule ID, a rule with larger ID overrides the
+	// labels of the same key from the rules with smaller IDs.
+	sortedRules []*LabelRule
+	// splitKeys is the sorted boundaries of all the rules.
+	splitKeys [][]byte
+}
+
+// NewRegionLabeler creates a Labeler instance and loads the rules from storage.
+func NewRegionLabeler(storage *core.Storage) (*RegionLabeler, error) {
+	l := &RegionLabeler{
+		storage:    storage,
+		labelRules: make(map[string]*LabelRule),
+	}
+	if err := l.loadRules(); err != nil {
+		return nil, err
+	}
+	l.buildIndex()
+	return l, nil
+}
+
+func (l *RegionLabeler) loadRules() error {
+	var toDelete []string
+	err := l.storage.LoadRegionRules(func(k, v string) {
+		var r LabelRule
+		if err := json.Unmarshal([]byte(v), &r); err != nil {
+			log.Error("failed to unmarshal label rule value", zap.String("rule-key", k), zap.String("rule-value", v), errs.ZapError(errs.ErrLoadRegionRule))
+			toDelete = append(toDelete, k)
+			return
+		}
+		if err := r.adjust(); err != nil {
+			log.Error("label rule is in bad format", zap.String("rule-key", k), zap.String("rule-value", v), errs.ZapError(errs.ErrLoadRegionRule, err))
+			toDelete = append(toDelete, k)
+			return
+		}
+		l.labelRules[r.ID] = &r
+	})
+	if err != nil {
+		return err
+	}
+	for _, d := range toDelete {
+		if err = l.storage.DeleteRegionRule(d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (l *RegionLabeler) buildIndex() {
+	l.sortedRules = make([]*LabelRule, 0, len(l.labelRules))
+	keys := make([][]byte, 0, 2*len(l.labelRules))
+	for _, r := range l.labelRules {
+		l.sortedRules = append(l.sortedRules, r)
+		if len(r.StartKey) > 0 {
+			keys = append(keys, r.StartKey)
+		}
+		if len(r.EndKey) > 0 {
+			keys = append(keys, r.EndKey)
+		}
+	}
+	sort.Slice(l.sortedRules, func(i, j int) bool { return l.sortedRules[i].ID < l.sortedRules[j].ID })
+	sort.Slice(keys, func(i, j int) bool { return bytes.Compare(keys[i], keys[j]) < 0 })
+	l.splitKeys = l.splitKeys[:0]
+	for _, k := range keys {
+		if n := len(l.splitKeys); n == 0 || !bytes.Equal(l.splitKeys[n-1], k) {
+			l.splitKeys = append(l.splitKeys, k)
+		}
+	}
+}
+
+// GetAllLabelRules returns all the rules sorted by ID.
+func (l *RegionLabeler) GetAllLabelRules() []*LabelRule {
+	l.RLock()
+	defer l.RUnlock()
+	rules := make([]*LabelRule, 0, len(l.sortedRules))
+	return append(rules, l.sortedRules...)
+}
+
+// GetLabelRule returns the Rule with the same ID.
+func (l *RegionLabeler) GetLabelRule(id string) *LabelRule {
+	l.RLock()
+	defer l.RUnlock()
+	return l.labelRules[id]
+}
+
+// SetLabelRule inserts or updates a LabelRule.
+func (l *RegionLabeler) SetLabelRule(rule *LabelRule) error {
+	if err := rule.adjust(); err != nil {
+		return err
+	}
+	l.Lock()
+	defer l.Unlock()
+	if err := l.storage.SaveRegionRule(rule.StoreKey(), rule); err != nil {
+		return err
+	}
+	l.labelRules[rule.ID] = rule
+	l.buildIndex()
+	return nil
+}
+
+// DeleteLabelRule removes a LabelRule.
+func (l *RegionLabeler) DeleteLabelRule(id string) error {
+	l.Lock()
+	defer l.Unlock()
+	rule, ok := l.labelRules[id]
+	if !ok {
+		return errs.ErrRegionRuleNotFound.FastGenByArgs(id)
+	}
+	if err := l.storage.DeleteRegionRule(rule.StoreKey()); err != nil {
+		return err
+	}
+	delete(l.labelRules, id)
+	l.buildIndex()
+	return nil
+}
+
+// GetRegionLabel returns the label of the region for a key.
+// If there are multiple rules that match, the one with the largest ID wins.
+func (l *RegionLabeler) GetRegionLabel(region *core.RegionInfo, key string) string {
+	l.RLock()
+	defer l.RUnlock()
+	for i := len(l.sortedRules) - 1; i >= 0; i-- {
+		r := l.sortedRules[i]
+		if !r.coversRegion(region) {
+			continue
+		}
+		if v := r.GetLabel(key); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// GetRegionLabels returns the labels of the region.
+// For each key, the label from the rule with the largest ID wins.
+func (l *RegionLabeler) GetRegionLabels(region *core.RegionInfo) []*RegionLabel {
+	l.RLock()
+	defer l.RUnlock()
+	var labels []*RegionLabel
+	index := make(map[string]int)
+	for _, r := range l.sortedRules {
+		if !r.coversRegion(region) {
+			continue
+		}
+		for _, label := range r.Labels {
+			if i, ok := index[label.Key]; ok {
+				labels[i].Value = label.Value
+				continue
+			}
+			index[label.Key] = len(labels)
+			labels = append(labels, &RegionLabel{Key: label.Key, Value: label.Value})
+		}
+	}
+	return labels
+}
+
+// GetSplitKeys returns all the boundaries of the label rules that are
+// strictly inside the range (start, end). A region that crosses any of
+// them would mix up the labels of different rules.
+func (l *RegionLabeler) GetSplitKeys(start, end []byte) [][]byte {
+	l.RLock()
+	defer l.RUnlock()
+	i := sort.Search(len(l.splitKeys), func(i int) bool {
+		return bytes.Compare(l.splitKeys[i], start) > 0
+	})
+	var keys [][]byte
+	for ; i < len(l.splitKeys); i++ {
+		if len(end) > 0 && bytes.Compare(l.splitKeys[i], end) >= 0 {
+			break
+		}
+		keys = append(keys, l.splitKeys[i])
+	}
+	return keys
+}
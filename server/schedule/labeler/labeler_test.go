@@ -0,0 +1,164 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package labeler
+
+import (
+	"encoding/hex"
+	"testing"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/tikv/pd/server/core"
+	"github.com/tikv/pd/server/kv"
+)
+
+func TestT(t *testing.T) {
+	TestingT(t)
+}
+
+var _ = Suite(&testLabelerSuite{})
+
+type testLabelerSuite struct {
+	store   *core.Storage
+	labeler *RegionLabeler
+}
+
+func (s *testLabelerSuite) SetUpTest(c *C) {
+	s.store = core.NewStorage(kv.NewMemoryKV())
+	var err error
+	s.labeler, err = NewRegionLabeler(s.store)
+	c.Assert(err, IsNil)
+}
+
+func (s *testLabelerSuite) TestAdjustRule(c *C) {
+	rules := []LabelRule{
+		{ID: "", Labels: []RegionLabel{{Key: "k", Value: "v"}}, StartKeyHex: "12abcd", EndKeyHex: "34cdef"},
+		{ID: "foo", StartKeyHex: "12abcd", EndKeyHex: "34cdef"},
+		{ID: "foo", Labels: []RegionLabel{{Key: "", Value: "v"}}, StartKeyHex: "12abcd", EndKeyHex: "34cdef"},
+		{ID: "foo", Labels: []RegionLabel{{Key: "k", Value: ""}}, StartKeyHex: "12abcd", EndKeyHex: "34cdef"},
+		{ID: "foo", Labels: []RegionLabel{{Key: "k", Value: "v"}}, StartKeyHex: "12abc", EndKeyHex: "34cdef"},
+		{ID: "foo", Labels: []RegionLabel{{Key: "k", Value: "v"}}, StartKeyHex: "12abcd", EndKeyHex: "xyz"},
+		{ID: "foo", Labels: []RegionLabel{{Key: "k", Value: "v"}}, StartKeyHex: "34cdef", EndKeyHex: "12abcd"},
+	}
+	for i := range rules {
+		c.Assert(s.labeler.SetLabelRule(&rules[i]), NotNil)
+	}
+	c.Assert(s.labeler.GetAllLabelRules(), HasLen, 0)
+}
+
+func (s *testLabelerSuite) TestGetSetRule(c *C) {
+	rules := []*LabelRule{
+		{ID: "rule1", Labels: []RegionLabel{{Key: "k1", Value: "v1"}}, StartKeyHex: "1234", EndKeyHex: "5678"},
+		{ID: "rule2", Labels: []RegionLabel{{Key: "k2", Value: "v2"}}, StartKeyHex: "ab12", EndKeyHex: "cd12"},
+		{ID: "rule3", Labels: []RegionLabel{{Key: "k3", Value: "v3"}}, StartKeyHex: "abcd", EndKeyHex: ""},
+	}
+	for _, r := range rules {
+		c.Assert(s.labeler.SetLabelRule(r), IsNil)
+	}
+
+	allRules := s.labeler.GetAllLabelRules()
+	c.Assert(allRules, HasLen, 3)
+	for i := range allRules {
+		c.Assert(allRules[i], DeepEquals, rules[i])
+	}
+	c.Assert(s.labeler.GetLabelRule("rule2"), DeepEquals, rules[1])
+	c.Assert(s.labeler.GetLabelRule("rule4"), IsNil)
+
+	c.Assert(s.labeler.DeleteLabelRule("rule2"), IsNil)
+	c.Assert(s.labeler.GetLabelRule("rule2"), IsNil)
+	c.Assert(s.labeler.DeleteLabelRule("rule2"), NotNil)
+	c.Assert(s.labeler.GetAllLabelRules(), HasLen, 2)
+
+	// The rules are persisted.
+	labeler, err := NewRegionLabeler(s.store)
+	c.Assert(err, IsNil)
+	c.Assert(labeler.GetAllLabelRules(), DeepEquals, s.labeler.GetAllLabelRules())
+}
+
+func (s *testLabelerSuite) TestRegionLabels(c *C) {
+	rules := []*LabelRule{
+		{ID: "rule1", Labels: []RegionLabel{{Key: "k1", Value: "v1"}, {Key: "k2", Value: "v2"}}, StartKeyHex: "1234", EndKeyHex: "5678"},
+		{ID: "rule2", Labels: []RegionLabel{{Key: "k2", Value: "v22"}}, StartKeyHex: "3456", EndKeyHex: ""},
+	}
+	for _, r := range rules {
+		c.Assert(s.labeler.SetLabelRule(r), IsNil)
+	}
+
+	testCases := []struct {
+		start, end string
+		labels     map[string]string
+	}{
+		{"1234", "2345", map[string]string{"k1": "v1", "k2": "v2"}},
+		{"1234", "4567", map[string]string{"k1": "v1", "k2": "v2"}},
+		{"3456", "5678", map[string]string{"k1": "v1", "k2": "v22"}},
+		{"4567", "6789", map[string]string{"k2": "v22"}},
+		{"4567", "", map[string]string{"k2": "v22"}},
+		{"0123", "2345", map[string]string{}},
+		{"1234", "", map[string]string{}},
+	}
+	for _, t := range testCases {
+		region := newTestRegion(t.start, t.end)
+		labels := s.labeler.GetRegionLabels(region)
+		c.Assert(labels, HasLen, len(t.labels))
+		for _, l := range labels {
+			c.Assert(l.Value, Equals, t.labels[l.Key])
+			c.Assert(s.labeler.GetRegionLabel(region, l.Key), Equals, l.Value)
+		}
+		c.Assert(s.labeler.GetRegionLabel(region, "k3"), Equals, "")
+	}
+}
+
+func (s *testLabelerSuite) TestGetSplitKeys(c *C) {
+	rules := []*LabelRule{
+		{ID: "rule1", Labels: []RegionLabel{{Key: "k1", Value: "v1"}}, StartKeyHex: "1234", EndKeyHex: "5678"},
+		{ID: "rule2", Labels: []RegionLabel{{Key: "k2", Value: "v2"}}, StartKeyHex: "5678", EndKeyHex: "9abc"},
+	}
+	for _, r := range rules {
+		c.Assert(s.labeler.SetLabelRule(r), IsNil)
+	}
+
+	testCases := []struct {
+		start, end string
+		keys       []string
+	}{
+		{"", "", []string{"1234", "5678", "9abc"}},
+		{"1234", "9abc", []string{"5678"}},
+		{"1234", "5678", nil},
+		{"0000", "5679", []string{"1234", "5678"}},
+		{"9abc", "", nil},
+	}
+	for _, t := range testCases {
+		keys := s.labeler.GetSplitKeys(decodeHex(t.start), decodeHex(t.end))
+		c.Assert(keys, HasLen, len(t.keys))
+		for i := range keys {
+			c.Assert(keys[i], DeepEquals, decodeHex(t.keys[i]))
+		}
+	}
+}
+
+func newTestRegion(start, end string) *core.RegionInfo {
+	return core.NewRegionInfo(&metapb.Region{
+		Id:       1,
+		StartKey: decodeHex(start),
+		EndKey:   decodeHex(end),
+	}, nil)
+}
+
+func decodeHex(s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
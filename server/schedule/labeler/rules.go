@@ -0,0 +1,121 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package labeler
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+
+	"github.com/tikv/pd/pkg/errs"
+	"github.com/tikv/pd/server/core"
+)
+
+// Well-known region labels that are consumed by the scheduling components.
+const (
+	// MergeOptionKey is the label key that controls whether the labeled
+	// regions can be merged.
+	MergeOptionKey = "merge_option"
+	// MergeOptionValueDeny prevents the labeled regions from being merged.
+	MergeOptionValueDeny = "deny"
+	// ScheduleOptionKey is the label key that controls whether the labeled
+	// regions can be moved by the balance schedulers and the scatterer.
+	ScheduleOptionKey = "schedule"
+	// ScheduleOptionValueDeny prevents the labeled regions from being scheduled.
+	ScheduleOptionValueDeny = "deny"
+)
+
+// RegionLabel is the label of a region.
+type RegionLabel struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// LabelRule is the rule to assign labels to the regions in a key range.
+// A region gets the labels of a rule only if it is fully covered by the rule.
+type LabelRule struct {
+	ID          string        `json:"id"`
+	Labels      []RegionLabel `json:"labels"`
+	StartKeyHex string        `json:"start_key"` // hex format start key, for marshal/unmarshal
+	EndKeyHex   string        `json:"end_key"`   // hex format end key, for marshal/unmarshal
+
+	StartKey []byte `json:"-"` // range start key
+	EndKey   []byte `json:"-"` // range end key
+}
+
+func (r *LabelRule) String() string {
+	b, _ := json.Marshal(r)
+	return string(b)
+}
+
+// StoreKey returns the rule's key for persistent store.
+func (r *LabelRule) StoreKey() string {
+	return hex.EncodeToString([]byte(r.ID))
+}
+
+// GetLabel returns the value of the label, or an empty string if the rule
+// does not contain the label.
+func (r *LabelRule) GetLabel(key string) string {
+	for _, l := range r.Labels {
+		if l.Key == key {
+			return l.Value
+		}
+	}
+	return ""
+}
+
+func (r *LabelRule) adjust() error {
+	var err error
+	if r.ID == "" {
+		return errs.ErrRegionRuleContent.FastGenByArgs("empty rule id")
+	}
+	if len(r.Labels) == 0 {
+		return errs.ErrRegionRuleContent.FastGenByArgs("no region labels")
+	}
+	for _, l := range r.Labels {
+		if l.Key == "" {
+			return errs.ErrRegionRuleContent.FastGenByArgs("empty label key")
+		}
+		if l.Value == "" {
+			return errs.ErrRegionRuleContent.FastGenByArgs("empty label value")
+		}
+	}
+	r.StartKey, err = hex.DecodeString(r.StartKeyHex)
+	if err != nil {
+		return errs.ErrHexDecodingString.FastGenByArgs(r.StartKeyHex)
+	}
+	r.EndKey, err = hex.DecodeString(r.EndKeyHex)
+	if err != nil {
+		return errs.ErrHexDecodingString.FastGenByArgs(r.EndKeyHex)
+	}
+	if len(r.EndKey) > 0 && bytes.Compare(r.EndKey, r.StartKey) <= 0 {
+		return errs.ErrRegionRuleContent.FastGenByArgs("endKey should be greater than startKey")
+	}
+	return nil
+}
+
+// coversRegion checks whether the region is fully covered by the rule.
+func (r *LabelRule) coversRegion(region *core.RegionInfo) bool {
+	return r.coversRange(region.GetStartKey(), region.GetEndKey())
+}
+
+func (r *LabelRule) coversRange(start, end []byte) bool {
+	if bytes.Compare(start, r.StartKey) < 0 {
+		return false
+	}
+	if len(r.EndKey) == 0 {
+		return true
+	}
+	return len(end) > 0 && bytes.Compare(end, r.EndKey) <= 0
+}
@@ -73,6 +73,14 @@ var (
 			Name:      "store_limit_cost",
 			Help:      "limit rate cost of store.",
 		}, []string{"store", "limit_type"})
+
+	operatorJournalDroppedCounter = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: "pd",
+			Subsystem: "schedule",
+			Name:      "operator_journal_dropped_records",
+			Help:      "Counter of operator records dropped by the journal.",
+		})
 )
 
 func init() {
@@ -83,4 +91,5 @@ func init() {
 	prometheus.MustRegister(storeLimitRateGauge)
 	prometheus.MustRegister(storeLimitCostCounter)
 	prometheus.MustRegister(operatorWaitCounter)
+	prometheus.MustRegister(operatorJournalDroppedCounter)
 }
@@ -47,6 +47,7 @@ type Builder struct {
 	// operation record
 	originPeers         peersMap
 	unhealthyPeers      peersMap
+	witnesses           peersMap
 	originLeaderStoreID uint64
 	targetPeers         peersMap
 	targetLeaderStoreID uint64
@@ -111,6 +112,11 @@ func NewBuilder(desc string, cluster opt.Cluster, region *core.RegionInfo, opts
 		unhealthyPeers.Set(p)
 	}
 
+	witnesses := newPeersMap()
+	for _, p := range region.GetWitnesses() {
+		witnesses.Set(p)
+	}
+
 	for _, p := range region.GetDownPeers() {
 		unhealthyPeers.Set(p.Peer)
 	}
@@ -144,6 +150,7 @@ func NewBuilder(desc string, cluster opt.Cluster, region *core.RegionInfo, opts
 	b.rules = rules
 	b.originPeers = originPeers
 	b.unhealthyPeers = unhealthyPeers
+	b.witnesses = witnesses
 	b.originLeaderStoreID = originLeaderStoreID
 	b.targetPeers = originPeers.Copy()
 	b.allowDemote = supportJointConsensus
@@ -236,6 +243,8 @@ func (b *Builder) SetLeader(storeID uint64) *Builder {
 		b.err = errors.Errorf("cannot transfer leader to %d: not voter", storeID)
 	} else if _, ok := b.unhealthyPeers[storeID]; ok {
 		b.err = errors.Errorf("cannot transfer leader to %d: unhealthy", storeID)
+	} else if w, ok := b.witnesses[storeID]; ok && w.GetId() == peer.GetId() {
+		b.err = errors.Errorf("cannot transfer leader to %d: witness", storeID)
 	} else {
 		b.targetLeaderStoreID = storeID
 	}
@@ -284,7 +293,7 @@ func (b *Builder) SetExpectedRoles(roles map[uint64]placement.PeerRoleType) *Bui
 			leaderCount++
 		case placement.Voter:
 			voterCount++
-		case placement.Follower, placement.Learner:
+		case placement.Follower, placement.Learner, placement.Witness:
 			if b.targetLeaderStoreID == id {
 				b.targetLeaderStoreID = 0
 			}
@@ -540,8 +549,8 @@ func (b *Builder) setTargetLeaderIfNotExist() {
 		if !b.allowLeader(peer, false) {
 			continue
 		}
-		// if role info is given, store having role follower should not be target leader.
-		if role, ok := b.expectedRoles[targetLeaderStoreID]; ok && role == placement.Follower {
+		// if role info is given, store having role follower or witness should not be target leader.
+		if role, ok := b.expectedRoles[targetLeaderStoreID]; ok && (role == placement.Follower || role == placement.Witness) {
 			continue
 		}
 		if b.targetLeaderStoreID == 0 {
@@ -708,6 +717,11 @@ func (b *Builder) allowLeader(peer *metapb.Peer, ignoreClusterLimit bool) bool {
 		return false
 	}
 
+	// witnesses do not hold data.
+	if w, ok := b.witnesses[peer.GetStoreId()]; ok && w.GetId() == peer.GetId() {
+		return false
+	}
+
 	// store does not exist
 	if peer.GetStoreId() == b.currentLeaderStoreID {
 		return true
@@ -104,6 +104,20 @@ func (s *testBuilderSuite) TestRecord(c *C) {
 	c.Assert(builder.lightWeight, IsTrue)
 }
 
+func (s *testBuilderSuite) TestWitness(c *C) {
+	peers := []*metapb.Peer{{Id: 11, StoreId: 1}, {Id: 12, StoreId: 2}, {Id: 13, StoreId: 3}}
+	region := core.NewRegionInfo(&metapb.Region{Id: 1, Peers: peers}, peers[0], core.WithWitnesses(peers[2:]))
+	c.Assert(NewBuilder("test", s.cluster, region).SetLeader(3).err, NotNil)
+	c.Assert(NewBuilder("test", s.cluster, region).SetLeader(2).err, IsNil)
+
+	// The witness is not chosen as the new leader when the leader is removed.
+	op, err := NewBuilder("test", s.cluster, region).RemovePeer(1).Build(0)
+	c.Assert(err, IsNil)
+	c.Assert(op.Step(0).(TransferLeader).ToStore, Equals, uint64(2))
+	_, err = NewBuilder("test", s.cluster, region).RemovePeer(1).RemovePeer(2).Build(0)
+	c.Assert(err, NotNil)
+}
+
 func (s *testBuilderSuite) TestPrepareBuild(c *C) {
 	// no voter.
 	_, err := s.newBuilder().SetPeers(map[uint64]*metapb.Peer{4: {StoreId: 4, Role: metapb.PeerRole_Learner}}).prepareBuild()
@@ -14,14 +14,17 @@
 package operator
 
 import (
+	"bytes"
 	"encoding/hex"
 	"fmt"
+	"math/big"
 	"math/rand"
 
 	"github.com/pingcap/errors"
 	"github.com/pingcap/kvproto/pkg/metapb"
 	"github.com/pingcap/kvproto/pkg/pdpb"
 
+	"github.com/tikv/pd/pkg/codec"
 	"github.com/tikv/pd/server/core"
 	"github.com/tikv/pd/server/schedule/opt"
 	"github.com/tikv/pd/server/schedule/placement"
@@ -117,6 +120,18 @@ func CreateSplitRegionOperator(desc string, region *core.RegionInfo, kind OpKind
 	return NewOperator(desc, brief, region.GetID(), region.GetRegionEpoch(), kind|OpSplit, step), nil
 }
 
+// CreateLoadSplitRegionOperator creates an operator that splits a hot region
+// at the middle of its key range. PD does not know the distribution of the
+// keys inside a region, so the middle key is only an approximation which works
+// well for the regions with uniformly distributed keys.
+func CreateLoadSplitRegionOperator(desc string, region *core.RegionInfo, kind OpKind, keyType core.KeyType) (*Operator, error) {
+	key := getLoadSplitKey(keyType, region.GetStartKey(), region.GetEndKey())
+	if key == nil {
+		return nil, errors.Errorf("cannot find split key for region %d", region.GetID())
+	}
+	return CreateSplitRegionOperator(desc, region, kind, pdpb.CheckPolicy_USEKEY, [][]byte{key})
+}
+
 // CreateMergeRegionOperator creates an operator that merge two region into one.
 func CreateMergeRegionOperator(desc string, cluster opt.Cluster, source *core.RegionInfo, target *core.RegionInfo, kind OpKind) ([]*Operator, error) {
 	if core.IsInJointState(source.GetPeers()...) || core.IsInJointState(target.GetPeers()...) {
@@ -244,3 +259,74 @@ func CreateLeaveJointStateOperator(desc string, cluster opt.Cluster, origin *cor
 	b.execChangePeerV2(false, true)
 	return NewOperator(b.desc, brief, b.regionID, b.regionEpoch, kind, b.steps...), nil
 }
+
+// getLoadSplitKey returns the middle key of the range [start, end). For the
+// table and txn key types, the keys are decoded first so that the split key
+// is still a valid encoded key.
+func getLoadSplitKey(keyType core.KeyType, start, end []byte) []byte {
+	if keyType == core.Table || keyType == core.Txn {
+		rawStart, rawEnd, ok := decodeKeyRange(start, end)
+		if ok {
+			if mid := getMidKey(rawStart, rawEnd); mid != nil {
+				return codec.EncodeBytes(mid)
+			}
+			return nil
+		}
+	}
+	return getMidKey(start, end)
+}
+
+func decodeKeyRange(start, end []byte) ([]byte, []byte, bool) {
+	var rawStart, rawEnd []byte
+	var err error
+	if len(start) > 0 {
+		if _, rawStart, err = codec.DecodeBytes(start); err != nil {
+			return nil, nil, false
+		}
+	}
+	if len(end) > 0 {
+		if _, rawEnd, err = codec.DecodeBytes(end); err != nil {
+			return nil, nil, false
+		}
+	}
+	return rawStart, rawEnd, true
+}
+
+// getMidKey returns a key in the middle of the range [start, end), an empty
+// end key means the range is unbounded. It returns nil if the range is too
+// narrow to find a key which is greater than start.
+func getMidKey(start, end []byte) []byte {
+	n := len(start)
+	if len(end) > n {
+		n = len(end)
+	}
+	// One more byte to make sure there is a key between two adjacent keys.
+	n++
+	lo := new(big.Int).SetBytes(padKey(start, n))
+	hi := new(big.Int).Lsh(big.NewInt(1), uint(8*n))
+	if len(end) > 0 {
+		hi.SetBytes(padKey(end, n))
+	}
+	mid := new(big.Int).Add(lo, hi)
+	mid.Rsh(mid, 1)
+	key := padLeft(mid.Bytes(), n)
+	key = bytes.TrimRight(key, "\x00")
+	if bytes.Compare(key, start) <= 0 || (len(end) > 0 && bytes.Compare(key, end) >= 0) {
+		return nil
+	}
+	return key
+}
+
+// padKey appends zeros to the key to make it n bytes.
+func padKey(key []byte, n int) []byte {
+	res := make([]byte, n)
+	copy(res, key)
+	return res
+}
+
+// padLeft prepends zeros to the big-endian number to make it n bytes.
+func padLeft(b []byte, n int) []byte {
+	res := make([]byte, n)
+	copy(res[n-len(b):], b)
+	return res
+}
@@ -21,6 +21,7 @@ import (
 	"github.com/pingcap/kvproto/pkg/metapb"
 	"github.com/pingcap/kvproto/pkg/pdpb"
 
+	"github.com/tikv/pd/pkg/codec"
 	"github.com/tikv/pd/pkg/mock/mockcluster"
 	"github.com/tikv/pd/server/config"
 	"github.com/tikv/pd/server/core"
@@ -151,6 +152,52 @@ func (s *testCreateOperatorSuite) TestCreateSplitRegionOperator(c *C) {
 	}
 }
 
+func (s *testCreateOperatorSuite) TestCreateLoadSplitRegionOperator(c *C) {
+	type testCase struct {
+		keyType       core.KeyType
+		startKey      []byte
+		endKey        []byte
+		splitKey      []byte
+		expectedError bool
+	}
+	cases := []testCase{
+		{core.Raw, nil, nil, []byte{0x80}, false},
+		{core.Raw, []byte("a"), []byte("c"), []byte("b"), false},
+		{core.Raw, []byte("a"), []byte("b"), []byte("a\x80"), false},
+		{core.Raw, []byte{0xff, 0xff}, nil, []byte{0xff, 0xff, 0x80}, false},
+		{core.Raw, []byte("a"), []byte("a\x00"), nil, true},
+		{core.Table, codec.EncodeBytes([]byte("a")), codec.EncodeBytes([]byte("c")), codec.EncodeBytes([]byte("b")), false},
+		{core.Txn, nil, codec.EncodeBytes([]byte{0x80}), codec.EncodeBytes([]byte{0x40}), false},
+		// Fall back to the raw keys if the keys cannot be decoded.
+		{core.Table, []byte("a"), []byte("c"), []byte("b"), false},
+	}
+
+	peers := []*metapb.Peer{
+		{Id: 1, StoreId: 1, Role: metapb.PeerRole_Voter},
+		{Id: 2, StoreId: 2, Role: metapb.PeerRole_Voter},
+		{Id: 3, StoreId: 3, Role: metapb.PeerRole_Voter},
+	}
+	for _, tc := range cases {
+		region := core.NewRegionInfo(&metapb.Region{
+			Id:       1,
+			StartKey: tc.startKey,
+			EndKey:   tc.endKey,
+			Peers:    peers,
+		}, peers[0])
+		op, err := CreateLoadSplitRegionOperator("test", region, OpHotRegion, tc.keyType)
+		if tc.expectedError {
+			c.Assert(err, NotNil)
+			continue
+		}
+		c.Assert(err, IsNil)
+		c.Assert(op.Kind(), Equals, OpHotRegion|OpSplit)
+		step, ok := op.Step(0).(SplitRegion)
+		c.Assert(ok, IsTrue)
+		c.Assert(step.Policy, Equals, pdpb.CheckPolicy_USEKEY)
+		c.Assert(step.SplitKeys, DeepEquals, [][]byte{tc.splitKey})
+	}
+}
+
 func (s *testCreateOperatorSuite) TestCreateMergeRegionOperator(c *C) {
 	type testCase struct {
 		sourcePeers   []*metapb.Peer // first is leader
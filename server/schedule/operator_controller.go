@@ -64,6 +64,7 @@ type OperatorController struct {
 	histories       *list.List
 	counts          map[operator.OpKind]uint64
 	opRecords       *OperatorRecords
+	journal         *OperatorJournal
 	storesLimit     map[uint64]map[storelimit.Type]*storelimit.StoreLimit
 	wop             WaitingOperator
 	wopStatus       *WaitingOperatorStatus
@@ -87,6 +88,21 @@ func NewOperatorController(ctx context.Context, cluster opt.Cluster, hbStreams *
 	}
 }
 
+// SetOperatorJournal sets the journal to save the events of operators. It
+// should be called before any operator is added.
+func (oc *OperatorController) SetOperatorJournal(journal *OperatorJournal) {
+	oc.Lock()
+	defer oc.Unlock()
+	oc.journal = journal
+}
+
+// GetOperatorJournal returns the journal of operators, nil if it is not set.
+func (oc *OperatorController) GetOperatorJournal() *OperatorJournal {
+	oc.RLock()
+	defer oc.RUnlock()
+	return oc.journal
+}
+
 // Ctx returns a context which will be canceled once RaftCluster is stopped.
 // For now, it is only used to control the lifetime of TTL cache in schedulers.
 func (oc *OperatorController) Ctx() context.Context {
@@ -119,6 +135,7 @@ func (oc *OperatorController) Dispatch(region *core.RegionInfo, source string) {
 			if source == DispatchFromHeartBeat && oc.checkStaleOperator(op, step, region) {
 				return
 			}
+			oc.GetOperatorJournal().onStep(op, step)
 			oc.SendScheduleCommand(region, step, source)
 		case operator.SUCCESS:
 			oc.pushHistory(op)
@@ -219,7 +236,7 @@ func (oc *OperatorController) pollNeedDispatchRegion() (r *core.RegionInfo, next
 				zap.Stringer("operator", op))
 			operatorCounter.WithLabelValues(op.Desc(), "disappear").Inc()
 		}
-		oc.buryOperator(op)
+		oc.buryOperator(op, zap.String("reason", "region disappeared"))
 		return nil, true
 	}
 	step := op.Check(r)
@@ -474,9 +491,11 @@ func (oc *OperatorController) addOperatorLocked(op *operator.Operator) bool {
 	}
 	oc.updateCounts(oc.operators)
 
+	oc.journal.onCreate(op)
 	var step operator.OpStep
 	if region := oc.cluster.GetRegion(op.RegionID()); region != nil {
 		if step = op.Check(region); step != nil {
+			oc.journal.onStep(op, step)
 			oc.SendScheduleCommand(region, step, DispatchFromCreate)
 		}
 	}
@@ -582,6 +601,13 @@ func (oc *OperatorController) buryOperator(op *operator.Operator, extraFields ..
 	}
 
 	oc.opRecords.Put(op)
+	var reason string
+	for _, f := range extraFields {
+		if f.Key == "reason" {
+			reason = f.String
+		}
+	}
+	oc.journal.onEnd(op, reason)
 }
 
 // GetOperatorStatus gets the operator and its status with the specify id.
@@ -803,6 +829,21 @@ func (oc *OperatorController) updateCounts(operators map[uint64]*operator.Operat
 	}
 }
 
+// CopyOperatorCounts copies the counts of the operators in another
+// controller, so that the limits of scheduling are checked against them. It's
+// used by a throwaway controller which doesn't have any operator.
+func (oc *OperatorController) CopyOperatorCounts(from *OperatorController) {
+	from.RLock()
+	counts := make(map[operator.OpKind]uint64, len(from.counts))
+	for k, count := range from.counts {
+		counts[k] = count
+	}
+	from.RUnlock()
+	oc.Lock()
+	defer oc.Unlock()
+	oc.counts = counts
+}
+
 // OperatorCount gets the count of operators filtered by mask.
 func (oc *OperatorController) OperatorCount(mask operator.OpKind) uint64 {
 	oc.RLock()
@@ -0,0 +1,410 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedule
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pingcap/log"
+	"github.com/tikv/pd/pkg/errs"
+	"github.com/tikv/pd/server/core"
+	"github.com/tikv/pd/server/schedule/operator"
+	"go.uber.org/zap"
+)
+
+// The events of an operator recorded in the journal.
+const (
+	OperatorEventCreate  = "create"
+	OperatorEventStep    = "step"
+	OperatorEventFinish  = "finish"
+	OperatorEventCancel  = "cancel"
+	OperatorEventTimeout = "timeout"
+	OperatorEventReplace = "replace"
+	OperatorEventExpire  = "expire"
+)
+
+// operatorJournalLimit is the max number of records kept in the journal.
+var operatorJournalLimit uint64 = 10000
+
+// operatorJournalLoadRetryInterval is the interval to retry loading the
+// records from the storage.
+var operatorJournalLoadRetryInterval = 5 * time.Second
+
+const (
+	// operatorJournalBufferSize is the number of records waiting to be saved.
+	// The records are dropped if the buffer is full.
+	operatorJournalBufferSize = 1024
+	// operatorJournalBatchSize is the max number of records saved in a
+	// transaction. Each record may remove an old one, so a transaction has at
+	// most 128 operations, far below the limits of kv.MaxTxnOps and
+	// kv.MaxTxnBytes.
+	operatorJournalBatchSize = 64
+	// operatorJournalFlushInterval is the interval to save the records.
+	operatorJournalFlushInterval = time.Second
+)
+
+// OperatorRecord is a record of an operator event in the journal.
+type OperatorRecord struct {
+	ID       uint64    `json:"id"`
+	Time     time.Time `json:"time"`
+	RegionID uint64    `json:"region_id"`
+	// Source is the desc of the operator, which tells the scheduler or checker
+	// that creates it.
+	Source string `json:"source"`
+	Kind   string `json:"kind"`
+	Event  string `json:"event"`
+	Step   string `json:"step,omitempty"`
+	Reason string `json:"reason,omitempty"`
+	// Stores are the stores involved in the steps of the operator.
+	Stores []uint64 `json:"stores,omitempty"`
+}
+
+func (r *OperatorRecord) hasStore(storeID uint64) bool {
+	for _, id := range r.Stores {
+		if id == storeID {
+			return true
+		}
+	}
+	return false
+}
+
+// OperatorJournal saves the events of operators to the storage, so that they
+// can be queried after the leader changes. It keeps at most
+// operatorJournalLimit records and the oldest ones are removed. The records
+// are saved in batches, and served from memory once they are saved.
+type OperatorJournal struct {
+	storage *core.Storage
+	records chan *OperatorRecord
+	// dropped is the number of the records dropped since it is last logged.
+	dropped uint64
+
+	mu sync.Mutex
+	// lastSteps is the last step recorded for the operator of each region, so
+	// that a step is only recorded once even if it is sent several times.
+	lastSteps map[uint64]string
+	// ring keeps the latest saved records in the order of ID. It is nil until
+	// the records are loaded from the storage, and no record is saved before
+	// that, so that the IDs are never reused.
+	ring *operatorRecordRing
+}
+
+// NewOperatorJournal creates an OperatorJournal. The records are loaded and
+// saved in background until the context is canceled.
+func NewOperatorJournal(ctx context.Context, storage *core.Storage) *OperatorJournal {
+	j := &OperatorJournal{
+		storage:   storage,
+		records:   make(chan *OperatorRecord, operatorJournalBufferSize),
+		lastSteps: make(map[uint64]string),
+	}
+	go j.run(ctx)
+	return j
+}
+
+func (j *OperatorJournal) run(ctx context.Context) {
+	if !j.load(ctx) {
+		return
+	}
+	ticker := time.NewTicker(operatorJournalFlushInterval)
+	defer ticker.Stop()
+	var pending []*OperatorRecord
+	for {
+		select {
+		case <-ctx.Done():
+			// The pending records are not saved, as the new leader may have
+			// saved records with the same IDs.
+			j.drop(len(pending))
+			j.logDropped()
+			return
+		case r := <-j.records:
+			pending = append(pending, r)
+			if len(pending) >= operatorJournalBatchSize {
+				pending = j.flush(pending)
+			}
+		case <-ticker.C:
+			pending = j.flush(pending)
+			j.logDropped()
+		}
+	}
+}
+
+// load loads the records until it succeeds or the context is canceled.
+func (j *OperatorJournal) load(ctx context.Context) bool {
+	ticker := time.NewTicker(operatorJournalLoadRetryInterval)
+	defer ticker.Stop()
+	for {
+		records, err := j.loadRecords()
+		if err == nil {
+			ring := newOperatorRecordRing(int(operatorJournalLimit))
+			for _, r := range records {
+				ring.push(r)
+			}
+			j.mu.Lock()
+			j.ring = ring
+			j.mu.Unlock()
+			return true
+		}
+		log.Error("failed to load operator records, the records are not saved until it succeeds", errs.ZapError(err))
+		select {
+		case <-ctx.Done():
+			return false
+		case <-ticker.C:
+			j.logDropped()
+		}
+	}
+}
+
+func (j *OperatorJournal) loadRecords() ([]*OperatorRecord, error) {
+	var records []*OperatorRecord
+	err := j.storage.LoadOperatorRecords(func(k, v string) {
+		r := &OperatorRecord{}
+		if err := json.Unmarshal([]byte(v), r); err != nil {
+			log.Error("failed to unmarshal operator record", zap.String("key", k), errs.ZapError(errs.ErrJSONUnmarshal.Wrap(err).FastGenWithCause()))
+			return
+		}
+		records = append(records, r)
+	})
+	return records, err
+}
+
+// flush saves the pending records in batches, along with removing the ones
+// out of the limit. The IDs are allocated when saving, and the records are
+// added to the ring only after they are saved. It returns the records failed
+// to save, which are retried later, and at most operatorJournalBufferSize of
+// them are kept.
+func (j *OperatorJournal) flush(pending []*OperatorRecord) []*OperatorRecord {
+	for len(pending) > 0 {
+		n := len(pending)
+		if n > operatorJournalBatchSize {
+			n = operatorJournalBatchSize
+		}
+		j.mu.Lock()
+		id := j.ring.lastID
+		j.mu.Unlock()
+		batch := j.storage.NewBatch()
+		saved := make([]*OperatorRecord, 0, n)
+		for _, r := range pending[:n] {
+			r.ID = id + 1
+			if err := batch.SaveOperatorRecord(r.ID, r); err != nil {
+				log.Error("failed to save operator record", zap.Uint64("region-id", r.RegionID), errs.ZapError(err))
+				r.ID = 0
+				j.drop(1)
+				continue
+			}
+			id = r.ID
+			if r.ID > operatorJournalLimit {
+				batch.DeleteOperatorRecord(r.ID - operatorJournalLimit)
+			}
+			saved = append(saved, r)
+		}
+		if err := batch.Commit(); err != nil {
+			log.Error("failed to save operator records", zap.Int("count", n), errs.ZapError(err))
+			for _, r := range saved {
+				r.ID = 0
+			}
+			pending = append(saved, pending[n:]...)
+			if over := len(pending) - operatorJournalBufferSize; over > 0 {
+				j.drop(over)
+				pending = pending[over:]
+			}
+			return pending
+		}
+		j.mu.Lock()
+		for _, r := range saved {
+			j.ring.push(r)
+		}
+		j.mu.Unlock()
+		pending = pending[n:]
+	}
+	return nil
+}
+
+func (j *OperatorJournal) drop(n int) {
+	atomic.AddUint64(&j.dropped, uint64(n))
+	operatorJournalDroppedCounter.Add(float64(n))
+}
+
+func (j *OperatorJournal) logDropped() {
+	if n := atomic.SwapUint64(&j.dropped, 0); n > 0 {
+		log.Warn("operator journal dropped records", zap.Uint64("count", n))
+	}
+}
+
+// GetRecords returns the records in the order of ID. regionID and storeID
+// are ignored if they are 0.
+func (j *OperatorJournal) GetRecords(regionID, storeID uint64, since time.Time) ([]*OperatorRecord, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.ring == nil {
+		return nil, errs.ErrOperatorJournalNotLoaded.FastGenByArgs()
+	}
+	ret := make([]*OperatorRecord, 0)
+	j.ring.forEach(func(r *OperatorRecord) {
+		if (regionID != 0 && r.RegionID != regionID) ||
+			(storeID != 0 && !r.hasStore(storeID)) ||
+			r.Time.Before(since) {
+			return
+		}
+		ret = append(ret, r)
+	})
+	return ret, nil
+}
+
+func (j *OperatorJournal) onCreate(op *operator.Operator) {
+	if j == nil {
+		return
+	}
+	j.put(op, OperatorEventCreate, "", "")
+}
+
+func (j *OperatorJournal) onStep(op *operator.Operator, step operator.OpStep) {
+	if j == nil || step == nil {
+		return
+	}
+	s := step.String()
+	j.mu.Lock()
+	if j.lastSteps[op.RegionID()] == s {
+		j.mu.Unlock()
+		return
+	}
+	j.lastSteps[op.RegionID()] = s
+	j.mu.Unlock()
+	j.put(op, OperatorEventStep, s, "")
+}
+
+func (j *OperatorJournal) onEnd(op *operator.Operator, reason string) {
+	if j == nil {
+		return
+	}
+	j.mu.Lock()
+	delete(j.lastSteps, op.RegionID())
+	j.mu.Unlock()
+
+	var event string
+	switch op.Status() {
+	case operator.SUCCESS:
+		event = OperatorEventFinish
+	case operator.TIMEOUT:
+		event = OperatorEventTimeout
+	case operator.REPLACED:
+		event = OperatorEventReplace
+	case operator.EXPIRED:
+		event = OperatorEventExpire
+	default:
+		event = OperatorEventCancel
+	}
+	j.put(op, event, "", reason)
+}
+
+func (j *OperatorJournal) put(op *operator.Operator, event, step, reason string) {
+	r := &OperatorRecord{
+		Time:     time.Now(),
+		RegionID: op.RegionID(),
+		Source:   op.Desc(),
+		Kind:     op.Kind().String(),
+		Event:    event,
+		Step:     step,
+		Reason:   reason,
+		Stores:   operatorStores(op),
+	}
+	select {
+	case j.records <- r:
+	default:
+		j.drop(1)
+	}
+}
+
+// operatorRecordRing keeps the latest records in the order of ID.
+type operatorRecordRing struct {
+	records []*OperatorRecord
+	// start is the index of the oldest record when the ring is full.
+	start  int
+	lastID uint64
+}
+
+func newOperatorRecordRing(size int) *operatorRecordRing {
+	return &operatorRecordRing{records: make([]*OperatorRecord, 0, size)}
+}
+
+// push adds a record to the ring, and the oldest one is removed if the ring
+// is full.
+func (r *operatorRecordRing) push(record *OperatorRecord) {
+	r.lastID = record.ID
+	if len(r.records) < cap(r.records) {
+		r.records = append(r.records, record)
+		return
+	}
+	r.records[r.start] = record
+	r.start = (r.start + 1) % len(r.records)
+}
+
+func (r *operatorRecordRing) forEach(f func(*OperatorRecord)) {
+	for i := range r.records {
+		f(r.records[(r.start+i)%len(r.records)])
+	}
+}
+
+// operatorStores returns the stores involved in the steps of the operator.
+func operatorStores(op *operator.Operator) []uint64 {
+	stores := make(map[uint64]struct{})
+	add := func(ids ...uint64) {
+		for _, id := range ids {
+			stores[id] = struct{}{}
+		}
+	}
+	for i := 0; i < op.Len(); i++ {
+		switch st := op.Step(i).(type) {
+		case operator.TransferLeader:
+			add(st.FromStore, st.ToStore)
+		case operator.AddPeer:
+			add(st.ToStore)
+		case operator.AddLearner:
+			add(st.ToStore)
+		case operator.AddLightPeer:
+			add(st.ToStore)
+		case operator.AddLightLearner:
+			add(st.ToStore)
+		case operator.PromoteLearner:
+			add(st.ToStore)
+		case operator.DemoteFollower:
+			add(st.ToStore)
+		case operator.RemovePeer:
+			add(st.FromStore)
+		case operator.ChangePeerV2Enter:
+			for _, pl := range st.PromoteLearners {
+				add(pl.ToStore)
+			}
+			for _, dv := range st.DemoteVoters {
+				add(dv.ToStore)
+			}
+		case operator.ChangePeerV2Leave:
+			for _, pl := range st.PromoteLearners {
+				add(pl.ToStore)
+			}
+			for _, dv := range st.DemoteVoters {
+				add(dv.ToStore)
+			}
+		}
+	}
+	ids := make([]uint64, 0, len(stores))
+	for id := range stores {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, k int) bool { return ids[i] < ids[k] })
+	return ids
+}
@@ -0,0 +1,232 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedule
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"sync/atomic"
+	"time"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/errors"
+	promtestutil "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/tikv/pd/pkg/errs"
+	"github.com/tikv/pd/pkg/mock/mockcluster"
+	"github.com/tikv/pd/pkg/testutil"
+	"github.com/tikv/pd/server/config"
+	"github.com/tikv/pd/server/core"
+	"github.com/tikv/pd/server/kv"
+	"github.com/tikv/pd/server/schedule/hbstream"
+	"github.com/tikv/pd/server/schedule/operator"
+)
+
+var _ = Suite(&testOperatorJournalSuite{})
+
+type testOperatorJournalSuite struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func (s *testOperatorJournalSuite) SetUpTest(c *C) {
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+}
+
+func (s *testOperatorJournalSuite) TearDownTest(c *C) {
+	s.cancel()
+}
+
+func (s *testOperatorJournalSuite) mustGetRecords(c *C, j *OperatorJournal, regionID, storeID uint64, since time.Time, n int) []*OperatorRecord {
+	var records []*OperatorRecord
+	testutil.WaitUntil(c, func(c *C) bool {
+		var err error
+		records, err = j.GetRecords(regionID, storeID, since)
+		if errs.ErrOperatorJournalNotLoaded.Equal(err) {
+			return false
+		}
+		c.Assert(err, IsNil)
+		return len(records) == n
+	})
+	return records
+}
+
+func (s *testOperatorJournalSuite) waitSaved(c *C, storage *core.Storage, ids ...uint64) {
+	testutil.WaitUntil(c, func(c *C) bool {
+		var saved []uint64
+		err := storage.LoadOperatorRecords(func(k, v string) {
+			r := &OperatorRecord{}
+			c.Assert(json.Unmarshal([]byte(v), r), IsNil)
+			saved = append(saved, r.ID)
+		})
+		c.Assert(err, IsNil)
+		return reflect.DeepEqual(saved, ids)
+	})
+}
+
+func (s *testOperatorJournalSuite) TestOperatorJournal(c *C) {
+	opt := config.NewTestOptions()
+	tc := mockcluster.NewCluster(opt)
+	stream := hbstream.NewTestHeartbeatStreams(s.ctx, tc.ID, tc, false /* no need to run */)
+	oc := NewOperatorController(s.ctx, tc, stream)
+	storage := core.NewStorage(kv.NewMemoryKV())
+	journal := NewOperatorJournal(s.ctx, storage)
+	oc.SetOperatorJournal(journal)
+	tc.AddLeaderStore(1, 2)
+	tc.AddLeaderStore(2, 0)
+	tc.AddLeaderStore(3, 0)
+	tc.AddLeaderRegion(1, 1, 2)
+	tc.AddLeaderRegion(2, 1, 2)
+
+	// The operator of region 1 finishes.
+	op1 := operator.NewOperator("test", "test", 1, tc.GetRegion(1).GetRegionEpoch(), operator.OpRegion,
+		operator.AddPeer{ToStore: 3, PeerID: 4},
+		operator.RemovePeer{FromStore: 2},
+	)
+	c.Assert(oc.AddOperator(op1), IsTrue)
+	// The step is only recorded once.
+	oc.Dispatch(tc.GetRegion(1), DispatchFromHeartBeat)
+	region := ApplyOperatorStep(tc.GetRegion(1), op1)
+	tc.PutRegion(region)
+	oc.Dispatch(region, DispatchFromHeartBeat)
+	ApplyOperator(tc, op1)
+	oc.Dispatch(tc.GetRegion(1), DispatchFromHeartBeat)
+	c.Assert(op1.Status(), Equals, operator.SUCCESS)
+
+	// The operator of region 2 is canceled.
+	op2 := operator.NewOperator("test", "test", 2, tc.GetRegion(2).GetRegionEpoch(), operator.OpLeader,
+		operator.TransferLeader{FromStore: 1, ToStore: 2},
+	)
+	c.Assert(oc.AddOperator(op2), IsTrue)
+	c.Assert(oc.RemoveOperator(op2), IsTrue)
+
+	records := s.mustGetRecords(c, journal, 1, 0, time.Time{}, 4)
+	events := make([]string, 0, len(records))
+	for _, r := range records {
+		c.Assert(r.Source, Equals, "test")
+		c.Assert(r.Stores, DeepEquals, []uint64{2, 3})
+		events = append(events, r.Event)
+	}
+	c.Assert(events, DeepEquals, []string{OperatorEventCreate, OperatorEventStep, OperatorEventStep, OperatorEventFinish})
+	c.Assert(records[1].Step, Equals, op1.Step(0).String())
+	c.Assert(records[2].Step, Equals, op1.Step(1).String())
+
+	records = s.mustGetRecords(c, journal, 0, 1, time.Time{}, 3)
+	c.Assert(records[2].RegionID, Equals, uint64(2))
+	c.Assert(records[2].Event, Equals, OperatorEventCancel)
+	s.mustGetRecords(c, journal, 0, 3, time.Time{}, 4)
+	s.mustGetRecords(c, journal, 0, 0, time.Now().Add(time.Minute), 0)
+
+	// The records are kept after the journal is recreated.
+	s.waitSaved(c, storage, 1, 2, 3, 4, 5, 6, 7)
+	s.cancel()
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+	journal = NewOperatorJournal(s.ctx, storage)
+	s.mustGetRecords(c, journal, 0, 0, time.Time{}, 7)
+	journal.onCreate(op2)
+	records = s.mustGetRecords(c, journal, 0, 0, time.Time{}, 8)
+	c.Assert(records[7].ID, Equals, uint64(8))
+}
+
+func (s *testOperatorJournalSuite) TestLimit(c *C) {
+	defer func(limit uint64) { operatorJournalLimit = limit }(operatorJournalLimit)
+	operatorJournalLimit = 3
+	storage := core.NewStorage(kv.NewMemoryKV())
+	journal := NewOperatorJournal(s.ctx, storage)
+	op := operator.NewOperator("test", "test", 1, nil, operator.OpLeader, operator.TransferLeader{FromStore: 1, ToStore: 2})
+	for i := 0; i < 5; i++ {
+		journal.onCreate(op)
+	}
+	records := s.mustGetRecords(c, journal, 0, 0, time.Time{}, 3)
+	c.Assert(records[0].ID, Equals, uint64(3))
+	s.waitSaved(c, storage, 3, 4, 5)
+}
+
+type failedLoadKV struct {
+	kv.Base
+	failed int32
+}
+
+func (kv *failedLoadKV) LoadRange(key, endKey string, limit int) ([]string, []string, error) {
+	if atomic.LoadInt32(&kv.failed) != 0 {
+		return nil, nil, errors.New("load failed")
+	}
+	return kv.Base.LoadRange(key, endKey, limit)
+}
+
+func (s *testOperatorJournalSuite) TestLoadFailure(c *C) {
+	defer func(interval time.Duration) { operatorJournalLoadRetryInterval = interval }(operatorJournalLoadRetryInterval)
+	operatorJournalLoadRetryInterval = 10 * time.Millisecond
+	base := &failedLoadKV{Base: kv.NewMemoryKV()}
+	storage := core.NewStorage(base)
+	op := operator.NewOperator("test", "test", 1, nil, operator.OpLeader, operator.TransferLeader{FromStore: 1, ToStore: 2})
+	c.Assert(storage.SaveOperatorRecord(1, &OperatorRecord{ID: 1}), IsNil)
+
+	// Nothing is saved before the records are loaded, and the records out of
+	// the buffer are dropped.
+	atomic.StoreInt32(&base.failed, 1)
+	dropped := promtestutil.ToFloat64(operatorJournalDroppedCounter)
+	journal := NewOperatorJournal(s.ctx, storage)
+	for i := 0; i < operatorJournalBufferSize+2; i++ {
+		journal.onCreate(op)
+	}
+	_, err := journal.GetRecords(0, 0, time.Time{})
+	c.Assert(errs.ErrOperatorJournalNotLoaded.Equal(err), IsTrue)
+	c.Assert(promtestutil.ToFloat64(operatorJournalDroppedCounter)-dropped, Equals, 2.0)
+	time.Sleep(5 * operatorJournalLoadRetryInterval)
+	s.waitSaved(c, core.NewStorage(base.Base), 1)
+
+	// The IDs continue after the loaded records.
+	atomic.StoreInt32(&base.failed, 0)
+	records := s.mustGetRecords(c, journal, 0, 0, time.Time{}, operatorJournalBufferSize+1)
+	c.Assert(records[1].ID, Equals, uint64(2))
+}
+
+type failedSaveKV struct {
+	kv.Base
+	failed int32
+}
+
+func (kv *failedSaveKV) Txn(conds []kv.Condition, ops []kv.Op) (bool, error) {
+	if atomic.LoadInt32(&kv.failed) != 0 {
+		return false, errors.New("save failed")
+	}
+	return kv.Base.Txn(conds, ops)
+}
+
+func (s *testOperatorJournalSuite) TestSaveFailure(c *C) {
+	base := &failedSaveKV{Base: kv.NewMemoryKV()}
+	storage := core.NewStorage(base)
+	journal := NewOperatorJournal(s.ctx, storage)
+	op := operator.NewOperator("test", "test", 1, nil, operator.OpLeader, operator.TransferLeader{FromStore: 1, ToStore: 2})
+	journal.onCreate(op)
+	s.mustGetRecords(c, journal, 0, 0, time.Time{}, 1)
+
+	// The records failed to save are not served, and their IDs are not used.
+	atomic.StoreInt32(&base.failed, 1)
+	journal.onCreate(op)
+	journal.onCreate(op)
+	time.Sleep(2 * operatorJournalFlushInterval)
+	records, err := journal.GetRecords(0, 0, time.Time{})
+	c.Assert(err, IsNil)
+	c.Assert(records, HasLen, 1)
+	s.waitSaved(c, core.NewStorage(base.Base), 1)
+
+	// The records are saved with the next IDs once the storage recovers.
+	atomic.StoreInt32(&base.failed, 0)
+	records = s.mustGetRecords(c, journal, 0, 0, time.Time{}, 3)
+	c.Assert(records[1].ID, Equals, uint64(2))
+	c.Assert(records[2].ID, Equals, uint64(3))
+	s.waitSaved(c, storage, 1, 2, 3)
+}
@@ -13,7 +13,10 @@
 
 package opt
 
-import "github.com/tikv/pd/server/core"
+import (
+	"github.com/tikv/pd/server/core"
+	"github.com/tikv/pd/server/schedule/labeler"
+)
 
 // IsRegionHealthy checks if a region is healthy for scheduling. It requires the
 // region does not have any down or pending peers. And when placement rules
@@ -59,3 +62,23 @@ func IsRegionReplicated(cluster Cluster, region *core.RegionInfo) bool {
 func ReplicatedRegion(cluster Cluster) func(*core.RegionInfo) bool {
 	return func(region *core.RegionInfo) bool { return IsRegionReplicated(cluster, region) }
 }
+
+// IsRegionScheduleAllowed checks if a region is allowed to be moved by the
+// balance schedulers. It returns false if the region is labeled with
+// `schedule=deny` by the region labeler.
+func IsRegionScheduleAllowed(cluster Cluster, region *core.RegionInfo) bool {
+	return cluster.GetRegionLabeler().GetRegionLabel(region, labeler.ScheduleOptionKey) != labeler.ScheduleOptionValueDeny
+}
+
+// ScheduleAllowedRegion returns a function that checks if a region is allowed
+// to be moved by the balance schedulers.
+func ScheduleAllowedRegion(cluster Cluster) func(*core.RegionInfo) bool {
+	return func(region *core.RegionInfo) bool { return IsRegionScheduleAllowed(cluster, region) }
+}
+
+// NonWitnessRegion returns a function that checks if the peer of a region on
+// the store is not a witness. The witnesses do not hold data, so they are
+// excluded from the balance schedulers.
+func NonWitnessRegion(storeID uint64) func(*core.RegionInfo) bool {
+	return func(region *core.RegionInfo) bool { return region.GetStoreWitness(storeID) == nil }
+}
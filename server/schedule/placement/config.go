@@ -16,18 +16,22 @@ package placement
 import (
 	"bytes"
 	"encoding/json"
+
+	"github.com/tikv/pd/pkg/errs"
 )
 
-// ruleConfig contains rule and rule group configurations.
+// ruleConfig contains rule, rule group and rule template configurations.
 type ruleConfig struct {
-	rules  map[[2]string]*Rule   // {group, id} => Rule
-	groups map[string]*RuleGroup // id => RuleGroup
+	rules     map[[2]string]*Rule      // {group, id} => Rule
+	groups    map[string]*RuleGroup    // id => RuleGroup
+	templates map[string]*RuleTemplate // name => RuleTemplate
 }
 
 func newRuleConfig() *ruleConfig {
 	return &ruleConfig{
-		rules:  make(map[[2]string]*Rule),
-		groups: make(map[string]*RuleGroup),
+		rules:     make(map[[2]string]*Rule),
+		groups:    make(map[string]*RuleGroup),
+		templates: make(map[string]*RuleTemplate),
 	}
 }
 
@@ -77,6 +81,10 @@ func (c *ruleConfig) getGroup(id string) *RuleGroup {
 	return &RuleGroup{ID: id}
 }
 
+func (c *ruleConfig) getTemplate(name string) *RuleTemplate {
+	return c.templates[name]
+}
+
 func (c *ruleConfig) beginPatch() *ruleConfigPatch {
 	return &ruleConfigPatch{
 		c:   c,
@@ -116,6 +124,21 @@ func (p *ruleConfigPatch) deleteGroup(id string) {
 	p.setGroup(&RuleGroup{ID: id})
 }
 
+func (p *ruleConfigPatch) getTemplate(name string) *RuleTemplate {
+	if t, ok := p.mut.templates[name]; ok {
+		return t // nil means delete.
+	}
+	return p.c.templates[name]
+}
+
+func (p *ruleConfigPatch) setTemplate(t *RuleTemplate) {
+	p.mut.templates[t.Name] = t
+}
+
+func (p *ruleConfigPatch) deleteTemplate(name string) {
+	p.mut.templates[name] = nil
+}
+
 func (p *ruleConfigPatch) iterateRules(f func(*Rule)) {
 	for _, r := range p.mut.rules {
 		if r != nil { // nil means delete.
@@ -129,6 +152,38 @@ func (p *ruleConfigPatch) iterateRules(f func(*Rule)) {
 	}
 }
 
+// applyTemplates derives the rules that reference templates. The rules which
+// are changed by the templates are added to the patch.
+func (p *ruleConfigPatch) applyTemplates() error {
+	for _, g := range p.mut.groups {
+		if g.Template != "" && p.getTemplate(g.Template) == nil {
+			return errs.ErrRuleTemplateNotFound.FastGenByArgs(g.Template)
+		}
+	}
+	var derived []*Rule
+	var err error
+	p.iterateRules(func(r *Rule) {
+		if r.Template == "" || err != nil {
+			return
+		}
+		t := p.getTemplate(r.Template)
+		if t == nil {
+			err = errs.ErrRuleTemplateNotFound.FastGenByArgs(r.Template)
+			return
+		}
+		if nr := t.applyTo(r); !jsonEquals(nr, r) {
+			derived = append(derived, nr)
+		}
+	})
+	if err != nil {
+		return err
+	}
+	for _, r := range derived {
+		p.setRule(r)
+	}
+	return nil
+}
+
 func (p *ruleConfigPatch) adjust() {
 	// setup rule.group for `buildRuleList` use.
 	p.iterateRules(func(r *Rule) { r.group = p.getGroup(r.GroupID) })
@@ -146,6 +201,11 @@ func (p *ruleConfigPatch) trim() {
 			delete(p.mut.groups, id)
 		}
 	}
+	for name, template := range p.mut.templates {
+		if jsonEquals(template, p.c.getTemplate(name)) {
+			delete(p.mut.templates, name)
+		}
+	}
 }
 
 // merge all mutations to ruleConfig.
@@ -160,6 +220,13 @@ func (p *ruleConfigPatch) commit() {
 	for id, group := range p.mut.groups {
 		p.c.groups[id] = group
 	}
+	for name, template := range p.mut.templates {
+		if template == nil {
+			delete(p.c.templates, name)
+		} else {
+			p.c.templates[name] = template
+		}
+	}
 	p.c.adjust()
 }
 
@@ -55,6 +55,27 @@ func (f *RegionFit) GetRuleFit(peerID uint64) *RuleFit {
 	return nil
 }
 
+// GetWitnesses returns the peers which are fitted to the witness rules with
+// the right role.
+func (f *RegionFit) GetWitnesses() []*metapb.Peer {
+	var witnesses []*metapb.Peer
+	for _, rf := range f.RuleFits {
+		if rf.Rule.Role != Witness {
+			continue
+		}
+	nextPeer:
+		for _, p := range rf.Peers {
+			for _, dp := range rf.PeersWithDifferentRole {
+				if dp.GetId() == p.GetId() {
+					continue nextPeer
+				}
+			}
+			witnesses = append(witnesses, p)
+		}
+	}
+	return witnesses
+}
+
 // CompareRegionFit determines the superiority of 2 fits.
 // It returns 1 when the first fit result is better.
 func CompareRegionFit(a, b *RegionFit) int {
@@ -268,7 +289,7 @@ func (p *fitPeer) matchRoleStrict(role PeerRoleType) bool {
 		return !core.IsLearner(p.Peer)
 	case Leader:
 		return p.isLeader
-	case Follower:
+	case Follower, Witness: // Witness is a follower without data.
 		return !core.IsLearner(p.Peer) && !p.isLeader
 	case Learner:
 		return core.IsLearner(p.Peer)
@@ -187,3 +187,22 @@ func (s *testFitSuite) TestIsolationScore(c *C) {
 		c.Assert(score1, tc.Checker, score2)
 	}
 }
+
+func (s *testFitSuite) TestWitness(c *C) {
+	stores := s.makeStores()
+	rules := []*Rule{s.makeRule("2/voter/zone=zone1+zone2/"), s.makeRule("1/witness/zone=zone3/")}
+
+	rf := FitRegion(stores, s.makeRegion("1111_leader,2111,3111"), rules)
+	c.Assert(rf.IsSatisfied(), IsTrue)
+	c.Assert(s.checkPeerMatch(rf.GetWitnesses(), "3111"), IsTrue)
+
+	// The leader cannot be a witness.
+	rf = FitRegion(stores, s.makeRegion("1111,2111,3111_leader"), rules)
+	c.Assert(rf.IsSatisfied(), IsFalse)
+	c.Assert(s.checkPeerMatch(rf.RuleFits[1].PeersWithDifferentRole, "3111"), IsTrue)
+	c.Assert(rf.GetWitnesses(), HasLen, 0)
+
+	// The learner cannot be a witness.
+	rf = FitRegion(stores, s.makeRegion("1111_leader,2111,3111_learner"), rules)
+	c.Assert(rf.GetWitnesses(), HasLen, 0)
+}
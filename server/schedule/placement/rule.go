@@ -33,10 +33,13 @@ const (
 	Follower PeerRoleType = "follower"
 	// Learner matches a learner.
 	Learner PeerRoleType = "learner"
+	// Witness matches a follower which votes but does not hold data. It never
+	// becomes the leader and is excluded from the region size of the store.
+	Witness PeerRoleType = "witness"
 )
 
 func validateRole(s PeerRoleType) bool {
-	return s == Voter || s == Leader || s == Follower || s == Learner
+	return s == Voter || s == Leader || s == Follower || s == Learner || s == Witness
 }
 
 // MetaPeerRole converts placement.PeerRoleType to metapb.PeerRole.
@@ -59,6 +62,7 @@ type Rule struct {
 	StartKeyHex      string            `json:"start_key"`                   // hex format start key, for marshal/unmarshal
 	EndKey           []byte            `json:"-"`                           // range end key
 	EndKeyHex        string            `json:"end_key"`                     // hex format end key, for marshal/unmarshal
+	Template         string            `json:"template,omitempty"`          // name of the template that the fields below are derived from
 	Role             PeerRoleType      `json:"role"`                        // expected role of the peers
 	Count            int               `json:"count"`                       // expected count of the peers
 	LabelConstraints []LabelConstraint `json:"label_constraints,omitempty"` // used to select stores to place peers
@@ -95,10 +99,13 @@ type RuleGroup struct {
 	ID       string `json:"id,omitempty"`
 	Index    int    `json:"index,omitempty"`
 	Override bool   `json:"override,omitempty"`
+	// Template is the template referenced by the rules in the bundle of the
+	// group that do not specify one.
+	Template string `json:"template,omitempty"`
 }
 
 func (g *RuleGroup) isDefault() bool {
-	return g.Index == 0 && !g.Override
+	return g.Index == 0 && !g.Override && g.Template == ""
 }
 
 func (g *RuleGroup) String() string {
@@ -170,6 +177,7 @@ type GroupBundle struct {
 	ID       string  `json:"group_id"`
 	Index    int     `json:"group_index"`
 	Override bool    `json:"group_override"`
+	Template string  `json:"group_template,omitempty"`
 	Rules    []*Rule `json:"rules"`
 }
 
@@ -37,6 +37,8 @@ type RuleManager struct {
 	initialized bool
 	ruleConfig  *ruleConfig
 	ruleList    ruleList
+	// version is increased once the rules change.
+	version uint64
 }
 
 // NewRuleManager creates a RuleManager instance.
@@ -56,6 +58,9 @@ func (m *RuleManager) Initialize(maxReplica int, locationLabels []string) error
 		return nil
 	}
 
+	if err := m.loadTemplates(); err != nil {
+		return err
+	}
 	if err := m.loadRules(); err != nil {
 		return err
 	}
@@ -82,6 +87,7 @@ func (m *RuleManager) Initialize(maxReplica int, locationLabels []string) error
 		return err
 	}
 	m.ruleList = ruleList
+	m.version++
 	m.initialized = true
 	return nil
 }
@@ -140,7 +146,19 @@ func (m *RuleManager) loadGroups() error {
 	})
 }
 
-// check and adjust rule from client or storage.
+func (m *RuleManager) loadTemplates() error {
+	return m.store.LoadRuleTemplates(func(k, v string) {
+		var t RuleTemplate
+		if err := json.Unmarshal([]byte(v), &t); err != nil {
+			log.Error("failed to unmarshal rule template", zap.String("template-name", k), errs.ZapError(errs.ErrLoadRuleTemplate, err))
+			return
+		}
+		m.ruleConfig.templates[t.Name] = &t
+	})
+}
+
+// check and adjust rule from client or storage. The content of the rule that
+// references a template is checked after it is derived from the template.
 func (m *RuleManager) adjustRule(r *Rule) error {
 	var err error
 	r.StartKey, err = hex.DecodeString(r.StartKeyHex)
@@ -160,21 +178,10 @@ func (m *RuleManager) adjustRule(r *Rule) error {
 	if r.ID == "" {
 		return errs.ErrRuleContent.FastGenByArgs("ID should not be empty")
 	}
-	if !validateRole(r.Role) {
-		return errs.ErrRuleContent.FastGenByArgs(fmt.Sprintf("invalid role %s", r.Role))
-	}
-	if r.Count <= 0 {
-		return errs.ErrRuleContent.FastGenByArgs(fmt.Sprintf("invalid count %d", r.Count))
-	}
-	if r.Role == Leader && r.Count > 1 {
-		return errs.ErrRuleContent.FastGenByArgs(fmt.Sprintf("define multiple leaders by count %d", r.Count))
-	}
-	for _, c := range r.LabelConstraints {
-		if !validateOp(c.Op) {
-			return errs.ErrRuleContent.FastGenByArgs(fmt.Sprintf("invalid op %s", c.Op))
-		}
+	if r.Template != "" {
+		return nil
 	}
-	return nil
+	return checkRuleContent(r.Role, r.Count, r.LabelConstraints)
 }
 
 // GetRule returns the Rule with the same (group, id).
@@ -220,6 +227,26 @@ func (m *RuleManager) GetSplitKeys(start, end []byte) [][]byte {
 	return m.ruleList.getSplitKeys(start, end)
 }
 
+// HasWitnessRule returns true if there is any rule with the witness role.
+func (m *RuleManager) HasWitnessRule() bool {
+	m.RLock()
+	defer m.RUnlock()
+	for _, r := range m.ruleConfig.rules {
+		if r.Role == Witness {
+			return true
+		}
+	}
+	return false
+}
+
+// GetVersion returns the version of the rules, which is increased once the
+// rules change.
+func (m *RuleManager) GetVersion() uint64 {
+	m.RLock()
+	defer m.RUnlock()
+	return m.version
+}
+
 // GetAllRules returns sorted all rules.
 func (m *RuleManager) GetAllRules() []*Rule {
 	m.RLock()
@@ -271,6 +298,9 @@ func (m *RuleManager) beginPatch() *ruleConfigPatch {
 }
 
 func (m *RuleManager) tryCommitPatch(patch *ruleConfigPatch) error {
+	if err := patch.applyTemplates(); err != nil {
+		return err
+	}
 	patch.adjust()
 
 	ruleList, err := buildRuleList(patch)
@@ -289,39 +319,37 @@ func (m *RuleManager) tryCommitPatch(patch *ruleConfigPatch) error {
 	// update in-memory state
 	patch.commit()
 	m.ruleList = ruleList
+	m.version++
 	return nil
 }
 
 func (m *RuleManager) savePatch(p *ruleConfig) error {
-	// TODO: it is not completely safe
-	// 1. in case that half of rules applied, error.. we have to cancel persisted rules
-	// but that may fail too, causing memory/disk inconsistency
-	// either rely a transaction API, or clients to request again until success
-	// 2. in case that PD is suddenly down in the loop, inconsistency again
-	// now we can only rely clients to request again
-	var err error
+	// Save all updates in one batch, so that the rules, groups and templates
+	// in storage are always consistent with each other.
+	batch := m.store.NewBatch()
 	for key, r := range p.rules {
 		if r == nil {
 			r = &Rule{GroupID: key[0], ID: key[1]}
-			err = m.store.DeleteRule(r.StoreKey())
-		} else {
-			err = m.store.SaveRule(r.StoreKey(), r)
-		}
-		if err != nil {
+			batch.DeleteRule(r.StoreKey())
+		} else if err := batch.SaveRule(r.StoreKey(), r); err != nil {
 			return err
 		}
 	}
 	for id, g := range p.groups {
 		if g.isDefault() {
-			err = m.store.DeleteRuleGroup(id)
-		} else {
-			err = m.store.SaveRuleGroup(id, g)
+			batch.DeleteRuleGroup(id)
+		} else if err := batch.SaveRuleGroup(id, g); err != nil {
+			return err
 		}
-		if err != nil {
+	}
+	for name, t := range p.templates {
+		if t == nil {
+			batch.DeleteRuleTemplate(name)
+		} else if err := batch.SaveRuleTemplate(name, t); err != nil {
 			return err
 		}
 	}
-	return nil
+	return batch.Commit()
 }
 
 // SetRules inserts or updates lots of Rules at once.
@@ -466,6 +494,7 @@ func (m *RuleManager) GetAllGroupBundles() []GroupBundle {
 			ID:       g.ID,
 			Index:    g.Index,
 			Override: g.Override,
+			Template: g.Template,
 		})
 	}
 	for _, r := range m.ruleConfig.rules {
@@ -491,7 +520,7 @@ func (m *RuleManager) GetGroupBundle(id string) (b GroupBundle) {
 	defer m.RUnlock()
 	b.ID = id
 	if g := m.ruleConfig.groups[id]; g != nil {
-		b.Index, b.Override = g.Index, g.Override
+		b.Index, b.Override, b.Template = g.Index, g.Override, g.Template
 		for _, r := range m.ruleConfig.rules {
 			if r.GroupID == id {
 				b.Rules = append(b.Rules, r)
@@ -530,8 +559,12 @@ func (m *RuleManager) SetAllGroupBundles(groups []GroupBundle, override bool) er
 			ID:       g.ID,
 			Index:    g.Index,
 			Override: g.Override,
+			Template: g.Template,
 		})
 		for _, r := range g.Rules {
+			if r.Template == "" {
+				r.Template = g.Template
+			}
 			if err := m.adjustRule(r); err != nil {
 				return err
 			}
@@ -562,8 +595,12 @@ func (m *RuleManager) SetGroupBundle(group GroupBundle) error {
 		ID:       group.ID,
 		Index:    group.Index,
 		Override: group.Override,
+		Template: group.Template,
 	})
 	for _, r := range group.Rules {
+		if r.Template == "" {
+			r.Template = group.Template
+		}
 		if err := m.adjustRule(r); err != nil {
 			return err
 		}
@@ -608,6 +645,101 @@ func (m *RuleManager) DeleteGroupBundle(id string, regex bool) error {
 	return nil
 }
 
+// GetRuleTemplate returns the RuleTemplate with the name.
+func (m *RuleManager) GetRuleTemplate(name string) *RuleTemplate {
+	m.RLock()
+	defer m.RUnlock()
+	return m.ruleConfig.getTemplate(name)
+}
+
+// GetRuleTemplates returns all RuleTemplates sorted by name.
+func (m *RuleManager) GetRuleTemplates() []*RuleTemplate {
+	m.RLock()
+	defer m.RUnlock()
+	templates := make([]*RuleTemplate, 0, len(m.ruleConfig.templates))
+	for _, t := range m.ruleConfig.templates {
+		templates = append(templates, t)
+	}
+	sort.Slice(templates, func(i, j int) bool { return templates[i].Name < templates[j].Name })
+	return templates
+}
+
+// GetRulesByTemplate returns sorted rules that reference the template.
+func (m *RuleManager) GetRulesByTemplate(name string) []*Rule {
+	m.RLock()
+	defer m.RUnlock()
+	var rules []*Rule
+	for _, r := range m.ruleConfig.rules {
+		if r.Template == name {
+			rules = append(rules, r)
+		}
+	}
+	sortRules(rules)
+	return rules
+}
+
+// SetRuleTemplate inserts or updates a RuleTemplate. All rules referencing the
+// template are derived from it again in the same patch. If stores is not nil,
+// the derived rules are rejected if there are not enough stores matching them.
+func (m *RuleManager) SetRuleTemplate(template *RuleTemplate, stores StoreSet) error {
+	if err := template.validate(); err != nil {
+		return err
+	}
+	m.Lock()
+	defer m.Unlock()
+	p := m.beginPatch()
+	p.setTemplate(template)
+	if err := p.applyTemplates(); err != nil {
+		return err
+	}
+	if stores != nil {
+		// Checks the rules in order so that the error is stable.
+		keys := make([][2]string, 0, len(p.mut.rules))
+		for key, r := range p.mut.rules {
+			if r != nil {
+				keys = append(keys, key)
+			}
+		}
+		sort.Slice(keys, func(i, j int) bool {
+			return keys[i][0] < keys[j][0] || (keys[i][0] == keys[j][0] && keys[i][1] < keys[j][1])
+		})
+		for _, key := range keys {
+			if err := checkRuleFit(p.mut.rules[key], stores); err != nil {
+				return err
+			}
+		}
+	}
+	if err := m.tryCommitPatch(p); err != nil {
+		return err
+	}
+	log.Info("rule template updated", zap.String("template", fmt.Sprint(template)))
+	return nil
+}
+
+// DeleteRuleTemplate removes a RuleTemplate. It fails if the template is still
+// referenced by any rule or rule group.
+func (m *RuleManager) DeleteRuleTemplate(name string) error {
+	m.Lock()
+	defer m.Unlock()
+	for _, r := range m.ruleConfig.rules {
+		if r.Template == name {
+			return errs.ErrRuleTemplateInUse.FastGenByArgs(name, fmt.Sprintf("rule %s/%s", r.GroupID, r.ID))
+		}
+	}
+	for _, g := range m.ruleConfig.groups {
+		if g.Template == name {
+			return errs.ErrRuleTemplateInUse.FastGenByArgs(name, fmt.Sprintf("group %s", g.ID))
+		}
+	}
+	p := m.beginPatch()
+	p.deleteTemplate(name)
+	if err := m.tryCommitPatch(p); err != nil {
+		return err
+	}
+	log.Info("rule template is removed", zap.String("template", name))
+	return nil
+}
+
 // IsInitialized returns whether the rule manager is initialized.
 func (m *RuleManager) IsInitialized() bool {
 	m.RLock()
@@ -275,6 +275,65 @@ func (s *testManagerSuite) TestGroupConfig(c *C) {
 	c.Assert(s.manager.GetRuleGroups(), DeepEquals, []*RuleGroup{g2})
 }
 
+func (s *testManagerSuite) TestRuleTemplate(c *C) {
+	stores := core.NewStoresInfo()
+	for id := uint64(1); id <= 4; id++ {
+		zone := "z1"
+		if id > 2 {
+			zone = "z2"
+		}
+		stores.SetStore(core.NewStoreInfoWithLabel(id, 0, map[string]string{"zone": zone}))
+	}
+	z1 := []LabelConstraint{{Key: "zone", Op: In, Values: []string{"z1"}}}
+	z2 := []LabelConstraint{{Key: "zone", Op: In, Values: []string{"z2"}}}
+
+	c.Assert(s.manager.SetRuleTemplate(&RuleTemplate{Name: "t", Role: "master", Count: 1}, stores), NotNil)
+	c.Assert(s.manager.SetRule(&Rule{GroupID: "g", ID: "1", Template: "t"}), ErrorMatches, ".*rule template t not found.*")
+	c.Assert(s.manager.SetRuleTemplate(&RuleTemplate{Name: "t", Role: Voter, Count: 2, LabelConstraints: z1}, stores), IsNil)
+
+	// Rules are derived from the template.
+	c.Assert(s.manager.SetRule(&Rule{GroupID: "g", ID: "1", Template: "t", StartKeyHex: "11", EndKeyHex: "22"}), IsNil)
+	c.Assert(s.manager.SetGroupBundle(GroupBundle{ID: "h", Template: "t", Rules: []*Rule{
+		{GroupID: "h", ID: "1", StartKeyHex: "22", EndKeyHex: "33"},
+	}}), IsNil)
+	for _, r := range []*Rule{s.manager.GetRule("g", "1"), s.manager.GetRule("h", "1")} {
+		c.Assert(r.Role, Equals, Voter)
+		c.Assert(r.Count, Equals, 2)
+		c.Assert(r.LabelConstraints, DeepEquals, z1)
+	}
+	c.Assert(s.manager.GetGroupBundle("h").Template, Equals, "t")
+	c.Assert(s.manager.GetRulesByTemplate("t"), HasLen, 2)
+
+	// Updating the template re-derives all rules.
+	c.Assert(s.manager.SetRuleTemplate(&RuleTemplate{Name: "t", Role: Follower, Count: 2, LabelConstraints: z2}, stores), IsNil)
+	for _, r := range []*Rule{s.manager.GetRule("g", "1"), s.manager.GetRule("h", "1")} {
+		c.Assert(r.Role, Equals, Follower)
+		c.Assert(r.LabelConstraints, DeepEquals, z2)
+	}
+	rules := s.manager.GetRulesByKey([]byte{0x11})
+	s.checkRules(c, rules, [][2]string{{"g", "1"}, {"pd", "default"}})
+	c.Assert(rules[0].Role, Equals, Follower)
+
+	// The update is rejected if the rules do not fit the stores.
+	err := s.manager.SetRuleTemplate(&RuleTemplate{Name: "t", Role: Voter, Count: 3, LabelConstraints: z2}, stores)
+	c.Assert(err, ErrorMatches, ".*rule g/1 needs 3 stores but only 2 stores match.*")
+	c.Assert(s.manager.GetRuleTemplate("t").Count, Equals, 2)
+	c.Assert(s.manager.GetRule("g", "1").Count, Equals, 2)
+
+	// Templates are persisted.
+	m2 := NewRuleManager(s.store)
+	c.Assert(m2.Initialize(3, []string{"zone"}), IsNil)
+	c.Assert(m2.GetRuleTemplates(), DeepEquals, s.manager.GetRuleTemplates())
+	c.Assert(m2.GetRule("g", "1"), DeepEquals, s.manager.GetRule("g", "1"))
+
+	// The template in use cannot be removed.
+	c.Assert(s.manager.DeleteRuleTemplate("t"), ErrorMatches, ".*referenced by.*")
+	c.Assert(s.manager.DeleteGroupBundle("h", false), IsNil)
+	c.Assert(s.manager.DeleteRule("g", "1"), IsNil)
+	c.Assert(s.manager.DeleteRuleTemplate("t"), IsNil)
+	c.Assert(s.manager.GetRuleTemplates(), HasLen, 0)
+}
+
 func (s *testManagerSuite) TestCheckApplyRules(c *C) {
 	err := checkApplyRules([]*Rule{
 		{
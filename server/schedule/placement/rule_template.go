@@ -0,0 +1,87 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package placement
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/tikv/pd/pkg/errs"
+)
+
+// RuleTemplate is a named placement policy. The rules that reference a
+// template derive the role, count and store constraints from it, so that the
+// same constraints are not repeated in every rule.
+type RuleTemplate struct {
+	Name             string            `json:"name"`
+	Role             PeerRoleType      `json:"role"`
+	Count            int               `json:"count"`
+	LabelConstraints []LabelConstraint `json:"label_constraints,omitempty"`
+	LocationLabels   []string          `json:"location_labels,omitempty"`
+	IsolationLevel   string            `json:"isolation_level,omitempty"`
+}
+
+func (t *RuleTemplate) String() string {
+	b, _ := json.Marshal(t)
+	return string(b)
+}
+
+func (t *RuleTemplate) validate() error {
+	if t.Name == "" {
+		return errs.ErrRuleContent.FastGenByArgs("template name should not be empty")
+	}
+	return checkRuleContent(t.Role, t.Count, t.LabelConstraints)
+}
+
+// applyTo returns a copy of the rule with the fields derived from the template.
+func (t *RuleTemplate) applyTo(r *Rule) *Rule {
+	nr := *r
+	nr.Role, nr.Count, nr.IsolationLevel = t.Role, t.Count, t.IsolationLevel
+	nr.LabelConstraints = append([]LabelConstraint(nil), t.LabelConstraints...)
+	nr.LocationLabels = append([]string(nil), t.LocationLabels...)
+	return &nr
+}
+
+func checkRuleContent(role PeerRoleType, count int, constraints []LabelConstraint) error {
+	if !validateRole(role) {
+		return errs.ErrRuleContent.FastGenByArgs(fmt.Sprintf("invalid role %s", role))
+	}
+	if count <= 0 {
+		return errs.ErrRuleContent.FastGenByArgs(fmt.Sprintf("invalid count %d", count))
+	}
+	if role == Leader && count > 1 {
+		return errs.ErrRuleContent.FastGenByArgs(fmt.Sprintf("define multiple leaders by count %d", count))
+	}
+	for _, c := range constraints {
+		if !validateOp(c.Op) {
+			return errs.ErrRuleContent.FastGenByArgs(fmt.Sprintf("invalid op %s", c.Op))
+		}
+	}
+	return nil
+}
+
+// checkRuleFit checks if there are enough stores matching the label
+// constraints of the rule.
+func checkRuleFit(r *Rule, stores StoreSet) error {
+	var matched int
+	for _, s := range stores.GetStores() {
+		if !s.IsTombstone() && MatchLabelConstraints(s, r.LabelConstraints) {
+			matched++
+		}
+	}
+	if matched < r.Count {
+		return errs.ErrRuleNotFit.FastGenByArgs(fmt.Sprintf("%s/%s", r.GroupID, r.ID), r.Count, matched)
+	}
+	return nil
+}
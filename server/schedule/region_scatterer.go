@@ -285,6 +285,10 @@ func (r *RegionScatterer) Scatter(region *core.RegionInfo, group string) (*opera
 		return nil, errors.Errorf("region %d is hot", region.GetID())
 	}
 
+	if !opt.IsRegionScheduleAllowed(r.cluster, region) {
+		return nil, errors.Errorf("region %d is labeled to deny scheduling", region.GetID())
+	}
+
 	return r.scatterRegion(region, group), nil
 }
 
@@ -113,6 +113,18 @@ func RegisterSliceDecoderBuilder(typ string, builder ConfigSliceDecoderBuilder)
 
 // CreateScheduler creates a scheduler with registered creator func.
 func CreateScheduler(typ string, opController *OperatorController, storage *core.Storage, dec ConfigDecoder) (Scheduler, error) {
+	batch := storage.NewBatch()
+	s, err := CreateSchedulerInBatch(typ, opController, storage, dec, batch)
+	if err != nil {
+		return nil, err
+	}
+	err = batch.Commit()
+	return s, err
+}
+
+// CreateSchedulerInBatch creates a scheduler with registered creator func, and adds
+// its config to the batch, which saves it with other updates atomically.
+func CreateSchedulerInBatch(typ string, opController *OperatorController, storage *core.Storage, dec ConfigDecoder, batch *core.Batch) (Scheduler, error) {
 	fn, ok := schedulerMap[typ]
 	if !ok {
 		return nil, errs.ErrSchedulerCreateFuncNotRegistered.FastGenByArgs(typ)
@@ -126,8 +138,8 @@ func CreateScheduler(typ string, opController *OperatorController, storage *core
 	if err != nil {
 		return nil, err
 	}
-	err = storage.SaveScheduleConfig(s.GetName(), data)
-	return s, err
+	batch.SaveScheduleConfig(s.GetName(), data)
+	return s, nil
 }
 
 // FindSchedulerTypeByName finds the type of the specified name.
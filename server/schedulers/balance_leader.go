@@ -137,6 +137,8 @@ func (l *balanceLeaderScheduler) Schedule(cluster opt.Cluster) []*operator.Opera
 	stores := cluster.GetStores()
 	sources := filter.SelectSourceStores(stores, l.filters, cluster.GetOpts())
 	targets := filter.SelectTargetStores(stores, l.filters, cluster.GetOpts())
+	l.diagnostic.setSourceStores(sources)
+	l.diagnostic.setTargetStores(targets)
 	opInfluence := l.opController.GetOpInfluence(cluster)
 	kind := core.NewScheduleKind(core.LeaderKind, leaderSchedulePolicy)
 	sort.Slice(sources, func(i, j int) bool {
@@ -191,18 +193,15 @@ func (l *balanceLeaderScheduler) Schedule(cluster opt.Cluster) []*operator.Opera
 // the best follower peer and transfers the leader.
 func (l *balanceLeaderScheduler) transferLeaderOut(cluster opt.Cluster, source *core.StoreInfo, opInfluence operator.OpInfluence) []*operator.Operator {
 	sourceID := source.GetID()
-	region := cluster.RandLeaderRegion(sourceID, l.conf.Ranges, opt.HealthRegion(cluster))
+	region := cluster.RandLeaderRegion(sourceID, l.conf.Ranges, opt.HealthRegion(cluster), opt.ScheduleAllowedRegion(cluster))
 	if region == nil {
 		log.Debug("store has no leader", zap.String("scheduler", l.GetName()), zap.Uint64("store-id", sourceID))
 		schedulerCounter.WithLabelValues(l.GetName(), "no-leader-region").Inc()
+		l.diagnostic.skip("no-leader-region")
 		return nil
 	}
 	targets := cluster.GetFollowerStores(region)
-	finalFilters := l.filters
-	if leaderFilter := filter.NewPlacementLeaderSafeguard(l.GetName(), cluster, region, source); leaderFilter != nil {
-		finalFilters = append(l.filters, leaderFilter)
-	}
-	targets = filter.SelectTargetStores(targets, finalFilters, cluster.GetOpts())
+	targets = filter.SelectTargetStores(targets, l.targetFilters(cluster, region, source), cluster.GetOpts())
 	leaderSchedulePolicy := l.opController.GetLeaderSchedulePolicy()
 	sort.Slice(targets, func(i, j int) bool {
 		kind := core.NewScheduleKind(core.LeaderKind, leaderSchedulePolicy)
@@ -217,6 +216,7 @@ func (l *balanceLeaderScheduler) transferLeaderOut(cluster opt.Cluster, source *
 	}
 	log.Debug("region has no target store", zap.String("scheduler", l.GetName()), zap.Uint64("region-id", region.GetID()))
 	schedulerCounter.WithLabelValues(l.GetName(), "no-target-store").Inc()
+	l.diagnostic.skip("no-target-store")
 	return nil
 }
 
@@ -225,10 +225,11 @@ func (l *balanceLeaderScheduler) transferLeaderOut(cluster opt.Cluster, source *
 // the worst follower peer and transfers the leader.
 func (l *balanceLeaderScheduler) transferLeaderIn(cluster opt.Cluster, target *core.StoreInfo) []*operator.Operator {
 	targetID := target.GetID()
-	region := cluster.RandFollowerRegion(targetID, l.conf.Ranges, opt.HealthRegion(cluster))
+	region := cluster.RandFollowerRegion(targetID, l.conf.Ranges, opt.HealthRegion(cluster), opt.ScheduleAllowedRegion(cluster), opt.NonWitnessRegion(targetID))
 	if region == nil {
 		log.Debug("store has no follower", zap.String("scheduler", l.GetName()), zap.Uint64("store-id", targetID))
 		schedulerCounter.WithLabelValues(l.GetName(), "no-follower-region").Inc()
+		l.diagnostic.skip("no-follower-region")
 		return nil
 	}
 	leaderStoreID := region.GetLeader().GetStoreId()
@@ -245,19 +246,31 @@ func (l *balanceLeaderScheduler) transferLeaderIn(cluster opt.Cluster, target *c
 	targets := []*core.StoreInfo{
 		target,
 	}
-	finalFilters := l.filters
-	if leaderFilter := filter.NewPlacementLeaderSafeguard(l.GetName(), cluster, region, source); leaderFilter != nil {
-		finalFilters = append(l.filters, leaderFilter)
-	}
-	targets = filter.SelectTargetStores(targets, finalFilters, cluster.GetOpts())
+	targets = filter.SelectTargetStores(targets, l.targetFilters(cluster, region, source), cluster.GetOpts())
 	if len(targets) < 1 {
 		log.Debug("region has no target store", zap.String("scheduler", l.GetName()), zap.Uint64("region-id", region.GetID()))
 		schedulerCounter.WithLabelValues(l.GetName(), "no-target-store").Inc()
+		l.diagnostic.skip("no-target-store")
 		return nil
 	}
 	return l.createOperator(cluster, region, source, targets[0])
 }
 
+// targetFilters returns the filters of the target stores to which the leader
+// of the region is transferred from the source store. While leaders are only
+// balanced within the groups of stores, the stores in the other groups are
+// filtered out.
+func (l *balanceLeaderScheduler) targetFilters(cluster opt.Cluster, region *core.RegionInfo, source *core.StoreInfo) []filter.Filter {
+	filters := append([]filter.Filter(nil), l.filters...)
+	if leaderFilter := filter.NewPlacementLeaderSafeguard(l.GetName(), cluster, region, source); leaderFilter != nil {
+		filters = append(filters, leaderFilter)
+	}
+	if groups := cluster.GetLeaderGroups(); groups != nil {
+		filters = append(filters, filter.NewStoreGroupFilter(l.GetName(), groups, source))
+	}
+	return filters
+}
+
 // createOperator creates the operator according to the source and target store.
 // If the region is hot or the difference between the two stores is tolerable, then
 // no new operator need to be created, otherwise create an operator that transfers
@@ -266,6 +279,7 @@ func (l *balanceLeaderScheduler) createOperator(cluster opt.Cluster, region *cor
 	if cluster.IsRegionHot(region) {
 		log.Debug("region is hot region, ignore it", zap.String("scheduler", l.GetName()), zap.Uint64("region-id", region.GetID()))
 		schedulerCounter.WithLabelValues(l.GetName(), "region-hot").Inc()
+		l.diagnostic.skip("region-hot")
 		return nil
 	}
 
@@ -277,6 +291,7 @@ func (l *balanceLeaderScheduler) createOperator(cluster opt.Cluster, region *cor
 	shouldBalance, sourceScore, targetScore := shouldBalance(cluster, source, target, region, kind, opInfluence, l.GetName())
 	if !shouldBalance {
 		schedulerCounter.WithLabelValues(l.GetName(), "skip").Inc()
+		l.diagnostic.skip(skipToleranceRatio)
 		return nil
 	}
 
@@ -134,6 +134,7 @@ func (s *balanceRegionScheduler) Schedule(cluster opt.Cluster) []*operator.Opera
 	stores := cluster.GetStores()
 	opts := cluster.GetOpts()
 	stores = filter.SelectSourceStores(stores, s.filters, opts)
+	s.diagnostic.setSourceStores(stores)
 	opInfluence := s.opController.GetOpInfluence(cluster)
 	kind := core.NewScheduleKind(core.RegionKind, core.BySize)
 	sort.Slice(stores, func(i, j int) bool {
@@ -148,21 +149,22 @@ func (s *balanceRegionScheduler) Schedule(cluster opt.Cluster) []*operator.Opera
 		for i := 0; i < balanceRegionRetryLimit; i++ {
 			// Priority pick the region that has a pending peer.
 			// Pending region may means the disk is overload, remove the pending region firstly.
-			region := cluster.RandPendingRegion(sourceID, s.conf.Ranges, opt.HealthAllowPending(cluster), opt.ReplicatedRegion(cluster))
+			region := cluster.RandPendingRegion(sourceID, s.conf.Ranges, opt.HealthAllowPending(cluster), opt.ReplicatedRegion(cluster), opt.ScheduleAllowedRegion(cluster), opt.NonWitnessRegion(sourceID))
 			if region == nil {
 				// Then pick the region that has a follower in the source store.
-				region = cluster.RandFollowerRegion(sourceID, s.conf.Ranges, opt.HealthRegion(cluster), opt.ReplicatedRegion(cluster))
+				region = cluster.RandFollowerRegion(sourceID, s.conf.Ranges, opt.HealthRegion(cluster), opt.ReplicatedRegion(cluster), opt.ScheduleAllowedRegion(cluster), opt.NonWitnessRegion(sourceID))
 			}
 			if region == nil {
 				// Then pick the region has the leader in the source store.
-				region = cluster.RandLeaderRegion(sourceID, s.conf.Ranges, opt.HealthRegion(cluster), opt.ReplicatedRegion(cluster))
+				region = cluster.RandLeaderRegion(sourceID, s.conf.Ranges, opt.HealthRegion(cluster), opt.ReplicatedRegion(cluster), opt.ScheduleAllowedRegion(cluster))
 			}
 			if region == nil {
 				// Finally pick learner.
-				region = cluster.RandLearnerRegion(sourceID, s.conf.Ranges, opt.HealthRegion(cluster), opt.ReplicatedRegion(cluster))
+				region = cluster.RandLearnerRegion(sourceID, s.conf.Ranges, opt.HealthRegion(cluster), opt.ReplicatedRegion(cluster), opt.ScheduleAllowedRegion(cluster))
 			}
 			if region == nil {
 				schedulerCounter.WithLabelValues(s.GetName(), "no-region").Inc()
+				s.diagnostic.skip("no-region")
 				continue
 			}
 			log.Debug("select region", zap.String("scheduler", s.GetName()), zap.Uint64("region-id", region.GetID()))
@@ -171,6 +173,7 @@ func (s *balanceRegionScheduler) Schedule(cluster opt.Cluster) []*operator.Opera
 			if cluster.IsRegionHot(region) {
 				log.Debug("region is hot", zap.String("scheduler", s.GetName()), zap.Uint64("region-id", region.GetID()))
 				schedulerCounter.WithLabelValues(s.GetName(), "region-hot").Inc()
+				s.diagnostic.skip("region-hot")
 				continue
 			}
 			// Check region whether have leader
@@ -210,6 +213,7 @@ func (s *balanceRegionScheduler) transferPeer(cluster opt.Cluster, region *core.
 	candidates := filter.NewCandidates(cluster.GetStores()).
 		FilterTarget(cluster.GetOpts(), filters...).
 		Sort(filter.RegionScoreComparer(cluster.GetOpts()))
+	s.diagnostic.setTargetStores(candidates.Stores)
 
 	for _, target := range candidates.Stores {
 		regionID := region.GetID()
@@ -222,6 +226,7 @@ func (s *balanceRegionScheduler) transferPeer(cluster opt.Cluster, region *core.
 		shouldBalance, sourceScore, targetScore := shouldBalance(cluster, source, target, region, kind, opInfluence, s.GetName())
 		if !shouldBalance {
 			schedulerCounter.WithLabelValues(s.GetName(), "skip").Inc()
+			s.diagnostic.skip(skipToleranceRatio)
 			continue
 		}
 
@@ -246,5 +251,6 @@ func (s *balanceRegionScheduler) transferPeer(cluster opt.Cluster, region *core.
 	}
 
 	schedulerCounter.WithLabelValues(s.GetName(), "no-replacement").Inc()
+	s.diagnostic.skip("no-replacement")
 	return nil
 }
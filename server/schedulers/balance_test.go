@@ -28,6 +28,7 @@ import (
 	"github.com/tikv/pd/server/kv"
 	"github.com/tikv/pd/server/schedule"
 	"github.com/tikv/pd/server/schedule/hbstream"
+	"github.com/tikv/pd/server/schedule/labeler"
 	"github.com/tikv/pd/server/schedule/operator"
 	"github.com/tikv/pd/server/versioninfo"
 )
@@ -236,6 +237,28 @@ func (s *testBalanceLeaderSchedulerSuite) TestBalanceLimit(c *C) {
 	c.Check(s.schedule(), NotNil)
 }
 
+func (s *testBalanceLeaderSchedulerSuite) TestScheduleDenyLabel(c *C) {
+	s.tc.SetTolerantSizeRatio(2.5)
+	// Stores:     1    2    3    4
+	// Leaders:    16   0    0    0
+	// Region1:    L    F    F    F
+	s.tc.AddLeaderStore(1, 16)
+	s.tc.AddLeaderStore(2, 0)
+	s.tc.AddLeaderStore(3, 0)
+	s.tc.AddLeaderStore(4, 0)
+	s.tc.AddLeaderRegion(1, 1, 2, 3, 4)
+	c.Check(s.schedule(), NotNil)
+
+	// The region labeled with schedule=deny should not be balanced.
+	c.Assert(s.tc.RegionLabeler.SetLabelRule(&labeler.LabelRule{
+		ID:     "deny",
+		Labels: []labeler.RegionLabel{{Key: labeler.ScheduleOptionKey, Value: labeler.ScheduleOptionValueDeny}},
+	}), IsNil)
+	c.Check(s.schedule(), IsNil)
+	c.Assert(s.tc.RegionLabeler.DeleteLabelRule("deny"), IsNil)
+	c.Check(s.schedule(), NotNil)
+}
+
 func (s *testBalanceLeaderSchedulerSuite) TestBalanceLeaderSchedulePolicy(c *C) {
 	// Stores:          1       2       3       4
 	// Leader Count:    10      10      10      10
@@ -60,17 +60,38 @@ func intervalGrow(x time.Duration, maxInterval time.Duration, typ intervalGrowth
 // BaseScheduler is a basic scheduler for all other complex scheduler
 type BaseScheduler struct {
 	OpController *schedule.OperatorController
+	diagnostic   *diagnosticRecorder
 }
 
 // NewBaseScheduler returns a basic scheduler
 func NewBaseScheduler(opController *schedule.OperatorController) *BaseScheduler {
-	return &BaseScheduler{OpController: opController}
+	return &BaseScheduler{
+		OpController: opController,
+		diagnostic:   newDiagnosticRecorder(),
+	}
 }
 
 func (s *BaseScheduler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.serveDiagnostic(w, r) {
+		return
+	}
 	fmt.Fprintf(w, "not implements")
 }
 
+// serveDiagnostic serves the request for the diagnostic information of the
+// recent rounds. It returns false if the request is not for it.
+func (s *BaseScheduler) serveDiagnostic(w http.ResponseWriter, r *http.Request) bool {
+	if s.diagnostic == nil || r.URL.Path != diagnosticPath || r.Method != http.MethodGet {
+		return false
+	}
+	s.diagnostic.ServeHTTP(w, r)
+	return true
+}
+
+func (s *BaseScheduler) getDiagnostic() *diagnosticRecorder {
+	return s.diagnostic
+}
+
 // GetMinInterval returns the minimal interval for the scheduler
 func (s *BaseScheduler) GetMinInterval() time.Duration {
 	return MinScheduleInterval
@@ -0,0 +1,167 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedulers
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/tikv/pd/server/config"
+	"github.com/tikv/pd/server/core"
+	"github.com/tikv/pd/server/schedule"
+	"github.com/tikv/pd/server/schedule/filter"
+	"github.com/tikv/pd/server/schedule/operator"
+	"github.com/tikv/pd/server/schedule/opt"
+	"github.com/unrolled/render"
+)
+
+const (
+	// diagnosticRoundLimit is the number of the recent rounds kept for each
+	// scheduler.
+	diagnosticRoundLimit = 20
+	diagnosticPath       = "/diagnostic"
+
+	// skipToleranceRatio means the difference of the scores between the
+	// source and target stores is within the tolerance ratio.
+	skipToleranceRatio = "tolerance-ratio"
+)
+
+// DiagnosticRound is the diagnostic information of a scheduling round. The
+// stores rejected by store limit are recorded as the filtered stores with the
+// type `store-state-exceed-add-limit-filter` or
+// `store-state-exceed-remove-limit-filter`.
+type DiagnosticRound struct {
+	Time time.Time `json:"time"`
+	// SourceStores and TargetStores are the candidate stores that pass the
+	// filters, only set by the balance schedulers. For balance-region, the
+	// target stores are the ones of the last selected region.
+	SourceStores   []uint64        `json:"source-stores,omitempty"`
+	TargetStores   []uint64        `json:"target-stores,omitempty"`
+	FilteredStores []filter.Record `json:"filtered-stores,omitempty"`
+	// SkipReasons counts the reasons why a candidate is skipped.
+	SkipReasons map[string]int `json:"skip-reasons,omitempty"`
+	Operators   int            `json:"operators"`
+}
+
+// diagnosticRecorder keeps the diagnostic information of the recent rounds
+// of a scheduler in a ring buffer.
+type diagnosticRecorder struct {
+	sync.RWMutex
+	rounds []*DiagnosticRound
+	next   int
+
+	// cur and filterRecorder are only accessed by the schedule goroutine. cur
+	// is nil if the scheduler is not run by ScheduleWithDiagnostic.
+	cur            *DiagnosticRound
+	filterRecorder *filter.Recorder
+}
+
+func newDiagnosticRecorder() *diagnosticRecorder {
+	return &diagnosticRecorder{
+		rounds: make([]*DiagnosticRound, 0, diagnosticRoundLimit),
+	}
+}
+
+// begin starts a round, in which the filters with the scope that run with the
+// options are recorded. A dry run of the scheduler runs on a copy of the
+// options, so it is not recorded in the round.
+func (d *diagnosticRecorder) begin(scope string, opts *config.PersistOptions) {
+	d.cur = &DiagnosticRound{Time: time.Now()}
+	d.filterRecorder = filter.NewRecorder(scope, opts)
+}
+
+func (d *diagnosticRecorder) end(ops []*operator.Operator) {
+	if d.cur == nil {
+		return
+	}
+	d.filterRecorder.Stop()
+	round := d.cur
+	round.FilteredStores = d.filterRecorder.GetRecords()
+	round.Operators = len(ops)
+	d.cur, d.filterRecorder = nil, nil
+
+	d.Lock()
+	defer d.Unlock()
+	if len(d.rounds) < diagnosticRoundLimit {
+		d.rounds = append(d.rounds, round)
+	} else {
+		d.rounds[d.next] = round
+	}
+	d.next = (d.next + 1) % diagnosticRoundLimit
+}
+
+func (d *diagnosticRecorder) setSourceStores(stores []*core.StoreInfo) {
+	if d.cur != nil {
+		d.cur.SourceStores = storeIDs(stores)
+	}
+}
+
+func (d *diagnosticRecorder) setTargetStores(stores []*core.StoreInfo) {
+	if d.cur != nil {
+		d.cur.TargetStores = storeIDs(stores)
+	}
+}
+
+func (d *diagnosticRecorder) skip(reason string) {
+	if d.cur == nil {
+		return
+	}
+	if d.cur.SkipReasons == nil {
+		d.cur.SkipReasons = make(map[string]int)
+	}
+	d.cur.SkipReasons[reason]++
+}
+
+// getRounds returns the recent rounds from the oldest to the latest.
+func (d *diagnosticRecorder) getRounds() []*DiagnosticRound {
+	d.RLock()
+	defer d.RUnlock()
+	rounds := make([]*DiagnosticRound, 0, len(d.rounds))
+	if len(d.rounds) < diagnosticRoundLimit {
+		return append(rounds, d.rounds...)
+	}
+	rounds = append(rounds, d.rounds[d.next:]...)
+	return append(rounds, d.rounds[:d.next]...)
+}
+
+func (d *diagnosticRecorder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rd := render.New(render.Options{IndentJSON: true})
+	rd.JSON(w, http.StatusOK, d.getRounds())
+}
+
+func storeIDs(stores []*core.StoreInfo) []uint64 {
+	ids := make([]uint64, 0, len(stores))
+	for _, s := range stores {
+		ids = append(ids, s.GetID())
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// ScheduleWithDiagnostic runs the scheduler once. The diagnostic information
+// of the round is recorded if the scheduler supports it.
+func ScheduleWithDiagnostic(s schedule.Scheduler, cluster opt.Cluster) []*operator.Operator {
+	ds, ok := s.(interface{ getDiagnostic() *diagnosticRecorder })
+	if !ok || ds.getDiagnostic() == nil {
+		return s.Schedule(cluster)
+	}
+	d := ds.getDiagnostic()
+	d.begin(s.GetName(), cluster.GetOpts())
+	var ops []*operator.Operator
+	defer func() { d.end(ops) }()
+	ops = s.Schedule(cluster)
+	return ops
+}
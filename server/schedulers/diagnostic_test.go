@@ -0,0 +1,157 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedulers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/pingcap/check"
+	"github.com/tikv/pd/pkg/mock/mockcluster"
+	"github.com/tikv/pd/server/config"
+	"github.com/tikv/pd/server/core"
+	"github.com/tikv/pd/server/kv"
+	"github.com/tikv/pd/server/schedule"
+	"github.com/tikv/pd/server/schedule/filter"
+	"github.com/tikv/pd/server/schedule/operator"
+	"github.com/tikv/pd/server/schedule/opt"
+)
+
+var _ = Suite(&testDiagnosticSuite{})
+
+type testDiagnosticSuite struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func (s *testDiagnosticSuite) SetUpTest(c *C) {
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+}
+
+func (s *testDiagnosticSuite) TearDownTest(c *C) {
+	s.cancel()
+}
+
+func (s *testDiagnosticSuite) TestRingBuffer(c *C) {
+	d := newDiagnosticRecorder()
+	c.Assert(d.getRounds(), HasLen, 0)
+	for i := 0; i < diagnosticRoundLimit+5; i++ {
+		d.begin("test", config.NewTestOptions())
+		d.end(make([]*operator.Operator, i))
+	}
+	rounds := d.getRounds()
+	c.Assert(rounds, HasLen, diagnosticRoundLimit)
+	for i, round := range rounds {
+		c.Assert(round.Operators, Equals, i+5)
+	}
+
+	// Not recorded if the round is not begun.
+	d.skip("test")
+	d.end(nil)
+	c.Assert(d.getRounds(), HasLen, diagnosticRoundLimit)
+}
+
+func (s *testDiagnosticSuite) TestBalanceLeader(c *C) {
+	opt := config.NewTestOptions()
+	tc := mockcluster.NewCluster(opt)
+	tc.SetTolerantSizeRatio(2.5)
+	oc := schedule.NewOperatorController(s.ctx, tc, nil)
+	lb, err := schedule.CreateScheduler(BalanceLeaderType, oc, core.NewStorage(kv.NewMemoryKV()), schedule.ConfigSliceDecoder(BalanceLeaderType, []string{"", ""}))
+	c.Assert(err, IsNil)
+
+	// Stores:     1    2    3    4
+	// Leaders:    1    0    0    0
+	// Region1:    L    F    F    F
+	tc.AddLeaderStore(1, 1)
+	tc.AddLeaderStore(2, 0)
+	tc.AddLeaderStore(3, 0)
+	tc.AddLeaderStore(4, 0)
+	tc.AddLeaderRegion(1, 1, 2, 3, 4)
+	tc.SetStoreBusy(4, true)
+	c.Assert(ScheduleWithDiagnostic(lb, tc), IsNil)
+
+	w := httptest.NewRecorder()
+	lb.ServeHTTP(w, httptest.NewRequest(http.MethodGet, diagnosticPath, nil))
+	var rounds []*DiagnosticRound
+	c.Assert(json.Unmarshal(w.Body.Bytes(), &rounds), IsNil)
+	c.Assert(rounds, HasLen, 1)
+	round := rounds[0]
+	c.Assert(round.Operators, Equals, 0)
+	c.Assert(round.SourceStores, DeepEquals, []uint64{1, 2, 3, 4})
+	c.Assert(round.TargetStores, DeepEquals, []uint64{1, 2, 3})
+	c.Assert(round.SkipReasons[skipToleranceRatio], Greater, 0)
+	c.Assert(round.FilteredStores, HasLen, 1)
+	c.Assert(round.FilteredStores[0].StoreID, Equals, uint64(4))
+	c.Assert(round.FilteredStores[0].Type, Equals, "store-state-busy-filter")
+
+	// The rounds are not recorded if the scheduler runs directly.
+	c.Assert(lb.Schedule(tc), IsNil)
+	c.Assert(lb.(*balanceLeaderScheduler).diagnostic.getRounds(), HasLen, 1)
+
+	// Operators are counted.
+	tc.UpdateLeaderCount(1, 16)
+	c.Assert(ScheduleWithDiagnostic(lb, tc), HasLen, 1)
+	rounds = lb.(*balanceLeaderScheduler).diagnostic.getRounds()
+	c.Assert(rounds, HasLen, 2)
+	c.Assert(rounds[1].Operators, Equals, 1)
+	c.Assert(rounds[1].FilteredStores, DeepEquals, []filter.Record{
+		{Action: "filter-target", StoreID: 4, Type: "store-state-busy-filter", Count: 2},
+	})
+}
+
+// dryRunCluster runs a scheduler on a copy of the options like a dry run.
+type dryRunCluster struct {
+	opt.Cluster
+	opts *config.PersistOptions
+}
+
+func (c *dryRunCluster) GetOpts() *config.PersistOptions {
+	return c.opts
+}
+
+func (s *testDiagnosticSuite) TestDryRunNotRecorded(c *C) {
+	opt := config.NewTestOptions()
+	tc := mockcluster.NewCluster(opt)
+	tc.SetTolerantSizeRatio(2.5)
+	oc := schedule.NewOperatorController(s.ctx, tc, nil)
+	lb, err := schedule.CreateScheduler(BalanceLeaderType, oc, core.NewStorage(kv.NewMemoryKV()), schedule.ConfigSliceDecoder(BalanceLeaderType, []string{"", ""}))
+	c.Assert(err, IsNil)
+	dryRun, err := schedule.CreateScheduler(BalanceLeaderType, oc, core.NewStorage(kv.NewMemoryKV()), schedule.ConfigSliceDecoder(BalanceLeaderType, []string{"", ""}))
+	c.Assert(err, IsNil)
+	c.Assert(dryRun.GetName(), Equals, lb.GetName())
+
+	tc.AddLeaderStore(1, 1)
+	tc.AddLeaderStore(2, 0)
+	tc.AddLeaderStore(3, 0)
+	tc.AddLeaderStore(4, 0)
+	tc.AddLeaderRegion(1, 1, 2, 3, 4)
+	tc.SetStoreBusy(4, true)
+
+	// The dry run rejects store 4 while a round of the running scheduler is
+	// recorded.
+	d := lb.(*balanceLeaderScheduler).diagnostic
+	d.begin(lb.GetName(), tc.GetOpts())
+	c.Assert(dryRun.Schedule(&dryRunCluster{Cluster: tc, opts: tc.GetOpts().Clone()}), IsNil)
+	d.end(nil)
+	rounds := d.getRounds()
+	c.Assert(rounds, HasLen, 1)
+	c.Assert(rounds[0].FilteredStores, HasLen, 0)
+
+	c.Assert(ScheduleWithDiagnostic(lb, tc), IsNil)
+	rounds = d.getRounds()
+	c.Assert(rounds, HasLen, 2)
+	c.Assert(rounds[1].FilteredStores, HasLen, 1)
+}
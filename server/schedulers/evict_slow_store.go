@@ -0,0 +1,221 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedulers
+
+import (
+	"github.com/pingcap/log"
+	"github.com/tikv/pd/pkg/errs"
+	"github.com/tikv/pd/server/core"
+	"github.com/tikv/pd/server/schedule"
+	"github.com/tikv/pd/server/schedule/operator"
+	"github.com/tikv/pd/server/schedule/opt"
+	"go.uber.org/zap"
+)
+
+const (
+	// EvictSlowStoreName is evict slow store scheduler name.
+	EvictSlowStoreName = "evict-slow-store-scheduler"
+	// EvictSlowStoreType is evict slow store scheduler type.
+	EvictSlowStoreType = "evict-slow-store"
+
+	slowStoreRecoverThreshold = 10
+)
+
+func init() {
+	schedule.RegisterSliceDecoderBuilder(EvictSlowStoreType, func(args []string) schedule.ConfigDecoder {
+		return func(v interface{}) error {
+			return nil
+		}
+	})
+
+	schedule.RegisterScheduler(EvictSlowStoreType, func(opController *schedule.OperatorController, storage *core.Storage, decoder schedule.ConfigDecoder) (schedule.Scheduler, error) {
+		conf := &evictSlowStoreSchedulerConfig{storage: storage, EvictedStores: make([]uint64, 0)}
+		if err := decoder(conf); err != nil {
+			return nil, err
+		}
+		return newEvictSlowStoreScheduler(opController, conf), nil
+	})
+}
+
+type evictSlowStoreSchedulerConfig struct {
+	storage       *core.Storage
+	EvictedStores []uint64 `json:"evict-stores"`
+}
+
+func (conf *evictSlowStoreSchedulerConfig) Persist() error {
+	name := conf.getSchedulerName()
+	data, err := schedule.EncodeConfig(conf)
+	if err != nil {
+		return err
+	}
+	return conf.storage.SaveScheduleConfig(name, data)
+}
+
+func (conf *evictSlowStoreSchedulerConfig) getSchedulerName() string {
+	return EvictSlowStoreName
+}
+
+func (conf *evictSlowStoreSchedulerConfig) evictStore() uint64 {
+	if len(conf.EvictedStores) == 0 {
+		return 0
+	}
+	return conf.EvictedStores[0]
+}
+
+// setStoreAndPersist keeps the evicted stores unchanged if it fails.
+func (conf *evictSlowStoreSchedulerConfig) setStoreAndPersist(id uint64) error {
+	old := conf.EvictedStores
+	conf.EvictedStores = []uint64{id}
+	if err := conf.Persist(); err != nil {
+		conf.EvictedStores = old
+		return err
+	}
+	return nil
+}
+
+func (conf *evictSlowStoreSchedulerConfig) clearAndPersist() error {
+	conf.EvictedStores = []uint64{}
+	return conf.Persist()
+}
+
+type evictSlowStoreScheduler struct {
+	*BaseScheduler
+	conf *evictSlowStoreSchedulerConfig
+}
+
+// newEvictSlowStoreScheduler creates a scheduler that detects the slow store
+// and transfers all leaders out of it until it recovers.
+func newEvictSlowStoreScheduler(opController *schedule.OperatorController, conf *evictSlowStoreSchedulerConfig) schedule.Scheduler {
+	return &evictSlowStoreScheduler{
+		BaseScheduler: NewBaseScheduler(opController),
+		conf:          conf,
+	}
+}
+
+func (s *evictSlowStoreScheduler) GetName() string {
+	return EvictSlowStoreName
+}
+
+func (s *evictSlowStoreScheduler) GetType() string {
+	return EvictSlowStoreType
+}
+
+func (s *evictSlowStoreScheduler) EncodeConfig() ([]byte, error) {
+	return schedule.EncodeConfig(s.conf)
+}
+
+func (s *evictSlowStoreScheduler) Prepare(cluster opt.Cluster) error {
+	evictStore := s.conf.evictStore()
+	if evictStore != 0 {
+		return cluster.SlowStoreEvicted(evictStore)
+	}
+	return nil
+}
+
+// Cleanup only resumes the store in the cluster, the evicted store is kept in
+// the config so that the new leader can continue evicting it.
+func (s *evictSlowStoreScheduler) Cleanup(cluster opt.Cluster) {
+	if evictStore := s.conf.evictStore(); evictStore != 0 {
+		cluster.SlowStoreRecovered(evictStore)
+	}
+}
+
+// prepareEvictLeader persists the evicted store only after the store is
+// evicted in the cluster, and the store is resumed if persisting fails.
+func (s *evictSlowStoreScheduler) prepareEvictLeader(cluster opt.Cluster, storeID uint64) error {
+	if err := cluster.SlowStoreEvicted(storeID); err != nil {
+		return err
+	}
+	if err := s.conf.setStoreAndPersist(storeID); err != nil {
+		log.Info("evict-slow-store-scheduler persist config failed", zap.Uint64("store-id", storeID), errs.ZapError(err))
+		cluster.SlowStoreRecovered(storeID)
+		return err
+	}
+	return nil
+}
+
+func (s *evictSlowStoreScheduler) cleanupEvictLeader(cluster opt.Cluster) {
+	evictSlowStore := s.conf.evictStore()
+	if evictSlowStore == 0 {
+		return
+	}
+	if err := s.conf.clearAndPersist(); err != nil {
+		log.Info("evict-slow-store-scheduler persist config failed", zap.Uint64("store-id", evictSlowStore), errs.ZapError(err))
+	}
+	cluster.SlowStoreRecovered(evictSlowStore)
+}
+
+func (s *evictSlowStoreScheduler) schedulerEvictLeader(cluster opt.Cluster) []*operator.Operator {
+	storeMap := map[uint64][]core.KeyRange{
+		s.conf.evictStore(): {core.NewKeyRange("", "")},
+	}
+	return scheduleEvictLeaderBatch(s.GetName(), s.GetType(), cluster, storeMap, EvictLeaderBatchSize)
+}
+
+func (s *evictSlowStoreScheduler) IsScheduleAllowed(cluster opt.Cluster) bool {
+	if s.conf.evictStore() != 0 {
+		return s.OpController.OperatorCount(operator.OpLeader) < cluster.GetOpts().GetLeaderScheduleLimit()
+	}
+	return true
+}
+
+func (s *evictSlowStoreScheduler) Schedule(cluster opt.Cluster) []*operator.Operator {
+	schedulerCounter.WithLabelValues(s.GetName(), "schedule").Inc()
+	if evictStore := s.conf.evictStore(); evictStore != 0 {
+		store := cluster.GetStore(evictStore)
+		if store == nil || store.IsTombstone() {
+			// The slow store has been removed, stop evicting and detect the
+			// slow store again next time.
+			log.Info("slow store has been removed", zap.Uint64("store-id", evictStore))
+		} else if store.IsSlowScoreWarmingUp() {
+			// The slow score is not calculated yet, such as after the PD leader
+			// changes, so keep evicting the store until it's known.
+			return s.schedulerEvictLeader(cluster)
+		} else if store.GetSlowScore() <= slowStoreRecoverThreshold {
+			log.Info("slow store has been recovered", zap.Uint64("store-id", evictStore), zap.Uint64("slow-score", store.GetSlowScore()))
+		} else {
+			return s.schedulerEvictLeader(cluster)
+		}
+		s.cleanupEvictLeader(cluster)
+		return nil
+	}
+
+	var slowStore *core.StoreInfo
+	for _, store := range cluster.GetStores() {
+		if store.IsTombstone() {
+			continue
+		}
+		if (store.IsUp() || store.IsOffline()) && store.IsSlow() {
+			// Do nothing if there is more than one slow store, evicting leaders
+			// from all of them may make the cluster unavailable.
+			if slowStore != nil {
+				schedulerCounter.WithLabelValues(s.GetName(), "multi-slow-store").Inc()
+				return nil
+			}
+			slowStore = store
+		}
+	}
+	if slowStore == nil {
+		schedulerCounter.WithLabelValues(s.GetName(), "no-slow-store").Inc()
+		return nil
+	}
+
+	log.Info("detected slow store, start to evict leaders",
+		zap.Uint64("store-id", slowStore.GetID()), zap.Uint64("slow-score", slowStore.GetSlowScore()))
+	if err := s.prepareEvictLeader(cluster, slowStore.GetID()); err != nil {
+		log.Info("prepare for evicting leader failed", zap.Uint64("store-id", slowStore.GetID()), errs.ZapError(err))
+		return nil
+	}
+	return s.schedulerEvictLeader(cluster)
+}
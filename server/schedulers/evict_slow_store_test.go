@@ -0,0 +1,202 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedulers
+
+import (
+	"context"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/errors"
+	"github.com/pingcap/kvproto/pkg/pdpb"
+	"github.com/tikv/pd/pkg/mock/mockcluster"
+	"github.com/tikv/pd/pkg/testutil"
+	"github.com/tikv/pd/server/config"
+	"github.com/tikv/pd/server/core"
+	"github.com/tikv/pd/server/kv"
+	"github.com/tikv/pd/server/schedule"
+	"github.com/tikv/pd/server/schedule/operator"
+)
+
+var _ = Suite(&testEvictSlowStoreSuite{})
+
+type testEvictSlowStoreSuite struct{}
+
+func setStoreBusyHeartbeats(tc *mockcluster.Cluster, storeID uint64, busy bool, count int) {
+	for i := 0; i < count; i++ {
+		store := tc.GetStore(storeID)
+		tc.PutStore(store.Clone(core.SetStoreStats(&pdpb.StoreStats{StoreId: storeID, IsBusy: busy})))
+	}
+}
+
+func (s *testEvictSlowStoreSuite) TestEvictSlowStore(c *C) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	opt := config.NewTestOptions()
+	tc := mockcluster.NewCluster(opt)
+
+	// Add stores 1, 2, 3
+	tc.AddLeaderStore(1, 0)
+	tc.AddLeaderStore(2, 0)
+	tc.AddLeaderStore(3, 0)
+	// Add regions 1, 2 with leaders in stores 1, 2
+	tc.AddLeaderRegion(1, 1, 2)
+	tc.AddLeaderRegion(2, 2, 1)
+	tc.UpdateLeaderCount(2, 16)
+
+	storage := core.NewStorage(kv.NewMemoryKV())
+	es, err := schedule.CreateScheduler(EvictSlowStoreType, schedule.NewOperatorController(ctx, nil, nil), storage, schedule.ConfigSliceDecoder(EvictSlowStoreType, []string{}))
+	c.Assert(err, IsNil)
+	bl, err := schedule.CreateScheduler(BalanceLeaderType, schedule.NewOperatorController(ctx, nil, nil), storage, schedule.ConfigSliceDecoder(BalanceLeaderType, []string{}))
+	c.Assert(err, IsNil)
+
+	// No slow store.
+	c.Assert(es.IsScheduleAllowed(tc), IsTrue)
+	c.Assert(es.Schedule(tc), IsNil)
+
+	// Store 1 becomes slow, its leaders should be evicted.
+	setStoreBusyHeartbeats(tc, 1, true, 10)
+	c.Assert(tc.GetStore(1).IsSlow(), IsTrue)
+	op := es.Schedule(tc)
+	testutil.CheckTransferLeader(c, op[0], operator.OpLeader, 1, 2)
+	c.Assert(op[0].Desc(), Equals, EvictSlowStoreType)
+	c.Assert(tc.GetStore(1).EvictedAsSlowStore(), IsTrue)
+
+	// The evicted store is persisted.
+	data, err := storage.LoadScheduleConfig(EvictSlowStoreName)
+	c.Assert(err, IsNil)
+	conf := &evictSlowStoreSchedulerConfig{}
+	c.Assert(schedule.DecodeConfig([]byte(data), conf), IsNil)
+	c.Assert(conf.EvictedStores, DeepEquals, []uint64{1})
+
+	// Cleanup resumes the store but keeps the config.
+	es.Cleanup(tc)
+	c.Assert(tc.GetStore(1).EvictedAsSlowStore(), IsFalse)
+	c.Assert(es.Prepare(tc), IsNil)
+	c.Assert(tc.GetStore(1).EvictedAsSlowStore(), IsTrue)
+
+	// The balance leader scheduler should not transfer leaders back before
+	// the evict slow store scheduler finds that store 1 has recovered.
+	setStoreBusyHeartbeats(tc, 1, false, 10)
+	c.Assert(bl.Schedule(tc), IsNil)
+
+	c.Assert(es.Schedule(tc), IsNil)
+	c.Assert(tc.GetStore(1).EvictedAsSlowStore(), IsFalse)
+	data, err = storage.LoadScheduleConfig(EvictSlowStoreName)
+	c.Assert(err, IsNil)
+	c.Assert(schedule.DecodeConfig([]byte(data), conf), IsNil)
+	c.Assert(conf.EvictedStores, HasLen, 0)
+	op = bl.Schedule(tc)
+	testutil.CheckTransferLeader(c, op[0], operator.OpKind(0), 2, 1)
+}
+
+func (s *testEvictSlowStoreSuite) TestRestartWithEvictedStore(c *C) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	opt := config.NewTestOptions()
+	tc := mockcluster.NewCluster(opt)
+
+	tc.AddLeaderStore(1, 0)
+	tc.AddLeaderStore(2, 0)
+	tc.AddLeaderRegion(1, 1, 2)
+
+	// The scheduler of the new leader is created with the persisted config.
+	storage := core.NewStorage(kv.NewMemoryKV())
+	data, err := schedule.EncodeConfig(&evictSlowStoreSchedulerConfig{EvictedStores: []uint64{1}})
+	c.Assert(err, IsNil)
+	c.Assert(storage.SaveScheduleConfig(EvictSlowStoreName, data), IsNil)
+	es, err := schedule.CreateScheduler(EvictSlowStoreType, schedule.NewOperatorController(ctx, nil, nil), storage, schedule.ConfigJSONDecoder(data))
+	c.Assert(err, IsNil)
+	c.Assert(es.Prepare(tc), IsNil)
+	c.Assert(tc.GetStore(1).EvictedAsSlowStore(), IsTrue)
+
+	// The store keeps evicted while its slow score is warming up.
+	setStoreBusyHeartbeats(tc, 1, false, 2)
+	c.Assert(tc.GetStore(1).IsSlowScoreWarmingUp(), IsTrue)
+	op := es.Schedule(tc)
+	testutil.CheckTransferLeader(c, op[0], operator.OpLeader, 1, 2)
+	c.Assert(tc.GetStore(1).EvictedAsSlowStore(), IsTrue)
+
+	// It recovers once the slow score is calculated.
+	setStoreBusyHeartbeats(tc, 1, false, 10)
+	c.Assert(tc.GetStore(1).IsSlowScoreWarmingUp(), IsFalse)
+	c.Assert(es.Schedule(tc), IsNil)
+	c.Assert(tc.GetStore(1).EvictedAsSlowStore(), IsFalse)
+	persisted, err := storage.LoadScheduleConfig(EvictSlowStoreName)
+	c.Assert(err, IsNil)
+	conf := &evictSlowStoreSchedulerConfig{}
+	c.Assert(schedule.DecodeConfig([]byte(persisted), conf), IsNil)
+	c.Assert(conf.EvictedStores, HasLen, 0)
+}
+
+func (s *testEvictSlowStoreSuite) TestMultiSlowStores(c *C) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	opt := config.NewTestOptions()
+	tc := mockcluster.NewCluster(opt)
+
+	tc.AddLeaderStore(1, 0)
+	tc.AddLeaderStore(2, 0)
+	tc.AddLeaderStore(3, 0)
+	tc.AddLeaderRegion(1, 1, 2, 3)
+
+	es, err := schedule.CreateScheduler(EvictSlowStoreType, schedule.NewOperatorController(ctx, nil, nil), core.NewStorage(kv.NewMemoryKV()), schedule.ConfigSliceDecoder(EvictSlowStoreType, []string{}))
+	c.Assert(err, IsNil)
+
+	// Do not evict any store if there are more than one slow store.
+	setStoreBusyHeartbeats(tc, 1, true, 10)
+	setStoreBusyHeartbeats(tc, 2, true, 10)
+	c.Assert(es.Schedule(tc), IsNil)
+	c.Assert(tc.GetStore(1).EvictedAsSlowStore(), IsFalse)
+	c.Assert(tc.GetStore(2).EvictedAsSlowStore(), IsFalse)
+}
+
+type failedSaveKV struct {
+	kv.Base
+}
+
+func (kv *failedSaveKV) Save(key, value string) error {
+	return errors.New("save failed")
+}
+
+func (s *testEvictSlowStoreSuite) TestPrepareEvictFailure(c *C) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	opt := config.NewTestOptions()
+	tc := mockcluster.NewCluster(opt)
+
+	tc.AddLeaderStore(1, 0)
+	tc.AddLeaderStore(2, 0)
+	tc.AddLeaderRegion(1, 1, 2)
+	setStoreBusyHeartbeats(tc, 1, true, 10)
+
+	// The config is not persisted if the store fails to be evicted.
+	storage := core.NewStorage(kv.NewMemoryKV())
+	es, err := schedule.CreateScheduler(EvictSlowStoreType, schedule.NewOperatorController(ctx, nil, nil), storage, schedule.ConfigSliceDecoder(EvictSlowStoreType, []string{}))
+	c.Assert(err, IsNil)
+	c.Assert(tc.SlowStoreEvicted(1), IsNil)
+	c.Assert(es.Schedule(tc), IsNil)
+	data, err := storage.LoadScheduleConfig(EvictSlowStoreName)
+	c.Assert(err, IsNil)
+	conf := &evictSlowStoreSchedulerConfig{}
+	c.Assert(schedule.DecodeConfig([]byte(data), conf), IsNil)
+	c.Assert(conf.EvictedStores, HasLen, 0)
+	tc.SlowStoreRecovered(1)
+
+	// The store is resumed if the config fails to be persisted.
+	es, err = schedule.CreateScheduler(EvictSlowStoreType, schedule.NewOperatorController(ctx, nil, nil), core.NewStorage(&failedSaveKV{kv.NewMemoryKV()}), schedule.ConfigSliceDecoder(EvictSlowStoreType, []string{}))
+	c.Assert(err, IsNil)
+	c.Assert(es.Schedule(tc), IsNil)
+	c.Assert(tc.GetStore(1).EvictedAsSlowStore(), IsFalse)
+	c.Assert(es.(*evictSlowStoreScheduler).conf.evictStore(), Equals, uint64(0))
+}
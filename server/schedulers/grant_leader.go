@@ -166,6 +166,9 @@ func newGrantLeaderScheduler(opController *schedule.OperatorController, conf *gr
 }
 
 func (s *grantLeaderScheduler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.serveDiagnostic(w, r) {
+		return
+	}
 	s.handler.ServeHTTP(w, r)
 }
 
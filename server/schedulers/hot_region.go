@@ -147,6 +147,9 @@ func (h *hotScheduler) GetType() string {
 }
 
 func (h *hotScheduler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if h.serveDiagnostic(w, r) {
+		return
+	}
 	h.conf.ServeHTTP(w, r)
 }
 
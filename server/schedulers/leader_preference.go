@@ -0,0 +1,427 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedulers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gorilla/mux"
+	"github.com/pingcap/log"
+	"github.com/tikv/pd/pkg/errs"
+	"github.com/tikv/pd/server/core"
+	"github.com/tikv/pd/server/schedule"
+	"github.com/tikv/pd/server/schedule/filter"
+	"github.com/tikv/pd/server/schedule/operator"
+	"github.com/tikv/pd/server/schedule/opt"
+	"github.com/unrolled/render"
+	"go.uber.org/zap"
+)
+
+const (
+	// LeaderPreferenceName is leader preference scheduler name.
+	LeaderPreferenceName = "leader-preference-scheduler"
+	// LeaderPreferenceType is leader preference scheduler type.
+	LeaderPreferenceType = "leader-preference"
+)
+
+func init() {
+	schedule.RegisterSliceDecoderBuilder(LeaderPreferenceType, func(args []string) schedule.ConfigDecoder {
+		return func(v interface{}) error {
+			if len(args) != 1 {
+				return errs.ErrSchedulerConfig.FastGenByArgs("preferences")
+			}
+			conf, ok := v.(*leaderPreferenceSchedulerConfig)
+			if !ok {
+				return errs.ErrScheduleConfigNotExist.FastGenByArgs()
+			}
+			preferences, err := parseLeaderPreferences(args[0])
+			if err != nil {
+				return err
+			}
+			conf.Preferences = preferences
+			return nil
+		}
+	})
+
+	schedule.RegisterScheduler(LeaderPreferenceType, func(opController *schedule.OperatorController, storage *core.Storage, decoder schedule.ConfigDecoder) (schedule.Scheduler, error) {
+		conf := &leaderPreferenceSchedulerConfig{storage: storage}
+		if err := decoder(conf); err != nil {
+			return nil, err
+		}
+		return newLeaderPreferenceScheduler(opController, conf), nil
+	})
+}
+
+// LeaderPreference is the weight of the leaders placed on the stores with
+// the label.
+type LeaderPreference struct {
+	Key    string  `json:"key"`
+	Value  string  `json:"value"`
+	Weight float64 `json:"weight"`
+}
+
+func (p LeaderPreference) String() string {
+	return fmt.Sprintf("%s=%s:%s", p.Key, p.Value, strconv.FormatFloat(p.Weight, 'f', -1, 64))
+}
+
+// parseLeaderPreferences parses the preferences like "zone=z1:3,zone=z2:1".
+func parseLeaderPreferences(s string) ([]LeaderPreference, error) {
+	var preferences []LeaderPreference
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		i, j := strings.Index(item, "="), strings.LastIndex(item, ":")
+		if i <= 0 || j <= i+1 {
+			return nil, errs.ErrSchedulerConfig.FastGenByArgs(fmt.Sprintf("preference %s", item))
+		}
+		weight, err := strconv.ParseFloat(item[j+1:], 64)
+		if err != nil {
+			return nil, errs.ErrSchedulerConfig.FastGenByArgs(fmt.Sprintf("preference %s: %v", item, err))
+		}
+		preferences = append(preferences, LeaderPreference{
+			Key:    strings.TrimSpace(item[:i]),
+			Value:  strings.TrimSpace(item[i+1 : j]),
+			Weight: weight,
+		})
+	}
+	if err := validateLeaderPreferences(preferences); err != nil {
+		return nil, err
+	}
+	return preferences, nil
+}
+
+func validateLeaderPreferences(preferences []LeaderPreference) error {
+	if len(preferences) == 0 {
+		return errs.ErrSchedulerConfig.FastGenByArgs("preferences")
+	}
+	var sum float64
+	for i, p := range preferences {
+		if p.Key == "" || p.Value == "" || p.Weight < 0 {
+			return errs.ErrSchedulerConfig.FastGenByArgs(fmt.Sprintf("preference %s", p))
+		}
+		for _, q := range preferences[:i] {
+			if q.Key == p.Key && q.Value == p.Value {
+				return errs.ErrSchedulerConfig.FastGenByArgs(fmt.Sprintf("duplicated preference %s=%s", p.Key, p.Value))
+			}
+		}
+		sum += p.Weight
+	}
+	if sum <= 0 {
+		return errs.ErrSchedulerConfig.FastGenByArgs("the sum of preference weights should be positive")
+	}
+	return nil
+}
+
+type leaderPreferenceSchedulerConfig struct {
+	mu          sync.RWMutex
+	storage     *core.Storage
+	Preferences []LeaderPreference `json:"preferences"`
+}
+
+func (conf *leaderPreferenceSchedulerConfig) EncodeConfig() ([]byte, error) {
+	conf.mu.RLock()
+	defer conf.mu.RUnlock()
+	return schedule.EncodeConfig(conf)
+}
+
+func (conf *leaderPreferenceSchedulerConfig) getPreferences() []LeaderPreference {
+	conf.mu.RLock()
+	defer conf.mu.RUnlock()
+	return append([]LeaderPreference(nil), conf.Preferences...)
+}
+
+// groupOf returns the index of the first preference matching the store, or
+// -1 if no preference matches.
+func groupOf(preferences []LeaderPreference, store *core.StoreInfo) int {
+	for i, p := range preferences {
+		if store.GetLabelValue(p.Key) == p.Value {
+			return i
+		}
+	}
+	return -1
+}
+
+// InSameGroup checks if the two stores match the same preference.
+func (conf *leaderPreferenceSchedulerConfig) InSameGroup(source, target *core.StoreInfo) bool {
+	conf.mu.RLock()
+	defer conf.mu.RUnlock()
+	return groupOf(conf.Preferences, source) == groupOf(conf.Preferences, target)
+}
+
+func (conf *leaderPreferenceSchedulerConfig) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	router := mux.NewRouter()
+	router.HandleFunc("/list", conf.handleGetConfig).Methods("GET")
+	router.HandleFunc("/config", conf.handleSetConfig).Methods("POST")
+	router.ServeHTTP(w, r)
+}
+
+func (conf *leaderPreferenceSchedulerConfig) handleGetConfig(w http.ResponseWriter, r *http.Request) {
+	conf.mu.RLock()
+	defer conf.mu.RUnlock()
+	rd := render.New(render.Options{IndentJSON: true})
+	rd.JSON(w, http.StatusOK, conf)
+}
+
+func (conf *leaderPreferenceSchedulerConfig) handleSetConfig(w http.ResponseWriter, r *http.Request) {
+	rd := render.New(render.Options{IndentJSON: true})
+	data, err := ioutil.ReadAll(r.Body)
+	r.Body.Close()
+	if err != nil {
+		rd.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	// The preferences can be either a list or a string like "zone=z1:3,zone=z2:1".
+	var newConf struct {
+		Preferences json.RawMessage `json:"preferences"`
+	}
+	if err := json.Unmarshal(data, &newConf); err != nil {
+		rd.JSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	var preferences []LeaderPreference
+	var str string
+	if err := json.Unmarshal(newConf.Preferences, &str); err == nil {
+		preferences, err = parseLeaderPreferences(str)
+		if err != nil {
+			rd.JSON(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	} else if err := json.Unmarshal(newConf.Preferences, &preferences); err != nil {
+		rd.JSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := validateLeaderPreferences(preferences); err != nil {
+		rd.JSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	conf.mu.Lock()
+	defer conf.mu.Unlock()
+	conf.Preferences = preferences
+	if err := conf.persist(); err != nil {
+		rd.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	rd.Text(w, http.StatusOK, "success")
+}
+
+func (conf *leaderPreferenceSchedulerConfig) persist() error {
+	data, err := schedule.EncodeConfig(conf)
+	if err != nil {
+		return err
+	}
+	return conf.storage.SaveScheduleConfig(LeaderPreferenceName, data)
+}
+
+// leaderGroup is the stores matching a preference and the leaders on them.
+type leaderGroup struct {
+	weight  float64
+	stores  []*core.StoreInfo
+	leaders int64
+	// diff is the number of leaders beyond the expectation.
+	diff float64
+}
+
+// leaderPreferenceScheduler distributes the leaders across the stores
+// grouped by the preferences in proportion to the preference weights. The
+// stores that match no preference are expected to have no leader. While the
+// scheduler is running, balance-leader-scheduler only balances leaders
+// within each group.
+type leaderPreferenceScheduler struct {
+	*BaseScheduler
+	conf    *leaderPreferenceSchedulerConfig
+	filters []filter.Filter
+}
+
+// newLeaderPreferenceScheduler creates a scheduler that places leaders
+// according to the preferred labels.
+func newLeaderPreferenceScheduler(opController *schedule.OperatorController, conf *leaderPreferenceSchedulerConfig) schedule.Scheduler {
+	s := &leaderPreferenceScheduler{
+		BaseScheduler: NewBaseScheduler(opController),
+		conf:          conf,
+	}
+	s.filters = []filter.Filter{
+		&filter.StoreStateFilter{ActionScope: s.GetName(), TransferLeader: true},
+		filter.NewSpecialUseFilter(s.GetName()),
+	}
+	return s
+}
+
+func (s *leaderPreferenceScheduler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.serveDiagnostic(w, r) {
+		return
+	}
+	s.conf.ServeHTTP(w, r)
+}
+
+func (s *leaderPreferenceScheduler) GetName() string {
+	return LeaderPreferenceName
+}
+
+func (s *leaderPreferenceScheduler) GetType() string {
+	return LeaderPreferenceType
+}
+
+func (s *leaderPreferenceScheduler) EncodeConfig() ([]byte, error) {
+	return s.conf.EncodeConfig()
+}
+
+func (s *leaderPreferenceScheduler) Prepare(cluster opt.Cluster) error {
+	cluster.SetLeaderGroups(s.conf)
+	return nil
+}
+
+func (s *leaderPreferenceScheduler) Cleanup(cluster opt.Cluster) {
+	cluster.SetLeaderGroups(nil)
+}
+
+func (s *leaderPreferenceScheduler) IsScheduleAllowed(cluster opt.Cluster) bool {
+	return s.OpController.OperatorCount(operator.OpLeader) < cluster.GetOpts().GetLeaderScheduleLimit()
+}
+
+func (s *leaderPreferenceScheduler) Schedule(cluster opt.Cluster) []*operator.Operator {
+	schedulerCounter.WithLabelValues(s.GetName(), "schedule").Inc()
+	source, target := s.selectGroups(cluster)
+	if source == nil || target == nil {
+		schedulerCounter.WithLabelValues(s.GetName(), "balanced").Inc()
+		return nil
+	}
+
+	sources := filter.SelectSourceStores(source.stores, s.filters, cluster.GetOpts())
+	sort.Slice(sources, func(i, j int) bool {
+		return sources[i].GetLeaderCount() > sources[j].GetLeaderCount()
+	})
+	targetIDs := make(map[uint64]struct{}, len(target.stores))
+	for _, store := range target.stores {
+		targetIDs[store.GetID()] = struct{}{}
+	}
+	s.diagnostic.setSourceStores(sources)
+	s.diagnostic.setTargetStores(target.stores)
+	for _, store := range sources {
+		for i := 0; i < balanceLeaderRetryLimit; i++ {
+			if op := s.transferLeaderOut(cluster, store, targetIDs); op != nil {
+				return []*operator.Operator{op}
+			}
+		}
+	}
+	schedulerCounter.WithLabelValues(s.GetName(), "no-target-store").Inc()
+	return nil
+}
+
+// selectGroups returns the group with the most extra leaders and the group
+// with the least leaders compared with the expectation. It returns nil if
+// moving a leader between them does not make the distribution better.
+func (s *leaderPreferenceScheduler) selectGroups(cluster opt.Cluster) (source, target *leaderGroup) {
+	preferences := s.conf.getPreferences()
+	groups := make([]*leaderGroup, len(preferences)+1)
+	for i := range groups {
+		groups[i] = &leaderGroup{}
+		if i < len(preferences) {
+			groups[i].weight = preferences[i].Weight
+		}
+	}
+	opInfluence := s.OpController.GetOpInfluence(cluster)
+	var total int64
+	for _, store := range cluster.GetStores() {
+		if store.IsTombstone() {
+			continue
+		}
+		// The last group holds the stores matching no preference.
+		g := groups[len(groups)-1]
+		if i := groupOf(preferences, store); i >= 0 {
+			g = groups[i]
+		}
+		leaders := int64(store.GetLeaderCount()) + opInfluence.GetStoreInfluence(store.GetID()).LeaderCount
+		g.stores = append(g.stores, store)
+		g.leaders += leaders
+		total += leaders
+	}
+
+	// The weights of the groups without any available store are ignored.
+	var sum float64
+	for _, g := range groups {
+		if g.weight > 0 && len(filter.SelectTargetStores(g.stores, s.filters, cluster.GetOpts())) > 0 {
+			sum += g.weight
+		} else {
+			g.weight = 0
+		}
+	}
+	if sum <= 0 {
+		return nil, nil
+	}
+	for _, g := range groups {
+		g.diff = float64(g.leaders) - float64(total)*g.weight/sum
+		if len(g.stores) == 0 {
+			continue
+		}
+		if source == nil || g.diff > source.diff {
+			source = g
+		}
+		if g.weight > 0 && (target == nil || g.diff < target.diff) {
+			target = g
+		}
+	}
+	// Moving a leader changes the difference of both groups by 1, which only
+	// makes sense if the gap is large enough.
+	if source == nil || target == nil || source.diff-target.diff < 2 {
+		return nil, nil
+	}
+	return source, target
+}
+
+// transferLeaderOut transfers the leader of a random region on the source
+// store to a follower in the target group with the fewest leaders.
+func (s *leaderPreferenceScheduler) transferLeaderOut(cluster opt.Cluster, source *core.StoreInfo, targetIDs map[uint64]struct{}) *operator.Operator {
+	region := cluster.RandLeaderRegion(source.GetID(), nil, opt.HealthRegion(cluster), opt.ScheduleAllowedRegion(cluster))
+	if region == nil {
+		schedulerCounter.WithLabelValues(s.GetName(), "no-leader-region").Inc()
+		s.diagnostic.skip("no-leader-region")
+		return nil
+	}
+	var candidates []*core.StoreInfo
+	for _, store := range cluster.GetFollowerStores(region) {
+		if _, ok := targetIDs[store.GetID()]; ok {
+			candidates = append(candidates, store)
+		}
+	}
+	finalFilters := s.filters
+	if leaderFilter := filter.NewPlacementLeaderSafeguard(s.GetName(), cluster, region, source); leaderFilter != nil {
+		finalFilters = append(s.filters, leaderFilter)
+	}
+	candidates = filter.SelectTargetStores(candidates, finalFilters, cluster.GetOpts())
+	if len(candidates) == 0 {
+		schedulerCounter.WithLabelValues(s.GetName(), "no-preferred-follower").Inc()
+		s.diagnostic.skip("no-preferred-follower")
+		return nil
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].GetLeaderCount() < candidates[j].GetLeaderCount()
+	})
+	target := candidates[0]
+	op, err := operator.CreateTransferLeaderOperator(LeaderPreferenceType, cluster, region, source.GetID(), target.GetID(), operator.OpLeader)
+	if err != nil {
+		log.Debug("fail to create leader preference operator", zap.Uint64("region-id", region.GetID()), errs.ZapError(err))
+		return nil
+	}
+	op.Counters = append(op.Counters, schedulerCounter.WithLabelValues(s.GetName(), "new-operator"))
+	return op
+}
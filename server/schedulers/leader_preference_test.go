@@ -0,0 +1,131 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedulers
+
+import (
+	"context"
+
+	. "github.com/pingcap/check"
+	"github.com/tikv/pd/pkg/mock/mockcluster"
+	"github.com/tikv/pd/pkg/testutil"
+	"github.com/tikv/pd/server/config"
+	"github.com/tikv/pd/server/core"
+	"github.com/tikv/pd/server/kv"
+	"github.com/tikv/pd/server/schedule"
+	"github.com/tikv/pd/server/schedule/operator"
+)
+
+var _ = Suite(&testLeaderPreferenceSuite{})
+
+type testLeaderPreferenceSuite struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func (s *testLeaderPreferenceSuite) SetUpTest(c *C) {
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+}
+
+func (s *testLeaderPreferenceSuite) TearDownTest(c *C) {
+	s.cancel()
+}
+
+func (s *testLeaderPreferenceSuite) TestParse(c *C) {
+	preferences, err := parseLeaderPreferences("zone=z1:3, zone=z2:1,host=h1:0.5")
+	c.Assert(err, IsNil)
+	c.Assert(preferences, DeepEquals, []LeaderPreference{
+		{Key: "zone", Value: "z1", Weight: 3},
+		{Key: "zone", Value: "z2", Weight: 1},
+		{Key: "host", Value: "h1", Weight: 0.5},
+	})
+	for _, s := range []string{"", "zone=z1", "zone:3", "=z1:3", "zone=:3", "zone=z1:x", "zone=z1:-1", "zone=z1:0", "zone=z1:1,zone=z1:2"} {
+		_, err := parseLeaderPreferences(s)
+		c.Assert(err, NotNil, Commentf("%s", s))
+	}
+}
+
+func (s *testLeaderPreferenceSuite) TestSchedule(c *C) {
+	opt := config.NewTestOptions()
+	tc := mockcluster.NewCluster(opt)
+	oc := schedule.NewOperatorController(s.ctx, tc, nil)
+	sche, err := schedule.CreateScheduler(LeaderPreferenceType, oc, core.NewStorage(kv.NewMemoryKV()), schedule.ConfigSliceDecoder(LeaderPreferenceType, []string{"zone=z1:3,zone=z2:1"}))
+	c.Assert(err, IsNil)
+
+	// Stores:     1    2    3    4    5
+	// Zone:       z1   z1   z2   z2   z3
+	// Leaders:    0    0    4    4    0
+	tc.AddLabelsStore(1, 8, map[string]string{"zone": "z1"})
+	tc.AddLabelsStore(2, 8, map[string]string{"zone": "z1"})
+	tc.AddLabelsStore(3, 8, map[string]string{"zone": "z2"})
+	tc.AddLabelsStore(4, 8, map[string]string{"zone": "z2"})
+	tc.AddLabelsStore(5, 8, map[string]string{"zone": "z3"})
+	tc.UpdateLeaderCount(3, 4)
+	tc.UpdateLeaderCount(4, 4)
+	tc.AddLeaderRegion(1, 3, 4, 1)
+	tc.AddLeaderRegion(2, 4, 3, 5)
+
+	// Leaders are moved from z2 to z1.
+	ops := sche.Schedule(tc)
+	c.Assert(ops, HasLen, 1)
+	testutil.CheckTransferLeader(c, ops[0], operator.OpLeader, 3, 1)
+
+	// z1 has 6 leaders and z2 has 2, which is balanced.
+	tc.UpdateLeaderCount(1, 3)
+	tc.UpdateLeaderCount(2, 3)
+	tc.UpdateLeaderCount(3, 1)
+	tc.UpdateLeaderCount(4, 1)
+	c.Assert(sche.Schedule(tc), HasLen, 0)
+
+	// The leaders on the store matching no preference are moved out.
+	tc.UpdateLeaderCount(5, 4)
+	tc.UpdateLeaderCount(2, 0)
+	tc.AddLeaderRegion(3, 5, 2, 3)
+	ops = sche.Schedule(tc)
+	c.Assert(ops, HasLen, 1)
+	testutil.CheckTransferLeader(c, ops[0], operator.OpLeader, 5, 2)
+}
+
+func (s *testLeaderPreferenceSuite) TestBalanceLeaderInGroup(c *C) {
+	opt := config.NewTestOptions()
+	tc := mockcluster.NewCluster(opt)
+	oc := schedule.NewOperatorController(s.ctx, tc, nil)
+	storage := core.NewStorage(kv.NewMemoryKV())
+	lb, err := schedule.CreateScheduler(BalanceLeaderType, oc, storage, schedule.ConfigSliceDecoder(BalanceLeaderType, []string{"", ""}))
+	c.Assert(err, IsNil)
+	sche, err := schedule.CreateScheduler(LeaderPreferenceType, oc, storage, schedule.ConfigSliceDecoder(LeaderPreferenceType, []string{"zone=z1:1,zone=z2:1"}))
+	c.Assert(err, IsNil)
+
+	// Stores:     1    2    3
+	// Zone:       z1   z1   z2
+	// Leaders:    16   0    0
+	// Region1:    L    -    F
+	tc.AddLabelsStore(1, 16, map[string]string{"zone": "z1"})
+	tc.AddLabelsStore(2, 16, map[string]string{"zone": "z1"})
+	tc.AddLabelsStore(3, 16, map[string]string{"zone": "z2"})
+	tc.UpdateLeaderCount(1, 16)
+	tc.AddLeaderRegion(1, 1, 3)
+	c.Assert(lb.Schedule(tc), HasLen, 1)
+
+	// The leaders are not balanced across the groups while the leader
+	// preference scheduler is running.
+	c.Assert(sche.Prepare(tc), IsNil)
+	c.Assert(lb.Schedule(tc), HasLen, 0)
+	tc.AddLeaderRegion(1, 1, 2, 3)
+	ops := lb.Schedule(tc)
+	c.Assert(ops, HasLen, 1)
+	testutil.CheckTransferLeader(c, ops[0], operator.OpLeader, 1, 2)
+
+	sche.Cleanup(tc)
+	c.Assert(tc.GetLeaderGroups(), IsNil)
+}
@@ -175,6 +175,9 @@ func newScatterRangeScheduler(opController *schedule.OperatorController, config
 }
 
 func (l *scatterRangeScheduler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if l.serveDiagnostic(w, r) {
+		return
+	}
 	l.handler.ServeHTTP(w, r)
 }
 
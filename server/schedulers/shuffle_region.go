@@ -79,6 +79,9 @@ func newShuffleRegionScheduler(opController *schedule.OperatorController, conf *
 }
 
 func (s *shuffleRegionScheduler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.serveDiagnostic(w, r) {
+		return
+	}
 	s.conf.ServeHTTP(w, r)
 }
 
@@ -0,0 +1,280 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedulers
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pingcap/log"
+	"github.com/tikv/pd/pkg/errs"
+	"github.com/tikv/pd/server/core"
+	"github.com/tikv/pd/server/schedule"
+	"github.com/tikv/pd/server/schedule/operator"
+	"github.com/tikv/pd/server/schedule/opt"
+	"github.com/tikv/pd/server/statistics"
+	"github.com/unrolled/render"
+	"go.uber.org/zap"
+)
+
+const (
+	// SplitHotRegionName is split hot region scheduler name.
+	SplitHotRegionName = "split-hot-region-scheduler"
+	// SplitHotRegionType is split hot region scheduler type.
+	SplitHotRegionType = "split-hot-region"
+
+	// splitHotRegionCoolDown is the duration to wait before splitting a region
+	// again, so that the flow of the split region can be reported by the
+	// following heartbeats.
+	splitHotRegionCoolDown = 2 * statistics.RegionHeartBeatReportInterval * time.Second
+)
+
+func init() {
+	schedule.RegisterSliceDecoderBuilder(SplitHotRegionType, func(args []string) schedule.ConfigDecoder {
+		return func(v interface{}) error {
+			return nil
+		}
+	})
+
+	schedule.RegisterScheduler(SplitHotRegionType, func(opController *schedule.OperatorController, storage *core.Storage, decoder schedule.ConfigDecoder) (schedule.Scheduler, error) {
+		conf := initSplitHotRegionSchedulerConfig()
+		if err := decoder(conf); err != nil {
+			return nil, err
+		}
+		conf.storage = storage
+		return newSplitHotRegionScheduler(opController, conf), nil
+	})
+}
+
+func initSplitHotRegionSchedulerConfig() *splitHotRegionSchedulerConfig {
+	return &splitHotRegionSchedulerConfig{
+		MinHotDegree:   5,
+		MinHotByteRate: 30 * 1024 * 1024,
+		MinHotKeyRate:  3000,
+		SplitLimit:     4,
+	}
+}
+
+type splitHotRegionSchedulerConfig struct {
+	sync.RWMutex
+	storage *core.Storage
+
+	// MinHotDegree is the number of consecutive report intervals that a region
+	// should stay hot before it is split.
+	MinHotDegree   int     `json:"min-hot-degree"`
+	MinHotByteRate float64 `json:"min-hot-byte-rate"`
+	MinHotKeyRate  float64 `json:"min-hot-key-rate"`
+	// SplitLimit is the max number of split operators created by the scheduler
+	// that can run at the same time.
+	SplitLimit uint64 `json:"split-limit"`
+}
+
+func (conf *splitHotRegionSchedulerConfig) EncodeConfig() ([]byte, error) {
+	conf.RLock()
+	defer conf.RUnlock()
+	return schedule.EncodeConfig(conf)
+}
+
+func (conf *splitHotRegionSchedulerConfig) GetSplitLimit() uint64 {
+	conf.RLock()
+	defer conf.RUnlock()
+	return conf.SplitLimit
+}
+
+// isHotEnough checks if the peer has been hot for enough report intervals.
+func (conf *splitHotRegionSchedulerConfig) isHotEnough(stat *statistics.HotPeerStat) bool {
+	conf.RLock()
+	defer conf.RUnlock()
+	return stat.HotDegree >= conf.MinHotDegree &&
+		(stat.GetByteRate() >= conf.MinHotByteRate || stat.GetKeyRate() >= conf.MinHotKeyRate)
+}
+
+func (conf *splitHotRegionSchedulerConfig) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	router := mux.NewRouter()
+	router.HandleFunc("/list", conf.handleGetConfig).Methods("GET")
+	router.HandleFunc("/config", conf.handleSetConfig).Methods("POST")
+	router.ServeHTTP(w, r)
+}
+
+func (conf *splitHotRegionSchedulerConfig) handleGetConfig(w http.ResponseWriter, r *http.Request) {
+	conf.RLock()
+	defer conf.RUnlock()
+	rd := render.New(render.Options{IndentJSON: true})
+	rd.JSON(w, http.StatusOK, conf)
+}
+
+func (conf *splitHotRegionSchedulerConfig) handleSetConfig(w http.ResponseWriter, r *http.Request) {
+	conf.Lock()
+	defer conf.Unlock()
+	rd := render.New(render.Options{IndentJSON: true})
+	data, err := ioutil.ReadAll(r.Body)
+	r.Body.Close()
+	if err != nil {
+		rd.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	oldc, _ := json.Marshal(conf)
+	newConf := struct {
+		MinHotDegree   int     `json:"min-hot-degree"`
+		MinHotByteRate float64 `json:"min-hot-byte-rate"`
+		MinHotKeyRate  float64 `json:"min-hot-key-rate"`
+		SplitLimit     uint64  `json:"split-limit"`
+	}{conf.MinHotDegree, conf.MinHotByteRate, conf.MinHotKeyRate, conf.SplitLimit}
+	if err := json.Unmarshal(data, &newConf); err != nil {
+		rd.JSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if newConf.MinHotDegree <= 0 || newConf.MinHotByteRate < 0 || newConf.MinHotKeyRate < 0 {
+		rd.JSON(w, http.StatusBadRequest, errs.ErrSchedulerConfig.FastGenByArgs("split-hot-region-scheduler").Error())
+		return
+	}
+	conf.MinHotDegree, conf.MinHotByteRate, conf.MinHotKeyRate, conf.SplitLimit =
+		newConf.MinHotDegree, newConf.MinHotByteRate, newConf.MinHotKeyRate, newConf.SplitLimit
+	newc, _ := json.Marshal(conf)
+	if bytes.Equal(oldc, newc) {
+		rd.Text(w, http.StatusOK, "no changed")
+		return
+	}
+	if err := conf.persist(); err != nil {
+		rd.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	rd.Text(w, http.StatusOK, "success")
+}
+
+func (conf *splitHotRegionSchedulerConfig) persist() error {
+	data, err := schedule.EncodeConfig(conf)
+	if err != nil {
+		return err
+	}
+	return conf.storage.SaveScheduleConfig(SplitHotRegionName, data)
+}
+
+// splitHotRegionScheduler splits the regions which stay hot for a while, so
+// that the load of a hot spot can be spread by the hot region scheduler.
+type splitHotRegionScheduler struct {
+	*BaseScheduler
+	conf *splitHotRegionSchedulerConfig
+	// recentSplits records the regions split recently and the time they are
+	// split, which is only accessed by the schedule goroutine.
+	recentSplits map[uint64]time.Time
+}
+
+// newSplitHotRegionScheduler creates a scheduler that splits the regions which
+// are hot for several consecutive report intervals.
+func newSplitHotRegionScheduler(opController *schedule.OperatorController, conf *splitHotRegionSchedulerConfig) schedule.Scheduler {
+	return &splitHotRegionScheduler{
+		BaseScheduler: NewBaseScheduler(opController),
+		conf:          conf,
+		recentSplits:  make(map[uint64]time.Time),
+	}
+}
+
+func (s *splitHotRegionScheduler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.serveDiagnostic(w, r) {
+		return
+	}
+	s.conf.ServeHTTP(w, r)
+}
+
+func (s *splitHotRegionScheduler) GetName() string {
+	return SplitHotRegionName
+}
+
+func (s *splitHotRegionScheduler) GetType() string {
+	return SplitHotRegionType
+}
+
+func (s *splitHotRegionScheduler) EncodeConfig() ([]byte, error) {
+	return s.conf.EncodeConfig()
+}
+
+func (s *splitHotRegionScheduler) IsScheduleAllowed(cluster opt.Cluster) bool {
+	return s.OpController.OperatorCount(operator.OpSplit) < s.conf.GetSplitLimit()
+}
+
+func (s *splitHotRegionScheduler) Schedule(cluster opt.Cluster) []*operator.Operator {
+	schedulerCounter.WithLabelValues(s.GetName(), "schedule").Inc()
+	now := time.Now()
+	for id, t := range s.recentSplits {
+		if now.Sub(t) > splitHotRegionCoolDown {
+			delete(s.recentSplits, id)
+		}
+	}
+
+	for _, stat := range s.collectHotPeers(cluster) {
+		if _, ok := s.recentSplits[stat.RegionID]; ok {
+			continue
+		}
+		region := cluster.GetRegion(stat.RegionID)
+		if region == nil || !opt.IsRegionHealthy(cluster, region) {
+			schedulerCounter.WithLabelValues(s.GetName(), "unhealthy-region").Inc()
+			continue
+		}
+		if !opt.IsRegionScheduleAllowed(cluster, region) {
+			schedulerCounter.WithLabelValues(s.GetName(), "deny-region").Inc()
+			continue
+		}
+		if s.OpController.GetOperator(region.GetID()) != nil {
+			continue
+		}
+		op, err := operator.CreateLoadSplitRegionOperator(SplitHotRegionType, region, operator.OpHotRegion, cluster.GetOpts().GetKeyType())
+		if err != nil {
+			log.Debug("fail to create split hot region operator", zap.Uint64("region-id", region.GetID()), errs.ZapError(err))
+			schedulerCounter.WithLabelValues(s.GetName(), "no-split-key").Inc()
+			continue
+		}
+		s.recentSplits[region.GetID()] = now
+		schedulerCounter.WithLabelValues(s.GetName(), "new-operator").Inc()
+		return []*operator.Operator{op}
+	}
+	schedulerCounter.WithLabelValues(s.GetName(), "no-hot-region").Inc()
+	return nil
+}
+
+// collectHotPeers returns the read and write hot peers that are hot enough to
+// split, sorted by the byte rate in descending order. Each region appears at
+// most once.
+func (s *splitHotRegionScheduler) collectHotPeers(cluster opt.Cluster) []*statistics.HotPeerStat {
+	candidates := make(map[uint64]*statistics.HotPeerStat)
+	for _, stats := range []map[uint64][]*statistics.HotPeerStat{cluster.RegionReadStats(), cluster.RegionWriteStats()} {
+		for _, peers := range stats {
+			for _, stat := range peers {
+				if !s.conf.isHotEnough(stat) {
+					continue
+				}
+				if old, ok := candidates[stat.RegionID]; !ok || old.GetByteRate() < stat.GetByteRate() {
+					candidates[stat.RegionID] = stat
+				}
+			}
+		}
+	}
+	ret := make([]*statistics.HotPeerStat, 0, len(candidates))
+	for _, stat := range candidates {
+		ret = append(ret, stat)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].GetByteRate() != ret[j].GetByteRate() {
+			return ret[i].GetByteRate() > ret[j].GetByteRate()
+		}
+		return ret[i].RegionID < ret[j].RegionID
+	})
+	return ret
+}
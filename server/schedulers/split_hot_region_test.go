@@ -0,0 +1,97 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedulers
+
+import (
+	"context"
+	"time"
+
+	. "github.com/pingcap/check"
+	"github.com/tikv/pd/pkg/mock/mockcluster"
+	"github.com/tikv/pd/server/config"
+	"github.com/tikv/pd/server/core"
+	"github.com/tikv/pd/server/kv"
+	"github.com/tikv/pd/server/schedule"
+	"github.com/tikv/pd/server/schedule/labeler"
+	"github.com/tikv/pd/server/schedule/operator"
+	"github.com/tikv/pd/server/versioninfo"
+)
+
+var _ = Suite(&testSplitHotRegionSuite{})
+
+type testSplitHotRegionSuite struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func (s *testSplitHotRegionSuite) SetUpTest(c *C) {
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+}
+
+func (s *testSplitHotRegionSuite) TearDownTest(c *C) {
+	s.cancel()
+}
+
+func (s *testSplitHotRegionSuite) TestSplitHotRegion(c *C) {
+	opt := config.NewTestOptions()
+	tc := mockcluster.NewCluster(opt)
+	tc.DisableFeature(versioninfo.JointConsensus)
+	tc.SetHotRegionCacheHitsThreshold(0)
+	oc := schedule.NewOperatorController(s.ctx, tc, nil)
+	sche, err := schedule.CreateScheduler(SplitHotRegionType, oc, core.NewStorage(kv.NewMemoryKV()), schedule.ConfigSliceDecoder(SplitHotRegionType, nil))
+	c.Assert(err, IsNil)
+	sche.(*splitHotRegionScheduler).conf.MinHotDegree = 3
+
+	for i := uint64(1); i <= 3; i++ {
+		tc.AddRegionStore(i, 0)
+	}
+	c.Assert(sche.Schedule(tc), HasLen, 0)
+
+	// Region 1 is not hot for enough report intervals.
+	addRegionInfo(tc, read, []testRegionInfo{
+		{1, []uint64{1, 2, 3}, 40 * MB, 0},
+		{2, []uint64{2, 1, 3}, 10 * MB, 0},
+	})
+	c.Assert(sche.Schedule(tc), HasLen, 0)
+
+	// Region 1 stays hot, while region 2 is hot but the flow is too small.
+	addRegionInfo(tc, read, []testRegionInfo{
+		{1, []uint64{1, 2, 3}, 40 * MB, 0},
+		{2, []uint64{2, 1, 3}, 10 * MB, 0},
+	})
+	ops := sche.Schedule(tc)
+	c.Assert(ops, HasLen, 1)
+	c.Assert(ops[0].RegionID(), Equals, uint64(1))
+	c.Assert(ops[0].Kind(), Equals, operator.OpHotRegion|operator.OpSplit)
+	// The region split recently is skipped.
+	c.Assert(sche.Schedule(tc), HasLen, 0)
+
+	// Region 2 becomes hot with key rate.
+	for i := 0; i < 2; i++ {
+		addRegionInfo(tc, write, []testRegionInfo{
+			{2, []uint64{2, 1, 3}, 10 * MB, 5000},
+		})
+	}
+	ops = sche.Schedule(tc)
+	c.Assert(ops, HasLen, 1)
+	c.Assert(ops[0].RegionID(), Equals, uint64(2))
+
+	// Regions with schedule=deny label are skipped.
+	sche.(*splitHotRegionScheduler).recentSplits = make(map[uint64]time.Time)
+	c.Assert(tc.GetRegionLabeler().SetLabelRule(&labeler.LabelRule{
+		ID:     "deny",
+		Labels: []labeler.RegionLabel{{Key: labeler.ScheduleOptionKey, Value: labeler.ScheduleOptionValueDeny}},
+	}), IsNil)
+	c.Assert(sche.Schedule(tc), HasLen, 0)
+}
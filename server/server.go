@@ -45,6 +45,7 @@ import (
 	"github.com/tikv/pd/pkg/logutil"
 	"github.com/tikv/pd/pkg/systimemon"
 	"github.com/tikv/pd/pkg/typeutil"
+	"github.com/tikv/pd/pkg/watch"
 	"github.com/tikv/pd/server/cluster"
 	"github.com/tikv/pd/server/config"
 	"github.com/tikv/pd/server/core"
@@ -59,7 +60,6 @@ import (
 	"github.com/tikv/pd/server/tso"
 	"github.com/tikv/pd/server/versioninfo"
 	"github.com/urfave/negroni"
-	"go.etcd.io/etcd/clientv3"
 	"go.etcd.io/etcd/embed"
 	"go.etcd.io/etcd/pkg/types"
 	"go.uber.org/zap"
@@ -67,7 +67,6 @@ import (
 )
 
 const (
-	etcdTimeout           = time.Second * 3
 	serverMetricsInterval = time.Minute
 	leaderTickInterval    = 50 * time.Millisecond
 	// pdRootPath for all pd servers.
@@ -105,13 +104,20 @@ type Server struct {
 	serverLoopWg     sync.WaitGroup
 
 	// for PD leader election.
-	member *member.Member
-	// etcd client
-	client *clientv3.Client
+	member member.ElectionMember
+	// kv stores the metadata which doesn't belong to the cluster, it has no
+	// root path.
+	kv kv.TTLBase
+	// leveldb stores the metadata in the standalone mode.
+	leveldb *kv.LeveldbKV
+	// standalone serves the client traffic in the standalone mode.
+	standalone *standaloneServer
 	// http client
 	httpClient *http.Client
 	clusterID  uint64 // pd cluster id.
 	rootPath   string
+	// leader URL -> gRPC connection, to forward the requests to the leader.
+	clientConns sync.Map // Store as map[string]*grpc.ClientConn
 
 	// Server services.
 	// for id allocator, we can use one allocator for
@@ -126,6 +132,8 @@ type Server struct {
 	basicCluster *core.BasicCluster
 	// for tso.
 	tsoAllocatorManager *tso.AllocatorManager
+	// tsoMerger merges the tso requests of the concurrent streams.
+	tsoMerger *tsoMerger
 	// for raft cluster
 	cluster *cluster.RaftCluster
 	// For async region heartbeat.
@@ -222,7 +230,7 @@ func CreateServer(ctx context.Context, cfg *config.Config, serviceBuilders ...Ha
 	s := &Server{
 		cfg:               cfg,
 		persistOptions:    config.NewPersistOptions(cfg),
-		member:            &member.Member{},
+		member:            &member.EmbeddedEtcdMember{},
 		ctx:               ctx,
 		startTimestamp:    time.Now().Unix(),
 		DiagnosticsServer: sysutil.NewDiagnosticsServer(cfg.Log.File.Filename),
@@ -245,6 +253,8 @@ func CreateServer(ctx context.Context, cfg *config.Config, serviceBuilders ...Ha
 	etcdCfg.ServiceRegister = func(gs *grpc.Server) {
 		pdpb.RegisterPDServer(gs, s)
 		diagnosticspb.RegisterDiagnosticsServer(gs, s)
+		watch.RegisterServer(gs, s)
+		syncer.RegisterSnapshotService(gs, s)
 	}
 	s.etcdCfg = etcdCfg
 	if EnableZap {
@@ -290,35 +300,26 @@ func (s *Server) startEtcd(ctx context.Context) error {
 		return errs.ErrCancelStartEtcd.FastGenByArgs()
 	}
 
-	endpoints := []string{s.etcdCfg.ACUrls[0].String()}
-	log.Info("create etcd v3 client", zap.Strings("endpoints", endpoints), zap.Reflect("cert", s.cfg.Security))
-
-	client, err := clientv3.New(clientv3.Config{
-		Endpoints:   endpoints,
-		DialTimeout: etcdTimeout,
-		TLS:         tlsConfig,
-	})
+	etcdMember, err := member.NewEmbeddedEtcdMember(etcd, tlsConfig)
 	if err != nil {
-		return errs.ErrNewEtcdClient.Wrap(err).GenWithStackByCause()
+		return err
 	}
 
-	etcdServerID := uint64(etcd.Server.ID())
-
 	// update advertise peer urls.
-	etcdMembers, err := etcdutil.ListEtcdMembers(client)
+	etcdMembers, err := etcdMember.GetMembers()
 	if err != nil {
 		return err
 	}
-	for _, m := range etcdMembers.Members {
-		if etcdServerID == m.ID {
-			etcdPeerURLs := strings.Join(m.PeerURLs, ",")
+	for _, m := range etcdMembers {
+		if etcdMember.ID() == m.GetMemberId() {
+			etcdPeerURLs := strings.Join(m.GetPeerUrls(), ",")
 			if s.cfg.AdvertisePeerUrls != etcdPeerURLs {
 				log.Info("update advertise peer urls", zap.String("from", s.cfg.AdvertisePeerUrls), zap.String("to", etcdPeerURLs))
 				s.cfg.AdvertisePeerUrls = etcdPeerURLs
 			}
 		}
 	}
-	s.client = client
+	s.kv = kv.NewEtcdKVBase(etcdMember.Client(), "")
 	s.httpClient = &http.Client{
 		Transport: &http.Transport{
 			DisableKeepAlives: true,
@@ -329,7 +330,7 @@ func (s *Server) startEtcd(ctx context.Context) error {
 	failpoint.Inject("memberNil", func() {
 		time.Sleep(1500 * time.Millisecond)
 	})
-	s.member = member.NewMember(etcd, client, etcdServerID)
+	s.member = etcdMember
 	return nil
 }
 
@@ -353,22 +354,28 @@ func (s *Server) startServer(ctx context.Context) error {
 	s.member.SetMemberDeployPath(s.member.ID())
 	s.member.SetMemberBinaryVersion(s.member.ID(), versioninfo.PDReleaseVersion)
 	s.member.SetMemberGitHash(s.member.ID(), versioninfo.PDGitHash)
-	s.idAllocator = id.NewAllocatorImpl(s.client, s.rootPath, s.member.MemberValue())
+	s.idAllocator = id.NewAllocatorImpl(s.kv, s.rootPath, s.member.MemberValue())
 	s.tsoAllocatorManager = tso.NewAllocatorManager(
-		s.member, s.rootPath, s.cfg.TSOSaveInterval.Duration, s.cfg.TSOUpdatePhysicalInterval.Duration,
+		s.member, s.kv, s.rootPath, s.cfg.TSOSaveInterval.Duration, s.cfg.TSOUpdatePhysicalInterval.Duration,
 		func() time.Duration { return s.persistOptions.GetMaxResetTSGap() },
 		s.GetTLSConfig())
+	s.tsoMerger = newTSOMerger(s.tsoAllocatorManager.HandleTSORequest)
 	if err = s.tsoAllocatorManager.SetLocalTSOConfig(s.cfg.LocalTSO); err != nil {
 		return err
 	}
-	encryptionKeyManager, err := encryptionkm.NewKeyManager(s.client, &s.cfg.Security.Encryption)
-	if err != nil {
-		return err
+	var kvBase kv.Base
+	if etcdMember, ok := s.member.(*member.EmbeddedEtcdMember); ok {
+		s.encryptionKeyManager, err = encryptionkm.NewKeyManager(etcdMember.Client(), &s.cfg.Security.Encryption)
+		if err != nil {
+			return err
+		}
+		kvBase = kv.NewEtcdKVBase(etcdMember.Client(), s.rootPath)
+	} else {
+		// The encryption is disabled in the standalone mode.
+		kvBase = kv.NewRootPathKV(s.leveldb, s.rootPath)
 	}
-	s.encryptionKeyManager = encryptionKeyManager
-	kvBase := kv.NewEtcdKVBase(s.client, s.rootPath)
 	path := filepath.Join(s.cfg.DataDir, "region-meta")
-	regionStorage, err := core.NewRegionStorage(ctx, path, encryptionKeyManager)
+	regionStorage, err := core.NewRegionStorage(ctx, path, s.encryptionKeyManager)
 	if err != nil {
 		return err
 	}
@@ -376,10 +383,10 @@ func (s *Server) startServer(ctx context.Context) error {
 	s.storage = core.NewStorage(
 		kvBase,
 		core.WithRegionStorage(regionStorage),
-		core.WithEncryptionKeyManager(encryptionKeyManager),
+		core.WithEncryptionKeyManager(s.encryptionKeyManager),
 	)
 	s.basicCluster = core.NewBasicCluster()
-	s.cluster = cluster.NewRaftCluster(ctx, s.GetClusterRootPath(), s.clusterID, syncer.NewRegionSyncer(s), s.client, s.httpClient)
+	s.cluster = cluster.NewRaftCluster(ctx, s.GetClusterRootPath(), s.clusterID, syncer.NewRegionSyncer(s), s.member, s.kv, s.httpClient)
 	s.hbStreams = hbstream.NewHeartbeatStreams(ctx, s.clusterID, s.cluster)
 
 	// Run callbacks
@@ -394,17 +401,17 @@ func (s *Server) startServer(ctx context.Context) error {
 
 func (s *Server) initClusterID() error {
 	// Get any cluster key to parse the cluster ID.
-	resp, err := etcdutil.EtcdKVGet(s.client, pdClusterIDPath)
+	value, err := s.kv.Load(pdClusterIDPath)
 	if err != nil {
 		return err
 	}
 
 	// If no key exist, generate a random cluster ID.
-	if len(resp.Kvs) == 0 {
-		s.clusterID, err = initOrGetClusterID(s.client, pdClusterIDPath)
+	if len(value) == 0 {
+		s.clusterID, err = initOrGetClusterID(s.kv, pdClusterIDPath)
 		return err
 	}
-	s.clusterID, err = typeutil.BytesToUint64(resp.Kvs[0].Value)
+	s.clusterID, err = typeutil.BytesToUint64([]byte(value))
 	return err
 }
 
@@ -424,19 +431,16 @@ func (s *Server) Close() {
 
 	s.stopServerLoop()
 
-	if s.client != nil {
-		if err := s.client.Close(); err != nil {
-			log.Error("close etcd client meet error", errs.ZapError(errs.ErrCloseEtcdClient, err))
-		}
+	if s.standalone != nil {
+		s.standalone.Close()
 	}
 
 	if s.httpClient != nil {
 		s.httpClient.CloseIdleConnections()
 	}
+	s.closeDelegateClients()
 
-	if s.member.Etcd() != nil {
-		s.member.Close()
-	}
+	s.member.Close()
 
 	if s.hbStreams != nil {
 		s.hbStreams.Close()
@@ -444,6 +448,11 @@ func (s *Server) Close() {
 	if err := s.storage.Close(); err != nil {
 		log.Error("close storage meet error", errs.ZapError(err))
 	}
+	if s.leveldb != nil {
+		if err := s.leveldb.Close(); err != nil {
+			log.Error("close leveldb meet error", errs.ZapError(errs.ErrLevelDBClose, err))
+		}
+	}
 
 	// Run callbacks
 	for _, cb := range s.closeCallbacks {
@@ -464,7 +473,11 @@ func (s *Server) Run() error {
 		log.Error("system time jumps backward", errs.ZapError(errs.ErrIncorrectSystemTime))
 		timeJumpBackCounter.Inc()
 	})
-	if err := s.startEtcd(s.ctx); err != nil {
+	if s.cfg.Standalone {
+		if err := s.startStandalone(); err != nil {
+			return err
+		}
+	} else if err := s.startEtcd(s.ctx); err != nil {
 		return err
 	}
 	if err := s.startServer(s.ctx); err != nil {
@@ -488,12 +501,15 @@ func (s *Server) LoopContext() context.Context {
 
 func (s *Server) startServerLoop(ctx context.Context) {
 	s.serverLoopCtx, s.serverLoopCancel = context.WithCancel(ctx)
-	s.serverLoopWg.Add(5)
+	s.serverLoopWg.Add(4)
 	go s.leaderLoop()
 	go s.etcdLeaderLoop()
 	go s.serverMetricsLoop()
 	go s.tsoAllocatorLoop()
-	go s.encryptionKeyManagerLoop()
+	if s.encryptionKeyManager != nil {
+		s.serverLoopWg.Add(1)
+		go s.encryptionKeyManagerLoop()
+	}
 }
 
 func (s *Server) stopServerLoop() {
@@ -541,9 +557,13 @@ func (s *Server) encryptionKeyManagerLoop() {
 }
 
 func (s *Server) collectEtcdStateMetrics() {
-	etcdStateGauge.WithLabelValues("term").Set(float64(s.member.Etcd().Server.Term()))
-	etcdStateGauge.WithLabelValues("appliedIndex").Set(float64(s.member.Etcd().Server.AppliedIndex()))
-	etcdStateGauge.WithLabelValues("committedIndex").Set(float64(s.member.Etcd().Server.CommittedIndex()))
+	etcdMember, ok := s.member.(*member.EmbeddedEtcdMember)
+	if !ok {
+		return
+	}
+	etcdStateGauge.WithLabelValues("term").Set(float64(etcdMember.Etcd().Server.Term()))
+	etcdStateGauge.WithLabelValues("appliedIndex").Set(float64(etcdMember.Etcd().Server.AppliedIndex()))
+	etcdStateGauge.WithLabelValues("committedIndex").Set(float64(etcdMember.Etcd().Server.CommittedIndex()))
 }
 
 func (s *Server) bootstrapCluster(req *pdpb.BootstrapRequest) (*pdpb.BootstrapResponse, error) {
@@ -569,15 +589,15 @@ func (s *Server) bootstrapCluster(req *pdpb.BootstrapRequest) (*pdpb.BootstrapRe
 	}
 	clusterRootPath := s.GetClusterRootPath()
 
-	var ops []clientv3.Op
-	ops = append(ops, clientv3.OpPut(clusterRootPath, string(clusterValue)))
+	var ops []kv.Op
+	ops = append(ops, kv.OpSave(clusterRootPath, string(clusterValue)))
 
 	// Set bootstrap time
 	bootstrapKey := makeBootstrapTimeKey(clusterRootPath)
 	nano := time.Now().UnixNano()
 
 	timeData := typeutil.Uint64ToBytes(uint64(nano))
-	ops = append(ops, clientv3.OpPut(bootstrapKey, string(timeData)))
+	ops = append(ops, kv.OpSave(bootstrapKey, string(timeData)))
 
 	// Set store meta
 	storeMeta := req.GetStore()
@@ -586,7 +606,7 @@ func (s *Server) bootstrapCluster(req *pdpb.BootstrapRequest) (*pdpb.BootstrapRe
 	if err != nil {
 		return nil, errors.WithStack(err)
 	}
-	ops = append(ops, clientv3.OpPut(storePath, string(storeValue)))
+	ops = append(ops, kv.OpSave(storePath, string(storeValue)))
 
 	regionValue, err := req.GetRegion().Marshal()
 	if err != nil {
@@ -595,15 +615,15 @@ func (s *Server) bootstrapCluster(req *pdpb.BootstrapRequest) (*pdpb.BootstrapRe
 
 	// Set region meta with region id.
 	regionPath := makeRegionKey(clusterRootPath, req.GetRegion().GetId())
-	ops = append(ops, clientv3.OpPut(regionPath, string(regionValue)))
+	ops = append(ops, kv.OpSave(regionPath, string(regionValue)))
 
 	// TODO: we must figure out a better way to handle bootstrap failed, maybe intervene manually.
-	bootstrapCmp := clientv3.Compare(clientv3.CreateRevision(clusterRootPath), "=", 0)
-	resp, err := kv.NewSlowLogTxn(s.client).If(bootstrapCmp).Then(ops...).Commit()
+	// The cluster root path must not exist.
+	ok, err := s.kv.Txn([]kv.Condition{{Key: clusterRootPath}}, ops)
 	if err != nil {
-		return nil, errs.ErrEtcdTxn.Wrap(err).GenWithStackByCause()
+		return nil, err
 	}
-	if !resp.Succeeded {
+	if !ok {
 		log.Warn("cluster already bootstrapped", zap.Uint64("cluster-id", clusterID))
 		return nil, errs.ErrEtcdTxn.FastGenByArgs()
 	}
@@ -662,14 +682,14 @@ func (s *Server) GetHandler() *Handler {
 	return s.handler
 }
 
-// GetEndpoints returns the etcd endpoints for outer use.
+// GetEndpoints returns the client urls of the server for outer use.
 func (s *Server) GetEndpoints() []string {
-	return s.client.Endpoints()
+	return s.member.Member().GetClientUrls()
 }
 
-// GetClient returns builtin etcd client.
-func (s *Server) GetClient() *clientv3.Client {
-	return s.client
+// GetKV returns the kv of server, which has no root path.
+func (s *Server) GetKV() kv.TTLBase {
+	return s.kv
 }
 
 // GetHTTPClient returns builtin etcd client.
@@ -683,7 +703,7 @@ func (s *Server) GetLeader() *pdpb.Member {
 }
 
 // GetMember returns the member of server.
-func (s *Server) GetMember() *member.Member {
+func (s *Server) GetMember() member.ElectionMember {
 	return s.member
 }
 
@@ -895,7 +915,11 @@ func (s *Server) SetPDServerConfig(cfg config.PDServerConfig) error {
 		if !strings.HasPrefix(cfg.DashboardAddress, "http") {
 			cfg.DashboardAddress = fmt.Sprintf("%s://%s", s.GetClientScheme(), cfg.DashboardAddress)
 		}
-		if !cluster.IsClientURL(cfg.DashboardAddress, s.client) {
+		members, err := s.member.GetMembers()
+		if err != nil {
+			return err
+		}
+		if !cluster.IsClientURL(cfg.DashboardAddress, members) {
 			return errors.Errorf("%s is not the client url of any member", cfg.DashboardAddress)
 		}
 	}
@@ -1208,9 +1232,11 @@ func (s *Server) campaignLeader() {
 		return
 	}
 
-	if err := s.encryptionKeyManager.SetLeadership(s.member.GetLeadership()); err != nil {
-		log.Error("failed to initialize encryption", errs.ZapError(err))
-		return
+	if s.encryptionKeyManager != nil {
+		if err := s.encryptionKeyManager.SetLeadership(s.member.GetLeadership()); err != nil {
+			log.Error("failed to initialize encryption", errs.ZapError(err))
+			return
+		}
 	}
 
 	// Try to create raft cluster.
@@ -1219,8 +1245,8 @@ func (s *Server) campaignLeader() {
 		return
 	}
 	defer s.stopRaftCluster()
-	if err := s.persistOptions.LoadTTLFromEtcd(s.ctx, s.client); err != nil {
-		log.Error("failed to load persistOptions from etcd", errs.ZapError(err))
+	if err := s.persistOptions.LoadTTLFromKV(s.ctx, s.kv); err != nil {
+		log.Error("failed to load persistOptions from kv", errs.ZapError(err))
 		return
 	}
 	s.member.EnableLeader()
@@ -1325,7 +1351,7 @@ func (s *Server) SaveTTLConfig(data map[string]interface{}, ttl time.Duration) e
 		}
 	}
 	for k, v := range data {
-		if err := s.persistOptions.SetTTLData(s.ctx, s.client, k, fmt.Sprint(v), ttl); err != nil {
+		if err := s.persistOptions.SetTTLData(s.ctx, s.kv, k, fmt.Sprint(v), ttl); err != nil {
 			return err
 		}
 	}
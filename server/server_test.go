@@ -15,8 +15,12 @@ package server
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"fmt"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"testing"
 
@@ -27,6 +31,10 @@ import (
 	"go.etcd.io/etcd/embed"
 	"go.etcd.io/etcd/pkg/types"
 	"go.uber.org/goleak"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
 )
 
 func TestServer(t *testing.T) {
@@ -185,6 +193,33 @@ func (s *testServerSuite) TestCheckClusterID(c *C) {
 	testutil.CleanServer(cfgA.DataDir)
 }
 
+func (s *testServerSuite) TestCheckWatcherCN(c *C) {
+	newContext := func(cn string) context.Context {
+		p := &peer.Peer{Addr: &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 1}}
+		if cn != "" {
+			p.AuthInfo = credentials.TLSInfo{State: tls.ConnectionState{
+				PeerCertificates: []*x509.Certificate{{Subject: pkix.Name{CommonName: cn}}},
+			}}
+		}
+		return peer.NewContext(context.Background(), p)
+	}
+	svr := &Server{cfg: config.NewConfig()}
+
+	// The watchers are not authenticated without TLS.
+	c.Assert(svr.checkWatcherCN(newContext("")), IsNil)
+	// The watchers must provide certificates with TLS.
+	svr.cfg.Security.CertPath, svr.cfg.Security.KeyPath = "pd.pem", "pd-key.pem"
+	c.Assert(status.Code(svr.checkWatcherCN(newContext(""))), Equals, codes.Unauthenticated)
+	c.Assert(svr.checkWatcherCN(newContext("tool")), IsNil)
+	// The common names must be allowed.
+	svr.cfg.Security.CertAllowedCN = []string{"pd"}
+	c.Assert(status.Code(svr.checkWatcherCN(newContext("tool"))), Equals, codes.PermissionDenied)
+	c.Assert(svr.checkWatcherCN(newContext("pd")), IsNil)
+	// The common names can not be checked without TLS.
+	svr.cfg.Security.CertPath, svr.cfg.Security.KeyPath = "", ""
+	c.Assert(status.Code(svr.checkWatcherCN(newContext(""))), Equals, codes.Unauthenticated)
+}
+
 var _ = Suite(&testServerHandlerSuite{})
 
 type testServerHandlerSuite struct{}
@@ -0,0 +1,210 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"crypto/tls"
+	"math"
+	"net"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/pingcap/log"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/soheilhy/cmux"
+	"github.com/tikv/pd/pkg/errs"
+	"github.com/tikv/pd/pkg/logutil"
+	"github.com/tikv/pd/server/kv"
+	"github.com/tikv/pd/server/member"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+)
+
+const (
+	// standaloneMetaDir is the directory under the data directory to store
+	// the metadata in the standalone mode.
+	standaloneMetaDir = "meta"
+	// grpcOverheadBytes is the size reserved for the gRPC message besides
+	// the request, the same as the embedded etcd.
+	grpcOverheadBytes = 512 * 1024
+)
+
+// standaloneServer serves the gRPC and HTTP requests on the client urls,
+// which are served by the embedded etcd if it is not in the standalone mode.
+type standaloneServer struct {
+	grpcServer *grpc.Server
+	httpServer *http.Server
+	listeners  []*trackingListener
+}
+
+// trackingListener tracks the accepted connections. cmux closes the listeners
+// of the gRPC and HTTP servers only after all connections are matched, and an
+// idle gRPC client never sends the headers to match, so the connections are
+// closed together with the listener to stop the servers.
+type trackingListener struct {
+	net.Listener
+	mu     sync.Mutex
+	conns  map[net.Conn]struct{}
+	closed bool
+}
+
+func newTrackingListener(l net.Listener) *trackingListener {
+	return &trackingListener{Listener: l, conns: make(map[net.Conn]struct{})}
+}
+
+func (l *trackingListener) Accept() (net.Conn, error) {
+	for {
+		c, err := l.Listener.Accept()
+		if err != nil {
+			return nil, err
+		}
+		l.mu.Lock()
+		if l.closed {
+			// The listener is being closed, the next Accept returns the error.
+			l.mu.Unlock()
+			c.Close()
+			continue
+		}
+		tc := &trackedConn{Conn: c, l: l}
+		l.conns[tc] = struct{}{}
+		l.mu.Unlock()
+		return tc, nil
+	}
+}
+
+// Close closes the listener and all connections it has accepted.
+func (l *trackingListener) Close() error {
+	l.mu.Lock()
+	l.closed = true
+	conns := l.conns
+	l.conns = make(map[net.Conn]struct{})
+	l.mu.Unlock()
+	err := l.Listener.Close()
+	for c := range conns {
+		c.(*trackedConn).Conn.Close()
+	}
+	return err
+}
+
+type trackedConn struct {
+	net.Conn
+	l *trackingListener
+}
+
+func (c *trackedConn) Close() error {
+	c.l.mu.Lock()
+	delete(c.l.conns, c)
+	c.l.mu.Unlock()
+	return c.Conn.Close()
+}
+
+func (s *Server) startStandalone() error {
+	tlsConfig, err := s.cfg.Security.ToTLSConfig()
+	if err != nil {
+		return err
+	}
+
+	leveldb, err := kv.NewLeveldbKV(filepath.Join(s.cfg.DataDir, standaloneMetaDir))
+	if err != nil {
+		return err
+	}
+	s.leveldb = leveldb
+	standaloneMember, err := member.NewStandaloneMember(leveldb)
+	if err != nil {
+		return err
+	}
+	s.kv = leveldb
+	s.httpClient = &http.Client{
+		Transport: &http.Transport{
+			DisableKeepAlives: true,
+			TLSClientConfig:   tlsConfig,
+		},
+	}
+	s.member = standaloneMember
+	return s.serveStandalone(tlsConfig)
+}
+
+func (s *Server) serveStandalone(tlsConfig *tls.Config) error {
+	grpcServer := grpc.NewServer(
+		grpc.MaxRecvMsgSize(int(s.etcdCfg.MaxRequestBytes)+grpcOverheadBytes),
+		grpc.MaxSendMsgSize(math.MaxInt32),
+	)
+	s.etcdCfg.ServiceRegister(grpcServer)
+
+	httpMux := http.NewServeMux()
+	for path, handler := range s.etcdCfg.UserHandlers {
+		httpMux.Handle(path, handler)
+	}
+	httpMux.Handle("/metrics", promhttp.Handler())
+	httpServer := &http.Server{Handler: httpMux}
+
+	s.standalone = &standaloneServer{
+		grpcServer: grpcServer,
+		httpServer: httpServer,
+	}
+	if tlsConfig != nil {
+		// The gRPC client negotiates HTTP/2 during the TLS handshake.
+		tlsConfig = tlsConfig.Clone()
+		tlsConfig.NextProtos = []string{"h2", "http/1.1"}
+	}
+	for _, u := range s.etcdCfg.LCUrls {
+		addr := u.Host
+		l, err := net.Listen("tcp", addr)
+		if err != nil {
+			return errs.ErrListenClientURL.Wrap(err).GenWithStackByArgs(addr)
+		}
+		tl := newTrackingListener(l)
+		s.standalone.listeners = append(s.standalone.listeners, tl)
+		if tlsConfig != nil {
+			l = tls.NewListener(tl, tlsConfig)
+		} else {
+			l = tl
+		}
+
+		m := cmux.New(l)
+		grpcListener := m.MatchWithWriters(cmux.HTTP2MatchHeaderFieldSendSettings("content-type", "application/grpc"))
+		httpListener := m.Match(cmux.Any())
+		go func() {
+			defer logutil.LogPanic()
+			if err := grpcServer.Serve(grpcListener); err != nil && err != grpc.ErrServerStopped {
+				log.Warn("grpc server exits", zap.String("address", addr), errs.ZapError(err))
+			}
+		}()
+		go func() {
+			defer logutil.LogPanic()
+			if err := httpServer.Serve(httpListener); err != nil && err != http.ErrServerClosed && err != cmux.ErrListenerClosed {
+				log.Warn("http server exits", zap.String("address", addr), errs.ZapError(err))
+			}
+		}()
+		go func() {
+			defer logutil.LogPanic()
+			if err := m.Serve(); err != nil && !strings.Contains(err.Error(), "use of closed network connection") {
+				log.Warn("listener exits", zap.String("address", addr), errs.ZapError(err))
+			}
+		}()
+		log.Info("serving client traffic in the standalone mode", zap.String("address", addr))
+	}
+	return nil
+}
+
+// Close stops serving the requests.
+func (ss *standaloneServer) Close() {
+	for _, l := range ss.listeners {
+		l.Close()
+	}
+	ss.grpcServer.Stop()
+	ss.httpServer.Close()
+}
@@ -26,14 +26,14 @@ import (
 	"github.com/pingcap/kvproto/pkg/pdpb"
 	"github.com/pingcap/log"
 	"github.com/tikv/pd/pkg/errs"
-	"github.com/tikv/pd/pkg/etcdutil"
 	"github.com/tikv/pd/pkg/grpcutil"
 	"github.com/tikv/pd/pkg/slice"
+	"github.com/tikv/pd/pkg/tsoutil"
+	"github.com/tikv/pd/pkg/typeutil"
 	"github.com/tikv/pd/server/config"
 	"github.com/tikv/pd/server/election"
 	"github.com/tikv/pd/server/kv"
 	"github.com/tikv/pd/server/member"
-	"go.etcd.io/etcd/clientv3"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
 )
@@ -59,7 +59,7 @@ type allocatorGroup struct {
 	// leadership, and for the Local TSO Allocator, leadership
 	// is a DC-level certificate to allow an allocator to generate
 	// TSO for local transactions in its DC.
-	leadership *election.Leadership
+	leadership election.Leadership
 	allocator  Allocator
 }
 
@@ -84,7 +84,9 @@ type AllocatorManager struct {
 	}
 	wg sync.WaitGroup
 	// for election use
-	member *member.Member
+	member member.ElectionMember
+	// kv stores the TSO related data, it has no root path.
+	kv kv.Base
 	// TSO config
 	rootPath               string
 	saveInterval           time.Duration
@@ -96,11 +98,14 @@ type AllocatorManager struct {
 		sync.RWMutex
 		clientConns map[string]*grpc.ClientConn
 	}
+	// skewRecorder keeps the skew incidents found by the allocators.
+	skewRecorder skewRecorder
 }
 
 // NewAllocatorManager creates a new TSO Allocator Manager.
 func NewAllocatorManager(
-	m *member.Member,
+	m member.ElectionMember,
+	kvBase kv.Base,
 	rootPath string,
 	saveInterval time.Duration,
 	updatePhysicalInterval time.Duration,
@@ -109,6 +114,7 @@ func NewAllocatorManager(
 ) *AllocatorManager {
 	allocatorManager := &AllocatorManager{
 		member:                 m,
+		kv:                     kvBase,
 		rootPath:               rootPath,
 		saveInterval:           saveInterval,
 		updatePhysicalInterval: updatePhysicalInterval,
@@ -132,10 +138,24 @@ func (am *AllocatorManager) SetLocalTSOConfig(localTSOConfig config.LocalTSOConf
 			zap.Uint64("server-id", serverID))
 		return nil
 	}
-	if err := am.checkDCLocationUpperLimit(localTSOConfig.DCLocation); err != nil {
+	// The dc-location which the server is moved to at runtime takes precedence over the config.
+	dcLocation := localTSOConfig.DCLocation
+	override, err := am.kv.Load(am.member.GetDCLocationOverridePath(serverID))
+	if err != nil {
+		return err
+	}
+	if len(override) > 0 && override != dcLocation {
+		log.Warn("the dc-location in the config is overridden by the one set at runtime",
+			zap.String("config-dc-location", dcLocation),
+			zap.String("dc-location", string(override)),
+			zap.String("server-name", serverName),
+			zap.Uint64("server-id", serverID))
+		dcLocation = override
+	}
+	if err := am.checkDCLocationUpperLimit(dcLocation); err != nil {
 		log.Error("check dc-location upper limit failed",
 			zap.Int("upper-limit", int(math.Pow(2, MaxSuffixBits))-1),
-			zap.String("dc-location", localTSOConfig.DCLocation),
+			zap.String("dc-location", dcLocation),
 			zap.String("server-name", serverName),
 			zap.Uint64("server-id", serverID),
 			errs.ZapError(err))
@@ -143,28 +163,100 @@ func (am *AllocatorManager) SetLocalTSOConfig(localTSOConfig config.LocalTSOConf
 	}
 	// The key-value pair in etcd will be like: serverID -> dcLocation
 	dcLocationKey := am.member.GetDCLocationPath(serverID)
-	resp, err := kv.
-		NewSlowLogTxn(am.member.Client()).
-		Then(clientv3.OpPut(dcLocationKey, localTSOConfig.DCLocation)).
-		Commit()
-	if err != nil {
-		return errs.ErrEtcdTxn.Wrap(err).GenWithStackByCause()
-	}
-	if !resp.Succeeded {
+	if err := am.kv.Save(dcLocationKey, dcLocation); err != nil {
 		log.Warn("write dc-location configuration into etcd failed",
-			zap.String("dc-location", localTSOConfig.DCLocation),
+			zap.String("dc-location", dcLocation),
 			zap.String("server-name", serverName),
 			zap.Uint64("server-id", serverID))
-		return errs.ErrEtcdTxn.FastGenByArgs()
+		return err
 	}
 	log.Info("write dc-location configuration into etcd",
-		zap.String("dc-location", localTSOConfig.DCLocation),
+		zap.String("dc-location", dcLocation),
 		zap.String("server-name", serverName),
 		zap.Uint64("server-id", serverID))
 	go am.ClusterDCLocationChecker()
 	return nil
 }
 
+// SetMemberDCLocation moves a PD member to the given dc-location at runtime, it's
+// only allowed on the PD leader. The dc-location is persisted to take precedence
+// over the one in the member's `LocalTSOConfig`, so the member stays in it after
+// restarting. If the member is the Local TSO Allocator leader of its previous
+// dc-location, it will be forced to resign.
+func (am *AllocatorManager) SetMemberDCLocation(serverID uint64, dcLocation string) error {
+	if !am.member.IsLeader() {
+		return errs.ErrSetDCLocation.FastGenByArgs("not the pd leader")
+	}
+	if len(dcLocation) == 0 || dcLocation == config.GlobalDCLocation {
+		return errs.ErrInvalidDCLocation.FastGenByArgs(fmt.Sprintf("%q can't be set to a member", dcLocation))
+	}
+	if err := am.checkDCLocationUpperLimit(dcLocation); err != nil {
+		return errs.ErrInvalidDCLocation.FastGenByArgs(err.Error())
+	}
+	clusterDCLocations, err := am.getClusterDCLocationsFromEtcd()
+	if err != nil {
+		return err
+	}
+	// Move the member to check the Local TSO suffixes after the change.
+	var oldDCLocation string
+	for dc, serverIDs := range clusterDCLocations {
+		remained := serverIDs[:0]
+		for _, id := range serverIDs {
+			if id != serverID {
+				remained = append(remained, id)
+			} else {
+				oldDCLocation = dc
+			}
+		}
+		if len(remained) == 0 {
+			delete(clusterDCLocations, dc)
+			continue
+		}
+		clusterDCLocations[dc] = remained
+	}
+	clusterDCLocations[dcLocation] = append(clusterDCLocations[dcLocation], serverID)
+	if err := am.checkLocalTSOSuffixes(clusterDCLocations); err != nil {
+		return err
+	}
+	ok, err := am.kv.Txn(nil, []kv.Op{
+		kv.OpSave(am.member.GetDCLocationPath(serverID), dcLocation),
+		kv.OpSave(am.member.GetDCLocationOverridePath(serverID), dcLocation),
+	})
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errs.ErrEtcdTxn.FastGenByArgs()
+	}
+	log.Info("move the member to another dc-location",
+		zap.String("old-dc-location", oldDCLocation),
+		zap.String("dc-location", dcLocation),
+		zap.Uint64("server-id", serverID))
+	am.ClusterDCLocationChecker()
+	if len(oldDCLocation) > 0 && oldDCLocation != dcLocation {
+		return am.resignAllocatorLeader(oldDCLocation, serverID)
+	}
+	return nil
+}
+
+// resignAllocatorLeader forces the member to resign the Local TSO Allocator leadership
+// of the dc-location if it's the leader. The lease of the leadership is revoked, so the
+// member steps down once it finds the lease expired, and the other members campaign.
+func (am *AllocatorManager) resignAllocatorLeader(dcLocation string, serverID uint64) error {
+	etcdMember, err := am.etcdMember()
+	if err != nil {
+		return err
+	}
+	revoked, err := election.RevokeLeader(etcdMember.Client(), am.getAllocatorPath(dcLocation), serverID)
+	if err != nil || !revoked {
+		return err
+	}
+	log.Info("force the local tso allocator leader to resign",
+		zap.String("dc-location", dcLocation),
+		zap.Uint64("server-id", serverID))
+	return nil
+}
+
 func (am *AllocatorManager) checkDCLocationUpperLimit(dcLocation string) error {
 	clusterDCLocations, err := am.getClusterDCLocationsFromEtcd()
 	if err != nil {
@@ -183,20 +275,18 @@ func (am *AllocatorManager) checkDCLocationUpperLimit(dcLocation string) error {
 }
 
 func (am *AllocatorManager) getClusterDCLocationsFromEtcd() (clusterDCLocations map[string][]uint64, err error) {
-	resp, err := etcdutil.EtcdKVGet(
-		am.member.Client(),
-		am.member.GetDCLocationPathPrefix(),
-		clientv3.WithPrefix())
+	prefix := am.member.GetDCLocationPathPrefix()
+	keys, values, err := am.kv.LoadRange(prefix, kv.GetPrefixRangeEnd(prefix), 0)
 	if err != nil {
 		return clusterDCLocations, err
 	}
 	clusterDCLocations = make(map[string][]uint64)
-	for _, kv := range resp.Kvs {
+	for i, key := range keys {
 		// The key will contain the member ID and the value is its dcLocation
-		serverPath := strings.Split(string(kv.Key), "/")
+		serverPath := strings.Split(key, "/")
 		// Get serverID from serverPath, e.g, /pd/dc-location/1232143243253 -> 1232143243253
 		serverID, err := strconv.ParseUint(serverPath[len(serverPath)-1], 10, 64)
-		dcLocation := string(kv.Value)
+		dcLocation := values[i]
 		if err != nil {
 			log.Warn("get server id and dcLocation from etcd failed, invalid server id",
 				zap.Any("splitted-serverPath", serverPath),
@@ -241,7 +331,7 @@ func (am *AllocatorManager) GetSuffixDCLocations() map[string]int32 {
 }
 
 // SetUpAllocator is used to set up an allocator, which will initialize the allocator and put it into allocator daemon.
-func (am *AllocatorManager) SetUpAllocator(parentCtx context.Context, dcLocation string, leadership *election.Leadership) error {
+func (am *AllocatorManager) SetUpAllocator(parentCtx context.Context, dcLocation string, leadership election.Leadership) error {
 	am.mu.Lock()
 	defer am.mu.Unlock()
 
@@ -254,7 +344,13 @@ func (am *AllocatorManager) SetUpAllocator(parentCtx context.Context, dcLocation
 	if dcLocation == config.GlobalDCLocation {
 		allocator = NewGlobalTSOAllocator(am, leadership)
 	} else {
-		allocator = NewLocalTSOAllocator(am, leadership, dcLocation)
+		// The Local TSO Allocator leader is elected among several PD servers,
+		// which needs the etcd leadership.
+		etcdLeadership, ok := leadership.(*election.EtcdLeadership)
+		if !ok {
+			return errs.ErrStandaloneMode.FastGenByArgs("local tso")
+		}
+		allocator = NewLocalTSOAllocator(am, etcdLeadership, dcLocation)
 	}
 	// Update or create a new allocatorGroup
 	am.mu.allocatorGroups[dcLocation] = &allocatorGroup{
@@ -289,6 +385,16 @@ func (am *AllocatorManager) getAllocatorPath(dcLocation string) string {
 	return path.Join(am.rootPath, dcLocation)
 }
 
+// etcdMember returns the member with the embedded etcd, which is required by
+// the Local TSO Allocator election.
+func (am *AllocatorManager) etcdMember() (*member.EmbeddedEtcdMember, error) {
+	etcdMember, ok := am.member.(*member.EmbeddedEtcdMember)
+	if !ok {
+		return nil, errs.ErrStandaloneMode.FastGenByArgs("local tso")
+	}
+	return etcdMember, nil
+}
+
 // similar logic with leaderLoop in server/server.go
 func (am *AllocatorManager) allocatorLeaderLoop(ctx context.Context, allocator *LocalTSOAllocator) {
 	defer log.Info("server is closed, return local tso allocator leader loop",
@@ -516,8 +622,13 @@ func (am *AllocatorManager) allocatorPatroller(serverCtx context.Context) {
 		if slice.NoneOf(allocatorGroups, func(i int) bool {
 			return allocatorGroups[i].dcLocation == dcLocation
 		}) {
-			if err := am.SetUpAllocator(serverCtx, dcLocation, election.NewLeadership(
-				am.member.Client(),
+			etcdMember, err := am.etcdMember()
+			if err != nil {
+				log.Error("check new allocators failed, can't set up a new local allocator", zap.String("dc-location", dcLocation), errs.ZapError(err))
+				continue
+			}
+			if err := am.SetUpAllocator(serverCtx, dcLocation, election.NewEtcdLeadership(
+				etcdMember.Client(),
 				am.getAllocatorPath(dcLocation),
 				fmt.Sprintf("%s local allocator leader election", dcLocation),
 			)); err != nil {
@@ -556,11 +667,14 @@ func (am *AllocatorManager) ClusterDCLocationChecker() {
 	}
 	// Update the new dc-locations
 	for dcLocation, serverIDs := range newClusterDCLocations {
-		if _, ok := am.mu.clusterDCLocations[dcLocation]; !ok {
-			am.mu.clusterDCLocations[dcLocation] = &dcLocationInfo{
-				serverIDs: serverIDs,
-				suffix:    -1,
-			}
+		if info, ok := am.mu.clusterDCLocations[dcLocation]; ok {
+			// The members may be moved to other dc-locations.
+			info.serverIDs = serverIDs
+			continue
+		}
+		am.mu.clusterDCLocations[dcLocation] = &dcLocationInfo{
+			serverIDs: serverIDs,
+			suffix:    -1,
 		}
 	}
 	// Only leader can write the TSO suffix to etcd in order to make it consistent in the cluster
@@ -580,46 +694,29 @@ func (am *AllocatorManager) ClusterDCLocationChecker() {
 }
 
 // getOrCreateLocalTSOSuffix will check whether we have the Local TSO suffix written into etcd.
-// If not, it will write a number into etcd according to the its joining order.
+// If not, it will write the smallest number not used by other dc-locations into etcd, so the
+// suffix of a removed dc-location can be reused.
 // If yes, it will just return the previous persisted one.
 func (am *AllocatorManager) getOrCreateLocalTSOSuffix(dcLocation string) (int32, error) {
 	// Try to get the suffix from etcd
-	resp, err := etcdutil.EtcdKVGet(
-		am.member.Client(),
-		am.GetLocalTSOSuffixPathPrefix(),
-		clientv3.WithPrefix())
+	suffixes, err := am.getLocalTSOSuffixesFromEtcd()
 	if err != nil {
 		return -1, err
 	}
-	var maxSuffix int64
-	for _, kv := range resp.Kvs {
-		suffix, err := strconv.ParseInt(string(kv.Value), 10, 32)
-		if err != nil {
-			return -1, err
-		}
-		splittedKey := strings.Split(string(kv.Key), "/")
-		curDCLocation := splittedKey[len(splittedKey)-1]
-		// If we already have the suffix persistted in etcd before,
-		// just use it as the result directly.
-		if curDCLocation == dcLocation {
-			return int32(suffix), nil
-		}
-		if suffix > maxSuffix {
-			maxSuffix = suffix
-		}
+	// If we already have the suffix persistted in etcd before,
+	// just use it as the result directly.
+	if suffix, ok := suffixes[dcLocation]; ok {
+		return suffix, nil
 	}
-	maxSuffix++
+	suffix := nextLocalTSOSuffix(suffixes)
 	localTSOSuffixKey := am.GetLocalTSOSuffixPath(dcLocation)
-	// The Local TSO suffix is determined by the joining order of this dc-location.
-	localTSOSuffixValue := strconv.FormatInt(maxSuffix, 10)
-	txnResp, err := kv.NewSlowLogTxn(am.member.Client()).
-		If(clientv3.Compare(clientv3.CreateRevision(localTSOSuffixKey), "=", 0)).
-		Then(clientv3.OpPut(localTSOSuffixKey, localTSOSuffixValue)).
-		Commit()
+	localTSOSuffixValue := strconv.FormatInt(int64(suffix), 10)
+	// The suffix key must not exist.
+	ok, err := am.kv.Txn([]kv.Condition{{Key: localTSOSuffixKey}}, []kv.Op{kv.OpSave(localTSOSuffixKey, localTSOSuffixValue)})
 	if err != nil {
-		return -1, errs.ErrEtcdTxn.Wrap(err).GenWithStackByCause()
+		return -1, err
 	}
-	if !txnResp.Succeeded {
+	if !ok {
 		log.Warn("write local tso suffix into etcd failed",
 			zap.String("dc-location", dcLocation),
 			zap.String("local-tso-surfix", localTSOSuffixValue),
@@ -627,7 +724,180 @@ func (am *AllocatorManager) getOrCreateLocalTSOSuffix(dcLocation string) (int32,
 			zap.Uint64("server-id", am.member.ID()))
 		return -1, errs.ErrEtcdTxn.FastGenByArgs()
 	}
-	return int32(maxSuffix), nil
+	return suffix, nil
+}
+
+// getLocalTSOSuffixesFromEtcd returns all Local TSO suffixes persisted in etcd
+// with a map which satisfies dcLocation -> suffix.
+func (am *AllocatorManager) getLocalTSOSuffixesFromEtcd() (map[string]int32, error) {
+	prefix := am.GetLocalTSOSuffixPathPrefix()
+	keys, values, err := am.kv.LoadRange(prefix, kv.GetPrefixRangeEnd(prefix), 0)
+	if err != nil {
+		return nil, err
+	}
+	suffixes := make(map[string]int32)
+	for i, key := range keys {
+		suffix, err := strconv.ParseInt(values[i], 10, 32)
+		if err != nil {
+			return nil, err
+		}
+		splittedKey := strings.Split(key, "/")
+		suffixes[splittedKey[len(splittedKey)-1]] = int32(suffix)
+	}
+	return suffixes, nil
+}
+
+// nextLocalTSOSuffix returns the smallest suffix not used yet. 0 is always
+// held by the Global TSO.
+func nextLocalTSOSuffix(suffixes map[string]int32) int32 {
+	used := make(map[int32]struct{}, len(suffixes))
+	for _, suffix := range suffixes {
+		used[suffix] = struct{}{}
+	}
+	suffix := int32(1)
+	for {
+		if _, ok := used[suffix]; !ok {
+			return suffix
+		}
+		suffix++
+	}
+}
+
+// checkLocalTSOSuffixes checks whether the Local TSO suffixes of the given dc-locations,
+// including the one going to be assigned to a new dc-location, all fit in the suffix
+// bits calculated by the number of the dc-locations.
+func (am *AllocatorManager) checkLocalTSOSuffixes(clusterDCLocations map[string][]uint64) error {
+	suffixes, err := am.getLocalTSOSuffixesFromEtcd()
+	if err != nil {
+		return err
+	}
+	limit := int32(1) << CalSuffixBits(len(clusterDCLocations))
+	for dcLocation := range clusterDCLocations {
+		suffix, ok := suffixes[dcLocation]
+		if !ok {
+			suffix = nextLocalTSOSuffix(suffixes)
+		}
+		if suffix >= limit {
+			return errs.ErrInvalidDCLocation.FastGenByArgs(fmt.Sprintf(
+				"the local tso suffix %d of dc-location %s exceeds the suffix bits, remove the unused dc-locations first", suffix, dcLocation))
+		}
+	}
+	return nil
+}
+
+// RemoveDCLocation retires a dc-location without any member, and reclaims its Local
+// TSO suffix, it's only allowed on the PD leader. The members should be moved to other
+// dc-locations first, and the removal only succeeds after the Local TSO Allocator of
+// the dc-location stops serving, so that the Global TSO can be fenced above all the
+// timestamps it has issued. It doesn't wait for the time window of the allocator to
+// pass but returns ErrDCLocationInUse, and the caller should retry later.
+func (am *AllocatorManager) RemoveDCLocation(dcLocation string) error {
+	if !am.member.IsLeader() {
+		return errs.ErrRemoveDCLocation.FastGenByArgs("not the pd leader")
+	}
+	if dcLocation == config.GlobalDCLocation {
+		return errs.ErrInvalidDCLocation.FastGenByArgs("the global dc-location can't be removed")
+	}
+	// Drop the dc-location from memory if it has no member, then the allocator patroller
+	// of the leader will delete its allocator.
+	am.ClusterDCLocationChecker()
+	clusterDCLocations, err := am.getClusterDCLocationsFromEtcd()
+	if err != nil {
+		return err
+	}
+	if serverIDs, ok := clusterDCLocations[dcLocation]; ok {
+		return errs.ErrDCLocationInUse.FastGenByArgs(dcLocation, fmt.Sprintf(
+			"%d members are in it, move them to other dc-locations first", len(serverIDs)))
+	}
+	suffixes, err := am.getLocalTSOSuffixesFromEtcd()
+	if err != nil {
+		return err
+	}
+	suffix, ok := suffixes[dcLocation]
+	if !ok {
+		return errs.ErrDCLocationNotFound.FastGenByArgs(dcLocation)
+	}
+	allocatorPath := am.getAllocatorPath(dcLocation)
+	if err := am.checkAllocatorStopped(dcLocation); err != nil {
+		return err
+	}
+	// The time window of the allocator is greater than all the timestamps it has issued.
+	retired := &timestampOracle{kv: am.kv, rootPath: allocatorPath}
+	window, err := retired.loadTimestamp()
+	if err != nil {
+		return err
+	}
+	fence, err := retired.loadHighWaterMark()
+	if err != nil {
+		return err
+	}
+	if window != typeutil.ZeroTime {
+		if ts := tsoutil.GenerateTS(tsoutil.GenerateTimestamp(window, 0)); ts > fence {
+			fence = ts
+		}
+	}
+	if fence > 0 {
+		if err := am.fenceGlobalTSO(fence); err != nil {
+			return err
+		}
+	}
+	// The time window should pass, so a new dc-location reusing the suffix won't
+	// issue the same timestamps as the retired one.
+	if d := time.Until(window); d > 0 {
+		return errs.ErrDCLocationInUse.FastGenByArgs(dcLocation, fmt.Sprintf(
+			"the time window of the local tso allocator has not passed, retry after %s", d.Round(time.Millisecond)))
+	}
+	// Delete the suffix and the time window only if no allocator leader comes up.
+	allocatorKeys, _, err := am.kv.LoadRange(allocatorPath+"/", kv.GetPrefixRangeEnd(allocatorPath+"/"), 0)
+	if err != nil {
+		return err
+	}
+	ops := []kv.Op{kv.OpRemove(am.GetLocalTSOSuffixPath(dcLocation))}
+	for _, key := range allocatorKeys {
+		ops = append(ops, kv.OpRemove(key))
+	}
+	ok, err = am.kv.Txn([]kv.Condition{{Key: allocatorPath}}, ops)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errs.ErrDCLocationInUse.FastGenByArgs(dcLocation, "the local tso allocator is still serving, retry later")
+	}
+	log.Info("remove the dc-location and reclaim its local tso suffix",
+		zap.String("dc-location", dcLocation),
+		zap.Int32("suffix", suffix),
+		zap.Uint64("fence", fence))
+	return nil
+}
+
+// checkAllocatorStopped checks there is no Local TSO Allocator leader of the dc-location.
+func (am *AllocatorManager) checkAllocatorStopped(dcLocation string) error {
+	etcdMember, err := am.etcdMember()
+	if err != nil {
+		return err
+	}
+	leader, _, err := election.GetLeader(etcdMember.Client(), am.getAllocatorPath(dcLocation))
+	if err != nil {
+		return err
+	}
+	if leader != nil {
+		return errs.ErrDCLocationInUse.FastGenByArgs(dcLocation, fmt.Sprintf(
+			"the local tso allocator is still served by %s, retry later", leader.GetName()))
+	}
+	return nil
+}
+
+// fenceGlobalTSO makes the Global TSO greater than the given timestamp.
+func (am *AllocatorManager) fenceGlobalTSO(ts uint64) error {
+	ag, ok := am.getAllocatorGroup(config.GlobalDCLocation)
+	if !ok {
+		return errs.ErrGetAllocator.FastGenByArgs("global allocator not found")
+	}
+	globalAllocator, ok := ag.allocator.(*GlobalTSOAllocator)
+	if !ok {
+		return errs.ErrGetAllocator.FastGenByArgs("invalid global tso allocator found")
+	}
+	return globalAllocator.timestampOracle.resetUserTimestamp(ag.leadership, ts, true)
 }
 
 // GetLocalTSOSuffixPathPrefix returns the etcd key prefix of the Local TSO suffix for the given dc-location.
@@ -682,26 +952,18 @@ func (am *AllocatorManager) PriorityChecker() {
 				zap.Uint64("next-leader-id", serverID),
 				zap.String("next-dc-location", myServerDCLocation))
 			nextLeaderKey := path.Join(am.rootPath, allocatorGroup.dcLocation, "next-leader")
-			// Grant a etcd lease with checkStep * 1.5
-			nextLeaderLease := clientv3.NewLease(am.member.Client())
-			ctx, cancel := context.WithTimeout(am.member.Client().Ctx(), etcdutil.DefaultRequestTimeout)
-			leaseResp, err := nextLeaderLease.Grant(ctx, int64(checkStep.Seconds()*1.5))
-			cancel()
+			etcdMember, err := am.etcdMember()
 			if err != nil {
-				err = errs.ErrEtcdGrantLease.Wrap(err).GenWithStackByCause()
-				log.Error("failed to grant the lease of the next leader id key", errs.ZapError(err))
+				log.Error("failed to write next leader id into etcd", errs.ZapError(err))
 				continue
 			}
-			resp, err := kv.NewSlowLogTxn(am.member.Client()).
-				If(clientv3.Compare(clientv3.CreateRevision(nextLeaderKey), "=", 0)).
-				Then(clientv3.OpPut(nextLeaderKey, fmt.Sprint(serverID), clientv3.WithLease(leaseResp.ID))).
-				Commit()
+			// Grant a etcd lease with checkStep * 1.5
+			ok, err := election.SetNextLeader(etcdMember.Client(), nextLeaderKey, serverID, int64(checkStep.Seconds()*1.5))
 			if err != nil {
-				err = errs.ErrEtcdTxn.Wrap(err).GenWithStackByCause()
 				log.Error("failed to write next leader id into etcd", errs.ZapError(err))
 				continue
 			}
-			if !resp.Succeeded {
+			if !ok {
 				log.Warn("write next leader id into etcd unsuccessfully")
 			}
 		}
@@ -738,28 +1000,19 @@ func (am *AllocatorManager) getServerDCLocation(serverID uint64) (string, error)
 
 func (am *AllocatorManager) getNextLeaderID(dcLocation string) (uint64, error) {
 	nextLeaderKey := path.Join(am.rootPath, dcLocation, "next-leader")
-	nextLeaderValue, err := etcdutil.GetValue(am.member.Client(), nextLeaderKey)
+	nextLeaderValue, err := am.kv.Load(nextLeaderKey)
 	if err != nil {
 		return 0, err
 	}
 	if len(nextLeaderValue) == 0 {
 		return 0, nil
 	}
-	return strconv.ParseUint(string(nextLeaderValue), 10, 64)
+	return strconv.ParseUint(nextLeaderValue, 10, 64)
 }
 
 func (am *AllocatorManager) deleteNextLeaderID(dcLocation string) error {
 	nextLeaderKey := path.Join(am.rootPath, dcLocation, "next-leader")
-	resp, err := kv.NewSlowLogTxn(am.member.Client()).
-		Then(clientv3.OpDelete(nextLeaderKey)).
-		Commit()
-	if err != nil {
-		return errs.ErrEtcdKVDelete.Wrap(err).GenWithStackByCause()
-	}
-	if !resp.Succeeded {
-		return errs.ErrEtcdTxn.FastGenByArgs()
-	}
-	return nil
+	return am.kv.Remove(nextLeaderKey)
 }
 
 func (am *AllocatorManager) deleteAllocatorGroup(dcLocation string) {
@@ -836,6 +1089,32 @@ func (am *AllocatorManager) GetAllocators(filters ...AllocatorGroupFilter) []All
 	return allocators
 }
 
+// GetSkewIncidents returns the recent skew incidents found by the allocators
+// of this server.
+func (am *AllocatorManager) GetSkewIncidents() []*SkewIncident {
+	return am.skewRecorder.list()
+}
+
+// getLocalHighWaterMarkPaths returns the high-water mark paths of the Local
+// TSO Allocators of all dc-locations in the cluster, which the Global TSO must
+// be greater than. The dc-locations are loaded from etcd rather than the
+// allocator groups, since a server only sets up the Local TSO Allocator of its
+// own dc-location.
+func (am *AllocatorManager) getLocalHighWaterMarkPaths() ([]string, error) {
+	clusterDCLocations, err := am.getClusterDCLocationsFromEtcd()
+	if err != nil {
+		return nil, err
+	}
+	paths := make([]string, 0, len(clusterDCLocations))
+	for dcLocation := range clusterDCLocations {
+		if dcLocation == config.GlobalDCLocation {
+			continue
+		}
+		paths = append(paths, path.Join(am.getAllocatorPath(dcLocation), highWaterMarkKey))
+	}
+	return paths, nil
+}
+
 // GetHoldingLocalAllocatorLeaders returns all Local TSO Allocator leaders this server holds.
 func (am *AllocatorManager) GetHoldingLocalAllocatorLeaders() ([]*LocalTSOAllocator, error) {
 	localAllocators := am.GetAllocators(
@@ -17,6 +17,7 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/pingcap/kvproto/pkg/pdpb"
@@ -24,7 +25,7 @@ import (
 	"github.com/tikv/pd/pkg/errs"
 	"github.com/tikv/pd/pkg/slice"
 	"github.com/tikv/pd/pkg/tsoutil"
-	"github.com/tikv/pd/pkg/typeutil"
+	"github.com/tikv/pd/server/config"
 	"github.com/tikv/pd/server/election"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
@@ -56,24 +57,27 @@ type GlobalTSOAllocator struct {
 	allocatorManager *AllocatorManager
 	// leadership is used to check the current PD server's leadership
 	// to determine whether a TSO request could be processed.
-	leadership      *election.Leadership
+	leadership      election.Leadership
 	timestampOracle *timestampOracle
 }
 
 // NewGlobalTSOAllocator creates a new global TSO allocator.
 func NewGlobalTSOAllocator(
 	am *AllocatorManager,
-	leadership *election.Leadership,
+	leadership election.Leadership,
 ) Allocator {
 	gta := &GlobalTSOAllocator{
 		allocatorManager: am,
 		leadership:       leadership,
 		timestampOracle: &timestampOracle{
-			client:                 leadership.GetClient(),
+			kv:                     am.kv,
 			rootPath:               am.rootPath,
 			saveInterval:           am.saveInterval,
 			updatePhysicalInterval: am.updatePhysicalInterval,
 			maxResetTSGap:          am.maxResetTSGap,
+			dcLocation:             config.GlobalDCLocation,
+			peerHighWaterMarkPaths: am.getLocalHighWaterMarkPaths,
+			skewRecorder:           &am.skewRecorder,
 		},
 	}
 	return gta
@@ -110,16 +114,75 @@ func (gta *GlobalTSOAllocator) GenerateTSO(count uint32) (pdpb.Timestamp, error)
 	if len(dcLocationMap) == 0 {
 		return gta.timestampOracle.getTS(gta.leadership, count, 0)
 	}
-	// Send maxTS to all Local TSO Allocator leaders to prewrite
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
+	// Prewrite the estimated MaxTS to all Local TSO Allocator leaders first,
+	// which only needs one round of the synchronization. Once any Local TSO
+	// is not less than the estimated one, do the full synchronization.
+	suffixBits := CalSuffixBits(gta.allocatorManager.GetClusterDCLocationsNumber())
+	maxTSO, err := gta.estimateMaxTS(count, suffixBits)
+	if err != nil {
+		return pdpb.Timestamp{}, err
+	}
+	written, err := gta.syncMaxTS(ctx, dcLocationMap, maxTSO)
+	if err != nil {
+		return pdpb.Timestamp{}, err
+	}
+	if written {
+		tsoCounter.WithLabelValues("global_estimate_ok").Inc()
+	} else {
+		tsoCounter.WithLabelValues("global_estimate_fail").Inc()
+		if maxTSO, err = gta.fullSyncMaxTS(ctx, dcLocationMap, count, suffixBits); err != nil {
+			return pdpb.Timestamp{}, err
+		}
+	}
+	gta.timestampOracle.raiseHighWaterMark(tsoutil.GenerateTS(maxTSO))
+	// Update the global TSO in memory, which is undifferentiated as the Local
+	// TSOs, so the next estimation only goes after it by the count.
+	if err := gta.timestampOracle.syncTimestamp(gta.leadership, maxTSO, suffixBits); err != nil {
+		log.Warn("update the global tso in memory failed", errs.ZapError(err))
+	}
+	return *maxTSO, nil
+}
+
+// estimateMaxTS estimates the MaxTS of the Local TSO Allocators with the Global
+// TSO. The Global TSO is updated to the MaxTS by every synchronization, and the
+// Local TSOs keep increasing as time goes by since then, so does the estimation.
+func (gta *GlobalTSOAllocator) estimateMaxTS(count uint32, suffixBits int) (*pdpb.Timestamp, error) {
+	// Load the last issued one before generating, which raises it.
+	lastIssued := atomic.LoadUint64(&gta.timestampOracle.lastIssued)
+	physical, logical, updateTime := gta.timestampOracle.generateTSO(int64(count), suffixBits)
+	if physical == 0 {
+		return nil, errs.ErrGenerateTimestamp.FastGenByArgs("timestamp in memory isn't initialized")
+	}
+	maxTSO := &pdpb.Timestamp{
+		Physical: physical + time.Since(updateTime).Milliseconds(),
+		Logical:  logical,
+	}
+	// The estimation may fall behind the Global TSOs issued before once the
+	// physical time is updated, then just go after the last one.
+	if tsoutil.GenerateTS(maxTSO) <= lastIssued {
+		lastPhysical, _ := tsoutil.ParseTS(lastIssued)
+		maxTSO.Physical = lastPhysical.UnixNano()/int64(time.Millisecond) + updateTimestampGuard.Milliseconds()
+		maxTSO.Logical = gta.timestampOracle.differentiateLogical(int64(count), suffixBits)
+	}
+	if maxTSO.GetLogical() >= maxLogical {
+		maxTSO.Physical += updateTimestampGuard.Milliseconds()
+		maxTSO.Logical = gta.timestampOracle.differentiateLogical(int64(count), suffixBits)
+	}
+	return maxTSO, nil
+}
+
+// fullSyncMaxTS collects the MaxTS of all Local TSO Allocator leaders, and
+// writes the MaxTS plus count to them.
+func (gta *GlobalTSOAllocator) fullSyncMaxTS(ctx context.Context, dcLocationMap map[string][]uint64, count uint32, suffixBits int) (*pdpb.Timestamp, error) {
 	maxTSO := &pdpb.Timestamp{}
 	// Collect the MaxTS with all Local TSO Allocator leaders first
-	if err := gta.syncMaxTS(ctx, dcLocationMap, maxTSO); err != nil {
-		return pdpb.Timestamp{}, err
+	if _, err := gta.syncMaxTS(ctx, dcLocationMap, maxTSO); err != nil {
+		return nil, err
 	}
 	maxTSO.Logical += int64(count)
-	maxTSO.Logical = gta.timestampOracle.differentiateLogical(maxTSO.Logical, gta.allocatorManager.GetClusterDCLocationsNumber())
+	maxTSO.Logical = gta.timestampOracle.differentiateLogical(maxTSO.Logical, suffixBits)
 	// If the maxTSO's logical part is bigger than maxLogical, just add a updateTimestampGuard
 	// to the physical time and empty the logical part. We just need to make sure it's bigger than
 	// all the other Local TSOs. And because the Global TSO's suffix will always be zero,
@@ -128,24 +191,13 @@ func (gta *GlobalTSOAllocator) GenerateTSO(count uint32) (pdpb.Timestamp, error)
 		maxTSO.Physical += updateTimestampGuard.Milliseconds()
 		maxTSO.Logical = 0
 	}
-	// Sync the MaxTS with all Local TSO Allocator leaders then
-	if err := gta.syncMaxTS(ctx, dcLocationMap, maxTSO); err != nil {
-		return pdpb.Timestamp{}, err
-	}
-	var (
-		currentGlobalTSO pdpb.Timestamp
-		err              error
-	)
-	if currentGlobalTSO, err = gta.getCurrentTSO(); err != nil {
-		return pdpb.Timestamp{}, err
-	}
-	if tsoutil.CompareTimestamp(&currentGlobalTSO, maxTSO) < 0 {
-		// Update the global TSO in memory
-		if err := gta.timestampOracle.resetUserTimestamp(gta.leadership, tsoutil.GenerateTS(maxTSO), true); err != nil {
-			log.Warn("update the global tso in memory failed", errs.ZapError(err))
-		}
+	// Sync the MaxTS with all Local TSO Allocator leaders then. A Local TSO
+	// may have gone beyond the MaxTS during the synchronization, which is
+	// concurrent with the Global TSO, so it's fine.
+	if _, err := gta.syncMaxTS(ctx, dcLocationMap, maxTSO); err != nil {
+		return nil, err
 	}
-	return *maxTSO, nil
+	return maxTSO, nil
 }
 
 const (
@@ -155,8 +207,10 @@ const (
 
 // syncMaxTS is used to sync the MaxTS with the Local TSO Allocator leaders in the dcLocationMap. If the maxTSO is empty, it will collect
 // the max Local TSO and load it into maxTSO. If the maxTSO is not empty, it will set in-memory-TSO of the Local TSO Allocator leaders to
-// maxTSO if maxTSO is greater.
-func (gta *GlobalTSOAllocator) syncMaxTS(ctx context.Context, dcLocationMap map[string][]uint64, maxTSO *pdpb.Timestamp) error {
+// maxTSO if maxTSO is greater, and returns false if any Local TSO is not less than maxTSO.
+func (gta *GlobalTSOAllocator) syncMaxTS(ctx context.Context, dcLocationMap map[string][]uint64, maxTSO *pdpb.Timestamp) (bool, error) {
+	inCollectingPhase := maxTSO.GetPhysical() == 0
+	written := true
 	maxRetryCount := 1
 	for i := 0; i < maxRetryCount; i++ {
 		// Collect all allocator leaders' client URLs
@@ -164,11 +218,11 @@ func (gta *GlobalTSOAllocator) syncMaxTS(ctx context.Context, dcLocationMap map[
 		for dcLocation := range dcLocationMap {
 			allocator, err := gta.allocatorManager.GetAllocator(dcLocation)
 			if err != nil {
-				return err
+				return false, err
 			}
 			allocatorLeader := allocator.(*LocalTSOAllocator).GetAllocatorLeader()
 			if allocatorLeader.GetMemberId() == 0 {
-				return errs.ErrSyncMaxTS.FastGenByArgs(fmt.Printf("%s does not have the local allocator leader yet", dcLocation))
+				return false, errs.ErrSyncMaxTS.FastGenByArgs(fmt.Printf("%s does not have the local allocator leader yet", dcLocation))
 			}
 			allocatorLeaders[dcLocation] = allocatorLeader
 		}
@@ -191,7 +245,7 @@ func (gta *GlobalTSOAllocator) syncMaxTS(ctx context.Context, dcLocationMap map[
 		for _, leaderURL := range leaderURLs {
 			leaderConn, err := gta.allocatorManager.getOrCreateGRPCConn(ctx, leaderURL)
 			if err != nil {
-				return err
+				return false, err
 			}
 			wg.Add(1)
 			go func(ctx context.Context, conn *grpc.ClientConn, respCh chan<- *pdpb.SyncMaxTSResponse, errCh chan<- error) {
@@ -201,7 +255,7 @@ func (gta *GlobalTSOAllocator) syncMaxTS(ctx context.Context, dcLocationMap map[
 						SenderId: gta.allocatorManager.member.ID(),
 					},
 				}
-				if maxTSO.GetPhysical() != 0 {
+				if !inCollectingPhase {
 					request.MaxTs = maxTSO
 				}
 				syncCtx, cancel := context.WithTimeout(ctx, rpcTimeout)
@@ -225,31 +279,20 @@ func (gta *GlobalTSOAllocator) syncMaxTS(ctx context.Context, dcLocationMap map[
 			errList = append(errList, err)
 		}
 		if len(errList) > 0 {
-			return errs.ErrSyncMaxTS.FastGenWithCause(errList)
+			return false, errs.ErrSyncMaxTS.FastGenWithCause(errList)
 		}
-		var (
-			respCount         int
-			syncedDCs         []string
-			inCollectingPhase bool
-		)
+		var syncedDCs []string
 		for resp := range respCh {
-			respCount++
 			if resp == nil {
-				return errs.ErrSyncMaxTS.FastGenByArgs("got nil response")
-			}
-			// Once we get a non-nil and non-zero MaxLocalTs first, we will think it's in the first phase
-			// of the Global TSO synchronization. So that we can have more detailed processing logic
-			// for each phase. For example, if we think we're in the first phase of the Global TSO
-			// synchronization, the inCollectingPhase will be set to true, and during this phase,
-			// any response with nil or empty MaxLocalTs will be regarded as an invalid response.
-			// Then the whole synchronization will fail.
-			if respCount == 1 && resp.GetMaxLocalTs() != nil && resp.GetMaxLocalTs().GetPhysical() != 0 {
-				inCollectingPhase = true
+				return false, errs.ErrSyncMaxTS.FastGenByArgs("got nil response")
 			}
+			// In the first phase of the Global TSO synchronization, any response with nil or
+			// empty MaxLocalTs will be regarded as an invalid response. Then the whole
+			// synchronization will fail.
 			if inCollectingPhase {
 				// Handle the response of the first phase: collect all the Local TSOs
 				if resp.GetMaxLocalTs() == nil || resp.GetMaxLocalTs().GetPhysical() == 0 {
-					return errs.ErrSyncMaxTS.FastGenByArgs("got nil or zero max local ts in the first sync phase")
+					return false, errs.ErrSyncMaxTS.FastGenByArgs("got nil or zero max local ts in the first sync phase")
 				}
 				// Compare and get the max one
 				if tsoutil.CompareTimestamp(resp.GetMaxLocalTs(), maxTSO) > 0 {
@@ -257,9 +300,10 @@ func (gta *GlobalTSOAllocator) syncMaxTS(ctx context.Context, dcLocationMap map[
 				}
 				syncedDCs = append(syncedDCs, resp.GetDcs()...)
 			} else {
-				// Handle the response of the second phase: set all the Local TSOs to the maxTSO
+				// Handle the response of the second phase: set all the Local TSOs to the maxTSO,
+				// and the MaxLocalTs is returned if some Local TSO is not less than maxTSO.
 				if resp.GetMaxLocalTs() != nil {
-					return errs.ErrSyncMaxTS.FastGenByArgs("got non-nil max local ts in the second sync phase")
+					written = false
 				}
 				syncedDCs = append(syncedDCs, resp.GetDcs()...)
 			}
@@ -273,10 +317,10 @@ func (gta *GlobalTSOAllocator) syncMaxTS(ctx context.Context, dcLocationMap map[
 				gta.allocatorManager.ClusterDCLocationChecker()
 				continue
 			}
-			return errs.ErrSyncMaxTS.FastGenByArgs(fmt.Sprintf("unsynced dc-locations found, synced dc-locations: %+v, unsynced dc-locations: %+v", syncedDCs, unsyncedDCs))
+			return false, errs.ErrSyncMaxTS.FastGenByArgs(fmt.Sprintf("unsynced dc-locations found, synced dc-locations: %+v, unsynced dc-locations: %+v", syncedDCs, unsyncedDCs))
 		}
 	}
-	return nil
+	return written, nil
 }
 
 func (gta *GlobalTSOAllocator) checkSyncedDCs(dcLocationMap map[string][]uint64, syncedDCs []string) (bool, []string) {
@@ -290,14 +334,6 @@ func (gta *GlobalTSOAllocator) checkSyncedDCs(dcLocationMap map[string][]uint64,
 	return len(unsyncedDCs) == 0, unsyncedDCs
 }
 
-func (gta *GlobalTSOAllocator) getCurrentTSO() (pdpb.Timestamp, error) {
-	currentPhysical, currentLogical := gta.timestampOracle.getTSO()
-	if currentPhysical == typeutil.ZeroTime {
-		return pdpb.Timestamp{}, errs.ErrGenerateTimestamp.FastGenByArgs("timestamp in memory isn't initialized")
-	}
-	return *tsoutil.GenerateTimestamp(currentPhysical, uint64(currentLogical)), nil
-}
-
 // Reset is used to reset the TSO allocator.
 func (gta *GlobalTSOAllocator) Reset() {
 	gta.timestampOracle.ResetTimestamp()
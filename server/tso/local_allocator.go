@@ -33,7 +33,7 @@ import (
 type LocalTSOAllocator struct {
 	allocatorManager *AllocatorManager
 	// leadership is used to campaign the corresponding DC's Local TSO Allocator.
-	leadership      *election.Leadership
+	leadership      *election.EtcdLeadership
 	timestampOracle *timestampOracle
 	// for election use, notice that the leadership that member holds is
 	// the leadership for PD leader. Local TSO Allocator's leadership is for the
@@ -48,18 +48,20 @@ type LocalTSOAllocator struct {
 // NewLocalTSOAllocator creates a new local TSO allocator.
 func NewLocalTSOAllocator(
 	am *AllocatorManager,
-	leadership *election.Leadership,
+	leadership *election.EtcdLeadership,
 	dcLocation string,
 ) Allocator {
 	return &LocalTSOAllocator{
 		allocatorManager: am,
 		leadership:       leadership,
 		timestampOracle: &timestampOracle{
-			client:                 leadership.GetClient(),
+			kv:                     am.kv,
 			rootPath:               leadership.GetLeaderKey(),
 			saveInterval:           am.saveInterval,
 			updatePhysicalInterval: am.updatePhysicalInterval,
 			maxResetTSGap:          am.maxResetTSGap,
+			dcLocation:             dcLocation,
+			skewRecorder:           &am.skewRecorder,
 		},
 		rootPath:   leadership.GetLeaderKey(),
 		dcLocation: dcLocation,
@@ -137,17 +139,25 @@ func (lta *LocalTSOAllocator) GetCurrentTSO() (pdpb.Timestamp, error) {
 	return *tsoutil.GenerateTimestamp(currentPhysical, uint64(currentLogical)), nil
 }
 
+// GetLastIssuedTSO returns the max TSO issued by the Local TSO Allocator.
+func (lta *LocalTSOAllocator) GetLastIssuedTSO() pdpb.Timestamp {
+	physical, logical := tsoutil.ParseTS(atomic.LoadUint64(&lta.timestampOracle.lastIssued))
+	return *tsoutil.GenerateTimestamp(physical, logical)
+}
+
 // WriteTSO is used to set the maxTS as current TSO in memory.
 func (lta *LocalTSOAllocator) WriteTSO(maxTS *pdpb.Timestamp) error {
 	currentTSO, err := lta.GetCurrentTSO()
 	if err != nil {
 		return err
 	}
+	suffixBits := CalSuffixBits(lta.allocatorManager.GetClusterDCLocationsNumber())
 	// If current local TSO has already been greater or equal to maxTS, then do not update it.
-	if tsoutil.CompareTimestamp(&currentTSO, maxTS) >= 0 {
+	// The local TSO in memory is undifferentiated, so compare it with the undifferentiated maxTS.
+	if tsoutil.CompareTimestamp(&currentTSO, &pdpb.Timestamp{Physical: maxTS.GetPhysical(), Logical: maxTS.GetLogical() >> suffixBits}) >= 0 {
 		return nil
 	}
-	return lta.timestampOracle.resetUserTimestamp(lta.leadership, tsoutil.GenerateTS(maxTS), true)
+	return lta.timestampOracle.syncTimestamp(lta.leadership, maxTS, suffixBits)
 }
 
 // EnableAllocatorLeader sets the Local TSO Allocator itself to a leader.
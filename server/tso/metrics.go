@@ -31,9 +31,18 @@ var (
 			Name:      "tso",
 			Help:      "Record of tso metadata.",
 		}, []string{"type"})
+
+	tsoSkewCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "pd",
+			Subsystem: "tso",
+			Name:      "skew_incidents_total",
+			Help:      "Counter of the timestamps which are not greater than the issued ones.",
+		}, []string{"dc", "phase"})
 )
 
 func init() {
 	prometheus.MustRegister(tsoCounter)
 	prometheus.MustRegister(tsoGauge)
+	prometheus.MustRegister(tsoSkewCounter)
 }
@@ -24,11 +24,11 @@ import (
 	"github.com/pingcap/kvproto/pkg/pdpb"
 	"github.com/pingcap/log"
 	"github.com/tikv/pd/pkg/errs"
-	"github.com/tikv/pd/pkg/etcdutil"
 	"github.com/tikv/pd/pkg/tsoutil"
 	"github.com/tikv/pd/pkg/typeutil"
+	"github.com/tikv/pd/server/config"
 	"github.com/tikv/pd/server/election"
-	"go.etcd.io/etcd/clientv3"
+	"github.com/tikv/pd/server/kv"
 	"go.uber.org/zap"
 )
 
@@ -48,11 +48,14 @@ const (
 type tsoObject struct {
 	physical time.Time
 	logical  int64
+	// updateTime is when the physical time is updated.
+	updateTime time.Time
 }
 
 // timestampOracle is used to maintain the logic of TSO.
 type timestampOracle struct {
-	client   *clientv3.Client
+	// kv stores the timestamp window, it has no root path.
+	kv       kv.Base
 	rootPath string
 	// TODO: remove saveInterval
 	saveInterval           time.Duration
@@ -62,21 +65,39 @@ type timestampOracle struct {
 	tsoMux struct {
 		sync.RWMutex
 		tso *tsoObject
+		// suffixBits is the suffix bits used by the last generated TSO.
+		suffixBits int
 	}
 	// last timestamp window stored in etcd
 	lastSavedTime atomic.Value // stored as time.Time
 	suffix        int
+
+	// lastIssued is the max timestamp issued by the allocator, which is saved
+	// into etcd as the high-water mark together with the time window.
+	lastIssued uint64
+	dcLocation string
+	// peerHighWaterMarkPaths returns the high-water marks of the other
+	// allocators that the timestamps of the allocator must be greater than.
+	peerHighWaterMarkPaths func() ([]string, error)
+	skewRecorder           *skewRecorder
 }
 
 func (t *timestampOracle) setTSOPhysical(next time.Time) {
 	t.tsoMux.Lock()
 	defer t.tsoMux.Unlock()
 	// make sure the ts won't fall back
-	if t.tsoMux.tso == nil || typeutil.SubTimeByWallClock(next, t.tsoMux.tso.physical) > 0 {
-		t.tsoMux.tso = &tsoObject{physical: next}
+	if t.tsoMux.tso == nil || subPhysical(next, t.tsoMux.tso.physical) > 0 {
+		t.tsoMux.tso = &tsoObject{physical: next, updateTime: time.Now()}
 	}
 }
 
+// subPhysical returns the difference between the physical parts of two TSOs,
+// which is in milliseconds as the precision of the TSO. The logical part of a
+// TSO only grows within the same millisecond.
+func subPhysical(after, before time.Time) time.Duration {
+	return time.Duration(after.UnixNano()/int64(time.Millisecond)-before.UnixNano()/int64(time.Millisecond)) * time.Millisecond
+}
+
 func (t *timestampOracle) getTSO() (time.Time, int64) {
 	t.tsoMux.RLock()
 	defer t.tsoMux.RUnlock()
@@ -86,20 +107,30 @@ func (t *timestampOracle) getTSO() (time.Time, int64) {
 	return t.tsoMux.tso.physical, t.tsoMux.tso.logical
 }
 
-// generateTSO will add the TSO's logical part with the given count and returns the new TSO result.
-func (t *timestampOracle) generateTSO(count int64, suffixBits int) (physical int64, logical int64) {
+// generateTSO will add the TSO's logical part with the given count and returns the new TSO result,
+// together with the time when the physical part is updated.
+func (t *timestampOracle) generateTSO(count int64, suffixBits int) (physical int64, logical int64, updateTime time.Time) {
 	t.tsoMux.Lock()
 	defer t.tsoMux.Unlock()
 	if t.tsoMux.tso == nil {
-		return 0, 0
+		return 0, 0, typeutil.ZeroTime
 	}
+	// The logical part is shifted by fewer bits once a dc-location is removed,
+	// so move on to the next physical time to keep the TSO increasing.
+	if suffixBits < t.tsoMux.suffixBits {
+		t.tsoMux.tso = &tsoObject{physical: t.tsoMux.tso.physical.Add(updateTimestampGuard), updateTime: time.Now()}
+	}
+	t.tsoMux.suffixBits = suffixBits
 	physical = t.tsoMux.tso.physical.UnixNano() / int64(time.Millisecond)
+	first := t.tsoMux.tso.logical + 1
 	t.tsoMux.tso.logical += count
 	logical = t.tsoMux.tso.logical
 	if suffixBits > 0 && t.suffix >= 0 {
+		first = t.differentiateLogical(first, suffixBits)
 		logical = t.differentiateLogical(logical, suffixBits)
 	}
-	return physical, logical
+	t.verifyIssued(physical, first, logical)
+	return physical, logical, t.tsoMux.tso.updateTime
 }
 
 // Because the Local TSO in each Local TSO Allocator is independent, so they are possible
@@ -123,28 +154,32 @@ func (t *timestampOracle) getTimestampPath() string {
 }
 
 func (t *timestampOracle) loadTimestamp() (time.Time, error) {
-	data, err := etcdutil.GetValue(t.client, t.getTimestampPath())
+	data, err := t.kv.Load(t.getTimestampPath())
 	if err != nil {
 		return typeutil.ZeroTime, err
 	}
 	if len(data) == 0 {
 		return typeutil.ZeroTime, nil
 	}
-	return typeutil.ParseTimestamp(data)
+	return typeutil.ParseTimestamp([]byte(data))
 }
 
 // save timestamp, if lastTs is 0, we think the timestamp doesn't exist, so create it,
 // otherwise, update it.
-func (t *timestampOracle) saveTimestamp(leadership *election.Leadership, ts time.Time) error {
+func (t *timestampOracle) saveTimestamp(leadership election.Leadership, ts time.Time) error {
 	key := t.getTimestampPath()
 	data := typeutil.Uint64ToBytes(uint64(ts.UnixNano()))
-	resp, err := leadership.LeaderTxn().
-		Then(clientv3.OpPut(key, string(data))).
-		Commit()
+	ops := []kv.Op{kv.OpSave(key, string(data))}
+	// Record the last issued timestamp under the leader lease, so the next
+	// leader can verify its timestamps don't fall back.
+	if lastIssued := atomic.LoadUint64(&t.lastIssued); lastIssued > 0 {
+		ops = append(ops, kv.OpSave(t.getHighWaterMarkPath(), string(typeutil.Uint64ToBytes(lastIssued))))
+	}
+	ok, err := t.kv.Txn([]kv.Condition{leadership.LeaderCondition()}, ops)
 	if err != nil {
 		return errs.ErrEtcdKVPut.Wrap(err).GenWithStackByCause()
 	}
-	if !resp.Succeeded {
+	if !ok {
 		return errs.ErrEtcdTxn.FastGenByArgs()
 	}
 	t.lastSavedTime.Store(ts)
@@ -152,7 +187,7 @@ func (t *timestampOracle) saveTimestamp(leadership *election.Leadership, ts time
 }
 
 // SyncTimestamp is used to synchronize the timestamp.
-func (t *timestampOracle) SyncTimestamp(leadership *election.Leadership) error {
+func (t *timestampOracle) SyncTimestamp(leadership election.Leadership) error {
 	tsoCounter.WithLabelValues("sync").Inc()
 
 	failpoint.Inject("delaySyncTimestamp", func() {
@@ -163,11 +198,20 @@ func (t *timestampOracle) SyncTimestamp(leadership *election.Leadership) error {
 	if err != nil {
 		return err
 	}
+	highWaterMark, err := t.loadHighWaterMark()
+	if err != nil {
+		return err
+	}
 
 	next := time.Now()
 	failpoint.Inject("fallBackSync", func() {
 		next = next.Add(time.Hour)
 	})
+	failpoint.Inject("localClockOffset", func(val failpoint.Value) {
+		if t.dcLocation != config.GlobalDCLocation {
+			next = next.Add(time.Duration(val.(int)) * time.Millisecond)
+		}
+	})
 
 	// If the current system time minus the saved etcd timestamp is less than `updateTimestampGuard`,
 	// the timestamp allocation will start from the saved etcd timestamp temporarily.
@@ -176,6 +220,16 @@ func (t *timestampOracle) SyncTimestamp(leadership *election.Leadership) error {
 		next = last.Add(updateTimestampGuard)
 	}
 
+	// The saved time window should always be greater than the issued timestamps,
+	// so it's a bug if the timestamps going to be issued don't exceed the
+	// high-water mark. Report it and start after the high-water mark instead.
+	if nextTS := tsoutil.GenerateTS(tsoutil.GenerateTimestamp(next, 0)); nextTS <= highWaterMark {
+		t.reportSkew(SkewPhaseSync, nextTS, highWaterMark)
+		physical, _ := tsoutil.ParseTS(highWaterMark)
+		next = physical.Add(updateTimestampGuard)
+	}
+	t.raiseHighWaterMark(highWaterMark)
+
 	save := next.Add(t.saveInterval)
 	if err = t.saveTimestamp(leadership, save); err != nil {
 		tsoCounter.WithLabelValues("err_save_sync_ts").Inc()
@@ -183,7 +237,7 @@ func (t *timestampOracle) SyncTimestamp(leadership *election.Leadership) error {
 	}
 
 	tsoCounter.WithLabelValues("sync_ok").Inc()
-	log.Info("sync and save timestamp", zap.Time("last", last), zap.Time("save", save), zap.Time("next", next))
+	log.Info("sync and save timestamp", zap.Time("last", last), zap.Uint64("high-water-mark", highWaterMark), zap.Time("save", save), zap.Time("next", next))
 	// save into memory
 	t.setTSOPhysical(next)
 	return nil
@@ -200,32 +254,46 @@ func (t *timestampOracle) isInitialized() bool {
 }
 
 // resetUserTimestamp update the TSO in memory with specified TSO by an atomicly way.
-func (t *timestampOracle) resetUserTimestamp(leadership *election.Leadership, tso uint64, ignoreSmaller bool) error {
+func (t *timestampOracle) resetUserTimestamp(leadership election.Leadership, tso uint64, ignoreSmaller bool) error {
+	nextPhysical, nextLogical := tsoutil.ParseTS(tso)
+	return t.resetUserTimestampInner(leadership, nextPhysical.Add(updateTimestampGuard), int64(nextLogical), ignoreSmaller)
+}
+
+// syncTimestamp updates the TSO in memory to the given one synchronized among
+// the allocators if it's greater. The suffix is removed from the logical part,
+// so the TSO in memory is always undifferentiated. Unlike resetUserTimestamp,
+// it doesn't move the physical time ahead, otherwise the TSO would run ahead
+// of the wall clock by syncing it again and again.
+func (t *timestampOracle) syncTimestamp(leadership election.Leadership, ts *pdpb.Timestamp, suffixBits int) error {
+	nextPhysical, nextLogical := tsoutil.ParseTimestamp(*ts)
+	return t.resetUserTimestampInner(leadership, nextPhysical, int64(nextLogical>>suffixBits), true)
+}
+
+func (t *timestampOracle) resetUserTimestampInner(leadership election.Leadership, nextPhysical time.Time, nextLogical int64, ignoreSmaller bool) error {
 	t.tsoMux.Lock()
 	defer t.tsoMux.Unlock()
 	if !leadership.Check() {
 		tsoCounter.WithLabelValues("err_lease_reset_ts").Inc()
 		return errs.ErrResetUserTimestamp.FastGenByArgs("lease expired")
 	}
-	nextPhysical, nextLogical := tsoutil.ParseTS(tso)
-	nextPhysical = nextPhysical.Add(updateTimestampGuard)
 	var err error
+	physicalDifference := subPhysical(nextPhysical, t.tsoMux.tso.physical)
 	// do not update if next logical time is less/before than prev
-	if typeutil.SubTimeByWallClock(nextPhysical, t.tsoMux.tso.physical) == 0 && int64(nextLogical) <= t.tsoMux.tso.logical {
+	if physicalDifference == 0 && nextLogical <= t.tsoMux.tso.logical {
 		tsoCounter.WithLabelValues("err_reset_small_counter").Inc()
 		if !ignoreSmaller {
 			err = errs.ErrResetUserTimestamp.FastGenByArgs("the specified counter is smaller than now")
 		}
 	}
 	// do not update if next physical time is less/before than prev
-	if typeutil.SubTimeByWallClock(nextPhysical, t.tsoMux.tso.physical) < 0 {
+	if physicalDifference < 0 {
 		tsoCounter.WithLabelValues("err_reset_small_ts").Inc()
 		if !ignoreSmaller {
 			err = errs.ErrResetUserTimestamp.FastGenByArgs("the specified ts is smaller than now")
 		}
 	}
 	// do not update if physical time is too greater than prev
-	if typeutil.SubTimeByWallClock(nextPhysical, t.tsoMux.tso.physical) >= t.maxResetTSGap() {
+	if physicalDifference >= t.maxResetTSGap() {
 		tsoCounter.WithLabelValues("err_reset_large_ts").Inc()
 		err = errs.ErrResetUserTimestamp.FastGenByArgs("the specified ts is too larger than now")
 	}
@@ -233,7 +301,7 @@ func (t *timestampOracle) resetUserTimestamp(leadership *election.Leadership, ts
 		return err
 	}
 	// save into etcd only if the time difference is big enough
-	if typeutil.SubTimeByWallClock(nextPhysical, t.tsoMux.tso.physical) > 3*updateTimestampGuard {
+	if physicalDifference > 3*updateTimestampGuard {
 		save := nextPhysical.Add(t.saveInterval)
 		if err = t.saveTimestamp(leadership, save); err != nil {
 			tsoCounter.WithLabelValues("err_save_reset_ts").Inc()
@@ -241,14 +309,11 @@ func (t *timestampOracle) resetUserTimestamp(leadership *election.Leadership, ts
 		}
 	}
 	// save into memory and make sure the ts won't fall back
-	if t.tsoMux.tso == nil {
-		t.tsoMux.tso = &tsoObject{physical: nextPhysical, logical: int64(nextLogical)}
-	}
-	if typeutil.SubTimeByWallClock(nextPhysical, t.tsoMux.tso.physical) > 0 {
-		t.tsoMux.tso = &tsoObject{physical: nextPhysical}
+	if physicalDifference > 0 {
+		t.tsoMux.tso = &tsoObject{physical: nextPhysical, logical: nextLogical, updateTime: time.Now()}
 	}
-	if typeutil.SubTimeByWallClock(nextPhysical, t.tsoMux.tso.physical) == 0 && int64(nextLogical) > t.tsoMux.tso.logical {
-		t.tsoMux.tso = &tsoObject{physical: nextPhysical, logical: int64(nextLogical)}
+	if physicalDifference == 0 && nextLogical > t.tsoMux.tso.logical {
+		t.tsoMux.tso = &tsoObject{physical: t.tsoMux.tso.physical, logical: nextLogical, updateTime: t.tsoMux.tso.updateTime}
 	}
 	tsoCounter.WithLabelValues("reset_tso_ok").Inc()
 	return nil
@@ -265,13 +330,18 @@ func (t *timestampOracle) resetUserTimestamp(leadership *election.Leadership, ts
 // 1. The saved time is monotonically increasing.
 // 2. The physical time is monotonically increasing.
 // 3. The physical time is always less than the saved timestamp.
-func (t *timestampOracle) UpdateTimestamp(leadership *election.Leadership) error {
+func (t *timestampOracle) UpdateTimestamp(leadership election.Leadership) error {
 	prevPhysical, prevLogical := t.getTSO()
 	now := time.Now()
 
 	failpoint.Inject("fallBackUpdate", func() {
 		now = now.Add(time.Hour)
 	})
+	failpoint.Inject("localClockOffset", func(val failpoint.Value) {
+		if t.dcLocation != config.GlobalDCLocation {
+			now = now.Add(time.Duration(val.(int)) * time.Millisecond)
+		}
+	})
 
 	tsoCounter.WithLabelValues("save").Inc()
 
@@ -317,7 +387,7 @@ func (t *timestampOracle) UpdateTimestamp(leadership *election.Leadership) error
 }
 
 // getTS is used to get a timestamp.
-func (t *timestampOracle) getTS(leadership *election.Leadership, count uint32, dcLocationNum int) (pdpb.Timestamp, error) {
+func (t *timestampOracle) getTS(leadership election.Leadership, count uint32, dcLocationNum int) (pdpb.Timestamp, error) {
 	var resp pdpb.Timestamp
 
 	if count == 0 {
@@ -345,7 +415,7 @@ func (t *timestampOracle) getTS(leadership *election.Leadership, count uint32, d
 			return pdpb.Timestamp{}, errs.ErrGenerateTimestamp.FastGenByArgs("timestamp in memory isn't initialized")
 		}
 		// Get a new TSO result with the given count
-		resp.Physical, resp.Logical = t.generateTSO(int64(count), CalSuffixBits(dcLocationNum))
+		resp.Physical, resp.Logical, _ = t.generateTSO(int64(count), CalSuffixBits(dcLocationNum))
 		if resp.GetPhysical() == 0 {
 			return pdpb.Timestamp{}, errs.ErrGenerateTimestamp.FastGenByArgs("timestamp in memory has been reset")
 		}
@@ -0,0 +1,157 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tso
+
+import (
+	"path"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pingcap/kvproto/pkg/pdpb"
+	"github.com/pingcap/log"
+	"github.com/tikv/pd/pkg/errs"
+	"github.com/tikv/pd/pkg/tsoutil"
+	"github.com/tikv/pd/pkg/typeutil"
+	"go.uber.org/zap"
+)
+
+const (
+	// highWaterMarkKey is the key of the last issued timestamp of an allocator.
+	highWaterMarkKey = "last-issued-ts"
+	// maxSkewIncidents is the max number of the skew incidents kept in memory.
+	maxSkewIncidents = 64
+)
+
+// The phases in which a skew incident is found.
+const (
+	SkewPhaseSync     = "sync"
+	SkewPhaseGenerate = "generate"
+)
+
+// SkewIncident is a timestamp that is not greater than the timestamps
+// issued before, which means the TSO falls back.
+type SkewIncident struct {
+	DCLocation string    `json:"dc_location"`
+	Phase      string    `json:"phase"`
+	Time       time.Time `json:"time"`
+	// Timestamp is the timestamp going to be issued.
+	Timestamp uint64 `json:"timestamp"`
+	// HighWaterMark is the max timestamp issued before.
+	HighWaterMark uint64 `json:"high_water_mark"`
+}
+
+// skewRecorder keeps the recent skew incidents of the allocators.
+type skewRecorder struct {
+	mu        sync.Mutex
+	incidents []*SkewIncident
+}
+
+func (r *skewRecorder) record(incident *SkewIncident) {
+	tsoSkewCounter.WithLabelValues(incident.DCLocation, incident.Phase).Inc()
+	log.Error("the tso falls back",
+		zap.String("dc-location", incident.DCLocation),
+		zap.String("phase", incident.Phase),
+		zap.Uint64("timestamp", incident.Timestamp),
+		zap.Uint64("high-water-mark", incident.HighWaterMark),
+		errs.ZapError(errs.ErrTSOSkew))
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.incidents = append(r.incidents, incident)
+	if len(r.incidents) > maxSkewIncidents {
+		r.incidents = r.incidents[len(r.incidents)-maxSkewIncidents:]
+	}
+}
+
+func (r *skewRecorder) list() []*SkewIncident {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	incidents := make([]*SkewIncident, len(r.incidents))
+	copy(incidents, r.incidents)
+	return incidents
+}
+
+func (t *timestampOracle) getHighWaterMarkPath() string {
+	return path.Join(t.rootPath, highWaterMarkKey)
+}
+
+// loadHighWaterMark loads the max timestamp issued by the previous leaders of
+// the allocator and the allocators it must exceed.
+func (t *timestampOracle) loadHighWaterMark() (uint64, error) {
+	paths := []string{t.getHighWaterMarkPath()}
+	if t.peerHighWaterMarkPaths != nil {
+		peerPaths, err := t.peerHighWaterMarkPaths()
+		if err != nil {
+			return 0, err
+		}
+		paths = append(paths, peerPaths...)
+	}
+	var maxTS uint64
+	for _, p := range paths {
+		data, err := t.kv.Load(p)
+		if err != nil {
+			return 0, err
+		}
+		if len(data) == 0 {
+			continue
+		}
+		ts, err := typeutil.BytesToUint64([]byte(data))
+		if err != nil {
+			return 0, err
+		}
+		if ts > maxTS {
+			maxTS = ts
+		}
+	}
+	return maxTS, nil
+}
+
+// raiseHighWaterMark raises the high-water mark in memory to the issued
+// timestamp if it is greater.
+func (t *timestampOracle) raiseHighWaterMark(ts uint64) {
+	for {
+		last := atomic.LoadUint64(&t.lastIssued)
+		if ts <= last || atomic.CompareAndSwapUint64(&t.lastIssued, last, ts) {
+			return
+		}
+	}
+}
+
+// verifyIssued checks the timestamps with the logical part from first to last
+// going to be issued are greater than all timestamps issued before, and
+// reports a skew incident if not.
+func (t *timestampOracle) verifyIssued(physical, first, last int64) {
+	if last >= maxLogical {
+		// It will be retried with a new physical time.
+		return
+	}
+	firstTS := tsoutil.GenerateTS(&pdpb.Timestamp{Physical: physical, Logical: first})
+	if highWaterMark := atomic.LoadUint64(&t.lastIssued); firstTS <= highWaterMark {
+		t.reportSkew(SkewPhaseGenerate, firstTS, highWaterMark)
+	}
+	t.raiseHighWaterMark(tsoutil.GenerateTS(&pdpb.Timestamp{Physical: physical, Logical: last}))
+}
+
+func (t *timestampOracle) reportSkew(phase string, ts, highWaterMark uint64) {
+	if t.skewRecorder == nil {
+		return
+	}
+	t.skewRecorder.record(&SkewIncident{
+		DCLocation:    t.dcLocation,
+		Phase:         phase,
+		Time:          time.Now(),
+		Timestamp:     ts,
+		HighWaterMark: highWaterMark,
+	})
+}
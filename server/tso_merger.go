@@ -0,0 +1,102 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"sync"
+
+	"github.com/pingcap/kvproto/pkg/pdpb"
+)
+
+// maxMergedTSOCount is the max number of timestamps allocated by a merged
+// batch, which keeps the batch far from exhausting the logical part.
+const maxMergedTSOCount = 1 << 16
+
+// tsoBatch is a batch of the tso requests allocated at once.
+type tsoBatch struct {
+	count    uint32
+	requests int
+	// prev is the batch created before this one, which must be allocated
+	// first to keep the timestamps of the batches in order.
+	prev *tsoBatch
+	done chan struct{}
+	ts   pdpb.Timestamp
+	err  error
+}
+
+// tsoMerger merges the tso requests of the concurrent streams into one
+// allocation. The requests arriving while a batch is being allocated are
+// collected into the next batch, which is allocated by its first request once
+// the previous batch is done. A request is never held back to wait for more
+// requests, so it adds no latency when there is no contention.
+type tsoMerger struct {
+	allocate func(dcLocation string, count uint32) (pdpb.Timestamp, error)
+
+	mu sync.Mutex
+	// collecting is the batch still accepting requests of a dc-location.
+	collecting map[string]*tsoBatch
+	// last is the latest batch of a dc-location.
+	last map[string]*tsoBatch
+}
+
+func newTSOMerger(allocate func(dcLocation string, count uint32) (pdpb.Timestamp, error)) *tsoMerger {
+	return &tsoMerger{
+		allocate:   allocate,
+		collecting: make(map[string]*tsoBatch),
+		last:       make(map[string]*tsoBatch),
+	}
+}
+
+// handle allocates count timestamps of the dc-location, and returns the
+// highest one like AllocatorManager.HandleTSORequest.
+func (m *tsoMerger) handle(dcLocation string, count uint32) (pdpb.Timestamp, error) {
+	m.mu.Lock()
+	b, first := m.collecting[dcLocation], false
+	if b == nil || b.count+count > maxMergedTSOCount {
+		b = &tsoBatch{prev: m.last[dcLocation], done: make(chan struct{})}
+		m.collecting[dcLocation] = b
+		m.last[dcLocation] = b
+		first = true
+	}
+	offset := b.count
+	b.count += count
+	b.requests++
+	m.mu.Unlock()
+
+	if first {
+		if b.prev != nil {
+			<-b.prev.done
+			b.prev = nil
+		}
+		m.mu.Lock()
+		if m.collecting[dcLocation] == b {
+			delete(m.collecting, dcLocation)
+		}
+		total, requests := b.count, b.requests
+		m.mu.Unlock()
+		b.ts, b.err = m.allocate(dcLocation, total)
+		tsoMergedRequests.Observe(float64(requests))
+		close(b.done)
+	} else {
+		<-b.done
+	}
+	if b.err != nil {
+		return pdpb.Timestamp{}, b.err
+	}
+	// The batch returns the highest timestamp, and the requests take the
+	// timestamps in the order they join the batch.
+	ts := b.ts
+	ts.Logical -= int64(b.count - offset - count)
+	return ts, nil
+}
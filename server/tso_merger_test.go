@@ -0,0 +1,89 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/errors"
+	"github.com/pingcap/kvproto/pkg/pdpb"
+)
+
+var _ = Suite(&testTSOMergerSuite{})
+
+type testTSOMergerSuite struct{}
+
+func (s *testTSOMergerSuite) TestMerge(c *C) {
+	var (
+		mu          sync.Mutex
+		logical     int64
+		allocations int
+	)
+	merger := newTSOMerger(func(dcLocation string, count uint32) (pdpb.Timestamp, error) {
+		// Slow down the allocation to let the requests be merged.
+		time.Sleep(time.Millisecond)
+		mu.Lock()
+		defer mu.Unlock()
+		logical += int64(count)
+		allocations++
+		return pdpb.Timestamp{Physical: 1, Logical: logical}, nil
+	})
+
+	const workers, requests = 10, 100
+	var wg sync.WaitGroup
+	results := make(chan int64, workers*requests*3)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(count uint32) {
+			defer wg.Done()
+			var last int64
+			for j := 0; j < requests; j++ {
+				ts, err := merger.handle("global", count)
+				c.Assert(err, IsNil)
+				c.Assert(ts.GetLogical(), Greater, last)
+				last = ts.GetLogical()
+				for k := int64(count) - 1; k >= 0; k-- {
+					results <- ts.GetLogical() - k
+				}
+			}
+		}(uint32(i%3 + 1))
+	}
+	wg.Wait()
+	close(results)
+
+	// Every timestamp is allocated to exactly one request.
+	var all []int64
+	for ts := range results {
+		all = append(all, ts)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i] < all[j] })
+	c.Assert(int64(len(all)), Equals, logical)
+	for i, ts := range all {
+		c.Assert(ts, Equals, int64(i+1))
+	}
+	c.Assert(allocations < workers*requests, IsTrue)
+}
+
+func (s *testTSOMergerSuite) TestError(c *C) {
+	merger := newTSOMerger(func(dcLocation string, count uint32) (pdpb.Timestamp, error) {
+		return pdpb.Timestamp{}, errors.New("not leader")
+	})
+	_, err := merger.handle("global", 1)
+	c.Assert(err, ErrorMatches, "not leader")
+	_, err = merger.handle("global", 1)
+	c.Assert(err, ErrorMatches, "not leader")
+}
@@ -14,7 +14,6 @@
 package server
 
 import (
-	"context"
 	"fmt"
 	"math/rand"
 	"path"
@@ -24,18 +23,13 @@ import (
 	"github.com/pingcap/kvproto/pkg/pdpb"
 	"github.com/pingcap/log"
 	"github.com/tikv/pd/pkg/errs"
-	"github.com/tikv/pd/pkg/etcdutil"
 	"github.com/tikv/pd/pkg/typeutil"
 	"github.com/tikv/pd/server/config"
+	"github.com/tikv/pd/server/kv"
 	"github.com/tikv/pd/server/versioninfo"
-	"go.etcd.io/etcd/clientv3"
 	"go.uber.org/zap"
 )
 
-const (
-	requestTimeout = etcdutil.DefaultRequestTimeout
-)
-
 // LogPDInfo prints the PD version information.
 func LogPDInfo() {
 	log.Info("Welcome to Placement Driver (PD)")
@@ -83,10 +77,7 @@ func CheckPDVersion(opt *config.PersistOptions) {
 	}
 }
 
-func initOrGetClusterID(c *clientv3.Client, key string) (uint64, error) {
-	ctx, cancel := context.WithTimeout(c.Ctx(), requestTimeout)
-	defer cancel()
-
+func initOrGetClusterID(kvBase kv.Base, key string) (uint64, error) {
 	// Generate a random cluster ID.
 	ts := uint64(time.Now().Unix())
 	clusterID := (ts << 32) + uint64(rand.Uint32())
@@ -95,31 +86,26 @@ func initOrGetClusterID(c *clientv3.Client, key string) (uint64, error) {
 	// Multiple PDs may try to init the cluster ID at the same time.
 	// Only one PD can commit this transaction, then other PDs can get
 	// the committed cluster ID.
-	resp, err := c.Txn(ctx).
-		If(clientv3.Compare(clientv3.CreateRevision(key), "=", 0)).
-		Then(clientv3.OpPut(key, string(value))).
-		Else(clientv3.OpGet(key)).
-		Commit()
+	ok, err := kvBase.Txn([]kv.Condition{{Key: key}}, []kv.Op{kv.OpSave(key, string(value))})
 	if err != nil {
-		return 0, errs.ErrEtcdTxn.Wrap(err).GenWithStackByCause()
+		return 0, err
 	}
 
 	// Txn commits ok, return the generated cluster ID.
-	if resp.Succeeded {
+	if ok {
 		return clusterID, nil
 	}
 
 	// Otherwise, parse the committed cluster ID.
-	if len(resp.Responses) == 0 {
-		return 0, errs.ErrEtcdTxn.FastGenByArgs()
+	committed, err := kvBase.Load(key)
+	if err != nil {
+		return 0, err
 	}
-
-	response := resp.Responses[0].GetResponseRange()
-	if response == nil || len(response.Kvs) != 1 {
+	if len(committed) == 0 {
 		return 0, errs.ErrEtcdTxn.FastGenByArgs()
 	}
 
-	return typeutil.BytesToUint64(response.Kvs[0].Value)
+	return typeutil.BytesToUint64([]byte(committed))
 }
 
 func makeStoreKey(clusterRootPath string, storeID uint64) string {
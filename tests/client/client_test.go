@@ -32,14 +32,18 @@ import (
 	"github.com/pingcap/kvproto/pkg/metapb"
 	"github.com/pingcap/kvproto/pkg/pdpb"
 	pd "github.com/tikv/pd/client"
+	"github.com/tikv/pd/pkg/grpcutil"
 	"github.com/tikv/pd/pkg/mock/mockid"
 	"github.com/tikv/pd/pkg/testutil"
+	"github.com/tikv/pd/pkg/watch"
 	"github.com/tikv/pd/server"
 	"github.com/tikv/pd/server/config"
 	"github.com/tikv/pd/server/core"
 	"github.com/tikv/pd/tests"
 	"go.etcd.io/etcd/clientv3"
 	"go.uber.org/goleak"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
 )
 
 func Test(t *testing.T) {
@@ -374,6 +378,330 @@ func (s *clientTestSuite) TestCustomTimeout(c *C) {
 	c.Assert(time.Since(start), Less, 2*time.Second)
 }
 
+func (s *clientTestSuite) TestFollowerHandle(c *C) {
+	cluster, err := tests.NewTestCluster(s.ctx, 3)
+	c.Assert(err, IsNil)
+	defer cluster.Destroy()
+
+	err = cluster.RunInitialServers()
+	c.Assert(err, IsNil)
+	leaderServer := cluster.GetServer(cluster.WaitLeader())
+	c.Assert(leaderServer.BootstrapCluster(), IsNil)
+	rc := leaderServer.GetServer().GetRaftCluster()
+	c.Assert(rc, NotNil)
+	region := &metapb.Region{
+		Id:          100,
+		RegionEpoch: &metapb.RegionEpoch{ConfVer: 1, Version: 1},
+		StartKey:    []byte("a"),
+		EndKey:      []byte("b"),
+		Peers:       []*metapb.Peer{{Id: 101, StoreId: 1}},
+	}
+	c.Assert(rc.HandleRegionHeartbeat(core.NewRegionInfo(region, region.Peers[0])), IsNil)
+
+	var follower *tests.TestServer
+	for _, s := range cluster.GetServers() {
+		if s != leaderServer {
+			follower = s
+			break
+		}
+	}
+	testutil.WaitUntil(c, func(c *C) bool {
+		return follower.GetServer().GetBasicCluster().GetRegion(region.GetId()) != nil
+	})
+
+	// The follower handles the request only if the client allows it.
+	grpcPDClient := testutil.MustNewGrpcClient(c, follower.GetAddr())
+	req := &pdpb.GetRegionRequest{Header: newHeader(follower.GetServer()), RegionKey: []byte("a")}
+	_, err = grpcPDClient.GetRegion(s.ctx, req)
+	c.Assert(err, ErrorMatches, ".*not leader.*")
+	var md metadata.MD
+	ctx := metadata.AppendToOutgoingContext(s.ctx, grpcutil.AllowFollowerHandleKey, "true")
+	resp, err := grpcPDClient.GetRegion(ctx, req, grpc.Header(&md))
+	c.Assert(err, IsNil)
+	c.Assert(resp.GetRegion().GetId(), Equals, region.GetId())
+	c.Assert(md.Get(grpcutil.FollowerHandleKey), DeepEquals, []string{"true"})
+	c.Assert(md.Get(grpcutil.RegionSyncIndexKey), HasLen, 1)
+
+	var endpoints []string
+	for _, s := range cluster.GetServers() {
+		endpoints = append(endpoints, s.GetConfig().AdvertiseClientUrls)
+	}
+	cli, err := pd.NewClientWithContext(s.ctx, endpoints, pd.SecurityOption{}, pd.WithAllowFollowerHandle())
+	c.Assert(err, IsNil)
+	defer cli.Close()
+	for i := 0; i < 3; i++ {
+		r, err := cli.GetRegion(s.ctx, []byte("a"))
+		c.Assert(err, IsNil)
+		c.Assert(r.Meta.GetId(), Equals, region.GetId())
+		r, err = cli.GetRegionByID(s.ctx, region.GetId())
+		c.Assert(err, IsNil)
+		c.Assert(r.Meta.GetId(), Equals, region.GetId())
+		regions, err := cli.ScanRegions(s.ctx, []byte("a"), []byte("b"), 10)
+		c.Assert(err, IsNil)
+		c.Assert(regions, HasLen, 1)
+	}
+
+	// The requests fall back to the leader if a follower is unavailable.
+	c.Assert(follower.Stop(), IsNil)
+	for i := 0; i < 3; i++ {
+		r, err := cli.GetRegion(s.ctx, []byte("a"))
+		c.Assert(err, IsNil)
+		c.Assert(r.Meta.GetId(), Equals, region.GetId())
+	}
+}
+
+func (s *clientTestSuite) TestFollowerHandleStaleness(c *C) {
+	cluster, err := tests.NewTestCluster(s.ctx, 2)
+	c.Assert(err, IsNil)
+	defer cluster.Destroy()
+
+	err = cluster.RunInitialServers()
+	c.Assert(err, IsNil)
+	leaderServer := cluster.GetServer(cluster.WaitLeader())
+	c.Assert(leaderServer.BootstrapCluster(), IsNil)
+	rc := leaderServer.GetServer().GetRaftCluster()
+	c.Assert(rc, NotNil)
+	var follower *tests.TestServer
+	for _, s := range cluster.GetServers() {
+		if s != leaderServer {
+			follower = s
+		}
+	}
+	grpcPDClient := testutil.MustNewGrpcClient(c, follower.GetAddr())
+	req := &pdpb.GetRegionRequest{Header: newHeader(follower.GetServer()), RegionKey: []byte("a")}
+	ctx := metadata.AppendToOutgoingContext(s.ctx, grpcutil.AllowFollowerHandleKey, "true")
+	testutil.WaitUntil(c, func(c *C) bool {
+		_, err := grpcPDClient.GetRegion(ctx, req)
+		return err == nil
+	})
+
+	// The follower stops hearing from the leader, so it does not know the
+	// region added after that.
+	c.Assert(failpoint.Enable("github.com/tikv/pd/server/region_syncer/dropSyncRegionResponse", "return(true)"), IsNil)
+	defer func() {
+		c.Assert(failpoint.Disable("github.com/tikv/pd/server/region_syncer/dropSyncRegionResponse"), IsNil)
+	}()
+	region := &metapb.Region{
+		Id:          100,
+		RegionEpoch: &metapb.RegionEpoch{ConfVer: 1, Version: 1},
+		StartKey:    []byte("a"),
+		EndKey:      []byte("b"),
+		Peers:       []*metapb.Peer{{Id: 101, StoreId: 1}},
+	}
+	c.Assert(rc.HandleRegionHeartbeat(core.NewRegionInfo(region, region.Peers[0])), IsNil)
+	time.Sleep(2 * time.Second)
+	c.Assert(follower.GetServer().GetBasicCluster().GetRegion(region.GetId()), IsNil)
+
+	var md metadata.MD
+	_, err = grpcPDClient.GetRegion(ctx, req, grpc.Header(&md))
+	c.Assert(err, IsNil)
+	c.Assert(md.Get(grpcutil.RegionSyncStalenessKey), HasLen, 1)
+	staleness, err := strconv.ParseInt(md.Get(grpcutil.RegionSyncStalenessKey)[0], 10, 64)
+	c.Assert(err, IsNil)
+	c.Assert(staleness, GreaterEqual, int64(2000))
+
+	// The client has not seen any index from the leader, but it still rejects
+	// the follower which has not heard from the leader for too long.
+	endpoints := []string{follower.GetConfig().AdvertiseClientUrls, leaderServer.GetConfig().AdvertiseClientUrls}
+	cli, err := pd.NewClientWithContext(s.ctx, endpoints, pd.SecurityOption{},
+		pd.WithAllowFollowerHandle(), pd.WithMaxFollowerStaleness(time.Second))
+	c.Assert(err, IsNil)
+	defer cli.Close()
+	for i := 0; i < 3; i++ {
+		r, err := cli.GetRegion(s.ctx, []byte("a"))
+		c.Assert(err, IsNil)
+		c.Assert(r, NotNil)
+		c.Assert(r.Meta.GetId(), Equals, region.GetId())
+	}
+}
+
+func (s *clientTestSuite) TestForwardRequests(c *C) {
+	cluster, err := tests.NewTestCluster(s.ctx, 3)
+	c.Assert(err, IsNil)
+	defer cluster.Destroy()
+
+	err = cluster.RunInitialServers()
+	c.Assert(err, IsNil)
+	leaderServer := cluster.GetServer(cluster.WaitLeader())
+	c.Assert(leaderServer.BootstrapCluster(), IsNil)
+	region := &metapb.Region{
+		Id:          100,
+		RegionEpoch: &metapb.RegionEpoch{ConfVer: 1, Version: 1},
+		Peers:       []*metapb.Peer{{Id: 101, StoreId: 1}},
+	}
+	c.Assert(leaderServer.GetServer().GetRaftCluster().HandleRegionHeartbeat(core.NewRegionInfo(region, region.Peers[0])), IsNil)
+
+	var follower *tests.TestServer
+	for _, s := range cluster.GetServers() {
+		if s != leaderServer {
+			follower = s
+			break
+		}
+	}
+	grpcPDClient := testutil.MustNewGrpcClient(c, follower.GetAddr())
+
+	// The follower forwards the requests to the leader only.
+	req := &pdpb.GetRegionRequest{Header: newHeader(follower.GetServer()), RegionKey: []byte("a")}
+	_, err = grpcPDClient.GetRegion(grpcutil.BuildForwardContext(s.ctx, follower.GetAddr()+"1"), req)
+	c.Assert(err, ErrorMatches, ".*not leader.*")
+	ctx := grpcutil.BuildForwardContext(s.ctx, leaderServer.GetAddr())
+	resp, err := grpcPDClient.GetRegion(ctx, req)
+	c.Assert(err, IsNil)
+	c.Assert(resp.GetRegion().GetId(), Equals, region.GetId())
+
+	// The TSO stream is relayed to the leader.
+	tsoClient, err := grpcPDClient.Tso(ctx)
+	c.Assert(err, IsNil)
+	defer tsoClient.CloseSend()
+	var last uint64
+	for i := 0; i < 3; i++ {
+		err = tsoClient.Send(&pdpb.TsoRequest{Header: newHeader(follower.GetServer()), Count: 1, DcLocation: config.GlobalDCLocation})
+		c.Assert(err, IsNil)
+		tsoResp, err := tsoClient.Recv()
+		c.Assert(err, IsNil)
+		ts := s.makeTS(tsoResp.GetTimestamp().GetPhysical(), tsoResp.GetTimestamp().GetLogical())
+		c.Assert(ts, Greater, last)
+		last = ts
+	}
+
+	var endpoints []string
+	for _, s := range cluster.GetServers() {
+		endpoints = append(endpoints, s.GetConfig().AdvertiseClientUrls)
+	}
+	cli, err := pd.NewClientWithContext(s.ctx, endpoints, pd.SecurityOption{}, pd.WithForwardingOption(true))
+	c.Assert(err, IsNil)
+	defer cli.Close()
+	physical, logical, err := cli.GetTS(s.ctx)
+	c.Assert(err, IsNil)
+	c.Assert(s.makeTS(physical, logical), Greater, last)
+	r, err := cli.GetRegion(s.ctx, []byte("a"))
+	c.Assert(err, IsNil)
+	c.Assert(r.Meta.GetId(), Equals, region.GetId())
+}
+
+func (s *clientTestSuite) TestWatchStores(c *C) {
+	cluster, err := tests.NewTestCluster(s.ctx, 3)
+	c.Assert(err, IsNil)
+	defer cluster.Destroy()
+
+	err = cluster.RunInitialServers()
+	c.Assert(err, IsNil)
+	leaderServer := cluster.GetServer(cluster.WaitLeader())
+	c.Assert(leaderServer.BootstrapCluster(), IsNil)
+
+	var endpoints []string
+	for _, s := range cluster.GetServers() {
+		endpoints = append(endpoints, s.GetConfig().AdvertiseClientUrls)
+	}
+	cli, err := pd.NewClientWithContext(s.ctx, endpoints, pd.SecurityOption{})
+	c.Assert(err, IsNil)
+	defer cli.Close()
+	ctx, cancel := context.WithCancel(s.ctx)
+	defer cancel()
+	ch, err := cli.WatchStores(ctx)
+	c.Assert(err, IsNil)
+	mustRecv := func(tp watch.EventType, storeID uint64) *metapb.Store {
+		select {
+		case events := <-ch:
+			c.Assert(events, HasLen, 1)
+			c.Assert(events[0].Type, Equals, tp)
+			c.Assert(events[0].Store.GetId(), Equals, storeID)
+			return events[0].Store
+		case <-time.After(10 * time.Second):
+			c.Fatal("no store event")
+		}
+		return nil
+	}
+
+	// The watch starts with all stores.
+	mustRecv(watch.EventAdd, 1)
+	rc := leaderServer.GetServer().GetRaftCluster()
+	c.Assert(rc.PutStore(&metapb.Store{Id: 10, Address: "mock://10", Version: "2.0.0"}), IsNil)
+	mustRecv(watch.EventAdd, 10)
+	c.Assert(rc.RemoveStore(10), IsNil)
+	store := mustRecv(watch.EventUpdate, 10)
+	c.Assert(store.GetState(), Equals, metapb.StoreState_Offline)
+
+	// The watch resumes after the leader changes.
+	c.Assert(leaderServer.ResignLeader(), IsNil)
+	leaderServer = cluster.GetServer(cluster.WaitLeader())
+	rc = leaderServer.GetServer().GetRaftCluster()
+	c.Assert(rc, NotNil)
+	c.Assert(rc.PutStore(&metapb.Store{Id: 11, Address: "mock://11", Version: "2.0.0"}), IsNil)
+	mustRecv(watch.EventAdd, 11)
+
+	// The channel is closed with the context.
+	cancel()
+	testutil.WaitUntil(c, func(c *C) bool {
+		select {
+		case _, ok := <-ch:
+			return !ok
+		default:
+			return false
+		}
+	})
+}
+
+func (s *clientTestSuite) TestWatchRegions(c *C) {
+	cluster, err := tests.NewTestCluster(s.ctx, 1)
+	c.Assert(err, IsNil)
+	defer cluster.Destroy()
+
+	err = cluster.RunInitialServers()
+	c.Assert(err, IsNil)
+	leaderServer := cluster.GetServer(cluster.WaitLeader())
+	c.Assert(leaderServer.BootstrapCluster(), IsNil)
+	rc := leaderServer.GetServer().GetRaftCluster()
+	c.Assert(rc, NotNil)
+
+	cli, err := pd.NewClientWithContext(s.ctx, []string{leaderServer.GetConfig().AdvertiseClientUrls}, pd.SecurityOption{})
+	c.Assert(err, IsNil)
+	defer cli.Close()
+	ctx, cancel := context.WithCancel(s.ctx)
+	defer cancel()
+	ch, err := cli.WatchRegions(ctx, 0, &watch.KeyRange{StartKey: []byte("b"), EndKey: []byte("d")})
+	c.Assert(err, IsNil)
+
+	for i, key := range []string{"a", "b", "c", "d"} {
+		peer := &metapb.Peer{Id: uint64(i) + 100, StoreId: 1}
+		region := &metapb.Region{
+			Id:          uint64(i) + 10,
+			StartKey:    []byte(key),
+			EndKey:      []byte{key[0] + 1},
+			Peers:       []*metapb.Peer{peer},
+			RegionEpoch: &metapb.RegionEpoch{ConfVer: 1, Version: 1},
+		}
+		c.Assert(rc.HandleRegionHeartbeat(core.NewRegionInfo(region, peer)), IsNil)
+	}
+	// Only the regions overlapping with the key range are received.
+	var received []uint64
+	var nextIndex uint64
+	testutil.WaitUntil(c, func(c *C) bool {
+		select {
+		case resp := <-ch:
+			c.Assert(resp.ResetRequired, IsFalse)
+			for _, e := range resp.Events {
+				received = append(received, e.Region.GetId())
+			}
+			nextIndex = resp.NextIndex
+		default:
+		}
+		return len(received) >= 2
+	})
+	c.Assert(received, DeepEquals, []uint64{11, 12})
+
+	// The watch from an index out of the history requires a reset.
+	ch, err = cli.WatchRegions(ctx, nextIndex+100)
+	c.Assert(err, IsNil)
+	select {
+	case resp := <-ch:
+		c.Assert(resp.ResetRequired, IsTrue)
+		c.Assert(resp.NextIndex, Equals, nextIndex)
+	case <-time.After(10 * time.Second):
+		c.Fatal("no region watch response")
+	}
+}
+
 func (s *clientTestSuite) waitLeader(c *C, cli client, leader string) {
 	testutil.WaitUntil(c, func(c *C) bool {
 		cli.ScheduleCheckLeader()
@@ -546,6 +874,61 @@ func (s *testClientSuite) TestTSORace(c *C) {
 	wg.Wait()
 }
 
+func (s *testClientSuite) TestTSOWithMultipleStreams(c *C) {
+	cli, err := pd.NewClientWithContext(s.ctx, s.srv.GetEndpoints(), pd.SecurityOption{}, pd.WithTSOStreamCount(4))
+	c.Assert(err, IsNil)
+	defer cli.Close()
+
+	var (
+		wg  sync.WaitGroup
+		mu  sync.Mutex
+		tss = make(map[int64]struct{})
+	)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var last int64
+			for i := 0; i < 100; i++ {
+				p, l, err := cli.GetTS(context.Background())
+				c.Assert(err, IsNil)
+				ts := p<<18 + l
+				c.Assert(ts, Greater, last)
+				last = ts
+				mu.Lock()
+				tss[ts] = struct{}{}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	// The timestamps from different streams never duplicate.
+	c.Assert(tss, HasLen, 20*100)
+}
+
+func (s *testClientSuite) TestSlowTSORequests(c *C) {
+	cli, err := pd.NewClientWithContext(s.ctx, s.srv.GetEndpoints(), pd.SecurityOption{})
+	c.Assert(err, IsNil)
+	defer cli.Close()
+
+	for i := 0; i < 100; i++ {
+		_, _, err := cli.GetTS(context.Background())
+		c.Assert(err, IsNil)
+	}
+	traces := cli.GetSlowTSORequests()
+	c.Assert(traces, Not(HasLen), 0)
+	for i, trace := range traces {
+		c.Assert(trace.DCLocation, Equals, "global")
+		c.Assert(trace.BatchSize, GreaterEqual, 1)
+		c.Assert(trace.DispatcherWait() >= 0, IsTrue)
+		c.Assert(trace.RPC() > 0, IsTrue)
+		c.Assert(trace.Total(), Equals, trace.DispatcherWait()+trace.RPC())
+		if i > 0 {
+			c.Assert(trace.Total() <= traces[i-1].Total(), IsTrue)
+		}
+	}
+}
+
 func (s *testClientSuite) TestGetRegion(c *C) {
 	regionID := regionIDAllocator.alloc()
 	region := &metapb.Region{
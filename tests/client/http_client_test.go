@@ -0,0 +1,250 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	pd "github.com/tikv/pd/client"
+	pdhttp "github.com/tikv/pd/client/http"
+	"github.com/tikv/pd/pkg/apiutil/serverapi"
+	"github.com/tikv/pd/pkg/testutil"
+	"github.com/tikv/pd/server"
+	"github.com/tikv/pd/tests"
+	"github.com/tikv/pd/tests/pdctl"
+)
+
+var _ = Suite(&httpClientTestSuite{})
+
+// dialClient is used to send the requests without keeping the connections
+// alive, so that no connection is leaked after the cluster is destroyed.
+var dialClient = &http.Client{
+	Transport: &http.Transport{
+		DisableKeepAlives: true,
+	},
+}
+
+type httpClientTestSuite struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func (s *httpClientTestSuite) SetUpSuite(c *C) {
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+	server.EnableZap = true
+}
+
+func (s *httpClientTestSuite) TearDownSuite(c *C) {
+	s.cancel()
+}
+
+func (s *httpClientTestSuite) prepareCluster(c *C, count int) (*tests.TestCluster, []string) {
+	cluster, err := tests.NewTestCluster(s.ctx, count)
+	c.Assert(err, IsNil)
+	c.Assert(cluster.RunInitialServers(), IsNil)
+	leader := cluster.GetServer(cluster.WaitLeader())
+	c.Assert(leader.BootstrapCluster(), IsNil)
+	for id := uint64(1); id <= 3; id++ {
+		pdctl.MustPutStore(c, leader.GetServer(), id, metapb.StoreState_Up, nil)
+	}
+	var endpoints []string
+	for _, s := range cluster.GetServers() {
+		endpoints = append(endpoints, s.GetConfig().AdvertiseClientUrls)
+	}
+	return cluster, endpoints
+}
+
+func (s *httpClientTestSuite) TestHTTPClient(c *C) {
+	cluster, endpoints := s.prepareCluster(c, 1)
+	defer cluster.Destroy()
+	cli, err := pdhttp.NewClient(s.ctx, endpoints, pd.SecurityOption{}, pdhttp.WithHTTPClient(dialClient))
+	c.Assert(err, IsNil)
+	defer cli.Close()
+
+	// Stores.
+	stores, err := cli.GetStores(s.ctx)
+	c.Assert(err, IsNil)
+	c.Assert(stores.Count, Equals, 3)
+	c.Assert(cli.SetStoreLabels(s.ctx, 1, map[string]string{"zone": "z1"}), IsNil)
+	store, err := cli.GetStore(s.ctx, 1)
+	c.Assert(err, IsNil)
+	c.Assert(store.Store.GetId(), Equals, uint64(1))
+	c.Assert(store.Store.GetLabels(), HasLen, 1)
+	c.Assert(store.Store.GetLabels()[0].GetValue(), Equals, "z1")
+	_, err = cli.GetStore(s.ctx, 100)
+	c.Assert(err, ErrorMatches, ".*404.*")
+
+	// Config.
+	c.Assert(cli.SetConfig(s.ctx, map[string]interface{}{
+		"enable-placement-rules": "true",
+		"leader-schedule-limit":  8,
+	}), IsNil)
+	schedule, err := cli.GetScheduleConfig(s.ctx)
+	c.Assert(err, IsNil)
+	c.Assert(schedule["leader-schedule-limit"], Equals, float64(8))
+	replication, err := cli.GetReplicateConfig(s.ctx)
+	c.Assert(err, IsNil)
+	c.Assert(replication["enable-placement-rules"], Equals, "true")
+	config, err := cli.GetConfig(s.ctx)
+	c.Assert(err, IsNil)
+	c.Assert(config, HasKey, "schedule")
+
+	// Placement rules.
+	rules, err := cli.GetPlacementRules(s.ctx)
+	c.Assert(err, IsNil)
+	c.Assert(rules, HasLen, 1)
+	c.Assert(rules[0].GroupID, Equals, "pd")
+	rule := &pdhttp.Rule{GroupID: "test", ID: "r1", Role: pdhttp.Learner, Count: 1,
+		LabelConstraints: []pdhttp.LabelConstraint{{Key: "zone", Op: pdhttp.In, Values: []string{"z1"}}}}
+	c.Assert(cli.SetPlacementRule(s.ctx, rule), IsNil)
+	got, err := cli.GetPlacementRule(s.ctx, "test", "r1")
+	c.Assert(err, IsNil)
+	c.Assert(got, DeepEquals, rule)
+	rules, err = cli.GetPlacementRulesByGroup(s.ctx, "test")
+	c.Assert(err, IsNil)
+	c.Assert(rules, HasLen, 1)
+	c.Assert(cli.SetPlacementRuleGroup(s.ctx, &pdhttp.RuleGroup{ID: "test", Index: 2}), IsNil)
+	group, err := cli.GetPlacementRuleGroup(s.ctx, "test")
+	c.Assert(err, IsNil)
+	c.Assert(group.Index, Equals, 2)
+	bundle, err := cli.GetPlacementRuleBundle(s.ctx, "test")
+	c.Assert(err, IsNil)
+	c.Assert(bundle.Index, Equals, 2)
+	c.Assert(bundle.Rules, HasLen, 1)
+	bundle.Rules[0].Count = 2
+	c.Assert(cli.SetPlacementRuleBundles(s.ctx, []*pdhttp.GroupBundle{bundle}, true), IsNil)
+	bundles, err := cli.GetPlacementRuleBundles(s.ctx)
+	c.Assert(err, IsNil)
+	c.Assert(bundles, HasLen, 2)
+	// The IDs are escaped in the paths.
+	rule = &pdhttp.Rule{GroupID: "test", ID: "r2?x#y", Role: pdhttp.Voter, Count: 1}
+	c.Assert(cli.SetPlacementRule(s.ctx, rule), IsNil)
+	got, err = cli.GetPlacementRule(s.ctx, "test", "r2?x#y")
+	c.Assert(err, IsNil)
+	c.Assert(got.ID, Equals, "r2?x#y")
+	c.Assert(cli.DeletePlacementRule(s.ctx, "test", "r2?x#y"), IsNil)
+	c.Assert(cli.DeletePlacementRule(s.ctx, "test", "r1"), IsNil)
+	c.Assert(cli.DeletePlacementRuleGroup(s.ctx, "test"), IsNil)
+	_, err = cli.GetPlacementRule(s.ctx, "test", "r1")
+	c.Assert(err, ErrorMatches, ".*404.*")
+
+	// Schedulers.
+	c.Assert(cli.AddScheduler(s.ctx, "evict-leader-scheduler", map[string]interface{}{"store_id": 1}), IsNil)
+	names, err := cli.GetSchedulers(s.ctx)
+	c.Assert(err, IsNil)
+	c.Assert(containsString(names, "evict-leader-scheduler"), IsTrue)
+	c.Assert(cli.PauseScheduler(s.ctx, "evict-leader-scheduler", 0), IsNil)
+	c.Assert(cli.RemoveScheduler(s.ctx, "evict-leader-scheduler"), IsNil)
+	names, err = cli.GetSchedulers(s.ctx)
+	c.Assert(err, IsNil)
+	c.Assert(containsString(names, "evict-leader-scheduler"), IsFalse)
+
+	// Operators.
+	ops, err := cli.GetOperators(s.ctx)
+	c.Assert(err, IsNil)
+	c.Assert(ops, HasLen, 0)
+	c.Assert(cli.TransferLeader(s.ctx, 100, 2), ErrorMatches, ".*region 100 not found.*")
+	_, err = cli.GetOperatorByRegion(s.ctx, 100)
+	c.Assert(err, NotNil)
+
+	// Hotspot.
+	hot, err := cli.GetHotReadRegions(s.ctx)
+	c.Assert(err, IsNil)
+	c.Assert(hot.AsLeader, HasLen, 0)
+	_, err = cli.GetHotWriteRegions(s.ctx)
+	c.Assert(err, IsNil)
+
+	// GC safe point.
+	safePoints, err := cli.GetGCSafePoint(s.ctx)
+	c.Assert(err, IsNil)
+	c.Assert(safePoints.GCSafePoint, Equals, uint64(0))
+}
+
+func (s *httpClientTestSuite) TestLeaderChange(c *C) {
+	cluster, endpoints := s.prepareCluster(c, 3)
+	defer cluster.Destroy()
+	grpcClient, err := pd.NewClientWithContext(s.ctx, endpoints, pd.SecurityOption{})
+	c.Assert(err, IsNil)
+	defer grpcClient.Close()
+	// Shares the member discovery of the gRPC client.
+	cli, err := pdhttp.NewClientWithServiceDiscovery(grpcClient.(pd.ServiceDiscovery), pd.SecurityOption{}, pdhttp.WithHTTPClient(dialClient))
+	c.Assert(err, IsNil)
+	defer cli.Close()
+
+	stores, err := cli.GetStores(s.ctx)
+	c.Assert(err, IsNil)
+	c.Assert(stores.Count, Equals, 3)
+
+	c.Assert(cluster.GetServer(cluster.GetLeader()).Stop(), IsNil)
+	c.Assert(cluster.WaitLeader(), Not(Equals), "")
+	testutil.WaitUntil(c, func(c *C) bool {
+		stores, err := cli.GetStores(s.ctx)
+		if err != nil {
+			c.Log(err)
+			return false
+		}
+		return stores.Count == 3
+	})
+}
+
+// mockDiscovery is a ServiceDiscovery with fixed members.
+type mockDiscovery struct {
+	urls []string
+}
+
+func (d *mockDiscovery) GetClusterID(context.Context) uint64 { return 0 }
+func (d *mockDiscovery) GetLeaderAddr() string               { return d.urls[0] }
+func (d *mockDiscovery) GetURLs() []string                   { return d.urls }
+func (d *mockDiscovery) ScheduleCheckLeader()                {}
+func (d *mockDiscovery) Close()                              {}
+
+func (s *httpClientTestSuite) TestRetry(c *C) {
+	var count int32
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&count, 1)
+		w.Header().Set(serverapi.RedirectFailedHeader, "true")
+		http.Error(w, "redirect failed", http.StatusInternalServerError)
+	})
+	srv1, srv2 := httptest.NewServer(handler), httptest.NewServer(handler)
+	defer srv1.Close()
+	defer srv2.Close()
+	sd := &mockDiscovery{urls: []string{srv1.URL, srv2.URL}}
+	cli, err := pdhttp.NewClientWithServiceDiscovery(sd, pd.SecurityOption{}, pdhttp.WithHTTPClient(dialClient), pdhttp.WithMaxRetryTimes(1))
+	c.Assert(err, IsNil)
+	defer cli.Close()
+
+	// The idempotent requests are retried on the other members.
+	_, err = cli.GetStores(s.ctx)
+	c.Assert(err, ErrorMatches, ".*500.*")
+	c.Assert(atomic.LoadInt32(&count), Equals, int32(2))
+	c.Assert(cli.RemoveScheduler(s.ctx, "balance-leader-scheduler"), NotNil)
+	c.Assert(atomic.LoadInt32(&count), Equals, int32(4))
+	// The others are not retried as they may have been handled by the leader.
+	c.Assert(cli.AddScheduler(s.ctx, "balance-leader-scheduler", nil), NotNil)
+	c.Assert(atomic.LoadInt32(&count), Equals, int32(5))
+}
+
+func containsString(ss []string, s string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
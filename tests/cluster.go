@@ -35,6 +35,7 @@ import (
 	"github.com/tikv/pd/server/core"
 	"github.com/tikv/pd/server/id"
 	"github.com/tikv/pd/server/join"
+	"github.com/tikv/pd/server/member"
 	"github.com/tikv/pd/server/tso"
 	"go.etcd.io/etcd/clientv3"
 	"go.uber.org/zap"
@@ -283,7 +284,7 @@ func (s *TestServer) MoveEtcdLeader(old, new uint64) error {
 func (s *TestServer) GetEtcdClient() *clientv3.Client {
 	s.RLock()
 	defer s.RUnlock()
-	return s.server.GetClient()
+	return s.server.GetMember().(*member.EmbeddedEtcdMember).Client()
 }
 
 // GetHTTPClient returns the builtin http client.
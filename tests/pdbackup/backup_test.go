@@ -17,11 +17,16 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"io/ioutil"
+	"os"
 	"strings"
 	"testing"
 	"time"
 
 	. "github.com/pingcap/check"
+	"github.com/pingcap/kvproto/pkg/pdpb"
+	"github.com/tikv/pd/pkg/etcdutil"
+	"github.com/tikv/pd/pkg/typeutil"
 	"github.com/tikv/pd/server"
 	"github.com/tikv/pd/tests"
 	"github.com/tikv/pd/tools/pd-backup/pdbackup"
@@ -71,3 +76,65 @@ func (s *backupTestSuite) TestBackup(c *C) {
 	c.Assert(err, IsNil)
 	c.Assert(backupInfo, DeepEquals, newInfo)
 }
+
+func (s *backupTestSuite) TestRestore(c *C) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cluster, err := tests.NewTestCluster(ctx, 1)
+	c.Assert(err, IsNil)
+	defer cluster.Destroy()
+	c.Assert(cluster.RunInitialServers(), IsNil)
+	leaderServer := cluster.GetServer(cluster.WaitLeader())
+	c.Assert(leaderServer.BootstrapCluster(), IsNil)
+	_, err = leaderServer.GetAllocator().Alloc()
+	c.Assert(err, IsNil)
+	_, err = leaderServer.GetServer().UpdateServiceGCSafePoint(ctx, &pdpb.UpdateServiceGCSafePointRequest{
+		Header:    &pdpb.RequestHeader{ClusterId: leaderServer.GetClusterID()},
+		ServiceId: []byte("br"),
+		TTL:       3600,
+		SafePoint: 1,
+	})
+	c.Assert(err, IsNil)
+
+	pdAddr := cluster.GetConfig().GetClientURL()
+	client, err := clientv3.New(clientv3.Config{Endpoints: strings.Split(pdAddr, ","), DialTimeout: 3 * time.Second})
+	c.Assert(err, IsNil)
+	defer client.Close()
+	backupInfo, err := pdbackup.GetBackupInfo(client, pdAddr, pdbackup.WithRegions())
+	c.Assert(err, IsNil)
+	c.Assert(backupInfo.Stores, HasLen, 1)
+	c.Assert(backupInfo.Regions, HasLen, 1)
+	c.Assert(backupInfo.ServiceGCSafePoints, HasLen, 2)
+
+	// The backup is checked after being loaded from the file.
+	f, err := ioutil.TempFile("", "pd-backup")
+	c.Assert(err, IsNil)
+	defer os.Remove(f.Name())
+	c.Assert(pdbackup.OutputToFile(backupInfo, f), IsNil)
+	_, err = f.Seek(0, 0)
+	c.Assert(err, IsNil)
+	loaded, err := pdbackup.LoadFromFile(f)
+	c.Assert(err, IsNil)
+	c.Assert(loaded.Checksum, Equals, backupInfo.Checksum)
+	loaded.AllocIDMax = 1
+	c.Assert(loaded.Check(), ErrorMatches, "checksum mismatch.*")
+
+	// Restore to a fresh cluster.
+	newCluster, err := tests.NewTestCluster(ctx, 1)
+	c.Assert(err, IsNil)
+	defer newCluster.Destroy()
+	c.Assert(newCluster.RunInitialServers(), IsNil)
+	newCluster.WaitLeader()
+	newPDAddr := newCluster.GetConfig().GetClientURL()
+	newClient, err := clientv3.New(clientv3.Config{Endpoints: strings.Split(newPDAddr, ","), DialTimeout: 3 * time.Second})
+	c.Assert(err, IsNil)
+	defer newClient.Close()
+	c.Assert(pdbackup.Restore(newClient, backupInfo), IsNil)
+	resp, err := etcdutil.EtcdKVGet(newClient, "/pd/cluster_id")
+	c.Assert(err, IsNil)
+	clusterID, err := typeutil.BytesToUint64(resp.Kvs[0].Value)
+	c.Assert(err, IsNil)
+	c.Assert(clusterID, Equals, backupInfo.ClusterID)
+	// Only a fresh cluster can be restored.
+	c.Assert(pdbackup.Restore(newClient, backupInfo), ErrorMatches, ".*already has data.*")
+}
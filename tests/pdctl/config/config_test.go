@@ -29,6 +29,7 @@ import (
 	"github.com/tikv/pd/pkg/typeutil"
 	"github.com/tikv/pd/server"
 	"github.com/tikv/pd/server/config"
+	"github.com/tikv/pd/server/schedule/labeler"
 	"github.com/tikv/pd/server/schedule/placement"
 	"github.com/tikv/pd/tests"
 	"github.com/tikv/pd/tests/pdctl"
@@ -368,6 +369,90 @@ func (s *configTestSuite) TestPlacementRuleGroups(c *C) {
 	c.Assert(strings.Contains(string(output), "404"), IsTrue)
 }
 
+func (s *configTestSuite) TestPlacementRuleTemplates(c *C) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cluster, err := tests.NewTestCluster(ctx, 1)
+	c.Assert(err, IsNil)
+	err = cluster.RunInitialServers()
+	c.Assert(err, IsNil)
+	cluster.WaitLeader()
+	pdAddr := cluster.GetConfig().GetClientURL()
+	cmd := pdctl.InitCommand()
+
+	store := metapb.Store{
+		Id:    1,
+		State: metapb.StoreState_Up,
+	}
+	leaderServer := cluster.GetServer(cluster.GetLeader())
+	c.Assert(leaderServer.BootstrapCluster(), IsNil)
+	svr := leaderServer.GetServer()
+	pdctl.MustPutStore(c, svr, store.Id, store.State, store.Labels)
+	defer cluster.Destroy()
+
+	_, output, err := pdctl.ExecuteCommandC(cmd, "-u", pdAddr, "config", "placement-rules", "enable")
+	c.Assert(err, IsNil)
+	c.Assert(strings.Contains(string(output), "Success!"), IsTrue)
+
+	f, err := ioutil.TempFile("/tmp", "pd_tests")
+	c.Assert(err, IsNil)
+	fname := f.Name()
+	f.Close()
+	defer func() {
+		os.RemoveAll(fname)
+	}()
+
+	// test set
+	template := placement.RuleTemplate{Name: "t1", Role: placement.Voter, Count: 1}
+	b, _ := json.Marshal(template)
+	c.Assert(ioutil.WriteFile(fname, b, 0600), IsNil)
+	_, output, err = pdctl.ExecuteCommandC(cmd, "-u", pdAddr, "config", "placement-rules", "rule-template", "set", "--in="+fname)
+	c.Assert(err, IsNil)
+	c.Assert(strings.Contains(string(output), "successfully"), IsTrue)
+
+	// test show
+	var t1 placement.RuleTemplate
+	_, output, err = pdctl.ExecuteCommandC(cmd, "-u", pdAddr, "config", "placement-rules", "rule-template", "show", "t1")
+	c.Assert(err, IsNil)
+	c.Assert(json.Unmarshal(output, &t1), IsNil)
+	c.Assert(t1, DeepEquals, template)
+	var templates []placement.RuleTemplate
+	_, output, err = pdctl.ExecuteCommandC(cmd, "-u", pdAddr, "config", "placement-rules", "rule-template", "show")
+	c.Assert(err, IsNil)
+	c.Assert(json.Unmarshal(output, &templates), IsNil)
+	c.Assert(templates, DeepEquals, []placement.RuleTemplate{template})
+
+	// The template in use can be neither unfit nor deleted.
+	b, _ = json.Marshal([]*placement.Rule{{GroupID: "pd", ID: "t", Template: "t1"}})
+	c.Assert(ioutil.WriteFile(fname, b, 0600), IsNil)
+	_, output, err = pdctl.ExecuteCommandC(cmd, "-u", pdAddr, "config", "placement-rules", "save", "--in="+fname)
+	c.Assert(err, IsNil)
+	c.Assert(strings.Contains(string(output), "Success!"), IsTrue)
+
+	// There are not enough stores for the rule referencing the template.
+	template.Count = 3
+	b, _ = json.Marshal(template)
+	c.Assert(ioutil.WriteFile(fname, b, 0600), IsNil)
+	_, output, err = pdctl.ExecuteCommandC(cmd, "-u", pdAddr, "config", "placement-rules", "rule-template", "set", "--in="+fname)
+	c.Assert(err, IsNil)
+	c.Assert(strings.Contains(string(output), "failed to save rule template"), IsTrue)
+	_, output, err = pdctl.ExecuteCommandC(cmd, "-u", pdAddr, "config", "placement-rules", "rule-template", "delete", "t1")
+	c.Assert(err, IsNil)
+	c.Assert(strings.Contains(string(output), "409"), IsTrue)
+
+	// The template can be deleted once the rule is deleted.
+	b, _ = json.Marshal([]*placement.Rule{{GroupID: "pd", ID: "t"}})
+	c.Assert(ioutil.WriteFile(fname, b, 0600), IsNil)
+	_, _, err = pdctl.ExecuteCommandC(cmd, "-u", pdAddr, "config", "placement-rules", "save", "--in="+fname)
+	c.Assert(err, IsNil)
+	_, output, err = pdctl.ExecuteCommandC(cmd, "-u", pdAddr, "config", "placement-rules", "rule-template", "delete", "t1")
+	c.Assert(err, IsNil)
+	c.Assert(strings.Contains(string(output), "Success!"), IsTrue)
+	_, output, err = pdctl.ExecuteCommandC(cmd, "-u", pdAddr, "config", "placement-rules", "rule-template", "show", "t1")
+	c.Assert(err, IsNil)
+	c.Assert(strings.Contains(string(output), "404"), IsTrue)
+}
+
 func (s *configTestSuite) TestPlacementRuleBundle(c *C) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -509,6 +594,75 @@ func (s *configTestSuite) TestPlacementRuleBundle(c *C) {
 	})
 }
 
+func (s *configTestSuite) TestRegionLabel(c *C) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cluster, err := tests.NewTestCluster(ctx, 1)
+	c.Assert(err, IsNil)
+	err = cluster.RunInitialServers()
+	c.Assert(err, IsNil)
+	cluster.WaitLeader()
+	pdAddr := cluster.GetConfig().GetClientURL()
+	cmd := pdctl.InitCommand()
+
+	store := metapb.Store{
+		Id:    1,
+		State: metapb.StoreState_Up,
+	}
+	leaderServer := cluster.GetServer(cluster.GetLeader())
+	c.Assert(leaderServer.BootstrapCluster(), IsNil)
+	svr := leaderServer.GetServer()
+	pdctl.MustPutStore(c, svr, store.Id, store.State, store.Labels)
+	defer cluster.Destroy()
+
+	// test set
+	_, output, err := pdctl.ExecuteCommandC(cmd, "-u", pdAddr, "config", "region-label", "set", "rule1", "1234", "5678", "merge_option=deny")
+	c.Assert(err, IsNil)
+	c.Assert(strings.Contains(string(output), "Success!"), IsTrue)
+	_, output, err = pdctl.ExecuteCommandC(cmd, "-u", pdAddr, "config", "region-label", "set", "rule2", "abcd", "", "schedule=deny", "k=v")
+	c.Assert(err, IsNil)
+	c.Assert(strings.Contains(string(output), "Success!"), IsTrue)
+	_, output, err = pdctl.ExecuteCommandC(cmd, "-u", pdAddr, "config", "region-label", "set", "rule3", "abcd", "", "k")
+	c.Assert(err, IsNil)
+	c.Assert(strings.Contains(string(output), "Success!"), IsFalse)
+
+	// test show
+	var rule labeler.LabelRule
+	_, output, err = pdctl.ExecuteCommandC(cmd, "-u", pdAddr, "config", "region-label", "show", "rule2")
+	c.Assert(err, IsNil)
+	c.Assert(json.Unmarshal(output, &rule), IsNil)
+	c.Assert(rule.Labels, DeepEquals, []labeler.RegionLabel{{Key: "schedule", Value: "deny"}, {Key: "k", Value: "v"}})
+	c.Assert(rule.StartKeyHex, Equals, "abcd")
+	c.Assert(rule.EndKeyHex, Equals, "")
+
+	// test load and save
+	fname := "region_labels.json"
+	defer os.Remove(fname)
+	_, _, err = pdctl.ExecuteCommandC(cmd, "-u", pdAddr, "config", "region-label", "load", "--out="+fname)
+	c.Assert(err, IsNil)
+	var rules []*labeler.LabelRule
+	b, _ := ioutil.ReadFile(fname)
+	c.Assert(json.Unmarshal(b, &rules), IsNil)
+	c.Assert(rules, HasLen, 2)
+	rules[0].Labels = []labeler.RegionLabel{{Key: "merge_option", Value: "allow"}}
+	b, _ = json.Marshal(rules)
+	c.Assert(ioutil.WriteFile(fname, b, 0644), IsNil)
+	_, output, err = pdctl.ExecuteCommandC(cmd, "-u", pdAddr, "config", "region-label", "save", "--in="+fname)
+	c.Assert(err, IsNil)
+	c.Assert(strings.Contains(string(output), "Success!"), IsTrue)
+
+	// test delete
+	_, output, err = pdctl.ExecuteCommandC(cmd, "-u", pdAddr, "config", "region-label", "delete", "rule2")
+	c.Assert(err, IsNil)
+	c.Assert(strings.Contains(string(output), "Success!"), IsTrue)
+	_, output, err = pdctl.ExecuteCommandC(cmd, "-u", pdAddr, "config", "region-label", "show")
+	c.Assert(err, IsNil)
+	c.Assert(json.Unmarshal(output, &rules), IsNil)
+	c.Assert(rules, HasLen, 1)
+	c.Assert(rules[0].ID, Equals, "rule1")
+	c.Assert(rules[0].Labels, DeepEquals, []labeler.RegionLabel{{Key: "merge_option", Value: "allow"}})
+}
+
 func (s *configTestSuite) TestReplicationMode(c *C) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
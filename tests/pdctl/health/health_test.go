@@ -52,8 +52,7 @@ func (s *healthTestSuite) TestHealth(c *C) {
 	cmd := pdctl.InitCommand()
 	defer tc.Destroy()
 
-	client := tc.GetEtcdClient()
-	members, err := cluster.GetMembers(client)
+	members, err := leaderServer.GetServer().GetMember().GetMembers()
 	c.Assert(err, IsNil)
 	healthMembers := cluster.CheckHealth(tc.GetHTTPClient(), members)
 	healths := []api.Health{}
@@ -55,6 +55,7 @@ func InitCommand() *cobra.Command {
 		command.NewPingCommand(),
 		command.NewOperatorCommand(),
 		command.NewSchedulerCommand(),
+		command.NewCheckerCommand(),
 		command.NewTSOCommand(),
 		command.NewHotSpotCommand(),
 		command.NewClusterCommand(),
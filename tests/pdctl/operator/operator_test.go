@@ -15,12 +15,14 @@ package operator_test
 
 import (
 	"context"
+	"fmt"
 	"strings"
 	"testing"
 	"time"
 
 	. "github.com/pingcap/check"
 	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/tikv/pd/pkg/testutil"
 	"github.com/tikv/pd/server"
 	"github.com/tikv/pd/server/config"
 	"github.com/tikv/pd/server/core"
@@ -227,4 +229,14 @@ func (s *operatorTestSuite) TestOperator(c *C) {
 	_, output, err = pdctl.ExecuteCommandC(cmd, "operator", "add", "transfer-region", "1", "2", "leader", "3", "follower")
 	c.Assert(err, IsNil)
 	c.Assert(strings.Contains(string(output), "Success!"), IsTrue)
+
+	// operator history [--region=<region_id>] [--store=<store_id>] [--since=<unix_timestamp>]
+	testutil.WaitUntil(c, func(c *C) bool {
+		_, output, err = pdctl.ExecuteCommandC(cmd, "operator", "history", "--region=3")
+		c.Assert(err, IsNil)
+		return strings.Contains(string(output), "admin-merge-region") && strings.Contains(string(output), "scatter-region")
+	})
+	_, output, err = pdctl.ExecuteCommandC(cmd, "operator", "history", "--region=3", fmt.Sprintf("--since=%d", time.Now().Add(time.Hour).Unix()))
+	c.Assert(err, IsNil)
+	c.Assert(strings.TrimSpace(string(output)), Equals, "[]")
 }
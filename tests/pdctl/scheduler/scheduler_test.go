@@ -291,6 +291,23 @@ func (s *schedulerTestSuite) TestScheduler(c *C) {
 	mustExec([]string{"-u", pdAddr, "scheduler", "resume", "balance-leader-scheduler"}, nil)
 	checkSchedulerWithStatusCommand(nil, "paused", nil)
 
+	// test diagnose scheduler.
+	var diagnosis map[string]interface{}
+	mustExec([]string{"-u", pdAddr, "scheduler", "diagnose", "balance-leader-scheduler", "--dry-run"}, &diagnosis)
+	c.Assert(diagnosis["name"], Equals, "balance-leader-scheduler")
+	c.Assert(diagnosis["paused"], Equals, false)
+	mustExec([]string{"-u", pdAddr, "scheduler", "diagnose", "balance-leader-scheduler", "--dry-run", "--set", "leader-schedule-limit=0"}, &diagnosis)
+	c.Assert(diagnosis["name"], Equals, "balance-leader-scheduler")
+	var checkerDiagnosis map[string]interface{}
+	mustExec([]string{"-u", pdAddr, "checker", "diagnose", "--limit", "10", "--set", "replica-schedule-limit=0"}, &checkerDiagnosis)
+	c.Assert(checkerDiagnosis["operators"], IsNil)
+
+	// test scheduler diagnostic.
+	var rounds []map[string]interface{}
+	mustExec([]string{"-u", pdAddr, "scheduler", "diagnostic", "balance-leader-scheduler"}, &rounds)
+	c.Assert(len(rounds), Greater, 0)
+	c.Assert(rounds[len(rounds)-1], HasKey, "time")
+
 	// set label scheduler to disabled manually.
 	cfg := leaderServer.GetServer().GetScheduleConfig()
 	origin := cfg.Schedulers
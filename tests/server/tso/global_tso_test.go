@@ -15,6 +15,8 @@ package tso_test
 
 import (
 	"context"
+	"path"
+	"strconv"
 	"strings"
 	"sync"
 	"testing"
@@ -23,10 +25,13 @@ import (
 	. "github.com/pingcap/check"
 	"github.com/pingcap/failpoint"
 	"github.com/pingcap/kvproto/pkg/pdpb"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/tikv/pd/pkg/testutil"
 	"github.com/tikv/pd/pkg/tsoutil"
+	"github.com/tikv/pd/pkg/typeutil"
 	"github.com/tikv/pd/server"
 	"github.com/tikv/pd/server/config"
+	"github.com/tikv/pd/server/tso"
 	"github.com/tikv/pd/tests"
 	"go.uber.org/goleak"
 )
@@ -109,6 +114,34 @@ func (s *testNormalGlobalTSOSuite) TestNormalGlobalTSO(c *C) {
 	wg.Wait()
 }
 
+// TestStandaloneGlobalTSO is used to test the global TSO of a standalone server,
+// which saves the time window in the local kv.
+func (s *testNormalGlobalTSOSuite) TestStandaloneGlobalTSO(c *C) {
+	cluster, err := tests.NewTestCluster(s.ctx, 1, func(conf *config.Config, serverName string) {
+		conf.Standalone = true
+	})
+	defer cluster.Destroy()
+	c.Assert(err, IsNil)
+
+	err = cluster.RunInitialServers()
+	c.Assert(err, IsNil)
+	leaderServer := cluster.GetServer(cluster.WaitLeader())
+	req := &pdpb.TsoRequest{
+		Header:     testutil.NewRequestHeader(leaderServer.GetClusterID()),
+		Count:      uint32(tsoCount),
+		DcLocation: config.GlobalDCLocation,
+	}
+	last := s.testGetNormalGlobalTimestamp(c, testutil.MustNewGrpcClient(c, leaderServer.GetAddr()), req)
+
+	// The TSO doesn't fall back after restarting.
+	c.Assert(leaderServer.Stop(), IsNil)
+	c.Assert(leaderServer.Run(), IsNil)
+	c.Assert(cluster.WaitLeader(), Equals, leaderServer.GetConfig().Name)
+	c.Assert(leaderServer.GetClusterID(), Equals, req.Header.ClusterId)
+	ts := s.testGetNormalGlobalTimestamp(c, testutil.MustNewGrpcClient(c, leaderServer.GetAddr()), req)
+	c.Assert(tsoutil.CompareTimestamp(ts, last), Equals, 1)
+}
+
 func (s *testNormalGlobalTSOSuite) testGetNormalGlobalTimestamp(c *C, pdCli pdpb.PDClient, req *pdpb.TsoRequest) *pdpb.Timestamp {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -270,6 +303,49 @@ func (s *testNormalGlobalTSOSuite) TestDelaySyncTimestamp(c *C) {
 	failpoint.Disable("github.com/tikv/pd/server/tso/delaySyncTimestamp")
 }
 
+// TestSkewIncident makes the persisted high-water mark greater than the time
+// window to check the new leader reports the skew and doesn't fall back.
+func (s *testNormalGlobalTSOSuite) TestSkewIncident(c *C) {
+	// Keep the leader from saving the time window during the test.
+	cluster, err := tests.NewTestCluster(s.ctx, 2, func(conf *config.Config, serverName string) {
+		conf.TSOSaveInterval = typeutil.NewDuration(time.Hour)
+	})
+	c.Assert(err, IsNil)
+	defer cluster.Destroy()
+
+	err = cluster.RunInitialServers()
+	c.Assert(err, IsNil)
+	leaderServer := cluster.GetServer(cluster.WaitLeader())
+	c.Assert(leaderServer, NotNil)
+	c.Assert(leaderServer.GetTSOAllocatorManager().GetSkewIncidents(), HasLen, 0)
+
+	// The time window is about one hour later, so the high-water mark is
+	// greater than it.
+	highWaterMark := tsoutil.GenerateTS(tsoutil.GenerateTimestamp(time.Now().Add(2*time.Hour), 0))
+	clusterID := leaderServer.GetClusterID()
+	key := path.Join("/pd", strconv.FormatUint(clusterID, 10), "last-issued-ts")
+	_, err = cluster.GetEtcdClient().Put(s.ctx, key, string(typeutil.Uint64ToBytes(highWaterMark)))
+	c.Assert(err, IsNil)
+
+	c.Assert(cluster.ResignLeader(), IsNil)
+	leaderServer = cluster.GetServer(cluster.WaitLeader())
+	c.Assert(leaderServer, NotNil)
+	incidents := leaderServer.GetTSOAllocatorManager().GetSkewIncidents()
+	c.Assert(incidents, HasLen, 1)
+	c.Assert(incidents[0].DCLocation, Equals, config.GlobalDCLocation)
+	c.Assert(incidents[0].Phase, Equals, tso.SkewPhaseSync)
+	c.Assert(incidents[0].HighWaterMark, Equals, highWaterMark)
+
+	grpcPDClient := testutil.MustNewGrpcClient(c, leaderServer.GetAddr())
+	req := &pdpb.TsoRequest{
+		Header:     testutil.NewRequestHeader(clusterID),
+		Count:      1,
+		DcLocation: config.GlobalDCLocation,
+	}
+	ts := s.testGetNormalGlobalTimestamp(c, grpcPDClient, req)
+	c.Assert(tsoutil.GenerateTS(ts), Greater, highWaterMark)
+}
+
 var _ = Suite(&testTimeFallBackSuite{})
 
 type testTimeFallBackSuite struct {
@@ -481,6 +557,137 @@ func (s *testSynchronizedGlobalTSO) TestSynchronizedGlobalTSO(c *C) {
 	}
 }
 
+// TestGlobalTSOWithEstimation makes the clocks of the Local TSO Allocators
+// ahead of the Global TSO Allocator's, to check the Global TSO is still
+// synchronized correctly, and the estimation works after a full synchronization.
+func (s *testSynchronizedGlobalTSO) TestGlobalTSOWithEstimation(c *C) {
+	c.Assert(failpoint.Enable("github.com/tikv/pd/server/tso/localClockOffset", `return(1000)`), IsNil)
+	defer failpoint.Disable("github.com/tikv/pd/server/tso/localClockOffset")
+	dcLocationConfig := map[string]string{
+		"pd1": "dc-1",
+		"pd2": "dc-2",
+		"pd3": "dc-3",
+	}
+	cluster, err := tests.NewTestCluster(s.ctx, len(dcLocationConfig), func(conf *config.Config, serverName string) {
+		conf.LocalTSO.EnableLocalTSO = true
+		conf.LocalTSO.DCLocation = dcLocationConfig[serverName]
+	})
+	defer cluster.Destroy()
+	c.Assert(err, IsNil)
+
+	err = cluster.RunInitialServers()
+	c.Assert(err, IsNil)
+
+	waitAllLeaders(s.ctx, c, cluster, dcLocationConfig)
+
+	for _, dcLocation := range dcLocationConfig {
+		pdName := cluster.WaitAllocatorLeader(dcLocation)
+		s.dcClientMap[dcLocation] = testutil.MustNewGrpcClient(c, cluster.GetServer(pdName).GetAddr())
+	}
+	s.leaderServer = cluster.GetServer(cluster.GetLeader())
+	c.Assert(s.leaderServer, NotNil)
+	s.dcClientMap[config.GlobalDCLocation] = testutil.MustNewGrpcClient(c, s.leaderServer.GetAddr())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	estimateOK, estimateFail := getTSOEventCount(c, "global_estimate_ok"), getTSOEventCount(c, "global_estimate_fail")
+	const rounds = 20
+	var lastGlobalTSO *pdpb.Timestamp
+	for i := 0; i < rounds; i++ {
+		oldLocalTSOs := make([]*pdpb.Timestamp, 0, len(dcLocationConfig))
+		for _, dcLocation := range dcLocationConfig {
+			oldLocalTSOs = append(oldLocalTSOs, s.testGetTimestamp(ctx, c, tsoCount, dcLocation))
+		}
+		globalTSO := s.testGetTimestamp(ctx, c, tsoCount, config.GlobalDCLocation)
+		for _, oldLocalTSO := range oldLocalTSOs {
+			c.Assert(tsoutil.CompareTimestamp(globalTSO, oldLocalTSO), Equals, 1)
+		}
+		if lastGlobalTSO != nil {
+			c.Assert(tsoutil.CompareTimestamp(globalTSO, lastGlobalTSO), Equals, 1)
+		}
+		lastGlobalTSO = globalTSO
+		for _, dcLocation := range dcLocationConfig {
+			newLocalTSO := s.testGetTimestamp(ctx, c, tsoCount, dcLocation)
+			c.Assert(tsoutil.CompareTimestamp(globalTSO, newLocalTSO), Equals, -1)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	// The Local TSOs go ahead of the estimation once their physical time is
+	// updated, but the estimation still works after a full synchronization.
+	estimateOK = getTSOEventCount(c, "global_estimate_ok") - estimateOK
+	estimateFail = getTSOEventCount(c, "global_estimate_fail") - estimateFail
+	c.Assert(estimateOK+estimateFail, Equals, float64(rounds))
+	c.Assert(estimateOK, Greater, float64(0))
+}
+
+// TestGlobalTSONotAheadOfClock issues many Global TSOs within a short time to
+// check the synchronizations don't make the Global TSO run ahead of the clock.
+func (s *testSynchronizedGlobalTSO) TestGlobalTSONotAheadOfClock(c *C) {
+	dcLocationConfig := map[string]string{
+		"pd1": "dc-1",
+		"pd2": "dc-2",
+		"pd3": "dc-3",
+	}
+	cluster, err := tests.NewTestCluster(s.ctx, len(dcLocationConfig), func(conf *config.Config, serverName string) {
+		conf.LocalTSO.EnableLocalTSO = true
+		conf.LocalTSO.DCLocation = dcLocationConfig[serverName]
+	})
+	defer cluster.Destroy()
+	c.Assert(err, IsNil)
+
+	err = cluster.RunInitialServers()
+	c.Assert(err, IsNil)
+
+	waitAllLeaders(s.ctx, c, cluster, dcLocationConfig)
+
+	for _, dcLocation := range dcLocationConfig {
+		pdName := cluster.WaitAllocatorLeader(dcLocation)
+		s.dcClientMap[dcLocation] = testutil.MustNewGrpcClient(c, cluster.GetServer(pdName).GetAddr())
+	}
+	s.leaderServer = cluster.GetServer(cluster.GetLeader())
+	c.Assert(s.leaderServer, NotNil)
+	s.dcClientMap[config.GlobalDCLocation] = testutil.MustNewGrpcClient(c, s.leaderServer.GetAddr())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	estimateOK, estimateFail := getTSOEventCount(c, "global_estimate_ok"), getTSOEventCount(c, "global_estimate_fail")
+	const count = 1000
+	var lastGlobalTSO *pdpb.Timestamp
+	for i := 0; i < count; i++ {
+		globalTSO := s.testGetTimestamp(ctx, c, tsoCount, config.GlobalDCLocation)
+		if lastGlobalTSO != nil {
+			c.Assert(tsoutil.CompareTimestamp(globalTSO, lastGlobalTSO), Equals, 1)
+		}
+		lastGlobalTSO = globalTSO
+		physical, _ := tsoutil.ParseTimestamp(*globalTSO)
+		c.Assert(time.Until(physical), LessEqual, 10*time.Millisecond)
+	}
+	// Most Global TSOs are allocated with the estimation, without
+	// collecting the Local TSOs first.
+	estimateOK = getTSOEventCount(c, "global_estimate_ok") - estimateOK
+	estimateFail = getTSOEventCount(c, "global_estimate_fail") - estimateFail
+	c.Assert(estimateOK+estimateFail, Equals, float64(count))
+	c.Assert(estimateOK, Greater, estimateFail)
+}
+
+func getTSOEventCount(c *C, eventType string) float64 {
+	families, err := prometheus.DefaultGatherer.Gather()
+	c.Assert(err, IsNil)
+	for _, family := range families {
+		if family.GetName() != "pd_tso_events" {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			for _, label := range m.GetLabel() {
+				if label.GetName() == "type" && label.GetValue() == eventType {
+					return m.GetCounter().GetValue()
+				}
+			}
+		}
+	}
+	return 0
+}
+
 func (s *testSynchronizedGlobalTSO) testGetTimestamp(ctx context.Context, c *C, n int, dcLocation string) *pdpb.Timestamp {
 	req := &pdpb.TsoRequest{
 		Header:     testutil.NewRequestHeader(s.leaderServer.GetClusterID()),
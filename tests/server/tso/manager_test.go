@@ -19,8 +19,10 @@ import (
 	"time"
 
 	. "github.com/pingcap/check"
+	"github.com/tikv/pd/pkg/errs"
 	"github.com/tikv/pd/pkg/etcdutil"
 	"github.com/tikv/pd/pkg/testutil"
+	"github.com/tikv/pd/pkg/tsoutil"
 	"github.com/tikv/pd/server"
 	"github.com/tikv/pd/server/config"
 	"github.com/tikv/pd/tests"
@@ -165,6 +167,68 @@ func (s *testManagerSuite) TestLocalTSOSuffix(c *C) {
 	}
 }
 
+// TestRemoveDCLocation moves the member of a dc-location to another one, then
+// removes the dc-location and checks whether its suffix is reclaimed.
+func (s *testManagerSuite) TestRemoveDCLocation(c *C) {
+	dcLocationConfig := map[string]string{
+		"pd1": "dc-1",
+		"pd2": "dc-2",
+		"pd3": "dc-3",
+	}
+	cluster, err := tests.NewTestCluster(s.ctx, len(dcLocationConfig), func(conf *config.Config, serverName string) {
+		conf.LocalTSO.EnableLocalTSO = true
+		conf.LocalTSO.DCLocation = dcLocationConfig[serverName]
+	})
+	defer cluster.Destroy()
+	c.Assert(err, IsNil)
+
+	err = cluster.RunInitialServers()
+	c.Assert(err, IsNil)
+
+	waitAllLeaders(s.ctx, c, cluster, dcLocationConfig)
+	am := cluster.GetServer(cluster.GetLeader()).GetTSOAllocatorManager()
+	suffix := am.GetSuffixDCLocations()["dc-3"]
+	c.Assert(suffix, Greater, int32(0))
+	localAllocatorLeader := cluster.GetServer(cluster.WaitAllocatorLeader("dc-3"))
+	localTS, err := localAllocatorLeader.GetTSOAllocatorManager().HandleTSORequest("dc-3", 1)
+	c.Assert(err, IsNil)
+
+	// The dc-location still having members can't be removed.
+	c.Assert(errs.ErrDCLocationInUse.Equal(am.RemoveDCLocation("dc-3")), IsTrue)
+	pd3ID := cluster.GetServer("pd3").GetServerID()
+	c.Assert(am.SetMemberDCLocation(pd3ID, "dc-1"), IsNil)
+	// To speed up the test, we force to do the check
+	for _, server := range cluster.GetServers() {
+		server.GetTSOAllocatorManager().ClusterDCLocationChecker()
+	}
+	// Wait for the Local TSO Allocator of dc-3 to stop serving.
+	testutil.WaitUntil(c, func(c *C) bool {
+		return am.RemoveDCLocation("dc-3") == nil
+	})
+	suffixResp, err := etcdutil.EtcdKVGet(cluster.GetEtcdClient(), am.GetLocalTSOSuffixPath("dc-3"))
+	c.Assert(err, IsNil)
+	c.Assert(suffixResp.Kvs, HasLen, 0)
+	c.Assert(errs.ErrDCLocationNotFound.Equal(am.RemoveDCLocation("dc-3")), IsTrue)
+	// The Global TSO is fenced above the timestamps of the removed dc-location.
+	globalTS, err := am.HandleTSORequest(config.GlobalDCLocation, 1)
+	c.Assert(err, IsNil)
+	c.Assert(tsoutil.CompareTimestamp(&globalTS, &localTS), Greater, 0)
+
+	// The new dc-location reuses the reclaimed suffix.
+	c.Assert(am.SetMemberDCLocation(pd3ID, "dc-4"), IsNil)
+	c.Assert(am.GetSuffixDCLocations()["dc-4"], Equals, suffix)
+	dcLocationConfig["pd3"] = "dc-4"
+	waitAllLeaders(s.ctx, c, cluster, dcLocationConfig)
+
+	// The member stays in the dc-location it's moved to when it writes its config
+	// again after restarting.
+	pd3 := cluster.GetServer("pd3")
+	c.Assert(pd3.GetTSOAllocatorManager().SetLocalTSOConfig(pd3.GetConfig().LocalTSO), IsNil)
+	dcLocation, err := etcdutil.GetValue(cluster.GetEtcdClient(), pd3.GetServer().GetMember().GetDCLocationPath(pd3ID))
+	c.Assert(err, IsNil)
+	c.Assert(string(dcLocation), Equals, "dc-4")
+}
+
 const waitAllocatorPriorityCheckInterval = 2 * time.Minute
 
 var _ = Suite(&testPrioritySuite{})
@@ -26,11 +26,14 @@ import (
 )
 
 var (
-	pdAddr   = flag.String("pd", "http://127.0.0.1:2379", "pd address")
-	filePath = flag.String("file", "backup.json", "backup file path and name")
-	caPath   = flag.String("cacert", "", "path of file that contains list of trusted SSL CAs")
-	certPath = flag.String("cert", "", "path of file that contains X509 certificate in PEM format")
-	keyPath  = flag.String("key", "", "path of file that contains X509 key in PEM format")
+	pdAddr            = flag.String("pd", "http://127.0.0.1:2379", "pd address")
+	filePath          = flag.String("file", "backup.json", "backup file path and name")
+	caPath            = flag.String("cacert", "", "path of file that contains list of trusted SSL CAs")
+	certPath          = flag.String("cert", "", "path of file that contains X509 certificate in PEM format")
+	keyPath           = flag.String("key", "", "path of file that contains X509 key in PEM format")
+	mode              = flag.String("mode", "backup", "backup or restore, restore rebuilds a fresh pd cluster from the backup file")
+	withRegions       = flag.Bool("regions", false, "whether to back up the region meta")
+	regionStoragePath = flag.String("region-storage", "", "back up the region meta from the region storage at the path instead of etcd, the pd using it must be stopped")
 )
 
 const (
@@ -39,9 +42,6 @@ const (
 
 func main() {
 	flag.Parse()
-	f, err := os.Create(*filePath)
-	checkErr(err)
-	defer f.Close()
 	urls := strings.Split(*pdAddr, ",")
 
 	tlsInfo := transport.TLSInfo{
@@ -59,12 +59,42 @@ func main() {
 	})
 	checkErr(err)
 
-	backInfo, err := pdbackup.GetBackupInfo(client, *pdAddr)
+	switch *mode {
+	case "backup":
+		backup(client)
+	case "restore":
+		restore(client)
+	default:
+		checkErr(fmt.Errorf("unknown mode %s", *mode))
+	}
+}
+
+func backup(client *clientv3.Client) {
+	var opts []pdbackup.BackupOption
+	if len(*regionStoragePath) > 0 {
+		opts = append(opts, pdbackup.WithRegionStorage(*regionStoragePath))
+	} else if *withRegions {
+		opts = append(opts, pdbackup.WithRegions())
+	}
+	backInfo, err := pdbackup.GetBackupInfo(client, *pdAddr, opts...)
 	checkErr(err)
-	pdbackup.OutputToFile(backInfo, f)
+	f, err := os.Create(*filePath)
+	checkErr(err)
+	defer f.Close()
+	checkErr(pdbackup.OutputToFile(backInfo, f))
 	fmt.Println("pd backup successful! dump file is:", *filePath)
 }
 
+func restore(client *clientv3.Client) {
+	f, err := os.Open(*filePath)
+	checkErr(err)
+	defer f.Close()
+	backInfo, err := pdbackup.LoadFromFile(f)
+	checkErr(err)
+	checkErr(pdbackup.Restore(client, backInfo))
+	fmt.Println("pd restore successful! please restart the PD cluster")
+}
+
 func checkErr(err error) {
 	if err != nil {
 		fmt.Println(err.Error())
@@ -16,6 +16,8 @@ package pdbackup
 import (
 	"bufio"
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -24,9 +26,14 @@ import (
 	"path"
 	"strconv"
 
+	"github.com/pingcap/errors"
+	"github.com/pingcap/kvproto/pkg/metapb"
 	"github.com/tikv/pd/pkg/etcdutil"
 	"github.com/tikv/pd/pkg/typeutil"
 	"github.com/tikv/pd/server/config"
+	"github.com/tikv/pd/server/core"
+	"github.com/tikv/pd/server/encryptionkm"
+	"github.com/tikv/pd/server/kv"
 	"go.etcd.io/etcd/clientv3"
 )
 
@@ -34,6 +41,9 @@ const (
 	pdRootPath      = "/pd"
 	pdClusterIDPath = "/pd/cluster_id"
 	pdConfigAPIPath = "/pd/api/v1/config"
+
+	// BackupVersion is the version of the backup format.
+	BackupVersion = 1
 )
 
 // BackupInfo is the backup infos.
@@ -42,53 +52,209 @@ type BackupInfo struct {
 	AllocIDMax        uint64         `json:"allocIDMax"`
 	AllocTimestampMax uint64         `json:"allocTimestampMax"`
 	Config            *config.Config `json:"config"`
+
+	Version int `json:"version"`
+	// Revision is the etcd revision the metadata is read at.
+	Revision    int64           `json:"revision"`
+	ClusterMeta *metapb.Cluster `json:"clusterMeta"`
+	Stores      []*StoreInfo    `json:"stores"`
+	// The placement rules, rule groups, scheduler configs and replication
+	// status are kept as they are saved by PD.
+	Rules               map[string]string        `json:"rules"`
+	RuleGroups          map[string]string        `json:"ruleGroups"`
+	ScheduleConfigs     map[string]string        `json:"scheduleConfigs"`
+	GCSafePoint         uint64                   `json:"gcSafePoint"`
+	ServiceGCSafePoints []*core.ServiceSafePoint `json:"serviceGCSafePoints"`
+	ReplicationStatus   map[string]string        `json:"replicationStatus"`
+	// EncryptionKeys is the data keys encrypted by the master key, which can
+	// only be used with the same master key.
+	EncryptionKeys []byte           `json:"encryptionKeys,omitempty"`
+	Regions        []*metapb.Region `json:"regions,omitempty"`
+	// Checksum is the SHA-256 of the backup with the empty checksum.
+	Checksum string `json:"checksum"`
+}
+
+// StoreInfo is the backup of a store.
+type StoreInfo struct {
+	Meta         *metapb.Store `json:"meta"`
+	LeaderWeight float64       `json:"leaderWeight"`
+	RegionWeight float64       `json:"regionWeight"`
+}
+
+type backupOptions struct {
+	withRegions       bool
+	regionStoragePath string
+}
+
+// BackupOption configures the backup.
+type BackupOption func(*backupOptions)
+
+// WithRegions exports the region meta saved in etcd.
+func WithRegions() BackupOption {
+	return func(opt *backupOptions) {
+		opt.withRegions = true
+	}
+}
+
+// WithRegionStorage exports the region meta from the `RegionStorage` at the
+// path instead of etcd. The PD using it must be stopped, since the storage
+// can only be opened by one process.
+func WithRegionStorage(path string) BackupOption {
+	return func(opt *backupOptions) {
+		opt.withRegions = true
+		opt.regionStoragePath = path
+	}
 }
 
 //GetBackupInfo return the BackupInfo
-func GetBackupInfo(client *clientv3.Client, pdAddr string) (*BackupInfo, error) {
-	backInfo := &BackupInfo{}
+func GetBackupInfo(client *clientv3.Client, pdAddr string, opts ...BackupOption) (*BackupInfo, error) {
+	options := &backupOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+	backInfo := &BackupInfo{Version: BackupVersion}
 	resp, err := etcdutil.EtcdKVGet(client, pdClusterIDPath)
 	if err != nil {
 		return nil, err
 	}
+	if len(resp.Kvs) == 0 {
+		return nil, errors.New("cluster id not found")
+	}
 	clusterID, err := typeutil.BytesToUint64(resp.Kvs[0].Value)
 	if err != nil {
 		return nil, err
 	}
 	backInfo.ClusterID = clusterID
+	// All metadata is read at the same revision to be a point-in-time snapshot.
+	backInfo.Revision = resp.Header.GetRevision()
 
 	rootPath := path.Join(pdRootPath, strconv.FormatUint(clusterID, 10))
-	allocIDPath := path.Join(rootPath, "alloc_id")
-	resp, err = etcdutil.EtcdKVGet(client, allocIDPath)
-	if err != nil {
+	snapshot := newSnapshotKV(client, rootPath, backInfo.Revision)
+	if err := backInfo.load(snapshot); err != nil {
 		return nil, err
 	}
-	var allocIDMax uint64 = 0
-	if resp.Count > 0 {
-		allocIDMax, err = typeutil.BytesToUint64(resp.Kvs[0].Value)
-		if err != nil {
+	if backInfo.EncryptionKeys, err = loadEncryptionKeys(client, backInfo.Revision); err != nil {
+		return nil, err
+	}
+	if options.withRegions {
+		var regionKV kv.Base = snapshot
+		if len(options.regionStoragePath) > 0 {
+			levelDB, err := kv.NewLeveldbKV(options.regionStoragePath)
+			if err != nil {
+				return nil, err
+			}
+			defer levelDB.Close()
+			regionKV = levelDB
+		}
+		if backInfo.Regions, err = loadRegions(regionKV); err != nil {
 			return nil, err
 		}
 	}
 
-	backInfo.AllocIDMax = allocIDMax
-
-	timestampPath := path.Join(rootPath, "timestamp")
-	resp, err = etcdutil.EtcdKVGet(client, timestampPath)
+	backInfo.Config, err = getConfig(pdAddr)
 	if err != nil {
 		return nil, err
 	}
-	allocTimestampMax, err := typeutil.BytesToUint64(resp.Kvs[0].Value)
+	backInfo.Checksum, err = backInfo.checksum()
 	if err != nil {
 		return nil, err
 	}
-	backInfo.AllocTimestampMax = allocTimestampMax
+	return backInfo, nil
+}
 
-	backInfo.Config, err = getConfig(pdAddr)
+// load loads the metadata except the regions and the config.
+func (b *BackupInfo) load(base kv.Base) error {
+	value, err := base.Load(allocIDPath)
+	if err != nil {
+		return err
+	}
+	if len(value) > 0 {
+		if b.AllocIDMax, err = typeutil.BytesToUint64([]byte(value)); err != nil {
+			return err
+		}
+	}
+	value, err = base.Load(timestampPath)
+	if err != nil {
+		return err
+	}
+	if len(value) == 0 {
+		return errors.New("timestamp not found")
+	}
+	if b.AllocTimestampMax, err = typeutil.BytesToUint64([]byte(value)); err != nil {
+		return err
+	}
+
+	storage := core.NewStorage(base)
+	meta := &metapb.Cluster{}
+	ok, err := storage.LoadMeta(meta)
+	if err != nil {
+		return err
+	}
+	if ok {
+		b.ClusterMeta = meta
+	}
+	b.Stores = b.Stores[:0]
+	if err := storage.LoadStores(func(store *core.StoreInfo) {
+		b.Stores = append(b.Stores, &StoreInfo{
+			Meta:         store.GetMeta(),
+			LeaderWeight: store.GetLeaderWeight(),
+			RegionWeight: store.GetRegionWeight(),
+		})
+	}); err != nil {
+		return err
+	}
+	b.Rules = make(map[string]string)
+	if err := storage.LoadRules(func(k, v string) { b.Rules[k] = v }); err != nil {
+		return err
+	}
+	b.RuleGroups = make(map[string]string)
+	if err := storage.LoadRuleGroups(func(k, v string) { b.RuleGroups[k] = v }); err != nil {
+		return err
+	}
+	names, configs, err := storage.LoadAllScheduleConfig()
+	if err != nil {
+		return err
+	}
+	b.ScheduleConfigs = make(map[string]string, len(names))
+	for i, name := range names {
+		b.ScheduleConfigs[name] = configs[i]
+	}
+	if b.GCSafePoint, err = storage.LoadGCSafePoint(); err != nil {
+		return err
+	}
+	if b.ServiceGCSafePoints, err = storage.GetAllServiceGCSafePoints(); err != nil {
+		return err
+	}
+	b.ReplicationStatus = make(map[string]string)
+	if err := storage.LoadRangeByPrefix(replicationPath+"/", func(k, v string) { b.ReplicationStatus[k] = v }); err != nil {
+		return err
+	}
+	return nil
+}
+
+// loadEncryptionKeys loads the encrypted data keys, which are shared by all
+// clusters in the etcd.
+func loadEncryptionKeys(client *clientv3.Client, revision int64) ([]byte, error) {
+	resp, err := etcdutil.EtcdKVGet(client, encryptionkm.EncryptionKeysPath, clientv3.WithRev(revision))
 	if err != nil {
 		return nil, err
 	}
-	return backInfo, nil
+	if len(resp.Kvs) == 0 {
+		return nil, nil
+	}
+	return resp.Kvs[0].Value, nil
+}
+
+// checksum returns the SHA-256 of the backup with the empty checksum.
+func (b *BackupInfo) checksum() (string, error) {
+	backup := *b
+	backup.Checksum = ""
+	data, err := json.Marshal(&backup)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
 }
 
 //OutputToFile output the backupInfo to the file.
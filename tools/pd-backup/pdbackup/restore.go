@@ -0,0 +1,277 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pdbackup
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/kvproto/pkg/encryptionpb"
+	"github.com/tikv/pd/pkg/etcdutil"
+	"github.com/tikv/pd/pkg/typeutil"
+	"github.com/tikv/pd/server/config"
+	"github.com/tikv/pd/server/core"
+	"github.com/tikv/pd/server/encryptionkm"
+	"github.com/tikv/pd/server/kv"
+	"go.etcd.io/etcd/clientv3"
+)
+
+const restoreTimeout = 10 * time.Second
+
+// LoadFromFile loads the backup from the file and checks its consistency.
+func LoadFromFile(r io.Reader) (*BackupInfo, error) {
+	backInfo := &BackupInfo{}
+	if err := json.NewDecoder(r).Decode(backInfo); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if err := backInfo.Check(); err != nil {
+		return nil, err
+	}
+	return backInfo, nil
+}
+
+// Check checks whether the backup is complete and consistent.
+func (b *BackupInfo) Check() error {
+	if b.Version != BackupVersion {
+		return errors.Errorf("unsupported backup version %d, expected %d", b.Version, BackupVersion)
+	}
+	checksum, err := b.checksum()
+	if err != nil {
+		return err
+	}
+	if checksum != b.Checksum {
+		return errors.Errorf("checksum mismatch, expected %s but got %s", b.Checksum, checksum)
+	}
+	if b.ClusterID == 0 {
+		return errors.New("invalid cluster id 0")
+	}
+	if b.ClusterMeta == nil {
+		return errors.New("the cluster is not bootstrapped")
+	}
+	if b.ClusterMeta.GetId() != b.ClusterID {
+		return errors.Errorf("cluster id %d mismatches the cluster meta %d", b.ClusterID, b.ClusterMeta.GetId())
+	}
+	if b.AllocTimestampMax == 0 {
+		return errors.New("invalid max timestamp 0")
+	}
+	// All allocated IDs must be within the max allocated ID, otherwise the
+	// restored cluster may allocate them again.
+	checkID := func(kind string, id uint64) error {
+		if id > b.AllocIDMax {
+			return errors.Errorf("%s id %d exceeds the max allocated id %d", kind, id, b.AllocIDMax)
+		}
+		return nil
+	}
+	stores := make(map[uint64]struct{}, len(b.Stores))
+	for _, store := range b.Stores {
+		if store.Meta == nil {
+			return errors.New("store meta not found")
+		}
+		if _, ok := stores[store.Meta.GetId()]; ok {
+			return errors.Errorf("duplicated store %d", store.Meta.GetId())
+		}
+		stores[store.Meta.GetId()] = struct{}{}
+		if err := checkID("store", store.Meta.GetId()); err != nil {
+			return err
+		}
+	}
+	for _, region := range b.Regions {
+		if err := checkID("region", region.GetId()); err != nil {
+			return err
+		}
+		for _, peer := range region.GetPeers() {
+			if err := checkID("peer", peer.GetId()); err != nil {
+				return err
+			}
+			if _, ok := stores[peer.GetStoreId()]; !ok {
+				return errors.Errorf("store %d of peer %d in region %d not found", peer.GetStoreId(), peer.GetId(), region.GetId())
+			}
+		}
+	}
+	for _, values := range []map[string]string{b.Rules, b.RuleGroups, b.ScheduleConfigs, b.ReplicationStatus} {
+		for key, value := range values {
+			if !json.Valid([]byte(value)) {
+				return errors.Errorf("invalid json value of %s", key)
+			}
+		}
+	}
+	if len(b.EncryptionKeys) > 0 {
+		content := &encryptionpb.EncryptedContent{}
+		if err := content.Unmarshal(b.EncryptionKeys); err != nil {
+			return errors.Annotate(err, "invalid encryption keys")
+		}
+		if content.GetMasterKey() == nil {
+			return errors.New("no master key config found with encryption keys")
+		}
+	}
+	return nil
+}
+
+// Restore rebuilds the metadata of the backup cluster in a fresh PD cluster,
+// which must have no data of the backup cluster ID. The cluster is bootstrapped
+// after all other metadata is written, and the PD cluster should be restarted
+// to use the restored cluster ID. The encryption keys are restored as well, so
+// the restored cluster must use the same master key.
+func Restore(client *clientv3.Client, backInfo *BackupInfo) error {
+	if err := backInfo.Check(); err != nil {
+		return err
+	}
+	rootPath := path.Join(pdRootPath, strconv.FormatUint(backInfo.ClusterID, 10))
+	resp, err := etcdutil.EtcdKVGet(client, rootPath+"/", clientv3.WithPrefix(), clientv3.WithCountOnly())
+	if err != nil {
+		return err
+	}
+	if resp.Count > 0 {
+		return errors.Errorf("cluster %d already has data, only a fresh cluster can be restored", backInfo.ClusterID)
+	}
+
+	base := kv.NewEtcdKVBase(client, rootPath)
+	storage := core.NewStorage(base)
+	for _, store := range backInfo.Stores {
+		if err := storage.SaveStore(store.Meta); err != nil {
+			return err
+		}
+		if err := storage.SaveStoreWeight(store.Meta.GetId(), store.LeaderWeight, store.RegionWeight); err != nil {
+			return err
+		}
+	}
+	for _, region := range backInfo.Regions {
+		// Save the region as it is, it may be encrypted already.
+		value, err := region.Marshal()
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if err := base.Save(regionPath(region.GetId()), string(value)); err != nil {
+			return err
+		}
+	}
+	for key, rule := range backInfo.Rules {
+		if err := storage.SaveRule(key, json.RawMessage(rule)); err != nil {
+			return err
+		}
+	}
+	for groupID, group := range backInfo.RuleGroups {
+		if err := storage.SaveRuleGroup(groupID, json.RawMessage(group)); err != nil {
+			return err
+		}
+	}
+	for name, cfg := range backInfo.ScheduleConfigs {
+		if err := storage.SaveScheduleConfig(name, []byte(cfg)); err != nil {
+			return err
+		}
+	}
+	if err := storage.SaveGCSafePoint(backInfo.GCSafePoint); err != nil {
+		return err
+	}
+	for _, ssp := range backInfo.ServiceGCSafePoints {
+		if err := storage.SaveServiceGCSafePoint(ssp); err != nil {
+			return err
+		}
+	}
+	for mode, status := range backInfo.ReplicationStatus {
+		if err := storage.SaveReplicationStatus(mode, json.RawMessage(status)); err != nil {
+			return err
+		}
+	}
+	if cfg := backInfo.Config; cfg != nil {
+		// Only the config persisted by PD is restored, like PersistOptions.Persist.
+		if err := storage.SaveConfig(&config.Config{
+			Schedule:        cfg.Schedule,
+			Replication:     cfg.Replication,
+			PDServerCfg:     cfg.PDServerCfg,
+			ReplicationMode: cfg.ReplicationMode,
+			LabelProperty:   cfg.LabelProperty,
+			ClusterVersion:  cfg.ClusterVersion,
+		}); err != nil {
+			return err
+		}
+	}
+
+	if err := bootstrap(client, rootPath, backInfo); err != nil {
+		return err
+	}
+	return verify(client, rootPath, backInfo)
+}
+
+// bootstrap writes the cluster ID, the allocated ID, the timestamp and the
+// cluster meta in one transaction if the cluster is not bootstrapped yet.
+func bootstrap(client *clientv3.Client, rootPath string, backInfo *BackupInfo) error {
+	clusterMeta, err := backInfo.ClusterMeta.Marshal()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	clusterRootPath := path.Join(rootPath, clusterPath)
+	bootstrapTime := typeutil.Uint64ToBytes(uint64(time.Now().UnixNano()))
+	ops := []clientv3.Op{
+		clientv3.OpPut(pdClusterIDPath, string(typeutil.Uint64ToBytes(backInfo.ClusterID))),
+		clientv3.OpPut(path.Join(rootPath, allocIDPath), string(typeutil.Uint64ToBytes(backInfo.AllocIDMax))),
+		clientv3.OpPut(path.Join(rootPath, timestampPath), string(typeutil.Uint64ToBytes(backInfo.AllocTimestampMax))),
+		clientv3.OpPut(clusterRootPath, string(clusterMeta)),
+		clientv3.OpPut(path.Join(clusterRootPath, "status", "raft_bootstrap_time"), string(bootstrapTime)),
+	}
+	if len(backInfo.EncryptionKeys) > 0 {
+		ops = append(ops, clientv3.OpPut(encryptionkm.EncryptionKeysPath, string(backInfo.EncryptionKeys)))
+	}
+	ctx, cancel := context.WithTimeout(client.Ctx(), restoreTimeout)
+	defer cancel()
+	resp, err := client.Txn(ctx).
+		If(clientv3.Compare(clientv3.CreateRevision(clusterRootPath), "=", 0)).
+		Then(ops...).
+		Commit()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if !resp.Succeeded {
+		return errors.Errorf("cluster %d is already bootstrapped", backInfo.ClusterID)
+	}
+	return nil
+}
+
+// verify reads the restored metadata back and compares it with the backup.
+func verify(client *clientv3.Client, rootPath string, backInfo *BackupInfo) error {
+	resp, err := etcdutil.EtcdKVGet(client, pdClusterIDPath)
+	if err != nil {
+		return err
+	}
+	restored := &BackupInfo{
+		Version:   backInfo.Version,
+		ClusterID: backInfo.ClusterID,
+		Revision:  backInfo.Revision,
+		Config:    backInfo.Config,
+	}
+	snapshot := newSnapshotKV(client, rootPath, resp.Header.GetRevision())
+	if err := restored.load(snapshot); err != nil {
+		return err
+	}
+	if restored.EncryptionKeys, err = loadEncryptionKeys(client, snapshot.revision); err != nil {
+		return err
+	}
+	if len(backInfo.Regions) > 0 {
+		if restored.Regions, err = loadRegions(snapshot); err != nil {
+			return err
+		}
+	}
+	if restored.Checksum, err = restored.checksum(); err != nil {
+		return err
+	}
+	if restored.Checksum != backInfo.Checksum {
+		return errors.New("the restored metadata mismatches the backup")
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pdbackup
+
+import (
+	"fmt"
+	"math"
+	"path"
+	"strings"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/tikv/pd/pkg/etcdutil"
+	"github.com/tikv/pd/server/kv"
+	"go.etcd.io/etcd/clientv3"
+)
+
+// The paths relative to the root path of the cluster.
+const (
+	allocIDPath         = "alloc_id"
+	timestampPath       = "timestamp"
+	clusterPath         = "raft"
+	replicationPath     = "replication_mode"
+	regionRangeLimit    = 10000
+	minRegionRangeLimit = 100
+)
+
+func regionPath(regionID uint64) string {
+	return path.Join(clusterPath, "r", fmt.Sprintf("%020d", regionID))
+}
+
+// snapshotKV reads the metadata of a cluster in etcd at a fixed revision, so
+// that all parts of a backup come from the same point in time.
+type snapshotKV struct {
+	client   *clientv3.Client
+	rootPath string
+	revision int64
+}
+
+func newSnapshotKV(client *clientv3.Client, rootPath string, revision int64) *snapshotKV {
+	return &snapshotKV{
+		client:   client,
+		rootPath: rootPath,
+		revision: revision,
+	}
+}
+
+func (kv *snapshotKV) Load(key string) (string, error) {
+	resp, err := etcdutil.EtcdKVGet(kv.client, path.Join(kv.rootPath, key), clientv3.WithRev(kv.revision))
+	if err != nil {
+		return "", err
+	}
+	if len(resp.Kvs) == 0 {
+		return "", nil
+	}
+	return string(resp.Kvs[0].Value), nil
+}
+
+func (kv *snapshotKV) LoadRange(key, endKey string, limit int) ([]string, []string, error) {
+	// Use `strings.Join` to keep the suffix '/' of the key like etcdKVBase.
+	key = strings.Join([]string{kv.rootPath, key}, "/")
+	endKey = strings.Join([]string{kv.rootPath, endKey}, "/")
+	resp, err := etcdutil.EtcdKVGet(kv.client, key,
+		clientv3.WithRange(endKey), clientv3.WithLimit(int64(limit)), clientv3.WithRev(kv.revision))
+	if err != nil {
+		return nil, nil, err
+	}
+	keys := make([]string, 0, len(resp.Kvs))
+	values := make([]string, 0, len(resp.Kvs))
+	for _, item := range resp.Kvs {
+		keys = append(keys, strings.TrimPrefix(strings.TrimPrefix(string(item.Key), kv.rootPath), "/"))
+		values = append(values, string(item.Value))
+	}
+	return keys, values, nil
+}
+
+func (kv *snapshotKV) Save(key, value string) error {
+	return errors.New("the snapshot is read-only")
+}
+
+func (kv *snapshotKV) Remove(key string) error {
+	return errors.New("the snapshot is read-only")
+}
+
+func (kv *snapshotKV) Txn(conds []kv.Condition, ops []kv.Op) (bool, error) {
+	return false, errors.New("the snapshot is read-only")
+}
+
+// loadRegions loads the region meta as it is saved, the encrypted regions
+// are not decrypted.
+func loadRegions(base kv.Base) ([]*metapb.Region, error) {
+	var regions []*metapb.Region
+	nextID := uint64(0)
+	endKey := regionPath(math.MaxUint64)
+	// Use a variable limit to keep the response under the gRPC message size
+	// limit like loading the regions in PD.
+	rangeLimit := regionRangeLimit
+	for {
+		_, res, err := base.LoadRange(regionPath(nextID), endKey, rangeLimit)
+		if err != nil {
+			if rangeLimit /= 2; rangeLimit >= minRegionRangeLimit {
+				continue
+			}
+			return nil, err
+		}
+		for _, s := range res {
+			region := &metapb.Region{}
+			if err := region.Unmarshal([]byte(s)); err != nil {
+				return nil, errors.WithStack(err)
+			}
+			nextID = region.GetId() + 1
+			regions = append(regions, region)
+		}
+		if len(res) < rangeLimit {
+			return regions, nil
+		}
+	}
+}
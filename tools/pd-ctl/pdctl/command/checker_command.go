@@ -0,0 +1,73 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/spf13/cobra"
+)
+
+var checkersPrefix = "pd/api/v1/checkers"
+
+// NewCheckerCommand returns a checker command.
+func NewCheckerCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "checker",
+		Short: "checker commands",
+	}
+	c.AddCommand(NewDiagnoseCheckerCommand())
+	return c
+}
+
+// NewDiagnoseCheckerCommand returns a command to run the checkers once.
+func NewDiagnoseCheckerCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "diagnose [--limit <limit>] [--set <config-item>=<value>]...",
+		Short: "run the checkers once and show the operators without dispatching them",
+		Run:   diagnoseCheckerCommandFunc,
+	}
+	c.Flags().Int("limit", 0, "the max number of regions to check, 0 means the default of PD")
+	c.Flags().StringSlice("set", nil, "schedule config items used instead of the current ones, such as replica-schedule-limit=8")
+	return c
+}
+
+func diagnoseCheckerCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 0 {
+		cmd.Println(cmd.UsageString())
+		return
+	}
+	limit, err := cmd.Flags().GetInt("limit")
+	if err != nil {
+		cmd.Println(err)
+		return
+	}
+	query, err := parseDiagnoseOverrides(cmd)
+	if err != nil {
+		cmd.Println(err)
+		return
+	}
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+	url := fmt.Sprintf("%s/diagnose?%s", checkersPrefix, query.Encode())
+	r, err := doRequest(cmd, url, http.MethodGet)
+	if err != nil {
+		cmd.Println(err)
+		return
+	}
+	cmd.Println(r)
+}
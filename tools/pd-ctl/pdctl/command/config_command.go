@@ -42,6 +42,8 @@ var (
 	ruleGroupsPrefix      = "pd/api/v1/config/rule_groups"
 	replicationModePrefix = "pd/api/v1/config/replication-mode"
 	ruleBundlePrefix      = "pd/api/v1/config/placement-rule"
+	ruleTemplatePrefix    = "pd/api/v1/config/rule_template"
+	ruleTemplatesPrefix   = "pd/api/v1/config/rule_templates"
 )
 
 // NewConfigCommand return a config subcommand of rootCmd
@@ -54,6 +56,7 @@ func NewConfigCommand() *cobra.Command {
 	conf.AddCommand(NewSetConfigCommand())
 	conf.AddCommand(NewDeleteConfigCommand())
 	conf.AddCommand(NewPlacementRulesCommand())
+	conf.AddCommand(NewRegionLabelCommand())
 	return conf
 }
 
@@ -488,7 +491,28 @@ func NewPlacementRulesCommand() *cobra.Command {
 	ruleBundleSave.Flags().String("in", "rules.json", "the file contains all group configs and all rules")
 	ruleBundleSave.Flags().Bool("partial", false, "do not drop all old configurations, partial update")
 	ruleBundle.AddCommand(ruleBundleGet, ruleBundleSet, ruleBundleDelete, ruleBundleLoad, ruleBundleSave)
-	c.AddCommand(enable, disable, show, load, save, ruleGroup, ruleBundle)
+	ruleTemplate := &cobra.Command{
+		Use:   "rule-template",
+		Short: "rule template configurations",
+	}
+	ruleTemplateShow := &cobra.Command{
+		Use:   "show [name]",
+		Short: "show rule template(s)",
+		Run:   showRuleTemplateFunc,
+	}
+	ruleTemplateSet := &cobra.Command{
+		Use:   "set",
+		Short: "update rule template from file, the rules referencing it are updated at once",
+		Run:   setRuleTemplateFunc,
+	}
+	ruleTemplateSet.Flags().String("in", "template.json", "the file contains one rule template")
+	ruleTemplateDelete := &cobra.Command{
+		Use:   "delete <name>",
+		Short: "delete rule template which is not referenced by any rule or rule group",
+		Run:   deleteRuleTemplateFunc,
+	}
+	ruleTemplate.AddCommand(ruleTemplateShow, ruleTemplateSet, ruleTemplateDelete)
+	c.AddCommand(enable, disable, show, load, save, ruleGroup, ruleBundle, ruleTemplate)
 	return c
 }
 
@@ -576,7 +600,7 @@ func putPlacementRulesFunc(cmd *cobra.Command, args []string) {
 
 	validOpts := opts[:0]
 	for _, op := range opts {
-		if op.Count > 0 {
+		if op.Count > 0 || op.Template != "" {
 			op.Action = placement.RuleOpAdd
 			validOpts = append(validOpts, op)
 		} else if op.Count == 0 {
@@ -653,6 +677,58 @@ func deleteRuleGroupFunc(cmd *cobra.Command, args []string) {
 	cmd.Println("Success!")
 }
 
+func showRuleTemplateFunc(cmd *cobra.Command, args []string) {
+	if len(args) > 1 {
+		cmd.Println(cmd.UsageString())
+		return
+	}
+
+	reqPath := ruleTemplatesPrefix
+	if len(args) > 0 {
+		reqPath = path.Join(ruleTemplatePrefix, url.PathEscape(args[0]))
+	}
+
+	res, err := doRequest(cmd, reqPath, http.MethodGet)
+	if err != nil {
+		cmd.Println(err)
+		return
+	}
+	cmd.Println(res)
+}
+
+func setRuleTemplateFunc(cmd *cobra.Command, args []string) {
+	var file string
+	if f := cmd.Flag("in"); f != nil {
+		file = f.Value.String()
+	}
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		cmd.Println(err)
+		return
+	}
+
+	res, err := doRequest(cmd, ruleTemplatePrefix, http.MethodPost, WithBody("application/json", bytes.NewReader(content)))
+	if err != nil {
+		cmd.Printf("failed to save rule template %s: %s\n", content, err)
+		return
+	}
+
+	cmd.Println(res)
+}
+
+func deleteRuleTemplateFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		cmd.Println(cmd.UsageString())
+		return
+	}
+	_, err := doRequest(cmd, path.Join(ruleTemplatePrefix, url.PathEscape(args[0])), http.MethodDelete)
+	if err != nil {
+		cmd.Printf("Failed to remove rule template: %s \n", err)
+		return
+	}
+	cmd.Println("Success!")
+}
+
 func getRuleBundle(cmd *cobra.Command, args []string) {
 	if len(args) != 1 {
 		cmd.Println(cmd.UsageString())
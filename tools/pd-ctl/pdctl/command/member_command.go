@@ -25,17 +25,19 @@ import (
 var (
 	membersPrefix      = "pd/api/v1/members"
 	leaderMemberPrefix = "pd/api/v1/leader"
+	dcLocationPrefix   = "pd/api/v1/admin/tso/dc-location"
 )
 
 // NewMemberCommand return a member subcommand of rootCmd
 func NewMemberCommand() *cobra.Command {
 	m := &cobra.Command{
-		Use:   "member [leader|delete|leader_priority]",
+		Use:   "member [leader|delete|leader_priority|dc_location]",
 		Short: "show the pd member status",
 		Run:   showMemberCommandFunc,
 	}
 	m.AddCommand(NewLeaderMemberCommand())
 	m.AddCommand(NewDeleteMemberCommand())
+	m.AddCommand(NewDCLocationMemberCommand())
 
 	m.AddCommand(&cobra.Command{
 		Use:   "leader_priority <member_name> <priority>",
@@ -64,6 +66,25 @@ func NewDeleteMemberCommand() *cobra.Command {
 	return d
 }
 
+// NewDCLocationMemberCommand return a dc_location subcommand of memberCmd
+func NewDCLocationMemberCommand() *cobra.Command {
+	d := &cobra.Command{
+		Use:   "dc_location <subcommand>",
+		Short: "dc-location commands",
+	}
+	d.AddCommand(&cobra.Command{
+		Use:   "set <member_name> <dc_location>",
+		Short: "move a member to another dc-location",
+		Run:   setMemberDCLocationCommandFunc,
+	})
+	d.AddCommand(&cobra.Command{
+		Use:   "remove <dc_location>",
+		Short: "remove a dc-location without any member and reclaim its local tso suffix",
+		Run:   removeDCLocationCommandFunc,
+	})
+	return d
+}
+
 // NewLeaderMemberCommand return a leader subcommand of memberCmd
 func NewLeaderMemberCommand() *cobra.Command {
 	d := &cobra.Command{
@@ -178,3 +199,33 @@ func setLeaderPriorityFunc(cmd *cobra.Command, args []string) {
 	}
 	cmd.Println("Success!")
 }
+
+func setMemberDCLocationCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 2 {
+		cmd.Println("Usage: member dc_location set <member_name> <dc_location>")
+		return
+	}
+	prefix := dcLocationPrefix + "/members/" + args[0]
+	data := map[string]interface{}{"dc-location": args[1]}
+	reqData, _ := json.Marshal(data)
+	_, err := doRequest(cmd, prefix, http.MethodPost, WithBody("application/json", bytes.NewBuffer(reqData)))
+	if err != nil {
+		cmd.Printf("Failed to set dc-location: %s\n", err)
+		return
+	}
+	cmd.Println("Success!")
+}
+
+func removeDCLocationCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		cmd.Println("Usage: member dc_location remove <dc_location>")
+		return
+	}
+	prefix := dcLocationPrefix + "/" + args[0]
+	_, err := doRequest(cmd, prefix, http.MethodDelete)
+	if err != nil {
+		cmd.Printf("Failed to remove dc-location %s: %s\n", args[0], err)
+		return
+	}
+	cmd.Println("Success!")
+}
@@ -16,6 +16,7 @@ package command
 import (
 	"fmt"
 	"net/http"
+	"net/url"
 	"strconv"
 
 	"github.com/pingcap/errors"
@@ -42,6 +43,7 @@ func NewOperatorCommand() *cobra.Command {
 	c.AddCommand(NewCheckOperatorCommand())
 	c.AddCommand(NewAddOperatorCommand())
 	c.AddCommand(NewRemoveOperatorCommand())
+	c.AddCommand(NewOperatorHistoryCommand())
 	return c
 }
 
@@ -103,6 +105,46 @@ func checkOperatorCommandFunc(cmd *cobra.Command, args []string) {
 	cmd.Println(r)
 }
 
+// NewOperatorHistoryCommand returns a command to show the records of operators.
+func NewOperatorHistoryCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "history [--region=<region_id>] [--store=<store_id>] [--since=<unix_timestamp>]",
+		Short: "show the records of operators, including the finished ones",
+		Run:   operatorHistoryCommandFunc,
+	}
+	c.Flags().Uint64("region", 0, "only show the records of the region")
+	c.Flags().Uint64("store", 0, "only show the records involving the store")
+	c.Flags().Int64("since", 0, "only show the records since the unix timestamp")
+	return c
+}
+
+func operatorHistoryCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 0 {
+		cmd.Println(cmd.UsageString())
+		return
+	}
+	query := url.Values{}
+	if regionID, _ := cmd.Flags().GetUint64("region"); regionID != 0 {
+		query.Set("region_id", strconv.FormatUint(regionID, 10))
+	}
+	if storeID, _ := cmd.Flags().GetUint64("store"); storeID != 0 {
+		query.Set("store_id", strconv.FormatUint(storeID, 10))
+	}
+	if since, _ := cmd.Flags().GetInt64("since"); since != 0 {
+		query.Set("since", strconv.FormatInt(since, 10))
+	}
+	path := operatorsPrefix + "/records"
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+	r, err := doRequest(cmd, path, http.MethodGet)
+	if err != nil {
+		cmd.Println(err)
+		return
+	}
+	cmd.Println(r)
+}
+
 // NewAddOperatorCommand returns a command to add operators.
 func NewAddOperatorCommand() *cobra.Command {
 	c := &cobra.Command{
@@ -336,7 +378,7 @@ func removePeerCommandFunc(cmd *cobra.Command, args []string) {
 // NewSplitRegionCommand returns a command to split a region.
 func NewSplitRegionCommand() *cobra.Command {
 	c := &cobra.Command{
-		Use:   "split-region <region_id> [--policy=scan|approximate]",
+		Use:   "split-region <region_id> [--policy=scan|approximate|load]",
 		Short: "split a region",
 		Run:   splitRegionCommandFunc,
 	}
@@ -358,7 +400,7 @@ func splitRegionCommandFunc(cmd *cobra.Command, args []string) {
 
 	policy := cmd.Flags().Lookup("policy").Value.String()
 	switch policy {
-	case "scan", "approximate":
+	case "scan", "approximate", "load":
 		break
 	default:
 		cmd.Println("Error: unknown policy")
@@ -0,0 +1,179 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/tikv/pd/server/schedule/labeler"
+)
+
+var (
+	regionLabelRulesPrefix  = "pd/api/v1/config/region-label/rules"
+	regionLabelRulePrefix   = "pd/api/v1/config/region-label/rule"
+	regionLabelRegionPrefix = "pd/api/v1/config/region-label/region"
+)
+
+// NewRegionLabelCommand returns a region-label subcommand of configCmd.
+func NewRegionLabelCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "region-label",
+		Short: "region label rules configuration",
+	}
+	show := &cobra.Command{
+		Use:   "show [<rule_id>]",
+		Short: "show region label rules",
+		Run:   showRegionLabelRulesFunc,
+	}
+	show.Flags().String("region", "", "show the labels of the region")
+	set := &cobra.Command{
+		Use:   "set <rule_id> <start_key> <end_key> <label_key>=<label_value> [<label_key>=<label_value>...]",
+		Short: "set a region label rule, the keys are in hex format",
+		Run:   setRegionLabelRuleFunc,
+	}
+	del := &cobra.Command{
+		Use:   "delete <rule_id>",
+		Short: "delete a region label rule",
+		Run:   deleteRegionLabelRuleFunc,
+	}
+	load := &cobra.Command{
+		Use:   "load",
+		Short: "load region label rules to a file",
+		Run:   loadRegionLabelRulesFunc,
+	}
+	load.Flags().String("out", "region_labels.json", "the filename contains rules")
+	save := &cobra.Command{
+		Use:   "save",
+		Short: "save region label rules from file",
+		Run:   saveRegionLabelRulesFunc,
+	}
+	save.Flags().String("in", "region_labels.json", "the filename contains rules")
+	c.AddCommand(show, set, del, load, save)
+	return c
+}
+
+func showRegionLabelRulesFunc(cmd *cobra.Command, args []string) {
+	if len(args) > 1 {
+		cmd.Println(cmd.UsageString())
+		return
+	}
+	region, _ := cmd.Flags().GetString("region")
+	var reqPath string
+	switch {
+	case region != "" && len(args) > 0:
+		cmd.Println(`"region" should not be specified with rule id at the same time`)
+		return
+	case region != "":
+		reqPath = path.Join(regionLabelRegionPrefix, region)
+	case len(args) > 0:
+		reqPath = path.Join(regionLabelRulePrefix, url.PathEscape(args[0]))
+	default:
+		reqPath = regionLabelRulesPrefix
+	}
+	res, err := doRequest(cmd, reqPath, http.MethodGet)
+	if err != nil {
+		cmd.Println(err)
+		return
+	}
+	cmd.Println(res)
+}
+
+func setRegionLabelRuleFunc(cmd *cobra.Command, args []string) {
+	if len(args) < 4 {
+		cmd.Println(cmd.UsageString())
+		return
+	}
+	rule := &labeler.LabelRule{
+		ID:          args[0],
+		StartKeyHex: args[1],
+		EndKeyHex:   args[2],
+	}
+	for _, kv := range args[3:] {
+		pair := strings.SplitN(kv, "=", 2)
+		if len(pair) != 2 {
+			cmd.Printf("invalid label %s, should be in <key>=<value> format\n", kv)
+			return
+		}
+		rule.Labels = append(rule.Labels, labeler.RegionLabel{Key: pair[0], Value: pair[1]})
+	}
+	if err := postRegionLabelRule(cmd, rule); err != nil {
+		cmd.Printf("Failed to set region label rule: %s\n", err)
+		return
+	}
+	cmd.Println("Success!")
+}
+
+func deleteRegionLabelRuleFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		cmd.Println(cmd.UsageString())
+		return
+	}
+	_, err := doRequest(cmd, path.Join(regionLabelRulePrefix, url.PathEscape(args[0])), http.MethodDelete)
+	if err != nil {
+		cmd.Printf("Failed to delete region label rule: %s\n", err)
+		return
+	}
+	cmd.Println("Success!")
+}
+
+func loadRegionLabelRulesFunc(cmd *cobra.Command, args []string) {
+	res, err := doRequest(cmd, regionLabelRulesPrefix, http.MethodGet)
+	if err != nil {
+		cmd.Println(err)
+		return
+	}
+	file, _ := cmd.Flags().GetString("out")
+	if err = ioutil.WriteFile(file, []byte(res), 0644); err != nil {
+		cmd.Println(err)
+		return
+	}
+	cmd.Println("rules saved to file " + file)
+}
+
+func saveRegionLabelRulesFunc(cmd *cobra.Command, args []string) {
+	file, _ := cmd.Flags().GetString("in")
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		cmd.Println(err)
+		return
+	}
+	var rules []*labeler.LabelRule
+	if err = json.Unmarshal(content, &rules); err != nil {
+		cmd.Println(err)
+		return
+	}
+	for _, rule := range rules {
+		if err = postRegionLabelRule(cmd, rule); err != nil {
+			cmd.Printf("failed to save rule %s: %s\n", rule.ID, err)
+			return
+		}
+	}
+	cmd.Println("Success!")
+}
+
+func postRegionLabelRule(cmd *cobra.Command, rule *labeler.LabelRule) error {
+	b, err := json.Marshal(rule)
+	if err != nil {
+		return err
+	}
+	_, err = doRequest(cmd, regionLabelRulePrefix, http.MethodPost, WithBody("application/json", bytes.NewBuffer(b)))
+	return err
+}
@@ -46,6 +46,8 @@ func NewSchedulerCommand() *cobra.Command {
 	c.AddCommand(NewPauseSchedulerCommand())
 	c.AddCommand(NewResumeSchedulerCommand())
 	c.AddCommand(NewConfigSchedulerCommand())
+	c.AddCommand(NewDiagnoseSchedulerCommand())
+	c.AddCommand(NewDiagnosticSchedulerCommand())
 	return c
 }
 
@@ -117,6 +119,87 @@ func showSchedulerCommandFunc(cmd *cobra.Command, args []string) {
 	cmd.Println(r)
 }
 
+// NewDiagnoseSchedulerCommand returns a command to diagnose a scheduler.
+func NewDiagnoseSchedulerCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "diagnose <scheduler> [--dry-run [--set <config-item>=<value>]...]",
+		Short: "diagnose a scheduler",
+		Run:   diagnoseSchedulerCommandFunc,
+	}
+	c.Flags().Bool("dry-run", false, "run the scheduler once and show the operators without dispatching them")
+	c.Flags().StringSlice("set", nil, "schedule config items used in the dry run instead of the current ones, such as region-schedule-limit=8")
+	return c
+}
+
+func diagnoseSchedulerCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		cmd.Println(cmd.UsageString())
+		return
+	}
+	dryRun, err := cmd.Flags().GetBool("dry-run")
+	if err != nil {
+		cmd.Println(err)
+		return
+	}
+	query, err := parseDiagnoseOverrides(cmd)
+	if err != nil {
+		cmd.Println(err)
+		return
+	}
+	query.Set("dry-run", strconv.FormatBool(dryRun))
+	url := fmt.Sprintf("%s/%s/diagnose?%s", schedulersPrefix, args[0], query.Encode())
+	r, err := doRequest(cmd, url, http.MethodGet)
+	if err != nil {
+		cmd.Println(err)
+		return
+	}
+	cmd.Println(r)
+}
+
+// parseDiagnoseOverrides returns the schedule config items of the set flag
+// as query parameters.
+func parseDiagnoseOverrides(cmd *cobra.Command) (url.Values, error) {
+	items, err := cmd.Flags().GetStringSlice("set")
+	if err != nil {
+		return nil, err
+	}
+	query := url.Values{}
+	for _, item := range items {
+		kv := strings.SplitN(item, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return nil, errors.Errorf("config item %q should be in the form of <config-item>=<value>", item)
+		}
+		query.Set(kv[0], kv[1])
+	}
+	return query, nil
+}
+
+// NewDiagnosticSchedulerCommand returns a command to show the diagnostic information of a scheduler.
+func NewDiagnosticSchedulerCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "diagnostic <scheduler>",
+		Short: "show the diagnostic information of the recent rounds of a scheduler",
+		Run:   showSchedulerDiagnosticCommandFunc,
+	}
+	return c
+}
+
+func showSchedulerDiagnosticCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		cmd.Println(cmd.UsageString())
+		return
+	}
+	r, err := doRequest(cmd, path.Join(schedulerConfigPrefix, args[0], "diagnostic"), http.MethodGet)
+	if err != nil {
+		if strings.Contains(err.Error(), "404") {
+			err = errors.New("[404] scheduler not found")
+		}
+		cmd.Println(err)
+		return
+	}
+	cmd.Println(r)
+}
+
 // NewAddSchedulerCommand returns a command to add scheduler.
 func NewAddSchedulerCommand() *cobra.Command {
 	c := &cobra.Command{
@@ -134,6 +217,9 @@ func NewAddSchedulerCommand() *cobra.Command {
 	c.AddCommand(NewBalanceHotRegionSchedulerCommand())
 	c.AddCommand(NewRandomMergeSchedulerCommand())
 	c.AddCommand(NewLabelSchedulerCommand())
+	c.AddCommand(NewEvictSlowStoreSchedulerCommand())
+	c.AddCommand(NewSplitHotRegionSchedulerCommand())
+	c.AddCommand(NewLeaderPreferenceSchedulerCommand())
 	return c
 }
 
@@ -306,6 +392,47 @@ func NewLabelSchedulerCommand() *cobra.Command {
 	return c
 }
 
+// NewEvictSlowStoreSchedulerCommand returns a command to add a evict-slow-store-scheduler.
+func NewEvictSlowStoreSchedulerCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "evict-slow-store-scheduler",
+		Short: "add a scheduler to detect and evict slow stores",
+		Run:   addSchedulerCommandFunc,
+	}
+	return c
+}
+
+// NewSplitHotRegionSchedulerCommand returns a command to add a split-hot-region-scheduler.
+func NewSplitHotRegionSchedulerCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "split-hot-region-scheduler",
+		Short: "add a scheduler to split the regions which stay hot for a while",
+		Run:   addSchedulerCommandFunc,
+	}
+	return c
+}
+
+// NewLeaderPreferenceSchedulerCommand returns a command to add a leader-preference-scheduler.
+func NewLeaderPreferenceSchedulerCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "leader-preference-scheduler <preferences>",
+		Short: "add a scheduler to place leaders according to the label weights, e.g. zone=z1:3,zone=z2:1",
+		Run:   addSchedulerForLeaderPreferenceCommandFunc,
+	}
+	return c
+}
+
+func addSchedulerForLeaderPreferenceCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		cmd.Println(cmd.UsageString())
+		return
+	}
+	input := make(map[string]interface{})
+	input["name"] = cmd.Name()
+	input["preferences"] = args[0]
+	postJSON(cmd, schedulersPrefix, input)
+}
+
 func addSchedulerCommandFunc(cmd *cobra.Command, args []string) {
 	if len(args) != 0 {
 		cmd.Println(cmd.UsageString())
@@ -402,6 +529,8 @@ func NewConfigSchedulerCommand() *cobra.Command {
 		newConfigGrantLeaderCommand(),
 		newConfigHotRegionCommand(),
 		newConfigShuffleRegionCommand(),
+		newConfigSplitHotRegionCommand(),
+		newConfigLeaderPreferenceCommand(),
 	)
 	return c
 }
@@ -423,6 +552,40 @@ func newConfigHotRegionCommand() *cobra.Command {
 	return c
 }
 
+func newConfigSplitHotRegionCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "split-hot-region-scheduler",
+		Short: "split-hot-region-scheduler config",
+		Run:   listSchedulerConfigCommandFunc,
+	}
+	c.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "list the config item",
+		Run:   listSchedulerConfigCommandFunc})
+	c.AddCommand(&cobra.Command{
+		Use:   "set <key> <value>",
+		Short: "set the config item",
+		Run:   func(cmd *cobra.Command, args []string) { postSchedulerConfigCommandFunc(cmd, c.Name(), args) }})
+	return c
+}
+
+func newConfigLeaderPreferenceCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "leader-preference-scheduler",
+		Short: "leader-preference-scheduler config",
+		Run:   listSchedulerConfigCommandFunc,
+	}
+	c.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "list the config item",
+		Run:   listSchedulerConfigCommandFunc})
+	c.AddCommand(&cobra.Command{
+		Use:   "set preferences <preferences>",
+		Short: "set the preferences, e.g. zone=z1:3,zone=z2:1",
+		Run:   func(cmd *cobra.Command, args []string) { postSchedulerConfigCommandFunc(cmd, c.Name(), args) }})
+	return c
+}
+
 func newConfigEvictLeaderCommand() *cobra.Command {
 	c := &cobra.Command{
 		Use:   "evict-leader-scheduler",
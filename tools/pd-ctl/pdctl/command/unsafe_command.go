@@ -0,0 +1,104 @@
+// Copyright 2020 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var unsafePrefix = "pd/api/v1/admin/unsafe"
+
+// NewUnsafeCommand returns the unsafe subcommand of rootCmd.
+func NewUnsafeCommand() *cobra.Command {
+	unsafeCmd := &cobra.Command{
+		Use:   "unsafe [command]",
+		Short: "Unsafe operations",
+	}
+	unsafeCmd.AddCommand(NewRemoveFailedStoresCommand())
+	return unsafeCmd
+}
+
+// NewRemoveFailedStoresCommand returns the unsafe remove-failed-stores command.
+func NewRemoveFailedStoresCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "remove-failed-stores <store_id1>[,<store_id2>,...]",
+		Short: "Remove failed stores unsafely and recover the regions that lost the majority of replicas",
+		Run:   removeFailedStoresCommandFunc,
+	}
+	cmd.Flags().Uint64("timeout", 600, "the timeout of the recovery in seconds")
+	cmd.AddCommand(NewRemoveFailedStoresShowCommand(), NewRemoveFailedStoresAbortCommand())
+	return cmd
+}
+
+// NewRemoveFailedStoresShowCommand returns the unsafe remove-failed-stores show command.
+func NewRemoveFailedStoresShowCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "show",
+		Short: "Show the progress or the report of the unsafe recovery",
+		Run:   removeFailedStoresShowCommandFunc,
+	}
+}
+
+// NewRemoveFailedStoresAbortCommand returns the unsafe remove-failed-stores abort command.
+func NewRemoveFailedStoresAbortCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "abort",
+		Short: "Abort the unsafe recovery in progress",
+		Run:   removeFailedStoresAbortCommandFunc,
+	}
+}
+
+func removeFailedStoresCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		cmd.Usage()
+		return
+	}
+	var stores []uint64
+	for _, s := range strings.Split(args[0], ",") {
+		id, err := strconv.ParseUint(strings.TrimSpace(s), 10, 64)
+		if err != nil {
+			cmd.Printf("Failed to parse store id %s: %s\n", s, err)
+			return
+		}
+		stores = append(stores, id)
+	}
+	timeout, err := cmd.Flags().GetUint64("timeout")
+	if err != nil {
+		cmd.Println(err)
+		return
+	}
+	postJSON(cmd, unsafePrefix+"/remove-failed-stores", map[string]interface{}{"stores": stores, "timeout": timeout})
+}
+
+func removeFailedStoresAbortCommandFunc(cmd *cobra.Command, args []string) {
+	r, err := doRequest(cmd, unsafePrefix+"/remove-failed-stores/abort", http.MethodPost)
+	if err != nil {
+		cmd.Printf("Failed to abort unsafe recovery: %s\n", err)
+		return
+	}
+	cmd.Println(r)
+}
+
+func removeFailedStoresShowCommandFunc(cmd *cobra.Command, args []string) {
+	r, err := doRequest(cmd, unsafePrefix+"/remove-failed-stores/show", http.MethodGet)
+	if err != nil {
+		cmd.Printf("Failed to get the progress of unsafe recovery: %s\n", err)
+		return
+	}
+	cmd.Println(r)
+}
@@ -83,6 +83,7 @@ func getBasicCmd() *cobra.Command {
 		command.NewPingCommand(),
 		command.NewOperatorCommand(),
 		command.NewSchedulerCommand(),
+		command.NewCheckerCommand(),
 		command.NewTSOCommand(),
 		command.NewHotSpotCommand(),
 		command.NewClusterCommand(),
@@ -90,6 +91,7 @@ func getBasicCmd() *cobra.Command {
 		command.NewLogCommand(),
 		command.NewPluginCommand(),
 		command.NewServiceGCSafepointCommand(),
+		command.NewUnsafeCommand(),
 		command.NewCompletionCommand(),
 	)
 
@@ -45,6 +45,8 @@ var (
 	caPath       = flag.String("cacert", "", "path of file that contains list of trusted SSL CAs")
 	certPath     = flag.String("cert", "", "path of file that contains X509 certificate in PEM format")
 	keyPath      = flag.String("key", "", "path of file that contains X509 key in PEM format")
+	streams      = flag.Int("streams", 1, "the number of tso streams of each pd client")
+	compare      = flag.Bool("compare", false, "run the benchmark with a single tso stream too and compare the throughput")
 	wg           sync.WaitGroup
 )
 
@@ -74,12 +76,21 @@ func main() {
 	}()
 
 	for i := 0; i < *count; i++ {
+		if *compare {
+			fmt.Printf("\nStart benchmark #%d with 1 tso stream, duration: %+vs\n", i, (*duration).Seconds())
+			base := bench(ctx, 1)
+			fmt.Printf("\nStart benchmark #%d with %d tso streams, duration: %+vs\n", i, *streams, (*duration).Seconds())
+			result := bench(ctx, *streams)
+			fmt.Printf("\nThroughput: %.0f/s with 1 tso stream, %.0f/s with %d tso streams, gain: %.2f%%\n",
+				base.throughput(), result.throughput(), *streams, (result.throughput()/base.throughput()-1)*100)
+			continue
+		}
 		fmt.Printf("\nStart benchmark #%d, duration: %+vs\n", i, (*duration).Seconds())
-		bench(ctx)
+		bench(ctx, *streams)
 	}
 }
 
-func bench(mainCtx context.Context) {
+func bench(mainCtx context.Context, streamCount int) *stats {
 	promServer = httptest.NewServer(promhttp.Handler())
 
 	// Initialize all clients
@@ -90,7 +101,7 @@ func bench(mainCtx context.Context) {
 			CAPath:   *caPath,
 			CertPath: *certPath,
 			KeyPath:  *keyPath,
-		})
+		}, pd.WithTSOStreamCount(streamCount))
 		if err != nil {
 			log.Fatal(fmt.Sprintf("create pd client #%d failed: %v", idx, err))
 		}
@@ -115,12 +126,14 @@ func bench(mainCtx context.Context) {
 		}
 	}
 
+	total := newStats()
 	wg.Add(1)
-	go showStats(ctx, durCh)
+	go showStats(ctx, durCh, total)
 
 	timer := time.NewTimer(*duration)
 	defer timer.Stop()
 
+	start := time.Now()
 	select {
 	case <-ctx.Done():
 	case <-timer.C:
@@ -128,13 +141,15 @@ func bench(mainCtx context.Context) {
 	cancel()
 
 	wg.Wait()
+	total.elapsed = time.Since(start)
 
 	for _, pdCli := range pdClients {
 		pdCli.Close()
 	}
+	return total
 }
 
-func showStats(ctx context.Context, durCh chan time.Duration) {
+func showStats(ctx context.Context, durCh chan time.Duration, total *stats) {
 	defer wg.Done()
 
 	statCtx, cancel := context.WithCancel(ctx)
@@ -143,7 +158,6 @@ func showStats(ctx context.Context, durCh chan time.Duration) {
 	ticker := time.NewTicker(*interval)
 
 	s := newStats()
-	total := newStats()
 
 	for {
 		select {
@@ -157,6 +171,7 @@ func showStats(ctx context.Context, durCh chan time.Duration) {
 		case d := <-durCh:
 			s.update(d)
 		case <-statCtx.Done():
+			total.merge(s)
 			fmt.Println("\nTotal:")
 			fmt.Println(total.Counter())
 			fmt.Println(total.Percentage())
@@ -197,6 +212,8 @@ type stats struct {
 	fourHundredCnt  int
 	eightHundredCnt int
 	oneThousandCnt  int
+	// elapsed is the duration of the benchmark.
+	elapsed time.Duration
 }
 
 func newStats() *stats {
@@ -311,6 +328,14 @@ func (s *stats) Percentage() string {
 		s.calculate(s.oneHundredCnt), s.calculate(s.twoHundredCnt), s.calculate(s.fourHundredCnt), s.calculate(s.eightHundredCnt), s.calculate(s.oneThousandCnt))
 }
 
+// throughput returns the number of the requests per second.
+func (s *stats) throughput() float64 {
+	if s.elapsed <= 0 {
+		return 0
+	}
+	return float64(s.count) / s.elapsed.Seconds()
+}
+
 func (s *stats) calculate(count int) float64 {
 	return float64(count) * 100 / float64(s.count)
 }